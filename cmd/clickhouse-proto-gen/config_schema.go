@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// configSchemaOutput is the file config-schema writes to, stdout when empty
+//
+//nolint:gochecknoglobals // cobra flag variable
+var configSchemaOutput string
+
+//nolint:gochecknoglobals // Standard cobra pattern for CLI subcommands
+var configSchemaCmd = &cobra.Command{
+	Use:   "config-schema",
+	Short: "Print the JSON Schema of the config file",
+	Long: `config-schema prints the JSON Schema of the config file. Editors with a YAML
+language server use it to complete keys, describe settings and flag mistakes while
+editing, for example with this first line in the config file:
+
+  # yaml-language-server: $schema=./config.schema.json
+
+Example usage:
+  clickhouse-proto-gen config-schema -o config.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: runConfigSchema,
+}
+
+func init() {
+	configSchemaCmd.Flags().StringVarP(&configSchemaOutput, "output", "o", "", "Write the schema to this file instead of stdout")
+	rootCmd.AddCommand(configSchemaCmd)
+}
+
+func runConfigSchema(cmd *cobra.Command, _ []string) error {
+	schema, err := config.JSONSchema()
+	if err != nil {
+		return fmt.Errorf("failed to build config schema: %w", err)
+	}
+
+	if configSchemaOutput == "" {
+		_, err := cmd.OutOrStdout().Write(schema)
+		return err
+	}
+	if err := os.WriteFile(configSchemaOutput, schema, 0o600); err != nil {
+		return fmt.Errorf("failed to write config schema: %w", err)
+	}
+	return nil
+}
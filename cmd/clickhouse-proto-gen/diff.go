@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/protogen"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// diff flags
+//
+//nolint:gochecknoglobals // cobra flag variables
+var (
+	diffSnapshot   string
+	diffMigrations string
+)
+
+//nolint:gochecknoglobals // Standard cobra pattern for CLI subcommands
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the selected tables with a schema snapshot",
+	Long: `diff loads the selected tables the same way generation does and compares them with
+the CREATE TABLE statements of a snapshot, such as the output of export-ddl. It lists
+added, removed, retyped and moved columns, and tables missing from the snapshot.
+
+With --migrations, it also writes stubs for each changed table to that directory:
+<table>.sql with ALTER TABLE statements bringing a table matching the snapshot to the
+current schema, and <table>.proto.txt with the fields to add to the table's message and
+the reserved numbers and names of removed columns.
+
+Example usage:
+  clickhouse-proto-gen diff --dsn "clickhouse://localhost:9000/mydb" --tables users,orders --snapshot schema.sql --migrations migrations/`,
+	Args: cobra.NoArgs,
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffSnapshot, "snapshot", "", "File with the CREATE TABLE statements to compare with (e.g., export-ddl output)")
+	diffCmd.Flags().StringVar(&diffMigrations, "migrations", "", "Write ALTER TABLE and proto field stubs for changed tables to this directory")
+	_ = diffCmd.MarkFlagRequired("snapshot")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, _ []string) error {
+	log, err := setupLogger()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd, log)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.ReadFile(diffSnapshot)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	snapshot, err := clickhouse.ParseDDL(string(src))
+	if err != nil {
+		return fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+
+	loaded, err := loadTables(context.Background(), cfg, log)
+	if err != nil {
+		return err
+	}
+	if err := loaded.failedError(cfg); err != nil {
+		return err
+	}
+	if len(loaded.tables) == 0 {
+		return errNoValidTables
+	}
+
+	diffs := clickhouse.DiffTables(snapshot, loaded.tables)
+	if err := writeDiff(cmd.OutOrStdout(), diffs); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+
+	if diffMigrations != "" && len(diffs) > 0 {
+		if err := writeMigrations(cfg, log, diffs); err != nil {
+			return err
+		}
+	}
+
+	return loaded.partialError(cfg)
+}
+
+// writeDiff prints the changes of every table, or that there are none
+func writeDiff(w io.Writer, diffs []clickhouse.TableDiff) error {
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintln(w, "No changes")
+		return err
+	}
+
+	var sb strings.Builder
+	for _, diff := range diffs {
+		if diff.New {
+			fmt.Fprintf(&sb, "%s: not in snapshot\n", diff.Table.Name)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s:\n", diff.Table.Name)
+		for _, column := range diff.Added {
+			fmt.Fprintf(&sb, "  + %s %s\n", column.Name, column.Type)
+		}
+		for _, column := range diff.Removed {
+			fmt.Fprintf(&sb, "  - %s %s\n", column.Name, column.Type)
+		}
+		for _, change := range diff.Changed {
+			fmt.Fprintf(&sb, "  ~ %s %s -> %s\n", change.Name, change.OldType, change.NewType)
+		}
+		for _, move := range diff.Moved {
+			fmt.Fprintf(&sb, "  > %s position %d -> %d\n", move.Name, move.OldPosition, move.NewPosition)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeMigrations writes the ALTER TABLE and proto stubs of every changed table
+func writeMigrations(cfg *config.Config, log logrus.FieldLogger, diffs []clickhouse.TableDiff) error {
+	if err := os.MkdirAll(diffMigrations, 0o750); err != nil {
+		return fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	generator := protogen.NewGenerator(cfg, log)
+	if err := generator.LoadFieldNumberLock(); err != nil {
+		return err
+	}
+	for i := range diffs {
+		diff := &diffs[i]
+		name := strings.ToLower(diff.Table.Name)
+
+		if err := os.WriteFile(filepath.Join(diffMigrations, name+".sql"), []byte(clickhouse.FormatMigration(diff)), 0o600); err != nil {
+			return fmt.Errorf("failed to write migration: %w", err)
+		}
+
+		if changes := generator.MigrationProtoChanges(diff); changes != "" {
+			if err := os.WriteFile(filepath.Join(diffMigrations, name+".proto.txt"), []byte(changes), 0o600); err != nil {
+				return fmt.Errorf("failed to write proto changes: %w", err)
+			}
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"tables":    len(diffs),
+		"directory": diffMigrations,
+	}).Info("Wrote migration stubs")
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// exportDDLOutput is the file export-ddl writes to, stdout when empty
+//
+//nolint:gochecknoglobals // cobra flag variable
+var exportDDLOutput string
+
+//nolint:gochecknoglobals // Standard cobra pattern for CLI subcommands
+var exportDDLCmd = &cobra.Command{
+	Use:   "export-ddl",
+	Short: "Print normalized CREATE TABLE statements for the selected tables",
+	Long: `export-ddl loads the selected tables the same way generation does and prints
+a normalized CREATE TABLE statement for each of them. The output can be committed
+and used later with --from-ddl to generate without a database connection.
+
+Example usage:
+  clickhouse-proto-gen export-ddl --dsn "clickhouse://localhost:9000/mydb" --tables users,orders -o schema.sql`,
+	Args: cobra.NoArgs,
+	RunE: runExportDDL,
+}
+
+func init() {
+	exportDDLCmd.Flags().StringVarP(&exportDDLOutput, "output", "o", "", "Write the statements to this file instead of stdout")
+	rootCmd.AddCommand(exportDDLCmd)
+}
+
+func runExportDDL(cmd *cobra.Command, _ []string) error {
+	log, err := setupLogger()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd, log)
+	if err != nil {
+		return err
+	}
+
+	loaded, err := loadTables(context.Background(), cfg, log)
+	if err != nil {
+		return err
+	}
+
+	if loaded.requested == 0 {
+		log.Warn("No tables found to export")
+		return nil
+	}
+
+	if err := loaded.failedError(cfg); err != nil {
+		return err
+	}
+
+	if len(loaded.tables) == 0 {
+		return errNoValidTables
+	}
+
+	statements := make([]string, 0, len(loaded.tables))
+	for _, table := range loaded.tables {
+		statements = append(statements, clickhouse.FormatDDL(table))
+	}
+	ddl := strings.Join(statements, "\n")
+
+	if exportDDLOutput == "" {
+		if _, err := fmt.Fprint(cmd.OutOrStdout(), ddl); err != nil {
+			return fmt.Errorf("failed to write DDL: %w", err)
+		}
+	} else {
+		if err := os.WriteFile(exportDDLOutput, []byte(ddl), 0o600); err != nil {
+			return fmt.Errorf("failed to write DDL file: %w", err)
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"tables_exported": len(loaded.tables),
+		"tables_skipped":  len(loaded.skipped.Tables),
+	}).Info("DDL export completed")
+
+	return loaded.partialError(cfg)
+}
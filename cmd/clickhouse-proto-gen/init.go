@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	errConfigExists = errors.New("config file already exists (use --force to overwrite)")
+	errNoTablesInit = errors.New("no tables found to put in the config")
+)
+
+//nolint:gochecknoglobals // cobra flag variables
+var (
+	initOutput    string
+	initForce     bool
+	initDatabases []string
+)
+
+//nolint:gochecknoglobals // Standard cobra pattern for CLI subcommands
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a starting config file for the tables of a database",
+	Long: `init lists the tables of the database (or DDL files) and writes a config file
+proposing them: tables grouped by database and name prefix, a package named after the
+database, and the shared table prefixes as API prefixes. Review and trim it, then generate
+with --config.
+
+Example usage:
+  clickhouse-proto-gen init --dsn "clickhouse://localhost:9000/mydb"
+  clickhouse-proto-gen init --dsn "clickhouse://localhost:9000/mydb" --databases mydb,metrics -o -`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+func init() {
+	initCmd.Flags().StringVarP(&initOutput, "output", "o", defaultInteractiveConfig, "Write the config to this file, - for stdout")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing config file")
+	initCmd.Flags().StringSliceVar(&initDatabases, "databases", nil, "Only propose tables of these databases (default: all but the system databases)")
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command, _ []string) error {
+	log, err := setupLogger()
+	if err != nil {
+		return err
+	}
+
+	if initOutput != "-" && !initForce {
+		if _, err := os.Stat(initOutput); err == nil {
+			return fmt.Errorf("%w: %s", errConfigExists, initOutput)
+		}
+	}
+
+	cfg, err := buildConfig(cmd, log)
+	if err != nil {
+		return err
+	}
+	if cfg.DSN == "" && len(cfg.FromDDL) == 0 {
+		return errNoSchemaSource
+	}
+
+	ctx := context.Background()
+	ch, err := connectSchemaSource(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := ch.Close(); err != nil {
+			log.WithError(err).Warn("Failed to close schema source")
+		}
+	}()
+
+	summaries, err := ch.SummarizeTables(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	opts := config.ScaffoldOptions{
+		DSN:     cfg.DSN,
+		FromDDL: cfg.FromDDL,
+		Cluster: cfg.Cluster,
+	}
+	if len(cfg.FromDDL) == 0 {
+		opts.Database = extractDatabaseFromDSN(cfg.DSN)
+	}
+	for _, summary := range summaries {
+		if len(initDatabases) > 0 && !slices.Contains(initDatabases, summary.Database) {
+			continue
+		}
+		opts.Tables = append(opts.Tables, config.ScaffoldTable{
+			Database: summary.Database,
+			Name:     summary.Name,
+			Engine:   summary.Engine,
+		})
+	}
+	if len(opts.Tables) == 0 {
+		return errNoTablesInit
+	}
+
+	data, err := config.Scaffold(opts)
+	if err != nil {
+		return err
+	}
+
+	if initOutput == "-" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err := os.WriteFile(initOutput, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{
+		"file":   initOutput,
+		"tables": len(opts.Tables),
+	}).Info("Wrote config file")
+	_, err = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s, review it and run: clickhouse-proto-gen --config %s\n", initOutput, initOutput)
+	return err
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/protogen"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/tui"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// defaultInteractiveConfig is the config file interactive writes when --config isn't set
+const defaultInteractiveConfig = "clickhouse-proto-gen.yaml"
+
+var errNoSchemaSource = errors.New("interactive needs --dsn or --from-ddl")
+
+// interactiveOutput is the config file the selection is written to
+//
+//nolint:gochecknoglobals // cobra flag variable
+var interactiveOutput string
+
+//nolint:gochecknoglobals // Standard cobra pattern for CLI subcommands
+var interactiveCmd = &cobra.Command{
+	Use:     "interactive",
+	Aliases: []string{"tui"},
+	Short:   "Pick tables in a terminal UI and write them to a config file",
+	Long: `interactive lists the tables of the database (or DDL files) with their engines and
+column counts. Search with /, select tables with space and preview the proto generated for
+the highlighted table. Enter writes the selection to the tables of the config file: the
+file given with --config keeps its other settings, otherwise a new file is written.
+
+Example usage:
+  clickhouse-proto-gen interactive --dsn "clickhouse://localhost:9000/mydb"`,
+	Args: cobra.NoArgs,
+	RunE: runInteractive,
+}
+
+func init() {
+	interactiveCmd.Flags().StringVarP(&interactiveOutput, "output", "o", "", "Write the config to this file (defaults to --config, or "+defaultInteractiveConfig+")")
+	rootCmd.AddCommand(interactiveCmd)
+}
+
+func runInteractive(cmd *cobra.Command, _ []string) error {
+	log, err := setupLogger()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := buildConfig(cmd, log)
+	if err != nil {
+		return err
+	}
+	if cfg.DSN == "" && len(cfg.FromDDL) == 0 {
+		return errNoSchemaSource
+	}
+
+	ctx := context.Background()
+	ch, err := connectSchemaSource(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := ch.Close(); err != nil {
+			log.WithError(err).Warn("Failed to close schema source")
+		}
+	}()
+
+	summaries, err := ch.SummarizeTables(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	database := ""
+	if len(cfg.FromDDL) == 0 {
+		database = extractDatabaseFromDSN(cfg.DSN)
+	}
+
+	// Log lines would tear through the screen, so they are held until the picker closes
+	restoreLog := holdLogOutput(log)
+	model := tui.NewModel(tui.Options{
+		Tables:   summaries,
+		Selected: cfg.Tables,
+		Database: database,
+		Preview:  previewTable(ctx, ch, protogen.NewGenerator(cfg, log)),
+	})
+	err = tui.Run(os.Stdin, os.Stdout, model)
+	restoreLog()
+	if err != nil {
+		return err
+	}
+
+	if _, save := model.Done(); !save {
+		log.Info("Interactive selection discarded")
+		return nil
+	}
+
+	return saveSelection(cmd, cfg, model.Selected())
+}
+
+// previewTable renders the proto file of a listed table
+func previewTable(ctx context.Context, ch clickhouse.Service, generator *protogen.Generator) tui.PreviewFunc {
+	return func(summary clickhouse.TableSummary) (string, error) {
+		table, err := ch.GetTable(ctx, summary.Database, summary.Name)
+		if err != nil {
+			return "", err
+		}
+		return generator.TableProto(table)
+	}
+}
+
+// holdLogOutput buffers log output until the returned function is called, which writes
+// the held lines and restores the previous output
+func holdLogOutput(log logrus.FieldLogger) func() {
+	logger, ok := log.(*logrus.Logger)
+	if !ok {
+		return func() {}
+	}
+
+	var held bytes.Buffer
+	previous := logger.Out
+	logger.SetOutput(&held)
+	return func() {
+		logger.SetOutput(previous)
+		_, _ = held.WriteTo(previous)
+	}
+}
+
+// saveSelection validates the configuration with the selected tables and writes them
+func saveSelection(cmd *cobra.Command, cfg *config.Config, selected []string) error {
+	cfg.Tables = selected
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// With several config files the selection goes to the last one, which overrides the others
+	var configFile string
+	if len(configFiles) > 0 {
+		configFile = configFiles[len(configFiles)-1]
+	}
+
+	path := interactiveOutput
+	switch {
+	case path != "":
+	case configFile != "":
+		path = configFile
+	default:
+		path = defaultInteractiveConfig
+	}
+
+	// A new output file starts from the loaded config file, so its settings carry over
+	if configFile != "" && path != configFile {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			data, err := os.ReadFile(configFile)
+			if err != nil {
+				return fmt.Errorf("failed to read config file: %w", err)
+			}
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				return fmt.Errorf("failed to write config file: %w", err)
+			}
+		}
+	}
+
+	if err := cfg.SaveTables(path); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(cmd.OutOrStdout(), "Wrote the selection (%d tables) to %s\n", len(selected), path)
+	return err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/selftest"
+	"github.com/spf13/cobra"
+)
+
+var errSelftestDSN = errors.New("selftest requires --dsn or a config file with dsn")
+
+// selftestWorkDir keeps the generated self-test files in this directory when set
+//
+//nolint:gochecknoglobals // cobra flag variable
+var selftestWorkDir string
+
+//nolint:gochecknoglobals // Standard cobra pattern for CLI subcommands
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Check generation end-to-end against a ClickHouse server",
+	Long: `selftest creates a scratch database with representative tables (nullable columns,
+arrays, maps, projections and a Distributed table), generates protos and SQL helpers
+for them, compiles the output with protoc and go, and executes every generated query.
+The scratch database is dropped afterwards.
+
+protoc, protoc-gen-go and go must be on PATH, and the DSN user needs permission to
+create and drop databases.
+
+Example usage:
+  clickhouse-proto-gen selftest --dsn "clickhouse://default@localhost:9000/default"`,
+	Args: cobra.NoArgs,
+	RunE: runSelftest,
+}
+
+func init() {
+	selftestCmd.Flags().StringVar(&selftestWorkDir, "work-dir", "", "Keep the generated files in this directory instead of a temporary one")
+	rootCmd.AddCommand(selftestCmd)
+}
+
+func runSelftest(cmd *cobra.Command, _ []string) error {
+	log, err := setupLogger()
+	if err != nil {
+		return err
+	}
+
+	// Only the DSN is used, so the config file isn't validated for generation
+	selftestDSN := dsn
+	if selftestDSN == "" && len(configFiles) > 0 {
+		cfg := config.NewConfig()
+		if err := cfg.LoadFromFiles(configFiles, profile, log); err != nil {
+			return fmt.Errorf("failed to load config file: %w", err)
+		}
+		selftestDSN = cfg.DSN
+	}
+	if selftestDSN == "" {
+		return errSelftestDSN
+	}
+
+	output, err := selftest.Run(context.Background(), selftest.Options{
+		DSN:     selftestDSN,
+		WorkDir: selftestWorkDir,
+	}, log)
+	if err != nil {
+		return fmt.Errorf("selftest failed: %w", err)
+	}
+
+	if _, err := fmt.Fprint(cmd.OutOrStdout(), output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package main provides the protoc-gen-clickhouse protoc/buf plugin entry point
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/plugin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+//nolint:gochecknoglobals // Version info set by ldflags during build
+var (
+	// Version info (set by ldflags)
+	Release = "dev"
+	Commit  = "none"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "--version" {
+		fmt.Printf("protoc-gen-clickhouse %s (commit: %s)\n", Release, Commit)
+		return
+	}
+
+	if err := run(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "protoc-gen-clickhouse: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run reads a CodeGeneratorRequest from in and writes the CodeGeneratorResponse to out.
+// Logs go to stderr since stdout carries the response.
+func run(in io.Reader, out io.Writer) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{}
+	if err := proto.Unmarshal(data, req); err != nil {
+		return fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	log := logrus.New()
+	log.SetOutput(os.Stderr)
+	log.SetLevel(logrus.WarnLevel)
+
+	resp := plugin.Run(context.Background(), req, log)
+
+	data, err = proto.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	if _, err := out.Write(data); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
+}
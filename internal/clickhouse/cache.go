@@ -0,0 +1,225 @@
+package clickhouse
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// cacheFormat is part of every cache key; bump it when the cached Table layout changes
+const cacheFormat = 3
+
+// cacheVersionPrefix starts the names of the per-version directories of a cache directory.
+// Only directories named like this are removed when pruning.
+const cacheVersionPrefix = "v-"
+
+// CacheOptions configure the on-disk cache of table schemas
+type CacheOptions struct {
+	// Dir holds one directory of entries per tool version
+	Dir string
+	// TTL is the age after which an entry is loaded again even if the table's metadata is
+	// unchanged. Zero keeps entries until the table changes.
+	TTL time.Duration
+	// Version identifies the build writing the entries; entries of other versions are
+	// ignored and removed
+	Version string
+}
+
+// WithCache reuses table schemas loaded by earlier runs. An entry is used while the table's
+// metadata_modification_time on the connected host is unchanged and it is younger than the TTL.
+// Entries of Distributed tables also depend on the modification time of their local table.
+func WithCache(opts CacheOptions) ServiceOption {
+	return func(s *service) {
+		s.cache = &opts
+	}
+}
+
+// cacheEntry is the file written for a cached table
+type cacheEntry struct {
+	Created time.Time `json:"created"`
+	Table   *Table    `json:"table"`
+}
+
+// versionDir returns the directory holding the entries of the current version
+func (o *CacheOptions) versionDir() string {
+	sum := sha256.Sum256([]byte(strconv.Itoa(cacheFormat) + "\x00" + o.Version))
+	return filepath.Join(o.Dir, cacheVersionPrefix+hex.EncodeToString(sum[:8]))
+}
+
+// cachedTable returns the table from the cache, loading and caching it on a miss. Cache
+// failures are logged and fall back to loading the table.
+func (s *service) cachedTable(ctx context.Context, database, tableName string) (*Table, error) {
+	log := s.log.WithFields(logrus.Fields{"database": database, "table": tableName})
+
+	modified, found, err := s.schemaModified(ctx, database, tableName)
+	if err != nil || !found {
+		if err != nil {
+			log.WithError(err).Debug("Failed to read table modification time, bypassing the cache")
+		}
+		return s.loadTable(ctx, database, tableName)
+	}
+
+	path := s.cachePath(database, tableName, modified...)
+	if table, ok := s.readCacheEntry(path, log); ok {
+		log.Debug("Loaded table schema from cache")
+		return table, nil
+	}
+
+	table, err := s.loadTable(ctx, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeCacheEntry(path, table); err != nil {
+		log.WithError(err).Warn("Failed to write schema cache entry")
+	}
+	return table, nil
+}
+
+// tableModified returns the latest metadata_modification_time of the table, across all
+// replicas with a cluster, and whether the table exists
+func (s *service) tableModified(ctx context.Context, database, tableName string) (uint32, bool, error) {
+	query := `
+		SELECT toUnixTimestamp(max(metadata_modification_time)), count()
+		FROM ` + s.systemTable("tables") + `
+		WHERE database = ? AND name = ?
+	`
+	var (
+		modified uint32
+		count    uint64
+	)
+	if err := s.conn.QueryRow(ctx, query, database, tableName).Scan(&modified, &count); err != nil {
+		return 0, false, err
+	}
+	return modified, count > 0, nil
+}
+
+// schemaModified returns the modification times the schema of a table is loaded from and
+// whether the table exists: its own and, for a Distributed table, that of the local table
+// its sorting key and projections are read from, which altering it doesn't touch
+func (s *service) schemaModified(ctx context.Context, database, tableName string) ([]uint32, bool, error) {
+	modified, found, err := s.tableModified(ctx, database, tableName)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	if !s.isDistributedTable(ctx, database, tableName) {
+		return []uint32{modified}, true, nil
+	}
+
+	local := s.getUnderlyingTableName(ctx, database, tableName)
+	if local == nil {
+		return nil, false, fmt.Errorf("failed to resolve the local table of %s.%s", database, tableName)
+	}
+	localModified, found, err := s.tableModified(ctx, local.Database, local.Table)
+	if err != nil {
+		return nil, false, err
+	}
+	if !found {
+		return nil, false, fmt.Errorf("local table %s.%s of %s.%s not found", local.Database, local.Table, database, tableName)
+	}
+	return []uint32{modified, localModified}, true, nil
+}
+
+// cachePath returns the entry file of a table as of the modification times of its schema
+func (s *service) cachePath(database, tableName string, modified ...uint32) string {
+	parts := []string{s.addr, s.cluster, database, tableName}
+	for _, m := range modified {
+		parts = append(parts, strconv.FormatUint(uint64(m), 10))
+	}
+	key := strings.Join(parts, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.cache.versionDir(), hex.EncodeToString(sum[:])+".json")
+}
+
+// readCacheEntry returns the table of an entry, removing entries that are expired or unreadable
+func (s *service) readCacheEntry(path string, log logrus.FieldLogger) (*Table, bool) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.WithError(err).Debug("Failed to read schema cache entry")
+		}
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Table == nil {
+		log.WithError(err).Debug("Discarding corrupt schema cache entry")
+		_ = os.Remove(path)
+		return nil, false
+	}
+	if s.cache.TTL > 0 && time.Since(entry.Created) > s.cache.TTL {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return entry.Table, true
+}
+
+// writeCacheEntry writes the entry through a temporary file, so concurrent runs never read
+// a partial entry
+func writeCacheEntry(path string, table *Table) error {
+	data, err := json.Marshal(cacheEntry{Created: time.Now(), Table: table})
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return fmt.Errorf("failed to create entry: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// pruneCache removes the entries of other tool versions and, with a TTL, the expired
+// entries of the current one
+func (s *service) pruneCache() {
+	dirs, err := os.ReadDir(s.cache.Dir)
+	if err != nil {
+		return
+	}
+
+	current := filepath.Base(s.cache.versionDir())
+	for _, dir := range dirs {
+		if !dir.IsDir() || !strings.HasPrefix(dir.Name(), cacheVersionPrefix) || dir.Name() == current {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.cache.Dir, dir.Name())); err != nil {
+			s.log.WithError(err).Debug("Failed to remove outdated schema cache")
+		}
+	}
+
+	if s.cache.TTL <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(s.cache.versionDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && time.Since(info.ModTime()) > s.cache.TTL {
+			_ = os.Remove(filepath.Join(s.cache.versionDir(), entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package clickhouse
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheService(t *testing.T, opts CacheOptions) *service {
+	t.Helper()
+	if opts.Dir == "" {
+		opts.Dir = t.TempDir()
+	}
+	s, ok := NewService("clickhouse://localhost:9000/db", logrus.New(), WithCache(opts)).(*service)
+	require.True(t, ok)
+	s.addr = "localhost:9000"
+	return s
+}
+
+func TestCacheEntry(t *testing.T) {
+	table := &Table{
+		Name:       "fct_block",
+		Database:   "mainnet",
+		Engine:     "ReplacingMergeTree(updated_date_time)",
+		Columns:    []Column{NewColumn("slot", "UInt32", 1), NewColumn("root", "Nullable(String)", 2)},
+		SortingKey: []string{"slot"},
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		s := newCacheService(t, CacheOptions{TTL: time.Hour, Version: "v1.0.0"})
+		path := s.cachePath("mainnet", "fct_block", 1700000000)
+		require.NoError(t, writeCacheEntry(path, table))
+
+		cached, ok := s.readCacheEntry(path, s.log)
+		require.True(t, ok)
+		assert.Equal(t, table, cached)
+	})
+
+	t.Run("Expired entries are removed", func(t *testing.T) {
+		s := newCacheService(t, CacheOptions{TTL: time.Hour})
+		path := s.cachePath("mainnet", "fct_block", 1700000000)
+		data, err := json.Marshal(cacheEntry{Created: time.Now().Add(-2 * time.Hour), Table: table})
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		_, ok := s.readCacheEntry(path, s.log)
+		assert.False(t, ok)
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("Without TTL entries don't expire", func(t *testing.T) {
+		s := newCacheService(t, CacheOptions{})
+		path := s.cachePath("mainnet", "fct_block", 1700000000)
+		data, err := json.Marshal(cacheEntry{Created: time.Now().Add(-24 * 365 * time.Hour), Table: table})
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		_, ok := s.readCacheEntry(path, s.log)
+		assert.True(t, ok)
+	})
+
+	t.Run("Corrupt entries are removed", func(t *testing.T) {
+		s := newCacheService(t, CacheOptions{})
+		path := s.cachePath("mainnet", "fct_block", 1700000000)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(`{"table":`), 0o600))
+
+		_, ok := s.readCacheEntry(path, s.log)
+		assert.False(t, ok)
+		assert.NoFileExists(t, path)
+	})
+}
+
+func TestCachePath(t *testing.T) {
+	dir := t.TempDir()
+	s := newCacheService(t, CacheOptions{Dir: dir, Version: "v1.0.0"})
+	base := s.cachePath("mainnet", "fct_block", 1700000000)
+
+	assert.Equal(t, base, s.cachePath("mainnet", "fct_block", 1700000000))
+	assert.NotEqual(t, base, s.cachePath("mainnet", "fct_block", 1700000001), "altering the table changes the key")
+	assert.NotEqual(t, base, s.cachePath("sepolia", "fct_block", 1700000000))
+
+	distributed := s.cachePath("mainnet", "fct_block", 1700000000, 1700000000)
+	assert.NotEqual(t, base, distributed)
+	assert.NotEqual(t, distributed, s.cachePath("mainnet", "fct_block", 1700000000, 1700000001), "altering the local table of a Distributed table changes the key")
+
+	other := newCacheService(t, CacheOptions{Dir: dir, Version: "v1.0.0"})
+	other.addr = "replica-2:9000"
+	assert.NotEqual(t, base, other.cachePath("mainnet", "fct_block", 1700000000), "hosts don't share entries")
+
+	upgraded := newCacheService(t, CacheOptions{Dir: dir, Version: "v1.1.0"})
+	assert.NotEqual(t, filepath.Dir(base), filepath.Dir(upgraded.cachePath("mainnet", "fct_block", 1700000000)), "versions don't share entries")
+}
+
+func TestPruneCache(t *testing.T) {
+	dir := t.TempDir()
+	old := newCacheService(t, CacheOptions{Dir: dir, Version: "v1.0.0"})
+	oldPath := old.cachePath("mainnet", "fct_block", 1700000000)
+	require.NoError(t, writeCacheEntry(oldPath, &Table{Name: "fct_block"}))
+
+	s := newCacheService(t, CacheOptions{Dir: dir, TTL: time.Hour, Version: "v1.1.0"})
+	fresh := s.cachePath("mainnet", "fct_block", 1700000000)
+	stale := s.cachePath("mainnet", "fct_slot", 1700000000)
+	require.NoError(t, writeCacheEntry(fresh, &Table{Name: "fct_block"}))
+	require.NoError(t, writeCacheEntry(stale, &Table{Name: "fct_slot"}))
+	require.NoError(t, os.Chtimes(stale, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour)))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "unrelated"), 0o750))
+
+	s.pruneCache()
+
+	assert.NoDirExists(t, filepath.Dir(oldPath), "entries of other versions are removed")
+	assert.FileExists(t, fresh)
+	assert.NoFileExists(t, stale, "expired entries are removed")
+	assert.DirExists(t, filepath.Join(dir, "unrelated"), "only version directories are pruned")
+}
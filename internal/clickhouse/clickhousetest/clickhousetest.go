@@ -0,0 +1,278 @@
+// Package clickhousetest provides an in-memory clickhouse.Service for exercising generation
+// without a running ClickHouse instance
+package clickhousetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"gopkg.in/yaml.v3"
+)
+
+// Fake service errors
+var (
+	ErrTableNotFound = errors.New("table not found in fixtures")
+	ErrNotConnected  = errors.New("fake service is not connected")
+)
+
+// Fixture is the file format of table definitions, in YAML or JSON
+type Fixture struct {
+	Tables []TableFixture `yaml:"tables" json:"tables"`
+}
+
+// TableFixture defines a table. Column positions follow the order of Columns.
+type TableFixture struct {
+	Database     string              `yaml:"database" json:"database"`
+	Name         string              `yaml:"name" json:"name"`
+	Comment      string              `yaml:"comment" json:"comment"`
+	Engine       string              `yaml:"engine" json:"engine"`
+	SortingKey   []string            `yaml:"sorting_key" json:"sorting_key"`
+	SamplingKey  string              `yaml:"sampling_key" json:"sampling_key"`
+	PartitionKey string              `yaml:"partition_key" json:"partition_key"`
+	Columns      []ColumnFixture     `yaml:"columns" json:"columns"`
+	Projections  []ProjectionFixture `yaml:"projections" json:"projections"`
+}
+
+// ColumnFixture defines a column with its ClickHouse type, e.g. "Nullable(String)"
+type ColumnFixture struct {
+	Name         string `yaml:"name" json:"name"`
+	Type         string `yaml:"type" json:"type"`
+	Comment      string `yaml:"comment" json:"comment"`
+	DefaultKind  string `yaml:"default_kind" json:"default_kind"`
+	DefaultValue string `yaml:"default_value" json:"default_value"`
+}
+
+// ProjectionFixture defines a projection. Type is "Normal" or "Aggregate".
+type ProjectionFixture struct {
+	Name    string   `yaml:"name" json:"name"`
+	OrderBy []string `yaml:"order_by" json:"order_by"`
+	Type    string   `yaml:"type" json:"type"`
+}
+
+// Service is a fake clickhouse.Service serving tables from memory. Failures can be injected
+// with FailConnect and FailTable. It is safe for concurrent use.
+type Service struct {
+	mu         sync.Mutex
+	tables     []*clickhouse.Table
+	connected  bool
+	connectErr error
+	tableErrs  map[string]error
+}
+
+var _ clickhouse.Service = (*Service)(nil)
+
+// New creates a fake service serving the given tables
+func New(tables ...*clickhouse.Table) *Service {
+	s := &Service{tableErrs: make(map[string]error)}
+	for _, table := range tables {
+		s.Add(table)
+	}
+	return s
+}
+
+// LoadFixtures creates a fake service from fixture files. Files ending in .json are read as
+// JSON, everything else as YAML.
+func LoadFixtures(paths ...string) (*Service, error) {
+	s := New()
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+
+		var fixture Fixture
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			err = json.Unmarshal(data, &fixture)
+		} else {
+			err = yaml.Unmarshal(data, &fixture)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+
+		for i := range fixture.Tables {
+			s.Add(fixture.Tables[i].Table())
+		}
+	}
+	return s, nil
+}
+
+// Table converts the fixture into a clickhouse.Table with derived column type info
+func (f *TableFixture) Table() *clickhouse.Table {
+	table := &clickhouse.Table{
+		Name:         f.Name,
+		Database:     f.Database,
+		Comment:      f.Comment,
+		Engine:       f.Engine,
+		Columns:      make([]clickhouse.Column, 0, len(f.Columns)),
+		SortingKey:   append([]string{}, f.SortingKey...),
+		SamplingKey:  f.SamplingKey,
+		PartitionKey: f.PartitionKey,
+		Projections:  make([]clickhouse.Projection, 0, len(f.Projections)),
+	}
+	if table.Database == "" {
+		table.Database = "default"
+	}
+
+	for i, col := range f.Columns {
+		column := clickhouse.NewColumn(col.Name, col.Type, uint64(i+1))
+		column.Comment = col.Comment
+		column.DefaultKind = col.DefaultKind
+		column.DefaultValue = col.DefaultValue
+		table.Columns = append(table.Columns, column)
+	}
+
+	for _, proj := range f.Projections {
+		projType := proj.Type
+		if projType == "" {
+			projType = "Normal"
+		}
+		table.Projections = append(table.Projections, clickhouse.Projection{
+			Name:       proj.Name,
+			OrderByKey: append([]string{}, proj.OrderBy...),
+			Type:       projType,
+		})
+	}
+
+	return table
+}
+
+// Add registers a table, replacing one with the same database and name
+func (s *Service) Add(table *clickhouse.Table) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.tables {
+		if existing.Database == table.Database && existing.Name == table.Name {
+			s.tables[i] = cloneTable(table)
+			return
+		}
+	}
+	s.tables = append(s.tables, cloneTable(table))
+}
+
+// FailConnect makes Connect return err
+func (s *Service) FailConnect(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connectErr = err
+}
+
+// FailTable makes GetTable return err for database.table
+func (s *Service) FailTable(database, table string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tableErrs[database+"."+table] = err
+}
+
+// Connect marks the service connected, unless a failure was injected
+func (s *Service) Connect(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.connectErr != nil {
+		return s.connectErr
+	}
+	s.connected = true
+	return nil
+}
+
+// Close disconnects the service
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connected = false
+	return nil
+}
+
+// ListTables returns database-qualified names of all tables in the order they were added
+func (s *Service) ListTables(_ context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, ErrNotConnected
+	}
+
+	names := make([]string, 0, len(s.tables))
+	for _, table := range s.tables {
+		names = append(names, table.Database+"."+table.Name)
+	}
+	return names, nil
+}
+
+// SummarizeTables returns the summaries of all tables in the order they were added
+func (s *Service) SummarizeTables(_ context.Context) ([]clickhouse.TableSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, ErrNotConnected
+	}
+
+	summaries := make([]clickhouse.TableSummary, 0, len(s.tables))
+	for _, table := range s.tables {
+		summaries = append(summaries, table.Summarize())
+	}
+	return summaries, nil
+}
+
+// GetTable returns a copy of a table, so callers can't modify the fixtures
+func (s *Service) GetTable(_ context.Context, database, tableName string) (*clickhouse.Table, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, ErrNotConnected
+	}
+	if err := s.tableErrs[database+"."+tableName]; err != nil {
+		return nil, err
+	}
+
+	for _, table := range s.tables {
+		if table.Database == database && table.Name == tableName {
+			return cloneTable(table), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s.%s", ErrTableNotFound, database, tableName)
+}
+
+// GetTables returns the tables that could be loaded, skipping the others like the real service
+func (s *Service) GetTables(ctx context.Context, database string, tableNames []string) ([]*clickhouse.Table, error) {
+	tables := make([]*clickhouse.Table, 0, len(tableNames))
+	for _, name := range tableNames {
+		db, tableName := database, name
+		if parts := strings.SplitN(name, ".", 2); len(parts) == 2 {
+			db, tableName = parts[0], parts[1]
+		}
+
+		table, err := s.GetTable(ctx, db, tableName)
+		if errors.Is(err, ErrNotConnected) {
+			return nil, err
+		}
+		if err != nil {
+			continue
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func cloneTable(table *clickhouse.Table) *clickhouse.Table {
+	clone := *table
+	clone.Columns = append([]clickhouse.Column{}, table.Columns...)
+	clone.SortingKey = append([]string{}, table.SortingKey...)
+	clone.Projections = make([]clickhouse.Projection, 0, len(table.Projections))
+	for _, proj := range table.Projections {
+		proj.OrderByKey = append([]string{}, proj.OrderByKey...)
+		clone.Projections = append(clone.Projections, proj)
+	}
+	return &clone
+}
@@ -0,0 +1,111 @@
+package clickhousetest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/protogen"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errTestConnect = errors.New("connection refused")
+	errTestTable   = errors.New("table is being altered")
+)
+
+func TestLoadFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	svc, err := LoadFixtures("testdata/tables.yaml", "testdata/tables.json")
+	require.NoError(t, err)
+	require.NoError(t, svc.Connect(ctx))
+
+	names, err := svc.ListTables(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"analytics.fct_block", "analytics.dim_node", "default.users"}, names)
+
+	summaries, err := svc.SummarizeTables(ctx)
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+	assert.Equal(t, clickhouse.TableSummary{Database: "analytics", Name: "fct_block", Engine: "ReplacingMergeTree", Columns: 6}, summaries[0])
+
+	block, err := svc.GetTable(ctx, "analytics", "fct_block")
+	require.NoError(t, err)
+	assert.Equal(t, "ReplacingMergeTree(updated_date_time)", block.Engine)
+	assert.Equal(t, []string{"slot", "block_root"}, block.SortingKey)
+	assert.Equal(t, []clickhouse.Projection{{Name: "p_by_proposer", OrderByKey: []string{"proposer_index"}, Type: "Normal"}}, block.Projections)
+	assert.Equal(t, clickhouse.Column{Name: "proposer_index", Type: "Nullable(UInt32)", Position: 4, IsNullable: true, BaseType: "UInt32"}, block.Columns[3])
+	assert.Equal(t, clickhouse.Column{Name: "tags", Type: "Array(LowCardinality(String))", Position: 5, IsArray: true, BaseType: "String"}, block.Columns[4])
+
+	// Returned tables are copies
+	block.Columns[0].Name = "changed"
+	again, err := svc.GetTable(ctx, "analytics", "fct_block")
+	require.NoError(t, err)
+	assert.Equal(t, "updated_date_time", again.Columns[0].Name)
+
+	users, err := svc.GetTables(ctx, "default", []string{"users", "missing", "analytics.dim_node"})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "Login email", users[0].Columns[1].Comment)
+	assert.Equal(t, "dim_node", users[1].Name)
+}
+
+func TestService_InjectedFailures(t *testing.T) {
+	ctx := context.Background()
+	svc := New(&clickhouse.Table{Database: "default", Name: "users"})
+
+	_, err := svc.ListTables(ctx)
+	require.ErrorIs(t, err, ErrNotConnected)
+
+	svc.FailConnect(errTestConnect)
+	require.ErrorIs(t, svc.Connect(ctx), errTestConnect)
+
+	svc.FailConnect(nil)
+	require.NoError(t, svc.Connect(ctx))
+
+	svc.FailTable("default", "users", errTestTable)
+	_, err = svc.GetTable(ctx, "default", "users")
+	require.ErrorIs(t, err, errTestTable)
+
+	_, err = svc.GetTable(ctx, "default", "orders")
+	require.ErrorIs(t, err, ErrTableNotFound)
+}
+
+// TestGenerateFromFixtures runs the generator end-to-end against fixture tables
+func TestGenerateFromFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	svc, err := LoadFixtures("testdata/tables.yaml")
+	require.NoError(t, err)
+	require.NoError(t, svc.Connect(ctx))
+	defer svc.Close()
+
+	tables, err := svc.GetTables(ctx, "analytics", []string{"fct_block", "dim_node"})
+	require.NoError(t, err)
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Package = "test.v1"
+	cfg.GoPackage = "github.com/acme/gen/testv1"
+
+	log := logrus.New()
+	log.SetLevel(logrus.WarnLevel)
+	require.NoError(t, protogen.NewGenerator(cfg, log).Generate(tables))
+
+	proto, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_block.proto"))
+	require.NoError(t, err)
+	assert.Contains(t, string(proto), "message FctBlock {")
+	assert.Contains(t, string(proto), "google.protobuf.UInt32Value proposer_index = 14;")
+	assert.Contains(t, string(proto), "service FctBlockService {")
+
+	assert.FileExists(t, filepath.Join(cfg.OutputDir, "fct_block_sql.go"))
+	assert.FileExists(t, filepath.Join(cfg.OutputDir, "dim_node.proto"))
+	assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "dim_node_sql.go"), "tables without a sorting key get no SQL helpers")
+}
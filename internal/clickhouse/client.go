@@ -4,6 +4,7 @@ package clickhouse
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -17,46 +18,157 @@ type Service interface {
 	Connect(ctx context.Context) error
 	Close() error
 	ListTables(ctx context.Context) ([]string, error)
+	SummarizeTables(ctx context.Context) ([]TableSummary, error)
 	GetTable(ctx context.Context, database, tableName string) (*Table, error)
 	GetTables(ctx context.Context, database string, tableNames []string) ([]*Table, error)
 }
 
+// ErrNoHostAvailable is returned by Connect when none of the hosts of the DSNs answers
+var ErrNoHostAvailable = errors.New("no ClickHouse host available")
+
 type service struct {
-	dsn  string
-	conn driver.Conn
-	log  logrus.FieldLogger
+	dsn       string
+	fallbacks []string
+	cluster   string
+	readOnly  *ReadOnlyOptions
+	trace     bool
+	cache     *CacheOptions
+	addr      string
+	conn      driver.Conn
+	log       logrus.FieldLogger
+}
+
+// ServiceOption configures a service created by NewService
+type ServiceOption func(*service)
+
+// WithCluster reads schemas from the system tables of every replica in cluster through
+// clusterAllReplicas. Use it when the DSN points at a load balancer in front of a sharded
+// cluster, where the node answering may not have the local tables.
+func WithCluster(cluster string) ServiceOption {
+	return func(s *service) {
+		s.cluster = cluster
+	}
+}
+
+// WithFallbackDSNs adds DSNs tried in order when no host of the main DSN can be reached,
+// e.g. other replicas or a second cluster holding the same schemas
+func WithFallbackDSNs(dsns ...string) ServiceOption {
+	return func(s *service) {
+		s.fallbacks = append(s.fallbacks, dsns...)
+	}
 }
 
 // NewService creates a new ClickHouse service
-func NewService(dsn string, log logrus.FieldLogger) Service {
-	return &service{
+func NewService(dsn string, log logrus.FieldLogger, opts ...ServiceOption) Service {
+	s := &service{
 		dsn: dsn,
 		log: log.WithField("component", "clickhouse"),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// systemTable returns the system table to read, across all replicas when a cluster is set
+func (s *service) systemTable(name string) string {
+	if s.cluster == "" {
+		return "system." + name
+	}
+	return fmt.Sprintf("clusterAllReplicas(%s, system.%s)", quoteString(s.cluster), name)
 }
 
+// Connect connects to the first host that answers. The hosts of each DSN (as in
+// clickhouse://host1:9000,host2:9000/db) are tried one at a time in order, then those of
+// the fallback DSNs, logging the hosts that failed and the one serving the metadata.
 func (s *service) Connect(ctx context.Context) error {
-	options, err := clickhouse.ParseDSN(s.dsn)
+	candidates, err := hostOptions(append([]string{s.dsn}, s.fallbacks...))
 	if err != nil {
-		return fmt.Errorf("failed to parse DSN: %w", err)
+		return err
+	}
+	if len(candidates) == 0 {
+		return ErrNoHostAvailable
 	}
 
+	errs := make([]error, 0, len(candidates))
+	for i, options := range candidates {
+		conn, err := openHost(ctx, options)
+		if err != nil {
+			if len(candidates) > 1 {
+				s.log.WithError(err).WithField("address", options.Addr[0]).Warn("ClickHouse host unavailable")
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", options.Addr[0], err))
+			continue
+		}
+
+		// Traced before the session restrictions, so the queries checking the user show up too
+		if s.trace {
+			conn = &tracingConn{Conn: conn, log: s.log.WithField("address", options.Addr[0])}
+		}
+
+		// A host that answers but refuses the session restrictions fails the run: another
+		// host would see the same user
+		if s.readOnly != nil {
+			restricted, err := s.restrict(ctx, conn)
+			if err != nil {
+				_ = conn.Close()
+				return err
+			}
+			conn = restricted
+		}
+
+		s.conn = conn
+		s.addr = options.Addr[0]
+		if s.cache != nil {
+			s.pruneCache()
+		}
+		s.log.WithFields(logrus.Fields{
+			"database": options.Auth.Database,
+			"address":  options.Addr[0],
+			"failover": i > 0,
+		}).Info("Connected to ClickHouse")
+		return nil
+	}
+
+	if len(errs) == 1 {
+		return errors.Unwrap(errs[0])
+	}
+	return fmt.Errorf("%w:\n%w", ErrNoHostAvailable, errors.Join(errs...))
+}
+
+// hostOptions parses the DSNs into connection options of a single host each, in the order
+// the hosts are tried
+func hostOptions(dsns []string) ([]*clickhouse.Options, error) {
+	var candidates []*clickhouse.Options
+	for i, dsn := range dsns {
+		options, err := clickhouse.ParseDSN(dsn)
+		if err != nil {
+			if i > 0 {
+				return nil, fmt.Errorf("failed to parse fallback DSN %d: %w", i, err)
+			}
+			return nil, fmt.Errorf("failed to parse DSN: %w", err)
+		}
+		for _, addr := range options.Addr {
+			host := *options
+			host.Addr = []string{addr}
+			candidates = append(candidates, &host)
+		}
+	}
+	return candidates, nil
+}
+
+// openHost opens and pings a connection to a single host
+func openHost(ctx context.Context, options *clickhouse.Options) (driver.Conn, error) {
 	conn, err := clickhouse.Open(options)
 	if err != nil {
-		return fmt.Errorf("failed to open connection: %w", err)
+		return nil, fmt.Errorf("failed to open connection: %w", err)
 	}
 
 	if err := conn.Ping(ctx); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
-
-	s.conn = conn
-	s.log.WithFields(logrus.Fields{
-		"database": options.Auth.Database,
-		"address":  options.Addr,
-	}).Info("Connected to ClickHouse")
-
-	return nil
+	return conn, nil
 }
 
 func (s *service) Close() error {
@@ -68,10 +180,10 @@ func (s *service) Close() error {
 
 func (s *service) ListTables(ctx context.Context) ([]string, error) {
 	query := `
-		SELECT database || '.' || name AS full_name
-		FROM system.tables
+		SELECT DISTINCT database || '.' || name AS full_name
+		FROM ` + s.systemTable("tables") + `
 		WHERE database NOT IN ('system', 'information_schema', 'INFORMATION_SCHEMA')
-		ORDER BY database, name
+		ORDER BY full_name
 	`
 
 	rows, err := s.conn.Query(ctx, query)
@@ -96,7 +208,53 @@ func (s *service) ListTables(ctx context.Context) ([]string, error) {
 	return tables, rows.Err()
 }
 
+// SummarizeTables lists every table with its engine and column count, without loading the
+// column definitions
+func (s *service) SummarizeTables(ctx context.Context) ([]TableSummary, error) {
+	query := `
+		SELECT t.database, t.name, any(t.engine), uniqExactIf(c.name, c.name != '')
+		FROM ` + s.systemTable("tables") + ` AS t
+		LEFT JOIN ` + s.systemTable("columns") + ` AS c ON c.database = t.database AND c.table = t.name
+		WHERE t.database NOT IN ('system', 'information_schema', 'INFORMATION_SCHEMA')
+		GROUP BY t.database, t.name
+		ORDER BY t.database, t.name
+	`
+
+	rows, err := s.conn.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table summaries: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			s.log.WithError(err).Warn("Failed to close rows")
+		}
+	}()
+
+	summaries := make([]TableSummary, 0, 100)
+	for rows.Next() {
+		var (
+			summary TableSummary
+			columns uint64
+		)
+		if err := rows.Scan(&summary.Database, &summary.Name, &summary.Engine, &columns); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		summary.Columns = int(columns) //nolint:gosec // Column count is small
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
+
 func (s *service) GetTable(ctx context.Context, database, tableName string) (*Table, error) {
+	if s.cache != nil {
+		return s.cachedTable(ctx, database, tableName)
+	}
+	return s.loadTable(ctx, database, tableName)
+}
+
+// loadTable reads the schema of a table from the system tables
+func (s *service) loadTable(ctx context.Context, database, tableName string) (*Table, error) {
 	table := &Table{
 		Name:        tableName,
 		Database:    database,
@@ -138,28 +296,42 @@ func (s *service) GetTable(ctx context.Context, database, tableName string) (*Ta
 	return table, nil
 }
 
-// loadTableMetadata loads table metadata including comment and sorting key
+// loadTableMetadata loads table metadata including comment, sorting, sampling and partition keys
 func (s *service) loadTableMetadata(ctx context.Context, database, tableName string, table *Table) error {
 	metaQuery := `
-		SELECT comment, sorting_key, engine, engine_full
-		FROM system.tables
+		SELECT comment, sorting_key, sampling_key, partition_key, engine, engine_full
+		FROM ` + s.systemTable("tables") + `
 		WHERE database = ? AND name = ?
+		LIMIT 1
 	`
-	var comment, sortingKey, engine, engineFull sql.NullString
-	if err := s.conn.QueryRow(ctx, metaQuery, database, tableName).Scan(&comment, &sortingKey, &engine, &engineFull); err != nil {
+	var comment, sortingKey, samplingKey, partitionKey, engine, engineFull sql.NullString
+	if err := s.conn.QueryRow(ctx, metaQuery, database, tableName).Scan(&comment, &sortingKey, &samplingKey, &partitionKey, &engine, &engineFull); err != nil {
 		return err
 	}
 
 	if comment.Valid {
 		table.Comment = comment.String
 	}
+	if samplingKey.Valid {
+		table.SamplingKey = samplingKey.String
+	}
+	if partitionKey.Valid {
+		table.PartitionKey = partitionKey.String
+	}
+	if engineFull.Valid && engineFull.String != "" {
+		table.Engine = engineDefinition(engineFull.String)
+	} else if engine.Valid {
+		// Views have no engine_full
+		table.Engine = engine.String
+	}
 
 	// Load sorting key
 	s.loadSortingKey(ctx, table, sortingKey, engine, engineFull)
 	return nil
 }
 
-// loadSortingKey loads the sorting key for a table
+// loadSortingKey loads the sorting key for a table. Distributed tables have no sorting,
+// sampling or partition key of their own, so they are read from the underlying table.
 func (s *service) loadSortingKey(ctx context.Context, table *Table, sortingKey, engine, engineFull sql.NullString) {
 	// Check if sorting key is directly available
 	if sortingKey.Valid && sortingKey.String != "" {
@@ -184,12 +356,13 @@ func (s *service) loadSortingKey(ctx context.Context, table *Table, sortingKey,
 
 	// Query underlying table for sorting key
 	underlyingQuery := `
-		SELECT sorting_key
-		FROM system.tables
+		SELECT sorting_key, sampling_key, partition_key
+		FROM ` + s.systemTable("tables") + `
 		WHERE database = ? AND name = ?
+		LIMIT 1
 	`
-	var underlyingSortingKey sql.NullString
-	if err := s.conn.QueryRow(ctx, underlyingQuery, underlyingTable.Database, underlyingTable.Table).Scan(&underlyingSortingKey); err != nil {
+	var underlyingSortingKey, underlyingSamplingKey, underlyingPartitionKey sql.NullString
+	if err := s.conn.QueryRow(ctx, underlyingQuery, underlyingTable.Database, underlyingTable.Table).Scan(&underlyingSortingKey, &underlyingSamplingKey, &underlyingPartitionKey); err != nil {
 		s.log.WithError(err).Warn("Failed to get underlying table sorting key")
 		return
 	}
@@ -197,21 +370,68 @@ func (s *service) loadSortingKey(ctx context.Context, table *Table, sortingKey,
 	if underlyingSortingKey.Valid && underlyingSortingKey.String != "" {
 		table.SortingKey = parseSortingKey(underlyingSortingKey.String)
 	}
+	if underlyingSamplingKey.Valid {
+		table.SamplingKey = underlyingSamplingKey.String
+	}
+	if underlyingPartitionKey.Valid {
+		table.PartitionKey = underlyingPartitionKey.String
+	}
+}
+
+// mergeReplicaColumns keeps one column per name. Reading across replicas returns a row per
+// distinct definition; when replicas disagree the first definition wins and a warning is
+// logged, since queries may fail on the replicas with the other definition.
+func (s *service) mergeReplicaColumns(database, tableName string, columns []Column) []Column {
+	merged := make([]Column, 0, len(columns))
+	seen := make(map[string]int, len(columns))
+	for _, col := range columns {
+		i, ok := seen[col.Name]
+		if !ok {
+			seen[col.Name] = len(merged)
+			merged = append(merged, col)
+			continue
+		}
+		if merged[i].Type != col.Type {
+			s.log.WithFields(logrus.Fields{
+				"database": database,
+				"table":    tableName,
+				"column":   col.Name,
+				"type":     merged[i].Type,
+				"other":    col.Type,
+			}).Warn("Column type differs between replicas, using the first")
+		}
+	}
+
+	// Columns added on some replicas only can share a position, which numbers proto fields
+	positions := make(map[uint64]bool, len(merged))
+	for _, col := range merged {
+		positions[col.Position] = true
+	}
+	if len(positions) != len(merged) {
+		s.log.WithFields(logrus.Fields{
+			"database": database,
+			"table":    tableName,
+		}).Warn("Column positions differ between replicas, renumbering columns")
+		for i := range merged {
+			merged[i].Position = uint64(i + 1)
+		}
+	}
+	return merged
 }
 
 // loadTableColumns loads the columns for a table
 func (s *service) loadTableColumns(ctx context.Context, database, tableName string) ([]Column, error) {
 	columnsQuery := `
-		SELECT 
+		SELECT DISTINCT
 			name,
 			type,
 			default_kind,
 			default_expression,
 			comment,
 			position
-		FROM system.columns
+		FROM ` + s.systemTable("columns") + `
 		WHERE database = ? AND table = ?
-		ORDER BY position
+		ORDER BY position, name
 	`
 
 	rows, err := s.conn.Query(ctx, columnsQuery, database, tableName)
@@ -251,9 +471,7 @@ func (s *service) loadTableColumns(ctx context.Context, database, tableName stri
 		}
 
 		// Parse type information
-		col.IsNullable = strings.HasPrefix(col.Type, "Nullable(")
-		col.IsArray = strings.HasPrefix(col.Type, "Array(")
-		col.BaseType = extractBaseType(col.Type)
+		setTypeInfo(&col)
 
 		columns = append(columns, col)
 	}
@@ -262,7 +480,7 @@ func (s *service) loadTableColumns(ctx context.Context, database, tableName stri
 		return nil, fmt.Errorf("error iterating columns: %w", err)
 	}
 
-	return columns, nil
+	return s.mergeReplicaColumns(database, tableName, columns), nil
 }
 
 func (s *service) GetTables(ctx context.Context, database string, tableNames []string) ([]*Table, error) {
@@ -376,6 +594,13 @@ func splitDistributedArgs(args string) []string {
 	return result
 }
 
+// setTypeInfo derives the nullable, array and base type properties from a column's type
+func setTypeInfo(col *Column) {
+	col.IsNullable = strings.HasPrefix(col.Type, "Nullable(")
+	col.IsArray = strings.HasPrefix(col.Type, "Array(")
+	col.BaseType = extractBaseType(col.Type)
+}
+
 func extractBaseType(clickhouseType string) string {
 	// Recursively remove wrapper types (Array, Nullable, LowCardinality)
 	// This handles nested cases like Array(Nullable(UInt64))
@@ -420,11 +645,11 @@ func extractBaseType(clickhouseType string) string {
 // loadTableProjections loads the projections for a table
 func (s *service) loadTableProjections(ctx context.Context, database, tableName string) ([]Projection, error) {
 	projectionsQuery := `
-		SELECT 
+		SELECT DISTINCT
 			name,
 			sorting_key,
 			type
-		FROM system.projections
+		FROM ` + s.systemTable("projections") + `
 		WHERE database = ? AND table = ?
 		ORDER BY name
 	`
@@ -457,6 +682,10 @@ func (s *service) loadTableProjections(ctx context.Context, database, tableName
 		proj.OrderByKey = sortingKeyArray
 		proj.Type = projType
 
+		// Replicas with diverging projection definitions return one row each
+		if len(projections) > 0 && projections[len(projections)-1].Name == proj.Name {
+			continue
+		}
 		projections = append(projections, proj)
 	}
 
@@ -471,8 +700,9 @@ func (s *service) loadTableProjections(ctx context.Context, database, tableName
 func (s *service) isDistributedTable(ctx context.Context, database, tableName string) bool {
 	query := `
 		SELECT engine
-		FROM system.tables
+		FROM ` + s.systemTable("tables") + `
 		WHERE database = ? AND name = ?
+		LIMIT 1
 	`
 	var engine sql.NullString
 	if err := s.conn.QueryRow(ctx, query, database, tableName).Scan(&engine); err != nil {
@@ -485,8 +715,9 @@ func (s *service) isDistributedTable(ctx context.Context, database, tableName st
 func (s *service) getUnderlyingTableName(ctx context.Context, database, tableName string) *underlyingTableInfo {
 	query := `
 		SELECT engine_full
-		FROM system.tables
+		FROM ` + s.systemTable("tables") + `
 		WHERE database = ? AND name = ?
+		LIMIT 1
 	`
 	var engineFull sql.NullString
 	if err := s.conn.QueryRow(ctx, query, database, tableName).Scan(&engineFull); err != nil {
@@ -523,26 +754,16 @@ func (s *service) loadDistributedTableProjections(ctx context.Context, database,
 }
 
 // parseSortingKey parses the sorting key expression from ClickHouse
-// It handles expressions like "column1, column2" or "column1 ASC, column2 DESC"
+// It handles expressions like "column1, column2" or "column1 ASC, column2 DESC". Column
+// names ClickHouse quotes, such as `block date`, are unquoted like those of parsed DDL.
 func parseSortingKey(sortingKey string) []string {
 	if sortingKey == "" {
 		return nil
 	}
 
-	// Split by comma
-	parts := strings.Split(sortingKey, ",")
-	columns := make([]string, 0, len(parts))
-
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		// Remove ASC/DESC modifiers and any parentheses
-		part = strings.TrimSuffix(strings.TrimSuffix(part, " ASC"), " DESC")
-		part = strings.Trim(part, "()")
-
-		if part != "" {
-			columns = append(columns, part)
-		}
+	tokens, err := tokenizeDDL(sortingKey)
+	if err != nil {
+		return nil
 	}
-
-	return columns
+	return sortingKeyFromTokens(tokens)
 }
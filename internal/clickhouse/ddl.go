@@ -0,0 +1,645 @@
+package clickhouse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DDL parsing errors
+var (
+	ErrDDLSyntax    = errors.New("invalid DDL")
+	ErrDDLReference = errors.New("unresolved table reference in DDL")
+	ErrDDLView      = errors.New("cannot derive view columns")
+)
+
+// ddlTable is a parsed CREATE TABLE statement whose references to other tables
+// (CREATE TABLE ... AS other, Distributed engines) are not resolved yet
+type ddlTable struct {
+	table      *Table
+	asTable    string // Source of CREATE TABLE ... AS [db.]table
+	engine     string
+	engineArgs []string
+	viewQuery  []token // SELECT query of a view whose columns are derived from it
+}
+
+// columnModifiers end a column's type in a column definition
+//
+//nolint:gochecknoglobals // Read-only lookup table
+var columnModifiers = map[string]bool{
+	"NULL": true, "NOT": true, "DEFAULT": true, "MATERIALIZED": true, "ALIAS": true, "EPHEMERAL": true,
+	"CODEC": true, "TTL": true, "COMMENT": true, "PRIMARY": true, "SETTINGS": true, "STATISTICS": true,
+}
+
+// ParseDDL parses the CREATE TABLE, VIEW and LIVE VIEW statements in src into tables, the
+// same model that is introspected from a live database. Other statements (materialized views,
+// databases, inserts) are ignored. Tables created with AS, Distributed tables and view queries
+// are resolved against the other tables in src.
+func ParseDDL(src string) ([]*Table, error) {
+	parsed, err := parseDDL(src)
+	if err != nil {
+		return nil, err
+	}
+	return resolveDDLTables(parsed)
+}
+
+// parseDDL parses every CREATE TABLE statement in src without resolving references
+func parseDDL(src string) ([]*ddlTable, error) {
+	tokens, err := tokenizeDDL(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*ddlTable
+	for _, statement := range splitStatements(tokens) {
+		dt, err := parseCreateTable(statement)
+		if err != nil {
+			return nil, err
+		}
+		if dt != nil {
+			tables = append(tables, dt)
+		}
+	}
+	return tables, nil
+}
+
+// ddlParser walks the tokens of a single statement
+type ddlParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *ddlParser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: tokenSymbol}
+	}
+	return p.tokens[p.pos]
+}
+
+// accept consumes the given keyword sequence if it comes next
+func (p *ddlParser) accept(keywords ...string) bool {
+	if p.pos+len(keywords) > len(p.tokens) {
+		return false
+	}
+	for i, keyword := range keywords {
+		if !p.tokens[p.pos+i].isKeyword(keyword) {
+			return false
+		}
+	}
+	p.pos += len(keywords)
+	return true
+}
+
+func (p *ddlParser) errorf(format string, args ...any) error {
+	line := 0
+	if len(p.tokens) > 0 {
+		line = p.tokens[min(p.pos, len(p.tokens)-1)].line
+	}
+	return fmt.Errorf("%w: line %d: %s", ErrDDLSyntax, line, fmt.Sprintf(format, args...))
+}
+
+// identifier consumes a plain or quoted identifier
+func (p *ddlParser) identifier() (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokenWord && tok.kind != tokenQuoted {
+		return "", false
+	}
+	p.pos++
+	return tok.text, true
+}
+
+// qualifiedName consumes [database.]name
+func (p *ddlParser) qualifiedName() (database, name string, err error) {
+	name, ok := p.identifier()
+	if !ok {
+		return "", "", p.errorf("expected table name")
+	}
+	if p.peek().isSymbol(".") {
+		p.pos++
+		database = name
+		if name, ok = p.identifier(); !ok {
+			return "", "", p.errorf("expected table name after %s.", database)
+		}
+	}
+	return database, name, nil
+}
+
+// parseCreateTable parses a CREATE TABLE, VIEW or LIVE VIEW statement, returning nil for
+// any other statement
+func parseCreateTable(statement []token) (*ddlTable, error) {
+	p := &ddlParser{tokens: statement}
+
+	if !p.accept("CREATE") && !p.accept("ATTACH") {
+		return nil, nil
+	}
+	p.accept("OR", "REPLACE")
+	p.accept("TEMPORARY")
+	switch {
+	case p.accept("TABLE"):
+	case p.accept("VIEW"):
+		return p.parseView("View")
+	case p.accept("LIVE", "VIEW"):
+		return p.parseView("LiveView")
+	default:
+		return nil, nil
+	}
+
+	dt, err := p.tableHeader()
+	if err != nil {
+		return nil, err
+	}
+	name := dt.table.Name
+
+	if p.peek().isSymbol("(") {
+		end := matchingParen(p.tokens, p.pos)
+		if end < 0 {
+			return nil, p.errorf("unbalanced parentheses in table %s", name)
+		}
+		if err := dt.parseElements(p.tokens[p.pos+1 : end]); err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+		p.pos = end + 1
+	}
+
+	if err := dt.parseClauses(p.tokens[p.pos:]); err != nil {
+		return nil, fmt.Errorf("table %s: %w", name, err)
+	}
+
+	if len(dt.table.Columns) == 0 && dt.asTable == "" {
+		return nil, p.errorf("table %s has no column definitions", name)
+	}
+	return dt, nil
+}
+
+// tableHeader parses `[IF NOT EXISTS] [db.]name [UUID 'uuid'] [ON CLUSTER cluster]`
+func (p *ddlParser) tableHeader() (*ddlTable, error) {
+	p.accept("IF", "NOT", "EXISTS")
+
+	database, name, err := p.qualifiedName()
+	if err != nil {
+		return nil, err
+	}
+	if p.accept("UUID") {
+		p.pos++
+	}
+	if p.accept("ON", "CLUSTER") {
+		p.pos++
+	}
+
+	return &ddlTable{table: &Table{
+		Name:        name,
+		Database:    database,
+		Columns:     []Column{},
+		SortingKey:  []string{},
+		Projections: []Projection{},
+	}}, nil
+}
+
+// parseElements parses the column list: columns, projections, indexes and constraints
+func (dt *ddlTable) parseElements(tokens []token) error {
+	for _, element := range splitTopLevel(tokens) {
+		if len(element) == 0 {
+			continue
+		}
+
+		first := element[0]
+		switch {
+		case first.isKeyword("INDEX") && containsKeyword(element, "TYPE"),
+			first.isKeyword("CONSTRAINT") && len(element) > 2 && (element[2].isKeyword("CHECK") || element[2].isKeyword("ASSUME")):
+			continue
+		case first.isKeyword("PROJECTION") && len(element) > 2 && element[2].isSymbol("("):
+			dt.table.Projections = append(dt.table.Projections, parseProjection(element))
+		case first.isKeyword("PRIMARY") && len(element) > 1 && element[1].isKeyword("KEY"):
+			if len(dt.table.SortingKey) == 0 {
+				dt.table.SortingKey = sortingKeyFromTokens(element[2:])
+			}
+		default:
+			col, err := parseColumn(element, uint64(len(dt.table.Columns)+1)) //nolint:gosec // Column count is small
+			if err != nil {
+				return err
+			}
+			dt.table.Columns = append(dt.table.Columns, col)
+		}
+	}
+	return nil
+}
+
+// parseColumn parses `name Type [NULL] [DEFAULT|MATERIALIZED|ALIAS|EPHEMERAL expr] [CODEC(...)] [TTL expr] [COMMENT 'text']`
+func parseColumn(element []token, position uint64) (Column, error) {
+	p := &ddlParser{tokens: element}
+
+	name, ok := p.identifier()
+	if !ok {
+		return Column{}, p.errorf("expected column name")
+	}
+
+	typeEnd := nextModifier(element, p.pos)
+	if typeEnd == p.pos {
+		return Column{}, p.errorf("column %s has no explicit type", name)
+	}
+	columnType := joinTokens(element[p.pos:typeEnd])
+	p.pos = typeEnd
+
+	col := Column{Name: name, Position: position}
+	for p.pos < len(p.tokens) {
+		keyword := strings.ToUpper(p.peek().text)
+		p.pos++
+
+		switch keyword {
+		case "NULL":
+			if !strings.HasPrefix(columnType, "Nullable(") {
+				columnType = "Nullable(" + columnType + ")"
+			}
+		case "NOT":
+			p.accept("NULL")
+		case "DEFAULT", "MATERIALIZED", "ALIAS", "EPHEMERAL":
+			end := nextClause(element, p.pos)
+			col.DefaultKind = keyword
+			col.DefaultValue = joinTokens(element[p.pos:end])
+			p.pos = end
+		case "COMMENT":
+			if tok := p.peek(); tok.kind == tokenString {
+				col.Comment = unquote(tok.text)
+				p.pos++
+			}
+		case "PRIMARY":
+			p.accept("KEY")
+		default:
+			// CODEC(...), TTL expr, SETTINGS (...), STATISTICS(...) don't affect the model
+			p.pos = nextClause(element, p.pos)
+		}
+	}
+
+	col.Type = columnType
+	setTypeInfo(&col)
+	return col, nil
+}
+
+// nextModifier returns the index of the first top-level column modifier keyword at or after start
+func nextModifier(tokens []token, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.isSymbol("("):
+			depth++
+		case tok.isSymbol(")"):
+			depth--
+		case depth == 0 && tok.kind == tokenWord && columnModifiers[strings.ToUpper(tok.text)]:
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// nextClause returns the end of a column expression: the next top-level CODEC, TTL, COMMENT,
+// SETTINGS or STATISTICS keyword. NULL and NOT may appear inside expressions, so they don't end one.
+func nextClause(tokens []token, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.isSymbol("("):
+			depth++
+		case tok.isSymbol(")"):
+			depth--
+		case depth == 0 && tok.kind == tokenWord:
+			switch strings.ToUpper(tok.text) {
+			case "CODEC", "TTL", "COMMENT", "SETTINGS", "STATISTICS":
+				return i
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// parseProjection parses `PROJECTION name (SELECT ... [GROUP BY ...] [ORDER BY ...])`
+func parseProjection(element []token) Projection {
+	name := element[1].text
+	inner := element[3:]
+	if end := matchingParen(element, 2); end > 0 {
+		inner = element[3:end]
+	}
+
+	proj := Projection{Name: name, Type: "Normal"}
+	orderBy := findKeywords(inner, "ORDER", "BY")
+	groupBy := findKeywords(inner, "GROUP", "BY")
+
+	if groupBy >= 0 {
+		proj.Type = "Aggregate"
+		end := len(inner)
+		if orderBy > groupBy {
+			end = orderBy
+		}
+		proj.OrderByKey = sortingKeyFromTokens(inner[groupBy+2 : end])
+	}
+	if orderBy >= 0 {
+		proj.OrderByKey = sortingKeyFromTokens(inner[orderBy+2:])
+	}
+	return proj
+}
+
+// parseClauses parses the table clauses following the column list
+func (dt *ddlTable) parseClauses(tokens []token) error {
+	var primaryKey []string
+
+	for _, clause := range splitClauses(tokens) {
+		body := clause.tokens
+		switch clause.name {
+		case "ENGINE":
+			dt.parseEngine(body)
+		case "ORDER BY":
+			dt.table.SortingKey = sortingKeyFromTokens(body)
+		case "PRIMARY KEY":
+			primaryKey = sortingKeyFromTokens(body)
+		case "SAMPLE BY":
+			dt.table.SamplingKey = joinTokens(body)
+		case "PARTITION BY":
+			dt.table.PartitionKey = joinTokens(body)
+		case "COMMENT":
+			if len(body) > 0 && body[0].kind == tokenString {
+				dt.table.Comment = unquote(body[0].text)
+			}
+		case "AS":
+			p := &ddlParser{tokens: body}
+			database, name, err := p.qualifiedName()
+			if err != nil || p.pos != len(body) {
+				return fmt.Errorf("%w: unsupported AS clause %q", ErrDDLSyntax, joinTokens(body))
+			}
+			dt.asTable = qualifiedTableName(database, name)
+		}
+	}
+
+	// Without ORDER BY, ClickHouse sorts by the primary key
+	if len(dt.table.SortingKey) == 0 && len(primaryKey) > 0 {
+		dt.table.SortingKey = primaryKey
+	}
+	return nil
+}
+
+// parseEngine parses `[=] Name[(args)]`
+func (dt *ddlTable) parseEngine(body []token) {
+	if len(body) > 0 && body[0].isSymbol("=") {
+		body = body[1:]
+	}
+	if len(body) == 0 {
+		return
+	}
+
+	dt.engine, dt.engineArgs = parseEngineDefinition(body)
+	dt.table.Engine = formatEngine(dt.engine, dt.engineArgs)
+}
+
+// parseEngineDefinition splits `Name[(args)]` tokens into the engine name and its arguments
+func parseEngineDefinition(tokens []token) (name string, args []string) {
+	if len(tokens) == 0 {
+		return "", nil
+	}
+
+	name = tokens[0].text
+	if len(tokens) > 1 && tokens[1].isSymbol("(") {
+		if end := matchingParen(tokens, 1); end > 0 {
+			for _, arg := range splitTopLevel(tokens[2:end]) {
+				args = append(args, joinTokens(arg))
+			}
+		}
+	}
+	return name, args
+}
+
+// formatEngine renders an engine with its arguments, e.g. "ReplacingMergeTree(version)"
+func formatEngine(name string, args []string) string {
+	if len(args) == 0 {
+		return name
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// engineDefinition extracts the engine and its arguments from system.tables engine_full,
+// which also carries the table's ORDER BY, SETTINGS and other clauses
+func engineDefinition(engineFull string) string {
+	tokens, err := tokenizeDDL(engineFull)
+	if err != nil {
+		return engineFull
+	}
+	return formatEngine(parseEngineDefinition(tokens))
+}
+
+// ddlClause is a top-level table clause such as ENGINE or ORDER BY
+type ddlClause struct {
+	name   string
+	tokens []token
+}
+
+// splitClauses splits the tokens after the column list into clauses. Tokens of an
+// AS SELECT query are dropped, since the query's own clauses don't describe the table.
+func splitClauses(tokens []token) []ddlClause {
+	var clauses []ddlClause
+	depth := 0
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if depth == 0 {
+			if name, width := clauseKeyword(tokens, i); name != "" {
+				if name == "AS" && i+1 < len(tokens) && (tokens[i+1].isKeyword("SELECT") || tokens[i+1].isKeyword("WITH")) {
+					break
+				}
+				clauses = append(clauses, ddlClause{name: name})
+				i += width - 1
+				continue
+			}
+		}
+
+		switch {
+		case tok.isSymbol("("):
+			depth++
+		case tok.isSymbol(")"):
+			depth--
+		}
+		if len(clauses) > 0 {
+			clauses[len(clauses)-1].tokens = append(clauses[len(clauses)-1].tokens, tok)
+		}
+	}
+	return clauses
+}
+
+// clauseKeyword returns the clause starting at tokens[i] and how many tokens its keyword spans
+func clauseKeyword(tokens []token, i int) (string, int) {
+	tok := tokens[i]
+	if tok.kind != tokenWord {
+		return "", 0
+	}
+
+	keyword := strings.ToUpper(tok.text)
+	switch keyword {
+	case "ENGINE", "TTL", "SETTINGS", "COMMENT", "AS":
+		return keyword, 1
+	case "ORDER", "PARTITION", "SAMPLE":
+		if i+1 < len(tokens) && tokens[i+1].isKeyword("BY") {
+			return keyword + " BY", 2
+		}
+	case "PRIMARY":
+		if i+1 < len(tokens) && tokens[i+1].isKeyword("KEY") {
+			return "PRIMARY KEY", 2
+		}
+	}
+	return "", 0
+}
+
+// sortingKeyFromTokens converts an ORDER BY / PRIMARY KEY expression into key columns.
+// `(a, b)` and `tuple(a, b)` are unwrapped; ASC/DESC modifiers are dropped and quoted
+// column names unquoted.
+func sortingKeyFromTokens(tokens []token) []string {
+	if len(tokens) > 1 && tokens[0].isKeyword("tuple") && tokens[1].isSymbol("(") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) > 0 && tokens[0].isSymbol("(") && matchingParen(tokens, 0) == len(tokens)-1 {
+		tokens = tokens[1 : len(tokens)-1]
+	}
+
+	key := []string{}
+	for _, part := range splitTopLevel(tokens) {
+		if n := len(part); n > 1 && (part[n-1].isKeyword("ASC") || part[n-1].isKeyword("DESC")) {
+			part = part[:n-1]
+		}
+		if len(part) > 0 {
+			key = append(key, keyElement(part))
+		}
+	}
+	return key
+}
+
+// keyElement returns the column a key element names, unquoted so it matches the column's
+// name: `block date` is the column block date. Elements wrapped in parentheses are
+// unwrapped; expressions such as toStartOfDay(ts) are kept as written.
+func keyElement(tokens []token) string {
+	for len(tokens) > 2 && tokens[0].isSymbol("(") && matchingParen(tokens, 0) == len(tokens)-1 {
+		tokens = tokens[1 : len(tokens)-1]
+	}
+	if len(tokens) == 1 && (tokens[0].kind == tokenWord || tokens[0].kind == tokenQuoted) {
+		return tokens[0].text
+	}
+	return joinTokens(tokens)
+}
+
+// findKeywords returns the index of a top-level keyword sequence, or -1
+func findKeywords(tokens []token, keywords ...string) int {
+	depth := 0
+	for i := range tokens {
+		switch {
+		case tokens[i].isSymbol("("):
+			depth++
+		case tokens[i].isSymbol(")"):
+			depth--
+		case depth == 0 && i+len(keywords) <= len(tokens):
+			match := true
+			for j, keyword := range keywords {
+				if !tokens[i+j].isKeyword(keyword) {
+					match = false
+					break
+				}
+			}
+			if match {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// containsKeyword reports whether a top-level keyword appears in tokens
+func containsKeyword(tokens []token, keyword string) bool {
+	return findKeywords(tokens, keyword) >= 0
+}
+
+// qualifiedTableName returns database.name, or name when database is empty
+func qualifiedTableName(database, name string) string {
+	if database == "" {
+		return name
+	}
+	return database + "." + name
+}
+
+// resolveDDLTables copies columns into CREATE TABLE ... AS tables and sorting keys and
+// projections into Distributed tables from their local tables, when those are defined
+func resolveDDLTables(parsed []*ddlTable) ([]*Table, error) {
+	lookup := func(ref, defaultDatabase string) *ddlTable {
+		database, name := defaultDatabase, ref
+		if idx := strings.LastIndex(ref, "."); idx >= 0 {
+			database, name = ref[:idx], ref[idx+1:]
+		}
+		for _, dt := range parsed {
+			if dt.table.Name == name && (database == "" || dt.table.Database == "" || dt.table.Database == database) {
+				return dt
+			}
+		}
+		return nil
+	}
+
+	resolving := make(map[*ddlTable]bool)
+	var resolve func(dt *ddlTable) error
+	resolve = func(dt *ddlTable) error {
+		if dt.asTable == "" {
+			return nil
+		}
+		if resolving[dt] {
+			return fmt.Errorf("%w: %s is part of an AS cycle", ErrDDLReference, dt.table.Name)
+		}
+		resolving[dt] = true
+
+		src := lookup(dt.asTable, dt.table.Database)
+		if src == nil {
+			return fmt.Errorf("%w: %s is created AS unknown table %s", ErrDDLReference, dt.table.Name, dt.asTable)
+		}
+		if err := resolve(src); err != nil {
+			return err
+		}
+
+		dt.table.Columns = append([]Column{}, src.table.Columns...)
+		if dt.engine == "" {
+			dt.engine, dt.engineArgs = src.engine, src.engineArgs
+			dt.table.Engine = src.table.Engine
+			dt.table.SortingKey = append([]string{}, src.table.SortingKey...)
+			dt.table.SamplingKey = src.table.SamplingKey
+			dt.table.PartitionKey = src.table.PartitionKey
+			dt.table.Projections = append([]Projection{}, src.table.Projections...)
+		}
+		dt.asTable = ""
+		return nil
+	}
+
+	tables := make([]*Table, 0, len(parsed))
+	for _, dt := range parsed {
+		if err := resolve(dt); err != nil {
+			return nil, err
+		}
+	}
+	if err := resolveDDLViews(parsed, lookup); err != nil {
+		return nil, err
+	}
+
+	for _, dt := range parsed {
+		if dt.engine == "Distributed" && len(dt.engineArgs) >= 3 {
+			database := strings.Trim(dt.engineArgs[1], " '`\"")
+			if database == "currentDatabase()" {
+				database = dt.table.Database
+			}
+			if local := lookup(qualifiedTableName(database, strings.Trim(dt.engineArgs[2], " '`\"")), dt.table.Database); local != nil {
+				if len(dt.table.SortingKey) == 0 {
+					dt.table.SortingKey = append([]string{}, local.table.SortingKey...)
+				}
+				if dt.table.SamplingKey == "" {
+					dt.table.SamplingKey = local.table.SamplingKey
+				}
+				if dt.table.PartitionKey == "" {
+					dt.table.PartitionKey = local.table.PartitionKey
+				}
+				if len(dt.table.Projections) == 0 {
+					dt.table.Projections = append([]Projection{}, local.table.Projections...)
+				}
+			}
+		}
+		tables = append(tables, dt.table)
+	}
+	return tables, nil
+}
@@ -0,0 +1,137 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatDDL renders a normalized CREATE TABLE statement for a table. The output parses back
+// into the same model with ParseDDL, except for aggregate projections, whose SELECT isn't
+// part of the model and which are written as comments.
+func FormatDDL(table *Table) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "CREATE TABLE %s\n(\n", formatTableName(table.Database, table.Name))
+
+	elements := make([]string, 0, len(table.Columns)+len(table.Projections))
+	for i := range table.Columns {
+		elements = append(elements, formatColumnDefinition(&table.Columns[i]))
+	}
+
+	mergeTree := isMergeTreeEngine(table.Engine)
+	var skipped []string
+	if mergeTree {
+		for _, proj := range table.Projections {
+			if strings.EqualFold(proj.Type, "Aggregate") {
+				skipped = append(skipped, proj.Name)
+				continue
+			}
+			elements = append(elements, fmt.Sprintf("PROJECTION %s (SELECT * ORDER BY %s)", quoteIdentifier(proj.Name), formatKey(table, proj.OrderByKey)))
+		}
+	}
+
+	sb.WriteString("    " + strings.Join(elements, ",\n    ") + "\n")
+	for _, name := range skipped {
+		fmt.Fprintf(&sb, "    -- PROJECTION %s: aggregate projection, definition not available\n", quoteIdentifier(name))
+	}
+	sb.WriteString(")")
+
+	if table.Engine != "" {
+		fmt.Fprintf(&sb, "\nENGINE = %s", table.Engine)
+	}
+	if mergeTree {
+		if table.PartitionKey != "" {
+			fmt.Fprintf(&sb, "\nPARTITION BY %s", table.PartitionKey)
+		}
+		fmt.Fprintf(&sb, "\nORDER BY %s", formatKey(table, table.SortingKey))
+		if table.SamplingKey != "" {
+			fmt.Fprintf(&sb, "\nSAMPLE BY %s", table.SamplingKey)
+		}
+	}
+	if table.Comment != "" {
+		fmt.Fprintf(&sb, "\nCOMMENT %s", quoteString(table.Comment))
+	}
+
+	sb.WriteString(";\n")
+	return sb.String()
+}
+
+// DDLExcerpt returns the lines of a table's CREATE TABLE statement telling where its data
+// lives and how it is laid out: the table name followed by its ENGINE, PARTITION BY and
+// ORDER BY clauses. Clauses the engine doesn't have are left out.
+func DDLExcerpt(table *Table) []string {
+	lines := []string{"CREATE TABLE " + formatTableName(table.Database, table.Name)}
+	if table.Engine != "" {
+		lines = append(lines, "ENGINE = "+table.Engine)
+	}
+	if isMergeTreeEngine(table.Engine) {
+		if table.PartitionKey != "" {
+			lines = append(lines, "PARTITION BY "+table.PartitionKey)
+		}
+		lines = append(lines, "ORDER BY "+formatKey(table, table.SortingKey))
+	}
+	return lines
+}
+
+// formatColumnDefinition renders `name Type [DEFAULT expr] [COMMENT 'text']`
+func formatColumnDefinition(col *Column) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s %s", quoteColumnName(col.Name), col.Type)
+	if col.DefaultKind != "" {
+		fmt.Fprintf(&sb, " %s %s", col.DefaultKind, col.DefaultValue)
+	}
+	if col.Comment != "" {
+		fmt.Fprintf(&sb, " COMMENT %s", quoteString(col.Comment))
+	}
+	return sb.String()
+}
+
+// quoteColumnName always quotes a column name, so names like `index` or `primary` stay
+// unambiguous
+func quoteColumnName(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// formatKey renders a sorting key of a table as an ORDER BY expression. Elements naming a
+// column are quoted when they need to be; expressions are kept as written.
+func formatKey(table *Table, key []string) string {
+	elements := make([]string, len(key))
+	for i, element := range key {
+		elements[i] = element
+		for j := range table.Columns {
+			if table.Columns[j].Name == element {
+				elements[i] = quoteIdentifier(element)
+				break
+			}
+		}
+	}
+
+	switch len(elements) {
+	case 0:
+		return "tuple()"
+	case 1:
+		return elements[0]
+	default:
+		return "(" + strings.Join(elements, ", ") + ")"
+	}
+}
+
+func formatTableName(database, name string) string {
+	if database == "" {
+		return quoteIdentifier(name)
+	}
+	return quoteIdentifier(database) + "." + quoteIdentifier(name)
+}
+
+// isMergeTreeEngine reports whether ORDER BY and projections apply to the engine.
+// An unknown engine is treated as MergeTree so the sorting key isn't lost.
+func isMergeTreeEngine(engine string) bool {
+	return engine == "" || strings.Contains(engine, "MergeTree")
+}
+
+// quoteString renders a ClickHouse string literal
+func quoteString(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return "'" + replacer.Replace(s) + "'"
+}
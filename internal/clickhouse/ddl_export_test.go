@@ -0,0 +1,136 @@
+package clickhouse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDDL(t *testing.T) {
+	table := &Table{
+		Name:     "users",
+		Database: "default",
+		Comment:  "User's accounts",
+		Engine:   "ReplacingMergeTree(updated_at)",
+		Columns: []Column{
+			{Name: "id", Type: "UInt64"},
+			{Name: "email", Type: "Nullable(String)", Comment: "Primary\temail"},
+			{Name: "updated_at", Type: "DateTime", DefaultKind: "DEFAULT", DefaultValue: "now()"},
+		},
+		SortingKey:   []string{"id", "email"},
+		SamplingKey:  "id",
+		PartitionKey: "toYYYYMM(updated_at)",
+		Projections: []Projection{
+			{Name: "p_by_email", OrderByKey: []string{"email"}, Type: "Normal"},
+			{Name: "p_daily", OrderByKey: []string{"id"}, Type: "Aggregate"},
+		},
+	}
+
+	expected := "CREATE TABLE default.users\n" +
+		"(\n" +
+		"    `id` UInt64,\n" +
+		"    `email` Nullable(String) COMMENT 'Primary\\temail',\n" +
+		"    `updated_at` DateTime DEFAULT now(),\n" +
+		"    PROJECTION p_by_email (SELECT * ORDER BY email)\n" +
+		"    -- PROJECTION p_daily: aggregate projection, definition not available\n" +
+		")\n" +
+		"ENGINE = ReplacingMergeTree(updated_at)\n" +
+		"PARTITION BY toYYYYMM(updated_at)\n" +
+		"ORDER BY (id, email)\n" +
+		"SAMPLE BY id\n" +
+		"COMMENT 'User\\'s accounts';\n"
+
+	assert.Equal(t, expected, FormatDDL(table))
+}
+
+func TestFormatDDL_NonMergeTreeEngine(t *testing.T) {
+	table := &Table{
+		Name:       "users",
+		Engine:     "Distributed('{cluster}', default, users_local, rand())",
+		Columns:    []Column{{Name: "id", Type: "UInt64"}},
+		SortingKey: []string{"id"},
+	}
+
+	ddl := FormatDDL(table)
+	assert.Contains(t, ddl, "ENGINE = Distributed('{cluster}', default, users_local, rand())")
+	assert.NotContains(t, ddl, "ORDER BY", "only MergeTree tables have a sorting key of their own")
+}
+
+func TestDDLExcerpt(t *testing.T) {
+	table := &Table{
+		Name:         "fct_block",
+		Database:     "mainnet",
+		Engine:       "ReplacingMergeTree(updated_date_time)",
+		SortingKey:   []string{"slot", "block_root"},
+		PartitionKey: "toStartOfMonth(slot_start_date_time)",
+	}
+	assert.Equal(t, []string{
+		"CREATE TABLE mainnet.fct_block",
+		"ENGINE = ReplacingMergeTree(updated_date_time)",
+		"PARTITION BY toStartOfMonth(slot_start_date_time)",
+		"ORDER BY (slot, block_root)",
+	}, DDLExcerpt(table))
+
+	view := &Table{Name: "v_block", Database: "mainnet", Engine: "View"}
+	assert.Equal(t, []string{"CREATE TABLE mainnet.v_block", "ENGINE = View"}, DDLExcerpt(view))
+}
+
+func TestFormatDDL_RoundTrip(t *testing.T) {
+	original, err := ParseDDL(testBeaconBlockDDL)
+	require.NoError(t, err)
+
+	statements := make([]string, 0, len(original))
+	for _, table := range original {
+		statements = append(statements, FormatDDL(table))
+	}
+
+	roundTripped, err := ParseDDL(strings.Join(statements, "\n"))
+	require.NoError(t, err)
+	require.Len(t, roundTripped, len(original))
+
+	for i, table := range original {
+		// Aggregate projections can't be reproduced from the model
+		expected := *table
+		expected.Projections = []Projection{}
+		for _, proj := range table.Projections {
+			if proj.Type != "Aggregate" {
+				expected.Projections = append(expected.Projections, proj)
+			}
+		}
+
+		assert.Equal(t, &expected, roundTripped[i], "table %s", table.Name)
+	}
+}
+
+func TestEngineDefinition(t *testing.T) {
+	tests := []struct {
+		engineFull string
+		expected   string
+	}{
+		{engineFull: "MergeTree ORDER BY id SETTINGS index_granularity = 8192", expected: "MergeTree"},
+		{engineFull: "ReplacingMergeTree(updated_at) PARTITION BY toYYYYMM(ts) ORDER BY (id, ts)", expected: "ReplacingMergeTree(updated_at)"},
+		{engineFull: "Distributed('default', 'db', 'users_local', rand())", expected: "Distributed('default', 'db', 'users_local', rand())"},
+		{engineFull: "Memory", expected: "Memory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, engineDefinition(tt.engineFull))
+		})
+	}
+}
+
+func TestFormatDDL_QuotedSortingKey(t *testing.T) {
+	original, err := ParseDDL("CREATE TABLE db.t (`block date` Date, `1col` UInt32, x String) ENGINE = MergeTree ORDER BY (`block date`, `1col`, toStartOfDay(`block date`))")
+	require.NoError(t, err)
+	require.Len(t, original, 1)
+
+	ddl := FormatDDL(original[0])
+	assert.Contains(t, ddl, "ORDER BY (`block date`, `1col`, toStartOfDay(`block date`))")
+
+	roundTripped, err := ParseDDL(ddl)
+	require.NoError(t, err)
+	assert.Equal(t, original[0].SortingKey, roundTripped[0].SortingKey)
+}
@@ -0,0 +1,266 @@
+package clickhouse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenWord   tokenKind = iota // Keywords, identifiers and numbers
+	tokenQuoted                  // `quoted` or "quoted" identifiers, text holds the unquoted name
+	tokenString                  // 'string' literals, text keeps the quotes
+	tokenSymbol                  // Punctuation and operators
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+// twoCharSymbols are operators kept together so expressions render as written
+//
+//nolint:gochecknoglobals // Read-only lookup table
+var twoCharSymbols = map[string]bool{
+	"!=": true, "<>": true, "<=": true, ">=": true, "==": true, "||": true, "->": true, "::": true,
+}
+
+// tokenizeDDL splits SQL into tokens, dropping whitespace and comments
+func tokenizeDDL(src string) ([]token, error) {
+	var tokens []token
+	line := 1
+
+	for i := 0; i < len(src); {
+		ch := src[i]
+
+		switch {
+		case ch == '\n':
+			line++
+			i++
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: line %d: unterminated comment", ErrDDLSyntax, line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case ch == '\'' || ch == '`' || ch == '"':
+			end, err := scanQuoted(src, i)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %w", ErrDDLSyntax, line, err)
+			}
+			tok := token{kind: tokenString, text: src[i:end], line: line}
+			if ch != '\'' {
+				tok = token{kind: tokenQuoted, text: unquote(src[i:end]), line: line}
+			}
+			tokens = append(tokens, tok)
+			line += strings.Count(src[i:end], "\n")
+			i = end
+		case isWordChar(ch):
+			start := i
+			for i < len(src) && isWordChar(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: src[start:i], line: line})
+		default:
+			width := 1
+			if i+1 < len(src) && twoCharSymbols[src[i:i+2]] {
+				width = 2
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: src[i : i+width], line: line})
+			i += width
+		}
+	}
+
+	return tokens, nil
+}
+
+// errUnterminatedString is wrapped into ErrDDLSyntax by the tokenizer
+var errUnterminatedString = errors.New("unterminated quoted string")
+
+// scanQuoted returns the index just past the quoted string starting at start.
+// Backslash escapes and doubled quotes are both supported.
+func scanQuoted(src string, start int) (int, error) {
+	quote := src[start]
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case quote:
+			if i+1 < len(src) && src[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+	return 0, errUnterminatedString
+}
+
+// unquote strips the quotes of a string literal or quoted identifier and resolves escapes
+func unquote(quoted string) string {
+	if len(quoted) < 2 {
+		return quoted
+	}
+
+	quote := quoted[0]
+	inner := quoted[1 : len(quoted)-1]
+
+	var sb strings.Builder
+	for i := 0; i < len(inner); i++ {
+		ch := inner[i]
+		switch {
+		case ch == '\\' && i+1 < len(inner):
+			i++
+			sb.WriteByte(unescape(inner[i]))
+		case ch == quote && i+1 < len(inner) && inner[i+1] == quote:
+			i++
+			sb.WriteByte(quote)
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
+
+func unescape(ch byte) byte {
+	switch ch {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	default:
+		return ch
+	}
+}
+
+func isWordChar(ch byte) bool {
+	return ch == '_' || ch == '$' || ch >= 0x80 ||
+		(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+// isKeyword reports whether the token is the given unquoted keyword (case-insensitive)
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (t token) isSymbol(symbol string) bool {
+	return t.kind == tokenSymbol && t.text == symbol
+}
+
+// joinTokens renders tokens back into normalized SQL text, e.g. "Decimal(18, 2)" or "toDate(ts)"
+func joinTokens(tokens []token) string {
+	var sb strings.Builder
+	for i, tok := range tokens {
+		if i > 0 && needsSpace(tokens[i-1], tok) {
+			sb.WriteByte(' ')
+		}
+		if tok.kind == tokenQuoted {
+			sb.WriteString(quoteIdentifier(tok.text))
+			continue
+		}
+		sb.WriteString(tok.text)
+	}
+	return sb.String()
+}
+
+func needsSpace(prev, cur token) bool {
+	switch {
+	case prev.isSymbol("(") || prev.isSymbol("[") || prev.isSymbol(".") || prev.isSymbol("::"):
+		return false
+	case cur.isSymbol(")") || cur.isSymbol("]") || cur.isSymbol(",") || cur.isSymbol(".") || cur.isSymbol("::"):
+		return false
+	case cur.isSymbol("(") || cur.isSymbol("["):
+		// Function calls, parameterized types and subscripts keep the bracket attached
+		return prev.kind == tokenSymbol && !prev.isSymbol(")") && !prev.isSymbol("]")
+	}
+	return true
+}
+
+// quoteIdentifier backticks an identifier unless it is a plain word
+func quoteIdentifier(name string) string {
+	plain := name != ""
+	for i := 0; i < len(name); i++ {
+		if !isWordChar(name[i]) || name[i] >= 0x80 {
+			plain = false
+			break
+		}
+	}
+	if plain && (name[0] < '0' || name[0] > '9') {
+		return name
+	}
+	return "`" + strings.ReplaceAll(strings.ReplaceAll(name, "\\", "\\\\"), "`", "\\`") + "`"
+}
+
+// splitTopLevel splits tokens on commas that are not nested in parentheses or brackets
+func splitTopLevel(tokens []token) [][]token {
+	var parts [][]token
+	depth, start := 0, 0
+
+	for i, tok := range tokens {
+		switch {
+		case tok.isSymbol("(") || tok.isSymbol("["):
+			depth++
+		case tok.isSymbol(")") || tok.isSymbol("]"):
+			depth--
+		case tok.isSymbol(",") && depth == 0:
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+
+	if start < len(tokens) {
+		parts = append(parts, tokens[start:])
+	}
+	return parts
+}
+
+// splitStatements splits tokens into statements on top-level semicolons
+func splitStatements(tokens []token) [][]token {
+	var statements [][]token
+	start := 0
+
+	for i, tok := range tokens {
+		if tok.isSymbol(";") {
+			if i > start {
+				statements = append(statements, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+	return statements
+}
+
+// matchingParen returns the index of the parenthesis closing the one at open, or -1
+func matchingParen(tokens []token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch {
+		case tokens[i].isSymbol("("):
+			depth++
+		case tokens[i].isSymbol(")"):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
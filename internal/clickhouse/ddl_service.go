@@ -0,0 +1,179 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DDL service errors
+var (
+	ErrNoDDLFiles    = errors.New("no DDL files matched")
+	ErrTableNotFound = errors.New("table not found in DDL files")
+)
+
+type ddlService struct {
+	patterns []string
+	tables   []*Table
+	log      logrus.FieldLogger
+	stdin    io.Reader // Read for the "-" pattern
+}
+
+// NewDDLService creates a Service that reads table schemas from CREATE TABLE statements
+// in .sql files instead of a live database. Patterns are file globs or directories; "-"
+// reads the statements from standard input.
+func NewDDLService(patterns []string, log logrus.FieldLogger) Service {
+	return &ddlService{
+		patterns: patterns,
+		log:      log.WithField("component", "ddl"),
+		stdin:    os.Stdin,
+	}
+}
+
+// Connect parses all DDL files; references between tables may span files
+func (s *ddlService) Connect(_ context.Context) error {
+	files, err := expandDDLPatterns(s.patterns)
+	if err != nil {
+		return err
+	}
+
+	var parsed []*ddlTable
+	for _, file := range files {
+		data, err := s.readFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read DDL file: %w", err)
+		}
+
+		tables, err := parseDDL(string(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		parsed = append(parsed, tables...)
+	}
+
+	s.tables, err = resolveDDLTables(parsed)
+	if err != nil {
+		return err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"files":  len(files),
+		"tables": len(s.tables),
+	}).Info("Loaded DDL files")
+
+	return nil
+}
+
+func (s *ddlService) Close() error {
+	return nil
+}
+
+func (s *ddlService) ListTables(_ context.Context) ([]string, error) {
+	tables := make([]string, 0, len(s.tables))
+	for _, table := range s.tables {
+		tables = append(tables, qualifiedTableName(table.Database, table.Name))
+	}
+	return tables, nil
+}
+
+func (s *ddlService) SummarizeTables(_ context.Context) ([]TableSummary, error) {
+	summaries := make([]TableSummary, 0, len(s.tables))
+	for _, table := range s.tables {
+		summaries = append(summaries, table.Summarize())
+	}
+	return summaries, nil
+}
+
+// GetTable returns a parsed table. A database-qualified match wins; otherwise a table with
+// the same name is used, since DDL files often leave out the database.
+func (s *ddlService) GetTable(_ context.Context, database, tableName string) (*Table, error) {
+	var match *Table
+	for _, table := range s.tables {
+		if table.Name != tableName {
+			continue
+		}
+		if table.Database == database {
+			match = table
+			break
+		}
+		if match == nil {
+			match = table
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, qualifiedTableName(database, tableName))
+	}
+
+	table := *match
+	if table.Database == "" {
+		table.Database = database
+	}
+	return &table, nil
+}
+
+func (s *ddlService) GetTables(ctx context.Context, database string, tableNames []string) ([]*Table, error) {
+	tables := make([]*Table, 0, len(tableNames))
+	for _, tableName := range tableNames {
+		table, err := s.GetTable(ctx, database, tableName)
+		if err != nil {
+			s.log.WithError(err).WithField("table", tableName).Warn("Failed to get table, skipping")
+			continue
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// expandDDLPatterns resolves globs and directories into a sorted, de-duplicated list of files
+func (s *ddlService) readFile(file string) ([]byte, error) {
+	if file == stdinPattern {
+		return io.ReadAll(s.stdin)
+	}
+	return os.ReadFile(filepath.Clean(file))
+}
+
+// stdinPattern is the DDL pattern standing for standard input
+const stdinPattern = "-"
+
+func expandDDLPatterns(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+
+	for _, pattern := range patterns {
+		if pattern == stdinPattern {
+			if !seen[pattern] {
+				seen[pattern] = true
+				files = append(files, pattern)
+			}
+			continue
+		}
+		if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+			pattern = filepath.Join(pattern, "*.sql")
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DDL pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrNoDDLFiles, patterns)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,103 @@
+package clickhouse
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDDLFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+func TestDDLService(t *testing.T) {
+	dir := t.TempDir()
+
+	// The Distributed table references a local table defined in another file
+	writeDDLFile(t, dir, "01_local.sql", `
+CREATE TABLE users_local (id UInt64, name String) ENGINE = MergeTree ORDER BY id;
+`)
+	writeDDLFile(t, dir, "02_distributed.sql", `
+CREATE TABLE analytics.users AS users_local ENGINE = Distributed(default, currentDatabase(), users_local, rand());
+`)
+	writeDDLFile(t, dir, "notes.txt", "not sql")
+
+	ctx := context.Background()
+	svc := NewDDLService([]string{dir}, logrus.New())
+	require.NoError(t, svc.Connect(ctx))
+	defer svc.Close()
+
+	tables, err := svc.ListTables(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users_local", "analytics.users"}, tables)
+
+	summaries, err := svc.SummarizeTables(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []TableSummary{
+		{Name: "users_local", Engine: "MergeTree", Columns: 2},
+		{Database: "analytics", Name: "users", Engine: "Distributed", Columns: 2},
+	}, summaries)
+
+	users, err := svc.GetTable(ctx, "analytics", "users")
+	require.NoError(t, err)
+	assert.Equal(t, "analytics", users.Database)
+	assert.Equal(t, []string{"id"}, users.SortingKey)
+	assert.Len(t, users.Columns, 2)
+
+	// Tables without a database in the DDL take the requested one
+	local, err := svc.GetTable(ctx, "default", "users_local")
+	require.NoError(t, err)
+	assert.Equal(t, "default", local.Database)
+
+	_, err = svc.GetTable(ctx, "default", "orders")
+	require.ErrorIs(t, err, ErrTableNotFound)
+}
+
+func TestDDLService_Stdin(t *testing.T) {
+	ctx := context.Background()
+	svc := NewDDLService([]string{"-"}, logrus.New())
+	svc.(*ddlService).stdin = strings.NewReader("CREATE TABLE users (id UInt64) ENGINE = MergeTree ORDER BY id;")
+	require.NoError(t, svc.Connect(ctx))
+	defer svc.Close()
+
+	tables, err := svc.ListTables(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users"}, tables)
+}
+
+func TestDDLService_ConnectErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeDDLFile(t, dir, "broken.sql", "CREATE TABLE t (a String COMMENT 'oops) ENGINE = Memory")
+
+	tests := []struct {
+		name        string
+		patterns    []string
+		expectedErr error
+	}{
+		{
+			name:        "No matching files",
+			patterns:    []string{filepath.Join(dir, "*.ddl")},
+			expectedErr: ErrNoDDLFiles,
+		},
+		{
+			name:        "Syntax error",
+			patterns:    []string{filepath.Join(dir, "*.sql")},
+			expectedErr: ErrDDLSyntax,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewDDLService(tt.patterns, logrus.New())
+			err := svc.Connect(context.Background())
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBeaconBlockDDL = `
+-- Beacon blocks, one row per slot
+CREATE DATABASE IF NOT EXISTS default;
+
+CREATE TABLE IF NOT EXISTS default.beacon_block_local ON CLUSTER '{cluster}'
+(
+    ` + "`updated_date_time`" + ` DateTime COMMENT 'When this row was last updated' CODEC(DoubleDelta, ZSTD(1)),
+    slot UInt32 COMMENT 'The slot number' CODEC(DoubleDelta, ZSTD(1)),
+    block_root FixedString(66) COMMENT 'It''s the block root',
+    proposer_index Nullable(UInt32),
+    graffiti String NULL DEFAULT '' COMMENT 'Proposer graffiti',
+    tags Array(LowCardinality(String)) DEFAULT [],
+    amount Decimal(18,2),
+    status Enum8('ok' = 1, 'orphaned' = 2),
+    seen_at DateTime64(3, 'UTC') MATERIALIZED now64(3) TTL seen_at + INTERVAL 1 DAY,
+    INDEX idx_slot slot TYPE minmax GRANULARITY 1,
+    PROJECTION p_by_root (SELECT * ORDER BY (block_root, slot)),
+    PROJECTION p_count (SELECT slot, count() GROUP BY slot)
+) ENGINE = ReplicatedReplacingMergeTree('/clickhouse/{cluster}/tables/{shard}/{table}', '{replica}', updated_date_time)
+PARTITION BY toStartOfMonth(seen_at)
+ORDER BY (slot, block_root DESC)
+SETTINGS index_granularity = 8192
+COMMENT 'Beacon blocks';
+
+CREATE TABLE default.beacon_block ON CLUSTER '{cluster}' AS default.beacon_block_local
+ENGINE = Distributed('{cluster}', default, beacon_block_local, cityHash64(slot));
+
+/* Materialized views are not parsed */
+CREATE MATERIALIZED VIEW default.beacon_block_mv TO default.beacon_block_local AS
+SELECT * FROM default.beacon_block_raw ORDER BY slot;
+`
+
+func TestParseDDL(t *testing.T) {
+	tables, err := ParseDDL(testBeaconBlockDDL)
+	require.NoError(t, err)
+	require.Len(t, tables, 2)
+
+	local := tables[0]
+	assert.Equal(t, "default", local.Database)
+	assert.Equal(t, "beacon_block_local", local.Name)
+	assert.Equal(t, "Beacon blocks", local.Comment)
+	assert.Equal(t, []string{"slot", "block_root"}, local.SortingKey)
+	assert.Equal(t, []Projection{
+		{Name: "p_by_root", OrderByKey: []string{"block_root", "slot"}, Type: "Normal"},
+		{Name: "p_count", OrderByKey: []string{"slot"}, Type: "Aggregate"},
+	}, local.Projections)
+
+	assert.Equal(t, []Column{
+		{Name: "updated_date_time", Type: "DateTime", Comment: "When this row was last updated", Position: 1, BaseType: "DateTime"},
+		{Name: "slot", Type: "UInt32", Comment: "The slot number", Position: 2, BaseType: "UInt32"},
+		{Name: "block_root", Type: "FixedString(66)", Comment: "It's the block root", Position: 3, BaseType: "FixedString"},
+		{Name: "proposer_index", Type: "Nullable(UInt32)", Position: 4, IsNullable: true, BaseType: "UInt32"},
+		{Name: "graffiti", Type: "Nullable(String)", DefaultKind: "DEFAULT", DefaultValue: "''", Comment: "Proposer graffiti", Position: 5, IsNullable: true, BaseType: "String"},
+		{Name: "tags", Type: "Array(LowCardinality(String))", DefaultKind: "DEFAULT", DefaultValue: "[]", Position: 6, IsArray: true, BaseType: "String"},
+		{Name: "amount", Type: "Decimal(18, 2)", Position: 7, BaseType: "Decimal"},
+		{Name: "status", Type: "Enum8('ok' = 1, 'orphaned' = 2)", Position: 8, BaseType: "Enum8"},
+		{Name: "seen_at", Type: "DateTime64(3, 'UTC')", DefaultKind: "MATERIALIZED", DefaultValue: "now64(3)", Position: 9, BaseType: "DateTime64"},
+	}, local.Columns)
+
+	// The Distributed table copies columns via AS and sorting key and projections from its local table
+	distributed := tables[1]
+	assert.Equal(t, "beacon_block", distributed.Name)
+	assert.Equal(t, local.Columns, distributed.Columns)
+	assert.Equal(t, local.SortingKey, distributed.SortingKey)
+	assert.Equal(t, local.Projections, distributed.Projections)
+	assert.Empty(t, distributed.Comment)
+}
+
+func TestParseDDL_SortingKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		ddl      string
+		expected []string
+	}{
+		{
+			name:     "Single column",
+			ddl:      "CREATE TABLE t (a UInt64) ENGINE = MergeTree ORDER BY a",
+			expected: []string{"a"},
+		},
+		{
+			name:     "Expression",
+			ddl:      "CREATE TABLE t (a UInt64, ts DateTime) ENGINE = MergeTree ORDER BY (toStartOfDay(ts), a)",
+			expected: []string{"toStartOfDay(ts)", "a"},
+		},
+		{
+			name:     "Empty tuple",
+			ddl:      "CREATE TABLE t (a UInt64) ENGINE = MergeTree ORDER BY tuple()",
+			expected: []string{},
+		},
+		{
+			name:     "Primary key without ORDER BY",
+			ddl:      "CREATE TABLE t (a UInt64, b String) ENGINE = MergeTree PRIMARY KEY (a, b)",
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "Primary key in column list",
+			ddl:      "CREATE TABLE t (a UInt64, b String, PRIMARY KEY (b)) ENGINE = MergeTree",
+			expected: []string{"b"},
+		},
+		{
+			name:     "Quoted column with a space",
+			ddl:      "CREATE TABLE t (`block date` Date, `x` String) ENGINE = MergeTree ORDER BY (`block date`, x)",
+			expected: []string{"block date", "x"},
+		},
+		{
+			name:     "Quoted column starting with a digit",
+			ddl:      "CREATE TABLE t (`1col` UInt32, y String) ENGINE = MergeTree ORDER BY (`1col`)",
+			expected: []string{"1col"},
+		},
+		{
+			name:     "No sorting key",
+			ddl:      "CREATE TABLE t (a UInt64) ENGINE = Memory",
+			expected: []string{},
+		},
+		{
+			name:     "AS SELECT clauses are ignored",
+			ddl:      "CREATE TABLE t (a UInt64) ENGINE = MergeTree ORDER BY a AS SELECT a FROM s ORDER BY b",
+			expected: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables, err := ParseDDL(tt.ddl)
+			require.NoError(t, err)
+			require.Len(t, tables, 1)
+			assert.Equal(t, tt.expected, tables[0].SortingKey)
+		})
+	}
+}
+
+func TestParseDDL_SamplingKey(t *testing.T) {
+	tables, err := ParseDDL(`
+CREATE TABLE default.events_local (id UInt64, user_id UInt64)
+ENGINE = MergeTree ORDER BY (id, intHash32(user_id)) SAMPLE BY intHash32(user_id);
+CREATE TABLE default.events_copy AS default.events_local;
+CREATE TABLE default.events AS default.events_local ENGINE = Distributed('{cluster}', default, events_local, rand());
+CREATE TABLE default.plain (id UInt64) ENGINE = MergeTree ORDER BY id;
+`)
+	require.NoError(t, err)
+	require.Len(t, tables, 4)
+
+	assert.Equal(t, "intHash32(user_id)", tables[0].SamplingKey)
+	assert.Equal(t, "intHash32(user_id)", tables[1].SamplingKey, "AS copies the sampling key with the engine")
+	assert.Equal(t, "intHash32(user_id)", tables[2].SamplingKey, "Distributed tables sample their local table")
+	assert.Empty(t, tables[3].SamplingKey)
+}
+
+func TestParseDDL_PartitionKey(t *testing.T) {
+	tables, err := ParseDDL(`
+CREATE TABLE default.events_local (id UInt64, ts DateTime)
+ENGINE = MergeTree PARTITION BY toYYYYMM(ts) ORDER BY id;
+CREATE TABLE default.events AS default.events_local ENGINE = Distributed('{cluster}', default, events_local, rand());
+CREATE TABLE default.plain (id UInt64) ENGINE = MergeTree ORDER BY id;
+`)
+	require.NoError(t, err)
+	require.Len(t, tables, 3)
+
+	assert.Equal(t, "toYYYYMM(ts)", tables[0].PartitionKey)
+	assert.Equal(t, "toYYYYMM(ts)", tables[1].PartitionKey, "Distributed tables take the key of their local table")
+	assert.Empty(t, tables[2].PartitionKey)
+}
+
+func TestParseDDL_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		ddl         string
+		expectedErr error
+	}{
+		{
+			name:        "Unterminated string",
+			ddl:         "CREATE TABLE t (a String COMMENT 'oops) ENGINE = Memory",
+			expectedErr: ErrDDLSyntax,
+		},
+		{
+			name:        "Column without type",
+			ddl:         "CREATE TABLE t (a DEFAULT 1) ENGINE = Memory",
+			expectedErr: ErrDDLSyntax,
+		},
+		{
+			name:        "Table without columns",
+			ddl:         "CREATE TABLE t ENGINE = MergeTree ORDER BY a AS SELECT 1 AS a",
+			expectedErr: ErrDDLSyntax,
+		},
+		{
+			name:        "AS unknown table",
+			ddl:         "CREATE TABLE t AS missing ENGINE = Memory",
+			expectedErr: ErrDDLReference,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDDL(tt.ddl)
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestJoinTokens(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "Decimal( 18 ,2 )", expected: "Decimal(18, 2)"},
+		{input: "Map(String,Array(UInt64))", expected: "Map(String, Array(UInt64))"},
+		{input: "Enum8('a'=1,'b'=2)", expected: "Enum8('a' = 1, 'b' = 2)"},
+		{input: "a * (b+c)", expected: "a * (b + c)"},
+		{input: "arr[1]", expected: "arr[1]"},
+		{input: "`my col`.x", expected: "`my col`.x"},
+		{input: "x -> x != 0", expected: "x -> x != 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := tokenizeDDL(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, joinTokens(tokens))
+		})
+	}
+}
+
+func TestParseDDL_Views(t *testing.T) {
+	const ddl = `
+CREATE TABLE default.blocks (
+    slot UInt32 COMMENT 'The slot number',
+    ts DateTime,
+    proposer String,
+    fee Nullable(UInt64) DEFAULT NULL
+) ENGINE = MergeTree ORDER BY slot;
+
+CREATE TABLE default.proposers (proposer String, name String) ENGINE = MergeTree ORDER BY proposer;
+
+CREATE VIEW default.block_summary AS
+SELECT DISTINCT
+    b.slot,
+    toDate(b.ts) AS day,
+    p.name AS proposer_name,
+    CAST(b.fee AS Decimal(38, 0)) AS fee,
+    b.ts::Date32 AS day32
+FROM default.blocks AS b
+LEFT JOIN proposers p ON p.proposer = b.proposer
+WHERE b.slot > 0;
+
+CREATE VIEW daily AS SELECT day, count() AS blocks, max(slot) AS last_slot FROM block_summary GROUP BY day;
+
+CREATE LIVE VIEW everything WITH REFRESH 5 AS SELECT * FROM blocks;
+
+CREATE VIEW explicit (a UInt8) AS SELECT 1 AS a;
+`
+	tables, err := ParseDDL(ddl)
+	require.NoError(t, err)
+	require.Len(t, tables, 6)
+
+	summary := tables[2]
+	assert.Equal(t, EngineView, summary.Engine)
+	assert.Empty(t, summary.SortingKey)
+	assert.Equal(t, []Column{
+		{Name: "slot", Type: "UInt32", Comment: "The slot number", Position: 1, BaseType: "UInt32"},
+		{Name: "day", Type: "Date", Position: 2, BaseType: "Date"},
+		{Name: "proposer_name", Type: "String", Position: 3, BaseType: "String"},
+		{Name: "fee", Type: "Decimal(38, 0)", Position: 4, BaseType: "Decimal"},
+		{Name: "day32", Type: "Date32", Position: 5, BaseType: "Date32"},
+	}, summary.Columns)
+
+	// Views reading from views are resolved after the views they read
+	daily := tables[3]
+	assert.Equal(t, []Column{
+		{Name: "day", Type: "Date", Position: 1, BaseType: "Date"},
+		{Name: "blocks", Type: "UInt64", Position: 2, BaseType: "UInt64"},
+		{Name: "last_slot", Type: "UInt32", Position: 3, BaseType: "UInt32"},
+	}, daily.Columns)
+
+	everything := tables[4]
+	assert.Equal(t, EngineLiveView, everything.Engine)
+	require.Len(t, everything.Columns, 4)
+	assert.Equal(t, "fee", everything.Columns[3].Name)
+	assert.Empty(t, everything.Columns[3].DefaultKind, "view columns have no defaults")
+
+	assert.Equal(t, []Column{{Name: "a", Type: "UInt8", Position: 1, BaseType: "UInt8"}}, tables[5].Columns)
+}
+
+func TestParseDDL_ViewErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		ddl         string
+		expectedErr error
+	}{
+		{
+			name:        "Expression without alias",
+			ddl:         "CREATE TABLE t (a UInt8) ENGINE = Memory; CREATE VIEW v AS SELECT toString(a) FROM t",
+			expectedErr: ErrDDLView,
+		},
+		{
+			name:        "Type that can't be inferred",
+			ddl:         "CREATE TABLE t (a UInt8) ENGINE = Memory; CREATE VIEW v AS SELECT a + 1 AS b FROM t",
+			expectedErr: ErrDDLView,
+		},
+		{
+			name:        "Unknown source table",
+			ddl:         "CREATE VIEW v AS SELECT a FROM missing",
+			expectedErr: ErrDDLReference,
+		},
+		{
+			name:        "Star over a subquery",
+			ddl:         "CREATE VIEW v AS SELECT * FROM (SELECT 1 AS a)",
+			expectedErr: ErrDDLView,
+		},
+		{
+			name:        "View without query",
+			ddl:         "CREATE VIEW v (a UInt8)",
+			expectedErr: ErrDDLSyntax,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDDL(tt.ddl)
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
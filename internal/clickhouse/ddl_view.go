@@ -0,0 +1,427 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// View engines as reported by system.tables
+const (
+	EngineView             = "View"
+	EngineLiveView         = "LiveView"
+	EngineMaterializedView = "MaterializedView"
+)
+
+// IsView reports whether the table is a view rather than a table storing data itself
+func IsView(table *Table) bool {
+	name := table.Engine
+	if idx := strings.IndexByte(name, '('); idx >= 0 {
+		name = name[:idx]
+	}
+	switch strings.TrimSpace(name) {
+	case EngineView, EngineLiveView, EngineMaterializedView:
+		return true
+	}
+	return false
+}
+
+// viewSourceEnd are the top-level keywords that end the FROM clause of a SELECT
+//
+//nolint:gochecknoglobals // Read-only lookup table
+var viewSourceEnd = map[string]bool{
+	"WHERE": true, "PREWHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true,
+	"SETTINGS": true, "UNION": true, "EXCEPT": true, "INTERSECT": true, "FORMAT": true,
+	"QUALIFY": true, "WINDOW": true, "SAMPLE": true, "COMMENT": true,
+}
+
+// joinKeywords may precede JOIN in a FROM clause and are never table aliases
+//
+//nolint:gochecknoglobals // Read-only lookup table
+var joinKeywords = map[string]bool{
+	"GLOBAL": true, "ANY": true, "ALL": true, "ASOF": true, "SEMI": true, "ANTI": true, "INNER": true,
+	"LEFT": true, "RIGHT": true, "FULL": true, "OUTER": true, "CROSS": true, "PASTE": true,
+	"JOIN": true, "ON": true, "USING": true, "FINAL": true, "ARRAY": true,
+}
+
+// typeConversions maps conversion functions to the type they return
+//
+//nolint:gochecknoglobals // Read-only lookup table
+var typeConversions = map[string]string{
+	"toUInt8": "UInt8", "toUInt16": "UInt16", "toUInt32": "UInt32", "toUInt64": "UInt64",
+	"toUInt128": "UInt128", "toUInt256": "UInt256",
+	"toInt8": "Int8", "toInt16": "Int16", "toInt32": "Int32", "toInt64": "Int64",
+	"toInt128": "Int128", "toInt256": "Int256",
+	"toFloat32": "Float32", "toFloat64": "Float64", "toString": "String", "toUUID": "UUID", "toBool": "Bool",
+	"toDate": "Date", "toDate32": "Date32", "toDateTime": "DateTime",
+	"toStartOfYear": "Date", "toStartOfQuarter": "Date", "toStartOfMonth": "Date", "toMonday": "Date",
+	"toStartOfWeek": "Date", "toStartOfDay": "DateTime", "toStartOfHour": "DateTime",
+	"toStartOfMinute": "DateTime", "toStartOfFiveMinutes": "DateTime", "toStartOfFifteenMinutes": "DateTime",
+	"count": "UInt64", "countIf": "UInt64", "uniq": "UInt64", "uniqExact": "UInt64", "uniqCombined": "UInt64",
+	"uniqHLL12": "UInt64", "length": "UInt64", "now": "DateTime",
+}
+
+// passthroughFunctions return a value of the same type as their first argument
+//
+//nolint:gochecknoglobals // Read-only lookup table
+var passthroughFunctions = map[string]bool{
+	"min": true, "max": true, "any": true, "anyLast": true, "argMin": true, "argMax": true,
+	"minIf": true, "maxIf": true, "anyIf": true, "argMinIf": true, "argMaxIf": true,
+	"assumeNotNull": true, "ifNull": true, "coalesce": true,
+}
+
+// parseView parses the rest of `CREATE [LIVE] VIEW [IF NOT EXISTS] [db.]name [UUID 'uuid']
+// [ON CLUSTER cluster] [(columns)] [WITH REFRESH ...] AS SELECT ...`. Without an explicit
+// column list, the columns are derived from the query once all tables are known.
+func (p *ddlParser) parseView(engine string) (*ddlTable, error) {
+	dt, err := p.tableHeader()
+	if err != nil {
+		return nil, err
+	}
+	name := dt.table.Name
+	dt.engine = engine
+	dt.table.Engine = engine
+
+	if p.peek().isSymbol("(") {
+		end := matchingParen(p.tokens, p.pos)
+		if end < 0 {
+			return nil, p.errorf("unbalanced parentheses in view %s", name)
+		}
+		if err := dt.parseElements(p.tokens[p.pos+1 : end]); err != nil {
+			return nil, fmt.Errorf("view %s: %w", name, err)
+		}
+		p.pos = end + 1
+	}
+
+	as := findKeywords(p.tokens[p.pos:], "AS")
+	if as < 0 || p.pos+as+1 >= len(p.tokens) {
+		return nil, p.errorf("view %s has no AS SELECT query", name)
+	}
+	if len(dt.table.Columns) == 0 {
+		dt.viewQuery = p.tokens[p.pos+as+1:]
+	}
+	return dt, nil
+}
+
+// viewSource is a table read by a view query, under the alias or name the query uses
+type viewSource struct {
+	alias string
+	table *ddlTable
+}
+
+// resolveDDLViews derives the columns of views defined without a column list from their
+// SELECT list. Views may read from other views, which are resolved first.
+func resolveDDLViews(parsed []*ddlTable, lookup func(ref, defaultDatabase string) *ddlTable) error {
+	resolving := make(map[*ddlTable]bool)
+
+	var resolve func(dt *ddlTable) error
+	resolve = func(dt *ddlTable) error {
+		if dt.viewQuery == nil {
+			return nil
+		}
+		if resolving[dt] {
+			return fmt.Errorf("%w: view %s reads from itself", ErrDDLView, dt.table.Name)
+		}
+		resolving[dt] = true
+
+		selectList, from := splitViewQuery(dt.viewQuery)
+		sources, err := viewSources(from, dt.table.Database, lookup)
+		if err != nil {
+			return fmt.Errorf("view %s: %w", dt.table.Name, err)
+		}
+		for _, src := range sources {
+			if src.table != nil {
+				if err := resolve(src.table); err != nil {
+					return err
+				}
+			}
+		}
+
+		columns, err := viewColumns(selectList, sources)
+		if err != nil {
+			return fmt.Errorf("view %s: %w", dt.table.Name, err)
+		}
+		dt.table.Columns = columns
+		dt.viewQuery = nil
+		return nil
+	}
+
+	for _, dt := range parsed {
+		if err := resolve(dt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitViewQuery returns the SELECT list and FROM clause of the first SELECT in a view query.
+// A leading WITH clause and DISTINCT are skipped; the FROM clause is empty for SELECT without FROM.
+func splitViewQuery(query []token) (selectList, from []token) {
+	if len(query) > 0 && query[0].isSymbol("(") {
+		if end := matchingParen(query, 0); end > 0 {
+			query = query[1:end]
+		}
+	}
+
+	start := findKeywords(query, "SELECT")
+	if start < 0 {
+		return nil, nil
+	}
+	query = query[start+1:]
+	if len(query) > 0 && query[0].isKeyword("DISTINCT") {
+		query = query[1:]
+	}
+
+	fromIdx := findKeywords(query, "FROM")
+	if fromIdx < 0 {
+		return query[:sourceEnd(query)], nil
+	}
+	from = query[fromIdx+1:]
+	return query[:fromIdx], from[:sourceEnd(from)]
+}
+
+// sourceEnd returns the index of the first top-level keyword ending a FROM clause
+func sourceEnd(tokens []token) int {
+	depth := 0
+	for i, tok := range tokens {
+		switch {
+		case tok.isSymbol("("):
+			depth++
+		case tok.isSymbol(")"):
+			depth--
+		case depth == 0 && tok.kind == tokenWord && viewSourceEnd[strings.ToUpper(tok.text)]:
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// viewSources parses the table references of a FROM clause: the first table and every
+// joined table, each with an optional alias. Subqueries and table functions are kept as
+// sources without a table so that columns taken from them are reported, not guessed.
+func viewSources(from []token, defaultDatabase string, lookup func(ref, defaultDatabase string) *ddlTable) ([]viewSource, error) {
+	var sources []viewSource
+
+	for i := 0; i < len(from); {
+		if i > 0 {
+			next := findKeywords(from[i:], "JOIN")
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+		if i >= len(from) {
+			break
+		}
+
+		src, end, err := viewSourceAt(from, i, defaultDatabase, lookup)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+		i = end
+	}
+	return sources, nil
+}
+
+// viewSourceAt parses the table reference starting at from[i], returning the index after it
+func viewSourceAt(from []token, i int, defaultDatabase string, lookup func(ref, defaultDatabase string) *ddlTable) (viewSource, int, error) {
+	var src viewSource
+
+	switch {
+	case from[i].isSymbol("("):
+		end := matchingParen(from, i)
+		if end < 0 {
+			return src, 0, fmt.Errorf("%w: unbalanced parentheses in FROM", ErrDDLSyntax)
+		}
+		i = end + 1
+	case i+1 < len(from) && from[i+1].isSymbol("("):
+		// Table function such as numbers(10) or remote(...)
+		end := matchingParen(from, i+1)
+		if end < 0 {
+			return src, 0, fmt.Errorf("%w: unbalanced parentheses in FROM", ErrDDLSyntax)
+		}
+		src.alias = from[i].text
+		i = end + 1
+	default:
+		ref := from[i].text
+		src.alias = ref
+		i++
+		if i+1 < len(from) && from[i].isSymbol(".") {
+			ref += "." + from[i+1].text
+			src.alias = from[i+1].text
+			i += 2
+		}
+		src.table = lookup(ref, defaultDatabase)
+		if src.table == nil {
+			return src, 0, fmt.Errorf("%w: reads from unknown table %s", ErrDDLReference, ref)
+		}
+	}
+
+	if i < len(from) && from[i].isKeyword("AS") {
+		i++
+	}
+	if i < len(from) && (from[i].kind == tokenQuoted || from[i].kind == tokenWord && !joinKeywords[strings.ToUpper(from[i].text)]) {
+		src.alias = from[i].text
+		i++
+	}
+	return src, i, nil
+}
+
+// viewColumns derives columns from a SELECT list. Plain column references keep the name,
+// type and comment of the source column; other expressions need an alias and a type that
+// can be inferred from CAST, a conversion function or an aggregate over a known column.
+func viewColumns(selectList []token, sources []viewSource) ([]Column, error) {
+	var columns []Column
+	add := func(col Column) {
+		col.Position = uint64(len(columns) + 1) //nolint:gosec // Column count is small
+		columns = append(columns, col)
+	}
+
+	for _, item := range splitTopLevel(selectList) {
+		if len(item) == 0 {
+			continue
+		}
+
+		// * and alias.*
+		if n := len(item); item[n-1].isSymbol("*") && (n == 1 || n == 3 && item[1].isSymbol(".")) {
+			expanded, err := expandStar(item, sources)
+			if err != nil {
+				return nil, err
+			}
+			for _, col := range expanded {
+				add(col)
+			}
+			continue
+		}
+
+		expr, name := item, ""
+		if n := len(item); n > 2 && item[n-2].isKeyword("AS") && (item[n-1].kind == tokenWord || item[n-1].kind == tokenQuoted) {
+			expr, name = item[:n-2], item[n-1].text
+		}
+
+		col, ok := referencedColumn(expr, sources)
+		if !ok {
+			chType, inferred := inferExprType(expr, sources)
+			if !inferred {
+				return nil, fmt.Errorf("%w: cannot infer the type of %s, wrap it in CAST(... AS Type) or list the view's columns",
+					ErrDDLView, joinTokens(expr))
+			}
+			col = NewColumn(name, chType, 0)
+		}
+		if name == "" {
+			if !ok {
+				return nil, fmt.Errorf("%w: expression %s needs an alias", ErrDDLView, joinTokens(expr))
+			}
+			name = col.Name
+		}
+		col.Name = name
+		add(col)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: the query selects no columns", ErrDDLView)
+	}
+	return columns, nil
+}
+
+// expandStar returns the columns selected by `*` or `alias.*`
+func expandStar(item []token, sources []viewSource) ([]Column, error) {
+	var columns []Column
+	for _, src := range sources {
+		if len(item) == 3 && item[1].isSymbol(".") && item[0].text != src.alias {
+			continue
+		}
+		if src.table == nil {
+			return nil, fmt.Errorf("%w: cannot expand %s over a subquery or table function", ErrDDLView, joinTokens(item))
+		}
+		for _, col := range src.table.table.Columns {
+			col.DefaultKind, col.DefaultValue = "", ""
+			columns = append(columns, col)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s matches no columns", ErrDDLView, joinTokens(item))
+	}
+	return columns, nil
+}
+
+// referencedColumn resolves `column` or `alias.column` to the source column it names
+func referencedColumn(expr []token, sources []viewSource) (Column, bool) {
+	alias, name := "", ""
+	switch {
+	case len(expr) == 1 && (expr[0].kind == tokenWord || expr[0].kind == tokenQuoted):
+		name = expr[0].text
+	case len(expr) == 3 && expr[1].isSymbol(".") && expr[2].kind != tokenSymbol:
+		alias, name = expr[0].text, expr[2].text
+	default:
+		return Column{}, false
+	}
+
+	for _, src := range sources {
+		if src.table == nil || alias != "" && alias != src.alias {
+			continue
+		}
+		for _, col := range src.table.table.Columns {
+			if col.Name == name {
+				col.DefaultKind, col.DefaultValue = "", ""
+				return col, true
+			}
+		}
+	}
+	return Column{}, false
+}
+
+// inferExprType infers the result type of CAST(x AS T), x::T, conversion functions and
+// aggregates that return their argument's type
+func inferExprType(expr []token, sources []viewSource) (string, bool) {
+	if idx := findSymbol(expr, "::"); idx > 0 && idx+1 < len(expr) {
+		return joinTokens(expr[idx+1:]), true
+	}
+	if len(expr) < 3 || expr[0].kind != tokenWord || !expr[1].isSymbol("(") || matchingParen(expr, 1) != len(expr)-1 {
+		return "", false
+	}
+
+	function, args := expr[0].text, splitTopLevel(expr[2:len(expr)-1])
+	switch {
+	case strings.EqualFold(function, "CAST") && len(args) == 1:
+		if as := findKeywords(args[0], "AS"); as > 0 && as+1 < len(args[0]) {
+			return castType(args[0][as+1:]), true
+		}
+	case strings.EqualFold(function, "CAST") && len(args) == 2:
+		return castType(args[1]), true
+	case function == "toDateTime64" && len(args) >= 2:
+		return "DateTime64(" + joinTokens(args[1]) + ")", true
+	case typeConversions[function] != "":
+		return typeConversions[function], true
+	case passthroughFunctions[function] && len(args) > 0:
+		if col, ok := referencedColumn(args[0], sources); ok {
+			return col.Type, true
+		}
+		return inferExprType(args[0], sources)
+	}
+	return "", false
+}
+
+// castType returns the target type of a CAST, given as a type or a string literal
+func castType(tokens []token) string {
+	if len(tokens) == 1 && tokens[0].kind == tokenString {
+		return unquote(tokens[0].text)
+	}
+	return joinTokens(tokens)
+}
+
+// findSymbol returns the index of a top-level symbol, or -1
+func findSymbol(tokens []token, symbol string) int {
+	depth := 0
+	for i, tok := range tokens {
+		switch {
+		case tok.isSymbol("("):
+			depth++
+		case tok.isSymbol(")"):
+			depth--
+		case depth == 0 && tok.isSymbol(symbol):
+			return i
+		}
+	}
+	return -1
+}
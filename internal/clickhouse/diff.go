@@ -0,0 +1,135 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TableDiff lists how a table's columns changed between a schema snapshot and its current
+// definition
+type TableDiff struct {
+	Table *Table // Current definition
+	// New is set when the snapshot has no table of this name; the columns aren't listed then
+	New     bool
+	Added   []Column
+	Removed []Column // As defined in the snapshot
+	Changed []ColumnChange
+	Moved   []ColumnMove
+}
+
+// ColumnChange is a column whose type changed
+type ColumnChange struct {
+	Name    string
+	OldType string
+	NewType string
+}
+
+// ColumnMove is a column whose position changed, e.g. because a column was added before it
+type ColumnMove struct {
+	Name        string
+	OldPosition uint64
+	NewPosition uint64
+}
+
+// Empty reports whether the table is unchanged
+func (d *TableDiff) Empty() bool {
+	return !d.New && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Moved) == 0
+}
+
+// DiffTables compares each current table with the snapshot table of the same name. Tables
+// are matched by name only, so a snapshot exported from another database still applies.
+// Snapshot tables without a current counterpart are ignored, since the current tables are
+// usually a selection. Unchanged tables are left out.
+func DiffTables(snapshot, current []*Table) []TableDiff {
+	previous := make(map[string]*Table, len(snapshot))
+	for _, table := range snapshot {
+		previous[table.Name] = table
+	}
+
+	var diffs []TableDiff
+	for _, table := range current {
+		old, ok := previous[table.Name]
+		if !ok {
+			diffs = append(diffs, TableDiff{Table: table, New: true})
+			continue
+		}
+
+		diff := diffColumns(old, table)
+		if !diff.Empty() {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+func diffColumns(old, current *Table) TableDiff {
+	diff := TableDiff{Table: current}
+
+	oldColumns := make(map[string]*Column, len(old.Columns))
+	for i := range old.Columns {
+		oldColumns[old.Columns[i].Name] = &old.Columns[i]
+	}
+	currentColumns := make(map[string]bool, len(current.Columns))
+
+	for _, column := range current.Columns {
+		currentColumns[column.Name] = true
+		previous, ok := oldColumns[column.Name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, column)
+		case previous.Type != column.Type:
+			diff.Changed = append(diff.Changed, ColumnChange{Name: column.Name, OldType: previous.Type, NewType: column.Type})
+		}
+		if ok && previous.Position != column.Position {
+			diff.Moved = append(diff.Moved, ColumnMove{Name: column.Name, OldPosition: previous.Position, NewPosition: column.Position})
+		}
+	}
+
+	for _, column := range old.Columns {
+		if !currentColumns[column.Name] {
+			diff.Removed = append(diff.Removed, column)
+		}
+	}
+	return diff
+}
+
+// FormatMigration renders ALTER TABLE statements that bring a table matching the snapshot
+// to its current definition. New columns are added after the column preceding them in the
+// current table. The result is a stub to review: a type change may need a conversion, and
+// dropping a column loses its data.
+func FormatMigration(diff *TableDiff) string {
+	var sb strings.Builder
+	table := formatTableName(diff.Table.Database, diff.Table.Name)
+
+	fmt.Fprintf(&sb, "-- Migration stub for %s: review before applying\n", diff.Table.Name)
+	if diff.New {
+		sb.WriteString("-- The table is not in the snapshot\n")
+		sb.WriteString(FormatDDL(diff.Table))
+		return sb.String()
+	}
+
+	for _, column := range diff.Added {
+		fmt.Fprintf(&sb, "ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s%s;\n", table, formatColumnDefinition(&column), previousColumnClause(diff.Table, column.Name))
+	}
+	for _, change := range diff.Changed {
+		fmt.Fprintf(&sb, "ALTER TABLE %s MODIFY COLUMN %s %s; -- was %s\n", table, quoteColumnName(change.Name), change.NewType, change.OldType)
+	}
+	for _, column := range diff.Removed {
+		fmt.Fprintf(&sb, "ALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", table, quoteColumnName(column.Name))
+	}
+	return sb.String()
+}
+
+// previousColumnClause returns the AFTER or FIRST clause placing a column where it is in the table
+func previousColumnClause(table *Table, name string) string {
+	for i, column := range table.Columns {
+		if column.Name != name {
+			continue
+		}
+		if i == 0 {
+			return " FIRST"
+		}
+		return " AFTER " + quoteColumnName(table.Columns[i-1].Name)
+	}
+	return ""
+}
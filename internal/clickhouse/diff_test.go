@@ -0,0 +1,90 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTables(t *testing.T) {
+	snapshot := []*Table{
+		{
+			Name:     "transfers",
+			Database: "staging",
+			Columns: []Column{
+				NewColumn("block_number", "UInt64", 1),
+				NewColumn("amount", "Float32", 2),
+				NewColumn("memo", "String", 3),
+			},
+		},
+		{Name: "unchanged", Columns: []Column{NewColumn("id", "UInt64", 1)}},
+		{Name: "not_selected", Columns: []Column{NewColumn("id", "UInt64", 1)}},
+	}
+	current := []*Table{
+		{
+			Name:     "transfers",
+			Database: "default",
+			Columns: []Column{
+				NewColumn("block_number", "UInt64", 1),
+				NewColumn("log_index", "UInt32", 2),
+				NewColumn("amount", "Float64", 3),
+			},
+		},
+		{Name: "unchanged", Columns: []Column{NewColumn("id", "UInt64", 1)}},
+		{Name: "labels", Columns: []Column{NewColumn("id", "UInt64", 1)}},
+	}
+
+	diffs := DiffTables(snapshot, current)
+	require.Len(t, diffs, 2)
+
+	transfers := diffs[0]
+	assert.Same(t, current[0], transfers.Table)
+	assert.False(t, transfers.New)
+	require.Len(t, transfers.Added, 1)
+	assert.Equal(t, "log_index", transfers.Added[0].Name)
+	require.Len(t, transfers.Removed, 1)
+	assert.Equal(t, uint64(3), transfers.Removed[0].Position, "removed columns keep their snapshot position")
+	assert.Equal(t, []ColumnChange{{Name: "amount", OldType: "Float32", NewType: "Float64"}}, transfers.Changed)
+	assert.Equal(t, []ColumnMove{{Name: "amount", OldPosition: 2, NewPosition: 3}}, transfers.Moved)
+
+	assert.Equal(t, "labels", diffs[1].Table.Name)
+	assert.True(t, diffs[1].New)
+	assert.False(t, diffs[1].Empty())
+}
+
+func TestFormatMigration(t *testing.T) {
+	table := &Table{
+		Name:     "transfers",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []Column{
+			{Name: "index", Type: "UInt32", Comment: "Log index", Position: 1},
+			{Name: "block_number", Type: "UInt64", Position: 2},
+			{Name: "fee", Type: "Nullable(Int64)", DefaultKind: "DEFAULT", DefaultValue: "NULL", Position: 3},
+		},
+		SortingKey: []string{"block_number"},
+	}
+
+	t.Run("Changed table", func(t *testing.T) {
+		diff := &TableDiff{
+			Table:   table,
+			Added:   []Column{table.Columns[0], table.Columns[2]},
+			Removed: []Column{{Name: "memo", Type: "String", Position: 3}},
+			Changed: []ColumnChange{{Name: "block_number", OldType: "UInt32", NewType: "UInt64"}},
+		}
+
+		expected := "-- Migration stub for transfers: review before applying\n" +
+			"ALTER TABLE default.transfers ADD COLUMN IF NOT EXISTS `index` UInt32 COMMENT 'Log index' FIRST;\n" +
+			"ALTER TABLE default.transfers ADD COLUMN IF NOT EXISTS `fee` Nullable(Int64) DEFAULT NULL AFTER `block_number`;\n" +
+			"ALTER TABLE default.transfers MODIFY COLUMN `block_number` UInt64; -- was UInt32\n" +
+			"ALTER TABLE default.transfers DROP COLUMN IF EXISTS `memo`;\n"
+		assert.Equal(t, expected, FormatMigration(diff))
+	})
+
+	t.Run("New table", func(t *testing.T) {
+		migration := FormatMigration(&TableDiff{Table: table, New: true})
+		assert.Contains(t, migration, "-- The table is not in the snapshot\n")
+		assert.Contains(t, migration, FormatDDL(table))
+	})
+}
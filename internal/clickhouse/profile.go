@@ -0,0 +1,91 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxSafeInteger is the largest integer a float64, and so a JavaScript number, holds exactly
+const maxSafeInteger = "9007199254740991"
+
+// profileRowLimit caps the rows a profiling query reads from a table
+const profileRowLimit = 10_000_000
+
+// ValueProfiler is implemented by services connected to a ClickHouse server, which can read
+// the data of a table besides its schema
+type ValueProfiler interface {
+	// UnsafeIntegerColumns returns the Int64/UInt64 columns of a table holding values beyond
+	// ±2^53. Only the first rows of large tables are read, and tables whose engine doesn't
+	// store data, like views or Kafka, are never queried.
+	UnsafeIntegerColumns(ctx context.Context, table *Table) ([]string, error)
+}
+
+// UnsafeIntegerColumns implements ValueProfiler
+func (s *service) UnsafeIntegerColumns(ctx context.Context, table *Table) ([]string, error) {
+	columns := profiledColumns(table)
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	flags := make([]uint8, len(columns))
+	dest := make([]any, len(columns))
+	for i := range flags {
+		dest[i] = &flags[i]
+	}
+	if err := s.conn.QueryRow(ctx, unsafeIntegerQuery(table, columns)).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to profile %s.%s: %w", table.Database, table.Name, err)
+	}
+
+	var unsafe []string
+	for i, col := range columns {
+		if flags[i] != 0 {
+			unsafe = append(unsafe, col.Name)
+		}
+	}
+	return unsafe, nil
+}
+
+// profiledColumns returns the Int64/UInt64 columns of a table worth profiling, none when
+// its engine doesn't store data
+func profiledColumns(table *Table) []Column {
+	engine := table.Summarize().Engine
+	if !strings.Contains(engine, "MergeTree") && engine != "Distributed" {
+		return nil
+	}
+
+	var columns []Column
+	for _, col := range table.Columns {
+		if col.BaseType == "Int64" || col.BaseType == "UInt64" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// unsafeIntegerQuery builds a query returning, for each column, whether one of the first
+// profileRowLimit rows holds a value beyond ±2^53. Limiting a subquery rather than setting
+// max_rows_to_read keeps the query runnable for readonly=1 users.
+func unsafeIntegerQuery(table *Table, columns []Column) string {
+	names := make([]string, len(columns))
+	exprs := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quoteColumnName(col.Name)
+		if col.IsArray {
+			exprs[i] = fmt.Sprintf("toUInt8(countIf(arrayExists(x -> %s, %s)) > 0)", unsafeIntegerCondition(col, "x"), names[i])
+		} else {
+			exprs[i] = fmt.Sprintf("toUInt8(countIf(%s) > 0)", unsafeIntegerCondition(col, names[i]))
+		}
+	}
+
+	return fmt.Sprintf("SELECT %s FROM (SELECT %s FROM %s LIMIT %d)",
+		strings.Join(exprs, ", "), strings.Join(names, ", "), formatTableName(table.Database, table.Name), profileRowLimit)
+}
+
+// unsafeIntegerCondition compares a value of an Int64/UInt64 column against ±2^53
+func unsafeIntegerCondition(col Column, value string) string {
+	if col.BaseType == "Int64" {
+		return fmt.Sprintf("%s > %s OR %s < -%s", value, maxSafeInteger, value, maxSafeInteger)
+	}
+	return fmt.Sprintf("%s > %s", value, maxSafeInteger)
+}
@@ -0,0 +1,40 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnsafeIntegerQuery(t *testing.T) {
+	table := &Table{
+		Name:     "fct_block",
+		Database: "mainnet",
+		Engine:   "ReplacingMergeTree(updated_date_time)",
+		Columns: []Column{
+			NewColumn("slot", "UInt32", 1),
+			NewColumn("value", "UInt64", 2),
+			NewColumn("delta", "Nullable(Int64)", 3),
+			NewColumn("fees", "Array(UInt64)", 4),
+		},
+	}
+
+	columns := profiledColumns(table)
+	assert.Len(t, columns, 3)
+	assert.Equal(t,
+		"SELECT toUInt8(countIf(`value` > 9007199254740991) > 0), "+
+			"toUInt8(countIf(`delta` > 9007199254740991 OR `delta` < -9007199254740991) > 0), "+
+			"toUInt8(countIf(arrayExists(x -> x > 9007199254740991, `fees`)) > 0) "+
+			"FROM (SELECT `value`, `delta`, `fees` FROM mainnet.fct_block LIMIT 10000000)",
+		unsafeIntegerQuery(table, columns))
+}
+
+func TestProfiledColumns_SkipsEnginesWithoutData(t *testing.T) {
+	for _, engine := range []string{"View", "MaterializedView", "Kafka('broker', 'topic', 'group', 'JSONEachRow')"} {
+		table := &Table{Name: "t", Engine: engine, Columns: []Column{NewColumn("value", "UInt64", 1)}}
+		assert.Empty(t, profiledColumns(table), engine)
+	}
+
+	table := &Table{Name: "t", Engine: "Distributed('cluster', 'db', 't_local')", Columns: []Column{NewColumn("value", "UInt64", 1)}}
+	assert.Len(t, profiledColumns(table), 1)
+}
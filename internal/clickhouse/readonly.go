@@ -0,0 +1,152 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrWritableUser is returned by Connect when read-only enforcement requires a user without
+// write access and the DSN's user has some
+var ErrWritableUser = errors.New("the ClickHouse user can modify data")
+
+// readOnlyLevel is the readonly setting the schema queries run with. Level 1 would also
+// refuse the limits sent along with each query; level 2 refuses writes and DDL just the same.
+const readOnlyLevel = 2
+
+// ReadOnlyOptions restrict the connection schemas are read through
+type ReadOnlyOptions struct {
+	// Settings are sent with every schema query in addition to readonly, e.g.
+	// max_execution_time. Users whose profile already sets readonly=1 can't change settings,
+	// so they are dropped for them.
+	Settings map[string]string
+	// RequireUser fails Connect when the user has write grants, instead of logging a warning
+	RequireUser bool
+}
+
+// WithReadOnly runs the schema queries in a read-only session and checks that the user
+// can't modify data, so the DSN can carry an account that is safe to hand to CI
+func WithReadOnly(opts ReadOnlyOptions) ServiceOption {
+	return func(s *service) {
+		s.readOnly = &opts
+	}
+}
+
+// settingsConn sends settings with every query of a connection
+type settingsConn struct {
+	driver.Conn
+	settings clickhouse.Settings
+}
+
+func (c *settingsConn) context(ctx context.Context) context.Context {
+	return clickhouse.Context(ctx, clickhouse.WithSettings(c.settings))
+}
+
+func (c *settingsConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	return c.Conn.Query(c.context(ctx), query, args...)
+}
+
+func (c *settingsConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	return c.Conn.QueryRow(c.context(ctx), query, args...)
+}
+
+func (c *settingsConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	return c.Conn.Select(c.context(ctx), dest, query, args...)
+}
+
+func (c *settingsConn) Exec(ctx context.Context, query string, args ...any) error {
+	return c.Conn.Exec(c.context(ctx), query, args...)
+}
+
+// restrict checks the user of a new connection and returns the connection with the
+// read-only session settings applied
+func (s *service) restrict(ctx context.Context, conn driver.Conn) (driver.Conn, error) {
+	var user string
+	var level uint8
+	if err := conn.QueryRow(ctx, "SELECT currentUser(), toUInt8(getSetting('readonly'))").Scan(&user, &level); err != nil {
+		return nil, fmt.Errorf("failed to read session settings: %w", err)
+	}
+
+	log := s.log.WithFields(logrus.Fields{"user": user, "readonly": level})
+	if level == 0 {
+		grants, err := writeGrants(ctx, conn)
+		switch {
+		case err != nil && s.readOnly.RequireUser:
+			return nil, fmt.Errorf("failed to check the grants of user %s: %w", user, err)
+		case err != nil:
+			log.WithError(err).Warn("Could not check the grants of the ClickHouse user")
+		case len(grants) > 0 && s.readOnly.RequireUser:
+			return nil, fmt.Errorf("%w: %s has %s; use a user limited to reading schemas (see \"Read-only Introspection\" in the README)",
+				ErrWritableUser, user, strings.Join(grants, ", "))
+		case len(grants) > 0:
+			log.WithField("grants", strings.Join(grants, ", ")).Warn("The ClickHouse user can modify data; schema queries run read-only, but a read-only user is safer")
+		}
+	}
+
+	settings := sessionSettings(level, s.readOnly.Settings)
+	log.WithField("settings", settings).Debug("Restricted the ClickHouse session")
+	if len(settings) == 0 {
+		return conn, nil
+	}
+	return &settingsConn{Conn: conn, settings: settings}, nil
+}
+
+// sessionSettings returns the settings sent with every schema query, given the readonly
+// level of the user's profile. readonly=1 forbids changing any setting, and readonly=2 all
+// but readonly itself.
+func sessionSettings(level uint8, extra map[string]string) clickhouse.Settings {
+	settings := make(clickhouse.Settings)
+	switch level {
+	case 0:
+		settings["readonly"] = readOnlyLevel
+	case 1:
+		return settings
+	}
+	for name, value := range extra {
+		if name != "readonly" {
+			settings[name] = value
+		}
+	}
+	return settings
+}
+
+// writeGrantsQuery lists the grants of the current user and its enabled roles allowing
+// changes to data or schemas
+const writeGrantsQuery = `
+	SELECT DISTINCT access_type
+	FROM system.grants
+	WHERE (user_name = currentUser() OR role_name IN (SELECT role_name FROM system.enabled_roles))
+	  AND NOT is_partial_revoke
+	  AND (access_type IN ('ALL', 'INSERT', 'TRUNCATE', 'OPTIMIZE', 'ALTER', 'CREATE', 'DROP', 'UNDROP TABLE', 'BACKUP', 'SYSTEM', 'KILL QUERY')
+	       OR startsWith(access_type, 'ALTER ')
+	       OR startsWith(access_type, 'CREATE ')
+	       OR startsWith(access_type, 'DROP '))
+`
+
+// writeGrants returns the write access types granted to the current user
+func writeGrants(ctx context.Context, conn driver.Conn) ([]string, error) {
+	rows, err := conn.Query(ctx, writeGrantsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query grants: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var grants []string
+	for rows.Next() {
+		var grant string
+		if err := rows.Scan(&grant); err != nil {
+			return nil, fmt.Errorf("failed to scan grant: %w", err)
+		}
+		grants = append(grants, grant)
+	}
+	sort.Strings(grants)
+	return grants, rows.Err()
+}
@@ -0,0 +1,54 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionSettings(t *testing.T) {
+	extra := map[string]string{"max_execution_time": "60", "readonly": "0"}
+
+	tests := []struct {
+		name     string
+		level    uint8
+		expected clickhouse.Settings
+	}{
+		{
+			name:     "Writable session gets readonly and the limits",
+			level:    0,
+			expected: clickhouse.Settings{"readonly": readOnlyLevel, "max_execution_time": "60"},
+		},
+		{
+			name:     "readonly=1 profile can't change settings",
+			level:    1,
+			expected: clickhouse.Settings{},
+		},
+		{
+			name:     "readonly=2 profile keeps its level and gets the limits",
+			level:    2,
+			expected: clickhouse.Settings{"max_execution_time": "60"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sessionSettings(tt.level, extra))
+		})
+	}
+}
+
+func TestWithReadOnly(t *testing.T) {
+	svc, ok := NewService("clickhouse://localhost:9000/test", logrus.New(),
+		WithReadOnly(ReadOnlyOptions{RequireUser: true})).(*service)
+	require.True(t, ok)
+	require.NotNil(t, svc.readOnly)
+	assert.True(t, svc.readOnly.RequireUser)
+
+	plain, ok := NewService("clickhouse://localhost:9000/test", logrus.New()).(*service)
+	require.True(t, ok)
+	assert.Nil(t, plain.readOnly, "services writing data, like mock-data, aren't restricted")
+}
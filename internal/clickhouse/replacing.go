@@ -0,0 +1,44 @@
+package clickhouse
+
+import "strings"
+
+// Replacing describes how a ReplacingMergeTree table deduplicates rows with the same sorting
+// key when it merges parts
+type Replacing struct {
+	// VersionColumn holds the version of each row; the row with the highest version is kept.
+	// Empty when the engine has none, keeping the last inserted row.
+	VersionColumn string
+	// IsDeletedColumn marks rows as deleted with 1, hiding the key from FINAL reads. Empty
+	// when the engine has none.
+	IsDeletedColumn string
+}
+
+// TableReplacing returns the deduplication of ReplacingMergeTree and
+// ReplicatedReplacingMergeTree tables, and false for tables with other engines
+func TableReplacing(table *Table) (Replacing, bool) {
+	tokens, err := tokenizeDDL(table.Engine)
+	if err != nil || len(tokens) == 0 {
+		return Replacing{}, false
+	}
+
+	name, args := parseEngineDefinition(tokens)
+	switch name {
+	case "ReplacingMergeTree":
+	case "ReplicatedReplacingMergeTree":
+		// The ZooKeeper path and replica name come first, unless left to their defaults
+		for len(args) > 0 && strings.HasPrefix(strings.TrimSpace(args[0]), "'") {
+			args = args[1:]
+		}
+	default:
+		return Replacing{}, false
+	}
+
+	var replacing Replacing
+	if len(args) > 0 {
+		replacing.VersionColumn = unquoteEngineArg(args[0])
+	}
+	if len(args) > 1 {
+		replacing.IsDeletedColumn = unquoteEngineArg(args[1])
+	}
+	return replacing, true
+}
@@ -0,0 +1,35 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableReplacing(t *testing.T) {
+	tests := []struct {
+		engine      string
+		expected    Replacing
+		isReplacing bool
+	}{
+		{engine: "MergeTree"},
+		{engine: "Distributed('prod', 'analytics', 'events_local', rand())"},
+		{engine: "ReplacingMergeTree", isReplacing: true},
+		{engine: "ReplacingMergeTree(updated_date_time)", expected: Replacing{VersionColumn: "updated_date_time"}, isReplacing: true},
+		{engine: "ReplacingMergeTree(version, is_deleted)", expected: Replacing{VersionColumn: "version", IsDeletedColumn: "is_deleted"}, isReplacing: true},
+		{
+			engine:      "ReplicatedReplacingMergeTree('/clickhouse/{shard}/events', '{replica}', `version`)",
+			expected:    Replacing{VersionColumn: "version"},
+			isReplacing: true,
+		},
+		{engine: "ReplicatedReplacingMergeTree(version)", expected: Replacing{VersionColumn: "version"}, isReplacing: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			replacing, ok := TableReplacing(&Table{Engine: tt.engine})
+			assert.Equal(t, tt.isReplacing, ok)
+			assert.Equal(t, tt.expected, replacing)
+		})
+	}
+}
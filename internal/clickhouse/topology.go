@@ -0,0 +1,58 @@
+package clickhouse
+
+import "strings"
+
+// Table topology kinds
+const (
+	TopologyLocal       = "local"       // Table stored on the node it is queried on
+	TopologyReplicated  = "replicated"  // Replicated* engine, copies kept in sync across replicas
+	TopologyDistributed = "distributed" // Distributed engine fanning queries out to local tables
+)
+
+// Topology describes where a table's data lives in a cluster
+type Topology struct {
+	Kind string
+	// Distributed tables only: the cluster queries fan out to, the table queried on each
+	// shard and the sharding key, if any
+	Cluster       string
+	LocalDatabase string
+	LocalTable    string
+	ShardingKey   string
+}
+
+// TableTopology derives the topology of a table from its engine definition. The local
+// database of a Distributed table defaults to the table's own database when the engine
+// uses currentDatabase() or an empty database.
+func TableTopology(table *Table) Topology {
+	tokens, err := tokenizeDDL(table.Engine)
+	if err != nil || len(tokens) == 0 {
+		return Topology{Kind: TopologyLocal}
+	}
+
+	name, args := parseEngineDefinition(tokens)
+	switch {
+	case name == "Distributed" && len(args) >= 3:
+		topology := Topology{
+			Kind:          TopologyDistributed,
+			Cluster:       unquoteEngineArg(args[0]),
+			LocalDatabase: unquoteEngineArg(args[1]),
+			LocalTable:    unquoteEngineArg(args[2]),
+		}
+		if topology.LocalDatabase == "" || topology.LocalDatabase == "currentDatabase()" {
+			topology.LocalDatabase = table.Database
+		}
+		if len(args) >= 4 {
+			topology.ShardingKey = args[3]
+		}
+		return topology
+	case strings.HasPrefix(name, "Replicated"):
+		return Topology{Kind: TopologyReplicated}
+	default:
+		return Topology{Kind: TopologyLocal}
+	}
+}
+
+// unquoteEngineArg strips quotes from a string or identifier engine argument
+func unquoteEngineArg(arg string) string {
+	return strings.Trim(strings.TrimSpace(arg), "'`\"")
+}
@@ -0,0 +1,58 @@
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableTopology(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    Table
+		expected Topology
+	}{
+		{
+			name:     "MergeTree table",
+			table:    Table{Database: "db", Name: "events", Engine: "MergeTree"},
+			expected: Topology{Kind: TopologyLocal},
+		},
+		{
+			name:     "No engine",
+			table:    Table{Database: "db", Name: "events"},
+			expected: Topology{Kind: TopologyLocal},
+		},
+		{
+			name:     "Replicated table",
+			table:    Table{Database: "db", Name: "events", Engine: "ReplicatedReplacingMergeTree('/clickhouse/{shard}/events', '{replica}', version)"},
+			expected: Topology{Kind: TopologyReplicated},
+		},
+		{
+			name:  "Distributed table with sharding key",
+			table: Table{Database: "db", Name: "events", Engine: "Distributed('prod', 'analytics', 'events_local', cityHash64(id))"},
+			expected: Topology{
+				Kind:          TopologyDistributed,
+				Cluster:       "prod",
+				LocalDatabase: "analytics",
+				LocalTable:    "events_local",
+				ShardingKey:   "cityHash64(id)",
+			},
+		},
+		{
+			name:  "Distributed table in the current database",
+			table: Table{Database: "db", Name: "events", Engine: "Distributed(prod, currentDatabase(), events_local)"},
+			expected: Topology{
+				Kind:          TopologyDistributed,
+				Cluster:       "prod",
+				LocalDatabase: "db",
+				LocalTable:    "events_local",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TableTopology(&tt.table))
+		})
+	}
+}
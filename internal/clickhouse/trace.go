@@ -0,0 +1,124 @@
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/sirupsen/logrus"
+)
+
+// WithQueryTrace logs every query the service runs at info level, with its bound arguments,
+// duration and the number of rows it returned
+func WithQueryTrace() ServiceOption {
+	return func(s *service) {
+		s.trace = true
+	}
+}
+
+// tracingConn logs the queries of a connection
+type tracingConn struct {
+	driver.Conn
+	log logrus.FieldLogger
+}
+
+func (c *tracingConn) entry(query string, args []any) *logrus.Entry {
+	fields := logrus.Fields{"sql": strings.Join(strings.Fields(query), " ")}
+	if len(args) > 0 {
+		fields["args"] = args
+	}
+	return c.log.WithFields(fields)
+}
+
+func (c *tracingConn) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.Conn.Query(ctx, query, args...)
+	entry := c.entry(query, args)
+	if err != nil {
+		entry.WithError(err).WithField("duration", time.Since(start)).Info("SQL query failed")
+		return nil, err
+	}
+	return &tracingRows{Rows: rows, entry: entry, start: start}, nil
+}
+
+func (c *tracingConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	start := time.Now()
+	row := c.Conn.QueryRow(ctx, query, args...)
+	return &tracingRow{Row: row, entry: c.entry(query, args).WithField("duration", time.Since(start))}
+}
+
+func (c *tracingConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	start := time.Now()
+	err := c.Conn.Select(ctx, dest, query, args...)
+	c.entry(query, args).WithError(err).WithField("duration", time.Since(start)).Info("SQL query")
+	return err
+}
+
+func (c *tracingConn) Exec(ctx context.Context, query string, args ...any) error {
+	start := time.Now()
+	err := c.Conn.Exec(ctx, query, args...)
+	c.entry(query, args).WithError(err).WithField("duration", time.Since(start)).Info("SQL query")
+	return err
+}
+
+// tracingRows logs its query once the rows are closed, when their number is known
+type tracingRows struct {
+	driver.Rows
+	entry  *logrus.Entry
+	start  time.Time
+	count  int
+	logged bool
+}
+
+func (r *tracingRows) Next() bool {
+	next := r.Rows.Next()
+	if next {
+		r.count++
+	}
+	return next
+}
+
+func (r *tracingRows) Close() error {
+	err := r.Rows.Close()
+	if !r.logged {
+		r.logged = true
+		entry := r.entry.WithFields(logrus.Fields{"duration": time.Since(r.start), "rows": r.count})
+		if rowsErr := r.Rows.Err(); rowsErr != nil {
+			entry = entry.WithError(rowsErr)
+		}
+		entry.Info("SQL query")
+	}
+	return err
+}
+
+// tracingRow logs its query when it is scanned, noting queries that found no row
+type tracingRow struct {
+	driver.Row
+	entry *logrus.Entry
+}
+
+func (r *tracingRow) Scan(dest ...any) error {
+	err := r.Row.Scan(dest...)
+	r.log(err)
+	return err
+}
+
+func (r *tracingRow) ScanStruct(dest any) error {
+	err := r.Row.ScanStruct(dest)
+	r.log(err)
+	return err
+}
+
+func (r *tracingRow) log(err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		r.entry.WithField("rows", 0).Info("SQL query")
+	case err != nil:
+		r.entry.WithError(err).Info("SQL query failed")
+	default:
+		r.entry.WithField("rows", 1).Info("SQL query")
+	}
+}
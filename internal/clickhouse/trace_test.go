@@ -0,0 +1,92 @@
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn answers queries with a fixed number of rows, or no row for QueryRow when rows is 0
+type fakeConn struct {
+	driver.Conn
+	rows int
+}
+
+func (c *fakeConn) Query(context.Context, string, ...any) (driver.Rows, error) {
+	return &fakeRows{remaining: c.rows}, nil
+}
+
+func (c *fakeConn) QueryRow(context.Context, string, ...any) driver.Row {
+	return &fakeRow{empty: c.rows == 0}
+}
+
+type fakeRows struct {
+	driver.Rows
+	remaining int
+}
+
+func (r *fakeRows) Next() bool {
+	r.remaining--
+	return r.remaining >= 0
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Err() error { return nil }
+
+type fakeRow struct {
+	driver.Row
+	empty bool
+}
+
+func (r *fakeRow) Scan(...any) error {
+	if r.empty {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func TestTracingConn(t *testing.T) {
+	t.Run("Query logs on close with the row count", func(t *testing.T) {
+		log, hook := test.NewNullLogger()
+		conn := &tracingConn{Conn: &fakeConn{rows: 3}, log: log}
+
+		rows, err := conn.Query(context.Background(), "SELECT name\n\tFROM system.columns\n\tWHERE table = ?", "users")
+		require.NoError(t, err)
+		read := 0
+		for rows.Next() {
+			read++
+		}
+		assert.Equal(t, 3, read)
+		assert.Empty(t, hook.Entries, "nothing is logged before the rows are read")
+		require.NoError(t, rows.Close())
+		require.NoError(t, rows.Close())
+
+		require.Len(t, hook.Entries, 1)
+		entry := hook.LastEntry()
+		assert.Equal(t, "SELECT name FROM system.columns WHERE table = ?", entry.Data["sql"])
+		assert.Equal(t, []any{"users"}, entry.Data["args"])
+		assert.Equal(t, 3, entry.Data["rows"])
+		assert.Contains(t, entry.Data, "duration")
+	})
+
+	t.Run("QueryRow logs queries without a row", func(t *testing.T) {
+		log, hook := test.NewNullLogger()
+		conn := &tracingConn{Conn: &fakeConn{}, log: log}
+
+		var key string
+		err := conn.QueryRow(context.Background(), "SELECT sorting_key FROM system.tables").Scan(&key)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		require.Len(t, hook.Entries, 1)
+		entry := hook.LastEntry()
+		assert.Equal(t, 0, entry.Data["rows"])
+		assert.NotContains(t, entry.Data, "args")
+		assert.NotContains(t, entry.Data, "error")
+	})
+}
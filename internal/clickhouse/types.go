@@ -1,14 +1,39 @@
 // Package clickhouse provides types and utilities for interacting with ClickHouse databases
 package clickhouse
 
+import "strings"
+
+// TableSummary describes a table without its schema, for listing tables before picking
+// which ones to load
+type TableSummary struct {
+	Database string
+	Name     string
+	Engine   string // Engine name without arguments, e.g. "ReplacingMergeTree"
+	Columns  int
+}
+
+// Summarize returns the summary of a loaded table
+func (t *Table) Summarize() TableSummary {
+	engine, _, _ := strings.Cut(t.Engine, "(")
+	return TableSummary{
+		Database: t.Database,
+		Name:     t.Name,
+		Engine:   strings.TrimSpace(engine),
+		Columns:  len(t.Columns),
+	}
+}
+
 // Table represents a ClickHouse table structure with its columns and metadata
 type Table struct {
-	Name        string
-	Database    string
-	Comment     string
-	Columns     []Column
-	SortingKey  []string // ORDER BY columns
-	Projections []Projection
+	Name         string
+	Database     string
+	Comment      string
+	Engine       string // Engine with its arguments, e.g. "ReplacingMergeTree(updated_date_time)"
+	Columns      []Column
+	SortingKey   []string // ORDER BY columns
+	SamplingKey  string   // SAMPLE BY expression, empty when the table can't be sampled
+	PartitionKey string   // PARTITION BY expression, empty when the table isn't partitioned
+	Projections  []Projection
 }
 
 // Column represents a ClickHouse table column with its properties
@@ -24,6 +49,13 @@ type Column struct {
 	BaseType     string
 }
 
+// NewColumn creates a column and derives its nullable, array and base type properties from chType
+func NewColumn(name, chType string, position uint64) Column {
+	col := Column{Name: name, Type: chType, Position: position}
+	setTypeInfo(&col)
+	return col
+}
+
 // TableMetadata contains additional metadata about a ClickHouse table
 type TableMetadata struct {
 	Database    string
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Errors and warnings of config file checks, wrapped in a FileError giving their position
+var (
+	ErrUnknownKey         = errors.New("unknown key")
+	ErrWrongType          = errors.New("wrong type")
+	ErrConflictingKeys    = errors.New("conflicting options")
+	ErrNestedProfile      = errors.New("profiles can't define profiles")
+	ErrNoAPITables        = errors.New("enable_api is set but no table in tables matches api_table_prefixes, so no API is generated")
+	ErrDeprecatedPrefixes = errors.New("api_table_prefixes is deprecated, use policies with api: true")
+	ErrUnmatchedPolicy    = errors.New("policy matches none of the tables")
+)
+
+// FileError is a problem with a setting at a position of the config file. It prints as
+// file:line:column like compiler errors, so editors and terminals can jump to it.
+type FileError struct {
+	File   string
+	Line   int
+	Column int
+	Path   string // Dotted path of the setting, e.g. field_numbers.strategy
+	Err    error
+}
+
+func (e *FileError) Error() string {
+	position := fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	if e.File != "" {
+		position = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %v", position, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", position, e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+func nodeError(node *yaml.Node, path string, err error) error {
+	return &FileError{Line: node.Line, Column: node.Column, Path: path, Err: err}
+}
+
+// checkFile checks a parsed config file against the Config type, reporting every unknown
+// key and value of the wrong type
+func checkFile(doc *yaml.Node, file string) error {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+
+	errs := checkNode(doc.Content[0], reflect.TypeOf(Config{}), "")
+	for _, err := range errs {
+		var fileErr *FileError
+		if errors.As(err, &fileErr) {
+			fileErr.File = file
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkNode checks a node against the Go type it is decoded into
+func checkNode(node *yaml.Node, t reflect.Type, path string) []error {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+
+	if t == reflect.TypeOf(yaml.Node{}) {
+		return checkProfile(node, path)
+	}
+	if t.Kind() == reflect.Pointer {
+		return checkNode(node, t.Elem(), path)
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		if node.Kind == yaml.ScalarNode {
+			if _, err := time.ParseDuration(node.Value); err == nil {
+				return nil
+			}
+		}
+		return []error{wrongType(node, path, "a duration like 500ms or 2s")}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return checkStruct(node, t, path)
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return []error{wrongType(node, path, "a mapping")}
+		}
+		var errs []error
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, checkNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+		return errs
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return []error{wrongType(node, path, "a list")}
+		}
+		var errs []error
+		for i, item := range node.Content {
+			errs = append(errs, checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return errs
+	default:
+		if err := checkScalar(node, t, path); err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+}
+
+func checkStruct(node *yaml.Node, t reflect.Type, path string) []error {
+	if node.Kind != yaml.MappingNode {
+		return []error{wrongType(node, path, "a mapping")}
+	}
+
+	fields := make(map[string]reflect.Type)
+	keys := make([]string, 0, t.NumField())
+	for _, field := range yamlFields(t) {
+		fields[field.Key] = field.Type
+		keys = append(keys, field.Key)
+	}
+
+	var errs []error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			err := fmt.Errorf("%w %q", ErrUnknownKey, key.Value)
+			if suggestion := closestKey(key.Value, keys); suggestion != "" {
+				err = fmt.Errorf("%w (did you mean %q?)", err, suggestion)
+			}
+			errs = append(errs, nodeError(key, path, err))
+			continue
+		}
+		errs = append(errs, checkNode(node.Content[i+1], fieldType, joinPath(path, key.Value))...)
+	}
+	return errs
+}
+
+// checkProfile checks the settings of a profile, which are those of the config file
+// except for further profiles
+func checkProfile(node *yaml.Node, path string) []error {
+	if node.Kind != yaml.MappingNode {
+		return []error{wrongType(node, path, "a mapping")}
+	}
+
+	settings := &yaml.Node{Kind: yaml.MappingNode, Line: node.Line, Column: node.Column}
+	var errs []error
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "profiles" {
+			errs = append(errs, nodeError(node.Content[i], path, ErrNestedProfile))
+			continue
+		}
+		settings.Content = append(settings.Content, node.Content[i], node.Content[i+1])
+	}
+	return append(errs, checkStruct(settings, reflect.TypeOf(Config{}), path)...)
+}
+
+// checkScalar checks a scalar setting. Strings take any scalar, like the decoder does.
+func checkScalar(node *yaml.Node, t reflect.Type, path string) error {
+	if node.Kind != yaml.ScalarNode {
+		return wrongType(node, path, "a "+scalarName(t))
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(node.Value) {
+		case "true", "false", "yes", "no", "on", "off", "y", "n":
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if node.Tag == "!!int" {
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if node.Tag == "!!int" || node.Tag == "!!float" {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return wrongType(node, path, "a "+scalarName(t))
+}
+
+func scalarName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "whole number"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+func wrongType(node *yaml.Node, path, expected string) error {
+	got := "a list"
+	switch node.Kind {
+	case yaml.MappingNode:
+		got = "a mapping"
+	case yaml.ScalarNode:
+		got = fmt.Sprintf("%q", node.Value)
+	}
+	return nodeError(node, path, fmt.Errorf("%w: expected %s, got %s", ErrWrongType, expected, got))
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closestKey suggests the known key nearest to an unknown one, if any is close enough to
+// be a typo
+func closestKey(key string, keys []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range keys {
+		if d := editDistance(strings.ToLower(key), candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// checkSettings reports settings that contradict each other. It runs on the merged config
+// files with the profile applied, before command-line flags are merged in, and reports
+// each problem in the file the setting came from.
+func checkSettings(root *yaml.Node, sources map[*yaml.Node]string) error {
+	var cfg Config
+	if err := root.Decode(&cfg); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	enableAPI := mappingValue(root, "enable_api")
+	if enableAPI != nil && cfg.EnableAPI && len(cfg.Emit) > 0 && !cfg.Emits(EmitREST) {
+		return sourceError(enableAPI, sources, "enable_api", fmt.Errorf("%w: enable_api is set but emit leaves out the %s section", ErrConflictingKeys, EmitREST))
+	}
+	return nil
+}
+
+// settingWarnings reports settings that are valid but likely mistakes, such as API prefixes
+// or policies matching none of the listed tables
+func settingWarnings(root *yaml.Node, sources map[*yaml.Node]string) []error {
+	var cfg Config
+	if root.Decode(&cfg) != nil {
+		return nil
+	}
+
+	var warnings []error
+	if prefixes := mappingValue(root, "api_table_prefixes"); prefixes != nil && len(cfg.APITablePrefixes) > 0 {
+		warnings = append(warnings, sourceError(prefixes, sources, "api_table_prefixes", ErrDeprecatedPrefixes))
+		if cfg.EnableAPI && len(cfg.Tables) > 0 && !slices.ContainsFunc(cfg.Tables, func(table string) bool {
+			return slices.ContainsFunc(cfg.APITablePrefixes, func(prefix string) bool {
+				return strings.HasPrefix(tableName(table), prefix)
+			})
+		}) {
+			warnings = append(warnings, sourceError(prefixes, sources, "api_table_prefixes", ErrNoAPITables))
+		}
+	}
+
+	policies := mappingValue(root, "policies")
+	if policies == nil || len(cfg.Tables) == 0 || len(policies.Content) != len(cfg.Policies) {
+		return warnings
+	}
+	for i, policy := range cfg.Policies {
+		if policy.Match == "" || slices.ContainsFunc(cfg.Tables, func(table string) bool { return policy.Matches(tableName(table)) }) {
+			continue
+		}
+		match := mappingValue(policies.Content[i], "match")
+		warnings = append(warnings, sourceError(match, sources, fmt.Sprintf("policies[%d].match", i), fmt.Errorf("%w: %q", ErrUnmatchedPolicy, policy.Match)))
+	}
+	return warnings
+}
+
+// tableName strips the database of a table listed as database.table
+func tableName(table string) string {
+	return table[strings.LastIndex(table, ".")+1:]
+}
+
+func sourceError(node *yaml.Node, sources map[*yaml.Node]string, path string, err error) error {
+	return &FileError{File: sources[node], Line: node.Line, Column: node.Column, Path: path, Err: err}
+}
+
+// mappingValue returns the value of a key in a mapping node, nil when the key is missing
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
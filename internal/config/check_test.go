@@ -0,0 +1,252 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateSchema rewrites the published config.schema.json:
+//
+//	go test ./internal/config -run TestJSONSchema_Published -update
+var updateSchema = flag.Bool("update", false, "rewrite config.schema.json at the repository root")
+
+func TestConfig_LoadFromFile_Checks(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expectErr   error
+		contains    []string
+	}{
+		{
+			name: "Unknown key with suggestion",
+			yamlContent: `dsn: clickhouse://localhost:9000/test
+tabels:
+  - users
+`,
+			expectErr: ErrUnknownKey,
+			contains:  []string{"config.yaml:2:1", `unknown key "tabels" (did you mean "tables"?)`},
+		},
+		{
+			name: "Unknown nested key",
+			yamlContent: `dsn: clickhouse://localhost:9000/test
+field_numbers:
+  stratgy: hash
+`,
+			expectErr: ErrUnknownKey,
+			contains:  []string{"config.yaml:3:3: field_numbers", `did you mean "strategy"?`},
+		},
+		{
+			name: "Unknown key without close match",
+			yamlContent: `dsn: clickhouse://localhost:9000/test
+something_else: true
+`,
+			expectErr: ErrUnknownKey,
+			contains:  []string{`unknown key "something_else"`},
+		},
+		{
+			name: "Wrong scalar types",
+			yamlContent: `dsn: clickhouse://localhost:9000/test
+include_comments: maybe
+max_page_size: lots
+`,
+			expectErr: ErrWrongType,
+			contains: []string{
+				`config.yaml:2:19: include_comments: wrong type: expected a boolean, got "maybe"`,
+				`config.yaml:3:16: max_page_size: wrong type: expected a whole number, got "lots"`,
+			},
+		},
+		{
+			name: "List where a string is expected",
+			yamlContent: `dsn:
+  - clickhouse://localhost:9000/test
+`,
+			expectErr: ErrWrongType,
+			contains:  []string{"dsn: wrong type: expected a string, got a list"},
+		},
+		{
+			name: "String where a list is expected",
+			yamlContent: `dsn: clickhouse://localhost:9000/test
+tables: users
+`,
+			expectErr: ErrWrongType,
+			contains:  []string{"tables: wrong type: expected a list"},
+		},
+		{
+			name: "Invalid duration",
+			yamlContent: `dsn: clickhouse://localhost:9000/test
+middleware:
+  enabled: true
+  slow_query_threshold: soon
+`,
+			expectErr: ErrWrongType,
+			contains:  []string{"config.yaml:4:25: middleware.slow_query_threshold", "a duration like 500ms or 2s"},
+		},
+		{
+			name: "enable_api without the rest section",
+			yamlContent: `dsn: clickhouse://localhost:9000/test
+enable_api: true
+emit: [messages, services]
+`,
+			expectErr: ErrConflictingKeys,
+			contains:  []string{"config.yaml:2:13: enable_api: conflicting options"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.yamlContent), 0o600))
+
+			err := NewConfig().LoadFromFile(path, logrus.New())
+			require.Error(t, err)
+			require.ErrorIs(t, err, tt.expectErr)
+			for _, want := range tt.contains {
+				assert.Contains(t, err.Error(), want)
+			}
+
+			var fileErr *FileError
+			require.True(t, errors.As(err, &fileErr))
+			assert.Equal(t, path, fileErr.File)
+		})
+	}
+}
+
+func TestConfig_LoadFromFile_ReportsEveryError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`dsn: clickhouse://localhost:9000/test
+tabels: [users]
+include_comments: maybe
+columns:
+  users:
+    email:
+      mask: hash
+      colour: red
+`), 0o600))
+
+	err := NewConfig().LoadFromFile(path, logrus.New())
+	require.Error(t, err)
+
+	var joined interface{ Unwrap() []error }
+	require.True(t, errors.As(err, &joined))
+	assert.Len(t, joined.Unwrap(), 3)
+	assert.Contains(t, err.Error(), `columns.users.email: unknown key "colour"`)
+}
+
+func TestConfig_LoadFromFile_Warnings(t *testing.T) {
+	tests := []struct {
+		name        string
+		yamlContent string
+		expected    []string
+	}{
+		{
+			name: "api_table_prefixes matching no table",
+			yamlContent: `dsn: clickhouse://localhost:9000/test
+tables: [users, orders]
+enable_api: true
+api_table_prefixes: [fct_]
+`,
+			expected: []string{
+				"config.yaml:4:21: api_table_prefixes: api_table_prefixes is deprecated",
+				"config.yaml:4:21: api_table_prefixes: enable_api is set but no table",
+			},
+		},
+		{
+			name: "Policy matching no table",
+			yamlContent: `dsn: clickhouse://localhost:9000/test
+tables: [fct_block, analytics.dim_node]
+policies:
+  - match: "*"
+    api: false
+  - match: dim_
+    api: true
+  - match: int_
+    service: false
+`,
+			expected: []string{`config.yaml:8:12: policies[2].match: policy matches none of the tables: "int_"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.yamlContent), 0o600))
+
+			log, hook := test.NewNullLogger()
+			require.NoError(t, NewConfig().LoadFromFile(path, log))
+
+			require.Len(t, hook.Entries, len(tt.expected))
+			for i, want := range tt.expected {
+				assert.Equal(t, logrus.WarnLevel, hook.Entries[i].Level)
+				assert.Contains(t, hook.Entries[i].Message, want)
+			}
+		})
+	}
+}
+
+func TestConfig_LoadFromFile_Example(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	require.NoError(t, NewConfig().LoadFromFile(filepath.Join("..", "..", "config.example.yaml"), log))
+
+	for _, entry := range hook.Entries {
+		assert.NotContains(t, entry.Message, ErrUnknownKey.Error())
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, schemaID, schema["$id"])
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	properties, ok := schema["properties"].(map[string]any)
+	require.True(t, ok)
+
+	dsn, ok := properties["dsn"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "string", dsn["type"])
+	assert.NotEmpty(t, dsn["description"])
+
+	onError, ok := properties["on_error"].(map[string]any)
+	require.True(t, ok)
+	assert.ElementsMatch(t, []any{OnErrorFail, OnErrorSkip, OnErrorReport}, onError["enum"])
+
+	emit, ok := properties["emit"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "array", emit["type"])
+	items, ok := emit["items"].(map[string]any)
+	require.True(t, ok)
+	assert.Contains(t, items["enum"], EmitREST)
+
+	fieldNumbers, ok := properties["field_numbers"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "object", fieldNumbers["type"])
+	assert.Equal(t, false, fieldNumbers["additionalProperties"])
+
+	assert.NotContains(t, string(data), `\u003c`)
+}
+
+func TestJSONSchema_Published(t *testing.T) {
+	data, err := JSONSchema()
+	require.NoError(t, err)
+
+	path := filepath.Join("..", "..", "config.schema.json")
+	if *updateSchema {
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+	}
+
+	published, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(published), "config.schema.json is out of date, run with -update to accept")
+}
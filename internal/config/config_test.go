@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -20,6 +21,11 @@ func TestNewConfig(t *testing.T) {
 	assert.Empty(t, cfg.DSN)
 	assert.Empty(t, cfg.Tables)
 	assert.Empty(t, cfg.GoPackage)
+	assert.False(t, cfg.Server.Enabled)
+	assert.Equal(t, ":9090", cfg.Server.ListenAddress)
+	assert.False(t, cfg.Middleware.Enabled)
+	assert.Equal(t, time.Second, cfg.Middleware.SlowQueryThreshold)
+	assert.Equal(t, OnErrorSkip, cfg.OnError)
 }
 
 func TestConfig_Validate(t *testing.T) {
@@ -115,6 +121,671 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Valid column mask",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Columns: map[string]map[string]ColumnConfig{
+					"users": {"email": {Mask: MaskHash}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Invalid column mask",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Columns: map[string]map[string]ColumnConfig{
+					"users": {"email": {Mask: "redact"}},
+				},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidMask,
+		},
+		{
+			name: "Invalid mask key setting",
+			config: Config{
+				DSN:            "clickhouse://localhost:9000/test",
+				OutputDir:      "./proto",
+				Package:        "test.v1",
+				Tables:         []string{"users"},
+				MaskKeySetting: "custom_key') || ('",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidMask,
+		},
+		{
+			name: "DDL files replace DSN and tables",
+			config: Config{
+				FromDDL:   []string{"schema/*.sql"},
+				OutputDir: "./proto",
+				Package:   "test.v1",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Valid on_error policy",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				OnError:   OnErrorReport,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Invalid on_error policy",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				OnError:   "ignore",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidOnError,
+		},
+		{
+			name: "Negative concurrency",
+			config: Config{
+				DSN:         "clickhouse://localhost:9000/test",
+				OutputDir:   "./proto",
+				Package:     "test.v1",
+				Tables:      []string{"users"},
+				Concurrency: -1,
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidConcurrency,
+		},
+		{
+			name: "Negative max_insert_rows",
+			config: Config{
+				DSN:           "clickhouse://localhost:9000/test",
+				OutputDir:     "./proto",
+				Package:       "test.v1",
+				Tables:        []string{"users"},
+				MaxInsertRows: -1,
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidInsertRows,
+		},
+		{
+			name: "Two columns renamed to the same column",
+			config: Config{
+				DSN:            "clickhouse://localhost:9000/test",
+				OutputDir:      "./proto",
+				Package:        "test.v1",
+				Tables:         []string{"users"},
+				RenamedColumns: map[string]map[string]string{"users": {"name": "full_name", "display_name": "full_name"}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidRename,
+		},
+		{
+			name: "max_change_percent above 100",
+			config: Config{
+				DSN:              "clickhouse://localhost:9000/test",
+				OutputDir:        "./proto",
+				Package:          "test.v1",
+				Tables:           []string{"users"},
+				MaxChangePercent: 101,
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidMaxChange,
+		},
+		{
+			name: "Invalid exclude_tables glob",
+			config: Config{
+				DSN:           "clickhouse://localhost:9000/test",
+				OutputDir:     "./proto",
+				Package:       "test.v1",
+				Tables:        []string{"fct_*"},
+				ExcludeTables: []string{"fct_[debug"},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidTableGlob,
+		},
+		{
+			name: "Invalid computed_columns mode",
+			config: Config{
+				DSN:             "clickhouse://localhost:9000/test",
+				OutputDir:       "./proto",
+				Package:         "test.v1",
+				Tables:          []string{"users"},
+				ComputedColumns: "drop",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidComputed,
+		},
+		{
+			name: "Invalid sql_parameters mode",
+			config: Config{
+				DSN:           "clickhouse://localhost:9000/test",
+				OutputDir:     "./proto",
+				Package:       "test.v1",
+				Tables:        []string{"users"},
+				SQLParameters: "inline",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidParameters,
+		},
+		{
+			name: "Server parameters with the result cache",
+			config: Config{
+				DSN:           "clickhouse://localhost:9000/test",
+				OutputDir:     "./proto",
+				Package:       "test.v1",
+				Tables:        []string{"users"},
+				SQLParameters: SQLParametersServer,
+				Middleware:    MiddlewareConfig{ResultCache: ResultCacheConfig{Enabled: true}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidParameters,
+		},
+		{
+			name: "Invalid empty_tables policy",
+			config: Config{
+				DSN:         "clickhouse://localhost:9000/test",
+				OutputDir:   "./proto",
+				Package:     "test.v1",
+				Tables:      []string{"users"},
+				EmptyTables: "fail",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidEmptyTables,
+		},
+		{
+			name: "Go module without a module path",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				GoModule:  GoModuleConfig{Enabled: true},
+			},
+			wantErr:   true,
+			expectErr: ErrGoModulePath,
+		},
+		{
+			name: "Invalid SQL build tag",
+			config: Config{
+				DSN:         "clickhouse://localhost:9000/test",
+				OutputDir:   "./proto",
+				Package:     "test.v1",
+				Tables:      []string{"users"},
+				SQLBuildTag: "chsql &&",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidBuildTag,
+		},
+		{
+			name: "Invalid topology target",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"events"},
+				Topology:  map[string]TopologyConfig{"events": {Target: "replica"}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidTopology,
+		},
+		{
+			name: "View without primary key",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"daily_totals"},
+				Views:     map[string]ViewConfig{"daily_totals": {}},
+			},
+			wantErr:   true,
+			expectErr: ErrViewPrimaryKey,
+		},
+		{
+			name: "Policy without match",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Policies:  []PolicyConfig{{Match: "fct_"}, {Service: ptr(false)}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidPolicy,
+		},
+		{
+			name: "Negative cache TTL",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Cache:     CacheConfig{Enabled: true, TTL: -time.Hour},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidCacheTTL,
+		},
+		{
+			name: "Malformed bool_columns pattern",
+			config: Config{
+				DSN:        "clickhouse://localhost:9000/test",
+				OutputDir:  "./proto",
+				Package:    "test.v1",
+				Tables:     []string{"users"},
+				Conversion: ConversionConfig{BoolColumns: []string{"users.is_[a"}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidBoolColumns,
+		},
+		{
+			name: "Invalid auto bigint pattern",
+			config: Config{
+				DSN:        "clickhouse://localhost:9000/test",
+				OutputDir:  "./proto",
+				Package:    "test.v1",
+				Tables:     []string{"users"},
+				Conversion: ConversionConfig{AutoBigIntToString: true, AutoBigIntToStringPatterns: []string{"*_[wei"}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidAutoBigInt,
+		},
+		{
+			name: "Zigzag encoding of an unsigned type",
+			config: Config{
+				DSN:        "clickhouse://localhost:9000/test",
+				OutputDir:  "./proto",
+				Package:    "test.v1",
+				Tables:     []string{"users"},
+				Conversion: ConversionConfig{IntEncodings: map[string]string{"UInt64": EncodingZigzag}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidEncoding,
+		},
+		{
+			name: "Encoding of a non-integer type",
+			config: Config{
+				DSN:        "clickhouse://localhost:9000/test",
+				OutputDir:  "./proto",
+				Package:    "test.v1",
+				Tables:     []string{"users"},
+				Conversion: ConversionConfig{IntEncodings: map[string]string{"Float64": EncodingFixed}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidEncoding,
+		},
+		{
+			name: "Negative comment max length",
+			config: Config{
+				DSN:              "clickhouse://localhost:9000/test",
+				OutputDir:        "./proto",
+				Package:          "test.v1",
+				Tables:           []string{"users"},
+				CommentMaxLength: -1,
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidCommentMax,
+		},
+		{
+			name: "Invalid column encoding",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Columns:   map[string]map[string]ColumnConfig{"users": {"id": {Encoding: "delta"}}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidEncoding,
+		},
+		{
+			name: "Max rows below max page size",
+			config: Config{
+				DSN:         "clickhouse://localhost:9000/test",
+				OutputDir:   "./proto",
+				Package:     "test.v1",
+				Tables:      []string{"users"},
+				MaxPageSize: 10000,
+				QueryLimits: QueryLimitsConfig{MaxRows: 5000},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidQueryLimits,
+		},
+		{
+			name: "IN chunk size not below max filter values",
+			config: Config{
+				DSN:         "clickhouse://localhost:9000/test",
+				OutputDir:   "./proto",
+				Package:     "test.v1",
+				Tables:      []string{"users"},
+				QueryLimits: QueryLimitsConfig{MaxFilterValues: 1000, InChunkSize: 1000},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidQueryLimits,
+		},
+		{
+			name: "Invalid column type override",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Columns:   map[string]map[string]ColumnConfig{"users": {"id": {Type: "int32"}}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidColumnType,
+		},
+		{
+			name: "Invalid column unit",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Columns:   map[string]map[string]ColumnConfig{"users": {"fee": {Unit: "wei\""}}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidUnit,
+		},
+		{
+			name: "Invalid column json_name",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Columns:   map[string]map[string]ColumnConfig{"users": {"fee": {JSONName: "fee-wei"}}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidJSONName,
+		},
+		{
+			name: "Invalid result cache pattern",
+			config: Config{
+				DSN:        "clickhouse://localhost:9000/test",
+				OutputDir:  "./proto",
+				Package:    "test.v1",
+				Tables:     []string{"users"},
+				Middleware: MiddlewareConfig{ResultCache: ResultCacheConfig{TTL: map[string]time.Duration{"dim_[": time.Hour}}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidResultCache,
+		},
+		{
+			name: "Tiers without archive",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Tiers:     map[string]TierConfig{"users": {Column: "created_at", Retention: 24 * time.Hour}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidTiers,
+		},
+		{
+			name: "Default where escaping its parentheses",
+			config: Config{
+				DSN:          "clickhouse://localhost:9000/test",
+				OutputDir:    "./proto",
+				Package:      "test.v1",
+				Tables:       []string{"users"},
+				DefaultWhere: map[string]DefaultWhereConfig{"users": {Where: "network = 'mainnet') OR (1"}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidWhere,
+		},
+		{
+			name: "Label services without values",
+			config: Config{
+				DSN:           "clickhouse://localhost:9000/test",
+				OutputDir:     "./proto",
+				Package:       "test.v1",
+				Tables:        []string{"users"},
+				LabelServices: LabelServicesConfig{Column: "meta_network_name"},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidLabels,
+		},
+		{
+			name: "Label values naming the same services",
+			config: Config{
+				DSN:           "clickhouse://localhost:9000/test",
+				OutputDir:     "./proto",
+				Package:       "test.v1",
+				Tables:        []string{"users"},
+				LabelServices: LabelServicesConfig{Column: "meta_network_name", Values: []string{"fusaka-devnet-3", "fusaka_devnet_3"}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidLabels,
+		},
+		{
+			name: "Default where with a semicolon",
+			config: Config{
+				DSN:          "clickhouse://localhost:9000/test",
+				OutputDir:    "./proto",
+				Package:      "test.v1",
+				Tables:       []string{"users"},
+				DefaultWhere: map[string]DefaultWhereConfig{"*": {Where: "network = 'mainnet'; DROP TABLE users"}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidWhere,
+		},
+		{
+			name: "Invalid deprecation pattern",
+			config: Config{
+				DSN:                "clickhouse://localhost:9000/test",
+				OutputDir:          "./proto",
+				Package:            "test.v1",
+				Tables:             []string{"users"},
+				DeprecationPattern: "DEPRECATED(",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidDeprecation,
+		},
+		{
+			name: "Invalid arrow format",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Arrow:     ArrowConfig{Enabled: true, Format: "ipc"},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidArrowFormat,
+		},
+		{
+			name: "Invalid field number strategy",
+			config: Config{
+				DSN:          "clickhouse://localhost:9000/test",
+				OutputDir:    "./proto",
+				Package:      "test.v1",
+				Tables:       []string{"users"},
+				FieldNumbers: FieldNumberConfig{Strategy: "random"},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidNumbering,
+		},
+		{
+			name: "Invalid field order",
+			config: Config{
+				DSN:        "clickhouse://localhost:9000/test",
+				OutputDir:  "./proto",
+				Package:    "test.v1",
+				Tables:     []string{"users"},
+				FieldOrder: "type",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidFieldOrder,
+		},
+		{
+			name: "Messages only",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Emit:      []string{EmitMessages, EmitAnnotations},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Unknown emit section",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Emit:      []string{"grpc"},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidEmit,
+		},
+		{
+			name: "SQL helpers without services",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Emit:      []string{EmitMessages, EmitSQL},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidEmit,
+		},
+		{
+			name: "Benchmarks without SQL helpers",
+			config: Config{
+				DSN:        "clickhouse://localhost:9000/test",
+				OutputDir:  "./proto",
+				Package:    "test.v1",
+				Tables:     []string{"users"},
+				Emit:       []string{EmitMessages, EmitServices},
+				Benchmarks: BenchmarkConfig{Enabled: true},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidEmit,
+		},
+		{
+			name: "Invalid reserved range",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Reserved:  map[string]ReservedConfig{"users": {Numbers: []string{"20 to 10"}}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidReserved,
+		},
+		{
+			name: "Extension ranges in proto3",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Reserved:  map[string]ReservedConfig{"*": {Extensions: []string{"1000 to max"}}},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidReserved,
+		},
+		{
+			name: "Extension ranges in edition 2023",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Syntax:    SyntaxEdition2023,
+				Reserved:  map[string]ReservedConfig{"*": {Extensions: []string{"1000 to max"}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Unknown syntax",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Syntax:    "edition2024",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidSyntax,
+		},
+		{
+			name: "proto2 with SQL helpers",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Syntax:    SyntaxProto2,
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidSyntax,
+		},
+		{
+			name: "Valid file options",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				FileOptions: FileOptionsConfig{
+					JavaPackage:     "io.example.test.v1",
+					CSharpNamespace: "Example.Test.V1",
+					PHPNamespace:    `Example\Test\V1`,
+					RubyPackage:     "Example::Test::V1",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "PHP namespace with dots",
+			config: Config{
+				DSN:         "clickhouse://localhost:9000/test",
+				OutputDir:   "./proto",
+				Package:     "test.v1",
+				Tables:      []string{"users"},
+				FileOptions: FileOptionsConfig{PHPNamespace: "Example.Test.V1"},
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidFileOption,
+		},
+		{
+			name: "Import prefix leaving the output directory",
+			config: Config{
+				DSN:          "clickhouse://localhost:9000/test",
+				OutputDir:    "./proto",
+				Package:      "test.v1",
+				Tables:       []string{"users"},
+				ImportPrefix: "../clickhouse/v1",
+			},
+			wantErr:   true,
+			expectErr: ErrInvalidImportPath,
+		},
+		{
+			name: "proto2 without SQL helpers",
+			config: Config{
+				DSN:       "clickhouse://localhost:9000/test",
+				OutputDir: "./proto",
+				Package:   "test.v1",
+				Tables:    []string{"users"},
+				Syntax:    SyntaxProto2,
+				Emit:      []string{EmitMessages, EmitServices},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,6 +804,153 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestParseFieldRange(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected FieldRange
+		wantErr  bool
+	}{
+		{value: "5", expected: FieldRange{Start: 5, End: 5}},
+		{value: "100 to 199", expected: FieldRange{Start: 100, End: 199}},
+		{value: "1000 to max", expected: FieldRange{Start: 1000, End: MaxFieldNumber}},
+		{value: "0", wantErr: true},
+		{value: "1 to 536870912", wantErr: true},
+		{value: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			r, err := ParseFieldRange(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, r)
+			assert.Equal(t, tt.value, r.String())
+		})
+	}
+}
+
+func TestConfig_ColumnOverrides(t *testing.T) {
+	cfg := &Config{
+		Columns: map[string]map[string]ColumnConfig{
+			"*": {
+				"email": {Mask: MaskOmit},
+				"ssn":   {Mask: MaskOmit},
+				"fee":   {Unit: "wei"},
+			},
+			"users": {
+				"email": {Mask: MaskHash},
+				"fee":   {Mask: MaskNull},
+			},
+		},
+	}
+
+	assert.Equal(t, MaskHash, cfg.ColumnOverrides("users", "email").Mask, "table-specific entry wins")
+	assert.Equal(t, MaskOmit, cfg.ColumnOverrides("users", "ssn").Mask, "wildcard entry applies")
+	assert.Equal(t, MaskOmit, cfg.ColumnOverrides("orders", "email").Mask)
+	assert.Empty(t, cfg.ColumnOverrides("orders", "id").Mask)
+	assert.Equal(t, ColumnConfig{Mask: MaskNull, Unit: "wei"}, cfg.ColumnOverrides("users", "fee"), "settings merge per key")
+}
+
+func TestConfig_TopologyTarget(t *testing.T) {
+	cfg := &Config{
+		Topology: map[string]TopologyConfig{
+			"*":      {Target: TargetLocal},
+			"events": {Target: TargetDistributed},
+		},
+	}
+
+	assert.Equal(t, TargetDistributed, cfg.TopologyTarget("events"), "table-specific entry wins")
+	assert.Equal(t, TargetLocal, cfg.TopologyTarget("blocks"), "wildcard entry applies")
+	assert.Equal(t, TargetDistributed, (&Config{}).TopologyTarget("events"), "defaults to distributed")
+}
+
+func TestConfig_DefaultWhereFor(t *testing.T) {
+	cfg := &Config{
+		DefaultWhere: map[string]DefaultWhereConfig{
+			"*":      {Where: "network = 'mainnet'"},
+			"events": {Where: "network IN ('mainnet', 'holesky') AND name != ')'", Overridable: true},
+		},
+	}
+
+	require.NoError(t, cfg.validateDefaultWhere())
+	assert.Equal(t, DefaultWhereConfig{Where: "network IN ('mainnet', 'holesky') AND name != ')'", Overridable: true}, cfg.DefaultWhereFor("events"), "table-specific entry wins")
+	assert.Equal(t, "network = 'mainnet'", cfg.DefaultWhereFor("blocks").Where, "wildcard entry applies")
+	assert.Empty(t, (&Config{}).DefaultWhereFor("events").Where)
+}
+
+func TestConfig_ResultCacheTTL(t *testing.T) {
+	cfg := &Config{}
+	cfg.Middleware.ResultCache.TTL = map[string]time.Duration{
+		"dim_*":       time.Hour,
+		"dim_node*":   time.Minute,
+		"dim_node_v2": time.Second,
+	}
+
+	assert.Equal(t, time.Second, cfg.ResultCacheTTL("dim_node_v2"), "exact entry wins")
+	assert.Equal(t, time.Minute, cfg.ResultCacheTTL("dim_node_v1"), "longer pattern wins")
+	assert.Equal(t, time.Hour, cfg.ResultCacheTTL("dim_block"))
+	assert.Zero(t, cfg.ResultCacheTTL("fct_block"), "tables without a TTL aren't cached")
+}
+
+func TestConfig_SelectTables(t *testing.T) {
+	listed := []string{"beacon.fct_block", "beacon.fct_debug_block", "beacon.dim_node", "other.fct_block"}
+
+	cfg := &Config{
+		Tables:        []string{"dim_node", "fct_*", "other.fct_block", "fct_block"},
+		ExcludeTables: []string{"fct_debug_*"},
+	}
+	assert.True(t, cfg.HasTableGlobs())
+	assert.Equal(t, []string{"dim_node", "beacon.fct_block", "other.fct_block", "fct_block"}, cfg.SelectTables(listed, "beacon"),
+		"globs match the default database and listed entries are kept as written")
+
+	cfg = &Config{
+		Tables:        []string{"*.fct_*", "users"},
+		ExcludeTables: []string{"other.*", "beacon.users"},
+	}
+	assert.Equal(t, []string{"beacon.fct_block", "beacon.fct_debug_block"}, cfg.SelectTables(listed, "beacon"),
+		"exclusions win over globs and listed tables")
+
+	cfg = &Config{ExcludeTables: []string{"fct_*"}}
+	assert.False(t, cfg.HasTableGlobs())
+	assert.Equal(t, []string{"beacon.dim_node"}, cfg.SelectTables(listed, ""), "an empty list selects every listed table")
+	assert.Equal(t, []string{"fct_block"}, (&Config{Tables: []string{"fct_*"}}).SelectTables([]string{"fct_block"}, ""),
+		"DDL tables without a database match unqualified globs")
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestConfig_Policy(t *testing.T) {
+	cfg := &Config{
+		Policies: []PolicyConfig{
+			{Match: "*", API: ptr(false)},
+			{Match: "fct_", API: ptr(true)},
+			{Match: "fct_raw_", Service: ptr(false)},
+			{Match: "int_", API: ptr(true), Service: ptr(false)},
+			{Match: "fct_block", Buckets: ptr(true)},
+		},
+	}
+
+	assert.Equal(t, TablePolicy{API: true, Service: true}, cfg.Policy("fct_attestation"))
+	assert.Equal(t, TablePolicy{API: true, Service: true, Buckets: true}, cfg.Policy("fct_block"))
+	assert.Equal(t, TablePolicy{API: true, Service: false}, cfg.Policy("fct_raw_events"), "later policies keep what they don't set")
+	assert.Equal(t, TablePolicy{API: true, Service: false}, cfg.Policy("int_block"))
+	assert.Equal(t, TablePolicy{API: false, Service: true}, cfg.Policy("dim_node"))
+	assert.Equal(t, TablePolicy{API: true, Service: true}, (&Config{}).Policy("dim_node"), "everything but buckets is on by default")
+
+	legacy := &Config{
+		APITablePrefixes: []string{"fct_", "dim_"},
+		Policies:         []PolicyConfig{{Match: "dim_", API: ptr(false)}},
+	}
+	assert.True(t, legacy.Policy("fct_block").API)
+	assert.False(t, legacy.Policy("dim_node").API, "policies override api_table_prefixes")
+	assert.False(t, legacy.Policy("stg_block").API)
+}
+
 func TestConfig_LoadFromFile(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -163,6 +981,50 @@ include_comments: true
 				assert.True(t, cfg.IncludeComments)
 			},
 		},
+		{
+			name: "Introspection settings",
+			yamlContent: `
+dsn: clickhouse://localhost:9000/test
+tables: [users]
+introspection:
+  require_readonly_user: true
+  settings:
+    max_result_rows: 100000
+`,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Introspection.ReadOnly, "readonly defaults to true")
+				assert.True(t, cfg.Introspection.RequireReadOnlyUser)
+				assert.Equal(t, map[string]string{"max_execution_time": "60", "max_result_rows": "100000"}, cfg.Introspection.Settings, "settings add to the defaults")
+			},
+		},
+		{
+			name: "Cache settings",
+			yamlContent: `
+dsn: clickhouse://localhost:9000/test
+tables: [users]
+cache:
+  dir: .cache/schemas
+  ttl: 1h30m
+`,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Cache.Enabled, "the cache is enabled by default")
+				assert.Equal(t, ".cache/schemas", cfg.Cache.Dir)
+				assert.Equal(t, 90*time.Minute, cfg.Cache.TTL)
+			},
+		},
+		{
+			name: "Snapshot settings",
+			yamlContent: `
+dsn: clickhouse://localhost:9000/test
+tables: [users]
+snapshot:
+  column: updated_date_time
+  dedup: true
+`,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, SnapshotConfig{Column: "updated_date_time", Dedup: true}, cfg.Snapshot)
+			},
+		},
 		{
 			name: "Minimal YAML file",
 			yamlContent: `
@@ -192,6 +1054,65 @@ include_comments: false
 				assert.False(t, cfg.IncludeComments)
 			},
 		},
+		{
+			name: "YAML with server scaffold",
+			yamlContent: `
+dsn: clickhouse://localhost:9000/test
+server:
+  enabled: true
+  listen_address: 0.0.0.0:8080
+`,
+			expectErr: false,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Server.Enabled)
+				assert.Equal(t, "0.0.0.0:8080", cfg.Server.ListenAddress)
+			},
+		},
+		{
+			name: "YAML with middleware",
+			yamlContent: `
+dsn: clickhouse://localhost:9000/test
+middleware:
+  enabled: true
+  slow_query_threshold: 250ms
+  tracing: true
+`,
+			expectErr: false,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Middleware.Enabled)
+				assert.Equal(t, 250*time.Millisecond, cfg.Middleware.SlowQueryThreshold)
+				assert.True(t, cfg.Middleware.Tracing)
+			},
+		},
+		{
+			name: "YAML with type mapping checks",
+			yamlContent: `
+dsn: clickhouse://localhost:9000/test
+strict: true
+warn_lossy: true
+`,
+			expectErr: false,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.True(t, cfg.Strict)
+				assert.True(t, cfg.WarnLossy)
+			},
+		},
+		{
+			name: "YAML with visibility profiles",
+			yamlContent: `
+dsn: clickhouse://localhost:9000/test
+visibility:
+  fct_block:
+    public: [slot, block_root]
+  "*":
+    internal: ["*"]
+`,
+			expectErr: false,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, []string{"slot", "block_root"}, cfg.Visibility["fct_block"]["public"])
+				assert.Equal(t, []string{"*"}, cfg.Visibility["*"]["internal"])
+			},
+		},
 		{
 			name:        "Invalid YAML",
 			yamlContent: `invalid yaml content: [}`,
@@ -241,6 +1162,70 @@ include_comments: false
 	}
 }
 
+func TestConfig_SaveTables(t *testing.T) {
+	log := logrus.New()
+
+	t.Run("New file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		cfg := NewConfig()
+		cfg.DSN = "clickhouse://localhost:9000/mydb"
+		cfg.Tables = []string{"mydb.users", "mydb.orders"}
+		require.NoError(t, cfg.SaveTables(path))
+
+		loaded := NewConfig()
+		require.NoError(t, loaded.LoadFromFile(path, log))
+		assert.Equal(t, cfg.DSN, loaded.DSN)
+		assert.Equal(t, cfg.Tables, loaded.Tables)
+		assert.Equal(t, "./proto", loaded.OutputDir)
+		assert.NoError(t, loaded.Validate())
+	})
+
+	t.Run("Existing file keeps other settings", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`# Production schema
+dsn: clickhouse://localhost:9000/mydb
+tables:
+  - users
+package: myapp.v1 # Versioned
+`), 0o600))
+
+		cfg := NewConfig()
+		cfg.Tables = []string{"users", "orders"}
+		require.NoError(t, cfg.SaveTables(path))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "# Production schema")
+		assert.Contains(t, string(data), "# Versioned")
+
+		loaded := NewConfig()
+		require.NoError(t, loaded.LoadFromFile(path, log))
+		assert.Equal(t, []string{"users", "orders"}, loaded.Tables)
+		assert.Equal(t, "myapp.v1", loaded.Package)
+	})
+
+	t.Run("Existing file without tables", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("dsn: clickhouse://localhost:9000/mydb\n"), 0o600))
+
+		cfg := NewConfig()
+		cfg.Tables = []string{"users"}
+		require.NoError(t, cfg.SaveTables(path))
+
+		loaded := NewConfig()
+		require.NoError(t, loaded.LoadFromFile(path, log))
+		assert.Equal(t, []string{"users"}, loaded.Tables)
+	})
+
+	t.Run("Not a mapping", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("- users\n"), 0o600))
+
+		cfg := NewConfig()
+		require.ErrorIs(t, cfg.SaveTables(path), ErrConfigNotMapping)
+	})
+}
+
 func TestConfig_LoadFromFile_FileNotExists(t *testing.T) {
 	cfg := NewConfig()
 	log := logrus.New()
@@ -405,14 +1390,22 @@ func TestConfig_MergeFlags(t *testing.T) {
 			apiBasePath:      "",
 			apiTablePrefixes: "",
 			expected: Config{
-				DSN:              "clickhouse://localhost:9000/test",
-				OutputDir:        "./proto",
-				Package:          "clickhouse.v1",
-				IncludeComments:  true,
-				MaxPageSize:      10000,
-				EnableAPI:        true,
-				APIBasePath:      "/api/v1", // Default from NewConfig()
-				APITablePrefixes: []string{},
+				DSN:                "clickhouse://localhost:9000/test",
+				OutputDir:          "./proto",
+				Package:            "clickhouse.v1",
+				IncludeComments:    true,
+				CommentMaxLength:   DefaultCommentMaxLength,
+				MaxPageSize:        10000,
+				EnableAPI:          true,
+				APIBasePath:        "/api/v1", // Default from NewConfig()
+				APITablePrefixes:   []string{},
+				OnError:            OnErrorSkip,
+				Introspection:      IntrospectionConfig{ReadOnly: true, Settings: map[string]string{"max_execution_time": "60"}},
+				Cache:              CacheConfig{Enabled: true, TTL: 24 * time.Hour},
+				Server:             ServerConfig{ListenAddress: ":9090"},
+				Middleware:         MiddlewareConfig{SlowQueryThreshold: time.Second},
+				QueryLimits:        QueryLimitsConfig{MaxFilterValues: DefaultMaxFilterValues},
+				DeprecationPattern: DefaultDeprecationPattern,
 			},
 		},
 		{
@@ -834,6 +1827,60 @@ func TestConversionConfig_ShouldConvertToString(t *testing.T) {
 	}
 }
 
+func TestConversionConfig_ShouldConvertToBool(t *testing.T) {
+	conv := ConversionConfig{BoolColumns: []string{"*.is_*", "fct_block.orphaned", "has_blobs"}}
+
+	tests := []struct {
+		tableName string
+		fieldName string
+		expected  bool
+	}{
+		{tableName: "fct_block", fieldName: "is_canonical", expected: true},
+		{tableName: "fct_block", fieldName: "orphaned", expected: true},
+		{tableName: "fct_attestation", fieldName: "orphaned", expected: false},
+		{tableName: "fct_attestation", fieldName: "has_blobs", expected: true},
+		{tableName: "fct_block", fieldName: "slot", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tableName+"."+tt.fieldName, func(t *testing.T) {
+			assert.Equal(t, tt.expected, conv.ShouldConvertToBool(tt.tableName, tt.fieldName))
+		})
+	}
+}
+
+func TestConversionConfig_AutoBigIntToString(t *testing.T) {
+	tests := []struct {
+		name      string
+		conv      ConversionConfig
+		fieldName string
+		expected  bool
+	}{
+		{name: "disabled", conv: ConversionConfig{}, fieldName: "balance_wei", expected: false},
+		{name: "default wei pattern", conv: ConversionConfig{AutoBigIntToString: true}, fieldName: "balance_wei", expected: true},
+		{name: "default value pattern", conv: ConversionConfig{AutoBigIntToString: true}, fieldName: "execution_payload_value", expected: true},
+		{name: "no default match", conv: ConversionConfig{AutoBigIntToString: true}, fieldName: "slot", expected: false},
+		{
+			name:      "custom patterns replace the defaults",
+			conv:      ConversionConfig{AutoBigIntToString: true, AutoBigIntToStringPatterns: []string{"fct_block.*_gwei"}},
+			fieldName: "balance_wei",
+			expected:  false,
+		},
+		{
+			name:      "custom table pattern",
+			conv:      ConversionConfig{AutoBigIntToString: true, AutoBigIntToStringPatterns: []string{"fct_block.*_gwei"}},
+			fieldName: "fee_gwei",
+			expected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.conv.ShouldConvertToString("fct_block", tt.fieldName))
+		})
+	}
+}
+
 func TestMatchesPattern(t *testing.T) {
 	tests := []struct {
 		name      string
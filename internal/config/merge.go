@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mergeNodes overrides the settings of base with the ones of over. Mappings are merged key
+// by key; any other value of over, including lists and null, replaces the one of base.
+// Neither node is modified, so the nodes of the result still point into the files they
+// were read from.
+func mergeNodes(base, over *yaml.Node) *yaml.Node {
+	if base == nil {
+		return over
+	}
+	if base.Kind != yaml.MappingNode || over.Kind != yaml.MappingNode {
+		return over
+	}
+
+	merged := &yaml.Node{
+		Kind:    yaml.MappingNode,
+		Tag:     base.Tag,
+		Line:    base.Line,
+		Column:  base.Column,
+		Content: append([]*yaml.Node(nil), base.Content...),
+	}
+	for i := 0; i+1 < len(over.Content); i += 2 {
+		key, value := over.Content[i], over.Content[i+1]
+		found := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				merged.Content[j+1] = mergeNodes(merged.Content[j+1], value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return merged
+}
+
+// recordSources maps every node of a file to the file, so problems found after merging can
+// still be reported at their position
+func recordSources(node *yaml.Node, file string, sources map[*yaml.Node]string) {
+	sources[node] = file
+	for _, child := range node.Content {
+		recordSources(child, file, sources)
+	}
+}
+
+// profileNode returns the settings of a named profile of the merged config
+func profileNode(root *yaml.Node, name string) (*yaml.Node, error) {
+	var names []string
+	if profiles := mappingValue(root, "profiles"); profiles != nil {
+		if profile := mappingValue(profiles, name); profile != nil {
+			if profile.Kind == yaml.MappingNode {
+				return profile, nil
+			}
+			// An empty profile overrides nothing
+			return &yaml.Node{Kind: yaml.MappingNode}, nil
+		}
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			names = append(names, profiles.Content[i].Value)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w %q: the config defines no profiles", ErrUnknownProfile, name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("%w %q (available: %s)", ErrUnknownProfile, name, strings.Join(names, ", "))
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mergeBaseConfig = `dsn: clickhouse://localhost:9000/analytics
+tables:
+  - users
+  - orders
+package: analytics.v1
+field_numbers:
+  strategy: lock
+  offset: 20
+columns:
+  users:
+    email:
+      mask: hash
+profiles:
+  dev:
+    dsn: clickhouse://localhost:9000/analytics_dev
+    tables: [users]
+  prod:
+    dsn: clickhouse://prod:9000/analytics
+    output_dir: ./gen/prod
+    columns:
+      users:
+        phone:
+          mask: "null"
+`
+
+func writeConfigFiles(t *testing.T, contents ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, 0, len(contents))
+	for i, content := range contents {
+		path := filepath.Join(dir, []string{"base.yaml", "override.yaml", "local.yaml"}[i])
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestConfig_LoadFromFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		profile  string
+		validate func(t *testing.T, cfg *Config)
+	}{
+		{
+			name:  "Single file without profile",
+			files: []string{mergeBaseConfig},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "clickhouse://localhost:9000/analytics", cfg.DSN)
+				assert.Equal(t, []string{"users", "orders"}, cfg.Tables)
+				assert.Len(t, cfg.Profiles, 2)
+			},
+		},
+		{
+			name: "Later files override key by key",
+			files: []string{mergeBaseConfig, `tables: [events]
+output_dir: ./gen
+field_numbers:
+  offset: 100
+`},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "clickhouse://localhost:9000/analytics", cfg.DSN)
+				assert.Equal(t, []string{"events"}, cfg.Tables, "lists are replaced, not appended")
+				assert.Equal(t, "./gen", cfg.OutputDir)
+				assert.Equal(t, "analytics.v1", cfg.Package)
+				assert.Equal(t, FieldNumbersLock, cfg.FieldNumbers.Strategy, "mappings are merged")
+				assert.Equal(t, 100, cfg.FieldNumbers.Offset)
+			},
+		},
+		{
+			name:    "Profile overrides the file",
+			files:   []string{mergeBaseConfig},
+			profile: "dev",
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "clickhouse://localhost:9000/analytics_dev", cfg.DSN)
+				assert.Equal(t, []string{"users"}, cfg.Tables)
+				assert.Equal(t, "analytics.v1", cfg.Package)
+			},
+		},
+		{
+			name:    "Profile merges nested settings",
+			files:   []string{mergeBaseConfig},
+			profile: "prod",
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "./gen/prod", cfg.OutputDir)
+				assert.Equal(t, MaskHash, cfg.Columns["users"]["email"].Mask)
+				assert.Equal(t, MaskNull, cfg.Columns["users"]["phone"].Mask)
+			},
+		},
+		{
+			name: "Profile applies after all files",
+			files: []string{mergeBaseConfig, `profiles:
+  prod:
+    package: analytics.prod.v1
+`, `package: local.v1
+`},
+			profile: "prod",
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "analytics.prod.v1", cfg.Package)
+				assert.Equal(t, "./gen/prod", cfg.OutputDir, "profiles of several files are merged")
+			},
+		},
+		{
+			name:  "Null resets a setting to its default",
+			files: []string{mergeBaseConfig, "package:\n"},
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, NewConfig().Package, cfg.Package)
+			},
+		},
+		{
+			name:    "Empty profile",
+			files:   []string{mergeBaseConfig, "profiles:\n  staging:\n"},
+			profile: "staging",
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "clickhouse://localhost:9000/analytics", cfg.DSN)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			require.NoError(t, cfg.LoadFromFiles(writeConfigFiles(t, tt.files...), tt.profile, logrus.New()))
+			tt.validate(t, cfg)
+		})
+	}
+}
+
+func TestConfig_LoadFromFiles_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		profile   string
+		expectErr error
+		contains  []string
+	}{
+		{
+			name:      "Unknown profile",
+			files:     []string{mergeBaseConfig},
+			profile:   "staging",
+			expectErr: ErrUnknownProfile,
+			contains:  []string{`unknown profile "staging" (available: dev, prod)`},
+		},
+		{
+			name:      "No profiles defined",
+			files:     []string{"dsn: clickhouse://localhost:9000/test\n"},
+			profile:   "dev",
+			expectErr: ErrUnknownProfile,
+			contains:  []string{"defines no profiles"},
+		},
+		{
+			name: "Unknown key in a profile",
+			files: []string{mergeBaseConfig, `profiles:
+  dev:
+    output_dri: ./gen
+`},
+			expectErr: ErrUnknownKey,
+			contains:  []string{"override.yaml:3:5: profiles.dev", `did you mean "output_dir"?`},
+		},
+		{
+			name: "Nested profiles",
+			files: []string{`profiles:
+  dev:
+    profiles:
+      local: {}
+`},
+			expectErr: ErrNestedProfile,
+			contains:  []string{"base.yaml:3:5: profiles.dev"},
+		},
+		{
+			name: "Conflict reported in the file that set it",
+			files: []string{`emit: [messages, services]
+`, `enable_api: true
+`},
+			expectErr: ErrConflictingKeys,
+			contains:  []string{"override.yaml:1:13: enable_api"},
+		},
+		{
+			name: "Conflict introduced by a profile",
+			files: []string{`emit: [messages, services]
+profiles:
+  api:
+    enable_api: true
+`},
+			profile:   "api",
+			expectErr: ErrConflictingKeys,
+			contains:  []string{"base.yaml:4:17: enable_api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewConfig().LoadFromFiles(writeConfigFiles(t, tt.files...), tt.profile, logrus.New())
+			require.Error(t, err)
+			require.ErrorIs(t, err, tt.expectErr)
+			for _, want := range tt.contains {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
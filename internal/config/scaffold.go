@@ -0,0 +1,317 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScaffoldTable is a table found by init
+type ScaffoldTable struct {
+	Database string
+	Name     string
+	Engine   string // Engine name without arguments
+}
+
+// ScaffoldOptions describe the schema source init proposes a config for
+type ScaffoldOptions struct {
+	DSN     string
+	FromDDL []string
+	Cluster string
+	// Database is the database of the DSN. Its tables are listed without database, and it
+	// names the proto package when every table is in it.
+	Database string
+	Tables   []ScaffoldTable
+}
+
+// Engines whose tables have no rows of their own to serve, left out of scaffolded configs
+//
+//nolint:gochecknoglobals // read-only lookup table
+var scaffoldSkippedEngines = map[string]bool{
+	"MaterializedView": true,
+	"Kafka":            true,
+	"RabbitMQ":         true,
+	"NATS":             true,
+	"Null":             true,
+	"Buffer":           true,
+}
+
+// Table name prefixes of staging and scratch tables, never suggested as API prefixes
+//
+//nolint:gochecknoglobals // read-only lookup table
+var scaffoldInternalPrefixes = map[string]bool{
+	"tmp":     true,
+	"temp":    true,
+	"test":    true,
+	"stg":     true,
+	"staging": true,
+	"int":     true,
+	"bak":     true,
+	"backup":  true,
+	"old":     true,
+}
+
+// distributedLocalSuffix marks the local tables behind Distributed tables of the same name
+const distributedLocalSuffix = "_local"
+
+// scaffoldGroup is a set of tables in one database sharing a name prefix
+type scaffoldGroup struct {
+	database string
+	prefix   string // Prefix with its trailing underscore, empty for ungrouped tables
+	tables   []string
+}
+
+// Scaffold proposes a config file for the given tables. Tables are grouped by database and
+// name prefix, prefixes shared by several tables are suggested for the HTTP API, and the
+// package is named after the database. The result is meant as a starting point to review.
+func Scaffold(opts ScaffoldOptions) ([]byte, error) {
+	groups := scaffoldGroups(opts.Tables, opts.Database)
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+
+	switch {
+	case len(opts.FromDDL) > 0:
+		addScaffoldKey(root, "from_ddl", "Schemas are read from these CREATE TABLE files", opts.FromDDL)
+	default:
+		addScaffoldKey(root, "dsn", "ClickHouse connection DSN", opts.DSN)
+		if opts.Cluster != "" {
+			addScaffoldKey(root, "cluster", "Read schemas from every replica of this cluster", opts.Cluster)
+		}
+	}
+
+	tables := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, group := range groups {
+		for i, name := range group.tables {
+			item := scaffoldValue(name)
+			if i == 0 {
+				item.HeadComment = group.comment()
+				if len(tables.Content) > 0 {
+					item.HeadComment = "\n" + item.HeadComment
+				}
+			}
+			tables.Content = append(tables.Content, item)
+		}
+	}
+	addScaffoldKey(root, "tables", "Tables to generate, grouped by database and name prefix. Remove the ones you don't need.", tables)
+
+	addScaffoldKey(root, "output_dir", "Output directory for generated proto files", "./proto")
+	addScaffoldKey(root, "package", "Protocol Buffer package name", scaffoldPackage(opts.Tables, opts.Database))
+
+	prefixes := scaffoldAPIPrefixes(groups)
+	addScaffoldKey(root, "enable_api", "HTTP annotations for the List and Get RPCs of the tables the policies open up", len(prefixes) > 0)
+	if len(prefixes) > 0 {
+		addScaffoldKey(root, "api_base_path", "", "/api/v1")
+		addScaffoldKey(root, "policies", "The API is off except for prefixes shared by several tables, review before exposing them", scaffoldPolicies(prefixes))
+	}
+
+	data, err := encodeYAML(&yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: "Generated by clickhouse-proto-gen init. Review the tables and settings, then run\nclickhouse-proto-gen --config <this file>",
+		Content:     []*yaml.Node{root},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The blank lines between table groups are indented like the list; drop the indent
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// addScaffoldKey appends a key to a mapping node. Keys with a comment start a new paragraph.
+func addScaffoldKey(root *yaml.Node, key, comment string, value any) {
+	if comment != "" && len(root.Content) > 0 {
+		comment = "\n" + comment
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key, HeadComment: comment}, scaffoldValue(value))
+}
+
+// scaffoldValue builds the node of a scaffolded setting. Strings are tagged explicitly so
+// values like "true" or "1" are quoted instead of changing type.
+func scaffoldValue(value any) *yaml.Node {
+	switch v := value.(type) {
+	case *yaml.Node:
+		return v
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case []string:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			node.Content = append(node.Content, scaffoldValue(item))
+		}
+		return node
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(v)}
+	}
+}
+
+// scaffoldPolicies builds policies turning the API off for every table, then on for the prefixes
+func scaffoldPolicies(prefixes []string) *yaml.Node {
+	policy := func(match string, api bool) *yaml.Node {
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		addScaffoldKey(node, "match", "", match)
+		addScaffoldKey(node, "api", "", api)
+		return node
+	}
+
+	policies := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{policy("*", false)}}
+	for _, prefix := range prefixes {
+		policies.Content = append(policies.Content, policy(prefix, true))
+	}
+	return policies
+}
+
+func (g scaffoldGroup) comment() string {
+	switch {
+	case g.prefix != "" && g.database != "":
+		return fmt.Sprintf("%s: %s* (%d tables)", g.database, g.prefix, len(g.tables))
+	case g.prefix != "":
+		return fmt.Sprintf("%s* (%d tables)", g.prefix, len(g.tables))
+	case g.database != "":
+		return g.database + ": other tables"
+	default:
+		return "Other tables"
+	}
+}
+
+// scaffoldGroups selects the tables worth generating and groups them by database and prefix.
+// Tables of skipped engines are left out, as are the local tables of Distributed tables.
+func scaffoldGroups(tables []ScaffoldTable, database string) []scaffoldGroup {
+	distributed := make(map[string]bool)
+	for _, table := range tables {
+		if table.Engine == "Distributed" {
+			distributed[table.Database+"."+table.Name] = true
+		}
+	}
+
+	type groupKey struct{ database, prefix string }
+	byKey := make(map[groupKey]*scaffoldGroup)
+	var keys []groupKey
+	for _, table := range tables {
+		if scaffoldSkippedEngines[table.Engine] || strings.HasPrefix(table.Name, ".inner") {
+			continue
+		}
+		if base, ok := strings.CutSuffix(table.Name, distributedLocalSuffix); ok && distributed[table.Database+"."+base] {
+			continue
+		}
+
+		name := table.Name
+		if table.Database != "" && table.Database != database {
+			name = table.Database + "." + table.Name
+		}
+
+		key := groupKey{database: table.Database, prefix: namePrefix(table.Name)}
+		group, ok := byKey[key]
+		if !ok {
+			group = &scaffoldGroup{database: key.database, prefix: key.prefix}
+			byKey[key] = group
+			keys = append(keys, key)
+		}
+		group.tables = append(group.tables, name)
+	}
+
+	// Prefixes of a single table don't group anything; those tables go with the ungrouped ones
+	merged := make(map[string]*scaffoldGroup)
+	var groups []*scaffoldGroup
+	for _, key := range keys {
+		group := byKey[key]
+		if len(group.tables) > 1 && key.prefix != "" {
+			groups = append(groups, group)
+			continue
+		}
+		rest, ok := merged[key.database]
+		if !ok {
+			rest = &scaffoldGroup{database: key.database}
+			merged[key.database] = rest
+			groups = append(groups, rest)
+		}
+		rest.tables = append(rest.tables, group.tables...)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].database != groups[j].database {
+			return groups[i].database < groups[j].database
+		}
+		// Ungrouped tables come last in their database
+		if (groups[i].prefix == "") != (groups[j].prefix == "") {
+			return groups[j].prefix == ""
+		}
+		return groups[i].prefix < groups[j].prefix
+	})
+
+	result := make([]scaffoldGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.Strings(group.tables)
+		result = append(result, *group)
+	}
+	return result
+}
+
+// namePrefix returns the part of a table name up to and including its first underscore
+func namePrefix(name string) string {
+	i := strings.Index(name, "_")
+	if i <= 0 || i == len(name)-1 {
+		return ""
+	}
+	return name[:i+1]
+}
+
+// scaffoldAPIPrefixes suggests the prefixes of table groups for the API, leaving out the
+// prefixes of staging and scratch tables
+func scaffoldAPIPrefixes(groups []scaffoldGroup) []string {
+	seen := make(map[string]bool)
+	var prefixes []string
+	for _, group := range groups {
+		if group.prefix == "" || seen[group.prefix] || scaffoldInternalPrefixes[strings.TrimSuffix(group.prefix, "_")] {
+			continue
+		}
+		seen[group.prefix] = true
+		prefixes = append(prefixes, group.prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// scaffoldPackage names the proto package after the database when all tables are in one,
+// falling back to the default package
+func scaffoldPackage(tables []ScaffoldTable, database string) string {
+	databases := make(map[string]bool)
+	for _, table := range tables {
+		db := table.Database
+		if db == "" {
+			db = database
+		}
+		databases[db] = true
+	}
+
+	if len(databases) != 1 {
+		return NewConfig().Package
+	}
+	for db := range databases {
+		if name := packageComponent(db); name != "" && db != "default" {
+			return name + ".v1"
+		}
+	}
+	return NewConfig().Package
+}
+
+// packageComponent turns a database name into a proto package component: lower case
+// letters, digits and underscores, not starting with a digit
+func packageComponent(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_', r >= '0' && r <= '9' && sb.Len() > 0:
+			sb.WriteRune(r)
+		case r == '-' || r == '.':
+			sb.WriteRune('_')
+		}
+	}
+	return strings.Trim(sb.String(), "_")
+}
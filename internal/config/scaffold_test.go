@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaffold(t *testing.T) {
+	data, err := Scaffold(ScaffoldOptions{
+		DSN:      "clickhouse://localhost:9000/analytics",
+		Database: "analytics",
+		Tables: []ScaffoldTable{
+			{Database: "analytics", Name: "fct_block", Engine: "Distributed"},
+			{Database: "analytics", Name: "fct_block_local", Engine: "ReplicatedMergeTree"},
+			{Database: "analytics", Name: "fct_attestation", Engine: "MergeTree"},
+			{Database: "analytics", Name: "dim_node", Engine: "MergeTree"},
+			{Database: "analytics", Name: "tmp_backfill", Engine: "MergeTree"},
+			{Database: "analytics", Name: "tmp_replay", Engine: "MergeTree"},
+			{Database: "analytics", Name: "mv_block", Engine: "MaterializedView"},
+			{Database: "analytics", Name: "true", Engine: "MergeTree"},
+			{Database: "metrics", Name: "fct_usage", Engine: "MergeTree"},
+		},
+	})
+	require.NoError(t, err)
+
+	content := string(data)
+	assert.Contains(t, content, "# analytics: fct_* (2 tables)\n  - fct_attestation\n  - fct_block\n")
+	assert.Contains(t, content, "# analytics: tmp_* (2 tables)")
+	assert.Contains(t, content, "# analytics: other tables\n  - dim_node\n  - \"true\"\n")
+	assert.Contains(t, content, "# metrics: other tables\n  - metrics.fct_usage\n")
+	assert.NotContains(t, content, "fct_block_local", "local tables of Distributed tables are left out")
+	assert.NotContains(t, content, "mv_block")
+	for _, line := range strings.Split(content, "\n") {
+		assert.Equal(t, strings.TrimRight(line, " "), line, "trailing whitespace")
+	}
+
+	// The proposal is a loadable, valid config
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	cfg := NewConfig()
+	require.NoError(t, cfg.LoadFromFile(path, logrus.New()))
+	require.NoError(t, cfg.Validate())
+
+	assert.Equal(t, "clickhouse://localhost:9000/analytics", cfg.DSN)
+	assert.Equal(t, []string{"fct_attestation", "fct_block", "tmp_backfill", "tmp_replay", "dim_node", "true", "metrics.fct_usage"}, cfg.Tables)
+	// Tables span two databases, so the package isn't named after one
+	assert.Equal(t, "clickhouse.v1", cfg.Package)
+	assert.True(t, cfg.EnableAPI)
+	assert.Len(t, cfg.Policies, 2, "staging prefixes and single tables aren't suggested")
+	assert.True(t, cfg.Policy("fct_block").API)
+	assert.False(t, cfg.Policy("dim_node").API)
+	assert.False(t, cfg.Policy("tmp_backfill").API)
+}
+
+func TestScaffold_Package(t *testing.T) {
+	tests := []struct {
+		name     string
+		database string
+		tables   []ScaffoldTable
+		expected string
+	}{
+		{name: "Single database", database: "analytics", tables: []ScaffoldTable{{Database: "analytics", Name: "users"}}, expected: "analytics.v1"},
+		{name: "Sanitized", tables: []ScaffoldTable{{Database: "Beacon-API", Name: "blocks"}}, expected: "beacon_api.v1"},
+		{name: "Default database", tables: []ScaffoldTable{{Database: "default", Name: "users"}}, expected: "clickhouse.v1"},
+		{name: "DDL without database", tables: []ScaffoldTable{{Name: "users"}}, expected: "clickhouse.v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, scaffoldPackage(tt.tables, tt.database))
+		})
+	}
+}
+
+func TestScaffold_FromDDL(t *testing.T) {
+	data, err := Scaffold(ScaffoldOptions{
+		FromDDL: []string{"schema/*.sql"},
+		Tables:  []ScaffoldTable{{Name: "users", Engine: "MergeTree"}},
+	})
+	require.NoError(t, err)
+
+	content := string(data)
+	assert.Contains(t, content, "from_ddl:\n  - schema/*.sql\n")
+	assert.NotContains(t, content, "dsn:")
+	assert.Contains(t, content, "enable_api: false")
+	assert.NotContains(t, content, "policies:")
+}
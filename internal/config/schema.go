@@ -0,0 +1,169 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configSource is this package's config definition, parsed for the doc comments of the
+// settings so the published schema describes them without repeating the comments
+//
+//go:embed config.go
+var configSource string //nolint:gochecknoglobals // embedded file
+
+// schemaID is where the published schema lives, for editors resolving it by URL
+const schemaID = "https://raw.githubusercontent.com/ethpandaops/clickhouse-proto-gen/master/config.schema.json"
+
+// schemaEnums lists the choices of settings with a fixed set of values, keyed by the Go
+// type and YAML key of the setting
+func schemaEnums() map[string][]string {
+	return map[string][]string{
+		"Config.on_error":            {OnErrorFail, OnErrorSkip, OnErrorReport},
+		"Config.field_order":         {FieldOrderPosition, FieldOrderName},
+		"Config.syntax":              {SyntaxProto3, SyntaxProto2, SyntaxEdition2023},
+		"Config.emit":                {EmitMessages, EmitServices, EmitREST, EmitSQL, EmitAnnotations},
+		"Config.sql_parameters":      {SQLParametersBound, SQLParametersServer},
+		"FieldNumberConfig.strategy": {FieldNumbersPosition, FieldNumbersHash, FieldNumbersLock},
+		"ColumnConfig.mask":          {MaskHash, MaskNull, MaskOmit},
+		"ColumnConfig.type":          {ColumnTypeString, ColumnTypeBytes, ColumnTypeBool},
+		"ColumnConfig.encoding":      {EncodingVarint, EncodingZigzag, EncodingFixed},
+		"TopologyConfig.target":      {TargetDistributed, TargetLocal},
+		"ArrowConfig.format":         {ArrowFormatJSON, ArrowFormatGo},
+	}
+}
+
+// JSONSchema returns the JSON Schema of the config file, for validation and completion
+// in editors
+func JSONSchema() ([]byte, error) {
+	builder := &schemaBuilder{docs: fieldDocs(), enums: schemaEnums()}
+
+	schema := builder.typeSchema(reflect.TypeOf(Config{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = schemaID
+	schema["title"] = "clickhouse-proto-gen config"
+
+	// Descriptions mention <table> and the like; keep them readable
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type schemaBuilder struct {
+	docs  map[string]string
+	enums map[string][]string
+}
+
+// typeSchema describes a Go type of the config as a JSON Schema
+func (b *schemaBuilder) typeSchema(t reflect.Type) map[string]any {
+	if t == reflect.TypeOf(yaml.Node{}) {
+		// Profiles hold the settings of the whole file, minus further profiles
+		return map[string]any{"$ref": "#", "not": map[string]any{"required": []string{"profiles"}}}
+	}
+	if t.Kind() == reflect.Pointer {
+		return b.typeSchema(t.Elem())
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return map[string]any{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]any)
+		for _, field := range yamlFields(t) {
+			property := b.typeSchema(field.Type)
+			id := t.Name() + "." + field.Key
+			if doc := b.docs[t.Name()+"."+field.Name]; doc != "" {
+				property["description"] = doc
+			}
+			if values, ok := b.enums[id]; ok {
+				if field.Type.Kind() == reflect.Slice {
+					property["items"] = map[string]any{"type": "string", "enum": values}
+				} else {
+					property["enum"] = values
+				}
+			}
+			properties[field.Key] = property
+		}
+		return map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.typeSchema(t.Elem())}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": b.typeSchema(t.Elem())}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// yamlField is a struct field of the config with its YAML key
+type yamlField struct {
+	Name string
+	Key  string
+	Type reflect.Type
+}
+
+// yamlFields lists the fields of a config struct that are read from YAML
+func yamlFields(t reflect.Type) []yamlField {
+	fields := make([]yamlField, 0, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || key == "-" || key == "" {
+			continue
+		}
+		fields = append(fields, yamlField{Name: field.Name, Key: key, Type: field.Type})
+	}
+	return fields
+}
+
+// fieldDocs reads the comments of the config struct fields, keyed by type and field name.
+// A comment after the field wins over the one above it, which may head a group of fields.
+func fieldDocs() map[string]string {
+	docs := make(map[string]string)
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", configSource, parser.ParseComments)
+	if err != nil {
+		return docs
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		structType, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return false
+		}
+		for _, field := range structType.Fields.List {
+			doc := field.Comment.Text()
+			if doc == "" {
+				doc = field.Doc.Text()
+			}
+			for _, name := range field.Names {
+				docs[spec.Name.Name+"."+name.Name] = strings.Join(strings.Fields(doc), " ")
+			}
+		}
+		return false
+	})
+	return docs
+}
@@ -0,0 +1,179 @@
+// Package mockdata fills tables of a development ClickHouse server with random rows that
+// respect the column types, so generated APIs can be tried without production data
+package mockdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+)
+
+// Mock data errors
+var (
+	ErrDSNRequired     = errors.New("mock data requires a DSN")
+	ErrNoInsertColumns = errors.New("no column values can be generated")
+)
+
+// defaultBatchSize is the number of rows per INSERT when Options.BatchSize is unset
+const defaultBatchSize = 10_000
+
+// Options configures a mock data run
+type Options struct {
+	// DSN of the ClickHouse server the rows are inserted into
+	DSN string
+	// Rows is the number of rows inserted per table
+	Rows int
+	// BatchSize is the number of rows per INSERT statement
+	BatchSize int
+	// Seed makes the generated values reproducible
+	Seed int64
+	// Now is the reference time of generated dates, time.Now() when zero
+	Now time.Time
+}
+
+// Run inserts opts.Rows random rows into each table. Views are skipped since they can't
+// be inserted into.
+func Run(ctx context.Context, opts Options, tables []*clickhouse.Table, log logrus.FieldLogger) error {
+	if opts.DSN == "" {
+		return ErrDSNRequired
+	}
+	log = log.WithField("component", "mockdata")
+
+	conn, err := connect(ctx, opts.DSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return forEachInsert(opts, tables, log, func(table *clickhouse.Table, statement string) error {
+		if err := conn.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", table.Name, err)
+		}
+		return nil
+	})
+}
+
+// Statements returns the INSERT statements Run would execute, for a dry run
+func Statements(opts Options, tables []*clickhouse.Table, log logrus.FieldLogger) ([]string, error) {
+	var statements []string
+	err := forEachInsert(opts, tables, log, func(_ *clickhouse.Table, statement string) error {
+		statements = append(statements, statement)
+		return nil
+	})
+	return statements, err
+}
+
+// forEachInsert builds the INSERT statements of every table and passes them to insert
+func forEachInsert(opts Options, tables []*clickhouse.Table, log logrus.FieldLogger, insert func(*clickhouse.Table, string) error) error {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	values := &valueGenerator{rng: rand.New(rand.NewSource(opts.Seed)), now: opts.Now, rows: opts.Rows} //nolint:gosec // Sample data, not security sensitive
+
+	for _, table := range tables {
+		if strings.Contains(table.Engine, "View") {
+			log.WithField("table", table.Name).Warn("Skipping view, rows can only be inserted into tables")
+			continue
+		}
+
+		columns, skipped := insertColumns(table)
+		if len(skipped) > 0 {
+			log.WithFields(logrus.Fields{"table": table.Name, "columns": skipped}).Warn("Leaving columns of unsupported types to their defaults")
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("%w for %s", ErrNoInsertColumns, table.Name)
+		}
+
+		for start := 0; start < opts.Rows; start += opts.BatchSize {
+			end := min(start+opts.BatchSize, opts.Rows)
+			if err := insert(table, buildInsert(table, columns, values, start, end)); err != nil {
+				return err
+			}
+		}
+		log.WithFields(logrus.Fields{"table": table.Name, "rows": opts.Rows}).Info("Generated mock rows")
+	}
+	return nil
+}
+
+// insertColumns returns the columns values are generated for, and the names of columns
+// left to their defaults because their type isn't supported. MATERIALIZED and ALIAS
+// columns can't be inserted and are left out silently.
+func insertColumns(table *clickhouse.Table) (columns []clickhouse.Column, skipped []string) {
+	for _, column := range table.Columns {
+		switch {
+		case column.DefaultKind == "MATERIALIZED" || column.DefaultKind == "ALIAS":
+		case supported(column.Type):
+			columns = append(columns, column)
+		default:
+			skipped = append(skipped, column.Name)
+		}
+	}
+	return columns, skipped
+}
+
+// buildInsert renders `INSERT INTO db.table (columns) VALUES` with rows [start, end)
+func buildInsert(table *clickhouse.Table, columns []clickhouse.Column, values *valueGenerator, start, end int) string {
+	var sb strings.Builder
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = quoteIdentifier(column.Name)
+	}
+	target := quoteIdentifier(table.Name)
+	if table.Database != "" {
+		target = quoteIdentifier(table.Database) + "." + target
+	}
+	fmt.Fprintf(&sb, "INSERT INTO %s (%s) VALUES\n", target, strings.Join(names, ", "))
+
+	// The first sorting key column increases with the row
+	sequential := ""
+	if len(table.SortingKey) > 0 {
+		sequential = table.SortingKey[0]
+	}
+
+	row := make([]string, len(columns))
+	for r := start; r < end; r++ {
+		for i, column := range columns {
+			row[i] = values.literal(column.Type, column.Name, r, column.Name == sequential)
+		}
+		fmt.Fprintf(&sb, "(%s)", strings.Join(row, ", "))
+		if r < end-1 {
+			sb.WriteString(",\n")
+		}
+	}
+	return sb.String()
+}
+
+// quoteIdentifier always quotes a name, so keywords like `index` stay unambiguous
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+func connect(ctx context.Context, dsn string) (driver.Conn, error) {
+	options, err := ch.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DSN: %w", err)
+	}
+
+	conn, err := ch.Open(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection: %w", err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return conn, nil
+}
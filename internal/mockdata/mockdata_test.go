@@ -0,0 +1,131 @@
+package mockdata
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:gochecknoglobals // Fixed reference time of the tests
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestGenerator() *valueGenerator {
+	return &valueGenerator{rng: rand.New(rand.NewSource(1)), now: testNow, rows: 10} //nolint:gosec // Test data
+}
+
+func TestParseType(t *testing.T) {
+	name, args := parseType("Map(LowCardinality(String), Array(Tuple(a UInt8, b String)))")
+	assert.Equal(t, "Map", name)
+	assert.Equal(t, []string{"LowCardinality(String)", "Array(Tuple(a UInt8, b String))"}, args)
+
+	name, args = parseType("Enum8('a, b' = 1, 'it\\'s' = 2)")
+	assert.Equal(t, "Enum8", name)
+	assert.Equal(t, []string{"'a, b' = 1", "'it\\'s' = 2"}, args)
+
+	name, args = parseType("UInt64")
+	assert.Equal(t, "UInt64", name)
+	assert.Nil(t, args)
+}
+
+func TestSupported(t *testing.T) {
+	for _, chType := range []string{"UInt64", "Nullable(Decimal(18, 4))", "Array(LowCardinality(String))", "Map(String, Array(UInt32))", "Tuple(count UInt8, String)", "DateTime64(3, 'UTC')"} {
+		assert.True(t, supported(chType), chType)
+	}
+	for _, chType := range []string{"Point", "JSON", "Array(Point)", "Map(String, Variant(String, UInt64))", "Tuple()"} {
+		assert.False(t, supported(chType), chType)
+	}
+}
+
+func TestValueGenerator_Literal(t *testing.T) {
+	v := newTestGenerator()
+
+	t.Run("Integer ranges", func(t *testing.T) {
+		for range 100 {
+			value := v.literal("UInt8", "count", 0, false)
+			assert.NotContains(t, value, "-")
+			assert.LessOrEqual(t, len(value), 3)
+		}
+	})
+
+	t.Run("Enum members", func(t *testing.T) {
+		for range 20 {
+			assert.Contains(t, []string{"'canonical'", "'orphaned'"}, v.literal("Enum8('canonical' = 1, 'orphaned' = -2)", "status", 0, false))
+		}
+	})
+
+	t.Run("FixedString length", func(t *testing.T) {
+		assert.Len(t, v.literal("FixedString(66)", "block_root", 0, false), 66+2)
+		assert.Len(t, v.literal("FixedString(2)", "code", 0, false), 2+2)
+	})
+
+	t.Run("Decimal scale", func(t *testing.T) {
+		value := v.literal("Decimal(9, 3)", "fee", 0, false)
+		_, fraction, ok := strings.Cut(value, ".")
+		require.True(t, ok)
+		assert.Len(t, fraction, 3)
+	})
+
+	t.Run("DateTime64 precision", func(t *testing.T) {
+		assert.Regexp(t, `^'\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{6}'$`, v.literal("DateTime64(6, 'UTC')", "ts", 0, false))
+	})
+
+	t.Run("Sequential values", func(t *testing.T) {
+		assert.Equal(t, "1000003", v.literal("UInt64", "slot", 3, true))
+		assert.Equal(t, "'2025-06-01 11:58:36'", v.literal("DateTime", "slot_start_date_time", 3, true))
+		assert.Equal(t, "'2025-05-25'", v.literal("Nullable(Date)", "day", 3, true), "sequential values are never NULL")
+	})
+
+	t.Run("Hex for hashes", func(t *testing.T) {
+		assert.Regexp(t, `^'0x[0-9a-f]{40}'$`, v.literal("String", "fee_recipient_address", 0, false))
+	})
+
+	t.Run("Nullable", func(t *testing.T) {
+		nulls := 0
+		for range 1000 {
+			if v.literal("Nullable(String)", "name", 0, false) == "NULL" {
+				nulls++
+			}
+		}
+		assert.InDelta(t, 100, nulls, 50)
+	})
+}
+
+func TestStatements(t *testing.T) {
+	tables := []*clickhouse.Table{
+		{
+			Name:       "blocks",
+			Database:   "dev",
+			Engine:     "MergeTree",
+			SortingKey: []string{"slot"},
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt32"},
+				{Name: "index", Type: "LowCardinality(String)"},
+				{Name: "location", Type: "Point"},
+				{Name: "doubled", Type: "UInt64", DefaultKind: "MATERIALIZED", DefaultValue: "slot * 2"},
+			},
+		},
+		{Name: "blocks_view", Engine: "View", Columns: []clickhouse.Column{{Name: "slot", Type: "UInt32"}}},
+	}
+	opts := Options{Rows: 5, BatchSize: 2, Seed: 7, Now: testNow}
+
+	statements, err := Statements(opts, tables, logrus.New())
+	require.NoError(t, err)
+	require.Len(t, statements, 3, "5 rows in batches of 2, views skipped")
+
+	assert.True(t, strings.HasPrefix(statements[0], "INSERT INTO `dev`.`blocks` (`slot`, `index`) VALUES\n(1000000, '"))
+	assert.Contains(t, statements[2], "(1000004, '")
+	assert.Equal(t, 1, strings.Count(statements[2], "("+"1000"), "last batch has the remaining row")
+
+	again, err := Statements(opts, tables, logrus.New())
+	require.NoError(t, err)
+	assert.Equal(t, statements, again, "the seed makes rows reproducible")
+
+	_, err = Statements(opts, []*clickhouse.Table{{Name: "shapes", Columns: []clickhouse.Column{{Name: "p", Type: "Point"}}}}, logrus.New())
+	require.ErrorIs(t, err, ErrNoInsertColumns)
+}
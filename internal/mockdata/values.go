@@ -0,0 +1,327 @@
+package mockdata
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slotInterval spaces the rows of a DateTime sorting key column, like blocks or slots
+const slotInterval = 12 * time.Second
+
+// nullRatio is the share of NULLs generated for Nullable columns
+const nullRatio = 0.1
+
+// lowCardinalityPool holds the values of LowCardinality(String) columns; earlier entries are
+// picked more often so the distribution looks like real categorical data
+//
+//nolint:gochecknoglobals // Fixed pool of sample values
+var lowCardinalityPool = []string{"lighthouse", "prysm", "teku", "nimbus", "lodestar", "grandine", "erigon", "reth"}
+
+// words are used for free-form String columns
+//
+//nolint:gochecknoglobals // Fixed pool of sample values
+var words = []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet", "kilo", "lima"}
+
+// valueGenerator renders random values of ClickHouse types as SQL literals
+type valueGenerator struct {
+	rng  *rand.Rand
+	now  time.Time
+	rows int // Rows generated per table, used to lay out sequential values
+}
+
+// supported reports whether values of the type can be generated. Columns of other types
+// are left out of the INSERT, so they get their default.
+func supported(chType string) bool {
+	name, args := parseType(chType)
+	switch name {
+	case "Nullable", "LowCardinality", "Array":
+		return len(args) == 1 && supported(args[0])
+	case "Map":
+		return len(args) == 2 && supported(args[0]) && supported(args[1])
+	case "Tuple":
+		for _, arg := range args {
+			if !supported(tupleElementType(arg)) {
+				return false
+			}
+		}
+		return len(args) > 0
+	}
+	_, ok := scalarKinds[name]
+	return ok
+}
+
+// scalarKinds lists the scalar types values are generated for
+//
+//nolint:gochecknoglobals // Lookup table
+var scalarKinds = map[string]bool{
+	"Int8": true, "Int16": true, "Int32": true, "Int64": true, "Int128": true, "Int256": true,
+	"UInt8": true, "UInt16": true, "UInt32": true, "UInt64": true, "UInt128": true, "UInt256": true,
+	"Float32": true, "Float64": true, "Bool": true, "String": true, "FixedString": true, "UUID": true,
+	"Date": true, "Date32": true, "DateTime": true, "DateTime64": true, "IPv4": true, "IPv6": true,
+	"Enum8": true, "Enum16": true, "Decimal": true, "Decimal32": true, "Decimal64": true,
+	"Decimal128": true, "Decimal256": true,
+}
+
+// literal returns a random value of chType for the given column and row. Values of the
+// first sorting key column increase with the row, so generated tables page naturally.
+func (v *valueGenerator) literal(chType, column string, row int, sequential bool) string {
+	name, args := parseType(chType)
+
+	switch name {
+	case "Nullable":
+		if !sequential && v.rng.Float64() < nullRatio {
+			return "NULL"
+		}
+		return v.literal(args[0], column, row, sequential)
+	case "LowCardinality":
+		if inner, _ := parseType(args[0]); inner == "String" {
+			return quote(lowCardinalityPool[int(v.rng.ExpFloat64()*2)%len(lowCardinalityPool)])
+		}
+		return v.literal(args[0], column, row, sequential)
+	case "Array":
+		elements := make([]string, v.rng.Intn(4))
+		for i := range elements {
+			elements[i] = v.literal(args[0], column, row, false)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case "Map":
+		entries := make([]string, 0, 3)
+		for i := range v.rng.Intn(4) {
+			entries = append(entries, v.literal(args[0], fmt.Sprintf("%s_%d", column, i), row, false)+": "+v.literal(args[1], column, row, false))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	case "Tuple":
+		elements := make([]string, len(args))
+		for i, arg := range args {
+			elements[i] = v.literal(tupleElementType(arg), column, row, false)
+		}
+		return "(" + strings.Join(elements, ", ") + ")"
+	}
+
+	if sequential {
+		if value, ok := v.sequentialLiteral(name, args, row); ok {
+			return value
+		}
+	}
+	return v.scalarLiteral(name, args, column)
+}
+
+// sequentialLiteral returns an increasing value for integer and date/time types
+func (v *valueGenerator) sequentialLiteral(name string, args []string, row int) (string, bool) {
+	switch name {
+	case "Int8", "Int16", "UInt8", "UInt16":
+		return strconv.Itoa(row % 100), true
+	case "Int32", "Int64", "Int128", "Int256", "UInt32", "UInt64", "UInt128", "UInt256":
+		return strconv.Itoa(1_000_000 + row), true
+	case "DateTime", "DateTime64":
+		start := v.now.Add(-time.Duration(v.rows) * slotInterval).Truncate(time.Second)
+		return v.timeLiteral(name, args, start.Add(time.Duration(row)*slotInterval)), true
+	case "Date", "Date32":
+		return quote(v.now.AddDate(0, 0, row-v.rows).Format(time.DateOnly)), true
+	}
+	return "", false
+}
+
+func (v *valueGenerator) scalarLiteral(name string, args []string, column string) string {
+	switch name {
+	case "Int8", "Int16", "Int32", "Int64", "Int128", "Int256", "UInt8", "UInt16", "UInt32", "UInt64", "UInt128", "UInt256":
+		return strconv.FormatInt(v.integer(name), 10)
+	case "Float32", "Float64":
+		return strconv.FormatFloat(float64(v.rng.Intn(10_000_000))/10_000, 'f', -1, 64)
+	case "Decimal", "Decimal32", "Decimal64", "Decimal128", "Decimal256":
+		return v.decimal(name, args)
+	case "Bool":
+		return strconv.FormatBool(v.rng.Intn(2) == 1)
+	case "Date", "Date32":
+		return quote(v.now.AddDate(0, 0, -v.rng.Intn(365)).Format(time.DateOnly))
+	case "DateTime", "DateTime64":
+		return v.timeLiteral(name, args, v.now.Add(-time.Duration(v.rng.Int63n(int64(30*24*time.Hour)))))
+	case "UUID":
+		return quote(v.uuid())
+	case "IPv4":
+		return quote(fmt.Sprintf("10.%d.%d.%d", v.rng.Intn(256), v.rng.Intn(256), 1+v.rng.Intn(254)))
+	case "IPv6":
+		return quote(fmt.Sprintf("2001:db8::%x:%x", v.rng.Intn(0x10000), 1+v.rng.Intn(0xffff)))
+	case "Enum8", "Enum16":
+		return v.enum(args)
+	case "FixedString":
+		size, _ := strconv.Atoi(firstArg(args))
+		return quote(v.fixedString(column, size))
+	}
+	return quote(v.text(column))
+}
+
+// integer returns a value in a plausible range of the type: small types use their full
+// range, larger ones stay below a million (signed types around zero)
+func (v *valueGenerator) integer(name string) int64 {
+	limit := int64(1_000_000)
+	switch name {
+	case "UInt8":
+		limit = 256
+	case "Int8":
+		limit = 128
+	case "UInt16":
+		limit = 65_536
+	case "Int16":
+		limit = 32_768
+	}
+	if strings.HasPrefix(name, "U") {
+		return v.rng.Int63n(limit)
+	}
+	return v.rng.Int63n(2*limit) - limit
+}
+
+// decimal returns a value with the scale of Decimal(P, S) or DecimalN(S)
+func (v *valueGenerator) decimal(name string, args []string) string {
+	precision, scale := 10, 0
+	if name == "Decimal" && len(args) == 2 {
+		precision, _ = strconv.Atoi(args[0])
+		scale, _ = strconv.Atoi(args[1])
+	} else if len(args) == 1 {
+		scale, _ = strconv.Atoi(args[0])
+		precision = map[string]int{"Decimal32": 9, "Decimal64": 18, "Decimal128": 38, "Decimal256": 76}[name]
+	}
+
+	digits := min(precision-scale, 6)
+	value := strconv.Itoa(v.rng.Intn(intPow10(max(digits, 0))))
+	if scale > 0 {
+		fraction := make([]byte, scale)
+		for i := range fraction {
+			fraction[i] = byte('0' + v.rng.Intn(10))
+		}
+		value += "." + string(fraction)
+	}
+	return value
+}
+
+// timeLiteral formats t for DateTime or DateTime64(precision[, timezone])
+func (v *valueGenerator) timeLiteral(name string, args []string, t time.Time) string {
+	layout := time.DateTime
+	if precision, err := strconv.Atoi(firstArg(args)); err == nil && name == "DateTime64" && precision > 0 {
+		layout += "." + strings.Repeat("0", precision)
+	}
+	return quote(t.UTC().Format(layout))
+}
+
+func (v *valueGenerator) enum(args []string) string {
+	if len(args) == 0 {
+		return "''"
+	}
+	member := args[v.rng.Intn(len(args))]
+	if idx := strings.LastIndex(member, "="); idx > 0 {
+		member = strings.TrimSpace(member[:idx])
+	}
+	return member
+}
+
+func (v *valueGenerator) uuid() string {
+	b := make([]byte, 16)
+	v.rng.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// text returns a value fitting the column's name: hex for hashes, roots and addresses,
+// otherwise a word with a number
+func (v *valueGenerator) text(column string) string {
+	lower := strings.ToLower(column)
+	switch {
+	case strings.Contains(lower, "address"):
+		return "0x" + v.hex(20)
+	case strings.Contains(lower, "hash"), strings.Contains(lower, "root"):
+		return "0x" + v.hex(32)
+	}
+	return fmt.Sprintf("%s-%d", words[v.rng.Intn(len(words))], v.rng.Intn(1000))
+}
+
+// fixedString returns a value of exactly size bytes
+func (v *valueGenerator) fixedString(column string, size int) string {
+	value := v.text(column)
+	if !strings.HasPrefix(value, "0x") && size >= 4 {
+		value = "0x" + v.hex(size)
+	}
+	for len(value) < size {
+		value += v.hex(size)
+	}
+	return value[:size]
+}
+
+func (v *valueGenerator) hex(size int) string {
+	b := make([]byte, size)
+	v.rng.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// parseType splits `Name(arg, arg)` into its name and top-level arguments
+func parseType(chType string) (name string, args []string) {
+	chType = strings.TrimSpace(chType)
+	open := strings.Index(chType, "(")
+	if open < 0 || !strings.HasSuffix(chType, ")") {
+		return chType, nil
+	}
+	return chType[:open], splitArgs(chType[open+1 : len(chType)-1])
+}
+
+// splitArgs splits on commas outside parentheses and quotes
+func splitArgs(s string) []string {
+	var (
+		args   []string
+		depth  int
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		args = append(args, rest)
+	}
+	return args
+}
+
+// tupleElementType strips the name of a named tuple element, e.g. `count UInt64`
+func tupleElementType(element string) string {
+	name, chType, ok := strings.Cut(element, " ")
+	if ok && !strings.Contains(name, "(") {
+		return strings.TrimSpace(chType)
+	}
+	return element
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+func intPow10(n int) int {
+	result := 1
+	for range n {
+		result *= 10
+	}
+	return result
+}
+
+// quote renders a ClickHouse string literal
+func quote(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + replacer.Replace(s) + "'"
+}
@@ -0,0 +1,274 @@
+// Package plugin implements protoc-gen-clickhouse, a protoc/buf plugin that checks existing
+// protos against a ClickHouse schema snapshot and emits the Go SQL helpers for them
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/protogen"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Plugin errors
+var (
+	ErrSchemaRequired   = errors.New("schema parameter is required (e.g. --clickhouse_opt=schema=schema/*.sql)")
+	ErrInvalidParameter = errors.New("invalid plugin parameter")
+	ErrSchemaMismatch   = errors.New("protos don't match the ClickHouse schema")
+	ErrMissingGoPackage = errors.New("go_package option is required")
+)
+
+// options are the plugin parameters passed with --clickhouse_opt
+type options struct {
+	schema         []string // DDL files, globs or directories
+	configFile     string   // clickhouse-proto-gen config with conversions, masks and tenant settings
+	sourceRelative bool     // paths=source_relative
+	module         string   // module= prefix stripped from output paths
+}
+
+// outputPackage groups the tables whose helpers share a Go package and output directory
+type outputPackage struct {
+	goPackage string
+	dir       string
+	tables    []*clickhouse.Table
+}
+
+// Run handles a code generator request. Problems with the protos are reported in the
+// response's error field, as protoc expects.
+func Run(ctx context.Context, req *pluginpb.CodeGeneratorRequest, log logrus.FieldLogger) *pluginpb.CodeGeneratorResponse {
+	resp := &pluginpb.CodeGeneratorResponse{
+		SupportedFeatures: proto.Uint64(uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)),
+	}
+
+	files, err := generate(ctx, req, log)
+	if err != nil {
+		resp.Error = proto.String(err.Error())
+		return resp
+	}
+
+	resp.File = files
+	return resp
+}
+
+func generate(ctx context.Context, req *pluginpb.CodeGeneratorRequest, log logrus.FieldLogger) ([]*pluginpb.CodeGeneratorResponse_File, error) {
+	opts, err := parseParameter(req.GetParameter())
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.NewConfig()
+	if opts.configFile != "" {
+		if err := cfg.LoadFromFile(opts.configFile, log); err != nil {
+			return nil, err
+		}
+	}
+
+	tables, err := loadSchema(ctx, opts.schema, log)
+	if err != nil {
+		return nil, err
+	}
+	if cfg, tables, err = protogen.ApplyCommentDirectives(cfg, tables); err != nil {
+		return nil, err
+	}
+	if tables, err = protogen.NewGenerator(cfg, log).ApplyViewKeys(tables); err != nil {
+		return nil, err
+	}
+
+	packages, err := matchTables(req, tables, cfg, opts, log)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*pluginpb.CodeGeneratorResponse_File
+	for _, pkg := range packages {
+		pkgCfg := *cfg
+		pkgCfg.GoPackage = pkg.goPackage
+		pkgCfg.OutputDir = ""
+		pkgCfg.GoModule = config.GoModuleConfig{}
+
+		gen := protogen.NewGenerator(&pkgCfg, log)
+		gen.SetOutput(func(filename, content string) error {
+			files = append(files, &pluginpb.CodeGeneratorResponse_File{
+				Name:    proto.String(path.Join(pkg.dir, filename)),
+				Content: proto.String(content),
+			})
+			return nil
+		})
+
+		if err := gen.GenerateSQLHelpers(pkg.tables); err != nil {
+			return nil, fmt.Errorf("failed to generate SQL helpers for %s: %w", pkg.goPackage, err)
+		}
+	}
+
+	return files, nil
+}
+
+// parseParameter parses the comma-separated key=value plugin parameter. schema may be repeated.
+func parseParameter(parameter string) (*options, error) {
+	opts := &options{}
+
+	for _, param := range strings.Split(parameter, ",") {
+		if param == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(param, "=")
+		switch key {
+		case "schema":
+			opts.schema = append(opts.schema, value)
+		case "config":
+			opts.configFile = value
+		case "module":
+			opts.module = value
+		case "paths":
+			switch value {
+			case "import":
+				opts.sourceRelative = false
+			case "source_relative":
+				opts.sourceRelative = true
+			default:
+				return nil, fmt.Errorf("%w: paths=%s (must be import or source_relative)", ErrInvalidParameter, value)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidParameter, param)
+		}
+	}
+
+	if len(opts.schema) == 0 {
+		return nil, ErrSchemaRequired
+	}
+	return opts, nil
+}
+
+// loadSchema reads every table defined in the DDL snapshot
+func loadSchema(ctx context.Context, patterns []string, log logrus.FieldLogger) ([]*clickhouse.Table, error) {
+	svc := clickhouse.NewDDLService(patterns, log)
+	if err := svc.Connect(ctx); err != nil {
+		return nil, err
+	}
+	defer svc.Close()
+
+	names, err := svc.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]*clickhouse.Table, 0, len(names))
+	for _, name := range names {
+		database, table, ok := strings.Cut(name, ".")
+		if !ok {
+			database, table = "default", name
+		}
+
+		t, err := svc.GetTable(ctx, database, table)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// matchTables pairs the messages of the files to generate with schema tables of the same
+// name, validates them and groups the tables by output package
+func matchTables(req *pluginpb.CodeGeneratorRequest, tables []*clickhouse.Table, cfg *config.Config, opts *options, log logrus.FieldLogger) ([]*outputPackage, error) {
+	byMessage := make(map[string]*clickhouse.Table, len(tables))
+	for _, table := range tables {
+		name := protogen.ToPascalCase(table.Name)
+		if _, exists := byMessage[name]; !exists {
+			byMessage[name] = table
+		}
+	}
+
+	generate := make(map[string]bool, len(req.GetFileToGenerate()))
+	for _, name := range req.GetFileToGenerate() {
+		generate[name] = true
+	}
+
+	// Messages are checked against the numbers the generator would assign them
+	validator := protogen.NewGenerator(cfg, log)
+	if err := validator.LoadFieldNumberLock(); err != nil {
+		return nil, err
+	}
+	packages := make(map[string]*outputPackage)
+	var mismatches []string
+
+	for _, file := range req.GetProtoFile() {
+		if !generate[file.GetName()] {
+			continue
+		}
+
+		for _, msg := range file.GetMessageType() {
+			table, ok := byMessage[msg.GetName()]
+			if !ok {
+				continue
+			}
+
+			problems := validateMessage(validator, table, file, msg)
+			if len(problems) > 0 {
+				mismatches = append(mismatches, problems...)
+				continue
+			}
+
+			pkg, err := packageFor(packages, file, opts)
+			if err != nil {
+				return nil, err
+			}
+			pkg.tables = append(pkg.tables, table)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return nil, fmt.Errorf("%w:\n  %s", ErrSchemaMismatch, strings.Join(mismatches, "\n  "))
+	}
+
+	result := make([]*outputPackage, 0, len(packages))
+	for _, pkg := range packages {
+		result = append(result, pkg)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].dir < result[j].dir })
+	return result, nil
+}
+
+// packageFor returns the output package of a file, creating it on first use
+func packageFor(packages map[string]*outputPackage, file *descriptorpb.FileDescriptorProto, opts *options) (*outputPackage, error) {
+	goPackage := file.GetOptions().GetGoPackage()
+	if goPackage == "" {
+		return nil, fmt.Errorf("%w in %s", ErrMissingGoPackage, file.GetName())
+	}
+
+	dir := outputDir(file, goPackage, opts)
+	key := dir + "\x00" + goPackage
+	if pkg, ok := packages[key]; ok {
+		return pkg, nil
+	}
+
+	pkg := &outputPackage{goPackage: goPackage, dir: dir}
+	packages[key] = pkg
+	return pkg, nil
+}
+
+// outputDir mirrors where protoc-gen-go places a file's Go code
+func outputDir(file *descriptorpb.FileDescriptorProto, goPackage string, opts *options) string {
+	if opts.sourceRelative {
+		return path.Dir(file.GetName())
+	}
+
+	importPath, _, _ := strings.Cut(goPackage, ";")
+	if opts.module != "" {
+		trimmed := strings.TrimPrefix(importPath, opts.module)
+		if trimmed != importPath {
+			return strings.TrimPrefix(trimmed, "/")
+		}
+	}
+	return importPath
+}
@@ -0,0 +1,178 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const testUsersDDL = `
+CREATE TABLE db.users (
+    id UInt64,
+    name Nullable(String),
+    tags Array(String),
+    attrs Map(String, UInt64)
+) ENGINE = MergeTree ORDER BY id;
+`
+
+func writeSchema(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	require.NoError(t, os.WriteFile(path, []byte(testUsersDDL), 0o600))
+	return path
+}
+
+func scalarField(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Type:   fieldType.Enum(),
+		Label:  label.Enum(),
+	}
+}
+
+func messageField(name string, number int32, typeName string, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+	field := scalarField(name, number, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, label)
+	field.TypeName = proto.String(typeName)
+	return field
+}
+
+// usersFile returns the descriptor of a users.proto as clickhouse-proto-gen generates it
+func usersFile() *descriptorpb.FileDescriptorProto {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	users := &descriptorpb.DescriptorProto{
+		Name: proto.String("Users"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			scalarField("id", 11, descriptorpb.FieldDescriptorProto_TYPE_UINT64, optional),
+			messageField("name", 12, ".google.protobuf.StringValue", optional),
+			scalarField("tags", 13, descriptorpb.FieldDescriptorProto_TYPE_STRING, repeated),
+			messageField("attrs", 14, ".test.v1.Users.AttrsEntry", repeated),
+		},
+		NestedType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("AttrsEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					scalarField("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional),
+					scalarField("value", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT64, optional),
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			},
+		},
+	}
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("users.proto"),
+		Package: proto.String("test.v1"),
+		Options: &descriptorpb.FileOptions{GoPackage: proto.String("github.com/acme/gen/chv1")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			users,
+			{Name: proto.String("ListUsersRequest")},
+			{Name: proto.String("GetUsersRequest")},
+		},
+	}
+}
+
+func runPlugin(t *testing.T, parameter string, file *descriptorpb.FileDescriptorProto) *pluginpb.CodeGeneratorResponse {
+	t.Helper()
+
+	log := logrus.New()
+	log.SetLevel(logrus.WarnLevel)
+
+	return Run(context.Background(), &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		Parameter:      proto.String(parameter),
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	}, log)
+}
+
+func TestRun_GeneratesSQLHelpers(t *testing.T) {
+	schema := writeSchema(t)
+
+	tests := []struct {
+		name      string
+		parameter string
+		dir       string
+	}{
+		{name: "Import path", parameter: "schema=" + schema, dir: "github.com/acme/gen/chv1/"},
+		{name: "Module prefix", parameter: "schema=" + schema + ",module=github.com/acme/gen", dir: "chv1/"},
+		{name: "Source relative", parameter: "schema=" + schema + ",paths=source_relative", dir: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := runPlugin(t, tt.parameter, usersFile())
+			require.Empty(t, resp.GetError())
+
+			files := make(map[string]string, len(resp.GetFile()))
+			for _, file := range resp.GetFile() {
+				files[file.GetName()] = file.GetContent()
+			}
+
+			require.Contains(t, files, tt.dir+"users_sql.go")
+			require.Contains(t, files, tt.dir+"common.go")
+			assert.Contains(t, files[tt.dir+"users_sql.go"], "package chv1\n")
+			assert.Contains(t, files[tt.dir+"users_sql.go"], "func BuildListUsersQuery(req *ListUsersRequest")
+		})
+	}
+}
+
+func TestRun_SchemaMismatch(t *testing.T) {
+	file := usersFile()
+	users := file.GetMessageType()[0]
+	users.Field[1] = scalarField("name", 12, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL)
+	users.Field = append(users.Field, scalarField("email", 15, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL))
+	file.MessageType = file.GetMessageType()[:2]
+
+	resp := runPlugin(t, "schema="+writeSchema(t), file)
+
+	assert.Empty(t, resp.GetFile())
+	assert.Contains(t, resp.GetError(), ErrSchemaMismatch.Error())
+	assert.Contains(t, resp.GetError(), "users.proto: message Users field name has type string, schema expects google.protobuf.StringValue")
+	assert.Contains(t, resp.GetError(), "users.proto: message Users field email has no column in table users")
+	assert.Contains(t, resp.GetError(), "users.proto: message GetUsersRequest is missing")
+}
+
+func TestParseParameter(t *testing.T) {
+	tests := []struct {
+		name        string
+		parameter   string
+		expected    *options
+		expectedErr error
+	}{
+		{
+			name:      "All options",
+			parameter: "schema=a.sql,schema=ddl/,config=gen.yaml,paths=source_relative,module=github.com/acme",
+			expected: &options{
+				schema:         []string{"a.sql", "ddl/"},
+				configFile:     "gen.yaml",
+				sourceRelative: true,
+				module:         "github.com/acme",
+			},
+		},
+		{name: "Missing schema", parameter: "paths=import", expectedErr: ErrSchemaRequired},
+		{name: "Invalid paths", parameter: "schema=a.sql,paths=relative", expectedErr: ErrInvalidParameter},
+		{name: "Unknown option", parameter: "schema=a.sql,tables=users", expectedErr: ErrInvalidParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseParameter(tt.parameter)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, opts)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/protogen"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// validateMessage compares a message with the fields clickhouse-proto-gen would generate
+// for its table and returns one line per difference
+func validateMessage(gen *protogen.Generator, table *clickhouse.Table, file *descriptorpb.FileDescriptorProto, msg *descriptorpb.DescriptorProto) []string {
+	var problems []string
+	prefix := fmt.Sprintf("%s: message %s", file.GetName(), msg.GetName())
+
+	actual := make(map[string]*descriptorpb.FieldDescriptorProto, len(msg.GetField()))
+	for _, field := range msg.GetField() {
+		actual[field.GetName()] = field
+	}
+
+	expected := gen.MessageFields(table)
+	known := make(map[string]bool, len(expected))
+	for _, want := range expected {
+		known[want.Name] = true
+
+		got, ok := actual[want.Name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is missing field %s (%s = %d)", prefix, want.Name, want.Type, want.Number))
+			continue
+		}
+
+		if gotType := fieldType(msg, got); gotType != want.Type {
+			problems = append(problems, fmt.Sprintf("%s field %s has type %s, schema expects %s", prefix, want.Name, gotType, want.Type))
+		}
+		if got.GetNumber() != want.Number {
+			problems = append(problems, fmt.Sprintf("%s field %s has number %d, schema expects %d", prefix, want.Name, got.GetNumber(), want.Number))
+		}
+	}
+
+	for _, field := range msg.GetField() {
+		if !known[field.GetName()] {
+			problems = append(problems, fmt.Sprintf("%s field %s has no column in table %s", prefix, field.GetName(), table.Name))
+		}
+	}
+
+	// The SQL helpers take the List/Get requests generated alongside tables with a sorting key
+	if len(table.SortingKey) > 0 {
+		for _, request := range []string{"List" + msg.GetName() + "Request", "Get" + msg.GetName() + "Request"} {
+			if !hasMessage(file, request) {
+				problems = append(problems, fmt.Sprintf("%s: message %s is missing, the SQL helpers need it", file.GetName(), request))
+			}
+		}
+	}
+
+	return problems
+}
+
+// fieldType renders a field's type the way it appears in a generated proto file,
+// e.g. "repeated string", "map<string, uint64>" or "google.protobuf.UInt32Value"
+func fieldType(msg *descriptorpb.DescriptorProto, field *descriptorpb.FieldDescriptorProto) string {
+	if entry := mapEntry(msg, field); entry != nil {
+		return fmt.Sprintf("map<%s, %s>", scalarOrMessage(entry.GetField()[0]), scalarOrMessage(entry.GetField()[1]))
+	}
+
+	name := scalarOrMessage(field)
+	if field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		return "repeated " + name
+	}
+	return name
+}
+
+// mapEntry returns the synthesized entry message of a map field
+func mapEntry(msg *descriptorpb.DescriptorProto, field *descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	if field.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return nil
+	}
+
+	typeName := field.GetTypeName()
+	for _, nested := range msg.GetNestedType() {
+		if nested.GetOptions().GetMapEntry() && strings.HasSuffix(typeName, "."+msg.GetName()+"."+nested.GetName()) && len(nested.GetField()) == 2 {
+			return nested
+		}
+	}
+	return nil
+}
+
+// scalarOrMessage returns the proto scalar name of a field, or its message or enum type name
+func scalarOrMessage(field *descriptorpb.FieldDescriptorProto) string {
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		return strings.TrimPrefix(field.GetTypeName(), ".")
+	default:
+		return strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+	}
+}
+
+func hasMessage(file *descriptorpb.FileDescriptorProto, name string) bool {
+	for _, msg := range file.GetMessageType() {
+		if msg.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
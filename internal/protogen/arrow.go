@@ -0,0 +1,236 @@
+package protogen
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+const (
+	defaultArrowDir     = "arrow"
+	defaultArrowPackage = "arrowschema"
+	arrowGoImport       = "github.com/apache/arrow-go/v18/arrow"
+
+	// Metadata keys linking Arrow fields and schemas back to ClickHouse and the proto
+	arrowMetaTable       = "clickhouse.table"
+	arrowMetaColumnType  = "clickhouse.type"
+	arrowMetaMessage     = "proto.message"
+	arrowMetaFieldNumber = "proto.field_number"
+)
+
+// arrowSchema is a schema in Arrow's JSON representation, as used by the integration tests
+// of the Arrow implementations
+type arrowSchema struct {
+	Fields   []arrowField    `json:"fields"`
+	Metadata []arrowMetadata `json:"metadata,omitempty"`
+}
+
+type arrowField struct {
+	Name     string          `json:"name"`
+	Nullable bool            `json:"nullable"`
+	Type     arrowType       `json:"type"`
+	Children []arrowField    `json:"children"`
+	Metadata []arrowMetadata `json:"metadata,omitempty"`
+}
+
+type arrowType struct {
+	Name       string `json:"name"`
+	BitWidth   int    `json:"bitWidth,omitempty"`
+	IsSigned   *bool  `json:"isSigned,omitempty"`
+	Precision  string `json:"precision,omitempty"`
+	KeysSorted *bool  `json:"keysSorted,omitempty"`
+}
+
+type arrowMetadata struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// GenerateArrow generates an Arrow schema of every table's rows, so services streaming
+// ClickHouse results over Arrow Flight share the proto API's types: fields have the proto
+// field names and their proto types' Arrow equivalents, wrappers are nullable and each
+// field records its ClickHouse type and proto field number as metadata.
+func (g *Generator) GenerateArrow(tables []*clickhouse.Table) error {
+	dir := g.emitterDir(g.config.Arrow.Dir, defaultArrowDir)
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create Arrow directory: %w", err)
+	}
+
+	schemas := make([]arrowSchema, 0, len(tables))
+	for _, table := range tables {
+		schemas = append(schemas, g.arrowSchema(table))
+	}
+
+	if g.config.Arrow.Format == config.ArrowFormatGo {
+		return g.writeFile(filepath.Join(dir, "schemas.go"), g.buildArrowGo(tables, schemas))
+	}
+
+	for i, table := range tables {
+		content, err := json.MarshalIndent(schemas[i], "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode Arrow schema of %s: %w", table.Name, err)
+		}
+		filename := filepath.Join(dir, strings.ToLower(table.Name)+".arrow.json")
+		if err := g.writeFile(filename, string(content)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// arrowSchema converts the row message of a table
+func (g *Generator) arrowSchema(table *clickhouse.Table) arrowSchema {
+	messageName := ToPascalCase(table.Name)
+	if g.config.Package != "" {
+		messageName = g.config.Package + "." + messageName
+	}
+	schema := arrowSchema{
+		Fields: make([]arrowField, 0),
+		Metadata: []arrowMetadata{
+			{Key: arrowMetaTable, Value: table.Name},
+			{Key: arrowMetaMessage, Value: messageName},
+		},
+	}
+
+	columns := make(map[string]*clickhouse.Column, len(table.Columns))
+	for i := range table.Columns {
+		columns[g.fieldName(table.Name, table.Columns[i].Name)] = &table.Columns[i]
+	}
+
+	for _, field := range g.rowMessage(table).Fields {
+		arrow := arrowFieldOf(field)
+		if column, ok := columns[field.Name]; ok {
+			arrow.Metadata = append(arrow.Metadata, arrowMetadata{Key: arrowMetaColumnType, Value: column.Type})
+		}
+		arrow.Metadata = append(arrow.Metadata, arrowMetadata{Key: arrowMetaFieldNumber, Value: strconv.Itoa(field.Number)})
+		schema.Fields = append(schema.Fields, arrow)
+	}
+	return schema
+}
+
+// arrowFieldOf maps a message field: repeated fields become lists of non-nullable items
+// and maps have non-nullable keys, while only wrapper fields are nullable
+func arrowFieldOf(field protoField) arrowField {
+	value := arrowField{Name: field.Name, Type: arrowValueType(field.Type), Nullable: field.Nullable(), Children: []arrowField{}}
+
+	switch {
+	case field.IsMap():
+		keysSorted := false
+		key := arrowField{Name: "key", Type: arrowValueType(field.MapKey), Children: []arrowField{}}
+		value.Name, value.Nullable = "value", true
+		entries := arrowField{Name: "entries", Type: arrowType{Name: "struct"}, Children: []arrowField{key, value}}
+		return arrowField{Name: field.Name, Type: arrowType{Name: "map", KeysSorted: &keysSorted}, Children: []arrowField{entries}}
+	case field.Repeated:
+		value.Name = "item"
+		return arrowField{Name: field.Name, Type: arrowType{Name: "list"}, Children: []arrowField{value}}
+	}
+	return value
+}
+
+// arrowValueType maps a proto scalar or wrapper to an Arrow type. 64-bit integers stay
+// integers: the JSON mapping's strings only exist on the wire.
+func arrowValueType(protoType string) arrowType {
+	scalar := wrapperScalar(protoType)
+	if scalar == "" {
+		scalar = protoType
+	}
+
+	signed, unsigned := true, false
+	switch scalar {
+	case protoInt32:
+		return arrowType{Name: "int", BitWidth: 32, IsSigned: &signed}
+	case protoInt64:
+		return arrowType{Name: "int", BitWidth: 64, IsSigned: &signed}
+	case protoUInt32:
+		return arrowType{Name: "int", BitWidth: 32, IsSigned: &unsigned}
+	case protoUInt64:
+		return arrowType{Name: "int", BitWidth: 64, IsSigned: &unsigned}
+	case protoFloat:
+		return arrowType{Name: "floatingpoint", Precision: "SINGLE"}
+	case protoDouble:
+		return arrowType{Name: "floatingpoint", Precision: "DOUBLE"}
+	case protoBool:
+		return arrowType{Name: "bool"}
+	case protoBytes:
+		return arrowType{Name: "binary"}
+	}
+	return arrowType{Name: "utf8"}
+}
+
+// buildArrowGo renders the schemas as arrow-go values, one exported variable per table
+func (g *Generator) buildArrowGo(tables []*clickhouse.Table, schemas []arrowSchema) string {
+	pkg := g.config.Arrow.Package
+	if pkg == "" {
+		pkg = defaultArrowPackage
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(sb, "// Package %s holds the Arrow schemas of the table rows, matching their proto messages.\n", pkg)
+	fmt.Fprintf(sb, "package %s\n\n", pkg)
+	fmt.Fprintf(sb, "import %q\n\n", arrowGoImport)
+	sb.WriteString("func schemaMetadata(keys, values []string) *arrow.Metadata {\n")
+	sb.WriteString("\tmd := arrow.NewMetadata(keys, values)\n")
+	sb.WriteString("\treturn &md\n")
+	sb.WriteString("}\n")
+
+	for i, table := range tables {
+		schema := schemas[i]
+		name := getProtocMessageName(table.Name) + "Schema"
+
+		fmt.Fprintf(sb, "\n// %s is the Arrow schema of %s rows\n", name, table.Name)
+		fmt.Fprintf(sb, "var %s = arrow.NewSchema([]arrow.Field{\n", name)
+		for _, field := range schema.Fields {
+			fmt.Fprintf(sb, "\t{Name: %q, Type: %s, Nullable: %t, Metadata: %s},\n",
+				field.Name, arrowGoType(field), field.Nullable, arrowGoMetadata(field.Metadata))
+		}
+		fmt.Fprintf(sb, "}, schemaMetadata(%s))\n", arrowGoMetadataArgs(schema.Metadata))
+	}
+	return sb.String()
+}
+
+// arrowGoType returns the arrow-go expression of a field's type
+func arrowGoType(field arrowField) string {
+	switch field.Type.Name {
+	case "list":
+		return fmt.Sprintf("arrow.ListOfNonNullable(%s)", arrowGoType(field.Children[0]))
+	case "map":
+		entries := field.Children[0]
+		return fmt.Sprintf("arrow.MapOf(%s, %s)", arrowGoType(entries.Children[0]), arrowGoType(entries.Children[1]))
+	case "int":
+		prefix := "Int"
+		if !*field.Type.IsSigned {
+			prefix = "Uint"
+		}
+		return fmt.Sprintf("arrow.PrimitiveTypes.%s%d", prefix, field.Type.BitWidth)
+	case "floatingpoint":
+		if field.Type.Precision == "SINGLE" {
+			return "arrow.PrimitiveTypes.Float32"
+		}
+		return "arrow.PrimitiveTypes.Float64"
+	case "bool":
+		return "arrow.FixedWidthTypes.Boolean"
+	case "binary":
+		return "arrow.BinaryTypes.Binary"
+	}
+	return "arrow.BinaryTypes.String"
+}
+
+func arrowGoMetadata(metadata []arrowMetadata) string {
+	return fmt.Sprintf("arrow.NewMetadata(%s)", arrowGoMetadataArgs(metadata))
+}
+
+// arrowGoMetadataArgs returns the key and value slices of metadata
+func arrowGoMetadataArgs(metadata []arrowMetadata) string {
+	keys := make([]string, len(metadata))
+	values := make([]string, len(metadata))
+	for i, md := range metadata {
+		keys[i], values[i] = strconv.Quote(md.Key), strconv.Quote(md.Value)
+	}
+	return fmt.Sprintf("[]string{%s}, []string{%s}", strings.Join(keys, ", "), strings.Join(values, ", "))
+}
@@ -0,0 +1,121 @@
+package protogen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrowFieldOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    protoField
+		expected string
+	}{
+		{name: "Signed", field: protoField{Type: protoInt64}, expected: "arrow.PrimitiveTypes.Int64"},
+		{name: "Unsigned", field: protoField{Type: protoUInt32}, expected: "arrow.PrimitiveTypes.Uint32"},
+		{name: "Wrapper", field: protoField{Type: "google.protobuf.DoubleValue"}, expected: "arrow.PrimitiveTypes.Float64"},
+		{name: "Bytes", field: protoField{Type: protoBytes}, expected: "arrow.BinaryTypes.Binary"},
+		{name: "String", field: protoField{Type: protoString}, expected: "arrow.BinaryTypes.String"},
+		{name: "List", field: protoField{Type: protoBool, Repeated: true}, expected: "arrow.ListOfNonNullable(arrow.FixedWidthTypes.Boolean)"},
+		{name: "Map", field: protoField{Type: protoFloat, MapKey: protoString}, expected: "arrow.MapOf(arrow.BinaryTypes.String, arrow.PrimitiveTypes.Float32)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := arrowFieldOf(tt.field)
+			assert.Equal(t, tt.expected, arrowGoType(field))
+			assert.Equal(t, tt.field.Nullable(), field.Nullable)
+		})
+	}
+}
+
+func TestGenerator_GenerateArrow_Go(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := config.NewConfig()
+	cfg.OutputDir = tmpDir
+	cfg.Package = "test.v1"
+	cfg.Arrow = config.ArrowConfig{Enabled: true, Format: config.ArrowFormatGo, Package: "flightschema"}
+
+	tables := []*clickhouse.Table{
+		{
+			Name: "fct_block_24h",
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1},
+				{Name: "fee", Type: "Nullable(Int64)", BaseType: "Int64", IsNullable: true, Position: 2},
+			},
+		},
+	}
+
+	require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateArrow(tables))
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, defaultArrowDir, "schemas.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "package flightschema\n")
+	assert.Contains(t, string(content), "var FctBlock24HSchema = arrow.NewSchema([]arrow.Field{\n")
+	assert.Contains(t, string(content), `{Name: "fee", Type: arrow.PrimitiveTypes.Int64, Nullable: true, Metadata: arrow.NewMetadata([]string{"clickhouse.type", "proto.field_number"}, []string{"Nullable(Int64)", "12"})},`)
+	assert.Contains(t, string(content), `}, schemaMetadata([]string{"clickhouse.table", "proto.message"}, []string{"fct_block_24h", "test.v1.FctBlock24h"}))`)
+	assert.NoFileExists(t, filepath.Join(tmpDir, defaultArrowDir, "fct_block_24h.arrow.json"))
+}
+
+func TestGenerator_ArrowJSONColumnTypes(t *testing.T) {
+	tests := []struct {
+		column   clickhouse.Column
+		expected string
+	}{
+		{clickhouse.NewColumn("slot", "UInt32", 1), `{"name": "slot", "nullable": false, "type": {"name": "int", "bitWidth": 32, "isSigned": false}, "children": []}`},
+		{clickhouse.NewColumn("block_root", "FixedString(66)", 2), `{"name": "block_root", "nullable": false, "type": {"name": "utf8"}, "children": []}`},
+		{clickhouse.NewColumn("gas_used", "Nullable(UInt64)", 3), `{"name": "gas_used", "nullable": true, "type": {"name": "int", "bitWidth": 64, "isSigned": false}, "children": []}`},
+		{clickhouse.NewColumn("base_fee", "Int64", 4), `{"name": "base_fee", "nullable": false, "type": {"name": "int", "bitWidth": 64, "isSigned": true}, "children": []}`},
+		{clickhouse.NewColumn("reward", "Float32", 5), `{"name": "reward", "nullable": false, "type": {"name": "floatingpoint", "precision": "SINGLE"}, "children": []}`},
+		{clickhouse.NewColumn("missed", "Bool", 6), `{"name": "missed", "nullable": false, "type": {"name": "bool"}, "children": []}`},
+		{
+			clickhouse.NewColumn("blob_sizes", "Array(UInt32)", 7),
+			`{"name": "blob_sizes", "nullable": false, "type": {"name": "list"}, "children": [
+				{"name": "item", "nullable": false, "type": {"name": "int", "bitWidth": 32, "isSigned": false}, "children": []}
+			]}`,
+		},
+		{
+			clickhouse.NewColumn("client_share", "Map(LowCardinality(String), Float64)", 8),
+			`{"name": "client_share", "nullable": false, "type": {"name": "map", "keysSorted": false}, "children": [
+				{"name": "entries", "nullable": false, "type": {"name": "struct"}, "children": [
+					{"name": "key", "nullable": false, "type": {"name": "utf8"}, "children": []},
+					{"name": "value", "nullable": true, "type": {"name": "floatingpoint", "precision": "DOUBLE"}, "children": []}
+				]}
+			]}`,
+		},
+	}
+
+	columns := make([]clickhouse.Column, len(tests))
+	for i, tt := range tests {
+		columns[i] = tt.column
+	}
+	cfg := config.NewConfig()
+	cfg.Package = "chain.v1"
+	cfg.Arrow.Enabled = true
+	files := generateColumnTypes(t, cfg, &clickhouse.Table{Name: "fct_block_24h", Database: "default", Engine: "MergeTree", SortingKey: []string{"slot"}}, columns)
+
+	var schema arrowSchema
+	require.NoError(t, json.Unmarshal([]byte(files[defaultArrowDir+"/fct_block_24h.arrow.json"]), &schema))
+	require.Len(t, schema.Fields, len(tests))
+
+	assert.Equal(t, []arrowMetadata{{Key: "clickhouse.table", Value: "fct_block_24h"}, {Key: "proto.message", Value: "chain.v1.FctBlock24h"}}, schema.Metadata)
+	assert.Equal(t, []arrowMetadata{{Key: "clickhouse.type", Value: "Nullable(UInt64)"}, {Key: "proto.field_number", Value: "13"}}, schema.Fields[2].Metadata)
+
+	for i, tt := range tests {
+		t.Run(tt.column.Name, func(t *testing.T) {
+			field := schema.Fields[i]
+			field.Metadata = nil
+			actual, err := json.Marshal(field)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(actual))
+		})
+	}
+}
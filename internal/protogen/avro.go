@@ -0,0 +1,138 @@
+package protogen
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+const (
+	defaultAvroDir = "avro"
+	avroNull       = "null"
+)
+
+// avroRecord is the .avsc schema of a table's rows
+type avroRecord struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace,omitempty"`
+	Doc       string      `json:"doc,omitempty"`
+	Fields    []avroField `json:"fields"`
+}
+
+type avroField struct {
+	Name    string          `json:"name"`
+	Type    any             `json:"type"`
+	Doc     string          `json:"doc,omitempty"`
+	Default json.RawMessage `json:"default,omitempty"`
+	// ClickHouseType is a custom attribute, ignored by Avro, recording the column's type
+	ClickHouseType string `json:"clickhouse.type,omitempty"`
+}
+
+type avroArray struct {
+	Type  string `json:"type"`
+	Items string `json:"items"`
+}
+
+type avroMap struct {
+	Type   string `json:"type"`
+	Values string `json:"values"`
+}
+
+// GenerateAvro generates a .avsc record schema per table with the layout of its proto
+// message: the same field names and order, wrappers as nullable unions and proto3 zero
+// values as defaults, so ingestion pipelines writing Avro stay in step with the read API.
+func (g *Generator) GenerateAvro(tables []*clickhouse.Table) error {
+	dir := g.emitterDir(g.config.Avro.Dir, defaultAvroDir)
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create Avro directory: %w", err)
+	}
+
+	for _, table := range tables {
+		content, err := json.MarshalIndent(g.avroRecord(table), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode Avro schema of %s: %w", table.Name, err)
+		}
+		filename := filepath.Join(dir, strings.ToLower(table.Name)+".avsc")
+		if err := g.writeFile(filename, string(content)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// avroNamespace returns the namespace of the records
+func (g *Generator) avroNamespace() string {
+	if g.config.Avro.Namespace != "" {
+		return g.config.Avro.Namespace
+	}
+	return g.config.Package
+}
+
+// avroRecord converts the row message of a table
+func (g *Generator) avroRecord(table *clickhouse.Table) avroRecord {
+	record := avroRecord{Type: "record", Name: ToPascalCase(table.Name), Namespace: g.avroNamespace(), Fields: make([]avroField, 0)}
+
+	columns := make(map[string]*clickhouse.Column, len(table.Columns))
+	for i := range table.Columns {
+		columns[g.fieldName(table.Name, table.Columns[i].Name)] = &table.Columns[i]
+	}
+
+	row := g.rowMessage(table)
+	record.Doc = row.Comment
+	for _, field := range row.Fields {
+		avro := avroFieldOf(field)
+		if column, ok := columns[field.Name]; ok {
+			avro.ClickHouseType = column.Type
+		}
+		record.Fields = append(record.Fields, avro)
+	}
+	return record
+}
+
+// avroFieldOf maps a message field. Wrappers become ["null", T] unions defaulting to null;
+// every other field defaults to the proto3 zero value of its type.
+func avroFieldOf(field protoField) avroField {
+	value, zero := avroValueType(field.Type)
+	avro := avroField{Name: field.Name, Type: value, Doc: field.Comment, Default: json.RawMessage(zero)}
+
+	switch {
+	case field.IsMap():
+		// Avro map keys are always strings, as are proto map keys in JSON
+		avro.Type, avro.Default = avroMap{Type: "map", Values: value}, json.RawMessage("{}")
+	case field.Repeated:
+		avro.Type, avro.Default = avroArray{Type: "array", Items: value}, json.RawMessage("[]")
+	case field.Nullable():
+		avro.Type, avro.Default = []string{avroNull, value}, json.RawMessage(avroNull)
+	}
+	return avro
+}
+
+// avroValueType returns the Avro type of a proto scalar or wrapper and the JSON of its zero
+// value. uint32 needs a long to hold its range; Avro has no unsigned 64-bit type, so uint64
+// is a long too, and values above the int64 range need the column converted to string.
+func avroValueType(protoType string) (avroType, zero string) {
+	scalar := wrapperScalar(protoType)
+	if scalar == "" {
+		scalar = protoType
+	}
+
+	switch scalar {
+	case protoInt32:
+		return "int", "0"
+	case protoInt64, protoUInt32, protoUInt64:
+		return "long", "0"
+	case protoFloat:
+		return "float", "0"
+	case protoDouble:
+		return "double", "0"
+	case protoBool:
+		return "boolean", "false"
+	case protoBytes:
+		return "bytes", `""`
+	}
+	return "string", `""`
+}
@@ -0,0 +1,110 @@
+package protogen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvroFieldOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    protoField
+		expected string
+	}{
+		{name: "Scalar", field: protoField{Name: "slot", Type: protoUInt32}, expected: `{"name":"slot","type":"long","default":0}`},
+		{name: "Converted bigint", field: protoField{Name: "value", Type: protoString}, expected: `{"name":"value","type":"string","default":""}`},
+		{name: "Wrapper", field: protoField{Name: "fee", Type: "google.protobuf.FloatValue"}, expected: `{"name":"fee","type":["null","float"],"default":null}`},
+		{name: "Repeated", field: protoField{Name: "roots", Type: protoBytes, Repeated: true}, expected: `{"name":"roots","type":{"type":"array","items":"bytes"},"default":[]}`},
+		{name: "Map", field: protoField{Name: "counts", Type: protoInt32, MapKey: protoUInt32}, expected: `{"name":"counts","type":{"type":"map","values":"int"},"default":{}}`},
+		{name: "Doc", field: protoField{Name: "ok", Type: protoBool, Comment: "Whether it worked"}, expected: `{"name":"ok","type":"boolean","doc":"Whether it worked","default":false}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(avroFieldOf(tt.field))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(encoded))
+		})
+	}
+}
+
+func TestGenerator_GenerateAvro_Namespace(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := config.NewConfig()
+	cfg.OutputDir = tmpDir
+	cfg.Package = "test.v1"
+	cfg.Avro = config.AvroConfig{Enabled: true, Dir: "schemas", Namespace: "com.example.ingest"}
+
+	tables := []*clickhouse.Table{
+		{
+			Name:    "Blocks",
+			Columns: []clickhouse.Column{{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1}},
+		},
+	}
+
+	require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateAvro(tables))
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "schemas", "blocks.avsc"))
+	require.NoError(t, err)
+
+	var record avroRecord
+	require.NoError(t, json.Unmarshal(content, &record))
+	assert.Equal(t, "com.example.ingest", record.Namespace)
+	assert.Equal(t, "Blocks", record.Name)
+	require.Len(t, record.Fields, 1)
+	assert.Equal(t, "UInt32", record.Fields[0].ClickHouseType)
+}
+
+func TestGenerator_AvroColumnTypes(t *testing.T) {
+	tests := []struct {
+		column   clickhouse.Column
+		expected string
+	}{
+		{clickhouse.NewColumn("block_number", "UInt64", 1), `{"name": "block_number", "type": "long", "default": 0, "clickhouse.type": "UInt64"}`},
+		{clickhouse.NewColumn("log_index", "UInt32", 2), `{"name": "log_index", "type": "long", "default": 0, "clickhouse.type": "UInt32"}`},
+		{clickhouse.NewColumn("to", "Nullable(String)", 3), `{"name": "to", "type": ["null", "string"], "default": null, "clickhouse.type": "Nullable(String)"}`},
+		{clickhouse.NewColumn("amount", "Float64", 4), `{"name": "amount", "type": "double", "default": 0, "clickhouse.type": "Float64"}`},
+		{clickhouse.NewColumn("fee", "Nullable(Int64)", 5), `{"name": "fee", "type": ["null", "long"], "default": null, "clickhouse.type": "Nullable(Int64)"}`},
+		{clickhouse.NewColumn("topics", "Array(String)", 6), `{"name": "topics", "type": {"type": "array", "items": "string"}, "default": [], "clickhouse.type": "Array(String)"}`},
+		{clickhouse.NewColumn("labels", "Map(String, UInt64)", 7), `{"name": "labels", "type": {"type": "map", "values": "long"}, "default": {}, "clickhouse.type": "Map(String, UInt64)"}`},
+		{clickhouse.NewColumn("is_contract", "Bool", 8), `{"name": "is_contract", "type": "boolean", "default": false, "clickhouse.type": "Bool"}`},
+	}
+
+	columns := make([]clickhouse.Column, len(tests))
+	for i, tt := range tests {
+		columns[i] = tt.column
+	}
+	cfg := config.NewConfig()
+	cfg.Package = "chain.v1"
+	cfg.IncludeComments = true
+	cfg.Avro.Enabled = true
+	files := generateColumnTypes(t, cfg, &clickhouse.Table{Name: "transfers", Database: "default", Engine: "MergeTree", Comment: "Token transfers", SortingKey: []string{"block_number"}}, columns)
+
+	var record struct {
+		Type      string            `json:"type"`
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Doc       string            `json:"doc"`
+		Fields    []json.RawMessage `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(files[defaultAvroDir+"/transfers.avsc"]), &record))
+	assert.Equal(t, "record", record.Type)
+	assert.Equal(t, "Transfers", record.Name)
+	assert.Equal(t, "chain.v1", record.Namespace)
+	assert.Equal(t, "Token transfers", record.Doc)
+	require.Len(t, record.Fields, len(tests))
+
+	for i, tt := range tests {
+		t.Run(tt.column.Name, func(t *testing.T) {
+			assert.JSONEq(t, tt.expected, string(record.Fields[i]))
+		})
+	}
+}
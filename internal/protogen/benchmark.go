@@ -0,0 +1,190 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// benchmarkFile is the name of the generated benchmark file in the Go output directory
+const benchmarkFile = "queries_bench_test.go"
+
+// Environment variables the generated benchmarks read
+const (
+	benchmarkDSNEnv      = "CLICKHOUSE_BENCH_DSN"
+	benchmarkDatabaseEnv = "CLICKHOUSE_BENCH_DATABASE"
+)
+
+// GenerateBenchmarks writes a Go benchmark per generated List and Get query into the
+// package of the SQL helpers. Each one builds its request with a synthetic filter on the
+// first sorting key column, runs the query against the server in $CLICKHOUSE_BENCH_DSN and
+// reports the rows ClickHouse read next to the latency, so changes to the shape of the
+// generated SQL show up when comparing runs of two tool versions with benchstat.
+func (g *Generator) GenerateBenchmarks(tables []*clickhouse.Table) error {
+	filename := filepath.Join(g.goOutputDir(), benchmarkFile)
+	if err := g.writeFile(filename, g.buildBenchmarks(tables)); err != nil {
+		return err
+	}
+
+	g.log.WithField("file", filename).Info("Generated query benchmarks")
+	return nil
+}
+
+func (g *Generator) buildBenchmarks(tables []*clickhouse.Table) string {
+	sb := &strings.Builder{}
+
+	g.writeSQLFileHeader(sb, "Benchmarks of the generated SQL queries")
+
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"context\"\n")
+	sb.WriteString("\t\"os\"\n")
+	sb.WriteString("\t\"sync/atomic\"\n")
+	sb.WriteString("\t\"testing\"\n\n")
+	sb.WriteString("\t\"github.com/ClickHouse/clickhouse-go/v2\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/reflect/protoreflect\"\n")
+	sb.WriteString(")\n\n")
+
+	for _, table := range tables {
+		if len(table.Columns) == 0 || len(table.SortingKey) == 0 {
+			continue
+		}
+		g.writeTableBenchmarks(sb, table)
+	}
+
+	sb.WriteString(benchmarkRuntime)
+	return sb.String()
+}
+
+// writeTableBenchmarks writes the List and Get benchmarks of a table
+func (g *Generator) writeTableBenchmarks(sb *strings.Builder, table *clickhouse.Table) {
+	messageName := getProtocMessageName(table.Name)
+	keyField := g.fieldName(table.Name, table.SortingKey[0])
+
+	tenantArg := ""
+	if tenant, _ := g.tenantScopeFor(table); tenant != nil {
+		tenantArg = ", 1"
+		if tenant.goType == protoString {
+			tenantArg = `, "1"`
+		}
+	}
+
+	for _, kind := range []string{"List", "Get"} {
+		fmt.Fprintf(sb, "func Benchmark%s%sQuery(b *testing.B) {\n", kind, messageName)
+		sb.WriteString("\tbenchmarkQuery(b, func(database string) (SQLQuery, error) {\n")
+		fmt.Fprintf(sb, "\t\treq := &%s%sRequest{}\n", kind, messageName)
+		fmt.Fprintf(sb, "\t\tsetBenchmarkFilter(req.ProtoReflect(), %q)\n", keyField)
+		fmt.Fprintf(sb, "\t\treturn Build%s%sQuery(req%s, WithDatabase(database))\n", kind, messageName, tenantArg)
+		sb.WriteString("\t})\n")
+		sb.WriteString("}\n\n")
+	}
+}
+
+// benchmarkRuntime is the table independent part of the benchmark file
+const benchmarkRuntime = `// benchmarkQuery runs the built query b.N times against the server in $` + benchmarkDSNEnv + `,
+// reporting the rows ClickHouse read and returned per run. Queries target the database in
+// $` + benchmarkDatabaseEnv + `, or the default database of the connection when unset.
+func benchmarkQuery(b *testing.B, build func(database string) (SQLQuery, error)) {
+	b.Helper()
+
+	dsn := os.Getenv("` + benchmarkDSNEnv + `")
+	if dsn == "" {
+		b.Skip("` + benchmarkDSNEnv + ` is not set")
+	}
+
+	options, err := clickhouse.ParseDSN(dsn)
+	if err != nil {
+		b.Fatalf("failed to parse DSN: %v", err)
+	}
+	conn, err := clickhouse.Open(options)
+	if err != nil {
+		b.Fatalf("failed to open connection: %v", err)
+	}
+	defer conn.Close()
+
+	query, err := build(os.Getenv("` + benchmarkDatabaseEnv + `"))
+	if err != nil {
+		b.Fatalf("failed to build query: %v", err)
+	}
+
+	// Named placeholders take their values from the parameters of the context
+	var rowsRead atomic.Uint64
+	ctx := clickhouse.Context(context.Background(), clickhouse.WithParameters(query.Parameters), clickhouse.WithProgress(func(p *clickhouse.Progress) {
+		rowsRead.Add(p.Rows)
+	}))
+
+	returned := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := conn.Query(ctx, query.Query, query.Args...)
+		if err != nil {
+			b.Fatalf("query failed: %v\n%s", err, query.Query)
+		}
+		for rows.Next() {
+			returned++
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			b.Fatalf("reading rows failed: %v\n%s", err, query.Query)
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(rowsRead.Load())/float64(b.N), "rows_read/op")
+	b.ReportMetric(float64(returned)/float64(b.N), "rows/op")
+}
+
+// setBenchmarkFilter sets the named field of a request. Filter messages get a "gte"
+// condition, or their first scalar condition; scalar fields get a sample value.
+func setBenchmarkFilter(msg protoreflect.Message, name string) {
+	field := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		return
+	}
+	if field.Kind() != protoreflect.MessageKind {
+		msg.Set(field, benchmarkSampleValue(field))
+		return
+	}
+
+	filter := msg.Mutable(field).Message()
+	fields := filter.Descriptor().Fields()
+	condition := fields.ByName("gte")
+	for i := 0; condition == nil && i < fields.Len(); i++ {
+		if fields.Get(i).Kind() != protoreflect.MessageKind {
+			condition = fields.Get(i)
+		}
+	}
+	if condition != nil {
+		filter.Set(condition, benchmarkSampleValue(condition))
+	}
+}
+
+func benchmarkSampleValue(field protoreflect.FieldDescriptor) protoreflect.Value {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString("1")
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte("1"))
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(1)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(1)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(1)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(1)
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(1)
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(1)
+	case protoreflect.EnumKind:
+		return protoreflect.ValueOfEnum(field.Enum().Values().Get(0).Number())
+	default:
+		return field.Default()
+	}
+}
+`
@@ -0,0 +1,125 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GenerateBenchmarks(t *testing.T) {
+	tables := []*clickhouse.Table{
+		{
+			Name:       "blocks",
+			SortingKey: []string{"slot"},
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1},
+				{Name: "tenant_id", Type: "String", BaseType: "String", Position: 2},
+			},
+		},
+		{
+			Name: "recent_blocks",
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1},
+			},
+		},
+	}
+
+	t.Run("Tenant scoped queries", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cfg := config.NewConfig()
+		cfg.OutputDir = tmpDir
+		cfg.Package = "test.v1"
+		cfg.GoPackage = "example.com/gen/testv1"
+		cfg.SQLBuildTag = "chsql"
+		cfg.Tenant = config.TenantConfig{Column: "tenant_id"}
+		cfg.Benchmarks.Enabled = true
+
+		require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateBenchmarks(tables))
+
+		content, err := os.ReadFile(filepath.Join(tmpDir, benchmarkFile))
+		require.NoError(t, err)
+		bench := string(content)
+		assert.Contains(t, bench, "//go:build chsql\n", "benchmarks need the build tag of the helpers they call")
+		assert.Contains(t, bench, "package testv1\n")
+		assert.Contains(t, bench, "return BuildListBlocksQuery(req, \"1\", WithDatabase(database))")
+		assert.Contains(t, bench, "func BenchmarkGetBlocksQuery(b *testing.B) {")
+		assert.NotContains(t, bench, "RecentBlocks", "tables without services have no queries")
+	})
+
+	t.Run("Go module requires the driver", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cfg := config.NewConfig()
+		cfg.OutputDir = tmpDir
+		cfg.Package = "test.v1"
+		cfg.GoModule = config.GoModuleConfig{Enabled: true, Path: "example.com/gen"}
+		cfg.Benchmarks.Enabled = true
+
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate(tables))
+
+		goMod, err := readFile(filepath.Join(tmpDir, defaultGoModuleDir, "go.mod"))
+		require.NoError(t, err)
+		assert.Contains(t, goMod, "github.com/ClickHouse/clickhouse-go/v2 "+clickhouseModuleVersion)
+		assert.FileExists(t, filepath.Join(tmpDir, defaultGoModuleDir, benchmarkFile))
+	})
+}
+
+func TestGenerator_BenchmarkFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    *clickhouse.Table
+		expected []string
+	}{
+		{
+			name: "Primary key filter",
+			table: &clickhouse.Table{
+				Name:       "transfers",
+				SortingKey: []string{"block_number", "log_index"},
+				Columns: []clickhouse.Column{
+					clickhouse.NewColumn("block_number", "UInt64", 1),
+					clickhouse.NewColumn("log_index", "UInt32", 2),
+				},
+			},
+			expected: []string{
+				"func BenchmarkListTransfersQuery(b *testing.B) {\n\tbenchmarkQuery(b, func(database string) (SQLQuery, error) {\n\t\treq := &ListTransfersRequest{}\n\t\tsetBenchmarkFilter(req.ProtoReflect(), \"block_number\")\n\t\treturn BuildListTransfersQuery(req, WithDatabase(database))\n",
+				"func BenchmarkGetTransfersQuery(b *testing.B) {\n\tbenchmarkQuery(b, func(database string) (SQLQuery, error) {\n\t\treq := &GetTransfersRequest{}\n\t\tsetBenchmarkFilter(req.ProtoReflect(), \"block_number\")\n\t\treturn BuildGetTransfersQuery(req, WithDatabase(database))\n",
+			},
+		},
+		{
+			name: "Date key",
+			table: &clickhouse.Table{
+				Name:       "fct_block_24h",
+				SortingKey: []string{"day"},
+				Columns: []clickhouse.Column{
+					clickhouse.NewColumn("day", "Date", 1),
+					clickhouse.NewColumn("blocks", "UInt32", 2),
+				},
+			},
+			expected: []string{
+				"\t\treq := &ListFctBlock24HRequest{}\n\t\tsetBenchmarkFilter(req.ProtoReflect(), \"day\")\n",
+				"\t\treq := &GetFctBlock24HRequest{}\n\t\tsetBenchmarkFilter(req.ProtoReflect(), \"day\")\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.OutputDir = t.TempDir()
+			cfg.GoPackage = "example.com/gen/testv1"
+			cfg.Benchmarks.Enabled = true
+			require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateBenchmarks([]*clickhouse.Table{tt.table}))
+
+			bench, err := readFile(filepath.Join(cfg.OutputDir, benchmarkFile))
+			require.NoError(t, err)
+			for _, want := range tt.expected {
+				assert.Contains(t, bench, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// bigIntColumns returns the Int64/UInt64 columns of a table whose message fields are
+// decimal strings because of bigint_to_string. Masked columns are left out: hashed values
+// aren't numbers.
+func (g *Generator) bigIntColumns(table *clickhouse.Table) []*clickhouse.Column {
+	var columns []*clickhouse.Column
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		if col.BaseType != typeInt64 && col.BaseType != typeUInt64 {
+			continue
+		}
+		if g.isMasked(table.Name, col.Name) || !g.config.Conversion.ShouldConvertToString(table.Name, col.Name) {
+			continue
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// hasBigIntColumns reports whether any of the tables has bigint_to_string fields
+func (g *Generator) hasBigIntColumns(tables []*clickhouse.Table) bool {
+	for _, table := range tables {
+		if len(table.SortingKey) > 0 && len(g.bigIntColumns(table)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBigIntAccessors writes methods on a table's row message reading and writing its
+// bigint_to_string fields as big.Int values
+func (g *Generator) writeBigIntAccessors(sb *strings.Builder, table *clickhouse.Table) {
+	messageName := getProtocMessageName(table.Name)
+
+	for _, col := range g.bigIntColumns(table) {
+		field := ToPascalCase(g.fieldName(table.Name, col.Name))
+		typeName := "Int64"
+		if col.BaseType == typeUInt64 {
+			typeName = "UInt64"
+		}
+
+		switch {
+		case col.IsArray:
+			fmt.Fprintf(sb, "\n// %sBigInts parses the %s values of %s\n", field, typeName, col.Name)
+			fmt.Fprintf(sb, "func (x *%s) %sBigInts() ([]*big.Int, error) {\n", messageName, field)
+			fmt.Fprintf(sb, "\tvalues := make([]*big.Int, len(x.Get%s()))\n", field)
+			fmt.Fprintf(sb, "\tfor i, value := range x.Get%s() {\n", field)
+			fmt.Fprintf(sb, "\t\tparsed, err := Parse%sString(value)\n", typeName)
+			fmt.Fprintf(sb, "\t\tif err != nil {\n")
+			fmt.Fprintf(sb, "\t\t\treturn nil, fmt.Errorf(\"%s[%%d]: %%w\", i, err)\n", col.Name)
+			fmt.Fprintf(sb, "\t\t}\n")
+			fmt.Fprintf(sb, "\t\tvalues[i] = parsed\n")
+			fmt.Fprintf(sb, "\t}\n")
+			fmt.Fprintf(sb, "\treturn values, nil\n")
+			fmt.Fprintf(sb, "}\n")
+
+			fmt.Fprintf(sb, "\n// Set%sBigInts sets the %s values of %s\n", field, typeName, col.Name)
+			fmt.Fprintf(sb, "func (x *%s) Set%sBigInts(values []*big.Int) error {\n", messageName, field)
+			fmt.Fprintf(sb, "\tformatted := make([]string, len(values))\n")
+			fmt.Fprintf(sb, "\tfor i, value := range values {\n")
+			fmt.Fprintf(sb, "\t\ts, err := Format%sString(value)\n", typeName)
+			fmt.Fprintf(sb, "\t\tif err != nil {\n")
+			fmt.Fprintf(sb, "\t\t\treturn fmt.Errorf(\"%s[%%d]: %%w\", i, err)\n", col.Name)
+			fmt.Fprintf(sb, "\t\t}\n")
+			fmt.Fprintf(sb, "\t\tformatted[i] = s\n")
+			fmt.Fprintf(sb, "\t}\n")
+			fmt.Fprintf(sb, "\tx.%s = formatted\n", field)
+			fmt.Fprintf(sb, "\treturn nil\n")
+			fmt.Fprintf(sb, "}\n")
+
+		case col.IsNullable:
+			// Edition 2023 fields are a *string with explicit presence rather than a wrapper
+			isNull, value, wrapped := fmt.Sprintf("x.Get%s() == nil", field), fmt.Sprintf("x.Get%s().GetValue()", field), "wrapperspb.String(s)"
+			if g.config.Syntax == config.SyntaxEdition2023 {
+				isNull, value, wrapped = fmt.Sprintf("x.%s == nil", field), fmt.Sprintf("x.Get%s()", field), "&s"
+			}
+
+			fmt.Fprintf(sb, "\n// %sBigInt parses the %s value of %s, nil when it is NULL\n", field, typeName, col.Name)
+			fmt.Fprintf(sb, "func (x *%s) %sBigInt() (*big.Int, error) {\n", messageName, field)
+			fmt.Fprintf(sb, "\tif %s {\n", isNull)
+			fmt.Fprintf(sb, "\t\treturn nil, nil\n")
+			fmt.Fprintf(sb, "\t}\n")
+			fmt.Fprintf(sb, "\treturn Parse%sString(%s)\n", typeName, value)
+			fmt.Fprintf(sb, "}\n")
+
+			fmt.Fprintf(sb, "\n// Set%sBigInt sets the %s value of %s, clearing it when value is nil\n", field, typeName, col.Name)
+			fmt.Fprintf(sb, "func (x *%s) Set%sBigInt(value *big.Int) error {\n", messageName, field)
+			fmt.Fprintf(sb, "\tif value == nil {\n")
+			fmt.Fprintf(sb, "\t\tx.%s = nil\n", field)
+			fmt.Fprintf(sb, "\t\treturn nil\n")
+			fmt.Fprintf(sb, "\t}\n")
+			fmt.Fprintf(sb, "\ts, err := Format%sString(value)\n", typeName)
+			fmt.Fprintf(sb, "\tif err != nil {\n")
+			fmt.Fprintf(sb, "\t\treturn fmt.Errorf(\"%s: %%w\", err)\n", col.Name)
+			fmt.Fprintf(sb, "\t}\n")
+			fmt.Fprintf(sb, "\tx.%s = %s\n", field, wrapped)
+			fmt.Fprintf(sb, "\treturn nil\n")
+			fmt.Fprintf(sb, "}\n")
+
+		default:
+			fmt.Fprintf(sb, "\n// %sBigInt parses the %s value of %s\n", field, typeName, col.Name)
+			fmt.Fprintf(sb, "func (x *%s) %sBigInt() (*big.Int, error) {\n", messageName, field)
+			fmt.Fprintf(sb, "\treturn Parse%sString(x.Get%s())\n", typeName, field)
+			fmt.Fprintf(sb, "}\n")
+
+			fmt.Fprintf(sb, "\n// Set%sBigInt sets the %s value of %s\n", field, typeName, col.Name)
+			fmt.Fprintf(sb, "func (x *%s) Set%sBigInt(value *big.Int) error {\n", messageName, field)
+			fmt.Fprintf(sb, "\ts, err := Format%sString(value)\n", typeName)
+			fmt.Fprintf(sb, "\tif err != nil {\n")
+			fmt.Fprintf(sb, "\t\treturn fmt.Errorf(\"%s: %%w\", err)\n", col.Name)
+			fmt.Fprintf(sb, "\t}\n")
+			fmt.Fprintf(sb, "\tx.%s = s\n", field)
+			fmt.Fprintf(sb, "\treturn nil\n")
+			fmt.Fprintf(sb, "}\n")
+		}
+	}
+}
+
+// GenerateBigIntHelpers generates a bigint.go file parsing and formatting the decimal
+// strings of bigint_to_string fields
+func (g *Generator) GenerateBigIntHelpers() error {
+	sb := &strings.Builder{}
+	g.writeSQLFileHeader(sb, "This file provides big.Int conversions of Int64/UInt64 fields exposed as strings.")
+
+	sb.WriteString(`import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Ranges of the column types whose fields are decimal strings
+var (
+	minInt64  = big.NewInt(math.MinInt64)
+	maxInt64  = big.NewInt(math.MaxInt64)
+	minUInt64 = big.NewInt(0)
+	maxUInt64 = new(big.Int).SetUint64(math.MaxUint64)
+)
+
+// ParseInt64String parses the decimal string of an Int64 field, rejecting anything but an
+// integer within the range of Int64
+func ParseInt64String(value string) (*big.Int, error) {
+	return parseBigIntString(value, minInt64, maxInt64)
+}
+
+// ParseUInt64String parses the decimal string of a UInt64 field, rejecting anything but an
+// integer within the range of UInt64
+func ParseUInt64String(value string) (*big.Int, error) {
+	return parseBigIntString(value, minUInt64, maxUInt64)
+}
+
+// FormatInt64String formats a value for an Int64 field, rejecting nil and values outside
+// the range of Int64
+func FormatInt64String(value *big.Int) (string, error) {
+	return formatBigIntString(value, minInt64, maxInt64)
+}
+
+// FormatUInt64String formats a value for a UInt64 field, rejecting nil and values outside
+// the range of UInt64
+func FormatUInt64String(value *big.Int) (string, error) {
+	return formatBigIntString(value, minUInt64, maxUInt64)
+}
+
+func parseBigIntString(value string, minValue, maxValue *big.Int) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer: %q", value)
+	}
+	if n.Cmp(minValue) < 0 || n.Cmp(maxValue) > 0 {
+		return nil, fmt.Errorf("%s is out of range [%s, %s]", value, minValue, maxValue)
+	}
+	return n, nil
+}
+
+func formatBigIntString(value *big.Int, minValue, maxValue *big.Int) (string, error) {
+	if value == nil {
+		return "", fmt.Errorf("value is nil")
+	}
+	if value.Cmp(minValue) < 0 || value.Cmp(maxValue) > 0 {
+		return "", fmt.Errorf("%s is out of range [%s, %s]", value, minValue, maxValue)
+	}
+	return value.String(), nil
+}
+`)
+
+	filename := filepath.Join(g.goOutputDir(), "bigint.go")
+	if err := g.writeFile(filename, sb.String()); err != nil {
+		return err
+	}
+
+	g.log.WithField("file", filename).Info("Generated bigint helper file")
+	return nil
+}
@@ -0,0 +1,68 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_BigIntHelpers(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:     "fct_tx",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("fee", "UInt64", 2),
+			clickhouse.NewColumn("tip", "Nullable(Int64)", 3),
+			clickhouse.NewColumn("blob_fees", "Array(UInt64)", 4),
+			clickhouse.NewColumn("sender_nonce", "UInt64", 5),
+		},
+		SortingKey: []string{"slot"},
+	}
+
+	generate := func(t *testing.T, conversion config.ConversionConfig) string {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.Conversion = conversion
+		cfg.Columns = map[string]map[string]config.ColumnConfig{"fct_tx": {"sender_nonce": {Mask: config.MaskHash}}}
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+		return cfg.OutputDir
+	}
+
+	t.Run("Converted fields", func(t *testing.T) {
+		dir := generate(t, config.ConversionConfig{BigIntToString: map[string][]string{"fct_tx": {"fee", "tip", "blob_fees", "sender_nonce"}}})
+
+		helpers, err := os.ReadFile(filepath.Join(dir, "bigint.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(helpers), "func ParseUInt64String(value string) (*big.Int, error)")
+		assert.Contains(t, string(helpers), "func FormatInt64String(value *big.Int) (string, error)")
+
+		content, err := os.ReadFile(filepath.Join(dir, "fct_tx_sql.go"))
+		require.NoError(t, err)
+		sql := string(content)
+		assert.Contains(t, sql, "\t\"math/big\"\n\n\t\"google.golang.org/protobuf/types/known/wrapperspb\"\n")
+		assert.Contains(t, sql, "func (x *FctTx) FeeBigInt() (*big.Int, error) {\n\treturn ParseUInt64String(x.GetFee())")
+		assert.Contains(t, sql, "func (x *FctTx) SetTipBigInt(value *big.Int) error {")
+		assert.Contains(t, sql, "\tx.Tip = wrapperspb.String(s)")
+		assert.Contains(t, sql, "func (x *FctTx) BlobFeesBigInts() ([]*big.Int, error) {")
+		// Hashed values aren't numbers
+		assert.NotContains(t, sql, "SenderNonceBigInt")
+	})
+
+	t.Run("No converted fields", func(t *testing.T) {
+		dir := generate(t, config.ConversionConfig{})
+
+		assert.NoFileExists(t, filepath.Join(dir, "bigint.go"))
+		content, err := os.ReadFile(filepath.Join(dir, "fct_tx_sql.go"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "math/big")
+	})
+}
@@ -0,0 +1,235 @@
+package protogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// Limits of the quantiles and histogram_bins fields of ListBuckets requests, enforced by the
+// generated ParseBucketValues
+const (
+	maxBucketQuantiles     = 10
+	maxBucketHistogramBins = 100
+)
+
+// bucketColumn returns the column a table's rows are bucketed by in its ListBuckets RPC: the
+// primary key, when it is a DateTime and a policy turns buckets on for the table. Tables
+// without one get no ListBuckets RPC.
+func (g *Generator) bucketColumn(table *clickhouse.Table) *clickhouse.Column {
+	if len(table.SortingKey) == 0 || !g.config.Policy(table.Name).Buckets {
+		return nil
+	}
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		if col.Name != table.SortingKey[0] {
+			continue
+		}
+		if col.IsArray || (col.BaseType != clickhouseDateTime && col.BaseType != clickhouseDateTime64) {
+			g.log.WithField("table", table.Name).Debug("Skipping ListBuckets: primary key is not a DateTime")
+			return nil
+		}
+		return col
+	}
+	return nil
+}
+
+// bucketValueFields returns the Go expression of the mapping of the fields whose minimum and
+// maximum ListBuckets can return: those of the numeric columns that aren't masked
+func (g *Generator) bucketValueFields(table *clickhouse.Table) string {
+	var fields []string
+	for _, col := range table.Columns {
+		if col.IsArray || g.isMasked(table.Name, col.Name) || !isNumericBaseType(col.BaseType) {
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%q", g.fieldName(table.Name, col.Name)))
+	}
+	return fmt.Sprintf("%s.Only(%s)", columnsByFieldVariable(table), strings.Join(fields, ", "))
+}
+
+// isNumericBaseType reports whether values of a ClickHouse base type convert to Float64
+func isNumericBaseType(baseType string) bool {
+	switch {
+	case strings.HasPrefix(baseType, "Interval"):
+		return false
+	case strings.HasPrefix(baseType, "Int"), strings.HasPrefix(baseType, "UInt"),
+		strings.HasPrefix(baseType, "Float"), strings.HasPrefix(baseType, "BFloat"),
+		strings.HasPrefix(baseType, "Decimal"):
+		return true
+	}
+	return false
+}
+
+// writeBucketMessages writes the request, bucket and response messages of the ListBuckets RPC.
+// The request takes the same filters as the List request.
+func (g *Generator) writeBucketMessages(sb *strings.Builder, table *clickhouse.Table, columnMap map[string]*clickhouse.Column, tenant *tenantScope) {
+	column := g.bucketColumn(table)
+	if column == nil {
+		return
+	}
+
+	messageName := ToPascalCase(table.Name)
+	required, optional := "", ""
+	if g.shouldGenerateAPI(table.Name) {
+		required = " [(google.api.field_behavior) = REQUIRED]"
+		optional = " [(google.api.field_behavior) = OPTIONAL]"
+	}
+
+	fmt.Fprintf(sb, "// Request for counting %s records per interval of %s\n", table.Name, column.Name)
+	fmt.Fprintf(sb, "message List%sBucketsRequest {\n", messageName)
+	fieldNumber := g.writeRequestFilterFields(sb, table, columnMap)
+	fmt.Fprintf(sb, "\n  // Width of each bucket: a positive number and a unit, one of s, m, h, d or w.\n")
+	fmt.Fprintf(sb, "  // Example: \"15m\" or \"1d\".\n")
+	fmt.Fprintf(sb, "  string interval = %d%s;\n", fieldNumber, required)
+	fmt.Fprintf(sb, "  // Numeric field whose minimum and maximum are returned for each bucket.\n")
+	fieldNumber++
+	fmt.Fprintf(sb, "  string value_field = %d%s;\n", fieldNumber, optional)
+	if tenant != nil {
+		fieldNumber++
+		g.writeTenantRequestField(sb, table, tenant, fieldNumber)
+	}
+	fmt.Fprintf(sb, "  // Quantile levels of value_field returned for each bucket, between 0 and 1.\n")
+	fmt.Fprintf(sb, "  // Example: [0.5, 0.95, 0.99]. At most %d; requires value_field.\n", maxBucketQuantiles)
+	fmt.Fprintf(sb, "  repeated double quantiles = %d%s;\n", fieldNumber+1, optional)
+	fmt.Fprintf(sb, "  // Number of histogram bins of value_field returned for each bucket, at most %d.\n", maxBucketHistogramBins)
+	fmt.Fprintf(sb, "  // Bins adapt to the values, so they can differ between buckets; requires value_field.\n")
+	fmt.Fprintf(sb, "  int32 histogram_bins = %d%s;\n", fieldNumber+2, optional)
+	fmt.Fprintf(sb, "  // Conditions on the aggregates of each bucket; only matching buckets are returned.\n")
+	fmt.Fprintf(sb, "  %sBucketsHaving having = %d%s;\n", messageName, fieldNumber+3, optional)
+	g.writeSkipDefaultWhereField(sb, table, fieldNumber+4)
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// Conditions on the aggregates of a bucket of %s, applied after counting\n", table.Name)
+	fmt.Fprintf(sb, "message %sBucketsHaving {\n", messageName)
+	fmt.Fprintf(sb, "  // Filter by the number of records in the interval. Example: {\"gte\": 10}\n")
+	fmt.Fprintf(sb, "  UInt64Filter count = 1%s;\n", optional)
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// Records of %s within one interval of %s\n", table.Name, column.Name)
+	fmt.Fprintf(sb, "message %sBucket {\n", messageName)
+	fmt.Fprintf(sb, "  // Start of the interval as a Unix timestamp in seconds\n")
+	fmt.Fprintf(sb, "  uint32 start = 1;\n")
+	fmt.Fprintf(sb, "  // Number of records in the interval\n")
+	fmt.Fprintf(sb, "  uint64 count = 2;\n")
+	fmt.Fprintf(sb, "  // Minimum of value_field in the interval, unset without value_field or values\n")
+	fmt.Fprintf(sb, "  google.protobuf.DoubleValue min = 3;\n")
+	fmt.Fprintf(sb, "  // Maximum of value_field in the interval, unset without value_field or values\n")
+	fmt.Fprintf(sb, "  google.protobuf.DoubleValue max = 4;\n")
+	fmt.Fprintf(sb, "  // Approximate quantiles of value_field, in the order of the requested levels\n")
+	fmt.Fprintf(sb, "  repeated %sQuantile quantiles = 5;\n", messageName)
+	fmt.Fprintf(sb, "  // Approximate histogram of value_field, lowest bin first\n")
+	fmt.Fprintf(sb, "  repeated %sHistogramBin histogram = 6;\n", messageName)
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// Quantile of value_field within one interval of %s\n", column.Name)
+	fmt.Fprintf(sb, "message %sQuantile {\n", messageName)
+	fmt.Fprintf(sb, "  // Requested quantile level, e.g. 0.95\n")
+	fmt.Fprintf(sb, "  double level = 1;\n")
+	fmt.Fprintf(sb, "  // Value of value_field at the level\n")
+	fmt.Fprintf(sb, "  double value = 2;\n")
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// Histogram bin of value_field within one interval of %s\n", column.Name)
+	fmt.Fprintf(sb, "message %sHistogramBin {\n", messageName)
+	fmt.Fprintf(sb, "  // Lower bound of the bin\n")
+	fmt.Fprintf(sb, "  double lower = 1;\n")
+	fmt.Fprintf(sb, "  // Upper bound of the bin\n")
+	fmt.Fprintf(sb, "  double upper = 2;\n")
+	fmt.Fprintf(sb, "  // Estimated number of records in the bin\n")
+	fmt.Fprintf(sb, "  double count = 3;\n")
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// Response for counting %s records per interval\n", table.Name)
+	fmt.Fprintf(sb, "message List%sBucketsResponse {\n", messageName)
+	fmt.Fprintf(sb, "  // Intervals with at least one record, oldest first.\n")
+	fmt.Fprintf(sb, "  repeated %sBucket buckets = 1;\n", messageName)
+	sb.WriteString("}\n\n")
+}
+
+// writeBucketRPC writes the ListBuckets RPC of a table's service
+func (g *Generator) writeBucketRPC(sb *strings.Builder, table *clickhouse.Table, columnMap map[string]*clickhouse.Column, deprecationComment, deprecationOption string) {
+	column := g.bucketColumn(table)
+	if column == nil {
+		return
+	}
+
+	messageName := ToPascalCase(table.Name)
+	fmt.Fprintf(sb, "  // List buckets | Count records per interval of %s\n", column.Name)
+	g.writeRPCExamples(sb, table, "ListBuckets", fmt.Sprintf("%s/%s:buckets", g.config.APIBasePath, table.Name),
+		g.listExampleParams(table, columnMap, exampleParam{path: "interval", value: "1h", json: `"1h"`}))
+	sb.WriteString(deprecationComment)
+	if !g.shouldGenerateAPI(table.Name) && deprecationOption == "" {
+		fmt.Fprintf(sb, "  rpc ListBuckets(List%sBucketsRequest) returns (List%sBucketsResponse);\n", messageName, messageName)
+		return
+	}
+	fmt.Fprintf(sb, "  rpc ListBuckets(List%sBucketsRequest) returns (List%sBucketsResponse) {\n", messageName, messageName)
+	sb.WriteString(deprecationOption)
+	if g.shouldGenerateAPI(table.Name) {
+		fmt.Fprintf(sb, "    option (google.api.http) = {\n")
+		fmt.Fprintf(sb, "      get: \"%s/%s:buckets\"\n", g.config.APIBasePath, table.Name)
+		fmt.Fprintf(sb, "    };\n")
+	}
+	fmt.Fprintf(sb, "  }\n")
+}
+
+// writeBucketsSQLBuilderFunction generates the SQL query builder function for a
+// ListBuckets request
+func (g *Generator) writeBucketsSQLBuilderFunction(sb *strings.Builder, table *clickhouse.Table) {
+	column := g.bucketColumn(table)
+	if column == nil {
+		return
+	}
+
+	messageName := getProtocMessageName(table.Name)
+	tenant, _ := g.tenantScopeFor(table)
+
+	fmt.Fprintf(sb, "\n// BuildList%sBucketsQuery constructs a parameterized SQL query from a List%sBucketsRequest,\n", messageName, messageName)
+	fmt.Fprintf(sb, "// counting the matching rows per interval of %s. At most %d buckets are returned.\n", column.Name, g.config.MaxPageSize)
+	g.writeTiersComment(sb, table)
+	fmt.Fprintf(sb, "func BuildList%sBucketsQuery(req *List%sBucketsRequest%s, options ...QueryOption) (SQLQuery, error) {\n", messageName, messageName, tenantParam(tenant))
+
+	writeValidateRequestCall(sb, fmt.Sprintf("List%sBucketsRequest", messageName))
+
+	fmt.Fprintf(sb, "\t// Build query using QueryBuilder\n")
+	fmt.Fprintf(sb, "\tqb := %s\n\n", g.newQueryBuilder())
+	writeTenantCondition(sb, tenant)
+	g.writeDefaultWhereCondition(sb, table, true)
+
+	columnMap := make(map[string]*clickhouse.Column)
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		columnMap[col.Name] = col
+	}
+	g.writeAllFilterConditions(sb, table, columnMap)
+
+	fmt.Fprintf(sb, "\t// Validate the bucket width and the aggregates of value_field\n")
+	fmt.Fprintf(sb, "\tinterval, err := ParseInterval(req.Interval)\n")
+	fmt.Fprintf(sb, "\tif err != nil {\n")
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"invalid interval: %%w\", err)\n")
+	fmt.Fprintf(sb, "\t}\n")
+	fmt.Fprintf(sb, "\tvalues, err := ParseBucketValues(req.ValueField, req.Quantiles, req.HistogramBins, %s)\n", g.bucketValueFields(table))
+	fmt.Fprintf(sb, "\tif err != nil {\n")
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, err\n")
+	fmt.Fprintf(sb, "\t}\n\n")
+	g.writeBucketsHavingConditions(sb)
+	g.writeTiersOption(sb, table, false)
+	g.writeTopologyOption(sb, table)
+
+	fmt.Fprintf(sb, "\treturn BuildBucketQuery(%s, %q, interval, values, qb, %d, options...)\n", g.queryTableArg(table), column.Name, g.config.MaxPageSize)
+	fmt.Fprintf(sb, "}\n")
+}
+
+// writeBucketsHavingConditions writes the conditions of the having filters of a ListBuckets
+// request, which follow every WHERE condition
+func (g *Generator) writeBucketsHavingConditions(sb *strings.Builder) {
+	fmt.Fprintf(sb, "\t// Filter the buckets by their aggregates\n")
+	fmt.Fprintf(sb, "\tqb.Having()\n")
+	fmt.Fprintf(sb, "\tif count := req.GetHaving().GetCount(); count != nil {\n")
+	fmt.Fprintf(sb, "\t\tswitch filter := count.Filter.(type) {\n")
+	g.writeNumericFilterCases(sb, "_count", "\t\t", typeUInt64)
+	fmt.Fprintf(sb, "\t\tdefault:\n")
+	fmt.Fprintf(sb, "\t\t\t// Unsupported filter type\n")
+	fmt.Fprintf(sb, "\t\t}\n")
+	fmt.Fprintf(sb, "\t}\n\n")
+}
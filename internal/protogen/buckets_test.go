@@ -0,0 +1,114 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_ListBuckets(t *testing.T) {
+	timing := &clickhouse.Table{
+		Name:     "fct_block_timing",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot_start_date_time", "DateTime", 1),
+			clickhouse.NewColumn("slot", "UInt32", 2),
+			clickhouse.NewColumn("seen_ms", "Nullable(Float64)", 3),
+			clickhouse.NewColumn("proposer_index", "UInt64", 4),
+			clickhouse.NewColumn("client", "LowCardinality(String)", 5),
+			clickhouse.NewColumn("blob_sizes", "Array(UInt32)", 6),
+		},
+		SortingKey: []string{"slot_start_date_time", "slot"},
+	}
+	block := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt64", 1)},
+		SortingKey: []string{"slot"},
+	}
+
+	on := true
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.EnableAPI = true
+	cfg.Policies = []config.PolicyConfig{{Match: "fct_", Buckets: &on}}
+	cfg.Columns = map[string]map[string]config.ColumnConfig{"fct_block_timing": {"proposer_index": {Mask: config.MaskHash}}}
+
+	gen := NewGenerator(cfg, logrus.New())
+	require.NoError(t, gen.Generate([]*clickhouse.Table{timing, block}))
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	proto := read("fct_block_timing.proto")
+	assert.Contains(t, proto, "message ListFctBlockTimingBucketsRequest {")
+	assert.Contains(t, proto, "string interval = 6 [(google.api.field_behavior) = REQUIRED];")
+	assert.Contains(t, proto, "string value_field = 7 [(google.api.field_behavior) = OPTIONAL];")
+	assert.Contains(t, proto, "google.protobuf.DoubleValue max = 4;")
+	assert.Contains(t, proto, "repeated double quantiles = 8 [(google.api.field_behavior) = OPTIONAL];")
+	assert.Contains(t, proto, "int32 histogram_bins = 9 [(google.api.field_behavior) = OPTIONAL];")
+	assert.Contains(t, proto, "FctBlockTimingBucketsHaving having = 10 [(google.api.field_behavior) = OPTIONAL];")
+	assert.Contains(t, proto, "message FctBlockTimingBucketsHaving {\n")
+	assert.Contains(t, proto, "  UInt64Filter count = 1 [(google.api.field_behavior) = OPTIONAL];\n")
+	assert.Contains(t, proto, "repeated FctBlockTimingQuantile quantiles = 5;")
+	assert.Contains(t, proto, "repeated FctBlockTimingHistogramBin histogram = 6;")
+	assert.Contains(t, proto, "message FctBlockTimingHistogramBin {")
+	assert.Contains(t, proto, "repeated FctBlockTimingBucket buckets = 1;")
+	assert.Contains(t, proto, "rpc ListBuckets(ListFctBlockTimingBucketsRequest) returns (ListFctBlockTimingBucketsResponse)")
+	assert.Contains(t, proto, `get: "/api/v1/fct_block_timing:buckets"`)
+	assert.Contains(t, proto, `import "google/protobuf/wrappers.proto";`)
+
+	sql := read("fct_block_timing_sql.go")
+	assert.Contains(t, sql, "func BuildListFctBlockTimingBucketsQuery(req *ListFctBlockTimingBucketsRequest, options ...QueryOption) (SQLQuery, error)")
+	assert.Contains(t, sql, "interval, err := ParseInterval(req.Interval)")
+	// Masked, array and non-numeric columns can't be aggregated
+	assert.Contains(t, sql, `values, err := ParseBucketValues(req.ValueField, req.Quantiles, req.HistogramBins, FctBlockTimingColumnsByField.Only("slot", "seen_ms"))`)
+	assert.Contains(t, sql, `return BuildBucketQuery("fct_block_timing", "slot_start_date_time", interval, values, qb, 10000, options...)`)
+	// The having filters follow every row filter, and compare the aggregates
+	assert.Contains(t, sql, "\tqb.Having()\n\tif count := req.GetHaving().GetCount(); count != nil {\n\t\tswitch filter := count.Filter.(type) {\n")
+	assert.Contains(t, sql, "\t\tcase *UInt64Filter_Gte:\n\t\t\tqb.AddCondition(\"_count\", \">=\", filter.Gte)\n")
+	rowFilter := strings.LastIndex(sql, "qb.AddCondition(\"`slot`\"")
+	assert.Positive(t, rowFilter)
+	assert.Less(t, rowFilter, strings.Index(sql, "qb.Having()"))
+	assert.Contains(t, sql, `if err := validateFilterValues("having.count", "in", len(filter.In.GetValues())); err != nil {`)
+
+	blockProto := read("fct_block.proto")
+	assert.NotContains(t, blockProto, "ListBuckets", "the primary key isn't a DateTime")
+}
+
+func TestBucketQueryGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{}
+	g.writeCommonSQLTypes(&sb)
+	g.writeCommonSQLFunctions(&sb)
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, "func ParseInterval(interval string) (string, error)")
+	assert.Contains(t, generatedCode, `"m": "MINUTE",`)
+	assert.Contains(t, generatedCode, "func BuildBucketQuery(table, timeColumn, interval string, values BucketValues, qb *QueryBuilder, limit uint32, options ...QueryOption) (SQLQuery, error)")
+	assert.Contains(t, generatedCode, "func ParseBucketValues(valueField string, quantiles []float64, histogramBins int32, fields ColumnsByField) (BucketValues, error)")
+	assert.Contains(t, generatedCode, "if len(quantiles) > 10 {")
+	assert.Contains(t, generatedCode, `aggregates[2] = fmt.Sprintf("quantiles(%s)(%s) AS _quantiles", strings.Join(levels, ", "), value)`)
+	assert.Contains(t, generatedCode, `aggregates[3] = fmt.Sprintf("histogram(%d)(%s) AS _histogram", values.HistogramBins, value)`)
+	assert.Contains(t, generatedCode, `query += " GROUP BY _start" + qb.GetHavingClause() + " ORDER BY _start"`)
+	assert.Contains(t, generatedCode, "buildFromClause(table, opts)")
+
+	// WHERE and HAVING conditions share the builder's arguments, HAVING last
+	assert.Contains(t, generatedCode, "func (qb *QueryBuilder) Having() {")
+	assert.Contains(t, generatedCode, "return qb.args[:qb.havingArgsFrom], qb.args[qb.havingArgsFrom:]")
+	assert.Contains(t, generatedCode, `return " WHERE " + strings.Join(conditions, " AND "), append(args, havingArgs...), nil`)
+	assert.Contains(t, generatedCode, `return SQLQuery{}, fmt.Errorf("HAVING conditions need an aggregating query")`)
+}
@@ -0,0 +1,245 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+const (
+	defaultClientDir = "client"
+	clientFile       = "client.go"
+)
+
+// GenerateClient generates <output_dir>/client, a Go package with a typed client per table
+// with services. The clients wrap the gRPC stubs of protoc-gen-go-grpc, adding iteration over
+// every page of a List request and retries of failed calls.
+func (g *Generator) GenerateClient(tables []*clickhouse.Table) error {
+	importPath := g.goImportPath()
+	if importPath == "" {
+		g.log.Warn("Skipping Go client: go_package is required to import the generated stubs")
+		return nil
+	}
+
+	dir := g.emitterDir(g.config.Client.Dir, defaultClientDir)
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create client directory: %w", err)
+	}
+
+	filename := filepath.Join(dir, clientFile)
+	if err := g.writeFile(filename, g.buildClient(tables, importPath)); err != nil {
+		return err
+	}
+
+	g.log.WithField("file", filename).Info("Generated Go client")
+	return nil
+}
+
+func (g *Generator) buildClient(tables []*clickhouse.Table, importPath string) string {
+	sb := &strings.Builder{}
+
+	sb.WriteString("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n\n")
+	sb.WriteString("// Package client wraps the generated gRPC services with a typed client per table: Get\n")
+	sb.WriteString("// returns the row itself, All and ListAll follow next_page_token through every page of a\n")
+	sb.WriteString("// List request, and WithRetryPolicy retries failed calls. The other RPCs of a service are\n")
+	sb.WriteString("// called through its stub.\n")
+	sb.WriteString("package client\n\n")
+
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"context\"\n")
+	sb.WriteString("\t\"iter\"\n")
+	sb.WriteString("\t\"time\"\n\n")
+	sb.WriteString("\t\"google.golang.org/grpc\"\n")
+	sb.WriteString("\t\"google.golang.org/grpc/codes\"\n")
+	sb.WriteString("\t\"google.golang.org/grpc/status\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/proto\"\n\n")
+	fmt.Fprintf(sb, "\tpb %q\n", importPath)
+	sb.WriteString(")\n\n")
+
+	sb.WriteString(clientRuntime)
+
+	for _, table := range tables {
+		g.writeTableClient(sb, table)
+	}
+	return sb.String()
+}
+
+// writeTableClient writes the client of a table with services, with a constructor for its
+// service and one per label service
+func (g *Generator) writeTableClient(sb *strings.Builder, table *clickhouse.Table) {
+	if len(table.Columns) == 0 || len(table.SortingKey) == 0 {
+		return
+	}
+
+	name := getProtocMessageName(table.Name)
+	stub := lowerFirst(name) + "Stub"
+	// The getter protoc-gen-go generates for the rows field of the List response
+	rows := "Get" + goCamelCase(strings.ToLower(table.Name))
+
+	fmt.Fprintf(sb, "\n// %s is the part of the %s services' stubs the client calls\n", stub, table.Name)
+	fmt.Fprintf(sb, "type %s interface {\n", stub)
+	fmt.Fprintf(sb, "\tList(ctx context.Context, in *pb.List%sRequest, opts ...grpc.CallOption) (*pb.List%sResponse, error)\n", name, name)
+	fmt.Fprintf(sb, "\tGet(ctx context.Context, in *pb.Get%sRequest, opts ...grpc.CallOption) (*pb.Get%sResponse, error)\n", name, name)
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// %sClient reads %s through %sService or one of its label services\n", name, table.Name, name)
+	fmt.Fprintf(sb, "type %sClient struct {\n", name)
+	fmt.Fprintf(sb, "\tstub    %s\n", stub)
+	sb.WriteString("\toptions options\n")
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// New%sClient returns a client of %sService on conn\n", name, name)
+	fmt.Fprintf(sb, "func New%sClient(conn grpc.ClientConnInterface, opts ...Option) *%sClient {\n", name, name)
+	fmt.Fprintf(sb, "\treturn &%sClient{stub: pb.New%sServiceClient(conn), options: newOptions(opts)}\n", name, name)
+	sb.WriteString("}\n\n")
+
+	for _, value := range g.labelValues(table) {
+		service := name + labelName(value)
+		fmt.Fprintf(sb, "// New%sClient returns a client of %sService on conn, reading the rows whose\n", service, service)
+		fmt.Fprintf(sb, "// %s is %s\n", g.config.LabelServices.Column, value)
+		fmt.Fprintf(sb, "func New%sClient(conn grpc.ClientConnInterface, opts ...Option) *%sClient {\n", service, name)
+		fmt.Fprintf(sb, "\treturn &%sClient{stub: pb.New%sServiceClient(conn), options: newOptions(opts)}\n", name, service)
+		sb.WriteString("}\n\n")
+	}
+
+	fmt.Fprintf(sb, "// List returns one page of the rows matching req\n")
+	fmt.Fprintf(sb, "func (c *%sClient) List(ctx context.Context, req *pb.List%sRequest, opts ...grpc.CallOption) (*pb.List%sResponse, error) {\n", name, name, name)
+	fmt.Fprintf(sb, "\treturn call(ctx, c.options, func(ctx context.Context) (*pb.List%sResponse, error) {\n", name)
+	sb.WriteString("\t\treturn c.stub.List(ctx, req, opts...)\n")
+	sb.WriteString("\t})\n")
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// Get returns the row of req's primary key\n")
+	fmt.Fprintf(sb, "func (c *%sClient) Get(ctx context.Context, req *pb.Get%sRequest, opts ...grpc.CallOption) (*pb.%s, error) {\n", name, name, name)
+	fmt.Fprintf(sb, "\tresp, err := call(ctx, c.options, func(ctx context.Context) (*pb.Get%sResponse, error) {\n", name)
+	sb.WriteString("\t\treturn c.stub.Get(ctx, req, opts...)\n")
+	sb.WriteString("\t})\n")
+	sb.WriteString("\treturn resp.GetItem(), err\n")
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// All iterates over the rows matching req, from its page_token to the last page. Iteration\n")
+	fmt.Fprintf(sb, "// stops after the first error; req itself isn't changed.\n")
+	fmt.Fprintf(sb, "func (c *%sClient) All(ctx context.Context, req *pb.List%sRequest, opts ...grpc.CallOption) iter.Seq2[*pb.%s, error] {\n", name, name, name)
+	fmt.Fprintf(sb, "\treturn paginate(ctx, req.GetPageToken(), func(ctx context.Context, pageToken string) ([]*pb.%s, string, error) {\n", name)
+	fmt.Fprintf(sb, "\t\tpage := proto.Clone(req).(*pb.List%sRequest)\n", name)
+	sb.WriteString("\t\tpage.PageToken = pageToken\n")
+	sb.WriteString("\t\tresp, err := c.List(ctx, page, opts...)\n")
+	fmt.Fprintf(sb, "\t\treturn resp.%s(), resp.GetNextPageToken(), err\n", rows)
+	sb.WriteString("\t})\n")
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// ListAll returns every row matching req, reading all of its pages\n")
+	fmt.Fprintf(sb, "func (c *%sClient) ListAll(ctx context.Context, req *pb.List%sRequest, opts ...grpc.CallOption) ([]*pb.%s, error) {\n", name, name, name)
+	sb.WriteString("\treturn collect(c.All(ctx, req, opts...))\n")
+	sb.WriteString("}\n")
+}
+
+// clientRuntime is the table-independent part of the generated client package
+const clientRuntime = `// RetryPolicy decides whether a failed call is retried. It is called with the number of the
+// retry, starting at 1, and the error of the failed attempt, and returns how long to wait
+// before retrying, or false to return the error.
+type RetryPolicy func(retry int, err error) (time.Duration, bool)
+
+// RetryUnavailable returns a RetryPolicy retrying calls failing with Unavailable or
+// ResourceExhausted up to maxRetries times, waiting backoff before the first retry and twice
+// as long before each further one
+func RetryUnavailable(maxRetries int, backoff time.Duration) RetryPolicy {
+	return func(retry int, err error) (time.Duration, bool) {
+		switch status.Code(err) {
+		case codes.Unavailable, codes.ResourceExhausted:
+		default:
+			return 0, false
+		}
+		if retry > maxRetries {
+			return 0, false
+		}
+		return backoff << (retry - 1), true
+	}
+}
+
+// options are the settings of a client
+type options struct {
+	retry RetryPolicy
+}
+
+// Option configures a client
+type Option func(*options)
+
+// WithRetryPolicy retries the failed calls of a client as policy decides. Calls aren't
+// retried by default.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// call calls rpc, retrying it as the retry policy of o decides until it succeeds or ctx is
+// done
+func call[T any](ctx context.Context, o options, rpc func(context.Context) (T, error)) (T, error) {
+	for retry := 1; ; retry++ {
+		resp, err := rpc(ctx)
+		if err == nil || o.retry == nil {
+			return resp, err
+		}
+		wait, ok := o.retry(retry, err)
+		if !ok {
+			return resp, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+// paginate iterates over the rows of the pages list returns, starting at pageToken and
+// following the next page token of each page until it is empty
+func paginate[T any](ctx context.Context, pageToken string, list func(context.Context, string) ([]T, string, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		token := pageToken
+		for {
+			rows, next, err := list(ctx, token)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, row := range rows {
+				if !yield(row, nil) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			token = next
+		}
+	}
+}
+
+// collect returns the rows of an iterator, or its first error
+func collect[T any](rows iter.Seq2[T, error]) ([]T, error) {
+	var all []T
+	for row, err := range rows {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, row)
+	}
+	return all, nil
+}
+`
@@ -0,0 +1,131 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clientPagingTest runs in the generated client package, paging through a stub serving
+// three pages of fct_block_24h rows and failing once with Unavailable
+const clientPagingTest = `package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "example.com/gen/testv1"
+)
+
+type pagingStub struct {
+	calls  int
+	tokens []string
+}
+
+func (s *pagingStub) List(_ context.Context, in *pb.ListFctBlock24HRequest, _ ...grpc.CallOption) (*pb.ListFctBlock24HResponse, error) {
+	s.calls++
+	if s.calls == 2 {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	s.tokens = append(s.tokens, in.GetPageToken())
+	next := map[string]string{"": "a", "a": "b"}[in.GetPageToken()]
+	return &pb.ListFctBlock24HResponse{
+		FctBlock_24H:  []*pb.FctBlock24H{{Slot: uint32(len(s.tokens))}},
+		NextPageToken: next,
+	}, nil
+}
+
+func (s *pagingStub) Get(context.Context, *pb.GetFctBlock24HRequest, ...grpc.CallOption) (*pb.GetFctBlock24HResponse, error) {
+	return &pb.GetFctBlock24HResponse{Item: &pb.FctBlock24H{Slot: 7}}, nil
+}
+
+func TestAll(t *testing.T) {
+	stub := &pagingStub{}
+	c := &FctBlock24HClient{stub: stub, options: newOptions([]Option{WithRetryPolicy(RetryUnavailable(1, time.Millisecond))})}
+
+	req := &pb.ListFctBlock24HRequest{}
+	rows, err := c.ListAll(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[2].GetSlot() != 3 {
+		t.Fatalf("rows = %v", rows)
+	}
+	if got := stub.tokens; len(got) != 3 || got[0] != "" || got[1] != "a" || got[2] != "b" {
+		t.Fatalf("page tokens = %q", got)
+	}
+	if req.GetPageToken() != "" {
+		t.Fatal("ListAll changed the request")
+	}
+
+	row, err := c.Get(context.Background(), &pb.GetFctBlock24HRequest{})
+	if err != nil || row.GetSlot() != 7 {
+		t.Fatalf("Get = %v, %v", row, err)
+	}
+}
+`
+
+func TestGenerator_GenerateClient(t *testing.T) {
+	tables := []*clickhouse.Table{
+		{
+			Name:       "fct_block",
+			SortingKey: []string{"slot"},
+			Columns: []clickhouse.Column{
+				clickhouse.NewColumn("slot", "UInt32", 1),
+				clickhouse.NewColumn("meta_network_name", "String", 2),
+			},
+		},
+		{
+			Name:       "fct_block_24h",
+			SortingKey: []string{"slot"},
+			Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		},
+		{
+			Name:    "no_key",
+			Columns: []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		},
+	}
+
+	t.Run("Clients", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.Package = "test.v1"
+		cfg.GoPackage = "example.com/gen/testv1"
+		cfg.Client.Enabled = true
+		cfg.LabelServices = config.LabelServicesConfig{Column: "meta_network_name", Values: []string{"mainnet"}}
+		generateModule(t, cfg, tables)
+
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "client", "client.go"))
+		require.NoError(t, err)
+		client := string(content)
+
+		// Label services share the table's client
+		assert.Contains(t, client, "func NewFctBlockMainnetClient(conn grpc.ClientConnInterface, opts ...Option) *FctBlockClient {\n")
+		assert.NotContains(t, client, "NoKey")
+
+		// The client compiles against the protoc output, and pages through List responses
+		path := filepath.Join(cfg.OutputDir, "client", "paging_test.go")
+		require.NoError(t, os.WriteFile(path, []byte(clientPagingTest), 0o600))
+		goCommand(t, cfg.OutputDir, "vet", "./...")
+		goCommand(t, cfg.OutputDir, "test", "./client")
+	})
+
+	t.Run("Requires go_package", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.Client.Enabled = true
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate(tables))
+		assert.NoDirExists(t, filepath.Join(cfg.OutputDir, "client"))
+	})
+}
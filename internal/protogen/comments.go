@@ -0,0 +1,78 @@
+package protogen
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// commentEllipsis ends comments truncated at comment_max_length
+const commentEllipsis = "..."
+
+// sanitizeComments returns the tables with their table and column comments made safe to
+// emit, copying only the tables whose comments change
+func (g *Generator) sanitizeComments(tables []*clickhouse.Table) []*clickhouse.Table {
+	result := make([]*clickhouse.Table, 0, len(tables))
+	for _, table := range tables {
+		comment := sanitizeComment(table.Comment, g.config.CommentMaxLength)
+
+		var columns []clickhouse.Column
+		for i, column := range table.Columns {
+			sanitized := sanitizeComment(column.Comment, g.config.CommentMaxLength)
+			if sanitized == column.Comment {
+				continue
+			}
+			if columns == nil {
+				columns = append([]clickhouse.Column{}, table.Columns...)
+			}
+			columns[i].Comment = sanitized
+		}
+
+		if comment == table.Comment && columns == nil {
+			result = append(result, table)
+			continue
+		}
+		copied := *table
+		copied.Comment = comment
+		if columns != nil {
+			copied.Columns = columns
+		}
+		result = append(result, &copied)
+	}
+	return result
+}
+
+// sanitizeComment cleans a ClickHouse comment for the comments and docs of generated files:
+// control characters are dropped, comment-closing sequences broken up, unbalanced backticks,
+// which would open a code span in Markdown docs, turned into quotes, and comments longer
+// than maxLength runes cut with an ellipsis. A maxLength of 0 keeps the full comment.
+func sanitizeComment(comment string, maxLength int) string {
+	if comment == "" {
+		return comment
+	}
+
+	comment = strings.ToValidUTF8(strings.ReplaceAll(comment, "\r\n", "\n"), "")
+	comment = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r):
+			return -1
+		}
+		return r
+	}, comment)
+
+	comment = strings.ReplaceAll(comment, "*/", "* /")
+	if strings.Count(comment, "`")%2 != 0 {
+		comment = strings.ReplaceAll(comment, "`", "'")
+	}
+
+	if runes := []rune(comment); maxLength > 0 && len(runes) > maxLength {
+		cut := max(maxLength-len(commentEllipsis), 0)
+		comment = strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + commentEllipsis
+	}
+	return strings.TrimSpace(comment)
+}
@@ -0,0 +1,56 @@
+package protogen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeComment(t *testing.T) {
+	tests := []struct {
+		name      string
+		comment   string
+		maxLength int
+		expected  string
+	}{
+		{name: "plain", comment: "Slot number", expected: "Slot number"},
+		{name: "comment closer", comment: "Ratio */ of blobs /* per block", expected: "Ratio * / of blobs /* per block"},
+		{name: "control characters", comment: "Line one\r\nLine\ttwo\x00\x1b[31m‮", expected: "Line one\nLine two[31m"},
+		{name: "invalid UTF-8", comment: "Root \xff\xfehash", expected: "Root hash"},
+		{name: "balanced backticks", comment: "Same as `slot` in `fct_block`", expected: "Same as `slot` in `fct_block`"},
+		{name: "unbalanced backticks", comment: "Copied from `db`.`table", expected: "Copied from 'db'.'table"},
+		{name: "truncated", comment: "Number of attestations in the block", maxLength: 20, expected: "Number of attesta..."},
+		{name: "truncated at space", comment: "Number of attestations", maxLength: 13, expected: "Number of..."},
+		{name: "within limit", comment: "Slot number", maxLength: 11, expected: "Slot number"},
+		{name: "multibyte", comment: "Ξ balance in ether", maxLength: 10, expected: "Ξ balan..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sanitized := sanitizeComment(tt.comment, tt.maxLength)
+			assert.Equal(t, tt.expected, sanitized)
+			assert.Equal(t, sanitized, sanitizeComment(sanitized, tt.maxLength), "sanitizing is idempotent")
+		})
+	}
+}
+
+func TestGenerator_SanitizeComments(t *testing.T) {
+	clean := &clickhouse.Table{Name: "clean", Comment: "Blocks", Columns: []clickhouse.Column{{Name: "slot", Comment: "Slot"}}}
+	dirty := &clickhouse.Table{Name: "dirty", Comment: "Blocks", Columns: []clickhouse.Column{
+		{Name: "slot", Comment: "Slot"},
+		{Name: "root", Comment: strings.Repeat("x", 30)},
+	}}
+
+	cfg := config.NewConfig()
+	cfg.CommentMaxLength = 10
+	tables := NewGenerator(cfg, logrus.New()).sanitizeComments([]*clickhouse.Table{clean, dirty})
+
+	assert.Same(t, clean, tables[0], "tables without changes aren't copied")
+	assert.NotSame(t, dirty, tables[1])
+	assert.Equal(t, "xxxxxxx...", tables[1].Columns[1].Comment)
+	assert.Equal(t, strings.Repeat("x", 30), dirty.Columns[1].Comment, "the input is left untouched")
+}
@@ -2,19 +2,22 @@ package protogen
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 )
 
 // GenerateCommonProto generates the common.proto file with shared types
 func (g *Generator) GenerateCommonProto() error {
-	filename := filepath.Join(g.config.OutputDir, "common.proto")
+	filename := filepath.Join(g.protoDir(), "common.proto")
+	return g.writeFile(filename, g.commonProtoContent())
+}
 
+// commonProtoContent renders common.proto: the filter, range and list types shared by all requests
+func (g *Generator) commonProtoContent() string {
 	var sb strings.Builder
 
 	// Write header
-	sb.WriteString("syntax = \"proto3\";\n\n")
+	g.writeSyntax(&sb)
 
 	if g.config.Package != "" {
 		fmt.Fprintf(&sb, "package %s;\n", g.config.Package)
@@ -23,9 +26,11 @@ func (g *Generator) GenerateCommonProto() error {
 	sb.WriteString("\nimport \"google/protobuf/wrappers.proto\";\n")
 	sb.WriteString("import \"google/protobuf/empty.proto\";\n")
 
-	if g.config.GoPackage != "" {
-		fmt.Fprintf(&sb, "option go_package = \"%s\";\n", g.config.GoPackage)
+	if goPackage := g.goPackage(); goPackage != "" {
+		fmt.Fprintf(&sb, "option go_package = \"%s\";\n", goPackage)
 	}
+	g.writeLanguageOptions(&sb, false)
+	g.writeFileFeatures(&sb)
 
 	sb.WriteString("\n// Common types used across all generated services\n\n")
 
@@ -34,8 +39,12 @@ func (g *Generator) GenerateCommonProto() error {
 
 	// Generate common request/response types
 	g.writeCommonTypes(&sb)
+	if g.responseMetaEnabled() {
+		g.writeResponseMetaMessage(&sb)
+	}
+	g.writeErrorDetailTypes(&sb)
 
-	return g.writeFile(filename, sb.String())
+	return g.applySyntax(sb.String())
 }
 
 func (g *Generator) writeRangeTypes(sb *strings.Builder) {
@@ -225,10 +234,10 @@ func (g *Generator) writeRangeTypes(sb *strings.Builder) {
 	sb.WriteString("  oneof filter {\n")
 	sb.WriteString("    string eq = 1;                 // Equal to value\n")
 	sb.WriteString("    string ne = 2;                 // Not equal to value\n")
-	sb.WriteString("    string contains = 3;           // Contains substring (SQL LIKE '%value%')\n")
-	sb.WriteString("    string starts_with = 4;        // Starts with prefix (SQL LIKE 'value%')\n")
-	sb.WriteString("    string ends_with = 5;          // Ends with suffix (SQL LIKE '%value')\n")
-	sb.WriteString("    string like = 6;               // SQL LIKE pattern (% and _ wildcards)\n")
+	sb.WriteString("    string contains = 3;           // Contains substring (% and _ match themselves)\n")
+	sb.WriteString("    string starts_with = 4;        // Starts with prefix (% and _ match themselves)\n")
+	sb.WriteString("    string ends_with = 5;          // Ends with suffix (% and _ match themselves)\n")
+	sb.WriteString("    string like = 6;               // SQL LIKE pattern (% and _ wildcards, \\ escapes them)\n")
 	sb.WriteString("    string not_like = 7;           // SQL NOT LIKE pattern\n")
 	sb.WriteString("    StringList in = 8;             // In list of values\n")
 	sb.WriteString("    StringList not_in = 9;         // Not in list of values\n")
@@ -241,10 +250,10 @@ func (g *Generator) writeRangeTypes(sb *strings.Builder) {
 	sb.WriteString("  oneof filter {\n")
 	sb.WriteString("    string eq = 1;                 // Equal to value\n")
 	sb.WriteString("    string ne = 2;                 // Not equal to value\n")
-	sb.WriteString("    string contains = 3;           // Contains substring (SQL LIKE '%value%')\n")
-	sb.WriteString("    string starts_with = 4;        // Starts with prefix (SQL LIKE 'value%')\n")
-	sb.WriteString("    string ends_with = 5;          // Ends with suffix (SQL LIKE '%value')\n")
-	sb.WriteString("    string like = 6;               // SQL LIKE pattern (% and _ wildcards)\n")
+	sb.WriteString("    string contains = 3;           // Contains substring (% and _ match themselves)\n")
+	sb.WriteString("    string starts_with = 4;        // Starts with prefix (% and _ match themselves)\n")
+	sb.WriteString("    string ends_with = 5;          // Ends with suffix (% and _ match themselves)\n")
+	sb.WriteString("    string like = 6;               // SQL LIKE pattern (% and _ wildcards, \\ escapes them)\n")
 	sb.WriteString("    string not_like = 7;           // SQL NOT LIKE pattern\n")
 	sb.WriteString("    StringList in = 8;             // In list of values\n")
 	sb.WriteString("    StringList not_in = 9;         // Not in list of values\n")
@@ -468,8 +477,8 @@ func (g *Generator) writeCommonTypes(sb *strings.Builder) {
 // GenerateAnnotationsProto generates the clickhouse/annotations.proto file with custom field options
 func (g *Generator) GenerateAnnotationsProto() error {
 	// Create clickhouse subdirectory in output dir
-	clickhouseDir := filepath.Join(g.config.OutputDir, "clickhouse")
-	if err := os.MkdirAll(clickhouseDir, 0o750); err != nil {
+	clickhouseDir := filepath.Join(g.protoDir(), "clickhouse")
+	if err := g.mkdirAll(clickhouseDir); err != nil {
 		return fmt.Errorf("failed to create clickhouse directory: %w", err)
 	}
 
@@ -478,7 +487,7 @@ func (g *Generator) GenerateAnnotationsProto() error {
 	var sb strings.Builder
 
 	// Write header
-	sb.WriteString("syntax = \"proto3\";\n\n")
+	g.writeSyntax(&sb)
 
 	// Annotations always use a fixed package name, not the user's configured package
 	// This allows generated files to reference extensions as (clickhouse.v1.projection_name)
@@ -488,11 +497,15 @@ func (g *Generator) GenerateAnnotationsProto() error {
 
 	// Use the user's configured go_package as the base for the annotations package
 	// Since annotations.proto is in clickhouse/ subdirectory, append /clickhouse to the package
-	if g.config.GoPackage != "" {
-		// Remove trailing slash if present
-		goPackage := strings.TrimSuffix(g.config.GoPackage, "/")
-		fmt.Fprintf(&sb, "\noption go_package = \"%s/clickhouse\";\n", goPackage)
+	importPath := g.goImportPath()
+	if importPath != "" || g.hasLanguageOptions() {
+		sb.WriteString("\n")
+	}
+	if importPath != "" {
+		fmt.Fprintf(&sb, "option go_package = \"%s/clickhouse\";\n", importPath)
 	}
+	g.writeLanguageOptions(&sb, true)
+	g.writeFileFeatures(&sb)
 
 	sb.WriteString("\n")
 
@@ -510,8 +523,41 @@ func (g *Generator) GenerateAnnotationsProto() error {
 	sb.WriteString("  // Group name for \"at least one required\" validation.\n")
 	sb.WriteString("  // All fields with the same required_group value form an OR constraint.\n")
 	sb.WriteString("  // Example: All primary key alternatives should share the same required_group.\n")
-	sb.WriteString("  string required_group = 50003;\n")
+	sb.WriteString("  string required_group = 50003;\n\n")
+
+	sb.WriteString("  // Redaction applied to this field at the SQL layer (hash or null).\n")
+	sb.WriteString("  // Masked fields can't be filtered or ordered on.\n")
+	sb.WriteString("  string mask = 50004;\n\n")
+
+	sb.WriteString("  // Unit of the field's values, e.g. wei, gwei, seconds or bytes.\n")
+	sb.WriteString("  string unit = 50005;\n")
+	sb.WriteString("}\n\n")
+
+	// Write the table metadata carried by messages and services
+	sb.WriteString("// The ClickHouse table a message or service was generated from.\n")
+	sb.WriteString("message TableSource {\n")
+	sb.WriteString("  // Database of the table; empty when the schema was read from DDL without one.\n")
+	sb.WriteString("  string source_database = 1;\n")
+	sb.WriteString("  // Name of the table.\n")
+	sb.WriteString("  string source_table = 2;\n")
+	sb.WriteString("  // Table engine with its arguments, e.g. ReplacingMergeTree(updated_date_time).\n")
+	sb.WriteString("  string engine = 3;\n")
+	sb.WriteString("  // ORDER BY columns of the table, or the configured primary key of a view.\n")
+	sb.WriteString("  repeated string sorting_key = 4;\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("extend google.protobuf.MessageOptions {\n")
+	sb.WriteString("  // Table the row message was generated from.\n")
+	sb.WriteString("  TableSource table = 50101;\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("extend google.protobuf.ServiceOptions {\n")
+	sb.WriteString("  // Table the service queries.\n")
+	sb.WriteString("  TableSource service_table = 50201;\n\n")
+	sb.WriteString("  // How long responses of the service may be cached, in seconds. Set on the services\n")
+	sb.WriteString("  // of tables the generated result cache covers.\n")
+	sb.WriteString("  uint32 cache_ttl_seconds = 50202;\n")
 	sb.WriteString("}\n")
 
-	return g.writeFile(filename, sb.String())
+	return g.writeFile(filename, g.applySyntax(sb.String()))
 }
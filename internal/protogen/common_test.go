@@ -309,6 +309,26 @@ func TestToPascalCase(t *testing.T) {
 	}
 }
 
+func TestGoCamelCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "fct_block", expected: "FctBlock"},
+		{input: "fct_block_24h", expected: "FctBlock_24H"},
+		{input: "field1_name2", expected: "Field1Name2"},
+		{input: "_private", expected: "XPrivate"},
+		{input: "field__name", expected: "Field_Name"},
+		{input: "block_root_", expected: "BlockRoot_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, goCamelCase(tt.input))
+		})
+	}
+}
+
 func TestGetFieldNumber(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -589,9 +609,11 @@ func TestGeneratedSQLCommonContainsVariableSubstitution(t *testing.T) {
 		"*QueryBuilderOptions", // options field (flexible whitespace)
 		"func NewQueryBuilder(options ...QueryBuilderOption) *QueryBuilder",
 		"VariableSubstitution: VariableSubstitutionStandard", // Default to ? style
-		"func (qb *QueryBuilder) formatVariable(index int) string",
+		"func (qb *QueryBuilder) formatVariable(index int, value interface{}) string",
 		"case VariableSubstitutionPositional:",
 		"fmt.Sprintf(\"$%d\", index)",
+		"case VariableSubstitutionNamed:",
+		"fmt.Sprintf(\"{p%d:%s}\", index, parameterType(value))",
 		"case VariableSubstitutionStandard:",
 		"return \"?\"",
 	}
@@ -602,10 +624,10 @@ func TestGeneratedSQLCommonContainsVariableSubstitution(t *testing.T) {
 
 	// Verify that the generated functions use formatVariable instead of hardcoded $%d
 	updatedFunctionCalls := []string{
-		"placeholder := qb.formatVariable(qb.argCounter)",
-		"placeholderMin := qb.formatVariable(qb.argCounter)",
-		"placeholderMax := qb.formatVariable(qb.argCounter)",
-		"placeholders[i] = qb.formatVariable(qb.argCounter)",
+		"placeholder := qb.formatVariable(qb.argCounter, value)",
+		"placeholderMin := qb.formatVariable(qb.argCounter, minValue)",
+		"placeholderMax := qb.formatVariable(qb.argCounter, maxValue)",
+		"placeholders[i] = qb.formatVariable(qb.argCounter, v)",
 	}
 
 	for _, expected := range updatedFunctionCalls {
@@ -0,0 +1,129 @@
+package protogen
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	gengo "google.golang.org/protobuf/cmd/protoc-gen-go/internal_gengo"
+	protoplugin "google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Modules of the compiled output, at the versions of this repository's module graph so
+// they come from the same module cache
+const compileGoMod = `go 1.24
+
+require (
+	google.golang.org/genproto/googleapis/api v0.0.0-20240814211410-ddb44dafa142
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1
+	google.golang.org/grpc v1.67.0
+	google.golang.org/protobuf v1.34.2
+)
+`
+
+// generateModule generates tables into cfg.OutputDir, which must be the directory of
+// cfg.GoPackage, and turns it into a Go module: the messages are generated with
+// protoc-gen-go and the services get the client half of protoc-gen-go-grpc's stubs. Tests
+// then compile or run the output with goCommand. It's skipped in short mode and without a
+// go command.
+func generateModule(t *testing.T, cfg *config.Config, tables []*clickhouse.Table) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("compiling the generated code is skipped in short mode")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found in PATH")
+	}
+
+	cfg.DescriptorSetOut = filepath.Join(t.TempDir(), "descriptors.binpb")
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate(tables))
+
+	data, err := os.ReadFile(cfg.DescriptorSetOut)
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, set))
+
+	req := &pluginpb.CodeGeneratorRequest{Parameter: proto.String("paths=import"), ProtoFile: set.GetFile()}
+	for _, file := range set.GetFile() {
+		if !strings.HasPrefix(file.GetName(), "google/") {
+			req.FileToGenerate = append(req.FileToGenerate, file.GetName())
+		}
+	}
+	plugin, err := protoplugin.Options{}.New(req)
+	require.NoError(t, err)
+	for _, file := range plugin.Files {
+		if file.Generate {
+			gengo.GenerateFile(plugin, file)
+			writeGRPCClientStubs(plugin, file)
+		}
+	}
+
+	resp := plugin.Response()
+	require.Empty(t, resp.GetError())
+	for _, file := range resp.GetFile() {
+		name, ok := strings.CutPrefix(file.GetName(), cfg.GoPackage+"/")
+		require.True(t, ok, "%s is outside of %s", file.GetName(), cfg.GoPackage)
+		path := filepath.Join(cfg.OutputDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(file.GetContent()), 0o600))
+	}
+
+	goMod := "module " + cfg.GoPackage + "\n\n" + compileGoMod
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "go.mod"), []byte(goMod), 0o600))
+}
+
+// writeGRPCClientStubs writes the client interfaces and constructors protoc-gen-go-grpc
+// generates for the services of a file
+func writeGRPCClientStubs(plugin *protoplugin.Plugin, file *protoplugin.File) {
+	if len(file.Services) == 0 {
+		return
+	}
+
+	g := plugin.NewGeneratedFile(file.GeneratedFilenamePrefix+"_grpc.pb.go", file.GoImportPath)
+	g.P("package ", file.GoPackageName)
+	context := g.QualifiedGoIdent(protoplugin.GoIdent{GoName: "Context", GoImportPath: "context"})
+	callOption := g.QualifiedGoIdent(protoplugin.GoIdent{GoName: "CallOption", GoImportPath: "google.golang.org/grpc"})
+	conn := g.QualifiedGoIdent(protoplugin.GoIdent{GoName: "ClientConnInterface", GoImportPath: "google.golang.org/grpc"})
+
+	for _, service := range file.Services {
+		client := service.GoName + "Client"
+		impl := strings.ToLower(client[:1]) + client[1:]
+
+		g.P("type ", client, " interface {")
+		for _, method := range service.Methods {
+			g.P(method.GoName, "(ctx ", context, ", in *", method.Input.GoIdent, ", opts ...", callOption, ") (*", method.Output.GoIdent, ", error)")
+		}
+		g.P("}")
+		g.P("type ", impl, " struct{ cc ", conn, " }")
+		g.P("func New", client, "(cc ", conn, ") ", client, " { return &", impl, "{cc} }")
+		for _, method := range service.Methods {
+			g.P("func (c *", impl, ") ", method.GoName, "(ctx ", context, ", in *", method.Input.GoIdent, ", opts ...", callOption, ") (*", method.Output.GoIdent, ", error) {")
+			g.P("out := new(", method.Output.GoIdent, ")")
+			g.P("if err := c.cc.Invoke(ctx, \"/", service.Desc.FullName(), "/", method.GoName, "\", in, out, opts...); err != nil { return nil, err }")
+			g.P("return out, nil")
+			g.P("}")
+		}
+	}
+}
+
+// goCommand runs the go command in the module of a generated output directory and returns
+// its combined output, failing the test when it fails. The throwaway module is only checked
+// against the local module cache.
+func goCommand(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOSUMDB=off", "GOWORK=off")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "go %s:\n%s", strings.Join(args, " "), output)
+	return string(output)
+}
@@ -0,0 +1,76 @@
+package protogen
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoStoredColumns is the reason tables without stored columns are skipped
+var ErrNoStoredColumns = errors.New("table has no stored columns")
+
+// isComputedColumn reports whether ClickHouse computes a column's value instead of storing
+// what was inserted
+func isComputedColumn(column *clickhouse.Column) bool {
+	return column.DefaultKind == "MATERIALIZED" || column.DefaultKind == "ALIAS"
+}
+
+// applyComputedColumns leaves the computed columns out of the tables when computed_columns
+// is exclude. Columns of the sorting key are kept, as the service filters on them.
+func (g *Generator) applyComputedColumns(tables []*clickhouse.Table) []*clickhouse.Table {
+	if g.config.ComputedColumns != config.ComputedColumnsExclude {
+		return tables
+	}
+
+	result := make([]*clickhouse.Table, 0, len(tables))
+	for _, table := range tables {
+		stored := *table
+		stored.Columns = make([]clickhouse.Column, 0, len(table.Columns))
+		for i := range table.Columns {
+			column := &table.Columns[i]
+			if isComputedColumn(column) && !slices.Contains(table.SortingKey, column.Name) {
+				g.log.WithFields(logrus.Fields{
+					"table":  table.Name,
+					"column": column.Name,
+					"kind":   column.DefaultKind,
+				}).Debug("Leaving out computed column")
+				continue
+			}
+			stored.Columns = append(stored.Columns, *column)
+		}
+		result = append(result, &stored)
+	}
+	return result
+}
+
+// guardEmptyTables handles the tables without a stored column, whose messages would be
+// empty or only hold computed values. The skip policy leaves them out and keeps them for
+// the report; warn generates their messages without a service.
+func (g *Generator) guardEmptyTables(tables []*clickhouse.Table) []*clickhouse.Table {
+	result := make([]*clickhouse.Table, 0, len(tables))
+	for _, table := range tables {
+		if slices.ContainsFunc(table.Columns, func(column clickhouse.Column) bool { return !isComputedColumn(&column) }) {
+			result = append(result, table)
+			continue
+		}
+
+		log := g.log.WithFields(logrus.Fields{
+			"table":   table.Name,
+			"columns": len(table.Columns),
+		})
+		if g.config.EmptyTables == config.EmptyTablesWarn {
+			log.Warn("Table has no stored columns, generating its message without a service")
+			messageOnly := *table
+			messageOnly.SortingKey = nil
+			result = append(result, &messageOnly)
+			continue
+		}
+
+		log.Warn("Skipping table without stored columns")
+		g.skipped = append(g.skipped, tableFailure{table: table, err: ErrNoStoredColumns})
+	}
+	return result
+}
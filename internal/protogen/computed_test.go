@@ -0,0 +1,112 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func computedColumn(name, chType, kind string, position uint64) clickhouse.Column {
+	column := clickhouse.NewColumn(name, chType, position)
+	column.DefaultKind = kind
+	column.DefaultValue = "0"
+	return column
+}
+
+func TestGenerator_ComputedColumns(t *testing.T) {
+	table := func() *clickhouse.Table {
+		return &clickhouse.Table{
+			Name:     "fct_block",
+			Database: "default",
+			Engine:   "MergeTree",
+			Columns: []clickhouse.Column{
+				computedColumn("slot_day", "Date", "MATERIALIZED", 1),
+				clickhouse.NewColumn("slot", "UInt32", 2),
+				computedColumn("slot_hex", "String", "ALIAS", 3),
+			},
+			SortingKey: []string{"slot_day", "slot"},
+		}
+	}
+
+	generate := func(t *testing.T, mode string) string {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.ComputedColumns = mode
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table()}))
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_block.proto"))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	included := parseProtoMessages(generate(t, config.ComputedColumnsInclude))[0].Fields
+	require.Len(t, included, 3)
+	assert.Equal(t, "slot_hex", included[2].Name)
+
+	excluded := parseProtoMessages(generate(t, config.ComputedColumnsExclude))[0].Fields
+	require.Len(t, excluded, 2)
+	assert.Equal(t, "slot_day", excluded[0].Name, "sorting key columns are kept")
+	assert.Equal(t, "slot", excluded[1].Name)
+}
+
+func TestGenerator_EmptyTables(t *testing.T) {
+	tables := func() []*clickhouse.Table {
+		return []*clickhouse.Table{
+			{
+				Name:       "fct_block",
+				Database:   "default",
+				Engine:     "MergeTree",
+				Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+				SortingKey: []string{"slot"},
+			},
+			{Name: "fct_empty", Database: "default", Engine: "MergeTree", SortingKey: []string{"slot"}},
+			{
+				Name:       "fct_aliases",
+				Database:   "default",
+				Engine:     "MergeTree",
+				Columns:    []clickhouse.Column{computedColumn("slot", "UInt32", "ALIAS", 1)},
+				SortingKey: []string{"slot"},
+			},
+		}
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		g := NewGenerator(cfg, logrus.New())
+		require.NoError(t, g.Generate(tables()))
+
+		assert.FileExists(t, filepath.Join(cfg.OutputDir, "fct_block.proto"))
+		assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "fct_empty.proto"))
+		assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "fct_aliases.proto"))
+		assert.Empty(t, g.TableFailures(), "skipped tables don't count as failures")
+
+		report := g.Report()
+		require.Len(t, report.Tables, 3)
+		assert.Equal(t, TableReport{Database: "default", Table: "fct_empty", Status: TableStatusSkipped, Reason: ErrNoStoredColumns.Error()}, report.Tables[1])
+		assert.Equal(t, "fct_aliases", report.Tables[2].Table)
+
+		_, err := NewGenerator(cfg, logrus.New()).TableProto(tables()[1])
+		assert.ErrorIs(t, err, ErrNoStoredColumns)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.EmptyTables = config.EmptyTablesWarn
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate(tables()))
+
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_aliases.proto"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "message FctAliases {")
+		assert.NotContains(t, string(content), "service ")
+		assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "fct_aliases_sql.go"))
+		assert.FileExists(t, filepath.Join(cfg.OutputDir, "fct_empty.proto"))
+	})
+}
@@ -0,0 +1,98 @@
+package protogen
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// tableFailure is a table whose files failed to generate
+type tableFailure struct {
+	table *clickhouse.Table
+	err   error
+}
+
+// concurrency returns how many tables are generated at once: the configured limit, or one
+// per CPU
+func (g *Generator) concurrency() int {
+	if g.config.Concurrency > 0 {
+		return g.config.Concurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// forEachTable runs generate for the tables concurrently and returns the ones it succeeded
+// for, in their original order. Failed tables are handled by the on_error policy: fail stops
+// starting tables and returns the first error in table order, skip and report log the
+// failure, record it for the report and leave the table out of the rest of the run. A panic
+// generating a table fails that table, with the panic's stack in its error.
+func (g *Generator) forEachTable(tables []*clickhouse.Table, generate func(*clickhouse.Table) error) ([]*clickhouse.Table, error) {
+	failFast := g.config.OnError == config.OnErrorFail
+	errs := make([]error, len(tables))
+	slots := make(chan struct{}, g.concurrency())
+
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+	for i, table := range tables {
+		slots <- struct{}{}
+		if failFast && failed.Load() {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+					failed.Store(true)
+				}
+			}()
+			if errs[i] = generate(table); errs[i] != nil {
+				failed.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	generated := make([]*clickhouse.Table, 0, len(tables))
+	for i, table := range tables {
+		err := errs[i]
+		if err == nil {
+			generated = append(generated, table)
+			continue
+		}
+
+		log := g.log.WithError(err).WithFields(logrus.Fields{
+			"database": table.Database,
+			"table":    table.Name,
+		})
+		if failFast {
+			log.Error("Failed to generate table files")
+			return nil, fmt.Errorf("%s.%s: %w", table.Database, table.Name, err)
+		}
+		log.Warn("Failed to generate table files, leaving the table out")
+		g.failed = append(g.failed, tableFailure{table: table, err: err})
+	}
+	return generated, nil
+}
+
+// TableFailures returns the tables whose files failed to generate in the last Generate run,
+// which the skip and report policies leave out instead of aborting
+func (g *Generator) TableFailures() []error {
+	failures := make([]error, 0, len(g.failed))
+	for _, f := range g.failed {
+		failures = append(failures, fmt.Errorf("%s.%s: %w", f.table.Database, f.table.Name, f.err))
+	}
+	return failures
+}
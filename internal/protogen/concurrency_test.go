@@ -0,0 +1,110 @@
+package protogen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func concurrencyTestTables(n int) []*clickhouse.Table {
+	tables := make([]*clickhouse.Table, 0, n)
+	for i := range n {
+		tables = append(tables, &clickhouse.Table{
+			Name:     fmt.Sprintf("fct_table_%02d", i),
+			Database: "default",
+			Engine:   "MergeTree",
+			Columns: []clickhouse.Column{
+				clickhouse.NewColumn("slot", "UInt32", 1),
+				clickhouse.NewColumn("value", "Nullable(UInt64)", 2),
+			},
+			SortingKey: []string{"slot"},
+		})
+	}
+	return tables
+}
+
+func TestGenerator_ConcurrentTablesMatchSequential(t *testing.T) {
+	generate := func(concurrency int) map[string]string {
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.GoPackage = "github.com/ethpandaops/beacon/gen/beaconv1"
+		cfg.Concurrency = concurrency
+		cfg.FieldNumbers.Strategy = config.FieldNumbersLock
+		g := NewGenerator(cfg, logrus.New())
+		require.NoError(t, g.Generate(concurrencyTestTables(24)))
+		assert.Equal(t, 24*2+7, g.Stats().Changed, "protos, SQL helpers, common.proto, annotations, common.go, status.go, validate.go, hash.go and the lock")
+		return readTree(t, cfg.OutputDir)
+	}
+
+	assert.Equal(t, generate(1), generate(8))
+}
+
+func TestGenerator_TableFailures(t *testing.T) {
+	newConfig := func(onError string) *config.Config {
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.OnError = onError
+		// A directory in place of its proto file fails the table's write
+		require.NoError(t, os.MkdirAll(filepath.Join(cfg.OutputDir, "fct_table_01.proto"), 0o750))
+		return cfg
+	}
+
+	t.Run("skip leaves the table out", func(t *testing.T) {
+		cfg := newConfig(config.OnErrorSkip)
+		g := NewGenerator(cfg, logrus.New())
+		require.NoError(t, g.Generate(concurrencyTestTables(3)))
+
+		require.Len(t, g.TableFailures(), 1)
+		assert.Contains(t, g.TableFailures()[0].Error(), "default.fct_table_01: failed to write file")
+		assert.FileExists(t, filepath.Join(cfg.OutputDir, "fct_table_00_sql.go"))
+		assert.FileExists(t, filepath.Join(cfg.OutputDir, "fct_table_02_sql.go"))
+		assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "fct_table_01_sql.go"))
+
+		report := g.Report()
+		require.Len(t, report.Tables, 3)
+		assert.Equal(t, TableStatusGenerated, report.Tables[0].Status)
+		assert.Equal(t, TableStatusGenerated, report.Tables[1].Status)
+		assert.Equal(t, TableReport{Database: "default", Table: "fct_table_01", Status: TableStatusSkipped, Reason: g.failed[0].err.Error()}, report.Tables[2])
+	})
+
+	t.Run("fail aborts the run", func(t *testing.T) {
+		cfg := newConfig(config.OnErrorFail)
+		err := NewGenerator(cfg, logrus.New()).Generate(concurrencyTestTables(3))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "default.fct_table_01")
+		assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "common.go"), "nothing after the table files is generated")
+	})
+
+	t.Run("a panic fails only its table", func(t *testing.T) {
+		for _, onError := range []string{config.OnErrorSkip, config.OnErrorFail} {
+			cfg := config.NewConfig()
+			cfg.OnError = onError
+			g := NewGenerator(cfg, logrus.New())
+			tables := concurrencyTestTables(3)
+
+			generated, err := g.forEachTable(tables, func(table *clickhouse.Table) error {
+				// Like a lookup of a missing column, which only fct_table_01 makes
+				columns := map[string]*clickhouse.Column{"fct_table_00": {}, "fct_table_02": {}}
+				_ = columns[table.Name].Name
+				return nil
+			})
+			if onError == config.OnErrorFail {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "default.fct_table_01: panic: runtime error: invalid memory address")
+				continue
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []*clickhouse.Table{tables[0], tables[2]}, generated)
+			require.Len(t, g.TableFailures(), 1)
+			assert.Contains(t, g.TableFailures()[0].Error(), "default.fct_table_01: panic: runtime error: invalid memory address")
+			assert.Contains(t, g.TableFailures()[0].Error(), "concurrency_test.go", "the error carries the panic's stack")
+		}
+	})
+}
@@ -0,0 +1,857 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+const (
+	defaultConformanceDir = "conformance"
+	conformanceFile       = "conformance_test.go"
+)
+
+// Environment variables the conformance suite reads
+const (
+	conformanceTargetEnv   = "CONFORMANCE_TARGET"
+	conformanceDSNEnv      = "CONFORMANCE_DSN"
+	conformanceDatabaseEnv = "CONFORMANCE_DATABASE"
+	conformanceTenantEnv   = "CONFORMANCE_TENANT"
+)
+
+// GenerateConformance generates <output_dir>/conformance with a test per table with
+// services. The tests call a running implementation of the services over gRPC and compare
+// its responses with direct queries of the same ClickHouse tables: pagination, every
+// operator of every filter, ordering by each scalar column, NULL values and Get. The
+// expected results are written against the ClickHouse columns rather than the generated
+// SQL helpers, so the suite catches helpers that don't do what the proto promises.
+func (g *Generator) GenerateConformance(tables []*clickhouse.Table) error {
+	importPath := g.goImportPath()
+	if importPath == "" {
+		g.log.Warn("Skipping conformance tests: go_package is required to import the generated messages")
+		return nil
+	}
+
+	dir := g.emitterDir(g.config.Conformance.Dir, defaultConformanceDir)
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create conformance directory: %w", err)
+	}
+
+	filename := filepath.Join(dir, conformanceFile)
+	if err := g.writeFile(filename, g.buildConformance(tables, importPath)); err != nil {
+		return err
+	}
+
+	g.log.WithField("file", filename).Info("Generated conformance tests")
+	return nil
+}
+
+func (g *Generator) buildConformance(tables []*clickhouse.Table, importPath string) string {
+	sb := &strings.Builder{}
+
+	sb.WriteString("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n\n")
+	sb.WriteString("// Package conformance checks a running implementation of the generated services against\n")
+	sb.WriteString("// the ClickHouse tables it serves. Seed the tables, start the implementation and run\n")
+	fmt.Fprintf(sb, "// go test with %s set to its gRPC address and %s to the ClickHouse DSN.\n", conformanceTargetEnv, conformanceDSNEnv)
+	sb.WriteString("package conformance\n\n")
+
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"context\"\n")
+	sb.WriteString("\t\"fmt\"\n")
+	sb.WriteString("\t\"os\"\n")
+	sb.WriteString("\t\"strconv\"\n")
+	sb.WriteString("\t\"strings\"\n")
+	sb.WriteString("\t\"testing\"\n\n")
+	sb.WriteString("\t\"github.com/ClickHouse/clickhouse-go/v2\"\n")
+	sb.WriteString("\t\"github.com/ClickHouse/clickhouse-go/v2/lib/driver\"\n")
+	sb.WriteString("\t\"google.golang.org/grpc\"\n")
+	sb.WriteString("\t\"google.golang.org/grpc/credentials/insecure\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/reflect/protoreflect\"\n\n")
+	fmt.Fprintf(sb, "\tpb %q\n", importPath)
+	sb.WriteString(")\n\n")
+
+	for _, table := range tables {
+		g.writeConformanceTest(sb, table)
+	}
+
+	sb.WriteString(conformanceRuntime)
+	return sb.String()
+}
+
+// writeConformanceTest writes the test of a table with services. Masked columns are left
+// out, since their values differ from the table's by design.
+func (g *Generator) writeConformanceTest(sb *strings.Builder, table *clickhouse.Table) {
+	if len(table.Columns) == 0 || len(table.SortingKey) == 0 {
+		return
+	}
+
+	var key *clickhouse.Column
+	for i := range table.Columns {
+		if table.Columns[i].Name == table.SortingKey[0] {
+			key = &table.Columns[i]
+		}
+	}
+	if key == nil {
+		return
+	}
+
+	messageName := ToPascalCase(table.Name)
+	goName := getProtocMessageName(table.Name)
+
+	fmt.Fprintf(sb, "func Test%sConformance(t *testing.T) {\n", goName)
+	sb.WriteString("\trunSuite(t, tableSuite{\n")
+	database, name := g.queryTarget(table)
+	fmt.Fprintf(sb, "\t\ttable:        %q,\n", quoteIdentifier(name))
+	if database != "" {
+		fmt.Fprintf(sb, "\t\tdatabase:     %q,\n", quoteIdentifier(database))
+	}
+	fmt.Fprintf(sb, "\t\tservice:      %q,\n", g.config.Package+"."+messageName+"Service")
+	fmt.Fprintf(sb, "\t\tlistRequest:  func() proto.Message { return &pb.List%sRequest{} },\n", goName)
+	fmt.Fprintf(sb, "\t\tlistResponse: func() proto.Message { return &pb.List%sResponse{} },\n", goName)
+	fmt.Fprintf(sb, "\t\tgetRequest:   func() proto.Message { return &pb.Get%sRequest{} },\n", goName)
+	fmt.Fprintf(sb, "\t\tgetResponse:  func() proto.Message { return &pb.Get%sResponse{} },\n", goName)
+	fmt.Fprintf(sb, "\t\tkey:          column%s,\n", g.conformanceColumn(table, key))
+	if tenant, _ := g.tenantScopeFor(table); tenant != nil {
+		fmt.Fprintf(sb, "\t\ttenantColumn: %q,\n", quoteIdentifier(tenant.column))
+		fmt.Fprintf(sb, "\t\ttenantField:  %q,\n", tenant.field)
+	}
+	if where := g.defaultWhereFor(table).Where; where != "" {
+		fmt.Fprintf(sb, "\t\tdefaultWhere: %q,\n", where)
+	}
+	sb.WriteString("\t\tcolumns: []column{\n")
+	for i := range table.Columns {
+		if g.isMasked(table.Name, table.Columns[i].Name) {
+			continue
+		}
+		fmt.Fprintf(sb, "\t\t\t%s,\n", g.conformanceColumn(table, &table.Columns[i]))
+	}
+	sb.WriteString("\t\t},\n")
+	sb.WriteString("\t})\n")
+	sb.WriteString("}\n\n")
+}
+
+// conformanceColumn renders the column literal of the suite: the proto field, the column
+// name order_by takes, the quoted column and the SQL of its proto value
+func (g *Generator) conformanceColumn(table *clickhouse.Table, col *clickhouse.Column) string {
+	value := unaliasedExpression(col, getSelectColumnExpression(col, table.Name, &g.config.Conversion))
+	// order_by takes the field, which is the column's alias when their names differ
+	field := g.fieldName(table.Name, col.Name)
+	return fmt.Sprintf("{field: %q, name: %q, column: %q, value: %q}",
+		field, field, quoteIdentifier(col.Name), value)
+}
+
+// conformanceRuntime is the table independent part of the conformance tests
+const conformanceRuntime = `// Environment variables configuring the suite
+const (
+	// targetEnv is the gRPC address of the implementation under test
+	targetEnv = "` + conformanceTargetEnv + `"
+	// dsnEnv is the DSN of the ClickHouse server holding the data the implementation serves
+	dsnEnv = "` + conformanceDSNEnv + `"
+	// databaseEnv is the database of the tables, the default database of the DSN when unset
+	databaseEnv = "` + conformanceDatabaseEnv + `"
+	// tenantEnv is the tenant the implementation resolves requests of tenant-scoped tables to
+	tenantEnv = "` + conformanceTenantEnv + `"
+)
+
+const (
+	// pageSize is the page size of the pagination and ordering checks
+	pageSize = 25
+	// fetchSize is the page size used to read every row matching a filter
+	fetchSize = 1000
+	// maxRows bounds the rows read through the service per check
+	maxRows = 100_000
+	// nullValue stands for NULL in values read through direct SQL
+	nullValue = "\x00"
+)
+
+// column describes a column of a table and the proto field it maps to
+type column struct {
+	field  string // Field of the row message and filter of the List request
+	name   string // Column name, as accepted by order_by
+	column string // Quoted column
+	value  string // SQL rendering the column as its proto value
+}
+
+// tableSuite describes the services of a table
+type tableSuite struct {
+	table        string // Quoted table the generated queries read
+	database     string // Quoted database of a local table in another one, which CONFORMANCE_DATABASE doesn't override
+	service      string // Full name of the gRPC service
+	listRequest  func() proto.Message
+	listResponse func() proto.Message
+	getRequest   func() proto.Message
+	getResponse  func() proto.Message
+	key          column // Primary key: the required List filter and the Get request field
+	tenantColumn string
+	tenantField  string
+	defaultWhere string // Condition of default_where every query of the service carries
+	columns      []column
+}
+
+// condition is a WHERE condition of a direct query with its arguments
+type condition struct {
+	sql  string
+	args []any
+}
+
+// harness runs the checks of a table against the service and ClickHouse
+type harness struct {
+	suite  tableSuite
+	conn   *grpc.ClientConn
+	db     driver.Conn
+	from   string
+	tenant protoreflect.Value
+	scope  []condition // Tenant and default_where conditions, if any
+	base   condition   // Key condition every List request carries
+	// setBase sets the key filter matching base on a List request
+	setBase func(protoreflect.Message)
+}
+
+// runSuite runs every check of a table. Checks skip when the seeded data doesn't allow
+// them, e.g. a filter operator on a column without values.
+func runSuite(t *testing.T, suite tableSuite) {
+	h := newHarness(t, suite)
+
+	t.Run("Pagination", h.testPagination)
+	t.Run("Filters", h.testFilters)
+	t.Run("Ordering", h.testOrdering)
+	t.Run("Nulls", h.testNulls)
+	t.Run("Get", h.testGet)
+}
+
+func newHarness(t *testing.T, suite tableSuite) *harness {
+	t.Helper()
+
+	target, dsn := os.Getenv(targetEnv), os.Getenv(dsnEnv)
+	if target == "" || dsn == "" {
+		t.Skip(targetEnv + " and " + dsnEnv + " are not set")
+	}
+
+	h := &harness{suite: suite, from: suite.table}
+	if suite.database != "" {
+		h.from = suite.database + "." + suite.table
+	} else if database := os.Getenv(databaseEnv); database != "" {
+		h.from = "\x60" + strings.ReplaceAll(database, "\x60", "\\\x60") + "\x60." + suite.table
+	}
+
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", target, err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	h.conn = conn
+
+	options, err := clickhouse.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse DSN: %v", err)
+	}
+	db, err := clickhouse.Open(options)
+	if err != nil {
+		t.Fatalf("failed to open ClickHouse connection: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	h.db = db
+
+	if suite.tenantColumn != "" {
+		tenant := os.Getenv(tenantEnv)
+		if tenant == "" {
+			t.Skip(tenantEnv + " is not set for a tenant-scoped table")
+		}
+		field := requestField(t, suite.listRequest().ProtoReflect(), suite.tenantField)
+		value, arg, err := parseValue(field, tenant)
+		if err != nil {
+			t.Fatalf("invalid tenant: %v", err)
+		}
+		h.tenant = value
+		h.scope = []condition{{sql: suite.tenantColumn + " = ?", args: []any{arg}}}
+	}
+	if suite.defaultWhere != "" {
+		h.scope = append(h.scope, condition{sql: suite.defaultWhere})
+	}
+
+	if h.count(t) == 0 {
+		t.Skip("no rows to check in " + h.from)
+	}
+	h.keyCondition(t)
+	return h
+}
+
+// keyCondition sets the key filter every List request needs to one matching all rows:
+// gte the smallest key, or like "%" for string keys
+func (h *harness) keyCondition(t *testing.T) {
+	field := requestField(t, h.suite.listRequest().ProtoReflect(), h.suite.key.field)
+	if field.Kind() != protoreflect.MessageKind {
+		t.Fatalf("List request field %s is not a filter", field.Name())
+	}
+	operators := field.Message().Fields()
+	key := "(" + h.suite.key.value + ")"
+
+	var op protoreflect.FieldDescriptor
+	var operand string
+	switch {
+	case operators.ByName("gte") != nil:
+		op = operators.ByName("gte")
+		operand = h.values(t, "min("+key+")", "")[0]
+		h.base.sql = key + " >= ?"
+	case operators.ByName("like") != nil:
+		op = operators.ByName("like")
+		operand = "%"
+		h.base.sql = key + " LIKE ?"
+	default:
+		t.Fatalf("no operator of %s matches every row", field.Message().FullName())
+	}
+
+	value, arg, err := parseValue(op, operand)
+	if err != nil {
+		t.Fatalf("invalid key value: %v", err)
+	}
+	h.base.args = []any{arg}
+	h.setBase = func(req protoreflect.Message) {
+		req.Mutable(field).Message().Set(op, value)
+	}
+}
+
+// testPagination pages through the rows in the default order, by primary key, and compares
+// the keys with the direct query
+func (h *harness) testPagination(t *testing.T) {
+	rows, complete := h.list(t, nil, pageSize, 20*pageSize)
+	got := formatFields(rows, h.suite.key.field)
+
+	suffix := " ORDER BY " + h.suite.key.column
+	if !complete {
+		suffix += " LIMIT " + strconv.Itoa(len(rows))
+	}
+	want := h.values(t, h.suite.key.value, suffix)
+	compareValues(t, "keys", got, want)
+}
+
+// testFilters checks every operator of every filter of the List request with a value from
+// the seeded data
+func (h *harness) testFilters(t *testing.T) {
+	request := h.suite.listRequest().ProtoReflect().Descriptor()
+	for _, c := range h.suite.columns {
+		field := request.Fields().ByName(protoreflect.Name(c.field))
+		if field == nil || field.Kind() != protoreflect.MessageKind {
+			continue
+		}
+		operators := field.Message().Fields()
+		for i := 0; i < operators.Len(); i++ {
+			op := operators.Get(i)
+			t.Run(c.field+"/"+string(op.Name()), func(t *testing.T) {
+				h.testOperator(t, c, field, op)
+			})
+		}
+	}
+}
+
+func (h *harness) testOperator(t *testing.T, c column, field, op protoreflect.FieldDescriptor) {
+	filters := field.Message().Fields()
+	var (
+		cond condition
+		set  func(protoreflect.Message)
+	)
+	switch {
+	case filters.ByName("has_key") != nil:
+		cond, set = h.mapOperator(t, c, op)
+	case filters.ByName("has") != nil:
+		cond, set = h.operator(t, c, op, arrayOperators, arrayOperand)
+	default:
+		cond, set = h.operator(t, c, op, scalarOperators, scalarOperand)
+	}
+
+	rows, complete := h.list(t, func(req protoreflect.Message) {
+		set(req.Mutable(field).Message())
+	}, fetchSize, maxRows)
+	if !complete {
+		t.Fatalf("more than %d rows match, seed fewer rows", maxRows)
+	}
+
+	if want := h.count(t, cond); uint64(len(rows)) != want {
+		t.Errorf("List returned %d rows, direct SQL %d rows matching %s %v", len(rows), want, cond.sql, cond.args)
+	}
+}
+
+// Direct SQL of the filter operators; %s is the proto value of the column
+var (
+	scalarOperators = map[string]string{
+		"eq": "%s = ?", "ne": "%s != ?", "lt": "%s < ?", "lte": "%s <= ?", "gt": "%s > ?", "gte": "%s >= ?",
+		"between": "%s BETWEEN ? AND ?", "in": "%s IN (?)", "not_in": "%s NOT IN (?)",
+		"contains": "position(%s, ?) > 0", "starts_with": "startsWith(%s, ?)", "ends_with": "endsWith(%s, ?)",
+		"like": "%s LIKE ?", "not_like": "%s NOT LIKE ?", "is_null": "%s IS NULL", "is_not_null": "%s IS NOT NULL",
+	}
+	arrayOperators = map[string]string{
+		"has": "has(%s, ?)", "has_all": "hasAll(%s, [?])", "has_any": "hasAny(%s, [?])",
+		"length_eq": "length(%s) = ?", "length_gt": "length(%s) > ?", "length_gte": "length(%s) >= ?",
+		"length_lt": "length(%s) < ?", "length_lte": "length(%s) <= ?",
+		"is_empty": "empty(%s)", "is_not_empty": "notEmpty(%s)",
+	}
+	mapOperators = map[string]string{
+		"has_key": "mapContains(%s, ?)", "not_has_key": "NOT mapContains(%s, ?)",
+		"has_any_key": "hasAny(mapKeys(%s), [?])", "has_all_keys": "hasAll(mapKeys(%s), [?])",
+	}
+)
+
+// scalarOperand returns the SQL of the value operators of scalar filters compare with
+func scalarOperand(value, _ string) string {
+	return value
+}
+
+// arrayOperand returns the SQL of the value operators of array filters compare with:
+// the length for length operators, otherwise an element
+func arrayOperand(value, op string) string {
+	if strings.HasPrefix(op, "length_") {
+		return "length(" + value + ")"
+	}
+	return value + "[1]"
+}
+
+// operator returns the direct SQL condition of a scalar or array filter operator, and a
+// function setting the operator on a filter with the same value. The value is the median
+// of the operand over the rows.
+func (h *harness) operator(t *testing.T, c column, op protoreflect.FieldDescriptor, templates map[string]string, operand func(value, op string) string) (condition, func(protoreflect.Message)) {
+	name := string(op.Name())
+	template, ok := templates[name]
+	if !ok {
+		t.Skip("no direct SQL for operator " + name)
+	}
+	value := "(" + c.value + ")"
+	cond := condition{sql: fmt.Sprintf(template, value)}
+
+	kind := operandField(op)
+	if kind == nil {
+		return cond, func(filter protoreflect.Message) { setOperand(filter, op, protoreflect.Value{}) }
+	}
+
+	// Array operands are taken from rows with elements
+	expr := operand(value, name)
+	var conditions []condition
+	if expr != value {
+		conditions = append(conditions, condition{sql: "notEmpty(" + value + ")"})
+	}
+	pivot, ok := h.pivot(t, expr, conditions...)
+	if !ok {
+		t.Skip("no values of " + c.name + " to filter by")
+	}
+	v, arg, err := parseValue(kind, pivot)
+	if err != nil {
+		t.Fatalf("invalid value of %s: %v", c.name, err)
+	}
+	for range strings.Count(template, "?") {
+		cond.args = append(cond.args, arg)
+	}
+	return cond, func(filter protoreflect.Message) { setOperand(filter, op, v) }
+}
+
+// mapOperator is operator for map filters. key_value checks equality of the value of a
+// key; the other operators check for a key.
+func (h *harness) mapOperator(t *testing.T, c column, op protoreflect.FieldDescriptor) (condition, func(protoreflect.Message)) {
+	value := "(" + c.value + ")"
+	pair, ok := h.pivot(t, "concat(toString(mapKeys("+value+")[1]), char(0), toString(mapValues("+value+")[1]))", condition{sql: "notEmpty(" + value + ")"})
+	if !ok {
+		t.Skip("no map entries of " + c.name + " to filter by")
+	}
+	key, entry, _ := strings.Cut(pair, "\x00")
+
+	name := string(op.Name())
+	if name == "key_value" {
+		fields := op.Message().Fields()
+		eq := fields.ByName("value_filter").Message().Fields().ByName("eq")
+		v, arg, err := parseValue(eq, entry)
+		if err != nil {
+			t.Fatalf("invalid value of %s: %v", c.name, err)
+		}
+		cond := condition{sql: value + "[?] = ?", args: []any{key, arg}}
+		return cond, func(filter protoreflect.Message) {
+			pairFilter := filter.Mutable(op).Message()
+			pairFilter.Set(fields.ByName("key"), protoreflect.ValueOfString(key))
+			pairFilter.Mutable(fields.ByName("value_filter")).Message().Set(eq, v)
+		}
+	}
+
+	template, ok := mapOperators[name]
+	if !ok {
+		t.Skip("no direct SQL for operator " + name)
+	}
+	cond := condition{sql: fmt.Sprintf(template, value), args: []any{key}}
+	return cond, func(filter protoreflect.Message) { setOperand(filter, op, protoreflect.ValueOfString(key)) }
+}
+
+// testOrdering orders the first page by each scalar column in both directions and compares
+// the column values with the direct query. Float columns are left out since their text
+// forms differ between ClickHouse and Go.
+func (h *harness) testOrdering(t *testing.T) {
+	row := h.rowDescriptor()
+	orderBy := h.suite.listRequest().ProtoReflect().Descriptor().Fields().ByName("order_by")
+	if orderBy == nil {
+		t.Skip("List request has no order_by")
+	}
+
+	for _, c := range h.suite.columns {
+		field := row.Fields().ByName(protoreflect.Name(c.field))
+		if field == nil || field.IsList() || field.IsMap() || isFloat(valueField(field)) {
+			continue
+		}
+		for _, direction := range []string{"", " DESC"} {
+			t.Run(c.field+strings.ToLower(direction), func(t *testing.T) {
+				rows, _ := h.list(t, func(req protoreflect.Message) {
+					req.Set(orderBy, protoreflect.ValueOfString(c.name+strings.ToLower(direction)))
+				}, pageSize, pageSize)
+
+				want := h.values(t, c.value, " ORDER BY "+c.column+direction+" LIMIT "+strconv.Itoa(pageSize))
+				compareValues(t, c.field, formatFields(rows, c.field), want)
+			})
+		}
+	}
+}
+
+// testNulls compares the unset wrapper fields of nullable columns with the NULLs in the table
+func (h *harness) testNulls(t *testing.T) {
+	row := h.rowDescriptor()
+	var nullable []column
+	for _, c := range h.suite.columns {
+		field := row.Fields().ByName(protoreflect.Name(c.field))
+		if field != nil && !field.IsList() && !field.IsMap() && field.Kind() == protoreflect.MessageKind {
+			nullable = append(nullable, c)
+		}
+	}
+	if len(nullable) == 0 {
+		t.Skip("no nullable columns")
+	}
+
+	rows, complete := h.list(t, nil, fetchSize, maxRows)
+	if !complete {
+		t.Fatalf("more than %d rows, seed fewer rows", maxRows)
+	}
+	for _, c := range nullable {
+		got := uint64(0)
+		for _, value := range formatFields(rows, c.field) {
+			if value == nullValue {
+				got++
+			}
+		}
+		if want := h.count(t, condition{sql: "(" + c.value + ") IS NULL"}); got != want {
+			t.Errorf("%s: List returned %d nulls, direct SQL %d", c.field, got, want)
+		}
+	}
+}
+
+// testGet gets the row of a key and compares its key
+func (h *harness) testGet(t *testing.T) {
+	pivot, ok := h.pivot(t, h.suite.key.value)
+	if !ok {
+		t.Skip("no key to get")
+	}
+
+	req := h.suite.getRequest().ProtoReflect()
+	field := requestField(t, req, h.suite.key.field)
+	value, _, err := parseValue(field, pivot)
+	if err != nil {
+		t.Fatalf("invalid key value: %v", err)
+	}
+	req.Set(field, value)
+	h.setTenant(t, req)
+
+	resp := h.suite.getResponse()
+	if err := h.conn.Invoke(context.Background(), "/"+h.suite.service+"/Get", req.Interface(), resp); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	item := firstField(resp.ProtoReflect().Descriptor(), func(field protoreflect.FieldDescriptor) bool {
+		return field.Kind() == protoreflect.MessageKind
+	})
+	if item == nil || !resp.ProtoReflect().Has(item) {
+		t.Fatalf("Get returned no row for %s = %s", h.suite.key.name, pivot)
+	}
+	got := formatFields([]protoreflect.Message{resp.ProtoReflect().Get(item).Message()}, h.suite.key.field)
+	compareValues(t, "key", got, []string{pivot})
+}
+
+// list reads the rows of List requests through the service, following next_page_token
+// until the last page or limit rows. configure adds filters or options to each request.
+func (h *harness) list(t *testing.T, configure func(protoreflect.Message), size, limit int) (rows []protoreflect.Message, complete bool) {
+	t.Helper()
+
+	token := ""
+	for len(rows) < limit {
+		req := h.suite.listRequest().ProtoReflect()
+		h.setBase(req)
+		h.setTenant(t, req)
+		if configure != nil {
+			configure(req)
+		}
+		fields := req.Descriptor().Fields()
+		req.Set(fields.ByName("page_size"), protoreflect.ValueOfInt32(int32(size)))
+		req.Set(fields.ByName("page_token"), protoreflect.ValueOfString(token))
+
+		resp := h.suite.listResponse()
+		if err := h.conn.Invoke(context.Background(), "/"+h.suite.service+"/List", req.Interface(), resp); err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+
+		message := resp.ProtoReflect()
+		page := message.Get(h.rowsField()).List()
+		if page.Len() > size {
+			t.Errorf("page has %d rows, more than page_size %d", page.Len(), size)
+		}
+		for i := 0; i < page.Len(); i++ {
+			rows = append(rows, page.Get(i).Message())
+		}
+
+		token = message.Get(message.Descriptor().Fields().ByName("next_page_token")).String()
+		if token == "" {
+			return rows, true
+		}
+	}
+	return rows, false
+}
+
+func (h *harness) setTenant(t *testing.T, req protoreflect.Message) {
+	if h.suite.tenantField != "" {
+		req.Set(requestField(t, req, h.suite.tenantField), h.tenant)
+	}
+}
+
+// rowsField returns the repeated row field of the List response
+func (h *harness) rowsField() protoreflect.FieldDescriptor {
+	return firstField(h.suite.listResponse().ProtoReflect().Descriptor(), func(field protoreflect.FieldDescriptor) bool {
+		return field.IsList() && field.Kind() == protoreflect.MessageKind
+	})
+}
+
+func (h *harness) rowDescriptor() protoreflect.MessageDescriptor {
+	return h.rowsField().Message()
+}
+
+// count returns the number of rows matching the conditions through direct SQL
+func (h *harness) count(t *testing.T, conditions ...condition) uint64 {
+	t.Helper()
+
+	where, args := h.where(conditions)
+	var count uint64
+	if err := h.db.QueryRow(context.Background(), "SELECT count() FROM "+h.from+where, args...).Scan(&count); err != nil {
+		t.Fatalf("count query failed: %v", err)
+	}
+	return count
+}
+
+// values returns expr of the rows matching the conditions as text, with nullValue for
+// NULL. suffix holds ORDER BY and LIMIT clauses.
+func (h *harness) values(t *testing.T, expr, suffix string, conditions ...condition) []string {
+	t.Helper()
+
+	where, args := h.where(conditions)
+	query := "SELECT ifNull(toString(" + expr + "), char(0)) FROM " + h.from + where + suffix
+	rows, err := h.db.Query(context.Background(), query, args...)
+	if err != nil {
+		t.Fatalf("query failed: %v\n%s", err, query)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatalf("failed to scan value: %v", err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("reading rows failed: %v", err)
+	}
+	return values
+}
+
+// pivot returns the median non-NULL value of expr over the rows matching the conditions
+func (h *harness) pivot(t *testing.T, expr string, conditions ...condition) (string, bool) {
+	conditions = append(conditions, condition{sql: "(" + expr + ") IS NOT NULL"})
+	count := h.count(t, conditions...)
+	if count == 0 {
+		return "", false
+	}
+	suffix := fmt.Sprintf(" ORDER BY %s LIMIT 1 OFFSET %d", expr, count/2)
+	return h.values(t, expr, suffix, conditions...)[0], true
+}
+
+// where renders the WHERE clause of the scope and key conditions and the given ones
+func (h *harness) where(conditions []condition) (string, []any) {
+	all := append(append([]condition{}, h.scope...), conditions...)
+	if h.base.sql != "" {
+		all = append(all, h.base)
+	}
+	if len(all) == 0 {
+		return "", nil
+	}
+
+	parts := make([]string, len(all))
+	var args []any
+	for i, cond := range all {
+		parts[i] = "(" + cond.sql + ")"
+		args = append(args, cond.args...)
+	}
+	return " WHERE " + strings.Join(parts, " AND "), args
+}
+
+// setOperand sets a filter operator to a value: scalar operators directly, list operators
+// to a single value, ranges to the value as min and max. Operators without a value, like
+// is_null, are set to their empty message.
+func setOperand(filter protoreflect.Message, op protoreflect.FieldDescriptor, value protoreflect.Value) {
+	if op.Kind() != protoreflect.MessageKind {
+		filter.Set(op, value)
+		return
+	}
+
+	operand := filter.Mutable(op).Message()
+	fields := operand.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		switch {
+		case field.IsList():
+			operand.Mutable(field).List().Append(value)
+		case field.Kind() == protoreflect.MessageKind:
+			wrapper := operand.Mutable(field).Message()
+			wrapper.Set(field.Message().Fields().ByName("value"), value)
+		default:
+			operand.Set(field, value)
+		}
+	}
+}
+
+// operandField returns the field whose kind an operator's value has, or nil for
+// operators without a value
+func operandField(op protoreflect.FieldDescriptor) protoreflect.FieldDescriptor {
+	if op.Kind() != protoreflect.MessageKind {
+		return op
+	}
+	fields := op.Message().Fields()
+	if fields.Len() == 0 {
+		return nil
+	}
+	return valueField(fields.Get(0))
+}
+
+// valueField returns the value field of wrapper message fields, or the field itself
+func valueField(field protoreflect.FieldDescriptor) protoreflect.FieldDescriptor {
+	if field.Kind() == protoreflect.MessageKind && !field.IsList() && !field.IsMap() {
+		if value := field.Message().Fields().ByName("value"); value != nil {
+			return value
+		}
+	}
+	return field
+}
+
+func requestField(t *testing.T, msg protoreflect.Message, name string) protoreflect.FieldDescriptor {
+	t.Helper()
+
+	field := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		t.Fatalf("%s has no field %s", msg.Descriptor().FullName(), name)
+	}
+	return field
+}
+
+func firstField(desc protoreflect.MessageDescriptor, match func(protoreflect.FieldDescriptor) bool) protoreflect.FieldDescriptor {
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if match(fields.Get(i)) {
+			return fields.Get(i)
+		}
+	}
+	return nil
+}
+
+// parseValue parses text read through direct SQL into a value of a field, and the Go value
+// binding it as a query argument
+func parseValue(field protoreflect.FieldDescriptor, text string) (protoreflect.Value, any, error) {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(text), text, nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(text)), text, nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(text)
+		return protoreflect.ValueOfBool(v), v, err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(text, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), int32(v), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(text, 10, 64)
+		return protoreflect.ValueOfInt64(v), v, err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(text, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), uint32(v), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(text, 10, 64)
+		return protoreflect.ValueOfUint64(v), v, err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(text, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), float32(v), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(text, 64)
+		return protoreflect.ValueOfFloat64(v), v, err
+	default:
+		return protoreflect.Value{}, nil, fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+}
+
+// formatFields returns a field of each row as text, the way ClickHouse's toString renders
+// its SQL value. Unset wrapper fields are nullValue.
+func formatFields(rows []protoreflect.Message, name string) []string {
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		field := row.Descriptor().Fields().ByName(protoreflect.Name(name))
+		value := valueField(field)
+		switch {
+		case value == field:
+			values = append(values, formatValue(row.Get(field), field))
+		case !row.Has(field):
+			values = append(values, nullValue)
+		default:
+			values = append(values, formatValue(row.Get(field).Message().Get(value), value))
+		}
+	}
+	return values
+}
+
+func formatValue(value protoreflect.Value, field protoreflect.FieldDescriptor) string {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(value.Bool())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return strconv.FormatInt(value.Int(), 10)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(value.Uint(), 10)
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 32)
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 64)
+	case protoreflect.BytesKind:
+		return string(value.Bytes())
+	default:
+		return value.String()
+	}
+}
+
+func isFloat(field protoreflect.FieldDescriptor) bool {
+	return field.Kind() == protoreflect.FloatKind || field.Kind() == protoreflect.DoubleKind
+}
+
+// compareValues reports the first difference between values returned by the service and
+// values of the direct query
+func compareValues(t *testing.T, what string, got, want []string) {
+	t.Helper()
+
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if got[i] != want[i] {
+			t.Errorf("%s differ at row %d: service returned %q, direct SQL %q", what, i, got[i], want[i])
+			return
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("service returned %d %s, direct SQL %d", len(got), what, len(want))
+	}
+}
+`
@@ -0,0 +1,107 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GenerateConformance(t *testing.T) {
+	tables := []*clickhouse.Table{
+		{
+			Name:       "blocks",
+			SortingKey: []string{"slot_start_date_time"},
+			Columns: []clickhouse.Column{
+				{Name: "slot_start_date_time", Type: "DateTime", BaseType: "DateTime", Position: 1},
+				{Name: "tenant_id", Type: "String", BaseType: "String", Position: 2},
+				{Name: "proposer", Type: "String", BaseType: "String", Position: 3},
+			},
+		},
+		{
+			Name:       "by_expression",
+			SortingKey: []string{"toDate(slot)"},
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1},
+			},
+		},
+	}
+
+	t.Run("Suites", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cfg := config.NewConfig()
+		cfg.OutputDir = tmpDir
+		cfg.Package = "test.v1"
+		cfg.GoPackage = "example.com/gen/testv1"
+		cfg.Tenant = config.TenantConfig{Column: "tenant_id", ExemptTables: []string{"by_expression"}}
+		cfg.Columns = map[string]map[string]config.ColumnConfig{"blocks": {"proposer": {Mask: config.MaskHash}}}
+		cfg.Conformance.Enabled = true
+
+		require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateConformance(tables))
+
+		content, err := os.ReadFile(filepath.Join(tmpDir, defaultConformanceDir, conformanceFile))
+		require.NoError(t, err)
+		suite := string(content)
+		assert.Contains(t, suite, "pb \"example.com/gen/testv1\"")
+		assert.Contains(t, suite, "service:      \"test.v1.BlocksService\",")
+		assert.Contains(t, suite, "key:          column{field: \"slot_start_date_time\", name: \"slot_start_date_time\", column: \"`slot_start_date_time`\", value: \"toUnixTimestamp(`slot_start_date_time`)\"},")
+		assert.Contains(t, suite, "tenantColumn: \"`tenant_id`\",\n\t\ttenantField:  \"tenant\",")
+		assert.NotContains(t, suite, "proposer", "masked columns differ from the table by design")
+		assert.NotContains(t, suite, "ByExpression", "the key filter needs a key column")
+	})
+
+	t.Run("Needs go_package", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cfg := config.NewConfig()
+		cfg.OutputDir = tmpDir
+		cfg.Conformance.Enabled = true
+
+		require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateConformance(tables))
+		assert.NoDirExists(t, filepath.Join(tmpDir, defaultConformanceDir))
+	})
+}
+
+func TestGenerator_ConformanceColumns(t *testing.T) {
+	tests := []struct {
+		column   clickhouse.Column
+		expected string
+	}{
+		{clickhouse.NewColumn("block_number", "UInt64", 1), "{field: \"block_number\", name: \"block_number\", column: \"`block_number`\", value: \"`block_number`\"},"},
+		{clickhouse.NewColumn("to", "Nullable(String)", 2), "{field: \"to\", name: \"to\", column: \"`to`\", value: \"`to`\"},"},
+		{clickhouse.NewColumn("topics", "Array(String)", 3), "{field: \"topics\", name: \"topics\", column: \"`topics`\", value: \"`topics`\"},"},
+		{clickhouse.NewColumn("labels", "Map(String, UInt64)", 4), "{field: \"labels\", name: \"labels\", column: \"`labels`\", value: \"`labels`\"},"},
+		{clickhouse.NewColumn("day", "Date", 5), "{field: \"day\", name: \"day\", column: \"`day`\", value: \"toString(`day`)\"},"},
+		{clickhouse.NewColumn("seen_at", "DateTime", 6), "{field: \"seen_at\", name: \"seen_at\", column: \"`seen_at`\", value: \"toUnixTimestamp(`seen_at`)\"},"},
+		// Masked columns differ from the table by design
+		{clickhouse.NewColumn("memo", "String", 7), ""},
+	}
+
+	columns := make([]clickhouse.Column, len(tests))
+	for i, tt := range tests {
+		columns[i] = tt.column
+	}
+	cfg := config.NewConfig()
+	cfg.Package = "chain.v1"
+	cfg.GoPackage = "example.com/gen/chainv1"
+	cfg.Columns = map[string]map[string]config.ColumnConfig{"transfers": {"memo": {Mask: config.MaskHash}}}
+	cfg.Conformance.Enabled = true
+	files := generateColumnTypes(t, cfg, &clickhouse.Table{Name: "transfers", Database: "default", Engine: "MergeTree", SortingKey: []string{"block_number"}}, columns)
+	suite := files[defaultConformanceDir+"/"+conformanceFile]
+	assert.Contains(t, suite, "func TestTransfersConformance(t *testing.T) {")
+	assert.Contains(t, suite, "key:          column{field: \"block_number\", name: \"block_number\", column: \"`block_number`\", value: \"`block_number`\"},")
+
+	for _, tt := range tests {
+		t.Run(tt.column.Name, func(t *testing.T) {
+			if tt.expected == "" {
+				assert.NotContains(t, suite, tt.column.Name)
+				return
+			}
+			assert.Contains(t, suite, "\t\t\t"+tt.expected+"\n")
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package protogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// defaultWhereFor returns the default_where entry of a table; its Where is empty when the
+// table's queries aren't filtered
+func (g *Generator) defaultWhereFor(table *clickhouse.Table) config.DefaultWhereConfig {
+	return g.config.DefaultWhereFor(table.Name)
+}
+
+// writeSkipDefaultWhereField writes the skip_default_where field of a request, when the
+// table's default_where is overridable
+func (g *Generator) writeSkipDefaultWhereField(sb *strings.Builder, table *clickhouse.Table, fieldNumber int) {
+	where := g.defaultWhereFor(table)
+	if where.Where == "" || !where.Overridable {
+		return
+	}
+
+	behavior := ""
+	if g.shouldGenerateAPI(table.Name) {
+		behavior = " [(google.api.field_behavior) = OPTIONAL]"
+	}
+	fmt.Fprintf(sb, "  // Reads the rows outside the table's default filter too. Unset reads only those matching\n")
+	fmt.Fprintf(sb, "  // %s.\n", where.Where)
+	fmt.Fprintf(sb, "  bool skip_default_where = %d%s;\n", fieldNumber, behavior)
+}
+
+// writeDefaultWhereCondition writes the default_where condition of a table into a
+// Build*Query function body. Requests can skip it when override is set and the table's
+// entry is overridable.
+func (g *Generator) writeDefaultWhereCondition(sb *strings.Builder, table *clickhouse.Table, override bool) {
+	where := g.defaultWhereFor(table)
+	if where.Where == "" {
+		return
+	}
+
+	fmt.Fprintf(sb, "\t// Filter by the table's default condition\n")
+	if override && where.Overridable {
+		fmt.Fprintf(sb, "\tif !req.SkipDefaultWhere {\n")
+		fmt.Fprintf(sb, "\t\tqb.AddRawCondition(%q)\n", where.Where)
+		fmt.Fprintf(sb, "\t}\n\n")
+		return
+	}
+	fmt.Fprintf(sb, "\tqb.AddRawCondition(%q)\n\n", where.Where)
+}
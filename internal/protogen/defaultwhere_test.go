@@ -0,0 +1,66 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_DefaultWhere(t *testing.T) {
+	table := func(name string) *clickhouse.Table {
+		return &clickhouse.Table{
+			Name:     name,
+			Database: "default",
+			Engine:   "MergeTree",
+			Columns: []clickhouse.Column{
+				clickhouse.NewColumn("slot_start_date_time", "DateTime", 1),
+				clickhouse.NewColumn("slot", "UInt32", 2),
+				clickhouse.NewColumn("meta_network_name", "String", 3),
+			},
+			SortingKey: []string{"slot_start_date_time", "slot"},
+		}
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.EnableAPI = true
+	cfg.UnsafeMutations = true
+	buckets := true
+	cfg.Policies = []config.PolicyConfig{{Match: "*", Buckets: &buckets}}
+	cfg.DefaultWhere = map[string]config.DefaultWhereConfig{
+		"*":         {Where: "meta_network_name = 'mainnet'"},
+		"fct_block": {Where: "meta_network_name IN ('mainnet', 'holesky')", Overridable: true},
+	}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table("fct_block"), table("fct_attestation")}))
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	// Overridable: List, Get and ListBuckets requests can skip the condition, mutations can't
+	proto := read("fct_block.proto")
+	assert.Contains(t, proto, "  // meta_network_name IN ('mainnet', 'holesky').\n  bool skip_default_where = 11 [(google.api.field_behavior) = OPTIONAL];\n")
+	assert.Contains(t, proto, "  bool skip_default_where = 3 [(google.api.field_behavior) = OPTIONAL];\n")
+	assert.Contains(t, proto, "  bool skip_default_where = 9 [(google.api.field_behavior) = OPTIONAL];\n")
+
+	sql := read("fct_block_sql.go")
+	assert.Equal(t, 3, strings.Count(sql, "\tif !req.SkipDefaultWhere {\n\t\tqb.AddRawCondition(\"meta_network_name IN ('mainnet', 'holesky')\")\n\t}\n"))
+	assert.Equal(t, 2, strings.Count(sql, "\n\tqb.AddRawCondition(\"meta_network_name IN ('mainnet', 'holesky')\")\n"))
+
+	// The "*" entry filters every other table, without a field to skip it
+	assert.NotContains(t, read("fct_attestation.proto"), "skip_default_where")
+	sql = read("fct_attestation_sql.go")
+	assert.NotContains(t, sql, "SkipDefaultWhere")
+	assert.Equal(t, 5, strings.Count(sql, "\tqb.AddRawCondition(\"meta_network_name = 'mainnet'\")\n"))
+
+	assert.Contains(t, read("common.go"), "qb.conditions = append(qb.conditions, \"(\"+condition+\")\")")
+}
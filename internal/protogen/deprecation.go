@@ -0,0 +1,71 @@
+package protogen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// deprecatedOption is the standard proto option marking a field, message or RPC deprecated
+const deprecatedOption = "deprecated = true"
+
+// compileDeprecationPattern compiles the configured deprecation marker. An empty or invalid
+// pattern disables deprecation; Config.Validate reports invalid patterns.
+func compileDeprecationPattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// deprecationNote reports whether a comment carries the deprecation marker and returns the
+// text after it, e.g. "use block_root instead" for "DEPRECATED: use block_root instead"
+func (g *Generator) deprecationNote(comment string) (string, bool) {
+	if g.deprecation == nil {
+		return "", false
+	}
+	loc := g.deprecation.FindStringIndex(comment)
+	if loc == nil {
+		return "", false
+	}
+	note := strings.TrimSpace(comment[loc[1]:])
+	if idx := strings.IndexByte(note, '\n'); idx >= 0 {
+		note = strings.TrimSpace(note[:idx])
+	}
+	return note, true
+}
+
+// applyDeprecation marks the message field of a deprecated column. The note stays in the
+// field comment, which is the column comment.
+func (g *Generator) applyDeprecation(field *ProtoField, column *clickhouse.Column) {
+	if _, ok := g.deprecationNote(column.Comment); ok {
+		field.Options = append(field.Options, deprecatedOption)
+	}
+}
+
+// writeMessageDeprecation marks the message of a deprecated table
+func (g *Generator) writeMessageDeprecation(sb *strings.Builder, table *clickhouse.Table) {
+	if _, ok := g.deprecationNote(table.Comment); ok {
+		fmt.Fprintf(sb, "  option %s;\n", deprecatedOption)
+	}
+}
+
+// rpcDeprecation returns the comment line and option line for the RPCs of a deprecated
+// table, both empty when the table isn't deprecated. The comment is written even without
+// include_comments so that clients see why the RPC is deprecated.
+func (g *Generator) rpcDeprecation(table *clickhouse.Table) (comment, option string) {
+	note, ok := g.deprecationNote(table.Comment)
+	if !ok {
+		return "", ""
+	}
+	if note == "" {
+		note = fmt.Sprintf("%s is deprecated.", table.Name)
+	}
+	return fmt.Sprintf("  // Deprecated: %s\n", note), fmt.Sprintf("    option %s;\n", deprecatedOption)
+}
@@ -0,0 +1,148 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_DeprecationNote(t *testing.T) {
+	tests := []struct {
+		name         string
+		pattern      string
+		comment      string
+		expectedNote string
+		deprecated   bool
+	}{
+		{
+			name:         "Default marker",
+			pattern:      config.DefaultDeprecationPattern,
+			comment:      "Block root. DEPRECATED: use block_hash instead",
+			expectedNote: "use block_hash instead",
+			deprecated:   true,
+		},
+		{
+			name:       "Marker without note",
+			pattern:    config.DefaultDeprecationPattern,
+			comment:    "DEPRECATED:",
+			deprecated: true,
+		},
+		{
+			name:    "Not deprecated",
+			pattern: config.DefaultDeprecationPattern,
+			comment: "Block root",
+		},
+		{
+			name:         "Custom pattern",
+			pattern:      `(?i)\[deprecated\]`,
+			comment:      "[Deprecated] removed in v3",
+			expectedNote: "removed in v3",
+			deprecated:   true,
+		},
+		{
+			name:    "Disabled",
+			comment: "DEPRECATED: use block_hash instead",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.DeprecationPattern = tt.pattern
+			note, deprecated := NewGenerator(cfg, logrus.New()).deprecationNote(tt.comment)
+			assert.Equal(t, tt.deprecated, deprecated)
+			assert.Equal(t, tt.expectedNote, note)
+		})
+	}
+}
+
+func TestGenerator_DeprecatedRPCsWithAPI(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.EnableAPI = true
+	gen := NewGenerator(cfg, logrus.New())
+
+	table := &clickhouse.Table{
+		Name:       "blocks_v1",
+		Comment:    "Blocks DEPRECATED: use blocks_v2",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+
+	var sb strings.Builder
+	gen.writeServiceDefinitions(&sb, table)
+	output := sb.String()
+
+	assert.Equal(t, 2, strings.Count(output, "  // Deprecated: use blocks_v2\n"))
+	assert.Contains(t, output, "  rpc List(ListBlocksV1Request) returns (ListBlocksV1Response) {\n    option deprecated = true;\n    option (google.api.http) = {\n")
+	assert.Contains(t, output, "  rpc Get(GetBlocksV1Request) returns (GetBlocksV1Response) {\n    option deprecated = true;\n    option (google.api.http) = {\n")
+}
+
+func TestGenerator_DeprecatedProto(t *testing.T) {
+	tests := []struct {
+		name          string
+		tableComment  string
+		columnComment string
+		contains      []string
+		absent        []string
+	}{
+		{
+			name:         "Deprecated table",
+			tableComment: "Beacon blocks. DEPRECATED: use blocks_v2",
+			contains: []string{
+				"message BlocksV1 {\n  option deprecated = true;\n",
+				"  // Deprecated: use blocks_v2\n  rpc List(ListBlocksV1Request) returns (ListBlocksV1Response) {\n    option deprecated = true;\n  }\n",
+				"  // Deprecated: use blocks_v2\n  rpc Get(GetBlocksV1Request) returns (GetBlocksV1Response) {\n    option deprecated = true;\n  }\n",
+			},
+			absent: []string{"string block_root = 12 [deprecated = true];"},
+		},
+		{
+			name:          "Deprecated column",
+			columnComment: "Block root. DEPRECATED: use block_hash",
+			contains: []string{
+				"  // Block root. DEPRECATED: use block_hash\n  string block_root = 12 [deprecated = true];",
+				"  rpc List(ListBlocksV1Request) returns (ListBlocksV1Response);",
+			},
+			absent: []string{"option deprecated = true;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &clickhouse.Table{
+				Name:     "blocks_v1",
+				Database: "default",
+				Engine:   "MergeTree",
+				Comment:  tt.tableComment,
+				Columns: []clickhouse.Column{
+					clickhouse.NewColumn("slot", "UInt32", 1),
+					clickhouse.NewColumn("block_root", "String", 2),
+				},
+				SortingKey: []string{"slot"},
+			}
+			table.Columns[1].Comment = tt.columnComment
+
+			cfg := config.NewConfig()
+			cfg.OutputDir = t.TempDir()
+			cfg.IncludeComments = true
+			log := logrus.New()
+			log.SetLevel(logrus.ErrorLevel)
+			require.NoError(t, NewGenerator(cfg, log).Generate([]*clickhouse.Table{table}))
+
+			content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "blocks_v1.proto"))
+			require.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, string(content), want)
+			}
+			for _, unwanted := range tt.absent {
+				assert.NotContains(t, string(content), unwanted)
+			}
+		})
+	}
+}
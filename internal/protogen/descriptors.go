@@ -0,0 +1,103 @@
+package protogen
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// Registers google/api/annotations.proto and google/api/field_behavior.proto, which
+	// the protos of tables with an API import
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+)
+
+// GenerateDescriptorSet compiles the proto files written by this run and writes them, with
+// every file they import, as a binary FileDescriptorSet to descriptor_set_out. Comments
+// are kept as source info, so reflection-based tools can show them.
+func (g *Generator) GenerateDescriptorSet() error {
+	files := make([]string, 0, len(g.protoSources))
+	for name := range g.protoSources {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+
+	// Files this run didn't write, like clickhouse/annotations.proto when emit leaves it
+	// out, are read from the output directory. Google's protos come from the Go registry.
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(protocompile.CompositeResolver{
+			&protocompile.SourceResolver{Accessor: protocompile.SourceAccessorFromMap(g.protoSources)},
+			&protocompile.SourceResolver{ImportPaths: []string{g.config.OutputDir}},
+			protocompile.ResolverFunc(func(path string) (protocompile.SearchResult, error) {
+				desc, err := protoregistry.GlobalFiles.FindFileByPath(path)
+				if err != nil {
+					return protocompile.SearchResult{}, err
+				}
+				return protocompile.SearchResult{Desc: desc}, nil
+			}),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+
+	compiled, err := compiler.Compile(context.Background(), files...)
+	if err != nil {
+		return fmt.Errorf("failed to compile proto files: %w", err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	for _, file := range compiled {
+		appendFileDescriptors(set, file, seen)
+	}
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("failed to encode descriptor set: %w", err)
+	}
+
+	filename := g.config.DescriptorSetOut
+	if dir := filepath.Dir(filename); dir != "." {
+		if err := g.mkdirAll(dir); err != nil {
+			return fmt.Errorf("failed to create descriptor set directory: %w", err)
+		}
+	}
+	return g.writeFile(filename, string(data))
+}
+
+// appendFileDescriptors appends a file to the set after the files it imports, as protoc's
+// --include_imports orders them
+func appendFileDescriptors(set *descriptorpb.FileDescriptorSet, file protoreflect.FileDescriptor, seen map[string]bool) {
+	if seen[file.Path()] {
+		return
+	}
+	seen[file.Path()] = true
+
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		appendFileDescriptors(set, imports.Get(i).FileDescriptor, seen)
+	}
+	set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+}
+
+// recordProtoSource keeps the content of a proto file written by this run for the descriptor
+// set, keyed by its import path
+func (g *Generator) recordProtoSource(filename, content string) {
+	if g.config.DescriptorSetOut == "" || filepath.Ext(filename) != ".proto" {
+		return
+	}
+	rel, err := filepath.Rel(g.config.OutputDir, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return
+	}
+	if g.protoSources == nil {
+		g.protoSources = make(map[string]string)
+	}
+	g.protoSources[filepath.ToSlash(rel)] = content
+}
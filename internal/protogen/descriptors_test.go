@@ -0,0 +1,99 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGenerator_DescriptorSet(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("block_root", "Nullable(String)", 2),
+		},
+		SortingKey: []string{"slot"},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Package = "beacon.v1"
+	cfg.EnableAPI = true
+	cfg.DescriptorSetOut = filepath.Join(cfg.OutputDir, "descriptors", "schema.binpb")
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	data, err := os.ReadFile(cfg.DescriptorSetOut)
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, set))
+
+	// Imports come before the files importing them
+	index := make(map[string]int, len(set.GetFile()))
+	for i, file := range set.GetFile() {
+		index[file.GetName()] = i
+	}
+	for _, name := range []string{"common.proto", "clickhouse/annotations.proto", "google/api/annotations.proto", "google/protobuf/wrappers.proto"} {
+		require.Contains(t, index, name)
+		assert.Less(t, index[name], index["fct_block.proto"], name)
+	}
+
+	// The set is self-contained
+	files, err := protodesc.NewFiles(set)
+	require.NoError(t, err)
+	desc, err := files.FindDescriptorByName("beacon.v1.FctBlockService")
+	require.NoError(t, err)
+	assert.Equal(t, "fct_block.proto", desc.ParentFile().Path())
+
+	// Comments are kept as source info
+	list := desc.(protoreflect.ServiceDescriptor).Methods().ByName("List")
+	assert.Equal(t, " List records | Retrieve paginated results with optional filtering\n",
+		list.ParentFile().SourceLocations().ByDescriptor(list).LeadingComments)
+}
+
+func TestGenerator_DescriptorSetImportPrefix(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Package = "clickhouse.v1"
+	cfg.ImportPrefix = "clickhouse/v1"
+	cfg.DescriptorSetOut = filepath.Join(cfg.OutputDir, "schema.binpb")
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	// The protos move below the prefix and import each other by their prefixed paths
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "clickhouse", "v1", "fct_block.proto"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "import \"clickhouse/v1/common.proto\";\n")
+	assert.Contains(t, string(content), "import \"clickhouse/v1/clickhouse/annotations.proto\";\n")
+	assert.FileExists(t, filepath.Join(cfg.OutputDir, "clickhouse", "v1", "common.proto"))
+	assert.FileExists(t, filepath.Join(cfg.OutputDir, "clickhouse", "v1", "clickhouse", "annotations.proto"))
+	assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "common.proto"))
+
+	data, err := os.ReadFile(cfg.DescriptorSetOut)
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, set))
+	files, err := protodesc.NewFiles(set)
+	require.NoError(t, err)
+	_, err = files.FindFileByPath("clickhouse/v1/fct_block.proto")
+	assert.NoError(t, err)
+}
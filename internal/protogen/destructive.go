@@ -0,0 +1,116 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// ErrDestructiveChange is returned when a run would rewrite more of the existing output than
+// max_change_percent allows, or remove services from it
+var ErrDestructiveChange = errors.New("generation would make destructive changes")
+
+const (
+	// defaultMaxChangePercent is the share of existing files a run may change when
+	// max_change_percent is unset
+	defaultMaxChangePercent = 50
+	// minGuardedFiles is how many existing files the output needs before the share of changed
+	// files is checked, so small projects can change a table without --force
+	minGuardedFiles = 10
+)
+
+// servicePattern matches the declaration of a service in a proto file
+//
+//nolint:gochecknoglobals // Compiled once
+var servicePattern = regexp.MustCompile(`(?m)^service (\w+) \{`)
+
+// CheckDestructive generates the tables in memory and compares the result with the output
+// directory. It fails when more than max_change_percent of the existing files the run writes
+// would change, or when services declared in the existing protos would disappear, as both
+// suggest a run against the wrong database. Nothing is written to the output directory.
+func CheckDestructive(cfg *config.Config, tables []*clickhouse.Table) error {
+	files, err := generateInMemory(cfg, tables)
+	if err != nil {
+		return err
+	}
+
+	existing, changed := 0, 0
+	for _, filename := range slices.Sorted(maps.Keys(files)) {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			continue
+		}
+		existing++
+		if string(data) != files[filename] {
+			changed++
+		}
+	}
+
+	var problems []string
+	limit := cfg.MaxChangePercent
+	if limit == 0 {
+		limit = defaultMaxChangePercent
+	}
+	if existing >= minGuardedFiles && changed*100 > existing*limit {
+		problems = append(problems, fmt.Sprintf("%d of %d existing files would change (max_change_percent is %d)", changed, existing, limit))
+	}
+
+	removed, err := removedServices(filepath.Join(cfg.OutputDir, filepath.FromSlash(cfg.ImportPrefix)), files)
+	if err != nil {
+		return err
+	}
+	if len(removed) > 0 {
+		problems = append(problems, "services would be removed: "+strings.Join(removed, ", "))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrDestructiveChange, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// removedServices returns the sorted names of the services declared in the protos of dir
+// that the run rewrites without declaring them in any proto. Protos the run doesn't write
+// keep their services.
+func removedServices(dir string, files map[string]string) ([]string, error) {
+	existing, err := filepath.Glob(filepath.Join(dir, "*.proto"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing protos: %w", err)
+	}
+
+	declared := make(map[string]bool)
+	for filename, content := range files {
+		if filepath.Ext(filename) != ".proto" {
+			continue
+		}
+		for _, match := range servicePattern.FindAllStringSubmatch(content, -1) {
+			declared[match[1]] = true
+		}
+	}
+
+	var removed []string
+	for _, filename := range existing {
+		if _, ok := files[filename]; !ok {
+			continue
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read existing proto %s: %w", filename, err)
+		}
+		for _, match := range servicePattern.FindAllStringSubmatch(string(data), -1) {
+			if !declared[match[1]] {
+				removed = append(removed, match[1])
+			}
+		}
+	}
+	slices.Sort(removed)
+	return removed, nil
+}
@@ -0,0 +1,113 @@
+package protogen
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDestructive(t *testing.T) {
+	// generated writes 8 tables to a fresh output directory, 19 files in all
+	generated := func(t *testing.T) *config.Config {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate(concurrencyTestTables(8)))
+		return cfg
+	}
+	// retyped changes the value column of the first n tables
+	retyped := func(n int) []*clickhouse.Table {
+		tables := concurrencyTestTables(8)
+		for _, table := range tables[:n] {
+			table.Columns[1] = clickhouse.NewColumn("value", "String", 2)
+		}
+		return tables
+	}
+
+	t.Run("first run", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		assert.NoError(t, CheckDestructive(cfg, concurrencyTestTables(8)))
+	})
+
+	t.Run("unchanged schema", func(t *testing.T) {
+		assert.NoError(t, CheckDestructive(generated(t), concurrencyTestTables(8)))
+	})
+
+	t.Run("some tables changed", func(t *testing.T) {
+		assert.NoError(t, CheckDestructive(generated(t), retyped(2)))
+	})
+
+	t.Run("most files changed", func(t *testing.T) {
+		cfg := generated(t)
+		err := CheckDestructive(cfg, retyped(8))
+		require.ErrorIs(t, err, ErrDestructiveChange)
+		assert.Contains(t, err.Error(), "16 of 22 existing files would change (max_change_percent is 50)")
+
+		cfg.MaxChangePercent = 100
+		assert.NoError(t, CheckDestructive(cfg, retyped(8)))
+	})
+
+	t.Run("services removed", func(t *testing.T) {
+		cfg := generated(t)
+		cfg.MaxChangePercent = 100
+		tables := concurrencyTestTables(8)
+		cfg.Emit = []string{config.EmitMessages, config.EmitSQL}
+		err := CheckDestructive(cfg, tables)
+		require.ErrorIs(t, err, ErrDestructiveChange)
+		assert.Contains(t, err.Error(), "services would be removed: FctTable00Service, FctTable01Service,")
+
+		// Protos the run leaves alone keep their services
+		cfg.Emit = nil
+		assert.NoError(t, CheckDestructive(cfg, tables[:3]))
+	})
+	t.Run("output left alone", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.GoPackage = "example.com/gen/testv1"
+		cfg.Middleware.Tracing = true
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate(concurrencyTestTables(8)))
+		before := outputFiles(t, cfg.OutputDir)
+		require.Contains(t, before, filepath.Join("middleware", "tracing.go"))
+
+		// A rerun would remove the tracing hooks and create the server and client packages
+		cfg.Middleware.Tracing = false
+		cfg.Middleware.Enabled = true
+		cfg.Server.Enabled = true
+		cfg.Client.Enabled = true
+		require.ErrorIs(t, CheckDestructive(cfg, retyped(8)), ErrDestructiveChange)
+		assert.Equal(t, before, outputFiles(t, cfg.OutputDir))
+	})
+}
+
+// outputFiles returns the content of the files and directories under dir, keyed by path
+// relative to it
+func outputFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			files[rel] = ""
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		files[rel] = string(data)
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
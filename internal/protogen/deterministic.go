@@ -0,0 +1,71 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNondeterministic is returned when two generation runs over the same tables differ
+var ErrNondeterministic = errors.New("generated output differs between runs")
+
+// CheckDeterministic generates the tables twice in memory and fails with the files whose
+// content differs between the runs, or that only one of them wrote. Nothing is written to
+// the output directory.
+func CheckDeterministic(cfg *config.Config, tables []*clickhouse.Table) error {
+	first, err := generateInMemory(cfg, tables)
+	if err != nil {
+		return err
+	}
+	second, err := generateInMemory(cfg, tables)
+	if err != nil {
+		return err
+	}
+
+	if differing := differingFiles(first, second); len(differing) > 0 {
+		return fmt.Errorf("%w: %s", ErrNondeterministic, strings.Join(differing, ", "))
+	}
+	return nil
+}
+
+// differingFiles returns the sorted names of the files whose content differs between two
+// runs, including files only one of them wrote
+func differingFiles(first, second map[string]string) []string {
+	var differing []string
+	for filename, content := range first {
+		if other, ok := second[filename]; !ok || other != content {
+			differing = append(differing, filename)
+		}
+	}
+	for filename := range second {
+		if _, ok := first[filename]; !ok {
+			differing = append(differing, filename)
+		}
+	}
+	sort.Strings(differing)
+	return differing
+}
+
+// generateInMemory runs a generator over the tables and returns the files it writes, keyed
+// by filename. Its logs are discarded, as the real run logs the same.
+func generateInMemory(cfg *config.Config, tables []*clickhouse.Table) (map[string]string, error) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	files := make(map[string]string)
+	g := NewGenerator(cfg, log)
+	g.SetOutput(func(filename, content string) error {
+		files[filename] = content
+		return nil
+	})
+	if err := g.Generate(tables); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,37 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDeterministic(t *testing.T) {
+	cases, err := filepath.Glob(filepath.Join("testdata", "*", "schema.json"))
+	require.NoError(t, err)
+
+	for _, schema := range cases {
+		dir := filepath.Dir(schema)
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			cfg, tables := loadGoldenCase(t, dir)
+			require.NoError(t, CheckDeterministic(cfg, tables))
+
+			entries, err := os.ReadDir(cfg.OutputDir)
+			require.NoError(t, err)
+			for _, entry := range entries {
+				assert.True(t, entry.IsDir(), "%s was written to the output directory", entry.Name())
+			}
+		})
+	}
+}
+
+func TestDifferingFiles(t *testing.T) {
+	first := map[string]string{"a.proto": "a", "b_sql.go": "b", "c.proto": "c"}
+	second := map[string]string{"a.proto": "a", "b_sql.go": "B", "d.proto": "d"}
+
+	assert.Equal(t, []string{"b_sql.go", "c.proto", "d.proto"}, differingFiles(first, second))
+	assert.Empty(t, differingFiles(first, first))
+}
@@ -0,0 +1,206 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// Comment directive errors
+var (
+	ErrInvalidDirective = errors.New("invalid comment directive")
+	ErrColumnType       = errors.New("column type override doesn't match the column")
+)
+
+// directivePattern matches @proto(...) and @api(...) directives in a column comment
+//
+//nolint:gochecknoglobals // Compiled once
+var directivePattern = regexp.MustCompile(`\s*@(proto|api)\(([^)]*)\)`)
+
+// ApplyCommentDirectives reads generation directives from column comments and returns a
+// configuration with them added as column overrides, along with copies of the tables
+// whose comments no longer contain them. Supported directives:
+//
+//	@api(hidden)             same as mask: omit
+//	@api(mask=hash|null|omit)
+//	@proto(type=string|bytes|bool)
+//	@proto(unit=wei)         same as unit: wei
+//	@proto(encoding=zigzag)  same as encoding: zigzag
+//
+// Table-specific entries under columns in the configuration take precedence over
+// directives. Type overrides to string and bool are applied through the bigint_to_string
+// and bool_columns conversions.
+func ApplyCommentDirectives(cfg *config.Config, tables []*clickhouse.Table) (*config.Config, []*clickhouse.Table, error) {
+	effective := *cfg
+	effective.Columns = make(map[string]map[string]config.ColumnConfig, len(cfg.Columns))
+	for table, columns := range cfg.Columns {
+		effective.Columns[table] = maps.Clone(columns)
+	}
+
+	result := make([]*clickhouse.Table, 0, len(tables))
+	for _, table := range tables {
+		copied, err := applyTableDirectives(&effective, table)
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, copied)
+	}
+
+	effective.Conversion = typeConversions(effective.Conversion, effective.Columns)
+	return &effective, result, nil
+}
+
+// applyTableDirectives records the directives of a table's columns in cfg and returns the
+// table with them stripped from the comments, or the table itself when it has none
+func applyTableDirectives(cfg *config.Config, table *clickhouse.Table) (*clickhouse.Table, error) {
+	var columns []clickhouse.Column
+
+	for i, column := range table.Columns {
+		if !directivePattern.MatchString(column.Comment) {
+			continue
+		}
+
+		override, comment, err := parseDirectives(column.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", table.Name, column.Name, err)
+		}
+
+		if cfg.Columns[table.Name] == nil {
+			cfg.Columns[table.Name] = make(map[string]config.ColumnConfig)
+		}
+		existing := cfg.Columns[table.Name][column.Name]
+		if existing.Mask == "" {
+			existing.Mask = override.Mask
+		}
+		if existing.Type == "" {
+			existing.Type = override.Type
+		}
+		if existing.Unit == "" {
+			existing.Unit = override.Unit
+		}
+		if existing.Encoding == "" {
+			existing.Encoding = override.Encoding
+		}
+		cfg.Columns[table.Name][column.Name] = existing
+
+		if columns == nil {
+			columns = append([]clickhouse.Column{}, table.Columns...)
+		}
+		columns[i].Comment = comment
+	}
+
+	if columns == nil {
+		return table, nil
+	}
+	copied := *table
+	copied.Columns = columns
+	return &copied, nil
+}
+
+// parseDirectives returns the overrides set by the directives in a comment and the
+// comment without them
+func parseDirectives(comment string) (config.ColumnConfig, string, error) {
+	var override config.ColumnConfig
+
+	for _, match := range directivePattern.FindAllStringSubmatch(comment, -1) {
+		for _, arg := range strings.Split(match[2], ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(arg), "=")
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+			switch {
+			case match[1] == "api" && key == "hidden" && value == "":
+				override.Mask = config.MaskOmit
+			case match[1] == "api" && key == "mask" && (value == config.MaskHash || value == config.MaskNull || value == config.MaskOmit):
+				override.Mask = value
+			case match[1] == "proto" && key == "type" && (value == config.ColumnTypeString || value == config.ColumnTypeBytes || value == config.ColumnTypeBool):
+				override.Type = value
+			case match[1] == "proto" && key == "unit" && config.IsValidUnit(value):
+				override.Unit = value
+			case match[1] == "proto" && key == "encoding" && (value == config.EncodingVarint || value == config.EncodingZigzag || value == config.EncodingFixed):
+				override.Encoding = value
+			default:
+				return override, "", fmt.Errorf("%w: @%s(%s)", ErrInvalidDirective, match[1], match[2])
+			}
+		}
+	}
+
+	return override, strings.TrimSpace(directivePattern.ReplaceAllString(comment, "")), nil
+}
+
+// typeConversions returns conv with the columns overridden to type string added to the
+// bigint_to_string conversion, and those overridden to type bool to bool_columns, which
+// map them to fields and filters of the type
+func typeConversions(conv config.ConversionConfig, columns map[string]map[string]config.ColumnConfig) config.ConversionConfig {
+	conv.BigIntToString = maps.Clone(conv.BigIntToString)
+	conv.BigIntToStringFields = append([]string{}, conv.BigIntToStringFields...)
+	conv.BoolColumns = append([]string{}, conv.BoolColumns...)
+
+	for _, table := range slices.Sorted(maps.Keys(columns)) {
+		overrides := columns[table]
+		for _, column := range slices.Sorted(maps.Keys(overrides)) {
+			override := overrides[column]
+			if override.Type == config.ColumnTypeBool {
+				conv.BoolColumns = append(conv.BoolColumns, table+"."+column)
+				continue
+			}
+			if override.Type != config.ColumnTypeString {
+				continue
+			}
+			if table == "*" {
+				conv.BigIntToStringFields = append(conv.BigIntToStringFields, "*."+column)
+				continue
+			}
+			if conv.BigIntToString == nil {
+				conv.BigIntToString = make(map[string][]string)
+			}
+			conv.BigIntToString[table] = append(append([]string{}, conv.BigIntToString[table]...), column)
+		}
+	}
+	return conv
+}
+
+// validateColumnTypes checks that type overrides fit the ClickHouse types of their columns
+func (g *Generator) validateColumnTypes(tables []*clickhouse.Table) error {
+	for _, table := range tables {
+		for _, column := range table.Columns {
+			var ok bool
+			switch g.config.ColumnOverrides(table.Name, column.Name).Type {
+			case "":
+				continue
+			case config.ColumnTypeString:
+				ok = column.BaseType == typeInt64 || column.BaseType == typeUInt64
+			case config.ColumnTypeBytes:
+				ok = column.BaseType == chTypeString || column.BaseType == "FixedString"
+			case config.ColumnTypeBool:
+				ok = column.BaseType == clickhouseUInt8
+			}
+			if !ok {
+				return fmt.Errorf("%w: %s.%s is %s", ErrColumnType, table.Name, column.Name, column.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// applyTypeOverride changes the message field of a String/FixedString column to bytes
+// when configured. String overrides are applied by the type mapper.
+func (g *Generator) applyTypeOverride(field *ProtoField, column *clickhouse.Column, tableName string) {
+	if g.config.ColumnOverrides(tableName, column.Name).Type != config.ColumnTypeBytes {
+		return
+	}
+
+	switch {
+	case column.IsArray:
+		field.Type = "repeated " + protoBytes
+	case column.IsNullable:
+		field.Type = g.typeMapper.getWrapperType(protoBytes)
+	default:
+		field.Type = protoBytes
+	}
+}
@@ -0,0 +1,222 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		name            string
+		comment         string
+		expected        config.ColumnConfig
+		expectedComment string
+		expectErr       bool
+	}{
+		{
+			name:            "No directives",
+			comment:         "The user's email",
+			expectedComment: "The user's email",
+		},
+		{
+			name:            "Hidden",
+			comment:         "Internal scoring @api(hidden)",
+			expected:        config.ColumnConfig{Mask: config.MaskOmit},
+			expectedComment: "Internal scoring",
+		},
+		{
+			name:            "Several directives",
+			comment:         "@proto(type=bytes) Raw payload @api(mask=hash)",
+			expected:        config.ColumnConfig{Mask: config.MaskHash, Type: config.ColumnTypeBytes},
+			expectedComment: "Raw payload",
+		},
+		{
+			name:            "Bool",
+			comment:         "Whether the block was orphaned @proto(type=bool)",
+			expected:        config.ColumnConfig{Type: config.ColumnTypeBool},
+			expectedComment: "Whether the block was orphaned",
+		},
+		{
+			name:            "Unit",
+			comment:         "Base fee @proto(type=string, unit=wei)",
+			expected:        config.ColumnConfig{Type: config.ColumnTypeString, Unit: "wei"},
+			expectedComment: "Base fee",
+		},
+		{
+			name:            "Encoding",
+			comment:         "Balance change @proto(encoding=zigzag)",
+			expected:        config.ColumnConfig{Encoding: config.EncodingZigzag},
+			expectedComment: "Balance change",
+		},
+		{
+			name:      "Unknown argument",
+			comment:   "@api(secret)",
+			expectErr: true,
+		},
+		{
+			name:      "Unsupported type",
+			comment:   "@proto(type=int32)",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			override, comment, err := parseDirectives(tt.comment)
+			if tt.expectErr {
+				require.ErrorIs(t, err, ErrInvalidDirective)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, override)
+			assert.Equal(t, tt.expectedComment, comment)
+		})
+	}
+}
+
+func TestApplyCommentDirectives(t *testing.T) {
+	table := &clickhouse.Table{
+		Name: "users",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("id", "UInt64", 1),
+			clickhouse.NewColumn("balance", "UInt64", 2),
+			clickhouse.NewColumn("email", "String", 3),
+			clickhouse.NewColumn("avatar", "Nullable(String)", 4),
+		},
+		SortingKey: []string{"id"},
+	}
+	table.Columns[1].Comment = "Balance in wei @proto(type=string)"
+	table.Columns[2].Comment = "@api(hidden)"
+	table.Columns[3].Comment = "@proto(type=bytes) @api(mask=null)"
+
+	cfg := config.NewConfig()
+	cfg.Columns = map[string]map[string]config.ColumnConfig{
+		"users": {"avatar": {Mask: config.MaskHash}},
+	}
+
+	effective, tables, err := ApplyCommentDirectives(cfg, []*clickhouse.Table{table})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Balance in wei", tables[0].Columns[1].Comment)
+	assert.Equal(t, "Balance in wei @proto(type=string)", table.Columns[1].Comment, "the input table is not modified")
+	assert.Equal(t, config.MaskOmit, effective.ColumnOverrides("users", "email").Mask)
+	assert.Equal(t, config.MaskHash, effective.ColumnOverrides("users", "avatar").Mask, "configured overrides win")
+	assert.Equal(t, config.ColumnTypeBytes, effective.ColumnOverrides("users", "avatar").Type)
+	assert.True(t, effective.Conversion.ShouldConvertToString("users", "balance"))
+	assert.Equal(t, map[string]config.ColumnConfig{"avatar": {Mask: config.MaskHash}}, cfg.Columns["users"], "the input config is not modified")
+
+	gen := NewGenerator(effective, logrus.New())
+	require.NoError(t, gen.validateColumnTypes(tables))
+
+	fields := gen.MessageFields(tables[0])
+	require.Len(t, fields, 3)
+	assert.Equal(t, protoString, fields[1].Type)
+	assert.Equal(t, "google.protobuf.StringValue", fields[2].Type, "hashing wins over the bytes override")
+
+	effective.Columns["users"]["id"] = config.ColumnConfig{Type: config.ColumnTypeBytes}
+	require.ErrorIs(t, gen.validateColumnTypes(tables), ErrColumnType)
+}
+
+func TestGenerator_CommentDirectives(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   clickhouse.Column
+		comment  string
+		contains []string
+		absent   []string
+	}{
+		{
+			name:     "Type string",
+			column:   clickhouse.NewColumn("balance", "UInt64", 2),
+			comment:  "Current balance @proto(type=string)",
+			contains: []string{"  // Current balance\n  string balance = 12;", "  StringFilter balance = 2;"},
+			absent:   []string{"@proto"},
+		},
+		{
+			name:     "Type bytes",
+			column:   clickhouse.NewColumn("public_key", "FixedString(32)", 2),
+			comment:  "Ed25519 public key @proto(type=bytes)",
+			contains: []string{"  // Ed25519 public key\n  bytes public_key = 12;"},
+		},
+		{
+			name:     "Mask",
+			column:   clickhouse.NewColumn("email", "String", 2),
+			comment:  "Contact address @api(mask=hash)",
+			contains: []string{"  // Contact address\n  string email = 12 [(clickhouse.v1.mask) = \"hash\"];"},
+			absent:   []string{"@api"},
+		},
+		{
+			name:     "Type bool",
+			column:   clickhouse.NewColumn("is_verified", "UInt8", 2),
+			comment:  "Whether KYC passed @proto(type=bool)",
+			contains: []string{"  // Whether KYC passed\n  bool is_verified = 12;", "  BoolFilter is_verified = 2;"},
+		},
+		{
+			name:     "Type bool nullable",
+			column:   clickhouse.NewColumn("is_frozen", "Nullable(UInt8)", 2),
+			comment:  "@proto(type=bool)",
+			contains: []string{"  google.protobuf.BoolValue is_frozen = 12;", "  NullableBoolFilter is_frozen = 2;"},
+		},
+		{
+			name:     "Unit",
+			column:   clickhouse.NewColumn("balance", "UInt64", 2),
+			comment:  "Current balance @proto(type=string, unit=wei)",
+			contains: []string{"  // Current balance\n  // Value in wei.\n  string balance = 12 [(clickhouse.v1.unit) = \"wei\"];"},
+		},
+		{
+			name:     "Encoding zigzag",
+			column:   clickhouse.NewColumn("balance_delta", "Int64", 2),
+			comment:  "Change since the previous day @proto(encoding=zigzag)",
+			contains: []string{"  // Change since the previous day\n  sint64 balance_delta = 12;", "  Int64Filter balance_delta = 2;"},
+		},
+		{
+			name:     "Encoding fixed",
+			column:   clickhouse.NewColumn("salt", "UInt64", 2),
+			comment:  "Random salt @proto(encoding=fixed)",
+			contains: []string{"  // Random salt\n  fixed64 salt = 12;", "  UInt64Filter salt = 2;"},
+		},
+		{
+			name:     "Hidden",
+			column:   clickhouse.NewColumn("risk_score", "Float64", 2),
+			comment:  "@api(hidden)",
+			contains: []string{"  reserved 12; // Omitted by column mask\n"},
+			absent:   []string{"risk_score"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column := tt.column
+			column.Comment = tt.comment
+			table := &clickhouse.Table{
+				Name:       "accounts",
+				Database:   "default",
+				Engine:     "MergeTree",
+				Columns:    []clickhouse.Column{clickhouse.NewColumn("account_id", "UInt64", 1), column},
+				SortingKey: []string{"account_id"},
+			}
+
+			cfg := config.NewConfig()
+			cfg.OutputDir = t.TempDir()
+			log := logrus.New()
+			log.SetLevel(logrus.ErrorLevel)
+			require.NoError(t, NewGenerator(cfg, log).Generate([]*clickhouse.Table{table}))
+
+			content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "accounts.proto"))
+			require.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, string(content), want)
+			}
+			for _, unwanted := range tt.absent {
+				assert.NotContains(t, string(content), unwanted)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// ErrColumnEncoding is returned when a column's encoding doesn't fit its ClickHouse type
+var ErrColumnEncoding = errors.New("column encoding doesn't match the column")
+
+// Proto scalars of the zigzag and fixed integer encodings
+const (
+	protoSInt32   = "sint32"
+	protoSInt64   = "sint64"
+	protoSFixed32 = "sfixed32"
+	protoSFixed64 = "sfixed64"
+	protoFixed32  = "fixed32"
+	protoFixed64  = "fixed64"
+)
+
+// columnEncoding returns the wire encoding of a column's field: the column's own encoding,
+// else the int_encodings entry of its type
+func (g *Generator) columnEncoding(tableName string, column *clickhouse.Column) string {
+	if encoding := g.config.ColumnOverrides(tableName, column.Name).Encoding; encoding != "" {
+		return encoding
+	}
+	return g.config.Conversion.IntEncodings[column.BaseType]
+}
+
+// validateColumnEncodings checks that column encodings are set on integer columns only, and
+// zigzag on signed ones
+func (g *Generator) validateColumnEncodings(tables []*clickhouse.Table) error {
+	for _, table := range tables {
+		for _, column := range table.Columns {
+			encoding := g.config.ColumnOverrides(table.Name, column.Name).Encoding
+			var ok bool
+			switch encoding {
+			case "", config.EncodingVarint:
+				continue
+			case config.EncodingZigzag:
+				ok = config.IsSignedIntType(column.BaseType)
+			case config.EncodingFixed:
+				ok = config.IsSignedIntType(column.BaseType) || config.IsUnsignedIntType(column.BaseType)
+			}
+			if !ok {
+				return fmt.Errorf("%w: %s.%s is %s, encoding %s", ErrColumnEncoding, table.Name, column.Name, column.Type, encoding)
+			}
+		}
+	}
+	return nil
+}
+
+// applyEncodingToField switches the integer field of a column to the zigzag or fixed scalar
+// of its encoding. Fields the column no longer maps to an integer, like bigint_to_string or
+// masked ones, and nullable fields, whose wrapper types have no such variants, keep theirs.
+func (g *Generator) applyEncodingToField(field *ProtoField, column *clickhouse.Column, tableName string) {
+	encoding := g.columnEncoding(tableName, column)
+	if encoding == "" || encoding == config.EncodingVarint {
+		return
+	}
+
+	scalar, repeated := strings.CutPrefix(field.Type, "repeated ")
+	encoded := encodedScalar(scalar, encoding)
+	if encoded == scalar {
+		return
+	}
+	if repeated {
+		encoded = "repeated " + encoded
+	}
+	field.Type = encoded
+}
+
+// encodedScalar returns the proto scalar of an integer scalar in an encoding, or the scalar
+// itself when the encoding has no variant of it
+func encodedScalar(scalar, encoding string) string {
+	switch {
+	case encoding == config.EncodingZigzag && scalar == protoInt32:
+		return protoSInt32
+	case encoding == config.EncodingZigzag && scalar == protoInt64:
+		return protoSInt64
+	case encoding == config.EncodingFixed && scalar == protoInt32:
+		return protoSFixed32
+	case encoding == config.EncodingFixed && scalar == protoInt64:
+		return protoSFixed64
+	case encoding == config.EncodingFixed && scalar == protoUInt32:
+		return protoFixed32
+	case encoding == config.EncodingFixed && scalar == protoUInt64:
+		return protoFixed64
+	}
+	return scalar
+}
+
+// decodedScalar returns the varint scalar holding the same values as a zigzag or fixed
+// scalar. Model emitters only care about values, which the encodings don't change.
+func decodedScalar(protoType string) string {
+	switch protoType {
+	case protoSInt32, protoSFixed32:
+		return protoInt32
+	case protoSInt64, protoSFixed64:
+		return protoInt64
+	case protoFixed32:
+		return protoUInt32
+	case protoFixed64:
+		return protoUInt64
+	}
+	return protoType
+}
@@ -0,0 +1,90 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_IntEncodings(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:     "fct_balance",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt64", 1),
+			clickhouse.NewColumn("delta", "Int64", 2),
+			clickhouse.NewColumn("deltas", "Array(Int32)", 3),
+			clickhouse.NewColumn("previous_delta", "Nullable(Int64)", 4),
+			clickhouse.NewColumn("salt", "UInt64", 5),
+			clickhouse.NewColumn("balance_wei", "Int64", 6),
+		},
+		SortingKey: []string{"slot"},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Conversion.IntEncodings = map[string]string{"Int64": config.EncodingZigzag, "Int32": config.EncodingFixed}
+	cfg.Conversion.BigIntToStringFields = []string{"balance_wei"}
+	cfg.Columns = map[string]map[string]config.ColumnConfig{
+		"fct_balance": {"salt": {Encoding: config.EncodingFixed}, "delta": {Encoding: config.EncodingVarint}},
+	}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_balance.proto"))
+	require.NoError(t, err)
+	proto := string(content)
+
+	assert.Contains(t, proto, "  uint64 slot = 11;")
+	assert.Contains(t, proto, "  int64 delta = 12;", "the column encoding overrides int_encodings")
+	assert.Contains(t, proto, "  repeated sfixed32 deltas = 13;")
+	assert.Contains(t, proto, "  google.protobuf.Int64Value previous_delta = 14;", "wrappers have no encodings")
+	assert.Contains(t, proto, "  fixed64 salt = 15;")
+	assert.Contains(t, proto, "  string balance_wei = 16;")
+
+	// Model emitters see the values, not the wire encoding
+	row := parseProtoMessages(proto)[0]
+	assert.Equal(t, protoInt32, row.Fields[2].Type)
+	assert.Equal(t, protoUInt64, row.Fields[4].Scalar())
+}
+
+func TestGenerator_ValidateColumnEncodings(t *testing.T) {
+	tables := []*clickhouse.Table{{
+		Name: "fct_balance",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("delta", "Int64", 1),
+			clickhouse.NewColumn("salt", "UInt64", 2),
+			clickhouse.NewColumn("name", "String", 3),
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		override map[string]config.ColumnConfig
+		wantErr  bool
+	}{
+		{name: "zigzag signed", override: map[string]config.ColumnConfig{"delta": {Encoding: config.EncodingZigzag}}},
+		{name: "fixed unsigned", override: map[string]config.ColumnConfig{"salt": {Encoding: config.EncodingFixed}}},
+		{name: "zigzag unsigned", override: map[string]config.ColumnConfig{"salt": {Encoding: config.EncodingZigzag}}, wantErr: true},
+		{name: "fixed string", override: map[string]config.ColumnConfig{"name": {Encoding: config.EncodingFixed}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Columns = map[string]map[string]config.ColumnConfig{"fct_balance": tt.override}
+			err := NewGenerator(cfg, logrus.New()).validateColumnEncodings(tables)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrColumnEncoding)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
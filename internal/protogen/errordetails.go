@@ -0,0 +1,137 @@
+package protogen
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// writeErrorDetailTypes writes the error detail messages of common.proto, which the statuses
+// of status.go carry so clients can tell rejected requests apart without parsing messages
+func (g *Generator) writeErrorDetailTypes(sb *strings.Builder) {
+	sb.WriteString("\n// InvalidFilter is the error detail of a request field whose value can't be used, such\n")
+	sb.WriteString("// as a missing primary key or a negative page_size\n")
+	sb.WriteString("message InvalidFilter {\n")
+	sb.WriteString("  // The request field, e.g. slot or page_size\n")
+	sb.WriteString("  string field = 1;\n")
+	sb.WriteString("  // What is wrong with the value, in English\n")
+	sb.WriteString("  string reason = 2;\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// UnknownColumn is the error detail of a request naming a field the table doesn't have,\n")
+	sb.WriteString("// or doesn't accept there, such as a masked field in order_by\n")
+	sb.WriteString("message UnknownColumn {\n")
+	sb.WriteString("  // The request field naming it: order_by, distinct_on or value_field\n")
+	sb.WriteString("  string field = 1;\n")
+	sb.WriteString("  // The unknown name\n")
+	sb.WriteString("  string name = 2;\n")
+	sb.WriteString("  // The field reading the column, when name is a column rather than a field\n")
+	sb.WriteString("  string suggested_field = 3;\n")
+	sb.WriteString("  // The fields accepted, sorted\n")
+	sb.WriteString("  repeated string valid_fields = 4;\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// PageTokenExpired is the error detail of a List request whose page_token can't be used,\n")
+	sb.WriteString("// e.g. one issued by another version of the service. List again without it.\n")
+	sb.WriteString("message PageTokenExpired {\n")
+	sb.WriteString("  // The rejected page token\n")
+	sb.WriteString("  string page_token = 1;\n")
+	sb.WriteString("}\n")
+}
+
+// GenerateErrorStatusHelpers writes status.go, whose constructors turn the errors of the
+// query builders into google.rpc.Status messages with the error details of common.proto
+func (g *Generator) GenerateErrorStatusHelpers() error {
+	sb := &strings.Builder{}
+	g.writeSQLFileHeader(sb, "This file turns rejected requests into google.rpc.Status messages with error details.")
+	sb.WriteString(errorStatusHelpers)
+
+	filename := filepath.Join(g.goOutputDir(), "status.go")
+	if err := g.writeFile(filename, sb.String()); err != nil {
+		return err
+	}
+
+	g.log.WithField("file", filename).Info("Generated error status helper file")
+	return nil
+}
+
+// errorStatusHelpers builds the statuses of the InvalidFilter, UnknownColumn and
+// PageTokenExpired details
+const errorStatusHelpers = `import (
+	"errors"
+
+	codepb "google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ErrorLocale is the locale of the status messages, which are also attached as a
+// google.rpc.LocalizedMessage. Clients showing errors in other languages can pick their own
+// message by the type and fields of the other detail.
+const ErrorLocale = "en-US"
+
+// NewInvalidFilterStatus returns the INVALID_ARGUMENT status of a request field whose value
+// can't be used, with an InvalidFilter detail
+func NewInvalidFilterStatus(field, reason string) *statuspb.Status {
+	return newRequestStatus(reason, &InvalidFilter{Field: field, Reason: reason})
+}
+
+// NewUnknownColumnStatus returns the INVALID_ARGUMENT status of a request field naming an
+// unknown field, with an UnknownColumn detail. suggestedField is the field reading the
+// column when name is a column, or empty.
+func NewUnknownColumnStatus(field, name, suggestedField string, validFields []string) *statuspb.Status {
+	err := &UnknownColumnError{Field: field, Name: name, SuggestedField: suggestedField, ValidFields: validFields}
+	return newRequestStatus("invalid "+field+": "+err.Error(), &UnknownColumn{
+		Field:          field,
+		Name:           name,
+		SuggestedField: suggestedField,
+		ValidFields:    validFields,
+	})
+}
+
+// NewPageTokenExpiredStatus returns the INVALID_ARGUMENT status of a List request whose
+// page_token can't be used, with a PageTokenExpired detail
+func NewPageTokenExpiredStatus(pageToken string) *statuspb.Status {
+	return newRequestStatus("invalid page_token: list again without it", &PageTokenExpired{PageToken: pageToken})
+}
+
+// ErrorStatus returns the status of an error of the query builders, which only reject
+// requests, so it is always INVALID_ARGUMENT. InvalidFilterError, UnknownColumnError and
+// PageTokenExpiredError get their detail; the message is the one of the error. Servers
+// return it with google.golang.org/grpc/status.ErrorProto.
+func ErrorStatus(err error) *statuspb.Status {
+	var invalidFilter *InvalidFilterError
+	var unknownColumn *UnknownColumnError
+	var pageToken *PageTokenExpiredError
+	switch {
+	case errors.As(err, &unknownColumn):
+		return newRequestStatus(err.Error(), &UnknownColumn{
+			Field:          unknownColumn.Field,
+			Name:           unknownColumn.Name,
+			SuggestedField: unknownColumn.SuggestedField,
+			ValidFields:    unknownColumn.ValidFields,
+		})
+	case errors.As(err, &invalidFilter):
+		return newRequestStatus(err.Error(), &InvalidFilter{Field: invalidFilter.Field, Reason: invalidFilter.Reason})
+	case errors.As(err, &pageToken):
+		return newRequestStatus(err.Error(), &PageTokenExpired{PageToken: pageToken.PageToken})
+	default:
+		return newRequestStatus(err.Error())
+	}
+}
+
+// newRequestStatus returns an INVALID_ARGUMENT status with the details and the message as a
+// google.rpc.LocalizedMessage
+func newRequestStatus(message string, details ...proto.Message) *statuspb.Status {
+	status := &statuspb.Status{Code: int32(codepb.Code_INVALID_ARGUMENT), Message: message}
+	details = append(details, &errdetails.LocalizedMessage{Locale: ErrorLocale, Message: message})
+	for _, detail := range details {
+		// Only fails for messages that can't be marshaled, which these always can
+		if packed, err := anypb.New(detail); err == nil {
+			status.Details = append(status.Details, packed)
+		}
+	}
+	return status
+}
+`
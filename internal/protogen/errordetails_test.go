@@ -0,0 +1,60 @@
+package protogen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_ErrorDetails(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+
+	generate := func(t *testing.T, emit ...string) string {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.GoPackage = "github.com/acme/api/gen/v1"
+		cfg.Emit = emit
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+		return cfg.OutputDir
+	}
+
+	dir := generate(t)
+	common, err := os.ReadFile(filepath.Join(dir, "common.proto"))
+	require.NoError(t, err)
+	assert.Contains(t, string(common), "message InvalidFilter {\n")
+	assert.Contains(t, string(common), "  repeated string valid_fields = 4;\n")
+	assert.Contains(t, string(common), "message PageTokenExpired {\n")
+
+	path := filepath.Join(dir, "status.go")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "func ErrorStatus(err error) *statuspb.Status {")
+	assert.Contains(t, string(content), "func NewUnknownColumnStatus(field, name, suggestedField string, validFields []string) *statuspb.Status {")
+	_, err = parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors)
+	require.NoError(t, err)
+
+	// The validators and builders return the errors the statuses are made from
+	sql, err := os.ReadFile(filepath.Join(dir, "fct_block_sql.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(sql), `return invalidFilter("slot", "primary key field slot is required")`)
+	assert.Contains(t, string(sql), "return SQLQuery{}, &PageTokenExpiredError{PageToken: req.PageToken, Err: err}")
+
+	// Without services there is no common.proto to hold the details
+	dir = generate(t, config.EmitMessages, config.EmitSQL)
+	assert.NoFileExists(t, filepath.Join(dir, "status.go"))
+}
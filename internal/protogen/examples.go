@@ -0,0 +1,184 @@
+package protogen
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// Servers the RPC examples call unless api_examples sets them
+const (
+	defaultExampleHTTPBaseURL = "http://localhost:8080"
+	defaultExampleGRPCAddress = "localhost:9090"
+)
+
+// exampleParam is a request field set by an RPC example
+type exampleParam struct {
+	path   string // Field path, with the filter operator after a dot, e.g. "slot.gte"
+	value  string // Value in a query string or URL path
+	json   string // Value in a JSON request body
+	inPath bool   // Whether the value is part of the URL path rather than the query string
+}
+
+// exampleValue is an example value of a column and how List requests filter on it
+type exampleValue struct {
+	op    string // Filter operator, empty when the column has no filter
+	value string
+	json  string
+}
+
+// writeRPCExamples writes curl and grpcurl invocations of an RPC as the last lines of its
+// comment. httpPath is the path of the RPC's HTTP annotation with its variables filled in.
+func (g *Generator) writeRPCExamples(sb *strings.Builder, table *clickhouse.Table, method, httpPath string, params []exampleParam) {
+	if !g.config.APIExamples.Enabled || !g.shouldGenerateAPI(table.Name) {
+		return
+	}
+
+	baseURL := g.config.APIExamples.HTTPBaseURL
+	if baseURL == "" {
+		baseURL = defaultExampleHTTPBaseURL
+	}
+	address := g.config.APIExamples.GRPCAddress
+	if address == "" {
+		address = defaultExampleGRPCAddress
+	}
+	service := ToPascalCase(table.Name) + "Service"
+	if g.config.Package != "" {
+		service = g.config.Package + "." + service
+	}
+
+	var query, body []string
+	for _, param := range params {
+		if !param.inPath {
+			query = append(query, param.path+"="+url.QueryEscape(param.value))
+		}
+		field, op, nested := strings.Cut(param.path, ".")
+		if nested {
+			body = append(body, fmt.Sprintf("%q: {%q: %s}", field, op, param.json))
+		} else {
+			body = append(body, fmt.Sprintf("%q: %s", field, param.json))
+		}
+	}
+
+	target := strings.TrimSuffix(baseURL, "/") + httpPath
+	if len(query) > 0 {
+		target += "?" + strings.Join(query, "&")
+	}
+
+	sb.WriteString("  //\n")
+	sb.WriteString("  // Examples:\n")
+	fmt.Fprintf(sb, "  //   curl '%s'\n", target)
+	fmt.Fprintf(sb, "  //   grpcurl -plaintext -d '{%s}' %s %s/%s\n", strings.Join(body, ", "), address, service, method)
+}
+
+// listExampleParams returns the fields set by the examples of List-like RPCs: a filter on
+// the primary key and the tenant, followed by extra
+func (g *Generator) listExampleParams(table *clickhouse.Table, columnMap map[string]*clickhouse.Column, extra ...exampleParam) []exampleParam {
+	var params []exampleParam
+	if column, ok := columnMap[table.SortingKey[0]]; ok {
+		if example := g.columnExample(table, column); example.op != "" {
+			params = append(params, exampleParam{
+				path:  g.fieldName(table.Name, column.Name) + "." + example.op,
+				value: example.value,
+				json:  example.json,
+			})
+		}
+	}
+	params = append(params, g.tenantExampleParams(table)...)
+	return append(params, extra...)
+}
+
+// getExampleParams returns the fields set by the examples of Get RPCs, along with the HTTP
+// path of the request
+func (g *Generator) getExampleParams(table *clickhouse.Table, columnMap map[string]*clickhouse.Column) (string, []exampleParam) {
+	primaryKey := table.SortingKey[0]
+	example := exampleValue{value: "1", json: "1"}
+	if column, ok := columnMap[primaryKey]; ok {
+		example = g.columnExample(table, column)
+	}
+
+	httpPath := fmt.Sprintf("%s/%s/%s", g.config.APIBasePath, table.Name, url.PathEscape(example.value))
+	params := []exampleParam{{path: g.fieldName(table.Name, primaryKey), value: example.value, json: example.json, inPath: true}}
+	return httpPath, append(params, g.tenantExampleParams(table)...)
+}
+
+// tenantExampleParams returns the tenant field of a table's example requests, if it has one
+func (g *Generator) tenantExampleParams(table *clickhouse.Table) []exampleParam {
+	tenant, _ := g.tenantScopeFor(table)
+	if tenant == nil {
+		return nil
+	}
+
+	switch tenant.protoType {
+	case protoString:
+		return []exampleParam{{path: tenant.field, value: "example", json: `"example"`}}
+	case protoInt64, protoUInt64:
+		return []exampleParam{{path: tenant.field, value: "1", json: `"1"`}}
+	}
+	return []exampleParam{{path: tenant.field, value: "1", json: "1"}}
+}
+
+// columnExample returns an example value of a column. Time columns are filtered from a
+// point in time, other numbers from a small value, and the rest by equality. 64-bit
+// integers are quoted in JSON, as protobuf's JSON mapping writes them as strings.
+func (g *Generator) columnExample(table *clickhouse.Table, column *clickhouse.Column) exampleValue {
+	value := exampleColumnValue(column)
+	filterType := strings.TrimPrefix(g.typeMapper.GetFilterTypeForColumn(column, table.Name, &g.config.Conversion), "Nullable")
+	switch filterType {
+	case "BoolFilter":
+		return exampleValue{op: "eq", value: "true", json: "true"}
+	case "StringFilter":
+		return exampleValue{op: "eq", value: value, json: strconv.Quote(value)}
+	case "Int64Filter", "UInt64Filter":
+		return exampleValue{op: "gte", value: value, json: strconv.Quote(value)}
+	case "Int32Filter", "UInt32Filter":
+		return exampleValue{op: "gte", value: value, json: value}
+	}
+
+	switch g.typeMapper.mapBaseType(column.BaseType, column.Type) {
+	case protoString, protoInt64, protoUInt64:
+		return exampleValue{value: value, json: strconv.Quote(value)}
+	}
+	return exampleValue{value: value, json: value}
+}
+
+// exampleColumnValue returns a value fitting a column's ClickHouse type
+func exampleColumnValue(column *clickhouse.Column) string {
+	switch column.BaseType {
+	case "DateTime":
+		return "1700000000"
+	case "DateTime64":
+		return "1700000000000000" // Microseconds, as DateTime64 fields hold them
+	case "Date", "Date32":
+		return "2024-01-01"
+	case "Enum8", "Enum16":
+		if name := firstEnumName(column.Type); name != "" {
+			return name
+		}
+	case "Float32", "Float64":
+		return "1.5"
+	case "Bool":
+		return "true"
+	case "UUID":
+		return "00000000-0000-0000-0000-000000000000"
+	}
+
+	if isNumericBaseType(column.BaseType) {
+		return "100"
+	}
+	return "example"
+}
+
+// firstEnumName returns the name of the first value of an Enum8 or Enum16 type, e.g. "ok"
+// for Enum8('ok' = 1, 'error' = 2)
+func firstEnumName(chType string) string {
+	_, rest, ok := strings.Cut(chType, "'")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, "'")
+	return name
+}
@@ -0,0 +1,123 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_APIExamples(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:     "fct_attestation",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot_start_date_time", "DateTime64(3)", 1),
+			clickhouse.NewColumn("network", "LowCardinality(String)", 2),
+			clickhouse.NewColumn("votes", "UInt32", 3),
+		},
+		SortingKey: []string{"slot_start_date_time"},
+	}
+
+	on := true
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Package = "beacon.v1"
+	cfg.EnableAPI = true
+	cfg.APIExamples = config.APIExamplesConfig{Enabled: true, HTTPBaseURL: "https://api.example.com/"}
+	cfg.Policies = []config.PolicyConfig{{Match: "fct_", Buckets: &on}}
+	cfg.Tenant = config.TenantConfig{Column: "network"}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_attestation.proto"))
+	require.NoError(t, err)
+	proto := string(content)
+
+	assert.Contains(t, proto, "  // List records | Retrieve paginated results with optional filtering\n  //\n  // Examples:\n"+
+		"  //   curl 'https://api.example.com/api/v1/fct_attestation?slot_start_date_time.gte=1700000000000000&tenant=example&page_size=10'\n"+
+		`  //   grpcurl -plaintext -d '{"slot_start_date_time": {"gte": "1700000000000000"}, "tenant": "example", "page_size": 10}' localhost:9090 beacon.v1.FctAttestationService/List`+"\n")
+	assert.Contains(t, proto, "  //   curl 'https://api.example.com/api/v1/fct_attestation/1700000000000000?tenant=example'\n"+
+		`  //   grpcurl -plaintext -d '{"slot_start_date_time": "1700000000000000", "tenant": "example"}' localhost:9090 beacon.v1.FctAttestationService/Get`+"\n")
+	assert.Contains(t, proto, "  //   curl 'https://api.example.com/api/v1/fct_attestation:buckets?slot_start_date_time.gte=1700000000000000&tenant=example&interval=1h'\n")
+}
+
+func TestGenerator_APIExamplesWithoutAPI(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.APIExamples.Enabled = true
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_block.proto"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "Examples:", "tables without HTTP annotations get no examples")
+}
+
+func TestGenerator_ColumnExample(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   clickhouse.Column
+		expected exampleValue
+	}{
+		{
+			name:     "DateTime from a point in time",
+			column:   clickhouse.NewColumn("slot_start_date_time", "DateTime", 1),
+			expected: exampleValue{op: "gte", value: "1700000000", json: "1700000000"},
+		},
+		{
+			name:     "Date by equality",
+			column:   clickhouse.NewColumn("day", "Date", 1),
+			expected: exampleValue{op: "eq", value: "2024-01-01", json: `"2024-01-01"`},
+		},
+		{
+			name:     "first enum value",
+			column:   clickhouse.NewColumn("status", "Enum8('ok' = 1, 'error' = 2)", 1),
+			expected: exampleValue{op: "eq", value: "ok", json: `"ok"`},
+		},
+		{
+			name:     "64-bit integers quoted in JSON",
+			column:   clickhouse.NewColumn("block_number", "UInt64", 1),
+			expected: exampleValue{op: "gte", value: "100", json: `"100"`},
+		},
+		{
+			name:     "bigint_to_string by equality",
+			column:   clickhouse.NewColumn("value_wei", "UInt64", 1),
+			expected: exampleValue{op: "eq", value: "100", json: `"100"`},
+		},
+		{
+			name:     "bool columns",
+			column:   clickhouse.NewColumn("is_canonical", "UInt8", 1),
+			expected: exampleValue{op: "eq", value: "true", json: "true"},
+		},
+		{
+			name:     "columns without filters",
+			column:   clickhouse.NewColumn("ratio", "Float64", 1),
+			expected: exampleValue{value: "1.5", json: "1.5"},
+		},
+	}
+
+	cfg := config.NewConfig()
+	cfg.Conversion.BigIntToStringFields = []string{"value_wei"}
+	cfg.Conversion.BoolColumns = []string{"is_*"}
+	g := NewGenerator(cfg, logrus.New())
+	table := &clickhouse.Table{Name: "fct_block"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, g.columnExample(table, &tt.column))
+		})
+	}
+}
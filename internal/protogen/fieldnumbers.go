@@ -0,0 +1,246 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFieldNumberLock is the lock file of the lock strategy, relative to the output directory
+const defaultFieldNumberLock = "field_numbers.lock.yaml"
+
+// defaultFieldNumberOffset is added to column positions unless configured otherwise
+const defaultFieldNumberOffset = 10
+
+// The hash strategy numbers fields within [hashFieldNumberMin, hashFieldNumberMax]: above the
+// numbers with one byte tags, which stay free for hand-written fields, and below the range
+// protobuf reserves for its implementation
+const (
+	hashFieldNumberMin = 16
+	hashFieldNumberMax = 18999
+)
+
+// Field numbers reserved by protobuf, skipped when appending to a lock
+const (
+	reservedFieldNumberMin = 19000
+	reservedFieldNumberMax = 19999
+)
+
+// fieldNumberLock records the field number of every column ever generated, keyed by table
+// then column. Columns stay in the lock after they are dropped, so their numbers are never
+// reused.
+type fieldNumberLock struct {
+	Tables map[string]map[string]int32 `yaml:"tables"`
+	// changed is set when numbers were assigned since the lock was loaded
+	changed bool
+}
+
+const fieldNumberLockHeader = `# Field numbers of the messages generated by clickhouse-proto-gen, keyed by table then column.
+# Commit this file. New columns are appended; dropped columns stay listed and keep their
+# numbers reserved, so the numbers of a message never change or get reused.
+`
+
+// fieldNumbers returns the field number of every column of a table, including columns that
+// are omitted from its message
+func (g *Generator) fieldNumbers(table *clickhouse.Table) map[string]int32 {
+	// Renamed columns are numbered under their original names
+	original := &clickhouse.Table{Name: table.Name, Columns: g.originallyNamedColumns(table)}
+
+	var numbers map[string]int32
+	switch g.config.FieldNumbers.Strategy {
+	case config.FieldNumbersHash:
+		numbers = hashFieldNumbers(original.Columns)
+	case config.FieldNumbersLock:
+		numbers = g.lockedFieldNumbers(original)
+	default:
+		numbers = positionFieldNumbers(original.Columns, g.fieldNumberOffset())
+	}
+
+	for i, column := range table.Columns {
+		if name := original.Columns[i].Name; name != column.Name {
+			numbers[column.Name] = numbers[name]
+			delete(numbers, name)
+		}
+	}
+	return numbers
+}
+
+func (g *Generator) fieldNumberOffset() int {
+	if g.config.FieldNumbers.Offset > 0 {
+		return g.config.FieldNumbers.Offset
+	}
+	return defaultFieldNumberOffset
+}
+
+func positionFieldNumbers(columns []clickhouse.Column, offset int) map[string]int32 {
+	numbers := make(map[string]int32, len(columns))
+	for _, column := range columns {
+		numbers[column.Name] = offsetFieldNumber(column.Position, offset)
+	}
+	return numbers
+}
+
+// hashFieldNumbers numbers columns by the FNV-1a hash of their name, so adding, dropping or
+// reordering columns leaves the numbers of the others alone. Columns whose hashes collide
+// are probed upwards in position order; only reordering two such columns swaps their numbers.
+func hashFieldNumbers(columns []clickhouse.Column) map[string]int32 {
+	ordered := make([]clickhouse.Column, len(columns))
+	copy(ordered, columns)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Position < ordered[j].Position })
+
+	const span = hashFieldNumberMax - hashFieldNumberMin + 1
+	numbers := make(map[string]int32, len(columns))
+	used := make(map[int32]bool, len(columns))
+	for _, column := range ordered {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(column.Name))
+		number := hashFieldNumberMin + int32(h.Sum32()%span)
+		for used[number] {
+			number++
+			if number > hashFieldNumberMax {
+				number = hashFieldNumberMin
+			}
+		}
+		used[number] = true
+		numbers[column.Name] = number
+	}
+	return numbers
+}
+
+// lockedFieldNumbers returns the locked numbers of a table's columns. Tables new to the lock
+// start out numbered by position, matching what the position strategy generated for them;
+// new columns of locked tables get the next number after every number the table ever used.
+func (g *Generator) lockedFieldNumbers(table *clickhouse.Table) map[string]int32 {
+	if g.lock == nil {
+		g.lock = &fieldNumberLock{}
+	}
+	if g.lock.Tables == nil {
+		g.lock.Tables = make(map[string]map[string]int32)
+	}
+
+	locked, ok := g.lock.Tables[table.Name]
+	if !ok {
+		locked = positionFieldNumbers(table.Columns, g.fieldNumberOffset())
+		g.lock.Tables[table.Name] = locked
+		g.lock.changed = len(locked) > 0 || g.lock.changed
+	}
+
+	numbers := make(map[string]int32, len(table.Columns))
+	for _, column := range table.Columns {
+		number, ok := locked[column.Name]
+		if !ok {
+			number = nextFieldNumber(locked)
+			locked[column.Name] = number
+			g.lock.changed = true
+		}
+		numbers[column.Name] = number
+	}
+	return numbers
+}
+
+// nextFieldNumber returns the number after the highest one in use, skipping the reserved range
+func nextFieldNumber(used map[string]int32) int32 {
+	var highest int32
+	for _, number := range used {
+		highest = max(highest, number)
+	}
+
+	next := highest + 1
+	if next >= reservedFieldNumberMin && next <= reservedFieldNumberMax {
+		next = reservedFieldNumberMax + 1
+	}
+	return next
+}
+
+// removedFieldNumbers returns the locked numbers of columns a table no longer has, with the
+// names of their columns, in ascending order
+func (g *Generator) removedFieldNumbers(table *clickhouse.Table) ([]int32, map[int32]string) {
+	if g.config.FieldNumbers.Strategy != config.FieldNumbersLock || g.lock == nil {
+		return nil, nil
+	}
+
+	current := make(map[string]bool, len(table.Columns))
+	for _, column := range table.Columns {
+		current[column.Name] = true
+	}
+
+	var numbers []int32
+	names := make(map[int32]string)
+	for name, number := range g.lock.Tables[table.Name] {
+		if !current[name] {
+			numbers = append(numbers, number)
+			names[number] = name
+		}
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	return numbers, names
+}
+
+func (g *Generator) fieldNumberLockPath() string {
+	path := g.config.FieldNumbers.LockFile
+	if path == "" {
+		path = defaultFieldNumberLock
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(g.config.OutputDir, path)
+}
+
+// LoadFieldNumberLock reads the lock file of the lock strategy. A missing lock file is an
+// empty lock. It does nothing for the other strategies.
+func (g *Generator) LoadFieldNumberLock() error {
+	if g.config.FieldNumbers.Strategy != config.FieldNumbersLock {
+		return nil
+	}
+
+	lock := &fieldNumberLock{}
+	data, err := os.ReadFile(filepath.Clean(g.fieldNumberLockPath()))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read field number lock: %w", err)
+	default:
+		if err := yaml.Unmarshal(data, lock); err != nil {
+			return fmt.Errorf("failed to parse field number lock %s: %w", g.fieldNumberLockPath(), err)
+		}
+	}
+
+	g.lock = lock
+	return nil
+}
+
+// writeFieldNumberLock writes the lock back when numbers were assigned during the run
+func (g *Generator) writeFieldNumberLock() error {
+	if g.lock == nil || !g.lock.changed {
+		return nil
+	}
+
+	data, err := yaml.Marshal(g.lock)
+	if err != nil {
+		return fmt.Errorf("failed to encode field number lock: %w", err)
+	}
+	if err := g.writeFile(g.fieldNumberLockPath(), fieldNumberLockHeader+string(data)); err != nil {
+		return err
+	}
+
+	g.lock.changed = false
+	g.log.WithField("file", g.fieldNumberLockPath()).Info("Updated field number lock")
+	return nil
+}
+
+// sortFields orders message fields by name when field_order is name. Field numbers are
+// unaffected, so the order only changes how the message reads, not its wire format.
+func (g *Generator) sortFields(fields []*ProtoField) {
+	if g.config.FieldOrder == config.FieldOrderName {
+		sort.SliceStable(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	}
+}
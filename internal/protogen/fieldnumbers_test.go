@@ -0,0 +1,156 @@
+package protogen
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_FieldNumbers(t *testing.T) {
+	columns := []clickhouse.Column{
+		clickhouse.NewColumn("slot", "UInt32", 1),
+		clickhouse.NewColumn("block_root", "String", 2),
+		clickhouse.NewColumn("proposer", "String", 3),
+	}
+	// proposer dropped, epoch inserted before the others
+	changed := []clickhouse.Column{
+		clickhouse.NewColumn("epoch", "UInt32", 1),
+		clickhouse.NewColumn("slot", "UInt32", 2),
+		clickhouse.NewColumn("block_root", "String", 3),
+	}
+
+	t.Run("Position with offset", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.FieldNumbers.Offset = 100
+		numbers := NewGenerator(cfg, logrus.New()).fieldNumbers(&clickhouse.Table{Name: "blocks", Columns: columns})
+		assert.Equal(t, map[string]int32{"slot": 101, "block_root": 102, "proposer": 103}, numbers)
+	})
+
+	t.Run("Hash", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.FieldNumbers.Strategy = config.FieldNumbersHash
+		g := NewGenerator(cfg, logrus.New())
+
+		before := g.fieldNumbers(&clickhouse.Table{Name: "blocks", Columns: columns})
+		after := g.fieldNumbers(&clickhouse.Table{Name: "blocks", Columns: changed})
+		assert.Equal(t, before["slot"], after["slot"], "numbers don't depend on positions")
+		assert.Equal(t, before["block_root"], after["block_root"])
+		for name, number := range after {
+			assert.GreaterOrEqual(t, number, int32(hashFieldNumberMin), name)
+			assert.LessOrEqual(t, number, int32(hashFieldNumberMax), name)
+		}
+	})
+
+	t.Run("Hash collisions probe in position order", func(t *testing.T) {
+		first, second := collidingColumnNames(t)
+		numbers := hashFieldNumbers([]clickhouse.Column{{Name: second, Position: 2}, {Name: first, Position: 1}})
+		assert.Equal(t, numbers[first]+1, numbers[second])
+	})
+
+	t.Run("Lock", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cfg := config.NewConfig()
+		cfg.OutputDir = tmpDir
+		cfg.Package = "test.v1"
+		cfg.FieldNumbers.Strategy = config.FieldNumbersLock
+
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{{Name: "blocks", Columns: columns}}))
+		lock, err := readFile(filepath.Join(tmpDir, defaultFieldNumberLock))
+		require.NoError(t, err)
+		assert.Contains(t, lock, "tables:\n    blocks:\n        block_root: 12\n        proposer: 13\n        slot: 11\n",
+			"tables new to the lock keep their position numbers")
+
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{{Name: "blocks", Columns: changed}}))
+		lock, err = readFile(filepath.Join(tmpDir, defaultFieldNumberLock))
+		require.NoError(t, err)
+		assert.Contains(t, lock, "        epoch: 14\n", "new columns are appended")
+		assert.Contains(t, lock, "        proposer: 13\n", "dropped columns stay locked")
+
+		proto, err := readFile(filepath.Join(tmpDir, "blocks.proto"))
+		require.NoError(t, err)
+		assert.Contains(t, proto, "uint32 epoch = 14;")
+		assert.Contains(t, proto, "uint32 slot = 11;")
+		assert.Contains(t, proto, "reserved 13; // Dropped column proposer\n")
+	})
+
+	t.Run("Lock skips the reserved range", func(t *testing.T) {
+		assert.Equal(t, int32(20000), nextFieldNumber(map[string]int32{"a": 18999}))
+		assert.Equal(t, int32(12), nextFieldNumber(map[string]int32{"a": 11}))
+	})
+}
+
+func TestGenerator_FieldOrderName(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.FieldOrder = config.FieldOrderName
+	cfg.Visibility = map[string]map[string][]string{"blocks": {"public": {"slot", "block_root"}}}
+	g := NewGenerator(cfg, logrus.New())
+
+	table := &clickhouse.Table{
+		Name: "blocks",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("block_root", "String", 2),
+			clickhouse.NewColumn("epoch", "UInt32", 3),
+		},
+	}
+
+	fields := g.MessageFields(table)
+	require.Len(t, fields, 3)
+	assert.Equal(t, []string{"block_root", "epoch", "slot"}, []string{fields[0].Name, fields[1].Name, fields[2].Name})
+	assert.Equal(t, []int32{12, 13, 11}, []int32{fields[0].Number, fields[1].Number, fields[2].Number}, "numbers follow the strategy, not the order")
+
+	sb := &strings.Builder{}
+	g.writeVisibilityMessages(sb, table)
+	assert.Contains(t, sb.String(), "message BlocksPublic {\n  string block_root = 12;\n  uint32 slot = 11;\n}\n")
+}
+
+func TestGenerator_MigrationProtoChangesHashNumbers(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.FieldNumbers.Strategy = config.FieldNumbersHash
+	g := NewGenerator(cfg, logrus.New())
+
+	table := &clickhouse.Table{
+		Name: "blocks",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("epoch", "UInt32", 1),
+			clickhouse.NewColumn("slot", "UInt32", 2),
+		},
+	}
+	diff := &clickhouse.TableDiff{
+		Table:   table,
+		Added:   []clickhouse.Column{table.Columns[0]},
+		Removed: []clickhouse.Column{clickhouse.NewColumn("proposer", "String", 2)},
+		Moved:   []clickhouse.ColumnMove{{Name: "slot", OldPosition: 1, NewPosition: 2}},
+	}
+
+	changes := g.MigrationProtoChanges(diff)
+	assert.Contains(t, changes, fmt.Sprintf("  reserved %d;\n", hashFieldNumbers(diff.Removed)["proposer"]))
+	assert.NotContains(t, changes, "Not wire compatible", "moving a column keeps its number")
+}
+
+// collidingColumnNames finds two column names whose hashes map to the same field number
+func collidingColumnNames(t *testing.T) (first, second string) {
+	t.Helper()
+
+	seen := make(map[uint32]string)
+	for i := 0; i < 100000; i++ {
+		name := fmt.Sprintf("column_%d", i)
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(name))
+		slot := h.Sum32() % (hashFieldNumberMax - hashFieldNumberMin + 1)
+		if other, ok := seen[slot]; ok {
+			return other, name
+		}
+		seen[slot] = name
+	}
+	t.Fatal("no colliding names found")
+	return "", ""
+}
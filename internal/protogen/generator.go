@@ -2,10 +2,16 @@
 package protogen
 
 import (
+	"bytes"
 	"fmt"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
 	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
@@ -29,100 +35,401 @@ type Generator struct {
 	config     *config.Config
 	typeMapper *TypeMapper
 	log        logrus.FieldLogger
+	stats      WriteStats
+	tables     []*clickhouse.Table
+	lock       *fieldNumberLock                     // Field numbers of the lock strategy, nil until loaded
+	output     func(filename, content string) error // Replaces the filesystem when set
+	// protoSources holds the proto files written by this run, keyed by import path, when a
+	// descriptor set is requested
+	protoSources map[string]string
+	// deprecation matches the deprecation marker in comments, nil when disabled
+	deprecation *regexp.Regexp
+	// failed holds the tables left out of the run after their files failed to generate
+	failed []tableFailure
+	// skipped holds the tables left out for having no stored columns
+	skipped []tableFailure
+	// mu guards stats, protoSources and output while tables are generated concurrently
+	mu sync.Mutex
+}
+
+// WriteStats counts the files written during a Generate run
+type WriteStats struct {
+	// Changed is the number of files created or rewritten
+	Changed int
+	// Unchanged is the number of files skipped because their content was identical
+	Unchanged int
 }
 
 // shouldGenerateAPI determines if a table should have HTTP API endpoints
 func (g *Generator) shouldGenerateAPI(tableName string) bool {
 	// If API generation is disabled, don't generate HTTP annotations
-	if !g.config.EnableAPI {
+	if !g.config.EnableAPI || !g.config.Emits(config.EmitREST) {
 		return false
 	}
 
-	// If no prefixes specified, generate API for all tables
-	if len(g.config.APITablePrefixes) == 0 {
-		return true
-	}
-
-	// Check if table matches any allowed prefix
-	for _, prefix := range g.config.APITablePrefixes {
-		if strings.HasPrefix(tableName, prefix) {
-			return true
-		}
-	}
-
-	// Table doesn't match any prefix - skip API generation
-	return false
+	// Policies and api_table_prefixes pick the tables with an API
+	return g.config.Policy(tableName).API
 }
 
 // NewGenerator creates a new proto file generator
 func NewGenerator(cfg *config.Config, log logrus.FieldLogger) *Generator {
 	return &Generator{
-		config:     cfg,
-		typeMapper: NewTypeMapper(),
-		log:        log.WithField("component", "generator"),
+		config:      cfg,
+		typeMapper:  NewTypeMapper(),
+		log:         log.WithField("component", "generator"),
+		deprecation: compileDeprecationPattern(cfg.DeprecationPattern),
 	}
 }
 
 // Generate creates proto files for the given tables
 func (g *Generator) Generate(tables []*clickhouse.Table) error {
-	// Validate conversion configuration
-	g.validateConversionConfig(tables)
+	g.stats = WriteStats{}
+	g.protoSources = nil
+	g.failed = nil
+	g.skipped = nil
+
+	tables, err := g.prepare(tables)
+	if err != nil {
+		return err
+	}
 
-	// Ensure output directory exists
-	if err := os.MkdirAll(g.config.OutputDir, 0o750); err != nil {
+	// Ensure output directory exists, with the import prefix directory of the protos
+	if err := g.mkdirAll(g.protoDir()); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Always generate common.proto for service support
-	if err := g.GenerateCommonProto(); err != nil {
-		return fmt.Errorf("failed to generate common.proto: %w", err)
+	// Generate common.proto for service support
+	if g.config.Emits(config.EmitServices) {
+		if err := g.GenerateCommonProto(); err != nil {
+			return fmt.Errorf("failed to generate common.proto: %w", err)
+		}
 	}
 
 	// Generate clickhouse/annotations.proto for custom field options
-	if err := g.GenerateAnnotationsProto(); err != nil {
-		return fmt.Errorf("failed to generate annotations.proto: %w", err)
+	if g.config.Emits(config.EmitAnnotations) {
+		if err := g.GenerateAnnotationsProto(); err != nil {
+			return fmt.Errorf("failed to generate annotations.proto: %w", err)
+		}
+	}
+
+	// Copy the protos of google/protobuf and google/api into the output
+	if g.config.VendorImports {
+		if err := g.GenerateVendoredImports(); err != nil {
+			return fmt.Errorf("failed to vendor imports: %w", err)
+		}
+	}
+
+	// Tables whose files fail are left out of everything after, unless on_error is fail
+	tables, err = g.generateTableFiles(tables)
+	if err != nil {
+		return err
+	}
+	g.tables = tables
+
+	// Describe the generated tables in registry.proto and registry.go if enabled
+	if g.registryEnabled() {
+		if err := g.GenerateRegistry(tables); err != nil {
+			return fmt.Errorf("failed to generate registry: %w", err)
+		}
+	}
+
+	// Generate the umbrella Query service if enabled
+	if g.queryServiceEnabled() {
+		if err := g.GenerateQueryService(tables); err != nil {
+			return fmt.Errorf("failed to generate Query service: %w", err)
+		}
+	}
+
+	// Compile the protos into a descriptor set if requested
+	if g.config.DescriptorSetOut != "" {
+		if err := g.GenerateDescriptorSet(); err != nil {
+			return fmt.Errorf("failed to generate descriptor set: %w", err)
+		}
+	}
+
+	// Lay the Go output out as a standalone module if enabled
+	if g.config.GoModule.Enabled {
+		if err := g.GenerateGoModule(tables); err != nil {
+			return fmt.Errorf("failed to generate Go module: %w", err)
+		}
+	}
+
+	// Generate the SQL helper files shared by the tables, whose own helpers were written
+	// with their proto files
+	if g.config.Emits(config.EmitSQL) {
+		if err := g.generateSQLSharedFiles(tables); err != nil {
+			return fmt.Errorf("failed to generate SQL helpers: %w", err)
+		}
+	}
+
+	// Generate benchmarks of the SQL helpers if enabled
+	if g.config.Benchmarks.Enabled {
+		if err := g.GenerateBenchmarks(tables); err != nil {
+			return fmt.Errorf("failed to generate query benchmarks: %w", err)
+		}
+	}
+
+	// Generate observability middleware if enabled
+	if g.middlewareEnabled() {
+		if err := g.GenerateMiddleware(tables); err != nil {
+			return fmt.Errorf("failed to generate middleware: %w", err)
+		}
+	}
+
+	// Generate gRPC server scaffold if enabled
+	if g.config.Server.Enabled {
+		if err := g.GenerateServerScaffold(tables); err != nil {
+			return fmt.Errorf("failed to generate server scaffold: %w", err)
+		}
+	}
+
+	// Generate the service conformance tests if enabled
+	if g.config.Conformance.Enabled {
+		if err := g.GenerateConformance(tables); err != nil {
+			return fmt.Errorf("failed to generate conformance tests: %w", err)
+		}
+	}
+
+	// Generate the Go client of the services if enabled
+	if g.config.Client.Enabled {
+		if err := g.GenerateClient(tables); err != nil {
+			return fmt.Errorf("failed to generate Go client: %w", err)
+		}
+	}
+
+	// Generate Python models if enabled
+	if g.config.Python.Enabled {
+		if err := g.GeneratePython(tables); err != nil {
+			return fmt.Errorf("failed to generate Python models: %w", err)
+		}
+	}
+
+	// Generate Rust structs if enabled
+	if g.config.Rust.Enabled {
+		if err := g.GenerateRust(tables); err != nil {
+			return fmt.Errorf("failed to generate Rust structs: %w", err)
+		}
+	}
+
+	// Generate Java records if enabled
+	if g.config.Java.Enabled {
+		if err := g.GenerateJava(tables); err != nil {
+			return fmt.Errorf("failed to generate Java records: %w", err)
+		}
+	}
+
+	// Generate GraphQL schema and resolvers if enabled
+	if g.config.GraphQL.Enabled {
+		if err := g.GenerateGraphQL(tables); err != nil {
+			return fmt.Errorf("failed to generate GraphQL schema: %w", err)
+		}
+	}
+
+	// Generate Arrow schemas if enabled
+	if g.config.Arrow.Enabled {
+		if err := g.GenerateArrow(tables); err != nil {
+			return fmt.Errorf("failed to generate Arrow schemas: %w", err)
+		}
+	}
+
+	// Generate Avro schemas if enabled
+	if g.config.Avro.Enabled {
+		if err := g.GenerateAvro(tables); err != nil {
+			return fmt.Errorf("failed to generate Avro schemas: %w", err)
+		}
+	}
+
+	// Generate Parquet schemas and Iceberg table specs if enabled
+	if g.config.Parquet.Enabled {
+		if err := g.GenerateParquet(tables); err != nil {
+			return fmt.Errorf("failed to generate Parquet schemas: %w", err)
+		}
+	}
+
+	// List lossy mappings last so they aren't buried in per-file output
+	g.logLossyMappings(tables)
+
+	return nil
+}
+
+// prepare applies comment directives and view keys to the tables and validates them
+// against the configuration
+func (g *Generator) prepare(tables []*clickhouse.Table) ([]*clickhouse.Table, error) {
+	// Directives in column comments act as column overrides
+	cfg, tables, err := ApplyCommentDirectives(g.config, tables)
+	if err != nil {
+		return nil, err
+	}
+	g.config = cfg
+	tables = g.sanitizeComments(tables)
+
+	// Tables left without stored columns get no working service
+	tables = g.guardEmptyTables(g.applyComputedColumns(tables))
+
+	// Views only get a service when a pseudo primary key is configured for them
+	tables, err = g.ApplyViewKeys(tables)
+	if err != nil {
+		return nil, err
+	}
+	// Policies may take the service away, leaving only the message
+	tables = g.applyServicePolicies(tables)
+	g.tables = tables
+
+	// Table proto files are named after the tables, so they can't be the shared files
+	if err := g.validateTableFiles(tables); err != nil {
+		return nil, err
+	}
+
+	// Validate conversion configuration
+	g.validateConversionConfig(tables)
+
+	// Every table with a service must be scopable when tenant isolation is enabled
+	if err := g.validateTenantScopes(tables); err != nil {
+		return nil, fmt.Errorf("invalid tenant configuration: %w", err)
+	}
+
+	// Renamed columns keep their original field, which no other column may have
+	if err := g.validateRenamedColumns(tables); err != nil {
+		return nil, fmt.Errorf("invalid renamed columns: %w", err)
+	}
+
+	// Masked columns are dropped from request filters, which primary keys can't be
+	if err := g.validateMasks(tables); err != nil {
+		return nil, fmt.Errorf("invalid column masks: %w", err)
+	}
+
+	if err := g.validateColumnTypes(tables); err != nil {
+		return nil, fmt.Errorf("invalid column types: %w", err)
+	}
+
+	if err := g.validateColumnEncodings(tables); err != nil {
+		return nil, fmt.Errorf("invalid column encodings: %w", err)
 	}
 
-	// Generate separate file for each table (includes both message and service)
+	if err := g.validateJSONNames(tables); err != nil {
+		return nil, fmt.Errorf("invalid json names: %w", err)
+	}
+
+	// Tiered tables are split by comparing a timestamp column with the cutoff
+	if err := g.validateTiers(tables); err != nil {
+		return nil, fmt.Errorf("invalid tiers: %w", err)
+	}
+
+	// Strict mode refuses to generate types that lose information
+	if err := g.checkStrictMappings(tables); err != nil {
+		return nil, fmt.Errorf("strict mode:\n%w", err)
+	}
+
+	// The lock strategy numbers fields from the lock of the previous run
+	if err := g.LoadFieldNumberLock(); err != nil {
+		return nil, err
+	}
+	if err := g.validateReserved(tables); err != nil {
+		return nil, fmt.Errorf("invalid reserved fields: %w", err)
+	}
+
+	return tables, nil
+}
+
+// TableProto renders the proto file of a single table without writing any files. The
+// field number lock is read but not updated, so columns new to the lock are numbered as
+// the next Generate run would number them.
+func (g *Generator) TableProto(table *clickhouse.Table) (string, error) {
+	tables, err := g.prepare([]*clickhouse.Table{table})
+	if err != nil {
+		return "", err
+	}
+	if len(tables) == 0 {
+		return "", fmt.Errorf("%s: %w", table.Name, ErrNoStoredColumns)
+	}
+	if err := g.validateSortingKey(tables[0]); err != nil {
+		return "", err
+	}
+	return g.tableProtoContent(tables[0]), nil
+}
+
+// generateTableFiles writes the files of each table, its proto file (message and service)
+// and SQL helpers, on up to concurrency tables at once. It returns the tables that were
+// generated and updates the field number lock with the numbers they use.
+func (g *Generator) generateTableFiles(tables []*clickhouse.Table) ([]*clickhouse.Table, error) {
+	if !g.config.Emits(config.EmitMessages) {
+		return tables, nil
+	}
+
+	// The lock strategy numbers new columns as it sees them, so every table is numbered
+	// before they are generated concurrently
 	for _, table := range tables {
+		g.fieldNumbers(table)
+	}
+	if g.config.Emits(config.EmitSQL) {
+		if err := g.mkdirAll(g.goOutputDir()); err != nil {
+			return nil, fmt.Errorf("failed to create Go output directory: %w", err)
+		}
+	}
+
+	generated, err := g.forEachTable(tables, func(table *clickhouse.Table) error {
+		if err := g.validateSortingKey(table); err != nil {
+			return err
+		}
 		if err := g.generateTableFile(table); err != nil {
-			g.log.WithError(err).WithFields(logrus.Fields{
-				"database": table.Database,
-				"table":    table.Name,
-			}).Error("Failed to generate proto file")
 			return err
 		}
+		if g.config.Emits(config.EmitSQL) && g.hasSQLHelper(table) {
+			return g.generateSQLHelper(table)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate SQL helper files
-	if err := g.GenerateSQLHelpers(tables); err != nil {
-		return fmt.Errorf("failed to generate SQL helpers: %w", err)
-	}
+	return generated, g.writeFieldNumberLock()
+}
 
-	return nil
+// protoDir returns the directory proto files are written to: output_dir, or the directory
+// of import_prefix below it
+func (g *Generator) protoDir() string {
+	return filepath.Join(g.config.OutputDir, filepath.FromSlash(g.config.ImportPrefix))
+}
+
+// protoImport returns the path generated protos import a generated proto file by
+func (g *Generator) protoImport(name string) string {
+	return path.Join(g.config.ImportPrefix, name)
 }
 
 func (g *Generator) generateTableFile(table *clickhouse.Table) error {
-	filename := filepath.Join(g.config.OutputDir,
+	filename := filepath.Join(g.protoDir(),
 		fmt.Sprintf("%s.proto", strings.ToLower(table.Name)))
 
+	return g.writeFile(filename, g.tableProtoContent(table))
+}
+
+// tableProtoContent renders the proto file of a table: its message, visibility profile
+// messages and, for tables with a sorting key, the service with its request and response messages
+func (g *Generator) tableProtoContent(table *clickhouse.Table) string {
 	var sb strings.Builder
 
-	// Check if this table needs wrapper types
-	needsWrapper := g.checkNeedsWrapper([]*clickhouse.Table{table})
 	// Check if service generation will need additional imports
-	hasService := len(table.SortingKey) > 0
+	hasService := len(table.SortingKey) > 0 && g.config.Emits(config.EmitServices)
+	// Check if this table needs wrapper types
+	needsWrapper := g.tableNeedsWrapperForMessage(table)
+	if hasService {
+		needsWrapper = g.checkNeedsWrapper([]*clickhouse.Table{table})
+	}
 	g.writeTableHeader(&sb, needsWrapper, hasService, table)
 
 	// Write the message definition
 	g.writeMessage(&sb, table)
 
+	// Write one message per visibility profile
+	g.writeVisibilityMessages(&sb, table)
+
 	// Write service definitions if table has sorting keys
 	if hasService {
 		g.writeServiceDefinitions(&sb, table)
 	}
 
-	return g.writeFile(filename, sb.String())
+	return g.applySyntax(sb.String())
 }
 
 func (g *Generator) checkNeedsWrapper(tables []*clickhouse.Table) bool {
@@ -142,6 +449,10 @@ func (g *Generator) checkNeedsWrapper(tables []*clickhouse.Table) bool {
 
 // tableNeedsWrapperForMessage checks if a table's nullable columns need wrapper types
 func (g *Generator) tableNeedsWrapperForMessage(table *clickhouse.Table) bool {
+	// Edition 2023 messages give nullable fields explicit presence instead
+	if g.config.Syntax == config.SyntaxEdition2023 {
+		return false
+	}
 	for _, column := range table.Columns {
 		if column.IsNullable && !column.IsArray {
 			// Check if the type would use a wrapper
@@ -161,6 +472,11 @@ func (g *Generator) tableNeedsWrapperForService(table *clickhouse.Table) bool {
 		return false
 	}
 
+	// ListBuckets returns the minimum and maximum as DoubleValue
+	if g.bucketColumn(table) != nil {
+		return true
+	}
+
 	// Check all columns that will be in the request message
 	for _, column := range table.Columns {
 		// Skip arrays - they use repeated, not wrappers
@@ -182,7 +498,7 @@ func (g *Generator) tableNeedsWrapperForService(table *clickhouse.Table) bool {
 }
 
 func (g *Generator) writeTableHeader(sb *strings.Builder, needsWrapper, hasService bool, table *clickhouse.Table) {
-	sb.WriteString("syntax = \"proto3\";\n\n")
+	g.writeSyntax(sb)
 
 	if g.config.Package != "" {
 		fmt.Fprintf(sb, "package %s;\n", g.config.Package)
@@ -190,7 +506,7 @@ func (g *Generator) writeTableHeader(sb *strings.Builder, needsWrapper, hasServi
 
 	// Add imports
 	if hasService {
-		sb.WriteString("\nimport \"common.proto\";\n")
+		fmt.Fprintf(sb, "\nimport \"%s\";\n", g.protoImport("common.proto"))
 	}
 	if needsWrapper {
 		sb.WriteString("import \"google/protobuf/wrappers.proto\";\n")
@@ -200,13 +516,21 @@ func (g *Generator) writeTableHeader(sb *strings.Builder, needsWrapper, hasServi
 	if hasService && g.shouldGenerateAPI(table.Name) {
 		sb.WriteString("import \"google/api/annotations.proto\";\n")
 		sb.WriteString("import \"google/api/field_behavior.proto\";\n")
-		// Always import annotations for uniform required_group handling
-		sb.WriteString("import \"clickhouse/annotations.proto\";\n")
+	}
+	// Messages and services carry the clickhouse.v1 source options
+	if g.config.Emits(config.EmitAnnotations) {
+		fmt.Fprintf(sb, "import \"%s\";\n", g.protoImport("clickhouse/annotations.proto"))
 	}
 
-	if g.config.GoPackage != "" {
-		fmt.Fprintf(sb, "\noption go_package = \"%s\";\n", g.config.GoPackage)
+	goPackage := g.goPackage()
+	if goPackage != "" || g.hasLanguageOptions() {
+		sb.WriteString("\n")
 	}
+	if goPackage != "" {
+		fmt.Fprintf(sb, "option go_package = \"%s\";\n", goPackage)
+	}
+	g.writeLanguageOptions(sb, false)
+	g.writeFileFeatures(sb)
 }
 
 func (g *Generator) writeMessage(sb *strings.Builder, table *clickhouse.Table) {
@@ -216,21 +540,63 @@ func (g *Generator) writeMessage(sb *strings.Builder, table *clickhouse.Table) {
 	if g.config.IncludeComments && table.Comment != "" {
 		g.writeComment(sb, table.Comment, "")
 	}
+	g.writeDDLExcerpt(sb, table)
 
 	fmt.Fprintf(sb, "\nmessage %s {\n", messageName)
+	g.writeMessageDeprecation(sb, table)
+	g.writeSourceOption(sb, table, "table")
+
+	fields, omitted := g.messageFields(table)
+	for _, field := range fields {
+		g.writeField(sb, field)
+	}
 
-	// Process columns
+	for _, number := range omitted {
+		fmt.Fprintf(sb, "  reserved %d; // Omitted by column mask\n", number)
+	}
+
+	g.writeReserved(sb, table)
+
+	sb.WriteString("}\n")
+}
+
+// MessageFields returns the fields of a table's message as they are written to its proto file
+func (g *Generator) MessageFields(table *clickhouse.Table) []*ProtoField {
+	fields, _ := g.messageFields(table)
+	return fields
+}
+
+// messageFields converts a table's columns to message fields, returning the field numbers
+// of omitted columns separately so they can be reserved
+func (g *Generator) messageFields(table *clickhouse.Table) (fields []*ProtoField, omitted []int32) {
+	numbers := g.fieldNumbers(table)
 	for _, column := range table.Columns {
 		field, err := g.typeMapper.ConvertColumn(&column, table.Name, &g.config.Conversion)
 		if err != nil {
 			g.log.WithError(err).WithField("column", column.Name).Warn("Failed to convert column")
 			continue
 		}
+		field.Number = numbers[column.Name]
+		g.applyRenameToField(field, &column, table.Name)
 
-		g.writeField(sb, field)
+		// Omitted columns never reach the API, but their field numbers stay reserved
+		if g.isOmitted(table.Name, column.Name) {
+			omitted = append(omitted, field.Number)
+			continue
+		}
+
+		g.applyTypeOverride(field, &column, table.Name)
+		g.applyMaskToField(field, &column, table.Name)
+		g.applyUnitToField(field, &column, table.Name)
+		g.applyPresenceToField(field)
+		g.applyEncodingToField(field, &column, table.Name)
+		g.applyJSONNameToField(field, &column, table.Name)
+		g.applyDeprecation(field, &column)
+		fields = append(fields, field)
 	}
 
-	sb.WriteString("}\n")
+	g.sortFields(fields)
+	return fields, omitted
 }
 
 func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhouse.Table) {
@@ -246,8 +612,6 @@ func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhou
 		table.Name)
 	fmt.Fprintf(sb, "message List%sRequest {\n", messageName)
 
-	fieldNumber := 1
-
 	// Get column info for sorting keys
 	columnMap := make(map[string]*clickhouse.Column)
 	for i := range table.Columns {
@@ -255,21 +619,7 @@ func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhou
 		columnMap[col.Name] = col
 	}
 
-	// Track which columns have been processed
-	processedColumns := make(map[string]bool)
-
-	// Process primary key (first sorting column) - REQUIRED
-	if len(table.SortingKey) > 0 {
-		fieldNumber = g.writePrimaryKeyField(sb, table.SortingKey[0], columnMap, processedColumns, fieldNumber, table)
-	}
-
-	// Process remaining sorting columns - OPTIONAL
-	for i := 1; i < len(table.SortingKey); i++ {
-		fieldNumber = g.writeSortingKeyField(sb, table.SortingKey[i], columnMap, processedColumns, fieldNumber, i+1, table.Name)
-	}
-
-	// Process all other columns - OPTIONAL
-	fieldNumber = g.writeRemainingColumnFilters(sb, table, processedColumns, fieldNumber)
+	fieldNumber := g.writeRequestFilterFields(sb, table, columnMap)
 
 	// Add pagination fields (AIP-132 standard)
 	fmt.Fprintf(sb, "\n  // The maximum number of %s to return.\n", table.Name)
@@ -299,6 +649,23 @@ func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhou
 	} else {
 		fmt.Fprintf(sb, "  string order_by = %d;\n", fieldNumber)
 	}
+
+	// Add mandatory tenant field when tenant isolation is enabled
+	tenant, _ := g.tenantScopeFor(table)
+	if tenant != nil {
+		fieldNumber++
+		g.writeTenantRequestField(sb, table, tenant, fieldNumber)
+	}
+
+	// After the tenant field, so adding these didn't renumber it
+	g.writeLimitByFields(sb, table, fieldNumber+1)
+	if table.SamplingKey != "" {
+		g.writeSampleField(sb, table, fieldNumber+3)
+	}
+	if column := g.snapshotColumn(table); column != nil {
+		g.writeAsOfField(sb, table, column, fieldNumber+4)
+	}
+	g.writeSkipDefaultWhereField(sb, table, fieldNumber+5)
 	sb.WriteString("}\n\n")
 
 	// Write response message
@@ -310,6 +677,7 @@ func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhou
 	fmt.Fprintf(sb, "  // A token, which can be sent as `page_token` to retrieve the next page.\n")
 	fmt.Fprintf(sb, "  // If this field is omitted, there are no subsequent pages.\n")
 	fmt.Fprintf(sb, "  string next_page_token = 2;\n")
+	g.writeResponseMetaField(sb)
 	sb.WriteString("}\n\n")
 
 	// Write Get request message (takes only primary key)
@@ -320,7 +688,7 @@ func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhou
 	// Add only the primary key field for Get request
 	primaryKey := table.SortingKey[0]
 	if column, exists := columnMap[primaryKey]; exists {
-		primaryKeyField := SanitizeName(primaryKey)
+		primaryKeyField := g.fieldName(table.Name, primaryKey)
 
 		// Get the base proto type (not filter type) for the primary key
 		protoType, _ := g.typeMapper.MapType(column, table.Name, &g.config.Conversion)
@@ -333,6 +701,10 @@ func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhou
 		// Primary key as a simple scalar value
 		fmt.Fprintf(sb, "  %s %s = 1; // Primary key (required)\n", protoType, primaryKeyField)
 	}
+	if tenant != nil {
+		g.writeTenantRequestField(sb, table, tenant, 2)
+	}
+	g.writeSkipDefaultWhereField(sb, table, 3)
 	sb.WriteString("}\n\n")
 
 	// Write Get response message
@@ -342,17 +714,34 @@ func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhou
 	fmt.Fprintf(sb, "  %s item = 1;\n", messageName)
 	sb.WriteString("}\n\n")
 
-	// Write service definition with both List and Get
+	// Write ListBuckets messages of time-series tables
+	g.writeBucketMessages(sb, table, columnMap, tenant)
+
+	// Write Insert messages of tables accepting writes
+	g.writeInsertMessages(sb, table, tenant)
+
+	// Write Delete and Update messages when mutations are enabled
+	g.writeMutationMessages(sb, table, columnMap, tenant)
+
+	// Write service definition with List, Get and the optional RPCs
 	fmt.Fprintf(sb, "// Query %s data\n",
 		table.Name)
 	fmt.Fprintf(sb, "service %sService {\n", messageName)
+	g.writeSourceOption(sb, table, "service_table")
+	g.writeCacheTTLOption(sb, table)
+
+	deprecationComment, deprecationOption := g.rpcDeprecation(table)
 
 	// Check if this table should have HTTP annotations
 	if g.shouldGenerateAPI(table.Name) {
 		// Generate List RPC WITH HTTP annotations
 		fmt.Fprintf(sb, "  // List records | Retrieve paginated results with optional filtering\n")
+		g.writeRPCExamples(sb, table, "List", fmt.Sprintf("%s/%s", g.config.APIBasePath, table.Name),
+			g.listExampleParams(table, columnMap, exampleParam{path: "page_size", value: "10", json: "10"}))
+		sb.WriteString(deprecationComment)
 		fmt.Fprintf(sb, "  rpc List(List%sRequest) returns (List%sResponse) {\n",
 			messageName, messageName)
+		sb.WriteString(deprecationOption)
 		fmt.Fprintf(sb, "    option (google.api.http) = {\n")
 		fmt.Fprintf(sb, "      get: \"%s/%s\"\n", g.config.APIBasePath, table.Name)
 		fmt.Fprintf(sb, "    };\n")
@@ -360,11 +749,15 @@ func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhou
 
 		// Generate Get RPC WITH HTTP annotations
 		primaryKey := table.SortingKey[0]
-		primaryKeyField := SanitizeName(primaryKey)
+		primaryKeyField := g.fieldName(table.Name, primaryKey)
 		fmt.Fprintf(sb, "  // Get record | Retrieve a single record by %s\n",
 			primaryKey)
+		getPath, getParams := g.getExampleParams(table, columnMap)
+		g.writeRPCExamples(sb, table, "Get", getPath, getParams)
+		sb.WriteString(deprecationComment)
 		fmt.Fprintf(sb, "  rpc Get(Get%sRequest) returns (Get%sResponse) {\n",
 			messageName, messageName)
+		sb.WriteString(deprecationOption)
 		fmt.Fprintf(sb, "    option (google.api.http) = {\n")
 		fmt.Fprintf(sb, "      get: \"%s/%s/{%s}\"\n", g.config.APIBasePath, table.Name, primaryKeyField)
 		fmt.Fprintf(sb, "    };\n")
@@ -372,14 +765,89 @@ func (g *Generator) writeServiceDefinitions(sb *strings.Builder, table *clickhou
 	} else {
 		// Generate List RPC WITHOUT HTTP annotations (basic gRPC only)
 		fmt.Fprintf(sb, "  // List records | Retrieve paginated results with optional filtering\n")
-		fmt.Fprintf(sb, "  rpc List(List%sRequest) returns (List%sResponse);\n",
-			messageName, messageName)
+		sb.WriteString(deprecationComment)
+		writeRPC(sb, "List", messageName, deprecationOption)
 		fmt.Fprintf(sb, "  // Get record | Retrieve a single record by primary key\n")
-		fmt.Fprintf(sb, "  rpc Get(Get%sRequest) returns (Get%sResponse);\n",
-			messageName, messageName)
+		sb.WriteString(deprecationComment)
+		writeRPC(sb, "Get", messageName, deprecationOption)
 	}
+	g.writeBucketRPC(sb, table, columnMap, deprecationComment, deprecationOption)
+	g.writeInsertRPC(sb, table, deprecationComment, deprecationOption)
+	g.writeMutationRPCs(sb, table, deprecationComment, deprecationOption)
 
 	sb.WriteString("}\n")
+
+	// Write the services of the label values with the List and Get RPCs
+	g.writeLabelServices(sb, table, deprecationComment, deprecationOption)
+}
+
+// writeRequestFilterFields writes the filter fields of a request over the table's rows,
+// numbered from 1, and returns the next field number
+func (g *Generator) writeRequestFilterFields(sb *strings.Builder, table *clickhouse.Table, columnMap map[string]*clickhouse.Column) int {
+	fieldNumber := 1
+
+	// Track which columns have been processed
+	processedColumns := make(map[string]bool)
+
+	// Process primary key (first sorting column) - REQUIRED
+	if len(table.SortingKey) > 0 {
+		fieldNumber = g.writePrimaryKeyField(sb, table.SortingKey[0], columnMap, processedColumns, fieldNumber, table)
+	}
+
+	// Masked columns can't be filtered on, as filters would reveal their values
+	for _, column := range table.Columns {
+		if g.isMasked(table.Name, column.Name) {
+			processedColumns[column.Name] = true
+		}
+	}
+
+	// Process remaining sorting columns - OPTIONAL
+	for i := 1; i < len(table.SortingKey); i++ {
+		if g.isMasked(table.Name, table.SortingKey[i]) {
+			continue
+		}
+		fieldNumber = g.writeSortingKeyField(sb, table.SortingKey[i], columnMap, processedColumns, fieldNumber, i+1, table.Name)
+	}
+
+	// Process all other columns - OPTIONAL
+	return g.writeRemainingColumnFilters(sb, table, processedColumns, fieldNumber)
+}
+
+// writeLimitByFields writes the distinct_on and limit_by fields of a List request, which
+// select ClickHouse's LIMIT n BY, e.g. the latest row per key with a descending order_by
+func (g *Generator) writeLimitByFields(sb *strings.Builder, table *clickhouse.Table, fieldNumber int) {
+	behavior := ""
+	if g.shouldGenerateAPI(table.Name) {
+		behavior = " [(google.api.field_behavior) = OPTIONAL]"
+	}
+	fmt.Fprintf(sb, "  // Fields whose distinct combinations each return only their first `limit_by` rows,\n")
+	fmt.Fprintf(sb, "  // in the order of `order_by`; with a descending order_by, the latest rows per key.\n")
+	fmt.Fprintf(sb, "  repeated string distinct_on = %d%s;\n", fieldNumber, behavior)
+	fmt.Fprintf(sb, "  // The number of rows returned per combination of `distinct_on`. Defaults to 1.\n")
+	fmt.Fprintf(sb, "  int32 limit_by = %d%s;\n", fieldNumber+1, behavior)
+}
+
+// writeSampleField writes the sample field of a List request, which reads a fraction of the
+// rows with ClickHouse's SAMPLE. Only tables with a SAMPLE BY key have it.
+func (g *Generator) writeSampleField(sb *strings.Builder, table *clickhouse.Table, fieldNumber int) {
+	behavior := ""
+	if g.shouldGenerateAPI(table.Name) {
+		behavior = " [(google.api.field_behavior) = OPTIONAL]"
+	}
+	fmt.Fprintf(sb, "  // Fraction of rows to read, between 0 and 1, e.g. 0.1 for about a tenth of them.\n")
+	fmt.Fprintf(sb, "  // Results are approximate. The same fraction reads the same rows, so pages stay consistent.\n")
+	fmt.Fprintf(sb, "  double sample = %d%s;\n", fieldNumber, behavior)
+}
+
+// writeRPC writes an RPC without HTTP annotations, with a body only when it has options
+func writeRPC(sb *strings.Builder, method, messageName, options string) {
+	if options == "" {
+		fmt.Fprintf(sb, "  rpc %s(%s%sRequest) returns (%s%sResponse);\n", method, method, messageName, method, messageName)
+		return
+	}
+	fmt.Fprintf(sb, "  rpc %s(%s%sRequest) returns (%s%sResponse) {\n", method, method, messageName, method, messageName)
+	sb.WriteString(options)
+	sb.WriteString("  }\n")
 }
 
 // writePrimaryKeyField writes the primary key field for service request
@@ -425,13 +893,13 @@ func (g *Generator) writePrimaryKeyField(sb *strings.Builder, sortCol string, co
 			// Mark as OPTIONAL when projections exist, REQUIRED otherwise
 			if len(projectionAlternatives) > 0 {
 				fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL, (clickhouse.v1.required_group) = \"primary_key\"];\n",
-					filterType, SanitizeName(sortCol), fieldNumber)
+					filterType, g.fieldName(table.Name, sortCol), fieldNumber)
 			} else {
 				fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = REQUIRED, (clickhouse.v1.required_group) = \"primary_key\"];\n",
-					filterType, SanitizeName(sortCol), fieldNumber)
+					filterType, g.fieldName(table.Name, sortCol), fieldNumber)
 			}
 		} else {
-			fmt.Fprintf(sb, "  %s %s = %d;\n", filterType, SanitizeName(sortCol), fieldNumber)
+			fmt.Fprintf(sb, "  %s %s = %d;\n", filterType, g.fieldName(table.Name, sortCol), fieldNumber)
 		}
 		fieldNumber++
 		fmt.Fprintf(sb, "\n")
@@ -443,13 +911,13 @@ func (g *Generator) writePrimaryKeyField(sb *strings.Builder, sortCol string, co
 			// Always include required_group annotation for uniform handling
 			if len(projectionAlternatives) > 0 {
 				fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL, (clickhouse.v1.required_group) = \"primary_key\"];\n",
-					protoType, SanitizeName(sortCol), fieldNumber)
+					protoType, g.fieldName(table.Name, sortCol), fieldNumber)
 			} else {
 				fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = REQUIRED, (clickhouse.v1.required_group) = \"primary_key\"];\n",
-					protoType, SanitizeName(sortCol), fieldNumber)
+					protoType, g.fieldName(table.Name, sortCol), fieldNumber)
 			}
 		} else {
-			fmt.Fprintf(sb, "  %s %s = %d;\n", protoType, SanitizeName(sortCol), fieldNumber)
+			fmt.Fprintf(sb, "  %s %s = %d;\n", protoType, g.fieldName(table.Name, sortCol), fieldNumber)
 		}
 		fieldNumber++
 		fmt.Fprintf(sb, "\n")
@@ -480,9 +948,9 @@ func (g *Generator) writeSortingKeyField(sb *strings.Builder, sortCol string, co
 	if filterType != "" {
 		fmt.Fprintf(sb, "  // %s\n", comment)
 		if g.shouldGenerateAPI(tableName) {
-			fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL];\n", filterType, SanitizeName(sortCol), fieldNumber)
+			fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL];\n", filterType, g.fieldName(tableName, sortCol), fieldNumber)
 		} else {
-			fmt.Fprintf(sb, "  %s %s = %d;\n", filterType, SanitizeName(sortCol), fieldNumber)
+			fmt.Fprintf(sb, "  %s %s = %d;\n", filterType, g.fieldName(tableName, sortCol), fieldNumber)
 		}
 		fieldNumber++
 		fmt.Fprintf(sb, "\n")
@@ -494,12 +962,12 @@ func (g *Generator) writeSortingKeyField(sb *strings.Builder, sortCol string, co
 			// Don't add OPTIONAL to repeated fields - arrays are never null, just empty
 			//nolint:gocritic // switch adds nothing here.
 			if strings.HasPrefix(wrapperType, "repeated ") {
-				fmt.Fprintf(sb, "  %s %s = %d;\n", wrapperType, SanitizeName(sortCol), fieldNumber)
+				fmt.Fprintf(sb, "  %s %s = %d;\n", wrapperType, g.fieldName(tableName, sortCol), fieldNumber)
 			} else {
-				fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL];\n", wrapperType, SanitizeName(sortCol), fieldNumber)
+				fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL];\n", wrapperType, g.fieldName(tableName, sortCol), fieldNumber)
 			}
 		} else {
-			fmt.Fprintf(sb, "  %s %s = %d;\n", wrapperType, SanitizeName(sortCol), fieldNumber)
+			fmt.Fprintf(sb, "  %s %s = %d;\n", wrapperType, g.fieldName(tableName, sortCol), fieldNumber)
 		}
 		fieldNumber++
 		fmt.Fprintf(sb, "\n")
@@ -549,12 +1017,12 @@ func (g *Generator) writeRemainingColumnFilters(sb *strings.Builder, table *clic
 				// Add projection annotations if this is a projection key
 				if projectionInfo != nil {
 					fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL, (clickhouse.v1.projection_name) = \"%s\", (clickhouse.v1.projection_alternative_for) = \"%s\", (clickhouse.v1.required_group) = \"primary_key\"];\n",
-						filterType, SanitizeName(column.Name), fieldNumber, projectionInfo.Name, basePrimaryKey)
+						filterType, g.fieldName(table.Name, column.Name), fieldNumber, projectionInfo.Name, basePrimaryKey)
 				} else {
-					fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL];\n", filterType, SanitizeName(column.Name), fieldNumber)
+					fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL];\n", filterType, g.fieldName(table.Name, column.Name), fieldNumber)
 				}
 			} else {
-				fmt.Fprintf(sb, "  %s %s = %d;\n", filterType, SanitizeName(column.Name), fieldNumber)
+				fmt.Fprintf(sb, "  %s %s = %d;\n", filterType, g.fieldName(table.Name, column.Name), fieldNumber)
 			}
 			fieldNumber++
 		} else {
@@ -565,15 +1033,15 @@ func (g *Generator) writeRemainingColumnFilters(sb *strings.Builder, table *clic
 				// Don't add OPTIONAL to repeated fields - arrays are never null, just empty
 				//nolint:gocritic // switch adds nothing here.
 				if strings.HasPrefix(wrapperType, "repeated ") {
-					fmt.Fprintf(sb, "  %s %s = %d;\n", wrapperType, SanitizeName(column.Name), fieldNumber)
+					fmt.Fprintf(sb, "  %s %s = %d;\n", wrapperType, g.fieldName(table.Name, column.Name), fieldNumber)
 				} else if projectionInfo != nil {
 					fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL, (clickhouse.v1.projection_name) = \"%s\", (clickhouse.v1.projection_alternative_for) = \"%s\", (clickhouse.v1.required_group) = \"primary_key\"];\n",
-						wrapperType, SanitizeName(column.Name), fieldNumber, projectionInfo.Name, basePrimaryKey)
+						wrapperType, g.fieldName(table.Name, column.Name), fieldNumber, projectionInfo.Name, basePrimaryKey)
 				} else {
-					fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL];\n", wrapperType, SanitizeName(column.Name), fieldNumber)
+					fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = OPTIONAL];\n", wrapperType, g.fieldName(table.Name, column.Name), fieldNumber)
 				}
 			} else {
-				fmt.Fprintf(sb, "  %s %s = %d;\n", wrapperType, SanitizeName(column.Name), fieldNumber)
+				fmt.Fprintf(sb, "  %s %s = %d;\n", wrapperType, g.fieldName(table.Name, column.Name), fieldNumber)
 			}
 			fieldNumber++
 		}
@@ -600,6 +1068,11 @@ func (g *Generator) writeField(sb *strings.Builder, field *ProtoField) {
 	}
 
 	// No need for optional modifier when using wrapper types
+	if len(field.Options) > 0 {
+		fmt.Fprintf(sb, "  %s %s = %d [%s];\n",
+			field.Type, field.Name, field.Number, strings.Join(field.Options, ", "))
+		return
+	}
 	fmt.Fprintf(sb, "  %s %s = %d;\n",
 		field.Type, field.Name, field.Number)
 }
@@ -617,15 +1090,92 @@ func (g *Generator) writeComment(sb *strings.Builder, comment, indent string) {
 	}
 }
 
+// SetOutput sends generated files to write instead of the filesystem. The protoc plugin
+// uses it to return files in its response.
+func (g *Generator) SetOutput(write func(filename, content string) error) {
+	g.output = write
+}
+
+// Stats returns the file write counts of the last Generate run
+func (g *Generator) Stats() WriteStats {
+	return g.stats
+}
+
+// writeFile writes a generated file, skipping the write when the file already has identical
+// content so mtimes stay stable and downstream builds aren't retriggered
 func (g *Generator) writeFile(filename, content string) error {
-	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+	if filepath.Ext(filename) == ".go" {
+		formatted, err := formatGoSource(filename, content)
+		if err != nil {
+			return err
+		}
+		content = formatted
+	}
+
+	g.mu.Lock()
+	g.recordProtoSource(filename, content)
+	if g.output != nil {
+		defer g.mu.Unlock()
+		g.stats.Changed++
+		return g.output(filename, content)
+	}
+	g.mu.Unlock()
+
+	data := []byte(content)
+
+	if existing, err := os.ReadFile(filename); err == nil && bytes.Equal(existing, data) {
+		g.countWrite(&g.stats.Unchanged)
+		g.log.WithField("file", filename).Debug("Generated file unchanged, skipping write")
+		return nil
+	}
+
+	if err := os.WriteFile(filename, data, 0o600); err != nil {
 		return fmt.Errorf("failed to write file %s: %w", filename, err)
 	}
 
+	g.countWrite(&g.stats.Changed)
 	g.log.WithField("file", filename).Info("Generated proto file")
 	return nil
 }
 
+// countWrite increments a write count of the stats
+func (g *Generator) countWrite(count *int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*count++
+}
+
+// writeFileIfMissing writes a user-editable scaffold file only when it doesn't exist yet
+func (g *Generator) writeFileIfMissing(filename, content string) error {
+	if _, err := os.Stat(filename); err == nil {
+		g.log.WithField("file", filename).Debug("Scaffold file already exists, leaving untouched")
+		return nil
+	}
+
+	return g.writeFile(filename, content)
+}
+
+// mkdirAll creates an output directory and its parents. Nothing is created when the files
+// are sent to SetOutput's function.
+func (g *Generator) mkdirAll(dir string) error {
+	if g.output != nil {
+		return nil
+	}
+	return os.MkdirAll(dir, 0o750)
+}
+
+// removeStaleFile removes a file an earlier run generated that this run doesn't, if there
+// is one. Nothing is removed when the files are sent to SetOutput's function.
+func (g *Generator) removeStaleFile(filename string) error {
+	if g.output != nil {
+		return nil
+	}
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 // getProtoType returns the proto type for a ClickHouse base type
 func getProtoType(baseType string) string {
 	switch baseType {
@@ -686,7 +1236,8 @@ func (g *Generator) buildTableColumnsMap(tables []*clickhouse.Table) map[string]
 
 // validateTableScopedConversions validates table-scoped bigint-to-string conversions
 func (g *Generator) validateTableScopedConversions(convConfig *config.ConversionConfig, tableColumns map[string]map[string]*clickhouse.Column) {
-	for tableName, fieldNames := range convConfig.BigIntToString {
+	for _, tableName := range slices.Sorted(maps.Keys(convConfig.BigIntToString)) {
+		fieldNames := convConfig.BigIntToString[tableName]
 		colMap, tableExists := tableColumns[tableName]
 		if !tableExists {
 			g.log.WithField("table", tableName).Warn("Table specified in bigint_to_string conversion config not found in tables being generated")
@@ -736,7 +1287,8 @@ func (g *Generator) validateCLIPatterns(convConfig *config.ConversionConfig, tab
 func (g *Generator) validatePattern(pattern, tablePattern, fieldPattern string, tableColumns map[string]map[string]*clickhouse.Column) {
 	found := false
 
-	for tableName, colMap := range tableColumns {
+	for _, tableName := range slices.Sorted(maps.Keys(tableColumns)) {
+		colMap := tableColumns[tableName]
 		// Check if table matches pattern
 		if !g.tableMatchesPattern(tablePattern, tableName) {
 			continue
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
 	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
@@ -105,6 +106,126 @@ func TestGenerator_Generate(t *testing.T) {
 	assert.Contains(t, contentStr, "message ListUsersResponse")
 }
 
+func TestGenerator_GenerateSkipsUnchangedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tempDir
+
+	log := logrus.New()
+	log.SetLevel(logrus.WarnLevel)
+
+	tables := []*clickhouse.Table{
+		{
+			Name: "users",
+			Columns: []clickhouse.Column{
+				{Name: "id", Type: "UInt64", BaseType: "UInt64", Position: 1},
+			},
+			SortingKey: []string{"id"},
+		},
+	}
+
+	gen := NewGenerator(cfg, log)
+	require.NoError(t, gen.Generate(tables))
+
+	first := gen.Stats()
+	assert.Positive(t, first.Changed)
+	assert.Zero(t, first.Unchanged)
+
+	// Age the table file so a rewrite would be visible in its mtime
+	usersProtoPath := filepath.Join(tempDir, "users.proto")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(usersProtoPath, past, past))
+
+	require.NoError(t, gen.Generate(tables))
+	assert.Equal(t, WriteStats{Unchanged: first.Changed}, gen.Stats())
+
+	info, err := os.Stat(usersProtoPath)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(past), "unchanged file must not be rewritten")
+
+	// Content changes are written again
+	tables[0].Comment = "User accounts"
+	require.NoError(t, gen.Generate(tables))
+	assert.Equal(t, 1, gen.Stats().Changed)
+}
+
+func TestGenerator_TableProto(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "proto")
+	cfg := config.NewConfig()
+	cfg.OutputDir = outputDir
+	cfg.FieldNumbers.Strategy = config.FieldNumbersLock
+
+	table := &clickhouse.Table{
+		Name: "users",
+		Columns: []clickhouse.Column{
+			{Name: "id", Type: "UInt64", BaseType: "UInt64", Position: 1},
+		},
+		SortingKey: []string{"id"},
+	}
+
+	proto, err := NewGenerator(cfg, logrus.New()).TableProto(table)
+	require.NoError(t, err)
+	assert.Contains(t, proto, "message Users {")
+	assert.Contains(t, proto, "uint64 id = 11;")
+	assert.Contains(t, proto, "service UsersService")
+
+	// Nothing is written, not even the field number lock
+	_, err = os.Stat(outputDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGenerator_GenerateEmitSections(t *testing.T) {
+	tables := []*clickhouse.Table{
+		{
+			Name: "users",
+			Columns: []clickhouse.Column{
+				{Name: "id", Type: "UInt64", BaseType: "UInt64", Position: 1},
+				{Name: "email", Type: "Nullable(String)", BaseType: "String", IsNullable: true, Position: 2},
+			},
+			SortingKey: []string{"id"},
+		},
+	}
+
+	generate := func(t *testing.T, emit ...string) string {
+		t.Helper()
+		tempDir := t.TempDir()
+		cfg := config.NewConfig()
+		cfg.OutputDir = tempDir
+		cfg.EnableAPI = true
+		cfg.Emit = emit
+		cfg.Columns = map[string]map[string]config.ColumnConfig{"users": {"email": {Mask: config.MaskHash}}}
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate(tables))
+		return tempDir
+	}
+
+	t.Run("Messages only", func(t *testing.T) {
+		dir := generate(t, config.EmitMessages)
+
+		proto, err := readFile(filepath.Join(dir, "users.proto"))
+		require.NoError(t, err)
+		assert.NotContains(t, proto, "service UsersService")
+		assert.NotContains(t, proto, "import \"common.proto\"")
+		assert.NotContains(t, proto, "(clickhouse.v1.", "annotations aren't emitted")
+		assert.Contains(t, proto, "google.protobuf.StringValue email = 12;\n")
+		assert.NoFileExists(t, filepath.Join(dir, "common.proto"))
+		assert.NoFileExists(t, filepath.Join(dir, "clickhouse", "annotations.proto"))
+		assert.NoFileExists(t, filepath.Join(dir, "users_sql.go"))
+	})
+
+	t.Run("Services without REST or SQL", func(t *testing.T) {
+		dir := generate(t, config.EmitMessages, config.EmitServices, config.EmitAnnotations)
+
+		proto, err := readFile(filepath.Join(dir, "users.proto"))
+		require.NoError(t, err)
+		assert.Contains(t, proto, "service UsersService")
+		assert.NotContains(t, proto, "google.api")
+		assert.Contains(t, proto, "[(clickhouse.v1.mask) = \"hash\"]")
+		assert.FileExists(t, filepath.Join(dir, "common.proto"))
+		assert.NoFileExists(t, filepath.Join(dir, "users_sql.go"))
+	})
+}
+
 func TestGenerator_CheckNeedsWrapper(t *testing.T) {
 	cfg := &config.Config{}
 	log := logrus.New()
@@ -1030,3 +1151,122 @@ func TestGenerator_NoProjectionAnnotationsWithoutProjections(t *testing.T) {
 	// Verify regular column remains OPTIONAL
 	assert.Contains(t, contentStr, "Filter by value - Record value (optional)")
 }
+
+func TestGenerator_ListRequestLimitBy(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tempDir
+	cfg.EnableAPI = true
+	cfg.Tenant = config.TenantConfig{Column: "tenant_id"}
+	cfg.Columns = map[string]map[string]config.ColumnConfig{"fct_events": {"owner": {Mask: config.MaskHash}}}
+
+	gen := NewGenerator(cfg, logrus.New())
+	require.NoError(t, gen.Generate([]*clickhouse.Table{tenantTestTable()}))
+
+	protoContent, err := readFile(filepath.Join(tempDir, "fct_events.proto"))
+	require.NoError(t, err)
+
+	// Numbered after the tenant field (7), which keeps its number
+	assert.Contains(t, protoContent, "string tenant = 7 [(google.api.field_behavior) = REQUIRED];")
+	assert.Contains(t, protoContent, "repeated string distinct_on = 8 [(google.api.field_behavior) = OPTIONAL];")
+	assert.Contains(t, protoContent, "int32 limit_by = 9 [(google.api.field_behavior) = OPTIONAL];")
+
+	sqlContent, err := readFile(filepath.Join(tempDir, "fct_events_sql.go"))
+	require.NoError(t, err)
+
+	// Masked columns can't be grouped on, like they can't be ordered on
+	assert.Contains(t, sqlContent, `limitBy, err := ParseDistinctOn(req.DistinctOn, req.LimitBy, FctEventsColumnsByField.Without("owner"))`)
+	assert.Contains(t, sqlContent, "options = append([]QueryOption{limitBy}, options...)")
+}
+
+func TestGenerator_ListRequestSample(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tempDir
+	cfg.EnableAPI = true
+
+	sampled := tenantTestTable()
+	sampled.SamplingKey = "event_id"
+	plain := tenantTestTable()
+	plain.Name = "fct_plain_events"
+
+	gen := NewGenerator(cfg, logrus.New())
+	require.NoError(t, gen.Generate([]*clickhouse.Table{sampled, plain}))
+
+	protoContent, err := readFile(filepath.Join(tempDir, "fct_events.proto"))
+	require.NoError(t, err)
+	assert.Contains(t, protoContent, "int32 limit_by = 9 [(google.api.field_behavior) = OPTIONAL];")
+	assert.Contains(t, protoContent, "double sample = 10 [(google.api.field_behavior) = OPTIONAL];")
+
+	sqlContent, err := readFile(filepath.Join(tempDir, "fct_events_sql.go"))
+	require.NoError(t, err)
+	assert.Contains(t, sqlContent, "sample, err := ParseSample(req.Sample)")
+	assert.Contains(t, sqlContent, "options = append([]QueryOption{sample}, options...)")
+
+	// Tables without a SAMPLE BY key can't be sampled
+	plainProto, err := readFile(filepath.Join(tempDir, "fct_plain_events.proto"))
+	require.NoError(t, err)
+	assert.NotContains(t, plainProto, "sample")
+	plainSQL, err := readFile(filepath.Join(tempDir, "fct_plain_events_sql.go"))
+	require.NoError(t, err)
+	assert.NotContains(t, plainSQL, "ParseSample")
+}
+
+// sampleTest runs in the generated package and checks the SAMPLE clause of sampled List
+// queries
+const sampleTest = `package testv1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	for _, tc := range []struct {
+		sample float64
+		clause string
+		valid  bool
+	}{
+		{sample: 0, valid: true},
+		{sample: 1, valid: true},
+		{sample: 0.1, clause: " SAMPLE 0.1", valid: true},
+		{sample: 1.5},
+		{sample: -0.1},
+	} {
+		q, err := BuildListFctEventsQuery(&ListFctEventsRequest{EventId: &UInt64Filter{Filter: &UInt64Filter_Eq{Eq: 1}}, Sample: tc.sample})
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("sample %v was accepted", tc.sample)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("sample %v: %v", tc.sample, err)
+		}
+		if got := strings.Contains(q.Query, " SAMPLE "); got != (tc.clause != "") || !strings.Contains(q.Query, tc.clause) {
+			t.Errorf("sample %v: %s", tc.sample, q.Query)
+		}
+	}
+}
+`
+
+func TestGenerator_ListRequestSampleValues(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.GoPackage = "example.com/gen/testv1"
+	generateModule(t, cfg, []*clickhouse.Table{{
+		Name:        "fct_events",
+		Engine:      "MergeTree",
+		SamplingKey: "event_id",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("event_id", "UInt64", 1),
+			clickhouse.NewColumn("name", "String", 2),
+		},
+		SortingKey: []string{"event_id"},
+	}})
+
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "sample_test.go"), []byte(sampleTest), 0o600))
+	goCommand(t, cfg.OutputDir, "test", "-run", "TestSample", ".")
+}
@@ -0,0 +1,56 @@
+package protogen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatGoSource(t *testing.T) {
+	input := "package db\n\nimport (\n" +
+		"\t\"google.golang.org/grpc\"\n" +
+		"\t\"fmt\"\n" +
+		"\tpb \"github.com/acme/gen/v1\"\n" +
+		"\t\"context\"\n" +
+		")\n\n" +
+		"var _ = fmt.Sprintf\nvar _ context.Context\nvar _ *grpc.Server\nvar _ pb.Foo\n" +
+		"func  f( a int )int{\nreturn a}\n"
+
+	expected := "package db\n\nimport (\n" +
+		"\t\"context\"\n" +
+		"\t\"fmt\"\n" +
+		"\n" +
+		"\tpb \"github.com/acme/gen/v1\"\n" +
+		"\t\"google.golang.org/grpc\"\n" +
+		")\n\n" +
+		"var _ = fmt.Sprintf\nvar _ context.Context\nvar _ *grpc.Server\nvar _ pb.Foo\n\n" +
+		"func f(a int) int {\n\treturn a\n}\n"
+
+	formatted, err := formatGoSource("db.go", input)
+	require.NoError(t, err)
+	assert.Equal(t, expected, formatted)
+}
+
+func TestFormatGoSource_KeepsCommentedImports(t *testing.T) {
+	input := "package db\n\nimport (\n\t\"google.golang.org/grpc\" // server\n\t\"fmt\"\n)\n"
+
+	assert.Equal(t, input, groupImports(input))
+}
+
+func TestWriteFile_InvalidGoCode(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	gen := NewGenerator(cfg, logrus.New())
+
+	filename := filepath.Join(cfg.OutputDir, "broken.go")
+	err := gen.writeFile(filename, "package db\n\nfunc f() {\n\treturn fmt.Sprintf(\"%d\" 1)\n}\n")
+
+	require.ErrorIs(t, err, ErrInvalidGoCode)
+	assert.Contains(t, err.Error(), "broken.go: 4:")
+	assert.Contains(t, err.Error(), "4 | \treturn fmt.Sprintf(\"%d\" 1)")
+	assert.NoFileExists(t, filename, "invalid code must not be written")
+}
@@ -0,0 +1,176 @@
+package protogen
+
+import (
+	"context"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse/clickhousetest"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the expected output of the golden tests:
+//
+//	go test ./internal/protogen -run TestGolden -update
+//
+//nolint:gochecknoglobals // test flag
+var update = flag.Bool("update", false, "rewrite golden files in testdata/<case>/expected")
+
+// sharedGoldenCase is the only golden case whose shared files are snapshotted
+const sharedGoldenCase = "basic"
+
+// sharedGoldenFiles are the files every run writes for all of its tables. Their content
+// barely depends on the tables, so only sharedGoldenCase snapshots them, and the other cases
+// only compare the files of their tables and features.
+//
+//nolint:gochecknoglobals // read-only
+var sharedGoldenFiles = []string{
+	"clickhouse/annotations.proto",
+	"common.go",
+	"common.proto",
+	"hash.go",
+	"status.go",
+	"validate.go",
+}
+
+// TestGolden generates every case in testdata/<case> and compares its output with
+// testdata/<case>/expected: the complete output for sharedGoldenCase, and all but the
+// sharedGoldenFiles for the others. A case has a schema.json in the clickhousetest fixture
+// format and an optional config.yaml applied on top of the default configuration.
+func TestGolden(t *testing.T) {
+	cases, err := filepath.Glob(filepath.Join("testdata", "*", "schema.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, cases, "no golden cases found")
+
+	for _, schema := range cases {
+		dir := filepath.Dir(schema)
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			actual := generateGoldenCase(t, dir)
+			if filepath.Base(dir) != sharedGoldenCase {
+				for _, name := range sharedGoldenFiles {
+					delete(actual, name)
+				}
+			}
+			expectedDir := filepath.Join(dir, "expected")
+
+			if *update {
+				require.NoError(t, os.RemoveAll(expectedDir))
+				for name, content := range actual {
+					path := filepath.Join(expectedDir, name)
+					require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+					require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+				}
+				return
+			}
+
+			expected := readTree(t, expectedDir)
+			assert.ElementsMatch(t, keys(expected), keys(actual), "generated files differ, run with -update to accept")
+			for name, want := range expected {
+				if got, ok := actual[name]; ok {
+					assert.Equal(t, want, got, "%s differs, run with -update to accept", name)
+				}
+			}
+		})
+	}
+}
+
+// generateGoldenCase runs the generator for a case and returns its output by relative path
+func generateGoldenCase(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	cfg, tables := loadGoldenCase(t, dir)
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	require.NoError(t, NewGenerator(cfg, log).Generate(tables))
+	return readTree(t, cfg.OutputDir)
+}
+
+// loadGoldenCase returns the configuration of a case, writing to a temporary directory, and
+// the tables of its schema
+func loadGoldenCase(t *testing.T, dir string) (*config.Config, []*clickhouse.Table) {
+	t.Helper()
+	ctx := context.Background()
+
+	svc, err := clickhousetest.LoadFixtures(filepath.Join(dir, "schema.json"))
+	require.NoError(t, err)
+	require.NoError(t, svc.Connect(ctx))
+
+	names, err := svc.ListTables(ctx)
+	require.NoError(t, err)
+	tables, err := svc.GetTables(ctx, "", names)
+	require.NoError(t, err)
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	cfg := config.NewConfig()
+	if configPath := filepath.Join(dir, "config.yaml"); fileExists(configPath) {
+		require.NoError(t, cfg.LoadFromFile(configPath, log))
+	}
+	cfg.OutputDir = t.TempDir()
+	return cfg, tables
+}
+
+// readTree returns the content of every file below root by slash-separated relative path
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+
+	files := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func keys(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	return names
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// generateColumnTypes generates a table of the given columns with cfg into a temporary
+// directory and returns the files written, by their slash-separated path in it. The column
+// type tests of the emitters check the lines their columns get in these files.
+func generateColumnTypes(t *testing.T, cfg *config.Config, table *clickhouse.Table, columns []clickhouse.Column) map[string]string {
+	t.Helper()
+	table.Columns = columns
+	cfg.OutputDir = t.TempDir()
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	require.NoError(t, NewGenerator(cfg, log).Generate([]*clickhouse.Table{table}))
+	return readTree(t, cfg.OutputDir)
+}
+
+// commented returns a column with the comment its DDL gives it
+func commented(column clickhouse.Column, comment string) clickhouse.Column {
+	column.Comment = comment
+	return column
+}
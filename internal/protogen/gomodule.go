@@ -0,0 +1,209 @@
+package protogen
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+const (
+	defaultGoModuleDir = "sqlgen"
+	defaultGoVersion   = "1.24"
+
+	// Versions required by the code protoc generates next to the SQL helpers
+	protobufModuleVersion   = "v1.36.6"
+	grpcModuleVersion       = "v1.73.0"
+	googleAPIsModuleVersion = "v0.0.0-20250707201910-8d1bb00bc6a7"
+	googleRPCModuleVersion  = "v0.0.0-20250707201910-8d1bb00bc6a7"
+
+	// Version required by the generated query benchmarks
+	clickhouseModuleVersion = "v2.40.1"
+)
+
+// goPackage returns the go_package option written to generated proto files. With a Go
+// module layout it defaults to the module path so protoc places its output in the module.
+func (g *Generator) goPackage() string {
+	goPackage := g.config.GoPackage
+	if !g.config.GoModule.Enabled {
+		return goPackage
+	}
+	if goPackage == "" {
+		goPackage = g.config.GoModule.Path
+	}
+
+	// protoc-gen-go must use the same package name as the SQL helpers
+	if name := g.config.GoModule.PackageName; name != "" {
+		importPath, _, _ := strings.Cut(goPackage, ";")
+		return importPath + ";" + name
+	}
+	return goPackage
+}
+
+// goImportPath returns the import path of the generated Go package, without the
+// optional ";name" suffix of go_package
+func (g *Generator) goImportPath() string {
+	importPath, _, _ := strings.Cut(g.goPackage(), ";")
+	return strings.TrimSuffix(importPath, "/")
+}
+
+// goPackageName returns the package clause of the generated Go files
+func (g *Generator) goPackageName() string {
+	if g.config.GoModule.Enabled && g.config.GoModule.PackageName != "" {
+		return g.config.GoModule.PackageName
+	}
+
+	goPackage := g.goPackage()
+	if goPackage == "" {
+		return "main"
+	}
+	if _, name, ok := strings.Cut(goPackage, ";"); ok && name != "" {
+		return name
+	}
+
+	parts := strings.Split(strings.TrimSuffix(goPackage, "/"), "/")
+	return sanitizeGoPackageName(parts[len(parts)-1])
+}
+
+// sanitizeGoPackageName turns an import path element into a valid package name the way
+// protoc-gen-go does, so the SQL helpers and the protoc output agree on the name
+func sanitizeGoPackageName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+
+	if token.IsKeyword(name) {
+		name += "_"
+	}
+	if r, _ := utf8.DecodeRuneInString(name); unicode.IsDigit(r) {
+		name = "_" + name
+	}
+	return name
+}
+
+// goOutputDir returns the directory the Go SQL helpers are written to
+func (g *Generator) goOutputDir() string {
+	if !g.config.GoModule.Enabled {
+		return g.config.OutputDir
+	}
+
+	dir := g.config.GoModule.Dir
+	if dir == "" {
+		dir = defaultGoModuleDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(g.config.OutputDir, dir)
+}
+
+// goModulePath returns the module path of the generated go.mod
+func (g *Generator) goModulePath() string {
+	if g.config.GoModule.Path != "" {
+		return g.config.GoModule.Path
+	}
+	return g.goImportPath()
+}
+
+// GenerateGoModule writes go.mod and doc.go so the Go output directory can be imported
+// or published as a module. go.mod is only written once so `go mod tidy` results and
+// added dependencies are kept; doc.go is regenerated on every run.
+func (g *Generator) GenerateGoModule(tables []*clickhouse.Table) error {
+	dir := g.goOutputDir()
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create Go module directory: %w", err)
+	}
+
+	if err := g.writeFileIfMissing(filepath.Join(dir, "go.mod"), g.buildGoMod(tables)); err != nil {
+		return err
+	}
+
+	return g.writeFile(filepath.Join(dir, "doc.go"), g.buildGoDoc(tables))
+}
+
+// buildGoMod renders the go.mod with the modules the protoc output depends on
+func (g *Generator) buildGoMod(tables []*clickhouse.Table) string {
+	goVersion := g.config.GoModule.GoVersion
+	if goVersion == "" {
+		goVersion = defaultGoVersion
+	}
+
+	requires := []string{"google.golang.org/protobuf " + protobufModuleVersion}
+	if g.hasServices(tables) {
+		requires = append(requires, "google.golang.org/grpc "+grpcModuleVersion)
+		requires = append(requires, "google.golang.org/genproto/googleapis/rpc "+googleRPCModuleVersion)
+	}
+	if g.hasAPIAnnotations(tables) {
+		requires = append(requires, "google.golang.org/genproto/googleapis/api "+googleAPIsModuleVersion)
+	}
+	if g.config.Benchmarks.Enabled {
+		requires = append(requires, "github.com/ClickHouse/clickhouse-go/v2 "+clickhouseModuleVersion)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "module %s\n\n", g.goModulePath())
+	fmt.Fprintf(&sb, "go %s\n\n", goVersion)
+	sb.WriteString("require (\n")
+	for _, req := range requires {
+		fmt.Fprintf(&sb, "\t%s\n", req)
+	}
+	sb.WriteString(")\n")
+	return sb.String()
+}
+
+// buildGoDoc renders the package documentation listing the generated query builders
+func (g *Generator) buildGoDoc(tables []*clickhouse.Table) string {
+	var sb strings.Builder
+
+	sb.WriteString("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&sb, "// Package %s contains the protobuf messages and SQL query builders\n", g.goPackageName())
+	sb.WriteString("// generated by clickhouse-proto-gen from ClickHouse table schemas.\n")
+	sb.WriteString("//\n")
+	sb.WriteString("// Each table with a sorting key gets BuildList<Table>Query and BuildGet<Table>Query\n")
+	sb.WriteString("// functions that turn List/Get requests into parameterized SQL.")
+
+	var names []string
+	for _, table := range tables {
+		if len(table.Columns) > 0 && len(table.SortingKey) > 0 {
+			names = append(names, table.Name)
+		}
+	}
+	if len(names) > 0 {
+		sb.WriteString(" Tables:\n//\n")
+		for _, name := range names {
+			fmt.Fprintf(&sb, "//   - %s\n", name)
+		}
+	} else {
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprintf(&sb, "package %s\n", g.goPackageName())
+	return sb.String()
+}
+
+// hasServices reports whether any table gets a gRPC service
+func (g *Generator) hasServices(tables []*clickhouse.Table) bool {
+	for _, table := range tables {
+		if len(table.Columns) > 0 && len(table.SortingKey) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAPIAnnotations reports whether any service uses google.api HTTP annotations
+func (g *Generator) hasAPIAnnotations(tables []*clickhouse.Table) bool {
+	for _, table := range tables {
+		if len(table.Columns) > 0 && len(table.SortingKey) > 0 && g.shouldGenerateAPI(table.Name) {
+			return true
+		}
+	}
+	return false
+}
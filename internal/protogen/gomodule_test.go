@@ -0,0 +1,110 @@
+package protogen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GenerateGoModule(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tempDir
+	cfg.Package = "test.v1"
+	cfg.GoModule = config.GoModuleConfig{
+		Enabled:     true,
+		Path:        "github.com/acme/schema-go",
+		PackageName: "schemav1",
+	}
+
+	gen := NewGenerator(cfg, logrus.New())
+
+	tables := []*clickhouse.Table{
+		{
+			Name:       "fct_block",
+			Columns:    []clickhouse.Column{{Name: "slot", Type: "UInt64", BaseType: "UInt64", Position: 1}},
+			SortingKey: []string{"slot"},
+		},
+	}
+
+	require.NoError(t, gen.Generate(tables))
+
+	moduleDir := filepath.Join(tempDir, "sqlgen")
+
+	goMod, err := readFile(filepath.Join(moduleDir, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, goMod, "module github.com/acme/schema-go\n\ngo 1.24\n")
+	assert.Contains(t, goMod, "google.golang.org/protobuf "+protobufModuleVersion)
+	assert.Contains(t, goMod, "google.golang.org/grpc "+grpcModuleVersion)
+	assert.Contains(t, goMod, "google.golang.org/genproto/googleapis/rpc "+googleRPCModuleVersion, "status.go builds google.rpc.Status")
+	assert.NotContains(t, goMod, "googleapis/api", "HTTP annotations are disabled")
+
+	doc, err := readFile(filepath.Join(moduleDir, "doc.go"))
+	require.NoError(t, err)
+	assert.Contains(t, doc, "//   - fct_block\n")
+
+	// The SQL helpers move into the module, the protos stay in the output directory
+	for _, name := range []string{"doc.go", "fct_block_sql.go", "common.go"} {
+		path := filepath.Join(moduleDir, name)
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+		require.NoError(t, err, name)
+		assert.Equal(t, "schemav1", file.Name.Name, name)
+	}
+	assert.NoFileExists(t, filepath.Join(tempDir, "fct_block_sql.go"))
+	assert.FileExists(t, filepath.Join(tempDir, "fct_block.proto"))
+
+	// protoc must place its output next to the helpers
+	proto, err := readFile(filepath.Join(tempDir, "fct_block.proto"))
+	require.NoError(t, err)
+	assert.Contains(t, proto, `option go_package = "github.com/acme/schema-go;schemav1";`)
+
+	// go.mod is kept once written so dependency updates survive regeneration
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte("module edited\n"), 0o600))
+	require.NoError(t, gen.Generate(tables))
+
+	goMod, err = readFile(filepath.Join(moduleDir, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module edited\n", goMod)
+}
+
+func TestGenerator_GoPackageName(t *testing.T) {
+	tests := []struct {
+		name      string
+		goPackage string
+		goModule  config.GoModuleConfig
+		expected  string
+	}{
+		{name: "No go_package", expected: "main"},
+		{name: "Last path element", goPackage: "github.com/acme/gen/clickhouse-v1", expected: "clickhouse_v1"},
+		{name: "Keyword", goPackage: "github.com/acme/package", expected: "package_"},
+		{name: "Leading digit", goPackage: "github.com/acme/2024.v1", expected: "_2024_v1"},
+		{name: "Explicit name in go_package", goPackage: "github.com/acme/gen/v1;genv1", expected: "genv1"},
+		{name: "Module path", goModule: config.GoModuleConfig{Enabled: true, Path: "github.com/acme/tables"}, expected: "tables"},
+		{
+			name:      "Module package name wins",
+			goPackage: "github.com/acme/gen",
+			goModule:  config.GoModuleConfig{Enabled: true, PackageName: "chgen"},
+			expected:  "chgen",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.GoPackage = tt.goPackage
+			cfg.GoModule = tt.goModule
+
+			gen := NewGenerator(cfg, logrus.New())
+			assert.Equal(t, tt.expected, gen.goPackageName())
+		})
+	}
+}
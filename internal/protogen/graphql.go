@@ -0,0 +1,467 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+const (
+	defaultGraphQLDir = "graphql"
+	graphQLEmptyInput = "Empty"
+)
+
+// graphQLScalars are the custom scalars of the schema in declaration order. Only the ones
+// a schema uses are declared.
+//
+//nolint:gochecknoglobals // Fixed list
+var graphQLScalars = []struct{ name, description string }{
+	{"Int64", "64-bit signed integer, serialized as a string like in the proto JSON mapping"},
+	{"UInt64", "64-bit unsigned integer, serialized as a string like in the proto JSON mapping"},
+	{"UInt32", "32-bit unsigned integer"},
+	{"Bytes", "Base64-encoded bytes"},
+	{"Map", "JSON object holding a ClickHouse Map column"},
+}
+
+// graphQLQuery holds the List and Get messages of a table with services
+type graphQLQuery struct {
+	table        *clickhouse.Table
+	name         string // Proto message name of the table
+	goName       string // Go type name protoc generates for the message
+	row          protoMessage
+	listRequest  protoMessage
+	listResponse protoMessage
+	getRequest   protoMessage
+	getResponse  protoMessage
+	tenant       *tenantScope
+}
+
+// graphQLSchema renders messages as GraphQL types
+type graphQLSchema struct {
+	g        *Generator
+	messages map[string]protoMessage
+	scalars  map[string]bool
+}
+
+// GenerateGraphQL generates <output_dir>/graphql with schema.graphql, exposing the List and
+// Get RPCs of every table as listX/getX queries with the filter messages as input types,
+// and resolvers.go, resolving those queries with the generated SQL helpers. The schema
+// follows the proto JSON mapping, so resolvers exchange JSON-shaped values with any
+// GraphQL server library.
+func (g *Generator) GenerateGraphQL(tables []*clickhouse.Table) error {
+	dir := g.emitterDir(g.config.GraphQL.Dir, defaultGraphQLDir)
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create GraphQL directory: %w", err)
+	}
+
+	schema := &graphQLSchema{g: g, messages: make(map[string]protoMessage), scalars: make(map[string]bool)}
+	order := make([]string, 0)
+	for _, msg := range g.commonMessages() {
+		schema.messages[msg.Name] = msg
+		order = append(order, msg.Name)
+	}
+
+	queries := make([]graphQLQuery, 0, len(tables))
+	for _, table := range tables {
+		messages := g.tableMessages(table)
+		for _, msg := range messages {
+			schema.messages[msg.Name] = msg
+			order = append(order, msg.Name)
+		}
+		if query, ok := schema.query(table); ok {
+			queries = append(queries, query)
+		}
+	}
+
+	if err := g.writeFile(filepath.Join(dir, "schema.graphql"), schema.build(queries, order)); err != nil {
+		return err
+	}
+
+	importPath := g.goImportPath()
+	if importPath == "" {
+		g.log.Warn("Skipping GraphQL resolvers: go_package is required to import the generated SQL helpers")
+		return nil
+	}
+	return g.writeFile(filepath.Join(dir, "resolvers.go"), buildGraphQLResolvers(queries, importPath))
+}
+
+// query returns the List and Get messages of a table, if it has services
+func (s *graphQLSchema) query(table *clickhouse.Table) (graphQLQuery, bool) {
+	if len(table.SortingKey) == 0 {
+		return graphQLQuery{}, false
+	}
+
+	name := ToPascalCase(table.Name)
+	query := graphQLQuery{
+		table:        table,
+		name:         name,
+		goName:       getProtocMessageName(table.Name),
+		row:          s.messages[name],
+		listRequest:  s.messages["List"+name+"Request"],
+		listResponse: s.messages["List"+name+"Response"],
+		getRequest:   s.messages["Get"+name+"Request"],
+		getResponse:  s.messages["Get"+name+"Response"],
+	}
+	query.tenant, _ = s.g.tenantScopeFor(table)
+
+	ok := len(query.listResponse.Fields) > 0 && query.getRequest.Name != ""
+	return query, ok
+}
+
+func (s *graphQLSchema) build(queries []graphQLQuery, order []string) string {
+	var requests, responses []protoMessage
+	for _, query := range queries {
+		requests = append(requests, query.listRequest, query.getRequest)
+		responses = append(responses, query.listResponse, query.getResponse)
+	}
+	// Requests become arguments, while responses are types themselves
+	inputs := s.reachable(requests)
+	outputs := s.reachable(responses)
+	for _, response := range responses {
+		outputs[response.Name] = true
+	}
+
+	body := &strings.Builder{}
+	if len(queries) > 0 {
+		body.WriteString("\ntype Query {\n")
+		for i, query := range queries {
+			if i > 0 {
+				body.WriteString("\n")
+			}
+			s.writeQuery(body, "list", query, query.listRequest, query.listResponse)
+			s.writeQuery(body, "get", query, query.getRequest, query.getResponse)
+		}
+		body.WriteString("}\n")
+	}
+
+	for _, name := range order {
+		if outputs[name] {
+			s.writeType(body, "type", s.messages[name], false)
+		}
+	}
+	for _, name := range order {
+		if inputs[name] {
+			s.writeType(body, "input", s.messages[name], true)
+		}
+	}
+	if s.scalars[graphQLEmptyInput] {
+		body.WriteString("\n\"An empty condition such as isNull; pass it as {}\"\n")
+		fmt.Fprintf(body, "input %s {\n  _: Boolean\n}\n", graphQLEmptyInput)
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString("# Code generated by clickhouse-proto-gen. DO NOT EDIT.\n")
+	sb.WriteString("# Types follow the proto JSON mapping of the generated messages.\n")
+	for _, scalar := range graphQLScalars {
+		if s.scalars[scalar.name] {
+			fmt.Fprintf(sb, "\n%q\nscalar %s\n", scalar.description, scalar.name)
+		}
+	}
+	sb.WriteString(body.String())
+	return sb.String()
+}
+
+// reachable returns the messages the fields of roots refer to, transitively
+func (s *graphQLSchema) reachable(roots []protoMessage) map[string]bool {
+	seen := make(map[string]bool)
+	queue := append([]protoMessage{}, roots...)
+	for len(queue) > 0 {
+		msg := queue[0]
+		queue = queue[1:]
+		for _, field := range msg.Fields {
+			next, ok := s.messages[field.Type]
+			if !ok || seen[field.Type] {
+				continue
+			}
+			seen[field.Type] = true
+			queue = append(queue, next)
+		}
+	}
+	return seen
+}
+
+// writeQuery writes a listX or getX query taking the request fields as arguments. Get
+// arguments are required.
+func (s *graphQLSchema) writeQuery(sb *strings.Builder, verb string, query graphQLQuery, request, response protoMessage) {
+	required := verb == "get"
+	if required {
+		writeGraphQLDescription(sb, fmt.Sprintf("Get a single %s record by primary key", query.table.Name), "  ")
+	} else {
+		writeGraphQLDescription(sb, fmt.Sprintf("List %s records with optional filtering, paginated", query.table.Name), "  ")
+	}
+
+	fmt.Fprintf(sb, "  %s%s(\n", verb, query.name)
+	for _, field := range request.Fields {
+		writeGraphQLDescription(sb, field.Comment, "    ")
+		typ := s.fieldType(field, true)
+		if required {
+			typ += "!"
+		}
+		fmt.Fprintf(sb, "    %s: %s\n", field.JSONName(), typ)
+	}
+	fmt.Fprintf(sb, "  ): %s!%s\n", response.Name, s.deprecation(query.row.Deprecated, query.row.Comment))
+}
+
+func (s *graphQLSchema) writeType(sb *strings.Builder, kind string, msg protoMessage, input bool) {
+	sb.WriteString("\n")
+	writeGraphQLDescription(sb, msg.Comment, "")
+	fmt.Fprintf(sb, "%s %s {\n", kind, msg.Name)
+	for _, field := range msg.Fields {
+		writeGraphQLDescription(sb, field.Comment, "  ")
+		directive := ""
+		if !input {
+			// Input fields can only be deprecated in newer revisions of the spec
+			directive = s.deprecation(field.Deprecated, field.Comment)
+		}
+		fmt.Fprintf(sb, "  %s: %s%s\n", field.JSONName(), s.fieldType(field, input), directive)
+	}
+	sb.WriteString("}\n")
+}
+
+// deprecation returns the @deprecated directive for a deprecated field or query, with the
+// note from its comment as the reason
+func (s *graphQLSchema) deprecation(deprecated bool, comment string) string {
+	if !deprecated {
+		return ""
+	}
+	if note, _ := s.g.deprecationNote(comment); note != "" {
+		return fmt.Sprintf(" @deprecated(reason: %s)", graphQLString(note))
+	}
+	return " @deprecated"
+}
+
+// fieldType returns the GraphQL type of a field. Output fields are non-null unless proto
+// JSON can omit them (wrappers and messages); input fields are all optional.
+func (s *graphQLSchema) fieldType(field protoField, input bool) string {
+	nonNull := "!"
+	if input {
+		nonNull = ""
+	}
+
+	switch {
+	case field.IsMap():
+		s.scalars["Map"] = true
+		return "Map" + nonNull
+	case field.Repeated:
+		return fmt.Sprintf("[%s!]%s", s.valueType(field.Type), nonNull)
+	case field.Nullable() || field.Scalar() == "":
+		return s.valueType(field.Type)
+	}
+	return s.valueType(field.Type) + nonNull
+}
+
+func (s *graphQLSchema) valueType(protoType string) string {
+	if protoType == protoEmpty {
+		s.scalars[graphQLEmptyInput] = true
+		return graphQLEmptyInput
+	}
+
+	scalar := wrapperScalar(protoType)
+	if scalar == "" {
+		scalar = protoType
+	}
+
+	var custom string
+	switch scalar {
+	case protoInt32:
+		return "Int"
+	case protoFloat, protoDouble:
+		return "Float"
+	case protoBool:
+		return "Boolean"
+	case protoString:
+		return "String"
+	case protoUInt32:
+		custom = "UInt32"
+	case protoInt64:
+		custom = "Int64"
+	case protoUInt64:
+		custom = "UInt64"
+	case protoBytes:
+		custom = "Bytes"
+	default:
+		return protoType
+	}
+	s.scalars[custom] = true
+	return custom
+}
+
+// writeGraphQLDescription writes a description, as a block string when it spans lines
+// or contains quotes
+func writeGraphQLDescription(sb *strings.Builder, comment, indent string) {
+	if comment == "" {
+		return
+	}
+	if !strings.ContainsAny(comment, "\n\"") {
+		fmt.Fprintf(sb, "%s%s\n", indent, graphQLString(comment))
+		return
+	}
+
+	fmt.Fprintf(sb, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(strings.ReplaceAll(comment, `"""`, `\"""`), "\n") {
+		if line == "" {
+			sb.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(sb, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(sb, "%s\"\"\"\n", indent)
+}
+
+// graphQLString quotes a single-line GraphQL string
+func graphQLString(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(s) + `"`
+}
+
+// buildGraphQLResolvers renders the resolver package. Resolvers decode the GraphQL
+// arguments into the request message with protojson, build the SQL with the generated
+// helpers, fetch the rows through a function per table and return the response as
+// JSON-shaped maps, which keeps them independent of a GraphQL library.
+func buildGraphQLResolvers(queries []graphQLQuery, importPath string) string {
+	sb := &strings.Builder{}
+
+	sb.WriteString("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n\n")
+	sb.WriteString("// Package graphql resolves the queries of schema.graphql with the generated SQL helpers.\n")
+	sb.WriteString("// Resolvers take the query arguments and return results as JSON-shaped maps following the\n")
+	sb.WriteString("// schema, so they can back the field resolvers of any GraphQL server library.\n")
+	sb.WriteString("package graphql\n\n")
+
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"context\"\n")
+	sb.WriteString("\t\"encoding/json\"\n")
+	sb.WriteString("\t\"errors\"\n")
+	sb.WriteString("\t\"fmt\"\n\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/encoding/protojson\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/proto\"\n\n")
+	fmt.Fprintf(sb, "\tpb %q\n", importPath)
+	sb.WriteString(")\n\n")
+
+	sb.WriteString("// defaultPageSize is the page size the SQL helpers use when a List request has none\n")
+	sb.WriteString("const defaultPageSize = 100\n\n")
+	sb.WriteString("// ErrNoFetcher is returned by the queries of a table without a fetch function\n")
+	sb.WriteString("var ErrNoFetcher = errors.New(\"no fetch function configured for table\")\n\n")
+
+	sb.WriteString("// Resolver resolves the queries of schema.graphql. Each table has a fetch function that\n")
+	sb.WriteString("// executes a query built by the SQL helpers and decodes the rows into messages.\n")
+	sb.WriteString("type Resolver struct {\n")
+	for _, query := range queries {
+		fmt.Fprintf(sb, "\t// %s fetches rows of %s\n", query.goName, query.table.Name)
+		fmt.Fprintf(sb, "\t%s func(ctx context.Context, query pb.SQLQuery) ([]*pb.%s, error)\n", query.goName, query.goName)
+	}
+	sb.WriteString("}\n")
+
+	for _, query := range queries {
+		writeGraphQLListResolver(sb, query)
+		writeGraphQLGetResolver(sb, query)
+	}
+
+	sb.WriteString(graphQLResolverHelpers)
+	return sb.String()
+}
+
+func writeGraphQLListResolver(sb *strings.Builder, query graphQLQuery) {
+	fmt.Fprintf(sb, "\n// List%s resolves Query.list%s\n", query.goName, query.name)
+	writeGraphQLResolverPrologue(sb, query, "List")
+	fmt.Fprintf(sb, "\trows, err := encodeMessages(items)\n")
+	sb.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	sb.WriteString("\ttoken, err := nextPageToken(req.GetPageSize(), req.GetPageToken(), len(items))\n")
+	sb.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(sb, "\treturn map[string]any{%q: rows, \"nextPageToken\": token}, nil\n", query.listResponse.Fields[0].JSONName())
+	sb.WriteString("}\n")
+}
+
+func writeGraphQLGetResolver(sb *strings.Builder, query graphQLQuery) {
+	fmt.Fprintf(sb, "\n// Get%s resolves Query.get%s. item is null when no row matches.\n", query.goName, query.name)
+	writeGraphQLResolverPrologue(sb, query, "Get")
+	sb.WriteString("\tif len(items) == 0 {\n")
+	sb.WriteString("\t\treturn map[string]any{\"item\": nil}, nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\titem, err := encodeMessage(items[0])\n")
+	sb.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	sb.WriteString("\treturn map[string]any{\"item\": item}, nil\n")
+	sb.WriteString("}\n")
+}
+
+// writeGraphQLResolverPrologue writes the signature and the part of a resolver that builds
+// the request and query and fetches the rows into items
+func writeGraphQLResolverPrologue(sb *strings.Builder, query graphQLQuery, method string) {
+	tenantArg, tenantParam := "", ""
+	if query.tenant != nil {
+		sb.WriteString("// tenant scopes the query and must come from the caller's authorization, not the arguments.\n")
+		tenantArg, tenantParam = ", tenant", fmt.Sprintf(", tenant %s", query.tenant.goType)
+	}
+	fmt.Fprintf(sb, "func (r *Resolver) %s%s(ctx context.Context%s, args map[string]any) (map[string]any, error) {\n", method, query.goName, tenantParam)
+	fmt.Fprintf(sb, "\tif r.%s == nil {\n", query.goName)
+	fmt.Fprintf(sb, "\t\treturn nil, fmt.Errorf(\"%%w: %s\", ErrNoFetcher)\n", query.table.Name)
+	sb.WriteString("\t}\n\n")
+	fmt.Fprintf(sb, "\treq := &pb.%s%sRequest{}\n", method, query.goName)
+	sb.WriteString("\tif err := decodeArgs(args, req); err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(sb, "\tquery, err := pb.Build%s%sQuery(req%s)\n", method, query.goName, tenantArg)
+	sb.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(sb, "\titems, err := r.%s(ctx, query)\n", query.goName)
+	sb.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n\n")
+}
+
+// graphQLResolverHelpers converts between GraphQL values and messages
+const graphQLResolverHelpers = `
+// decodeArgs decodes query arguments into a request. The schema follows the proto JSON
+// mapping, so the arguments are the request's JSON. Unknown fields, such as the placeholder
+// of the Empty input, are ignored.
+func decodeArgs(args map[string]any, req proto.Message) error {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, req); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// encodeMessage returns a message as the JSON-shaped value of its GraphQL type
+func encodeMessage(msg proto.Message) (map[string]any, error) {
+	data, err := (protojson.MarshalOptions{EmitUnpopulated: true}).Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var value map[string]any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func encodeMessages[T proto.Message](msgs []T) ([]any, error) {
+	values := make([]any, 0, len(msgs))
+	for _, msg := range msgs {
+		value, err := encodeMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// nextPageToken returns the token of the page after a List result, the way the generated
+// services compute it from the offset encoded in the request's token
+func nextPageToken(pageSize int32, pageToken string, count int) (string, error) {
+	limit := uint32(defaultPageSize)
+	if pageSize > 0 {
+		limit = uint32(pageSize)
+	}
+
+	var offset uint32
+	if pageToken != "" {
+		decoded, err := pb.DecodePageToken(pageToken)
+		if err != nil {
+			return "", fmt.Errorf("invalid page_token: %w", err)
+		}
+		offset = decoded
+	}
+	return pb.CalculateNextPageToken(offset, limit, uint32(count)), nil
+}
+`
@@ -0,0 +1,152 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLSchema_FieldType(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    protoField
+		input    bool
+		expected string
+		scalar   string
+	}{
+		{name: "Scalar output", field: protoField{Type: protoInt32}, expected: "Int!"},
+		{name: "Scalar input", field: protoField{Type: protoInt32}, input: true, expected: "Int"},
+		{name: "Big integer", field: protoField{Type: protoUInt64}, expected: "UInt64!", scalar: "UInt64"},
+		{name: "Wrapper", field: protoField{Type: "google.protobuf.StringValue"}, expected: "String"},
+		{name: "Message", field: protoField{Type: "Blocks"}, expected: "Blocks"},
+		{name: "Repeated output", field: protoField{Type: protoBytes, Repeated: true}, expected: "[Bytes!]!", scalar: "Bytes"},
+		{name: "Repeated input", field: protoField{Type: protoDouble, Repeated: true}, input: true, expected: "[Float!]"},
+		{name: "Map", field: protoField{Type: protoString, MapKey: protoString}, expected: "Map!", scalar: "Map"},
+		{name: "Empty", field: protoField{Type: protoEmpty, Oneof: "filter"}, input: true, expected: "Empty", scalar: graphQLEmptyInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &graphQLSchema{scalars: make(map[string]bool)}
+			assert.Equal(t, tt.expected, s.fieldType(tt.field, tt.input))
+			if tt.scalar != "" {
+				assert.True(t, s.scalars[tt.scalar])
+			}
+		})
+	}
+}
+
+func TestGraphQLString(t *testing.T) {
+	assert.Equal(t, `"use \"root\" instead"`, graphQLString(`use "root" instead`))
+	assert.Equal(t, `"a\\b\nc"`, graphQLString("a\\b\nc"))
+}
+
+func TestGenerator_GenerateGraphQL(t *testing.T) {
+	tables := []*clickhouse.Table{
+		{
+			Name:       "blocks",
+			Comment:    "DEPRECATED: use blocks_v2",
+			SortingKey: []string{"slot"},
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1},
+				{Name: "tenant_id", Type: "String", BaseType: "String", Position: 2},
+			},
+		},
+		{
+			Name: "recent_blocks",
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1},
+			},
+		},
+	}
+
+	t.Run("Schema and resolvers", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cfg := config.NewConfig()
+		cfg.OutputDir = tmpDir
+		cfg.Package = "test.v1"
+		cfg.GoPackage = "example.com/gen/testv1"
+		cfg.IncludeComments = true
+		cfg.Tenant = config.TenantConfig{Column: "tenant_id"}
+		cfg.GraphQL.Enabled = true
+
+		require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateGraphQL(tables))
+
+		schema, err := os.ReadFile(filepath.Join(tmpDir, defaultGraphQLDir, "schema.graphql"))
+		require.NoError(t, err)
+		assert.Contains(t, string(schema), "  ): ListBlocksResponse! @deprecated(reason: \"use blocks_v2\")\n")
+		assert.Contains(t, string(schema), "  getBlocks(\n    \"Primary key (required)\"\n    slot: UInt32!\n")
+		assert.Contains(t, string(schema), "input UInt32Filter {")
+		assert.Contains(t, string(schema), "type GetBlocksResponse {\n  item: Blocks\n}\n")
+		assert.NotContains(t, string(schema), "RecentBlocks", "tables without services have no queries")
+		assert.NotContains(t, string(schema), "input ListBlocksRequest")
+
+		resolvers, err := os.ReadFile(filepath.Join(tmpDir, defaultGraphQLDir, "resolvers.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(resolvers), "pb \"example.com/gen/testv1\"")
+		assert.Contains(t, string(resolvers), "func (r *Resolver) ListBlocks(ctx context.Context, tenant string, args map[string]any) (map[string]any, error) {")
+		assert.Contains(t, string(resolvers), "query, err := pb.BuildGetBlocksQuery(req, tenant)")
+		assert.Contains(t, string(resolvers), "return map[string]any{\"blocks\": rows, \"nextPageToken\": token}, nil")
+	})
+
+	t.Run("Resolvers need go_package", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		cfg := config.NewConfig()
+		cfg.OutputDir = tmpDir
+		cfg.GraphQL.Enabled = true
+
+		require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateGraphQL(tables[:1]))
+		assert.FileExists(t, filepath.Join(tmpDir, defaultGraphQLDir, "schema.graphql"))
+		assert.NoFileExists(t, filepath.Join(tmpDir, defaultGraphQLDir, "resolvers.go"))
+	})
+}
+
+func TestGenerator_GraphQLColumnTypes(t *testing.T) {
+	tests := []struct {
+		column   clickhouse.Column
+		field    string
+		argument string
+	}{
+		{clickhouse.NewColumn("block_number", "UInt64", 1), "blockNumber: UInt64!", "blockNumber: UInt64Filter"},
+		{clickhouse.NewColumn("log_index", "UInt32", 2), "logIndex: UInt32!", "logIndex: UInt32Filter"},
+		{clickhouse.NewColumn("to", "Nullable(String)", 3), "to: String", "to: NullableStringFilter"},
+		{clickhouse.NewColumn("amount", "Float64", 4), "amount: Float!", "amount: Float"},
+		{clickhouse.NewColumn("topics", "Array(String)", 5), "topics: [String!]!", "topics: ArrayStringFilter"},
+		{clickhouse.NewColumn("labels", "Map(String, UInt64)", 6), "labels: Map!", "labels: MapStringUInt64Filter"},
+		{commented(clickhouse.NewColumn("memo", "String", 7), "Memo. DEPRECATED: use labels"), "memo: String! @deprecated(reason: \"use labels\")", "memo: StringFilter"},
+	}
+
+	columns := make([]clickhouse.Column, len(tests))
+	for i, tt := range tests {
+		columns[i] = tt.column
+	}
+	cfg := config.NewConfig()
+	cfg.GoPackage = "example.com/gen/testv1"
+	cfg.GraphQL.Enabled = true
+	files := generateColumnTypes(t, cfg, &clickhouse.Table{Name: "transfers", Database: "default", Engine: "MergeTree", SortingKey: []string{"block_number"}}, columns)
+	schema := files[defaultGraphQLDir+"/schema.graphql"]
+
+	// Only the scalars the schema uses are declared
+	for _, scalar := range []string{"UInt64", "UInt32", "Map"} {
+		assert.Contains(t, schema, "\nscalar "+scalar+"\n")
+	}
+	assert.NotContains(t, schema, "scalar Bytes")
+
+	_, row, _ := strings.Cut(schema, "type Transfers {\n")
+	row, _, _ = strings.Cut(row, "\n}\n")
+	_, arguments, _ := strings.Cut(schema, "  listTransfers(\n")
+	arguments, _, _ = strings.Cut(arguments, "  ): ListTransfersResponse!\n")
+	for _, tt := range tests {
+		t.Run(tt.column.Name, func(t *testing.T) {
+			assert.Contains(t, row+"\n", "  "+tt.field+"\n")
+			assert.Contains(t, arguments, "    "+tt.argument+"\n")
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// ErrSortingKeyColumn is returned when the first element of a table's sorting key, which its
+// service's requests filter on, isn't one of its columns
+var ErrSortingKeyColumn = errors.New("sorting key doesn't start with a column")
+
+// identifierEscaper escapes the characters ending a backtick-quoted ClickHouse identifier
+//
+//nolint:gochecknoglobals // Stateless, built once
+var identifierEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+
+// quoteIdentifier quotes a column name for the generated SQL, so that names which are
+// keywords (index, order) or hold spaces, dots or backticks read as a single identifier.
+// The generated common.go quotes the names it receives at runtime with QuoteIdentifier,
+// which must agree with it.
+func quoteIdentifier(name string) string {
+	return "`" + identifierEscaper.Replace(name) + "`"
+}
+
+// sortingKeyClause returns the ORDER BY clause of a table's sorting key. Elements naming a
+// column are quoted; expressions such as toStartOfDay(ts) are kept as written.
+func sortingKeyClause(table *clickhouse.Table) string {
+	keys := make([]string, len(table.SortingKey))
+	for i, key := range table.SortingKey {
+		keys[i] = key
+		if findColumn(table, key) != nil {
+			keys[i] = quoteIdentifier(key)
+		}
+	}
+	return " ORDER BY " + strings.Join(keys, ", ")
+}
+
+// validateSortingKey checks that a table with a service or SQL helpers is sorted by one of
+// its columns first, such as block date in ORDER BY (`block date`, x), rather than by an
+// expression like toStartOfDay(ts), which requests have no field to filter on
+func (g *Generator) validateSortingKey(table *clickhouse.Table) error {
+	if len(table.SortingKey) == 0 || findColumn(table, table.SortingKey[0]) != nil {
+		return nil
+	}
+	if !g.config.Emits(config.EmitServices) && !g.config.Emits(config.EmitSQL) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is sorted by %s", ErrSortingKeyColumn, table.Name, table.SortingKey[0])
+}
+
+// columnsByFieldVariable returns the name of the generated mapping of a table's fields to
+// its columns
+func columnsByFieldVariable(table *clickhouse.Table) string {
+	return getProtocMessageName(table.Name) + "ColumnsByField"
+}
+
+// fieldsByColumnVariable returns the name of the generated mapping of a table's columns to
+// the fields reading them
+func fieldsByColumnVariable(table *clickhouse.Table) string {
+	return getProtocMessageName(table.Name) + "FieldsByColumn"
+}
+
+// writeColumnsByField writes the mapping of a table's proto fields to the columns they read.
+// Requests naming fields are checked against it rather than against lists of their own, so
+// they agree on the fields and their columns. Omitted columns have no field. The reverse
+// mapping follows it, with columns and their fields sorted so lookups don't depend on map
+// order.
+func (g *Generator) writeColumnsByField(sb *strings.Builder, table *clickhouse.Table) {
+	variable := columnsByFieldVariable(table)
+	fmt.Fprintf(sb, "// %s maps the fields of %s to the columns of %s they read\n", variable, getProtocMessageName(table.Name), table.Name)
+	fmt.Fprintf(sb, "var %s = ColumnsByField{\n", variable)
+	for _, col := range table.Columns {
+		if g.isOmitted(table.Name, col.Name) {
+			continue
+		}
+		fmt.Fprintf(sb, "\t%q: %q,\n", g.fieldName(table.Name, col.Name), col.Name)
+	}
+	sb.WriteString("}\n\n")
+
+	fieldsByColumn := make(map[string][]string)
+	for _, col := range table.Columns {
+		if !g.isOmitted(table.Name, col.Name) {
+			fieldsByColumn[col.Name] = append(fieldsByColumn[col.Name], g.fieldName(table.Name, col.Name))
+		}
+	}
+	columns := make([]string, 0, len(fieldsByColumn))
+	for column := range fieldsByColumn {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	reverse := fieldsByColumnVariable(table)
+	fmt.Fprintf(sb, "// %s maps the columns of %s to the fields of %s reading them, sorted\n", reverse, table.Name, getProtocMessageName(table.Name))
+	fmt.Fprintf(sb, "var %s = FieldsByColumn{\n", reverse)
+	for _, column := range columns {
+		fields := fieldsByColumn[column]
+		sort.Strings(fields)
+		quoted := make([]string, len(fields))
+		for i, field := range fields {
+			quoted[i] = strconv.Quote(field)
+		}
+		fmt.Fprintf(sb, "\t%q: {%s},\n", column, strings.Join(quoted, ", "))
+	}
+	sb.WriteString("}\n\n")
+}
@@ -0,0 +1,180 @@
+package protogen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"slot", "`slot`"},
+		{"index", "`index`"},
+		{"block root", "`block root`"},
+		{"a.b", "`a.b`"},
+		{"odd`name", "`odd\\`name`"},
+		{`back\slash`, "`back\\\\slash`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, quoteIdentifier(tt.name))
+		})
+	}
+}
+
+func TestGenerator_ExoticColumnNames(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	table := &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("index", "UInt32", 1),
+			clickhouse.NewColumn("order", "UInt32", 2),
+			clickhouse.NewColumn("block root", "String", 3),
+			clickhouse.NewColumn("meta.version", "UInt8", 4),
+		},
+		SortingKey: []string{"index", "order"},
+	}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	path := filepath.Join(cfg.OutputDir, "fct_block_sql.go")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	sql := string(content)
+
+	// Filters and keys quote the column, while results are aliased to the proto field
+	assert.Contains(t, sql, "qb.AddCondition(\"`index`\", \"=\", filter.Eq)")
+	assert.Contains(t, sql, "qb.AddCondition(\"`order`\", \"=\", filter.Eq)")
+	assert.Contains(t, sql, "`block root` AS `block_root`")
+	assert.Contains(t, sql, "toUInt32(`meta.version`) AS `meta_version`")
+	assert.Contains(t, sql, "orderByClause = \" ORDER BY `index`, `order`\"")
+	assert.Regexp(t, `\t"block_root": +"block root",\n`, sql)
+	// The reverse mapping is written sorted by column
+	assert.Contains(t, sql, "var FctBlockFieldsByColumn = FieldsByColumn{\n\t\"block root\":   {\"block_root\"},\n\t\"index\":        {\"index\"},\n")
+
+	_, err = parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors)
+	require.NoError(t, err)
+}
+
+func TestGenerator_QuotedSortingKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		ddl       string
+		contains  []string
+		expectErr error
+	}{
+		{
+			name: "Column with a space",
+			ddl:  "CREATE TABLE db.t5 (`block date` Date, `x` String) ENGINE = MergeTree ORDER BY (`block date`, x)",
+			contains: []string{
+				"qb.AddCondition(\"`block date`\", \"=\", filter.Eq)",
+				"qb.AddCondition(\"`block date`\", \"=\", req.BlockDate)",
+				"orderByClause = \" ORDER BY `block date`, `x`\"",
+			},
+		},
+		{
+			name: "Column starting with a digit",
+			ddl:  "CREATE TABLE db.t5 (`1col` UInt32, `y` String) ENGINE = MergeTree ORDER BY (`1col`)",
+			contains: []string{
+				"qb.AddCondition(\"`1col`\", \"=\", filter.Eq)",
+				"orderByClause = \" ORDER BY `1col`\"",
+			},
+		},
+		{
+			name:      "Expression",
+			ddl:       "CREATE TABLE db.t5 (ts DateTime, `y` String) ENGINE = MergeTree ORDER BY (toStartOfHour(ts))",
+			expectErr: ErrSortingKeyColumn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables, err := clickhouse.ParseDDL(tt.ddl)
+			require.NoError(t, err)
+
+			cfg := config.NewConfig()
+			cfg.OutputDir = t.TempDir()
+			cfg.OnError = config.OnErrorFail
+			log := logrus.New()
+			log.SetLevel(logrus.FatalLevel)
+			err = NewGenerator(cfg, log).Generate(tables)
+			if tt.expectErr != nil {
+				require.ErrorIs(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "t5_sql.go"))
+			require.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, string(content), want)
+			}
+		})
+	}
+}
+
+// fieldsByColumnTest runs in the generated package and checks the reverse lookups
+const fieldsByColumnTest = `package testv1
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFieldsByColumn(t *testing.T) {
+	if field, ok := FctBlockFieldsByColumn.Field("slot_number"); !ok || field != "slot" {
+		t.Errorf("slot_number is read by %q", field)
+	}
+	if _, ok := FctBlockFieldsByColumn.Field("slot"); ok {
+		t.Error("the renamed column has a field")
+	}
+
+	// Columns read by several fields resolve to the first of them, whatever the map order
+	fields := ColumnsByField{"d": "x", "b": "x", "c": "x", "a": "x", "e": "y"}
+	for i := 0; i < 20; i++ {
+		if field, _ := fields.Field("x"); field != "a" {
+			t.Fatalf("x is read by %q", field)
+		}
+	}
+	if got := fields.FieldsByColumn()["x"]; len(got) != 4 || got[0] != "a" || got[3] != "d" {
+		t.Errorf("x is read by %v", got)
+	}
+	var err *UnknownColumnError
+	if !errors.As(fields.unknownField("order_by", "x"), &err) || err.SuggestedField != "a" {
+		t.Errorf("x suggests %q", err.SuggestedField)
+	}
+}
+`
+
+func TestGenerator_FieldsByColumnValues(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.GoPackage = "example.com/gen/testv1"
+	cfg.RenamedColumns = map[string]map[string]string{"fct_block": {"slot": "slot_number"}}
+	generateModule(t, cfg, []*clickhouse.Table{{
+		Name:   "fct_block",
+		Engine: "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot_number", "UInt32", 1),
+			clickhouse.NewColumn("block_root", "String", 2),
+		},
+		SortingKey: []string{"slot_number"},
+	}})
+
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "fields_test.go"), []byte(fieldsByColumnTest), 0o600))
+	goCommand(t, cfg.OutputDir, "test", "-run", "TestFieldsByColumn", ".")
+}
@@ -0,0 +1,351 @@
+package protogen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// defaultMaxInsertRows is the number of rows an Insert request may carry when
+// max_insert_rows is unset
+const defaultMaxInsertRows = 10000
+
+// insertEnabled reports whether a table gets an Insert RPC and INSERT builder: a policy
+// turns inserts on and the table stores its rows itself
+func (g *Generator) insertEnabled(table *clickhouse.Table) bool {
+	if len(table.SortingKey) == 0 || !g.config.Policy(table.Name).Insert {
+		return false
+	}
+	if clickhouse.IsView(table) {
+		g.log.WithField("table", table.Name).Debug("Skipping Insert: views can't be inserted into")
+		return false
+	}
+	return true
+}
+
+// maxInsertRows returns the number of rows an Insert request may carry
+func (g *Generator) maxInsertRows() int {
+	if g.config.MaxInsertRows > 0 {
+		return g.config.MaxInsertRows
+	}
+	return defaultMaxInsertRows
+}
+
+// insertColumns returns the columns an Insert writes with their message fields. Computed
+// columns can't be inserted, masked columns don't hold the stored values in their fields,
+// and omitted columns have none; ClickHouse fills them all with their defaults.
+func (g *Generator) insertColumns(table *clickhouse.Table) ([]*clickhouse.Column, []*ProtoField) {
+	fields, _ := g.messageFields(table)
+	fieldsByName := make(map[string]*ProtoField, len(fields))
+	for _, field := range fields {
+		fieldsByName[field.Name] = field
+	}
+
+	var (
+		columns      []*clickhouse.Column
+		columnFields []*ProtoField
+	)
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		field := fieldsByName[g.fieldName(table.Name, col.Name)]
+		if field == nil || isComputedColumn(col) || g.isMasked(table.Name, col.Name) {
+			continue
+		}
+		columns = append(columns, col)
+		columnFields = append(columnFields, field)
+	}
+	return columns, columnFields
+}
+
+// getInsertValueExpression returns the SQL expression a column's value is inserted with, ?
+// standing for the value. It reverses the conversion the column is read with: values read
+// as they are stored are inserted as they are, others are cast back to the column type.
+func getInsertValueExpression(col *clickhouse.Column, tableName string, convConfig *config.ConversionConfig) string {
+	switch {
+	case getSelectColumnExpression(col, tableName, convConfig) == col.Name:
+		return "?"
+	case col.BaseType == clickhouseDateTime64 && col.IsArray:
+		return fmt.Sprintf("CAST(arrayMap(x -> fromUnixTimestamp64Micro(toInt64(x)), ?) AS %s)", col.Type)
+	case col.BaseType == clickhouseDateTime64:
+		// Casting a number to DateTime64 would read it as seconds rather than microseconds
+		return fmt.Sprintf("CAST(fromUnixTimestamp64Micro(toInt64(?)) AS %s)", col.Type)
+	default:
+		return fmt.Sprintf("CAST(? AS %s)", col.Type)
+	}
+}
+
+// writeInsertMessages writes the request and response messages of the Insert RPC
+func (g *Generator) writeInsertMessages(sb *strings.Builder, table *clickhouse.Table, tenant *tenantScope) {
+	if !g.insertEnabled(table) {
+		return
+	}
+
+	messageName := ToPascalCase(table.Name)
+	required := ""
+	if g.shouldGenerateAPI(table.Name) {
+		required = " [(google.api.field_behavior) = REQUIRED]"
+	}
+
+	fmt.Fprintf(sb, "// Request for inserting %s records\n", table.Name)
+	fmt.Fprintf(sb, "message Insert%sRequest {\n", messageName)
+	fmt.Fprintf(sb, "  // The records to insert in one batch, at most %d.\n", g.maxInsertRows())
+	fmt.Fprintf(sb, "  // Computed and masked columns are left to their ClickHouse defaults.\n")
+	fmt.Fprintf(sb, "  repeated %s rows = 1%s;\n", messageName, required)
+	if tenant != nil {
+		g.writeTenantRequestField(sb, table, tenant, 2)
+	}
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// Response for inserting %s records\n", table.Name)
+	fmt.Fprintf(sb, "message Insert%sResponse {\n", messageName)
+	fmt.Fprintf(sb, "  // Number of records inserted\n")
+	fmt.Fprintf(sb, "  uint64 inserted_rows = 1;\n")
+	sb.WriteString("}\n\n")
+}
+
+// writeInsertRPC writes the Insert RPC of a table's service
+func (g *Generator) writeInsertRPC(sb *strings.Builder, table *clickhouse.Table, deprecationComment, deprecationOption string) {
+	if !g.insertEnabled(table) {
+		return
+	}
+
+	messageName := ToPascalCase(table.Name)
+	fmt.Fprintf(sb, "  // Insert records | Write a batch of records\n")
+	sb.WriteString(deprecationComment)
+	if !g.shouldGenerateAPI(table.Name) {
+		writeRPC(sb, "Insert", messageName, deprecationOption)
+		return
+	}
+	fmt.Fprintf(sb, "  rpc Insert(Insert%sRequest) returns (Insert%sResponse) {\n", messageName, messageName)
+	sb.WriteString(deprecationOption)
+	fmt.Fprintf(sb, "    option (google.api.http) = {\n")
+	fmt.Fprintf(sb, "      post: \"%s/%s:insert\"\n", g.config.APIBasePath, table.Name)
+	fmt.Fprintf(sb, "      body: \"*\"\n")
+	fmt.Fprintf(sb, "    };\n")
+	fmt.Fprintf(sb, "  }\n")
+}
+
+// writeInsertSQLBuilderFunction generates the SQL query builder function for an Insert
+// request, which writes every row with one INSERT ... VALUES statement
+func (g *Generator) writeInsertSQLBuilderFunction(sb *strings.Builder, table *clickhouse.Table) {
+	if !g.insertEnabled(table) {
+		return
+	}
+
+	messageName := getProtocMessageName(table.Name)
+	tenant, _ := g.tenantScopeFor(table)
+
+	fmt.Fprintf(sb, "\n// BuildInsert%sQuery constructs a parameterized INSERT of the rows of an Insert%sRequest.\n", messageName, messageName)
+	fmt.Fprintf(sb, "// Values are converted back to the column types the way BuildList%sQuery converts them.\n", messageName)
+	fmt.Fprintf(sb, "func BuildInsert%sQuery(req *Insert%sRequest%s, options ...QueryOption) (SQLQuery, error) {\n", messageName, messageName, tenantParam(tenant))
+	g.writeInsertBody(sb, table, tenant, nil)
+}
+
+// writeInsertBody writes the body of an INSERT builder taking an Insert request. With a
+// version, rows without one get the current time as their version.
+func (g *Generator) writeInsertBody(sb *strings.Builder, table *clickhouse.Table, tenant *tenantScope, version *upsertVersion) {
+	columns, fields := g.insertColumns(table)
+
+	fmt.Fprintf(sb, "\tif len(req.GetRows()) == 0 {\n")
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"rows are required\")\n")
+	fmt.Fprintf(sb, "\t}\n")
+	fmt.Fprintf(sb, "\tif len(req.GetRows()) > %d {\n", g.maxInsertRows())
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"rows must not exceed %%d, got %%d\", %d, len(req.GetRows()))\n", g.maxInsertRows())
+	fmt.Fprintf(sb, "\t}\n")
+	if tenant != nil {
+		zero := tenant.zeroValue()
+		fmt.Fprintf(sb, "\tif tenant == %s {\n", zero)
+		fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"tenant is required\")\n")
+		fmt.Fprintf(sb, "\t}\n")
+		fmt.Fprintf(sb, "\tif req.%s != %s && req.%s != tenant {\n", tenant.goFieldName(), zero, tenant.goFieldName())
+		fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"request %s does not match the authorized tenant\")\n", tenant.field)
+		fmt.Fprintf(sb, "\t}\n")
+	}
+	sb.WriteString("\n")
+
+	fmt.Fprintf(sb, "\tcolumns := []string{")
+	for i, col := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(sb, "%q", col.Name)
+	}
+	sb.WriteString("}\n")
+	fmt.Fprintf(sb, "\tvalues := []string{")
+	for i, col := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(sb, "%q", getInsertValueExpression(col, table.Name, &g.config.Conversion))
+	}
+	sb.WriteString("}\n\n")
+
+	if version != nil {
+		fmt.Fprintf(sb, "\tnow := time.Now()\n")
+	}
+	fmt.Fprintf(sb, "\targs := make([]interface{}, 0, len(req.GetRows())*len(columns))\n")
+	fmt.Fprintf(sb, "\tfor i, row := range req.GetRows() {\n")
+	fmt.Fprintf(sb, "\t\tif row == nil {\n")
+	fmt.Fprintf(sb, "\t\t\treturn SQLQuery{}, fmt.Errorf(\"rows[%%d] is empty\", i)\n")
+	fmt.Fprintf(sb, "\t\t}\n")
+
+	args := make([]string, 0, len(columns))
+	for i, col := range columns {
+		field := fields[i]
+		if tenant != nil && col.Name == tenant.column {
+			getter := fmt.Sprintf("row.Get%s()", ToPascalCase(field.Name))
+			fmt.Fprintf(sb, "\t\tif %s != %s && %s != tenant {\n", getter, tenant.zeroValue(), getter)
+			fmt.Fprintf(sb, "\t\t\treturn SQLQuery{}, fmt.Errorf(\"rows[%%d] %s does not match the authorized tenant\", i)\n", field.Name)
+			fmt.Fprintf(sb, "\t\t}\n")
+			args = append(args, "tenant")
+			continue
+		}
+		if version != nil && col.Name == version.column {
+			fmt.Fprintf(sb, "\t\tversion := row.Get%s()\n", ToPascalCase(field.Name))
+			fmt.Fprintf(sb, "\t\tif version == 0 {\n")
+			fmt.Fprintf(sb, "\t\t\tversion = %s\n", version.now)
+			fmt.Fprintf(sb, "\t\t}\n")
+			args = append(args, "version")
+			continue
+		}
+		args = append(args, g.writeInsertArg(sb, field, "\t\t"))
+	}
+	fmt.Fprintf(sb, "\t\targs = append(args, %s)\n", strings.Join(args, ", "))
+	fmt.Fprintf(sb, "\t}\n\n")
+
+	fmt.Fprintf(sb, "\treturn BuildInsertQuery(\"%s\", columns, values, args, options...)\n", table.Name)
+	fmt.Fprintf(sb, "}\n")
+}
+
+// writeInsertArg returns the Go expression of a row's value for a message field. NULLs of
+// nullable fields are written to a variable first, indented by indent, as nil must reach
+// the driver untyped.
+func (g *Generator) writeInsertArg(sb *strings.Builder, field *ProtoField, indent string) string {
+	name := ToPascalCase(field.Name)
+	getter := fmt.Sprintf("row.Get%s()", name)
+
+	scalar := wrapperScalar(field.Type)
+	presence := slices.Contains(field.Options, explicitPresenceOption)
+	if scalar == "" && !presence {
+		if field.Type == protoBytes {
+			return fmt.Sprintf("string(%s)", getter)
+		}
+		return getter
+	}
+
+	variable := "value" + name
+	value := getter
+	isSet := fmt.Sprintf("row.%s != nil", name)
+	if !presence {
+		value = getter + ".GetValue()"
+		isSet = getter + " != nil"
+	}
+	if scalar == protoBytes || field.Type == protoBytes {
+		value = fmt.Sprintf("string(%s)", value)
+	}
+
+	fmt.Fprintf(sb, "%svar %s interface{}\n", indent, variable)
+	fmt.Fprintf(sb, "%sif %s {\n", indent, isSet)
+	fmt.Fprintf(sb, "%s\t%s = %s\n", indent, variable, value)
+	fmt.Fprintf(sb, "%s}\n", indent)
+	return variable
+}
+
+// writeInsertSQLFunctions writes BuildInsertQuery into common.go when any table has an
+// Insert RPC
+func (g *Generator) writeInsertSQLFunctions(sb *strings.Builder) {
+	if !slices.ContainsFunc(g.tables, g.insertEnabled) {
+		return
+	}
+
+	sb.WriteString(`
+// BuildInsertQuery constructs a parameterized INSERT ... VALUES of rows into table. values
+// holds the SQL expression of each column, with ? standing for its value, and args the
+// values of every row in column order.
+func BuildInsertQuery(table string, columns, values []string, args []interface{}, options ...QueryOption) (SQLQuery, error) {
+	opts := &QueryOptions{` + g.defaultAsyncInsert() + `}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if len(columns) == 0 || len(columns) != len(values) {
+		return SQLQuery{}, fmt.Errorf("every inserted column needs one value expression")
+	}
+	if len(args) == 0 || len(args)%len(columns) != 0 {
+		return SQLQuery{}, fmt.Errorf("got %d values for %d columns", len(args), len(columns))
+	}
+
+	escapedColumns := make([]string, 0, len(columns))
+	for _, col := range columns {
+		escapedColumns = append(escapedColumns, QuoteIdentifier(col))
+	}
+
+	target := quoteTable(opts.Database, table)
+
+	row := "(" + strings.Join(values, ", ") + ")"
+	rows := make([]string, len(args)/len(columns))
+	for i := range rows {
+		rows[i] = row
+	}
+
+	settings := ""
+	if opts.AsyncInsert != nil {
+		wait := 0
+		if opts.AsyncInsert.Wait {
+			wait = 1
+		}
+		settings = fmt.Sprintf(" SETTINGS async_insert = 1, wait_for_async_insert = %d", wait)
+	}
+
+	return SQLQuery{
+		Query: fmt.Sprintf("INSERT INTO %s (%s)%s VALUES %s", target, strings.Join(escapedColumns, ", "), settings, strings.Join(rows, ", ")),
+		Args:  args,
+	}, nil
+}
+
+// AsyncInsert buffers the rows of an INSERT on the server (ClickHouse async_insert), which
+// writes buffered rows of many small INSERTs together instead of a part per INSERT
+type AsyncInsert struct {
+	// Wait returns only once the buffer holding the rows is flushed (wait_for_async_insert)
+	Wait bool
+}
+
+// WithAsyncInsert buffers an INSERT on the server. With wait it returns once the rows are
+// written; without, as soon as they are buffered, losing them if the server fails first.
+func WithAsyncInsert(wait bool) QueryOption {
+	return func(opts *QueryOptions) {
+		opts.AsyncInsert = &AsyncInsert{Wait: wait}
+	}
+}
+
+// WithoutAsyncInsert writes the rows of an INSERT before returning, whatever the default
+func WithoutAsyncInsert() QueryOption {
+	return func(opts *QueryOptions) {
+		opts.AsyncInsert = nil
+	}
+}
+`)
+}
+
+// writeAsyncInsertQueryOption writes the AsyncInsert field of QueryOptions when any table
+// has an Insert RPC
+func (g *Generator) writeAsyncInsertQueryOption(sb *strings.Builder) {
+	if !slices.ContainsFunc(g.tables, g.insertEnabled) {
+		return
+	}
+	sb.WriteString("\t// AsyncInsert buffers INSERTs on the server; nil writes them before returning\n")
+	sb.WriteString("\tAsyncInsert *AsyncInsert\n")
+}
+
+// defaultAsyncInsert returns the QueryOptions field setting the async_insert config as the
+// default of BuildInsertQuery
+func (g *Generator) defaultAsyncInsert() string {
+	cfg := g.config.AsyncInsert
+	if !cfg.Enabled {
+		return ""
+	}
+	return fmt.Sprintf("AsyncInsert: &AsyncInsert{Wait: %t}", cfg.Wait == nil || *cfg.Wait)
+}
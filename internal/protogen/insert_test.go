@@ -0,0 +1,136 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Insert(t *testing.T) {
+	events := &clickhouse.Table{
+		Name:     "fct_events",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("network", "String", 2),
+			clickhouse.NewColumn("seen_at", "DateTime64(3)", 3),
+			clickhouse.NewColumn("client", "Nullable(String)", 4),
+			clickhouse.NewColumn("peer_id", "String", 5),
+			computedColumn("slot_day", "Date", "MATERIALIZED", 6),
+		},
+		SortingKey: []string{"slot"},
+	}
+	view := &clickhouse.Table{
+		Name:       "fct_events_view",
+		Database:   "default",
+		Engine:     "View",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+
+	on := true
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.EnableAPI = true
+	cfg.MaxInsertRows = 500
+	cfg.Policies = []config.PolicyConfig{{Match: "fct_", Insert: &on}}
+	cfg.Columns = map[string]map[string]config.ColumnConfig{"fct_events": {"peer_id": {Mask: config.MaskHash}}}
+	cfg.Tenant = config.TenantConfig{Column: "network", ExemptTables: []string{"fct_events_view"}}
+
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{events, view}))
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	proto := read("fct_events.proto")
+	assert.Contains(t, proto, "message InsertFctEventsRequest {")
+	assert.Contains(t, proto, "repeated FctEvents rows = 1 [(google.api.field_behavior) = REQUIRED];")
+	assert.Contains(t, proto, "string tenant = 2 [(google.api.field_behavior) = REQUIRED];")
+	assert.Contains(t, proto, "uint64 inserted_rows = 1;")
+	assert.Contains(t, proto, "rpc Insert(InsertFctEventsRequest) returns (InsertFctEventsResponse)")
+	assert.Contains(t, proto, `post: "/api/v1/fct_events:insert"`)
+
+	sql := read("fct_events_sql.go")
+	assert.Contains(t, sql, "func BuildInsertFctEventsQuery(req *InsertFctEventsRequest, tenant string, options ...QueryOption) (SQLQuery, error) {")
+	assert.Contains(t, sql, "if len(req.GetRows()) > 500 {")
+	// Masked and computed columns are left to their defaults
+	assert.Contains(t, sql, `columns := []string{"slot", "network", "seen_at", "client"}`)
+	assert.Contains(t, sql, `values := []string{"?", "?", "CAST(fromUnixTimestamp64Micro(toInt64(?)) AS DateTime64(3))", "?"}`)
+	assert.Contains(t, sql, "if row.GetNetwork() != \"\" && row.GetNetwork() != tenant {")
+	assert.Contains(t, sql, "if row.GetClient() != nil {\n\t\t\tvalueClient = row.GetClient().GetValue()")
+	assert.Contains(t, sql, "args = append(args, row.GetSlot(), tenant, row.GetSeenAt(), valueClient)")
+	assert.Contains(t, sql, `return BuildInsertQuery("fct_events", columns, values, args, options...)`)
+
+	assert.NotContains(t, read("fct_events_view.proto"), "Insert", "views can't be inserted into")
+	assert.Contains(t, read("common.go"), "func BuildInsertQuery(table string, columns, values []string, args []interface{}, options ...QueryOption) (SQLQuery, error) {")
+}
+
+func TestGenerator_InsertAsync(t *testing.T) {
+	generate := func(t *testing.T, asyncInsert config.AsyncInsertConfig) string {
+		t.Helper()
+		on := true
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.Policies = []config.PolicyConfig{{Match: "*", Insert: &on}}
+		cfg.AsyncInsert = asyncInsert
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{tenantTestTable()}))
+		common, err := os.ReadFile(filepath.Join(cfg.OutputDir, "common.go"))
+		require.NoError(t, err)
+		return string(common)
+	}
+
+	common := generate(t, config.AsyncInsertConfig{})
+	assert.Contains(t, common, "\tAsyncInsert *AsyncInsert\n")
+	assert.Contains(t, common, "func WithAsyncInsert(wait bool) QueryOption {")
+	assert.Contains(t, common, "func WithoutAsyncInsert() QueryOption {")
+	assert.Contains(t, common, "\topts := &QueryOptions{}\n")
+	assert.Contains(t, common, `settings = fmt.Sprintf(" SETTINGS async_insert = 1, wait_for_async_insert = %d", wait)`)
+
+	assert.Contains(t, generate(t, config.AsyncInsertConfig{Enabled: true}), "opts := &QueryOptions{AsyncInsert: &AsyncInsert{Wait: true}}")
+	noWait := false
+	assert.Contains(t, generate(t, config.AsyncInsertConfig{Enabled: true, Wait: &noWait}), "opts := &QueryOptions{AsyncInsert: &AsyncInsert{Wait: false}}")
+}
+
+func TestGenerator_InsertOff(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{tenantTestTable()}))
+
+	common, err := os.ReadFile(filepath.Join(cfg.OutputDir, "common.go"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(common), "BuildInsertQuery", "inserts are opt-in")
+	assert.NotContains(t, string(common), "AsyncInsert")
+}
+
+func TestGetInsertValueExpression(t *testing.T) {
+	conv := config.NewConfig().Conversion
+	tests := []struct {
+		chType   string
+		expected string
+	}{
+		{"UInt64", "?"},
+		{"UInt8", "CAST(? AS UInt8)"},
+		{"DateTime", "CAST(? AS DateTime)"},
+		{"Date", "CAST(? AS Date)"},
+		{"Decimal(38, 18)", "CAST(? AS Decimal(38, 18))"},
+		{"Nullable(DateTime64(6))", "CAST(fromUnixTimestamp64Micro(toInt64(?)) AS Nullable(DateTime64(6)))"},
+		{"Array(DateTime64(3))", "CAST(arrayMap(x -> fromUnixTimestamp64Micro(toInt64(x)), ?) AS Array(DateTime64(3)))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chType, func(t *testing.T) {
+			column := clickhouse.NewColumn("value", tt.chType, 1)
+			assert.Equal(t, tt.expected, getInsertValueExpression(&column, "fct_table", &conv))
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+const (
+	defaultJavaDir     = "java"
+	defaultJavaPackage = "models"
+	javaHeader         = "// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n"
+	javaEmpty          = "Map<String, Object>"
+)
+
+// javaKeywords can't be record component names; components named after them get a
+// trailing underscore
+//
+//nolint:gochecknoglobals // Lookup table
+var javaKeywords = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true, "case": true,
+	"catch": true, "char": true, "class": true, "const": true, "continue": true, "default": true,
+	"do": true, "double": true, "else": true, "enum": true, "extends": true, "false": true,
+	"final": true, "finally": true, "float": true, "for": true, "goto": true, "if": true,
+	"implements": true, "import": true, "instanceof": true, "int": true, "interface": true,
+	"long": true, "native": true, "new": true, "null": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "short": true, "static": true,
+	"strictfp": true, "super": true, "switch": true, "synchronized": true, "this": true,
+	"throw": true, "throws": true, "transient": true, "true": true, "try": true, "void": true,
+	"volatile": true, "while": true, "_": true,
+	// Methods every record has, which an accessor can't override
+	"hashCode": true, "toString": true, "getClass": true, "notify": true, "notifyAll": true, "wait": true,
+}
+
+// GenerateJava generates a Java record for every message, one file each, in the package
+// directory under the Java source root. Components are named after the proto JSON names
+// and use boxed types, so the records map to and from the REST API's JSON with any mapper
+// supporting records and unset fields are null.
+func (g *Generator) GenerateJava(tables []*clickhouse.Table) error {
+	pkg := g.javaPackage()
+	dir := filepath.Join(g.emitterDir(g.config.Java.Dir, defaultJavaDir), filepath.FromSlash(strings.ReplaceAll(pkg, ".", "/")))
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create Java directory: %w", err)
+	}
+
+	messages := g.commonMessages()
+	for _, table := range tables {
+		messages = append(messages, g.tableMessages(table)...)
+	}
+
+	for _, msg := range messages {
+		if err := g.writeFile(filepath.Join(dir, msg.Name+".java"), buildJavaRecord(pkg, msg)); err != nil {
+			return err
+		}
+	}
+
+	return g.writeFile(filepath.Join(dir, "package-info.java"), buildJavaPackageInfo(pkg))
+}
+
+// javaPackage returns the package of the records
+func (g *Generator) javaPackage() string {
+	switch {
+	case g.config.Java.Package != "":
+		return g.config.Java.Package
+	case g.config.Package != "":
+		return g.config.Package
+	}
+	return defaultJavaPackage
+}
+
+func buildJavaPackageInfo(pkg string) string {
+	sb := &strings.Builder{}
+	sb.WriteString(javaHeader)
+	sb.WriteString("\n/**\n")
+	sb.WriteString(" * Records of the generated protobuf messages, matching their proto JSON mapping.\n")
+	sb.WriteString(" *\n")
+	sb.WriteString(" * <p>64-bit integers are strings, as in the JSON, and unset fields are null. Serialize\n")
+	sb.WriteString(" * without null fields, e.g. with Jackson's {@code JsonInclude.Include.NON_NULL}, since\n")
+	sb.WriteString(" * the API rejects filters with more than one condition present.\n")
+	sb.WriteString(" */\n")
+	fmt.Fprintf(sb, "package %s;\n", pkg)
+	return sb.String()
+}
+
+func buildJavaRecord(pkg string, msg protoMessage) string {
+	sb := &strings.Builder{}
+	sb.WriteString(javaHeader)
+	fmt.Fprintf(sb, "package %s;\n", pkg)
+
+	var imports []string
+	for _, field := range msg.Fields {
+		if field.Repeated && !slices.Contains(imports, "java.util.List") {
+			imports = append(imports, "java.util.List")
+		}
+		if (field.IsMap() || field.Type == protoEmpty) && !slices.Contains(imports, "java.util.Map") {
+			imports = append(imports, "java.util.Map")
+		}
+	}
+	if len(imports) > 0 {
+		sb.WriteString("\n")
+		slices.Sort(imports)
+		for _, imp := range imports {
+			fmt.Fprintf(sb, "import %s;\n", imp)
+		}
+	}
+
+	sb.WriteString("\n")
+	writeJavadoc(sb, msg)
+	if msg.Deprecated {
+		sb.WriteString("@Deprecated\n")
+	}
+
+	if len(msg.Fields) == 0 {
+		fmt.Fprintf(sb, "public record %s() {\n}\n", msg.Name)
+		return sb.String()
+	}
+
+	fmt.Fprintf(sb, "public record %s(\n", msg.Name)
+	for i, field := range msg.Fields {
+		annotation := ""
+		if field.Deprecated {
+			annotation = "@Deprecated "
+		}
+		separator := ","
+		if i == len(msg.Fields)-1 {
+			separator = ""
+		}
+		fmt.Fprintf(sb, "    %s%s %s%s\n", annotation, javaFieldType(field), javaComponentName(field), separator)
+	}
+	sb.WriteString(") {\n")
+	writeJavaFactories(sb, msg)
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// writeJavadoc documents a record and its components
+func writeJavadoc(sb *strings.Builder, msg protoMessage) {
+	var params []protoField
+	for _, field := range msg.Fields {
+		if field.Comment != "" {
+			params = append(params, field)
+		}
+	}
+	if msg.Comment == "" && len(params) == 0 {
+		return
+	}
+
+	sb.WriteString("/**\n")
+	if msg.Comment != "" {
+		writeJavadocLines(sb, "", msg.Comment)
+		if len(params) > 0 {
+			sb.WriteString(" *\n")
+		}
+	}
+	for _, field := range params {
+		writeJavadocLines(sb, fmt.Sprintf("@param %s ", javaComponentName(field)), field.Comment)
+	}
+	sb.WriteString(" */\n")
+}
+
+func writeJavadocLines(sb *strings.Builder, prefix, text string) {
+	text = strings.ReplaceAll(text, "*/", "*&#47;")
+	for i, line := range strings.Split(text, "\n") {
+		if i == 0 {
+			line = prefix + line
+		}
+		if line == "" {
+			sb.WriteString(" *\n")
+			continue
+		}
+		fmt.Fprintf(sb, " * %s\n", line)
+	}
+}
+
+// writeJavaFactories adds a factory per condition to messages that are a single oneof, such
+// as the filters, so that a filter is built with exactly one condition set, e.g.
+// UInt64Filter.ofEq("5")
+func writeJavaFactories(sb *strings.Builder, msg protoMessage) {
+	oneof := msg.Fields[0].Oneof
+	if oneof == "" || slices.ContainsFunc(msg.Fields, func(f protoField) bool { return f.Oneof != oneof }) {
+		return
+	}
+
+	for i, field := range msg.Fields {
+		args := make([]string, len(msg.Fields))
+		for j := range args {
+			args[j] = "null"
+		}
+
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if field.Comment != "" {
+			fmt.Fprintf(sb, "    /** %s */\n", strings.ReplaceAll(field.Comment, "*/", "*&#47;"))
+		}
+		if field.Deprecated {
+			sb.WriteString("    @Deprecated\n")
+		}
+		name := "of" + ToPascalCase(field.Name)
+		if field.Type == protoEmpty {
+			args[i] = "Map.of()"
+			fmt.Fprintf(sb, "    public static %s %s() {\n", msg.Name, name)
+		} else {
+			args[i] = "value"
+			fmt.Fprintf(sb, "    public static %s %s(%s value) {\n", msg.Name, name, javaFieldType(field))
+		}
+		fmt.Fprintf(sb, "        return new %s(%s);\n", msg.Name, strings.Join(args, ", "))
+		sb.WriteString("    }\n")
+	}
+}
+
+// javaComponentName returns the record component of a field: its JSON name, suffixed when
+// it is a keyword
+func javaComponentName(field protoField) string {
+	name := field.JSONName()
+	if javaKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// javaFieldType returns the type of a record component
+func javaFieldType(field protoField) string {
+	value := javaValueType(field.Type)
+	switch {
+	case field.IsMap():
+		return fmt.Sprintf("Map<%s, %s>", javaValueType(field.MapKey), value)
+	case field.Repeated:
+		return fmt.Sprintf("List<%s>", value)
+	}
+	return value
+}
+
+// javaValueType maps a proto value type to a boxed Java type. uint32 needs a Long to hold
+// its range, while 64-bit integers and base64 bytes are strings as in the JSON.
+func javaValueType(protoType string) string {
+	if protoType == protoEmpty {
+		return javaEmpty
+	}
+
+	scalar := wrapperScalar(protoType)
+	if scalar == "" {
+		scalar = protoType
+	}
+
+	switch scalar {
+	case protoInt32:
+		return "Integer"
+	case protoUInt32:
+		return "Long"
+	case protoFloat:
+		return "Float"
+	case protoDouble:
+		return "Double"
+	case protoBool:
+		return "Boolean"
+	case protoInt64, protoUInt64, protoString, protoBytes:
+		return "String"
+	}
+	return protoType
+}
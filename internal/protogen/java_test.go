@@ -0,0 +1,164 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJavaFieldType(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    protoField
+		expected string
+	}{
+		{name: "int32", field: protoField{Type: protoInt32}, expected: "Integer"},
+		{name: "uint32 needs a long", field: protoField{Type: protoUInt32}, expected: "Long"},
+		{name: "Big integer as string", field: protoField{Type: protoUInt64}, expected: "String"},
+		{name: "Wrapper", field: protoField{Type: "google.protobuf.DoubleValue"}, expected: "Double"},
+		{name: "Repeated", field: protoField{Type: protoBool, Repeated: true}, expected: "List<Boolean>"},
+		{name: "Map", field: protoField{Type: protoInt64, MapKey: protoString}, expected: "Map<String, String>"},
+		{name: "Empty", field: protoField{Type: protoEmpty}, expected: "Map<String, Object>"},
+		{name: "Message", field: protoField{Type: "Blocks"}, expected: "Blocks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, javaFieldType(tt.field))
+		})
+	}
+}
+
+func TestJavaComponentName(t *testing.T) {
+	assert.Equal(t, "blockNumber", javaComponentName(protoField{Name: "block_number"}))
+	assert.Equal(t, "default_", javaComponentName(protoField{Name: "default"}))
+	assert.Equal(t, "hashCode_", javaComponentName(protoField{Name: "hash_code"}))
+}
+
+func TestBuildJavaRecord(t *testing.T) {
+	t.Run("Message", func(t *testing.T) {
+		content := buildJavaRecord("test.v1", protoMessage{
+			Name:       "Blocks",
+			Comment:    "Beacon blocks",
+			Deprecated: true,
+			Fields: []protoField{
+				{Name: "slot", Type: protoUInt32, Comment: "The slot */ number"},
+				{Name: "tags", Type: protoString, Repeated: true, Deprecated: true},
+			},
+		})
+		assert.Equal(t, `// Code generated by clickhouse-proto-gen. DO NOT EDIT.
+package test.v1;
+
+import java.util.List;
+
+/**
+ * Beacon blocks
+ *
+ * @param slot The slot *&#47; number
+ */
+@Deprecated
+public record Blocks(
+    Long slot,
+    @Deprecated List<String> tags
+) {
+}
+`, content)
+	})
+
+	t.Run("Filter factories", func(t *testing.T) {
+		content := buildJavaRecord("test.v1", protoMessage{
+			Name: "NullableUInt64Filter",
+			Fields: []protoField{
+				{Name: "eq", Type: protoUInt64, Oneof: "filter", Comment: "Equal to value"},
+				{Name: "is_null", Type: protoEmpty, Oneof: "filter"},
+			},
+		})
+		assert.Contains(t, content, "import java.util.Map;\n")
+		assert.Contains(t, content, `    /** Equal to value */
+    public static NullableUInt64Filter ofEq(String value) {
+        return new NullableUInt64Filter(value, null);
+    }
+
+    public static NullableUInt64Filter ofIsNull() {
+        return new NullableUInt64Filter(null, Map.of());
+    }
+`)
+	})
+}
+
+func TestGenerator_GenerateJava(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tmpDir
+	cfg.Package = "test.v1"
+	cfg.Java.Enabled = true
+	cfg.Java.Package = "com.acme.chain"
+
+	tables := []*clickhouse.Table{
+		{
+			Name:       "blocks",
+			SortingKey: []string{"slot"},
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1},
+			},
+		},
+	}
+
+	require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateJava(tables))
+
+	dir := filepath.Join(tmpDir, defaultJavaDir, "com", "acme", "chain")
+	for _, name := range []string{"package-info.java", "UInt32Filter.java", "Blocks.java", "ListBlocksRequest.java"} {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "GetBlocksRequest.java"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "package com.acme.chain;\n")
+	assert.Contains(t, string(content), "public record GetBlocksRequest(\n    Long slot\n) {\n}\n")
+}
+
+func TestGenerator_JavaColumnTypes(t *testing.T) {
+	tests := []struct {
+		column    clickhouse.Column
+		component string
+		param     string
+	}{
+		{clickhouse.NewColumn("block_number", "UInt64", 1), "String blockNumber", ""},
+		{clickhouse.NewColumn("log_index", "UInt32", 2), "Long logIndex", ""},
+		{commented(clickhouse.NewColumn("from", "String", 3), "Sender address"), "String from", " * @param from Sender address\n"},
+		{clickhouse.NewColumn("to", "Nullable(String)", 4), "String to", ""},
+		{clickhouse.NewColumn("amount", "Float64", 5), "Double amount", ""},
+		{clickhouse.NewColumn("fee", "Nullable(Int64)", 6), "String fee", ""},
+		{clickhouse.NewColumn("topics", "Array(String)", 7), "List<String> topics", ""},
+		{clickhouse.NewColumn("labels", "Map(String, UInt64)", 8), "Map<String, String> labels", ""},
+		{clickhouse.NewColumn("is_contract", "Bool", 9), "Boolean isContract", ""},
+		{commented(clickhouse.NewColumn("memo", "String", 10), "Memo. DEPRECATED: use labels"), "@Deprecated String memo", " * @param memo Memo. DEPRECATED: use labels\n"},
+	}
+
+	columns := make([]clickhouse.Column, len(tests))
+	for i, tt := range tests {
+		columns[i] = tt.column
+	}
+	cfg := config.NewConfig()
+	cfg.Package = "chain.v1"
+	cfg.IncludeComments = true
+	cfg.Java.Enabled = true
+	files := generateColumnTypes(t, cfg, &clickhouse.Table{Name: "transfers", Database: "default", Engine: "MergeTree", SortingKey: []string{"block_number"}}, columns)
+
+	content := files[defaultJavaDir+"/chain/v1/Transfers.java"]
+	assert.Contains(t, content, "package chain.v1;\n\nimport java.util.List;\nimport java.util.Map;\n")
+	assert.Contains(t, content, "    @Deprecated String memo\n) {\n}\n")
+	for _, tt := range tests {
+		t.Run(tt.column.Name, func(t *testing.T) {
+			assert.Contains(t, content, "\n    "+tt.component)
+			assert.Contains(t, content, tt.param)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// ErrJSONNameConflict is returned when two fields of a message would share a JSON name
+var ErrJSONNameConflict = errors.New("fields share a JSON name")
+
+// applyJSONNameToField sets the json_name option of a column's field when its column
+// configures one, replacing the lowerCamelCase name of the proto JSON mapping
+func (g *Generator) applyJSONNameToField(field *ProtoField, column *clickhouse.Column, tableName string) {
+	name := g.config.ColumnOverrides(tableName, column.Name).JSONName
+	if name == "" {
+		return
+	}
+	field.Options = append(field.Options, fmt.Sprintf("json_name = %q", name))
+}
+
+// columnJSONName returns the JSON name of a column's field: its configured json_name, else
+// the lowerCamelCase form of the field name
+func (g *Generator) columnJSONName(tableName, columnName string) string {
+	if name := g.config.ColumnOverrides(tableName, columnName).JSONName; name != "" {
+		return name
+	}
+	return protoField{Name: g.fieldName(tableName, columnName)}.JSONName()
+}
+
+// validateJSONNames checks that no configured json_name gives a field the JSON name of
+// another field of its table, which protoc rejects
+func (g *Generator) validateJSONNames(tables []*clickhouse.Table) error {
+	for _, table := range tables {
+		seen := make(map[string]string, len(table.Columns))
+		for _, column := range table.Columns {
+			if g.isOmitted(table.Name, column.Name) {
+				continue
+			}
+			name := g.columnJSONName(table.Name, column.Name)
+			if other, ok := seen[name]; ok {
+				return fmt.Errorf("%w: %s.%s and %s.%s are both %s", ErrJSONNameConflict, table.Name, other, table.Name, column.Name, name)
+			}
+			seen[name] = column.Name
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGenerator_JSONNames(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:     "blocks",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("block_root", "String", 2),
+		},
+		SortingKey: []string{"slot"},
+	}
+
+	generate := func(t *testing.T, jsonName string) (*config.Config, error) {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.Package = "test.v1"
+		cfg.Python.Enabled = true
+		cfg.DescriptorSetOut = filepath.Join(cfg.OutputDir, "schema.binpb")
+		cfg.Columns = map[string]map[string]config.ColumnConfig{
+			"blocks": {"block_root": {JSONName: jsonName}},
+		}
+		return cfg, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table})
+	}
+
+	cfg, err := generate(t, "root_hash")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "blocks.proto"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `string block_root = 12 [json_name = "root_hash"];`)
+
+	// Models follow the override rather than the default lowerCamelCase name
+	content, err = os.ReadFile(filepath.Join(cfg.OutputDir, "python", "blocks.py"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `block_root: str = Field(default="", alias="root_hash")`)
+
+	data, err := os.ReadFile(cfg.DescriptorSetOut)
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, set))
+	files, err := protodesc.NewFiles(set)
+	require.NoError(t, err)
+	desc, err := files.FindDescriptorByName("test.v1.Blocks.block_root")
+	require.NoError(t, err)
+	assert.Equal(t, "root_hash", desc.(protoreflect.FieldDescriptor).JSONName())
+
+	t.Run("conflicting name", func(t *testing.T) {
+		_, err := generate(t, "slot")
+		require.ErrorIs(t, err, ErrJSONNameConflict)
+	})
+}
@@ -0,0 +1,138 @@
+package protogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+)
+
+// labelValues returns the label values a table gets a service each for, or nil when label
+// services are disabled or the table has no such label column
+func (g *Generator) labelValues(table *clickhouse.Table) []string {
+	name := g.config.LabelServices.Column
+	if name == "" || len(table.SortingKey) == 0 {
+		return nil
+	}
+
+	column := findColumn(table, name)
+	if column == nil || column.IsArray || column.IsNullable || column.BaseType != chTypeString {
+		return nil
+	}
+
+	// The first key column is required in List requests, which the baked-in label can't satisfy
+	if table.SortingKey[0] == name {
+		g.log.WithFields(logrus.Fields{
+			"table":  table.Name,
+			"column": name,
+		}).Debug("Skipping label services: the label column is the primary key")
+		return nil
+	}
+	return g.config.LabelServices.Values
+}
+
+// labelName returns the PascalCase name of a label value in its service and builders, e.g.
+// FusakaDevnet3 for fusaka-devnet-3
+func labelName(value string) string {
+	return ToPascalCase(strings.ReplaceAll(value, "-", "_"))
+}
+
+// labelServiceNames returns the names of the label services of a table, in the order of
+// label_services.values
+func (g *Generator) labelServiceNames(table *clickhouse.Table) []string {
+	values := g.labelValues(table)
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = ToPascalCase(table.Name) + labelName(value) + "Service"
+	}
+	return names
+}
+
+// writeLabelServices writes the label services of a table, each with the List and Get RPCs of
+// its service under the REST path of its value
+func (g *Generator) writeLabelServices(sb *strings.Builder, table *clickhouse.Table, deprecationComment, deprecationOption string) {
+	messageName := ToPascalCase(table.Name)
+	primaryKeyField := g.fieldName(table.Name, table.SortingKey[0])
+	names := g.labelServiceNames(table)
+
+	for i, value := range g.labelValues(table) {
+		fmt.Fprintf(sb, "\n// Query %s data whose %s is %s\n", table.Name, g.config.LabelServices.Column, value)
+		fmt.Fprintf(sb, "service %s {\n", names[i])
+		g.writeSourceOption(sb, table, "service_table")
+		g.writeCacheTTLOption(sb, table)
+
+		fmt.Fprintf(sb, "  // List records | Retrieve paginated results with optional filtering\n")
+		sb.WriteString(deprecationComment)
+		if !g.shouldGenerateAPI(table.Name) {
+			writeRPC(sb, "List", messageName, deprecationOption)
+			fmt.Fprintf(sb, "  // Get record | Retrieve a single record by primary key\n")
+			sb.WriteString(deprecationComment)
+			writeRPC(sb, "Get", messageName, deprecationOption)
+			sb.WriteString("}\n")
+			continue
+		}
+
+		fmt.Fprintf(sb, "  rpc List(List%sRequest) returns (List%sResponse) {\n", messageName, messageName)
+		sb.WriteString(deprecationOption)
+		fmt.Fprintf(sb, "    option (google.api.http) = {\n")
+		fmt.Fprintf(sb, "      get: \"%s/%s/%s\"\n", g.config.APIBasePath, value, table.Name)
+		fmt.Fprintf(sb, "    };\n")
+		fmt.Fprintf(sb, "  }\n")
+		fmt.Fprintf(sb, "  // Get record | Retrieve a single record by %s\n", table.SortingKey[0])
+		sb.WriteString(deprecationComment)
+		fmt.Fprintf(sb, "  rpc Get(Get%sRequest) returns (Get%sResponse) {\n", messageName, messageName)
+		sb.WriteString(deprecationOption)
+		fmt.Fprintf(sb, "    option (google.api.http) = {\n")
+		fmt.Fprintf(sb, "      get: \"%s/%s/%s/{%s}\"\n", g.config.APIBasePath, value, table.Name, primaryKeyField)
+		fmt.Fprintf(sb, "    };\n")
+		fmt.Fprintf(sb, "  }\n")
+		sb.WriteString("}\n")
+	}
+}
+
+// writeBuilderSignature writes the signature of a table's List or Get builder. Tables with
+// label services get the exported builder and one per label value calling an unexported
+// builder taking the label, whose signature is written last.
+func (g *Generator) writeBuilderSignature(sb *strings.Builder, table *clickhouse.Table, kind, requestType string, tenant *tenantScope) {
+	messageName := getProtocMessageName(table.Name)
+	values := g.labelValues(table)
+	if len(values) == 0 {
+		fmt.Fprintf(sb, "func Build%s%sQuery(req *%s%s, options ...QueryOption) (SQLQuery, error) {\n", kind, messageName, requestType, tenantParam(tenant))
+		return
+	}
+
+	tenantArg := ""
+	if tenant != nil {
+		tenantArg = ", tenant"
+	}
+	core := fmt.Sprintf("build%s%sQuery", kind, messageName)
+
+	fmt.Fprintf(sb, "func Build%s%sQuery(req *%s%s, options ...QueryOption) (SQLQuery, error) {\n", kind, messageName, requestType, tenantParam(tenant))
+	fmt.Fprintf(sb, "\treturn %s(req%s, \"\", options...)\n", core, tenantArg)
+	fmt.Fprintf(sb, "}\n")
+	for _, value := range values {
+		fmt.Fprintf(sb, "\n// Build%s%s%sQuery constructs the query of %s%sService's %s RPC,\n",
+			kind, messageName, labelName(value), ToPascalCase(table.Name), labelName(value), kind)
+		fmt.Fprintf(sb, "// reading only the rows whose %s is %s\n", g.config.LabelServices.Column, value)
+		fmt.Fprintf(sb, "func Build%s%s%sQuery(req *%s%s, options ...QueryOption) (SQLQuery, error) {\n", kind, messageName, labelName(value), requestType, tenantParam(tenant))
+		fmt.Fprintf(sb, "\treturn %s(req%s, %q, options...)\n", core, tenantArg, value)
+		fmt.Fprintf(sb, "}\n")
+	}
+
+	fmt.Fprintf(sb, "\n// %s builds the %s query of %s. A label other than \"\" restricts it to\n", core, kind, table.Name)
+	fmt.Fprintf(sb, "// the rows whose %s is label.\n", g.config.LabelServices.Column)
+	fmt.Fprintf(sb, "func %s(req *%s%s, label string, options ...QueryOption) (SQLQuery, error) {\n", core, requestType, tenantParam(tenant))
+}
+
+// writeLabelCondition writes the condition of the label a builder of a table with label
+// services is called with
+func (g *Generator) writeLabelCondition(sb *strings.Builder, table *clickhouse.Table) {
+	if len(g.labelValues(table)) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "\t// Read only the rows of the label service's value\n")
+	fmt.Fprintf(sb, "\tif label != \"\" {\n")
+	fmt.Fprintf(sb, "\t\tqb.AddCondition(%q, \"=\", label)\n", quoteIdentifier(g.config.LabelServices.Column))
+	fmt.Fprintf(sb, "\t}\n\n")
+}
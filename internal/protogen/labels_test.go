@@ -0,0 +1,80 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_LabelServices(t *testing.T) {
+	block := &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("meta_network_name", "LowCardinality(String)", 2),
+		},
+		SortingKey: []string{"slot", "meta_network_name"},
+	}
+	byNetwork := &clickhouse.Table{
+		Name:       "dim_network",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("meta_network_name", "String", 1)},
+		SortingKey: []string{"meta_network_name"},
+	}
+	unlabelled := &clickhouse.Table{
+		Name:       "dim_node",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("name", "String", 1)},
+		SortingKey: []string{"name"},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Package = "test.v1"
+	cfg.EnableAPI = true
+	cfg.APIBasePath = "/api/v1"
+	cfg.Server.Enabled = true
+	cfg.LabelServices = config.LabelServicesConfig{Column: "meta_network_name", Values: []string{"mainnet", "fusaka-devnet-3"}}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{block, byNetwork, unlabelled}))
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	// Each value gets a service with List and Get under its path, after the table's service
+	proto := read("fct_block.proto")
+	assert.Contains(t, proto, "\n// Query fct_block data whose meta_network_name is mainnet\nservice FctBlockMainnetService {\n")
+	assert.Contains(t, proto, "\n// Query fct_block data whose meta_network_name is fusaka-devnet-3\nservice FctBlockFusakaDevnet3Service {\n")
+	assert.Contains(t, proto, "  rpc List(ListFctBlockRequest) returns (ListFctBlockResponse) {\n    option (google.api.http) = {\n      get: \"/api/v1/mainnet/fct_block\"\n")
+	assert.Contains(t, proto, "      get: \"/api/v1/fusaka-devnet-3/fct_block/{slot}\"\n")
+	assert.Less(t, strings.Index(proto, "service FctBlockService {"), strings.Index(proto, "service FctBlockMainnetService {"))
+
+	// The builders share an unexported one taking the label
+	sql := read("fct_block_sql.go")
+	assert.Contains(t, sql, "func BuildListFctBlockQuery(req *ListFctBlockRequest, options ...QueryOption) (SQLQuery, error) {\n\treturn buildListFctBlockQuery(req, \"\", options...)\n}\n")
+	assert.Contains(t, sql, "func BuildListFctBlockMainnetQuery(req *ListFctBlockRequest, options ...QueryOption) (SQLQuery, error) {\n\treturn buildListFctBlockQuery(req, \"mainnet\", options...)\n}\n")
+	assert.Contains(t, sql, "func BuildGetFctBlockFusakaDevnet3Query(req *GetFctBlockRequest, options ...QueryOption) (SQLQuery, error) {\n\treturn buildGetFctBlockQuery(req, \"fusaka-devnet-3\", options...)\n}\n")
+	assert.Contains(t, sql, "func buildListFctBlockQuery(req *ListFctBlockRequest, label string, options ...QueryOption) (SQLQuery, error) {\n")
+	assert.Equal(t, 2, strings.Count(sql, "\tif label != \"\" {\n\t\tqb.AddCondition(\"`meta_network_name`\", \"=\", label)\n\t}\n"))
+
+	// Tables keyed by the label, or without it, keep their single service
+	for _, name := range []string{"dim_network", "dim_node"} {
+		assert.Equal(t, 1, strings.Count(read(name+".proto"), "\nservice "), name)
+		assert.NotContains(t, read(name+"_sql.go"), "label", name)
+	}
+
+	assert.Contains(t, read("server/main.go"), "\"test.v1.FctBlockFusakaDevnet3Service\",")
+}
@@ -0,0 +1,38 @@
+package protogen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// writeQueryLimits writes the query_limits of the config into the generated common.go, where
+// every query builder applies them
+func (g *Generator) writeQueryLimits(sb *strings.Builder) {
+	limits := g.config.QueryLimits
+
+	var settings []string
+	if limits.MaxPartitionsToRead > 0 {
+		settings = append(settings, fmt.Sprintf("max_partitions_to_read = %d", limits.MaxPartitionsToRead))
+	}
+	if limits.MaxRowsToRead > 0 {
+		settings = append(settings, fmt.Sprintf("max_rows_to_read = %d", limits.MaxRowsToRead))
+	}
+	clause := ""
+	if len(settings) > 0 {
+		clause = " SETTINGS " + strings.Join(settings, ", ")
+	}
+
+	sb.WriteString("// Safety limits of every query, set by the query_limits of the generator config\n")
+	sb.WriteString("const (\n")
+	sb.WriteString("\t// maxQueryRows caps the LIMIT of every query; 0 leaves it uncapped\n")
+	fmt.Fprintf(sb, "\tmaxQueryRows uint32 = %d\n", limits.MaxRows)
+	sb.WriteString("\t// querySettings is appended to every query, making ClickHouse refuse the ones\n")
+	sb.WriteString("\t// reading too much\n")
+	fmt.Fprintf(sb, "\tquerySettings = %q\n", clause)
+	sb.WriteString("\t// maxFilterValues is the most values a list filter such as in, not_in or has_any may\n")
+	sb.WriteString("\t// hold, checked by the request validators; 0 leaves them unbounded\n")
+	fmt.Fprintf(sb, "\tmaxFilterValues = %d\n", limits.MaxFilterValues)
+	sb.WriteString("\t// inChunkSize splits IN and NOT IN lists longer than it into groups; 0 keeps them whole\n")
+	fmt.Fprintf(sb, "\tinChunkSize = %d\n", limits.InChunkSize)
+	sb.WriteString(")\n\n")
+}
@@ -0,0 +1,80 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_QueryLimits(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt64", 1)},
+		SortingKey: []string{"slot"},
+	}
+
+	generate := func(t *testing.T, limits config.QueryLimitsConfig) string {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.QueryLimits = limits
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "common.go"))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("Configured", func(t *testing.T) {
+		common := generate(t, config.QueryLimitsConfig{MaxRows: 20000, MaxPartitionsToRead: 30, MaxRowsToRead: 500000000, MaxFilterValues: 500, InChunkSize: 100})
+
+		assert.Contains(t, common, "maxQueryRows uint32 = 20000")
+		assert.Contains(t, common, `querySettings = " SETTINGS max_partitions_to_read = 30, max_rows_to_read = 500000000"`)
+		assert.Contains(t, common, "\tmaxFilterValues = 500\n")
+		assert.Contains(t, common, "\tinChunkSize = 100\n")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		common := generate(t, config.QueryLimitsConfig{})
+
+		assert.Contains(t, common, "maxQueryRows uint32 = 0")
+		assert.Contains(t, common, `querySettings = ""`)
+		assert.Contains(t, common, "\tmaxFilterValues = 0\n")
+		assert.Contains(t, common, "\tinChunkSize = 0\n")
+	})
+}
+
+func TestQueryLimitsGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{}
+	g.writeCommonSQLFunctions(&sb)
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, "func capLimit(limit uint32) uint32")
+	// Both query builders cap their LIMIT and append the settings
+	assert.Equal(t, 2, strings.Count(generatedCode, "if limit = capLimit(limit); limit > 0 {"))
+	assert.Equal(t, 2, strings.Count(generatedCode, "query += querySettings"))
+}
+
+func TestInListGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{}
+	g.writeCommonSQLFunctions(&sb)
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, "func inList(column, operator string, placeholders []string) string {")
+	// Every IN and NOT IN list of the QueryBuilder can be chunked
+	assert.Equal(t, 10, strings.Count(generatedCode, "qb.conditions = append(qb.conditions, inList("))
+	assert.NotContains(t, generatedCode, `IN (%s)", column, strings.Join(placeholders`)
+}
@@ -0,0 +1,79 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrLossyMapping is returned in strict mode for columns whose type can't be represented faithfully
+var ErrLossyMapping = errors.New("lossy type mapping")
+
+// lossyMapping is a column whose proto type loses information compared to its ClickHouse type
+type lossyMapping struct {
+	table      string
+	column     string
+	columnType string
+	reason     string
+}
+
+// lossyMappings returns the lossy mappings of the given tables in table and column order.
+// Omitted and hashed columns are excluded since their values never reach the API as-is.
+func (g *Generator) lossyMappings(tables []*clickhouse.Table) []lossyMapping {
+	var mappings []lossyMapping
+
+	for _, table := range tables {
+		for i := range table.Columns {
+			col := &table.Columns[i]
+
+			switch g.columnMask(table.Name, col.Name) {
+			case config.MaskOmit, config.MaskHash:
+				continue
+			}
+
+			if reason := g.typeMapper.LossyReason(col); reason != "" {
+				mappings = append(mappings, lossyMapping{
+					table:      table.Name,
+					column:     col.Name,
+					columnType: col.Type,
+					reason:     reason,
+				})
+			}
+		}
+	}
+
+	return mappings
+}
+
+// checkStrictMappings fails with one error per lossy column when strict mode is enabled
+func (g *Generator) checkStrictMappings(tables []*clickhouse.Table) error {
+	if !g.config.Strict {
+		return nil
+	}
+
+	mappings := g.lossyMappings(tables)
+	errs := make([]error, 0, len(mappings))
+	for _, m := range mappings {
+		errs = append(errs, fmt.Errorf("%w: %s.%s (%s): %s", ErrLossyMapping, m.table, m.column, m.columnType, m.reason))
+	}
+
+	return errors.Join(errs...)
+}
+
+// logLossyMappings lists every lossy mapping when warn_lossy is enabled
+func (g *Generator) logLossyMappings(tables []*clickhouse.Table) {
+	if !g.config.WarnLossy {
+		return
+	}
+
+	for _, m := range g.lossyMappings(tables) {
+		g.log.WithFields(logrus.Fields{
+			"table":  m.table,
+			"column": m.column,
+			"type":   m.columnType,
+		}).Warn("Lossy type mapping: " + m.reason)
+	}
+}
@@ -0,0 +1,67 @@
+package protogen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lossyTestTable() *clickhouse.Table {
+	return &clickhouse.Table{
+		Name: "events",
+		Columns: []clickhouse.Column{
+			{Name: "id", Type: "UInt64", BaseType: "UInt64", Position: 1},
+			{Name: "payload", Type: "Tuple(String, UInt64)", BaseType: "Tuple", Position: 2},
+			{Name: "shape", Type: "Geometry", BaseType: "Geometry", Position: 3},
+			{Name: "secret", Type: "Geometry", BaseType: "Geometry", Position: 4},
+		},
+		SortingKey: []string{"id"},
+	}
+}
+
+func TestGenerator_StrictModeFailsOnLossyMappings(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Strict = true
+	cfg.Columns = map[string]map[string]config.ColumnConfig{
+		"events": {"secret": {Mask: config.MaskOmit}},
+	}
+
+	gen := NewGenerator(cfg, logrus.New())
+	err := gen.Generate([]*clickhouse.Table{lossyTestTable()})
+	require.ErrorIs(t, err, ErrLossyMapping)
+
+	assert.Contains(t, err.Error(), "events.payload (Tuple(String, UInt64)): tuples are not supported, mapped to string")
+	assert.Contains(t, err.Error(), "events.shape (Geometry): unknown type Geometry, mapped to string")
+	assert.NotContains(t, err.Error(), "secret", "omitted columns are never exposed")
+	assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "events.proto"))
+}
+
+func TestGenerator_WarnLossyListsMappings(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.WarnLossy = true
+
+	log, hook := test.NewNullLogger()
+	gen := NewGenerator(cfg, log)
+	require.NoError(t, gen.Generate([]*clickhouse.Table{lossyTestTable()}))
+
+	var lossy []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel {
+			lossy = append(lossy, entry.Data["column"].(string)+": "+entry.Message)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"payload: Lossy type mapping: tuples are not supported, mapped to string",
+		"shape: Lossy type mapping: unknown type Geometry, mapped to string",
+		"secret: Lossy type mapping: unknown type Geometry, mapped to string",
+	}, lossy)
+}
@@ -52,6 +52,11 @@ func (tm *TypeMapper) MapType(column *clickhouse.Column, tableName string, convC
 		return protoString, nil
 	}
 
+	// UInt8 columns holding booleans map like Bool columns
+	if isBoolColumn(column, tableName, convConfig) {
+		baseType = "Bool"
+	}
+
 	// Check for repeated field (Array)
 	var repeated bool
 	if column.IsArray {
@@ -78,6 +83,78 @@ func (tm *TypeMapper) MapType(column *clickhouse.Column, tableName string, convC
 }
 
 func (tm *TypeMapper) mapBaseType(baseType, fullType string) string {
+	if protoType := tm.lookupBaseType(baseType, fullType); protoType != "" {
+		return protoType
+	}
+
+	// Unknown type, default to string
+	return protoString
+}
+
+// IsKnownType reports whether the column's base type has an explicit mapping,
+// as opposed to falling back to string
+func (tm *TypeMapper) IsKnownType(column *clickhouse.Column) bool {
+	return tm.lookupBaseType(column.BaseType, column.Type) != ""
+}
+
+// LossyReason explains why the column's ClickHouse type can't be represented faithfully
+// by its proto type, or returns an empty string if the mapping is exact
+func (tm *TypeMapper) LossyReason(column *clickhouse.Column) string {
+	switch column.BaseType {
+	case "Tuple":
+		return "tuples are not supported, mapped to string"
+	case "Map":
+		return tm.mapLossyReason(column.Type)
+	}
+
+	if !tm.IsKnownType(column) {
+		return fmt.Sprintf("unknown type %s, mapped to string", column.BaseType)
+	}
+
+	if column.IsArray && strings.HasPrefix(strings.TrimPrefix(column.Type, "Array("), "Array(") {
+		return "nested arrays are flattened into a single repeated field"
+	}
+
+	// Only a top-level Nullable becomes a wrapper type
+	if !column.IsNullable && strings.Contains(column.Type, "Nullable(") {
+		if column.IsArray {
+			return "NULL array elements become default values"
+		}
+		return "NULL values become default values"
+	}
+
+	return ""
+}
+
+// mapLossyReason explains why a Map type falls back to string, if it does
+func (tm *TypeMapper) mapLossyReason(fullType string) string {
+	keyType, valueType := tm.parseMapType(fullType)
+	if keyType == "" || valueType == "" {
+		return fmt.Sprintf("map type %s can't be represented as a proto map, mapped to string", fullType)
+	}
+
+	if !tm.isValidProtoMapKey(tm.mapClickHouseTypeToProto(keyType)) {
+		return fmt.Sprintf("map key type %s is not a valid proto map key, mapped to string", keyType)
+	}
+
+	valueBase := valueType
+	if idx := strings.Index(valueBase, "("); idx > 0 {
+		valueBase = valueBase[:idx]
+	}
+	switch valueBase {
+	case "Array", "Map", "Tuple":
+		// Composite values are not expanded into nested messages
+	default:
+		if tm.lookupBaseType(valueBase, valueBase) != "" {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("map value type %s is not supported, mapped to string", valueType)
+}
+
+// lookupBaseType returns the proto type for a ClickHouse base type, or an empty string if unknown
+func (tm *TypeMapper) lookupBaseType(baseType, fullType string) string {
 	// Handle DateTime64 specially to check precision
 	if baseType == "DateTime64" {
 		// DateTime64 uses int64 because toUnixTimestamp64Micro() returns Int64
@@ -96,12 +173,7 @@ func (tm *TypeMapper) mapBaseType(baseType, fullType string) string {
 	}
 
 	// Handle special types
-	if protoType := tm.mapSpecialType(baseType, fullType); protoType != "" {
-		return protoType
-	}
-
-	// Unknown type, default to string
-	return protoString
+	return tm.mapSpecialType(baseType, fullType)
 }
 
 func (tm *TypeMapper) mapNumericType(baseType string) string {
@@ -315,10 +387,14 @@ func (tm *TypeMapper) getWrapperTypeForColumn(column *clickhouse.Column) string
 func GetFieldNumber(position uint64) int32 {
 	// Add offset of 10 to avoid low field numbers
 	// Field numbers 1-10 are often reserved for future use
-	const offset = 10
+	return offsetFieldNumber(position, defaultFieldNumberOffset)
+}
+
+// offsetFieldNumber numbers a column by its position plus offset
+func offsetFieldNumber(position uint64, offset int) int32 {
 	const maxInt32 = 2147483647
 
-	fieldNum := position + offset
+	fieldNum := position + uint64(offset) //nolint:gosec // offset is validated to be non-negative
 	if fieldNum > maxInt32 {
 		return maxInt32
 	}
@@ -411,12 +487,48 @@ func ToPascalCase(name string) string {
 	return strings.Join(parts, "")
 }
 
+// goCamelCase returns the Go name protoc-gen-go gives a proto field, which differs from
+// ToPascalCase: an underscore is only dropped before a lowercase letter and the letter
+// after a digit is capitalized, so fct_block_24h becomes FctBlock_24H
+func goCamelCase(name string) string {
+	var b []byte
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '.' && i+1 < len(name) && isASCIILower(name[i+1]):
+			// Skip the dot of ".{{lowercase}}"
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || name[i-1] == '.'):
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(name) && isASCIILower(name[i+1]):
+			// Skip the underscore of "_{{lowercase}}"
+		case c >= '0' && c <= '9':
+			b = append(b, c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(name) && isASCIILower(name[i+1]); i++ {
+				b = append(b, name[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isASCIILower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
 // ProtoField represents a protobuf field definition
 type ProtoField struct {
 	Name    string
 	Type    string
 	Number  int32
 	Comment string
+	Options []string // Field options, e.g. (clickhouse.v1.mask) = "hash"
 }
 
 // ConvertColumn converts a ClickHouse column to a ProtoField
@@ -560,6 +672,17 @@ func (tm *TypeMapper) getArrayFilterType(column *clickhouse.Column) string {
 
 // GetFilterTypeForColumn returns the appropriate filter type for a column based on its type and nullability
 func (tm *TypeMapper) GetFilterTypeForColumn(column *clickhouse.Column, tableName string, convConfig *config.ConversionConfig) string {
+	// UInt8 columns holding booleans use BoolFilter; there is no array filter for them
+	if isBoolColumn(column, tableName, convConfig) {
+		switch {
+		case column.IsArray:
+			return ""
+		case column.IsNullable:
+			return "NullableBoolFilter"
+		}
+		return "BoolFilter"
+	}
+
 	// Arrays use dedicated array filter types
 	if column.IsArray {
 		return tm.getArrayFilterType(column)
@@ -583,6 +706,11 @@ func (tm *TypeMapper) GetFilterTypeForColumn(column *clickhouse.Column, tableNam
 	return tm.getScalarFilterType(column)
 }
 
+// isBoolColumn reports whether a column is a UInt8 column matching bool_columns, exposed as bool
+func isBoolColumn(column *clickhouse.Column, tableName string, convConfig *config.ConversionConfig) bool {
+	return column.BaseType == clickhouseUInt8 && convConfig.ShouldConvertToBool(tableName, column.Name)
+}
+
 // IsFixedString checks if a ClickHouse type is FixedString and returns its length
 // Handles both FixedString(N) and Nullable(FixedString(N))
 func IsFixedString(chType string) (isFixed bool, length int) {
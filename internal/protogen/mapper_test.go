@@ -606,6 +606,78 @@ func TestTypeMapper_ParseMapType(t *testing.T) {
 	}
 }
 
+func TestTypeMapper_LossyReason(t *testing.T) {
+	tm := NewTypeMapper()
+
+	tests := []struct {
+		name     string
+		column   clickhouse.Column
+		expected string
+	}{
+		{
+			name:     "Exact scalar",
+			column:   clickhouse.Column{Type: "UInt64", BaseType: "UInt64"},
+			expected: "",
+		},
+		{
+			name:     "Top-level Nullable uses a wrapper",
+			column:   clickhouse.Column{Type: "Nullable(String)", BaseType: "String", IsNullable: true},
+			expected: "",
+		},
+		{
+			name:     "Supported map",
+			column:   clickhouse.Column{Type: "Map(String, UInt64)", BaseType: "Map"},
+			expected: "",
+		},
+		{
+			name:     "Unknown type",
+			column:   clickhouse.Column{Type: "Geometry", BaseType: "Geometry"},
+			expected: "unknown type Geometry, mapped to string",
+		},
+		{
+			name:     "Tuple",
+			column:   clickhouse.Column{Type: "Tuple(String, UInt64)", BaseType: "Tuple"},
+			expected: "tuples are not supported, mapped to string",
+		},
+		{
+			name:     "Map with invalid key",
+			column:   clickhouse.Column{Type: "Map(Float64, String)", BaseType: "Map"},
+			expected: "map key type Float64 is not a valid proto map key, mapped to string",
+		},
+		{
+			name:     "Map with composite value",
+			column:   clickhouse.Column{Type: "Map(String, Array(String))", BaseType: "Map"},
+			expected: "map value type Array(String) is not supported, mapped to string",
+		},
+		{
+			name:     "Array of maps",
+			column:   clickhouse.Column{Type: "Array(Map(String, String))", BaseType: "Map", IsArray: true},
+			expected: "map type Array(Map(String, String)) can't be represented as a proto map, mapped to string",
+		},
+		{
+			name:     "Nested arrays",
+			column:   clickhouse.Column{Type: "Array(Array(UInt32))", BaseType: "UInt32", IsArray: true},
+			expected: "nested arrays are flattened into a single repeated field",
+		},
+		{
+			name:     "Array of Nullable",
+			column:   clickhouse.Column{Type: "Array(Nullable(String))", BaseType: "String", IsArray: true},
+			expected: "NULL array elements become default values",
+		},
+		{
+			name:     "LowCardinality of Nullable",
+			column:   clickhouse.Column{Type: "LowCardinality(Nullable(String))", BaseType: "String"},
+			expected: "NULL values become default values",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tm.LossyReason(&tt.column))
+		})
+	}
+}
+
 func TestTypeMapper_GetFilterTypeForColumn(t *testing.T) {
 	tm := NewTypeMapper()
 
@@ -1462,3 +1534,54 @@ func TestGetSelectColumnExpression_BigIntToStringConversion(t *testing.T) {
 		})
 	}
 }
+
+func TestTypeMapper_BoolColumns(t *testing.T) {
+	conv := &config.ConversionConfig{BoolColumns: []string{"*.is_*"}}
+	tm := NewTypeMapper()
+
+	tests := []struct {
+		name       string
+		column     clickhouse.Column
+		protoType  string
+		filterType string
+		expression string
+	}{
+		{
+			name:       "UInt8",
+			column:     clickhouse.NewColumn("is_canonical", "UInt8", 1),
+			protoType:  protoBool,
+			filterType: "BoolFilter",
+			expression: "toBool(`is_canonical`) AS `is_canonical`",
+		},
+		{
+			name:       "Nullable(UInt8)",
+			column:     clickhouse.NewColumn("is_orphaned", "Nullable(UInt8)", 1),
+			protoType:  "google.protobuf.BoolValue",
+			filterType: "NullableBoolFilter",
+			expression: "toBool(`is_orphaned`) AS `is_orphaned`",
+		},
+		{
+			name:       "Array(Nullable(UInt8))",
+			column:     clickhouse.NewColumn("is_voted", "Array(Nullable(UInt8))", 1),
+			protoType:  "repeated bool",
+			expression: "arrayMap(x -> toBool(coalesce(x, 0)), `is_voted`) AS `is_voted`",
+		},
+		{
+			name:       "Other types are unaffected",
+			column:     clickhouse.NewColumn("is_count", "UInt16", 1),
+			protoType:  protoUInt32,
+			filterType: "UInt32Filter",
+			expression: "toUInt32(`is_count`) AS `is_count`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protoType, err := tm.MapType(&tt.column, "fct_block", conv)
+			require.NoError(t, err)
+			assert.Equal(t, tt.protoType, protoType)
+			assert.Equal(t, tt.filterType, tm.GetFilterTypeForColumn(&tt.column, "fct_block", conv))
+			assert.Equal(t, tt.expression, getSelectColumnExpression(&tt.column, "fct_block", conv))
+		})
+	}
+}
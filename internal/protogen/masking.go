@@ -0,0 +1,160 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// ErrMaskedPrimaryKey is returned when a primary key column is configured with a mask
+var ErrMaskedPrimaryKey = errors.New("primary key columns cannot be masked")
+
+// columnMask returns the configured mask for a column, or an empty string
+func (g *Generator) columnMask(tableName, columnName string) string {
+	return g.config.ColumnOverrides(tableName, columnName).Mask
+}
+
+// isMasked reports whether a column is redacted and therefore must not be filtered or ordered on
+func (g *Generator) isMasked(tableName, columnName string) bool {
+	return g.columnMask(tableName, columnName) != ""
+}
+
+// isOmitted reports whether a column is removed from the API surface entirely
+func (g *Generator) isOmitted(tableName, columnName string) bool {
+	return g.columnMask(tableName, columnName) == config.MaskOmit
+}
+
+// hasMaskOptions reports whether any message field of the table carries a mask option
+func (g *Generator) hasMaskOptions(table *clickhouse.Table) bool {
+	for _, column := range table.Columns {
+		if g.isMasked(table.Name, column.Name) && !g.isOmitted(table.Name, column.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMasks ensures masked columns can be dropped from request filters.
+// Primary keys (including projection primary keys) are required filters, so they can't be masked.
+func (g *Generator) validateMasks(tables []*clickhouse.Table) error {
+	for _, table := range tables {
+		keys := make([]string, 0, len(table.Projections)+1)
+		if len(table.SortingKey) > 0 {
+			keys = append(keys, table.SortingKey[0])
+		}
+		for _, proj := range table.Projections {
+			if len(proj.OrderByKey) > 0 {
+				keys = append(keys, proj.OrderByKey[0])
+			}
+		}
+
+		for _, key := range keys {
+			if g.isMasked(table.Name, key) {
+				return fmt.Errorf("%w: %s.%s", ErrMaskedPrimaryKey, table.Name, key)
+			}
+		}
+	}
+	return nil
+}
+
+// applyMaskToField adjusts a message field for its column mask. Hashed columns become
+// strings; every masked field is tagged with the clickhouse.v1.mask option unless the
+// annotations section isn't emitted.
+func (g *Generator) applyMaskToField(field *ProtoField, column *clickhouse.Column, tableName string) {
+	mask := g.columnMask(tableName, column.Name)
+	if mask == "" {
+		return
+	}
+
+	if mask == config.MaskHash {
+		switch {
+		case column.IsArray:
+			field.Type = "repeated " + protoString
+		case column.IsNullable:
+			field.Type = g.typeMapper.getWrapperType(protoString)
+		default:
+			field.Type = protoString
+		}
+	}
+
+	if g.config.Emits(config.EmitAnnotations) {
+		field.Options = append(field.Options, fmt.Sprintf("(clickhouse.v1.mask) = %q", mask))
+	}
+}
+
+// selectColumnExpressions returns the SELECT expressions for a table, applying column masks.
+// Omitted columns are excluded entirely.
+func (g *Generator) selectColumnExpressions(table *clickhouse.Table) []string {
+	expressions := make([]string, 0, len(table.Columns))
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		expr := getSelectColumnExpression(col, table.Name, &g.config.Conversion)
+
+		switch g.columnMask(table.Name, col.Name) {
+		case config.MaskOmit:
+			continue
+		case config.MaskHash:
+			expr = getHashedColumnExpression(col, g.maskKeySetting())
+		case config.MaskNull:
+			expr = getNulledColumnExpression(col, expr)
+		}
+
+		expressions = append(expressions, g.fieldSelectExpression(col, table.Name, expr))
+	}
+	return expressions
+}
+
+// orderableFields returns the Go expression of the mapping of the fields that order_by and
+// distinct_on accept: the fields of the columns that aren't masked, so their values can't be
+// inferred from result ordering
+func (g *Generator) orderableFields(table *clickhouse.Table) string {
+	var masked []string
+	for _, col := range table.Columns {
+		if g.isMasked(table.Name, col.Name) && !g.isOmitted(table.Name, col.Name) {
+			masked = append(masked, fmt.Sprintf("%q", g.fieldName(table.Name, col.Name)))
+		}
+	}
+	if len(masked) == 0 {
+		return columnsByFieldVariable(table)
+	}
+	return fmt.Sprintf("%s.Without(%s)", columnsByFieldVariable(table), strings.Join(masked, ", "))
+}
+
+// maskKeySetting returns the ClickHouse setting holding the secret key of hash masks
+func (g *Generator) maskKeySetting() string {
+	if g.config.MaskKeySetting == "" {
+		return config.DefaultMaskKeySetting
+	}
+	return g.config.MaskKeySetting
+}
+
+// getHashedColumnExpression returns a SELECT expression replacing the column value with the
+// SHA-256 hex digest of the secret key in the keySetting setting and the value. Without the
+// key, low-entropy values such as IPs or emails could be recovered from a lookup table of
+// their digests; getSetting fails the query when the setting isn't set.
+func getHashedColumnExpression(col *clickhouse.Column, keySetting string) string {
+	key := fmt.Sprintf("getSetting('%s')", keySetting)
+	if col.IsArray {
+		return fmt.Sprintf("arrayMap(x -> hex(SHA256(concat(%s, ifNull(toString(x), '')))), %s) AS %s", key, quoteIdentifier(col.Name), quoteIdentifier(col.Name))
+	}
+	return fmt.Sprintf("hex(SHA256(concat(%s, toString(%s)))) AS %s", key, quoteIdentifier(col.Name), quoteIdentifier(col.Name))
+}
+
+// getNulledColumnExpression returns a SELECT expression producing the zero (or NULL) value of the
+// column's API type, keeping the field in the response shape without exposing its value
+func getNulledColumnExpression(col *clickhouse.Column, expr string) string {
+	return fmt.Sprintf("defaultValueOfArgument(%s) AS %s", unaliasedExpression(col, expr), quoteIdentifier(col.Name))
+}
+
+// unaliasedExpression strips the alias from a SELECT expression of a column, quoting plain
+// column names, so it can be used inside other expressions
+func unaliasedExpression(col *clickhouse.Column, expr string) string {
+	inner := strings.TrimSuffix(expr, " AS "+quoteIdentifier(col.Name))
+	if inner == col.Name {
+		inner = quoteIdentifier(col.Name)
+	}
+	return inner
+}
@@ -0,0 +1,141 @@
+package protogen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func maskingTestTable() *clickhouse.Table {
+	return &clickhouse.Table{
+		Name: "users",
+		Columns: []clickhouse.Column{
+			{Name: "id", Type: "UInt64", BaseType: "UInt64", Position: 1},
+			{Name: "email", Type: "String", BaseType: "String", Position: 2},
+			{Name: "phone", Type: "Nullable(String)", BaseType: "String", IsNullable: true, Position: 3},
+			{Name: "ip_addresses", Type: "Array(IPv4)", BaseType: "IPv4", IsArray: true, Position: 4},
+			{Name: "birthday", Type: "Date", BaseType: "Date", Position: 5},
+			{Name: "ssn", Type: "String", BaseType: "String", Position: 6},
+		},
+		SortingKey: []string{"id", "email"},
+	}
+}
+
+func maskingTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Columns = map[string]map[string]config.ColumnConfig{
+		"*": {
+			"ssn": {Mask: config.MaskOmit},
+		},
+		"users": {
+			"email":        {Mask: config.MaskHash},
+			"phone":        {Mask: config.MaskHash},
+			"ip_addresses": {Mask: config.MaskHash},
+			"birthday":     {Mask: config.MaskNull},
+		},
+	}
+	return cfg
+}
+
+func TestGenerator_SelectColumnExpressionsWithMasks(t *testing.T) {
+	gen := NewGenerator(maskingTestConfig(t), logrus.New())
+
+	assert.Equal(t, []string{
+		"id",
+		"hex(SHA256(concat(getSetting('custom_mask_key'), toString(`email`)))) AS `email`",
+		"hex(SHA256(concat(getSetting('custom_mask_key'), toString(`phone`)))) AS `phone`",
+		"arrayMap(x -> hex(SHA256(concat(getSetting('custom_mask_key'), ifNull(toString(x), '')))), `ip_addresses`) AS `ip_addresses`",
+		"defaultValueOfArgument(toString(`birthday`)) AS `birthday`",
+	}, gen.selectColumnExpressions(maskingTestTable()))
+
+	assert.Equal(t, `UsersColumnsByField.Without("email", "phone", "ip_addresses", "birthday")`, gen.orderableFields(maskingTestTable()))
+
+	// The key of hash masks can come from another setting
+	cfg := maskingTestConfig(t)
+	cfg.MaskKeySetting = "custom_pii_key"
+	expressions := NewGenerator(cfg, logrus.New()).selectColumnExpressions(maskingTestTable())
+	assert.Equal(t, "hex(SHA256(concat(getSetting('custom_pii_key'), toString(`email`)))) AS `email`", expressions[1])
+}
+
+func TestGetNulledColumnExpression(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   clickhouse.Column
+		expected string
+	}{
+		{
+			name:     "Plain column is backticked",
+			column:   clickhouse.Column{Name: "name", Type: "String", BaseType: "String"},
+			expected: "defaultValueOfArgument(`name`) AS `name`",
+		},
+		{
+			name:     "Converted column keeps API type",
+			column:   clickhouse.Column{Name: "created_at", Type: "DateTime", BaseType: "DateTime"},
+			expected: "defaultValueOfArgument(toUnixTimestamp(`created_at`)) AS `created_at`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr := getSelectColumnExpression(&tt.column, "test_table", &config.ConversionConfig{})
+			assert.Equal(t, tt.expected, getNulledColumnExpression(&tt.column, expr))
+		})
+	}
+}
+
+func TestGenerator_GenerateWithMasks(t *testing.T) {
+	cfg := maskingTestConfig(t)
+	gen := NewGenerator(cfg, logrus.New())
+
+	require.NoError(t, gen.Generate([]*clickhouse.Table{maskingTestTable()}))
+
+	protoContent, err := readFile(filepath.Join(cfg.OutputDir, "users.proto"))
+	require.NoError(t, err)
+
+	// Annotations are imported for the mask option even without API generation
+	assert.Contains(t, protoContent, "import \"clickhouse/annotations.proto\";")
+
+	// Message fields
+	assert.Contains(t, protoContent, "string email = 12 [(clickhouse.v1.mask) = \"hash\"];")
+	assert.Contains(t, protoContent, "google.protobuf.StringValue phone = 13 [(clickhouse.v1.mask) = \"hash\"];")
+	assert.Contains(t, protoContent, "repeated string ip_addresses = 14 [(clickhouse.v1.mask) = \"hash\"];")
+	assert.Contains(t, protoContent, "string birthday = 15 [(clickhouse.v1.mask) = \"null\"];")
+	assert.Contains(t, protoContent, "reserved 16; // Omitted by column mask")
+	assert.NotContains(t, protoContent, "ssn")
+
+	// Masked columns have no request filters, even when part of the sorting key
+	assert.NotContains(t, protoContent, "Filter by email")
+	assert.NotContains(t, protoContent, "Filter by birthday")
+
+	sqlContent, err := readFile(filepath.Join(cfg.OutputDir, "users_sql.go"))
+	require.NoError(t, err)
+
+	assert.Contains(t, sqlContent, `ParseOrderBy(req.OrderBy, UsersColumnsByField.Without("email", "phone", "ip_addresses", "birthday"))`)
+	assert.Contains(t, sqlContent, "hex(SHA256(concat(getSetting('custom_mask_key'), toString(`email`)))) AS `email`")
+	assert.NotContains(t, sqlContent, "ssn")
+	assert.NotContains(t, sqlContent, "qb.AddCondition(\"`email`\"")
+
+	annotations, err := readFile(filepath.Join(cfg.OutputDir, "clickhouse", "annotations.proto"))
+	require.NoError(t, err)
+	assert.Contains(t, annotations, "string mask = 50004;")
+}
+
+func TestGenerator_GenerateRejectsMaskedPrimaryKey(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Columns = map[string]map[string]config.ColumnConfig{
+		"users": {"id": {Mask: config.MaskHash}},
+	}
+
+	gen := NewGenerator(cfg, logrus.New())
+	err := gen.Generate([]*clickhouse.Table{maskingTestTable()})
+	require.ErrorIs(t, err, ErrMaskedPrimaryKey)
+}
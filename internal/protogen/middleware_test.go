@@ -0,0 +1,156 @@
+package protogen
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GenerateMiddleware(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tempDir
+	cfg.Package = "test.v1"
+	cfg.Middleware.Enabled = true
+	cfg.Middleware.SlowQueryThreshold = 500 * time.Millisecond
+
+	gen := NewGenerator(cfg, logrus.New())
+
+	tables := []*clickhouse.Table{
+		{
+			Name:       "fct_block",
+			Columns:    []clickhouse.Column{{Name: "slot", Type: "UInt64", BaseType: "UInt64", Position: 1}},
+			SortingKey: []string{"slot"},
+		},
+	}
+
+	require.NoError(t, gen.GenerateMiddleware(tables))
+
+	path := filepath.Join(tempDir, "middleware", "middleware.go")
+	content, err := readFile(path)
+	require.NoError(t, err)
+
+	assert.Contains(t, content, "package middleware")
+	assert.Contains(t, content, "const DefaultSlowQueryThreshold = 500 * time.Millisecond")
+	assert.Contains(t, content, "\"test.v1.FctBlockService\": \"fct_block\",")
+	assert.Contains(t, content, "func UnaryServerInterceptor() grpc.UnaryServerInterceptor")
+	assert.Contains(t, content, "func InstrumentConn(conn driver.Conn, opts Options) *Conn")
+	assert.Contains(t, content, "\"slow ClickHouse query\"")
+
+	// Queries carry a query_id, which errors report and cancellation kills
+	assert.Contains(t, content, "const QueryIDHeader = \"x-clickhouse-query-id\"")
+	assert.Contains(t, content, "_ = grpc.SetHeader(ctx, metadata.Pairs(QueryIDHeader, queryID))")
+	assert.Contains(t, content, "clickhouse.Context(ctx, clickhouse.WithQueryID(queryID))")
+	assert.Contains(t, content, "return &QueryError{QueryID: queryID, Err: err}")
+	assert.Contains(t, content, "\"KILL QUERY WHERE query_id = ? ASYNC\"")
+
+	// Generated requests carry their RequestHash in the context
+	assert.Contains(t, content, "requestHash: requestHash(req)})")
+	assert.Contains(t, content, "func RequestHashFrom(ctx context.Context) string {")
+
+	_, err = parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors)
+	require.NoError(t, err)
+
+	// Without tracing the hooks are stubbed out in middleware.go
+	assert.Contains(t, content, "func startQuerySpan(ctx context.Context, _, _ string) (context.Context, func(int, error))")
+	assert.NoFileExists(t, filepath.Join(tempDir, "middleware", "tracing.go"))
+}
+
+func TestGenerator_GenerateMiddlewareWithTracing(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tempDir
+	cfg.Middleware.Tracing = true
+
+	gen := NewGenerator(cfg, logrus.New())
+	require.True(t, gen.middlewareEnabled(), "tracing implies the middleware package")
+
+	require.NoError(t, gen.GenerateMiddleware(nil))
+
+	middlewareContent, err := readFile(filepath.Join(tempDir, "middleware", "middleware.go"))
+	require.NoError(t, err)
+	assert.NotContains(t, middlewareContent, "func startQuerySpan", "stubs must not clash with tracing.go")
+
+	tracingPath := filepath.Join(tempDir, "middleware", "tracing.go")
+	tracingContent, err := readFile(tracingPath)
+	require.NoError(t, err)
+
+	assert.Contains(t, tracingContent, "func startQuerySpan(ctx context.Context, query, queryID string) (context.Context, func(int, error))")
+	assert.Contains(t, tracingContent, "clickhouse.Context(ctx, clickhouse.WithSpan(span.SpanContext()))")
+	assert.Contains(t, tracingContent, "attribute.String(\"clickhouse.query_hash\", QueryHash(query))")
+	assert.Contains(t, tracingContent, "attribute.String(\"clickhouse.request_hash\", l.requestHash)")
+	assert.Contains(t, tracingContent, "attribute.String(\"clickhouse.filters\", l.filters)")
+	assert.Contains(t, tracingContent, "attribute.String(\"clickhouse.query_id\", queryID)")
+
+	_, err = parser.ParseFile(token.NewFileSet(), tracingPath, nil, parser.AllErrors)
+	require.NoError(t, err)
+
+	// Disabling tracing again removes the stale hooks
+	cfg.Middleware.Tracing = false
+	require.NoError(t, gen.GenerateMiddleware(nil))
+	assert.NoFileExists(t, tracingPath)
+}
+
+func TestGenerator_ServerScaffoldWithMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		goPackage     string
+		expectWiring  bool
+		expectImports []string
+	}{
+		{
+			name:          "go_package set wires middleware",
+			goPackage:     "github.com/acme/api/gen/v1",
+			expectWiring:  true,
+			expectImports: []string{"\"github.com/acme/api/gen/v1/middleware\"", "promhttp"},
+		},
+		{
+			name:         "no go_package leaves server uninstrumented",
+			goPackage:    "",
+			expectWiring: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			cfg := config.NewConfig()
+			cfg.OutputDir = tempDir
+			cfg.GoPackage = tt.goPackage
+			cfg.Server.Enabled = true
+			cfg.Middleware.Enabled = true
+
+			gen := NewGenerator(cfg, logrus.New())
+			require.NoError(t, gen.GenerateServerScaffold(nil))
+
+			path := filepath.Join(tempDir, "server", "main.go")
+			content, err := readFile(path)
+			require.NoError(t, err)
+
+			_, err = parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors)
+			require.NoError(t, err)
+
+			if tt.expectWiring {
+				assert.Contains(t, content, "grpc.ChainUnaryInterceptor(middleware.UnaryServerInterceptor())")
+				assert.Contains(t, content, "middleware.InstrumentConn(conn, middleware.Options{SlowQueryThreshold: slowQuery, KillOnCancel: true})")
+				for _, imp := range tt.expectImports {
+					assert.Contains(t, content, imp)
+				}
+			} else {
+				assert.NotContains(t, content, "middleware.")
+				assert.Contains(t, content, "registerServices(srv, conn)")
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package protogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// MigrationProtoChanges renders the proto side of a table's schema drift as lines to apply
+// to its row message: fields of added columns, reserved numbers and names of removed
+// columns, and comments on fields whose type or number changes, since both break clients
+// built against the old message. It returns an empty string for tables new to the snapshot,
+// whose proto file is simply generated.
+func (g *Generator) MigrationProtoChanges(diff *clickhouse.TableDiff) string {
+	if diff.New {
+		return ""
+	}
+
+	table := diff.Table
+	previous := g.previousFieldNumbers(diff)
+	fields := make(map[string]*ProtoField)
+	for _, field := range g.MessageFields(table) {
+		fields[field.Name] = field
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "// Changes to message %s in %s\n", ToPascalCase(table.Name), g.protoImport(strings.ToLower(table.Name)+".proto"))
+
+	added := false
+	for _, column := range diff.Added {
+		if _, ok := g.renamedFrom(table.Name, column.Name); ok {
+			// Renamed columns keep the field of their original column
+			continue
+		}
+		field, ok := fields[g.fieldName(table.Name, column.Name)]
+		if !ok {
+			// Omitted by a column mask
+			continue
+		}
+		if !added {
+			sb.WriteString("\n// Fields of added columns\n")
+			added = true
+		}
+		g.writeField(sb, field)
+	}
+
+	removed := make([]clickhouse.Column, 0, len(diff.Removed))
+	for _, column := range diff.Removed {
+		if _, ok := g.config.RenamedColumns[table.Name][column.Name]; !ok {
+			removed = append(removed, column)
+		}
+	}
+	if len(removed) > 0 {
+		sb.WriteString("\n// Removed columns: reserve their field numbers and names\n")
+		used := make(map[int32]string, len(fields))
+		for _, field := range fields {
+			used[field.Number] = field.Name
+		}
+		for _, column := range removed {
+			number := previous[column.Name]
+			if owner, ok := used[number]; ok {
+				// Columns moved into the position, so only the name can be reserved
+				fmt.Fprintf(sb, "  // %d is now the number of %s\n", number, owner)
+			} else {
+				fmt.Fprintf(sb, "  reserved %d;\n", number)
+			}
+			fmt.Fprintf(sb, "  reserved %q;\n", SanitizeName(column.Name))
+		}
+	}
+
+	g.writeBreakingFieldChanges(sb, diff, fields, previous)
+	return sb.String()
+}
+
+// previousFieldNumbers numbers the columns of a table as defined in the snapshot
+func (g *Generator) previousFieldNumbers(diff *clickhouse.TableDiff) map[string]int32 {
+	added := make(map[string]bool, len(diff.Added))
+	for _, column := range diff.Added {
+		added[column.Name] = true
+	}
+	moved := make(map[string]uint64, len(diff.Moved))
+	for _, move := range diff.Moved {
+		moved[move.Name] = move.OldPosition
+	}
+
+	snapshot := &clickhouse.Table{Name: diff.Table.Name}
+	for _, column := range diff.Table.Columns {
+		if added[column.Name] {
+			continue
+		}
+		if position, ok := moved[column.Name]; ok {
+			column.Position = position
+		}
+		snapshot.Columns = append(snapshot.Columns, column)
+	}
+	snapshot.Columns = append(snapshot.Columns, diff.Removed...)
+
+	if g.config.FieldNumbers.Strategy != config.FieldNumbersLock {
+		return g.fieldNumbers(snapshot)
+	}
+
+	// Numbering the snapshot must not lock its dropped columns as new ones. Columns the lock
+	// lacks were generated before the table was locked, so they were numbered by position.
+	numbers := positionFieldNumbers(snapshot.Columns, g.fieldNumberOffset())
+	if g.lock != nil {
+		for name, number := range g.lock.Tables[snapshot.Name] {
+			if _, ok := numbers[name]; ok {
+				numbers[name] = number
+			}
+		}
+	}
+	return numbers
+}
+
+// writeBreakingFieldChanges lists existing fields whose proto type or number changes
+func (g *Generator) writeBreakingFieldChanges(sb *strings.Builder, diff *clickhouse.TableDiff, fields map[string]*ProtoField, previous map[string]int32) {
+	var notes []string
+	for _, change := range diff.Changed {
+		field, ok := fields[g.fieldName(diff.Table.Name, change.Name)]
+		if !ok {
+			continue
+		}
+		old := clickhouse.NewColumn(change.Name, change.OldType, 0)
+		oldType, err := g.typeMapper.MapType(&old, diff.Table.Name, &g.config.Conversion)
+		if err == nil && oldType != field.Type {
+			notes = append(notes, fmt.Sprintf("%s: type %s -> %s (%s -> %s)", field.Name, oldType, field.Type, change.OldType, change.NewType))
+		}
+	}
+	for _, move := range diff.Moved {
+		field, ok := fields[g.fieldName(diff.Table.Name, move.Name)]
+		if !ok || previous[move.Name] == field.Number {
+			// The hash and lock strategies keep the numbers of moved columns
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("%s: number %d -> %d (column moved from position %d to %d)",
+			field.Name, previous[move.Name], field.Number, move.OldPosition, move.NewPosition))
+	}
+
+	if len(notes) == 0 {
+		return
+	}
+	sb.WriteString("\n// Not wire compatible with the old message:\n")
+	for _, note := range notes {
+		fmt.Fprintf(sb, "//   %s\n", note)
+	}
+}
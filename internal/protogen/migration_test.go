@@ -0,0 +1,64 @@
+package protogen
+
+import (
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerator_MigrationProtoChanges(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.IncludeComments = true
+	cfg.Columns = map[string]map[string]config.ColumnConfig{
+		"transfers": {"secret": {Mask: config.MaskOmit}},
+	}
+	g := NewGenerator(cfg, logrus.New())
+
+	table := &clickhouse.Table{
+		Name: "transfers",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("block_number", "UInt64", 1),
+			clickhouse.NewColumn("fee", "Nullable(Int64)", 2),
+			clickhouse.NewColumn("amount", "Float64", 3),
+			clickhouse.NewColumn("secret", "String", 4),
+		},
+	}
+	table.Columns[1].Comment = "Fee paid"
+
+	t.Run("Changed table", func(t *testing.T) {
+		diff := &clickhouse.TableDiff{
+			Table: table,
+			Added: []clickhouse.Column{table.Columns[1], table.Columns[3]},
+			Removed: []clickhouse.Column{
+				clickhouse.NewColumn("memo", "String", 3),
+				clickhouse.NewColumn("note", "String", 5),
+			},
+			Changed: []clickhouse.ColumnChange{
+				{Name: "amount", OldType: "Float32", NewType: "Float64"},
+				{Name: "block_number", OldType: "LowCardinality(UInt64)", NewType: "UInt64"},
+			},
+			Moved: []clickhouse.ColumnMove{{Name: "amount", OldPosition: 2, NewPosition: 3}},
+		}
+
+		expected := "// Changes to message Transfers in transfers.proto\n" +
+			"\n// Fields of added columns\n" +
+			"  // Fee paid\n" +
+			"  google.protobuf.Int64Value fee = 12;\n" +
+			"\n// Removed columns: reserve their field numbers and names\n" +
+			"  // 13 is now the number of amount\n" +
+			"  reserved \"memo\";\n" +
+			"  reserved 15;\n" +
+			"  reserved \"note\";\n" +
+			"\n// Not wire compatible with the old message:\n" +
+			"//   amount: type float -> double (Float32 -> Float64)\n" +
+			"//   amount: number 12 -> 13 (column moved from position 2 to 3)\n"
+		assert.Equal(t, expected, g.MigrationProtoChanges(diff))
+	})
+
+	t.Run("New table", func(t *testing.T) {
+		assert.Empty(t, g.MigrationProtoChanges(&clickhouse.TableDiff{Table: table, New: true}))
+	})
+}
@@ -0,0 +1,279 @@
+package protogen
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// protoMessage is a message of a generated proto file, the input of the model emitters for
+// other languages. Emitters read the rendered protos rather than the tables so their types
+// always match the proto, including overrides, masks and visibility profiles.
+type protoMessage struct {
+	Name       string
+	Comment    string
+	Fields     []protoField
+	Deprecated bool
+}
+
+// protoField is a field of a generated message
+type protoField struct {
+	Name       string
+	Type       string // Value type: a scalar, google.protobuf wrapper or message name
+	MapKey     string // Key type of map fields
+	Number     int
+	Comment    string
+	Repeated   bool
+	Oneof      string // Name of the oneof the field belongs to
+	Deprecated bool
+	JSON       string // json_name option of the field, if set
+}
+
+// IsMap reports whether the field is a map<MapKey, Type>
+func (f protoField) IsMap() bool {
+	return f.MapKey != ""
+}
+
+// Nullable reports whether the field is a google.protobuf wrapper, null in JSON when unset
+func (f protoField) Nullable() bool {
+	return wrapperScalar(f.Type) != ""
+}
+
+// Scalar returns the scalar type of the field's value, unwrapping google.protobuf wrappers,
+// or an empty string for message fields
+func (f protoField) Scalar() string {
+	if scalar := wrapperScalar(f.Type); scalar != "" {
+		return scalar
+	}
+	if isProtoScalar(f.Type) {
+		return f.Type
+	}
+	return ""
+}
+
+// JSONName returns the name the proto JSON mapping uses for the field: its json_name
+// option, else the lowerCamelCase form of its name
+func (f protoField) JSONName() string {
+	if f.JSON != "" {
+		return f.JSON
+	}
+	var sb strings.Builder
+	upper := false
+	for _, r := range f.Name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// wrapperScalar returns the scalar wrapped by a google.protobuf wrapper type, or ""
+func wrapperScalar(protoType string) string {
+	switch protoType {
+	case "google.protobuf.StringValue":
+		return protoString
+	case "google.protobuf.BoolValue":
+		return protoBool
+	case "google.protobuf.Int32Value":
+		return protoInt32
+	case "google.protobuf.Int64Value":
+		return protoInt64
+	case "google.protobuf.UInt32Value":
+		return protoUInt32
+	case "google.protobuf.UInt64Value":
+		return protoUInt64
+	case "google.protobuf.FloatValue":
+		return protoFloat
+	case "google.protobuf.DoubleValue":
+		return protoDouble
+	case "google.protobuf.BytesValue":
+		return protoBytes
+	}
+	return ""
+}
+
+func isProtoScalar(protoType string) bool {
+	switch protoType {
+	case protoInt32, protoInt64, protoUInt32, protoUInt64, protoFloat, protoDouble, protoString, protoBool, protoBytes:
+		return true
+	}
+	return false
+}
+
+// isBigIntScalar reports whether the proto JSON mapping encodes a scalar as a string to
+// keep its precision
+func isBigIntScalar(scalar string) bool {
+	return scalar == protoInt64 || scalar == protoUInt64
+}
+
+// emitterDir returns the output directory of a model emitter: dir relative to output_dir
+// unless absolute, or fallback when unset
+func (g *Generator) emitterDir(dir, fallback string) string {
+	if dir == "" {
+		dir = fallback
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(g.config.OutputDir, dir)
+}
+
+// tableMessages returns the messages of a table's proto file
+func (g *Generator) tableMessages(table *clickhouse.Table) []protoMessage {
+	return parseProtoMessages(g.tableProtoContent(table))
+}
+
+// rowMessage returns the message of a table's rows
+func (g *Generator) rowMessage(table *clickhouse.Table) protoMessage {
+	name := ToPascalCase(table.Name)
+	for _, msg := range g.tableMessages(table) {
+		if msg.Name == name {
+			return msg
+		}
+	}
+	return protoMessage{Name: name}
+}
+
+// commonMessages returns the messages of common.proto
+func (g *Generator) commonMessages() []protoMessage {
+	return parseProtoMessages(g.commonProtoContent())
+}
+
+// parseProtoMessages parses the top-level messages of a generated proto file. It only
+// understands the subset of proto syntax the generator writes: flat messages with scalar,
+// repeated, map and message fields, oneofs, options and reserved numbers. Enums and
+// services are skipped.
+func parseProtoMessages(content string) []protoMessage {
+	var (
+		messages []protoMessage
+		current  *protoMessage
+		comment  []string
+		oneof    string
+		skip     int  // Depth of a skipped enum or service block
+		blank    bool // A blank line followed the pending comment
+	)
+
+	for _, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+
+		switch {
+		case skip > 0:
+			skip += strings.Count(line, "{") - strings.Count(line, "}")
+		case line == "":
+			// A table comment is separated from its message by a blank line, while a
+			// comment after a blank line starts a new block
+			blank = true
+			continue
+		case strings.HasPrefix(line, "//"):
+			if blank {
+				comment = nil
+			}
+			blank = false
+			comment = append(comment, strings.TrimSpace(strings.TrimPrefix(line, "//")))
+			continue
+		case current == nil && strings.HasPrefix(line, "message ") && strings.HasSuffix(line, "{"):
+			name := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "message "), "{"))
+			current = &protoMessage{Name: name, Comment: stripDDLExcerpt(strings.Join(comment, "\n"))}
+		case current == nil && (strings.HasPrefix(line, "enum ") || strings.HasPrefix(line, "service ")):
+			skip = strings.Count(line, "{") - strings.Count(line, "}")
+		case current == nil:
+		case line == "}" && oneof != "":
+			oneof = ""
+		case line == "}":
+			messages = append(messages, *current)
+			current = nil
+		case strings.HasPrefix(line, "oneof ") && strings.HasSuffix(line, "{"):
+			oneof = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "oneof "), "{"))
+		case strings.HasPrefix(line, "option "):
+			current.Deprecated = current.Deprecated || strings.Contains(line, deprecatedOption)
+		case strings.HasPrefix(line, "reserved "):
+		default:
+			if field, ok := parseProtoField(line); ok {
+				if field.Comment == "" {
+					field.Comment = strings.Join(comment, "\n")
+				}
+				field.Oneof = oneof
+				current.Fields = append(current.Fields, field)
+			}
+		}
+		comment, blank = nil, false
+	}
+	return messages
+}
+
+// parseProtoField parses `[repeated] Type name = N [options]; // comment`
+func parseProtoField(line string) (protoField, bool) {
+	var field protoField
+
+	if idx := strings.Index(line, "//"); idx >= 0 {
+		field.Comment = strings.TrimSpace(line[idx+2:])
+		line = strings.TrimSpace(line[:idx])
+	}
+	definition, ok := strings.CutSuffix(line, ";")
+	if !ok {
+		return field, false
+	}
+	explicit := false
+	if idx := strings.Index(definition, "["); idx >= 0 {
+		field.Deprecated = strings.Contains(definition[idx:], deprecatedOption)
+		explicit = strings.Contains(definition[idx:], explicitPresenceOption)
+		field.JSON = parseJSONNameOption(definition[idx:])
+		definition = strings.TrimSpace(definition[:idx])
+	}
+	// proto2 labels every singular field; nullable ones still use wrappers there
+	definition = strings.TrimPrefix(definition, "optional ")
+
+	declaration, number, ok := strings.Cut(definition, "=")
+	if !ok {
+		return field, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(number))
+	if err != nil {
+		return field, false
+	}
+	field.Number = n
+
+	if strings.HasPrefix(declaration, "map<") {
+		end := strings.Index(declaration, ">")
+		if end < 0 {
+			return field, false
+		}
+		key, value, _ := strings.Cut(declaration[len("map<"):end], ",")
+		field.MapKey, field.Type = strings.TrimSpace(key), strings.TrimSpace(value)
+		field.Name = strings.TrimSpace(declaration[end+1:])
+		return field, field.Name != ""
+	}
+
+	parts := strings.Fields(declaration)
+	if len(parts) == 3 && parts[0] == "repeated" {
+		field.Repeated = true
+		parts = parts[1:]
+	}
+	if len(parts) != 2 {
+		return field, false
+	}
+	field.Type, field.Name = decodedScalar(parts[0]), parts[1]
+	if explicit {
+		// Models see the nullable fields of edition 2023 files as the wrappers of proto3 ones
+		field.Type = NewTypeMapper().getWrapperType(field.Type)
+	}
+	return field, true
+}
+
+// parseJSONNameOption returns the json_name in a field's options, or ""
+func parseJSONNameOption(options string) string {
+	_, value, ok := strings.Cut(options, "json_name = \"")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(value, "\"")
+	return name
+}
@@ -0,0 +1,108 @@
+package protogen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProtoMessages(t *testing.T) {
+	content := `syntax = "proto3";
+
+package test.v1;
+
+// Beacon blocks
+
+message Blocks {
+  option deprecated = true;
+
+  // The slot number
+  uint32 slot = 11;
+  google.protobuf.StringValue graffiti = 12;
+  repeated string tags = 13;
+  map<string, uint64> labels = 14 [deprecated = true]; // Label counts
+  reserved 15;
+}
+
+// SortOrder defines the order of results
+enum SortOrder {
+  ASC = 0;
+}
+
+message UInt32Filter {
+  oneof filter {
+    uint32 eq = 1; // Equal to value
+    UInt32List in = 2;
+  }
+  bool strict = 3;
+}
+
+service BlocksService {
+  rpc List(ListBlocksRequest) returns (ListBlocksResponse) {
+    option deprecated = true;
+  }
+}
+`
+
+	messages := parseProtoMessages(content)
+	require.Len(t, messages, 2)
+
+	blocks := messages[0]
+	assert.Equal(t, "Blocks", blocks.Name)
+	assert.Equal(t, "Beacon blocks", blocks.Comment)
+	assert.True(t, blocks.Deprecated)
+	assert.Equal(t, []protoField{
+		{Name: "slot", Type: protoUInt32, Number: 11, Comment: "The slot number"},
+		{Name: "graffiti", Type: "google.protobuf.StringValue", Number: 12},
+		{Name: "tags", Type: protoString, Number: 13, Repeated: true},
+		{Name: "labels", Type: protoUInt64, MapKey: protoString, Number: 14, Comment: "Label counts", Deprecated: true},
+	}, blocks.Fields)
+
+	filter := messages[1]
+	assert.Equal(t, "UInt32Filter", filter.Name)
+	assert.Empty(t, filter.Comment)
+	assert.Equal(t, []protoField{
+		{Name: "eq", Type: protoUInt32, Number: 1, Comment: "Equal to value", Oneof: "filter"},
+		{Name: "in", Type: "UInt32List", Number: 2, Oneof: "filter"},
+		{Name: "strict", Type: protoBool, Number: 3},
+	}, filter.Fields)
+}
+
+func TestProtoField(t *testing.T) {
+	wrapped := protoField{Name: "block_root", Type: "google.protobuf.UInt64Value"}
+	assert.True(t, wrapped.Nullable())
+	assert.Equal(t, protoUInt64, wrapped.Scalar())
+	assert.Equal(t, "blockRoot", wrapped.JSONName())
+
+	message := protoField{Name: "item", Type: "Blocks"}
+	assert.False(t, message.Nullable())
+	assert.Empty(t, message.Scalar())
+	assert.False(t, message.IsMap())
+
+	assert.Equal(t, "eventTime2", protoField{Name: "event_time_2"}.JSONName())
+}
+
+func TestParseProtoMessages_DDLExcerpt(t *testing.T) {
+	withComment := `// Beacon blocks
+//
+// CREATE TABLE default.blocks
+//   ENGINE = MergeTree
+//   ORDER BY slot
+
+message Blocks {
+  uint32 slot = 11;
+}
+`
+	withoutComment := `// CREATE TABLE default.blocks
+//   ENGINE = MergeTree
+//   ORDER BY slot
+
+message Blocks {
+  uint32 slot = 11;
+}
+`
+
+	assert.Equal(t, "Beacon blocks", parseProtoMessages(withComment)[0].Comment)
+	assert.Empty(t, parseProtoMessages(withoutComment)[0].Comment)
+}
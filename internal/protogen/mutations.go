@@ -0,0 +1,317 @@
+package protogen
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// mutationWarning is written above the Delete and Update RPCs and builders
+const mutationWarning = `Mutations run asynchronously and rewrite every data part holding a matching row,
+which is expensive on large tables. The call returns once ClickHouse accepted the mutation;
+follow its progress in system.mutations.`
+
+// mutationsEnabled reports whether a table gets Delete and Update RPCs: unsafe_mutations is
+// set and the table stores its rows itself
+func (g *Generator) mutationsEnabled(table *clickhouse.Table) bool {
+	if !g.config.UnsafeMutations || len(table.SortingKey) == 0 {
+		return false
+	}
+	if clickhouse.IsView(table) {
+		g.log.WithField("table", table.Name).Debug("Skipping mutations: views can't be mutated")
+		return false
+	}
+	return true
+}
+
+// mutationTarget returns the table a mutation runs on and the cluster it runs on. ClickHouse
+// can't mutate a Distributed table, so its local table is mutated on every node instead.
+func mutationTarget(table *clickhouse.Table) (string, string) {
+	topology := clickhouse.TableTopology(table)
+	if topology.Kind != clickhouse.TopologyDistributed {
+		return table.Name, ""
+	}
+	if topology.LocalDatabase != "" && topology.LocalDatabase != table.Database {
+		return topology.LocalDatabase + "." + topology.LocalTable, topology.Cluster
+	}
+	return topology.LocalTable, topology.Cluster
+}
+
+// updatableColumns returns the columns an Update may set with their message fields: the
+// columns an Insert writes, except those of the sorting and partition keys, which
+// ClickHouse can't update, and the tenant column
+func (g *Generator) updatableColumns(table *clickhouse.Table) ([]*clickhouse.Column, []*ProtoField) {
+	tenant, _ := g.tenantScopeFor(table)
+	columns, fields := g.insertColumns(table)
+
+	var (
+		updatable       []*clickhouse.Column
+		updatableFields []*ProtoField
+	)
+	for i, col := range columns {
+		if slices.Contains(table.SortingKey, col.Name) || (tenant != nil && col.Name == tenant.column) {
+			continue
+		}
+		if table.PartitionKey != "" && regexp.MustCompile(`\b`+regexp.QuoteMeta(col.Name)+`\b`).MatchString(table.PartitionKey) {
+			continue
+		}
+		updatable = append(updatable, col)
+		updatableFields = append(updatableFields, fields[i])
+	}
+	return updatable, updatableFields
+}
+
+// writeMutationMessages writes the request and response messages of the Delete and Update
+// RPCs. Both requests take the filters of the List request.
+func (g *Generator) writeMutationMessages(sb *strings.Builder, table *clickhouse.Table, columnMap map[string]*clickhouse.Column, tenant *tenantScope) {
+	if !g.mutationsEnabled(table) {
+		return
+	}
+
+	messageName := ToPascalCase(table.Name)
+	required := ""
+	if g.shouldGenerateAPI(table.Name) {
+		required = " [(google.api.field_behavior) = REQUIRED]"
+	}
+
+	fmt.Fprintf(sb, "// Request for deleting the %s records matching the filters.\n", table.Name)
+	writeCommentLines(sb, "", mutationWarning)
+	fmt.Fprintf(sb, "message Delete%sRequest {\n", messageName)
+	fieldNumber := g.writeRequestFilterFields(sb, table, columnMap)
+	if tenant != nil {
+		g.writeTenantRequestField(sb, table, tenant, fieldNumber)
+	}
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// Response for deleting %s records, sent once the mutation is accepted\n", table.Name)
+	fmt.Fprintf(sb, "message Delete%sResponse {}\n\n", messageName)
+
+	if columns, _ := g.updatableColumns(table); len(columns) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, "// Request for updating the %s records matching the filters.\n", table.Name)
+	writeCommentLines(sb, "", mutationWarning)
+	fmt.Fprintf(sb, "message Update%sRequest {\n", messageName)
+	fieldNumber = g.writeRequestFilterFields(sb, table, columnMap)
+	fmt.Fprintf(sb, "\n  // New values of the fields listed in update_fields; other fields are ignored.\n")
+	fmt.Fprintf(sb, "  %s update_values = %d%s;\n", messageName, fieldNumber, required)
+	fmt.Fprintf(sb, "  // Fields to set, by name. Fields of the sorting and partition keys, computed and\n")
+	fmt.Fprintf(sb, "  // masked fields can't be updated.\n")
+	fmt.Fprintf(sb, "  repeated string update_fields = %d%s;\n", fieldNumber+1, required)
+	if tenant != nil {
+		g.writeTenantRequestField(sb, table, tenant, fieldNumber+2)
+	}
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(sb, "// Response for updating %s records, sent once the mutation is accepted\n", table.Name)
+	fmt.Fprintf(sb, "message Update%sResponse {}\n\n", messageName)
+}
+
+// writeMutationRPCs writes the Delete and Update RPCs of a table's service
+func (g *Generator) writeMutationRPCs(sb *strings.Builder, table *clickhouse.Table, deprecationComment, deprecationOption string) {
+	if !g.mutationsEnabled(table) {
+		return
+	}
+
+	fmt.Fprintf(sb, "  // Delete records | Delete the records matching the filters with ALTER TABLE ... DELETE.\n")
+	writeCommentLines(sb, "  ", mutationWarning)
+	sb.WriteString(deprecationComment)
+	g.writeMutationRPC(sb, table, "Delete", "delete", deprecationOption)
+
+	if columns, _ := g.updatableColumns(table); len(columns) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "  // Update records | Set fields of the records matching the filters with ALTER TABLE ... UPDATE.\n")
+	writeCommentLines(sb, "  ", mutationWarning)
+	sb.WriteString(deprecationComment)
+	g.writeMutationRPC(sb, table, "Update", "update", deprecationOption)
+}
+
+// writeMutationRPC writes one mutation RPC, posted to <table>:<verb> with the API
+func (g *Generator) writeMutationRPC(sb *strings.Builder, table *clickhouse.Table, method, verb, deprecationOption string) {
+	messageName := ToPascalCase(table.Name)
+	if !g.shouldGenerateAPI(table.Name) {
+		writeRPC(sb, method, messageName, deprecationOption)
+		return
+	}
+	fmt.Fprintf(sb, "  rpc %s(%s%sRequest) returns (%s%sResponse) {\n", method, method, messageName, method, messageName)
+	sb.WriteString(deprecationOption)
+	fmt.Fprintf(sb, "    option (google.api.http) = {\n")
+	fmt.Fprintf(sb, "      post: \"%s/%s:%s\"\n", g.config.APIBasePath, table.Name, verb)
+	fmt.Fprintf(sb, "      body: \"*\"\n")
+	fmt.Fprintf(sb, "    };\n")
+	fmt.Fprintf(sb, "  }\n")
+}
+
+// writeCommentLines writes a multi-line comment, proto or Go, with every line prefixed by indent
+func writeCommentLines(sb *strings.Builder, indent, comment string) {
+	for _, line := range strings.Split(comment, "\n") {
+		fmt.Fprintf(sb, "%s// %s\n", indent, line)
+	}
+}
+
+// writeMutationSQLBuilderFunctions generates the SQL query builder functions of the Delete
+// and Update requests
+func (g *Generator) writeMutationSQLBuilderFunctions(sb *strings.Builder, table *clickhouse.Table) {
+	if !g.mutationsEnabled(table) {
+		return
+	}
+
+	messageName := getProtocMessageName(table.Name)
+	tenant, _ := g.tenantScopeFor(table)
+	target, cluster := mutationTarget(table)
+
+	fmt.Fprintf(sb, "\n// BuildDelete%sQuery constructs an ALTER TABLE ... DELETE mutation of the rows matching\n", messageName)
+	fmt.Fprintf(sb, "// a Delete%sRequest.\n", messageName)
+	writeCommentLines(sb, "", mutationWarning)
+	fmt.Fprintf(sb, "func BuildDelete%sQuery(req *Delete%sRequest%s, options ...QueryOption) (SQLQuery, error) {\n", messageName, messageName, tenantParam(tenant))
+	g.writeMutationFilters(sb, table, tenant, fmt.Sprintf("Delete%sRequest", messageName))
+	fmt.Fprintf(sb, "\treturn BuildMutationQuery(%q, %q, nil, nil, nil, qb, options...)\n", target, cluster)
+	fmt.Fprintf(sb, "}\n")
+
+	columns, fields := g.updatableColumns(table)
+	if len(columns) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, "\n// BuildUpdate%sQuery constructs an ALTER TABLE ... UPDATE mutation setting the\n", messageName)
+	fmt.Fprintf(sb, "// update_fields of the rows matching an Update%sRequest to their update_values.\n", messageName)
+	writeCommentLines(sb, "", mutationWarning)
+	fmt.Fprintf(sb, "func BuildUpdate%sQuery(req *Update%sRequest%s, options ...QueryOption) (SQLQuery, error) {\n", messageName, messageName, tenantParam(tenant))
+	fmt.Fprintf(sb, "\t// Validate the fields to update\n")
+	fmt.Fprintf(sb, "\tif req.GetUpdateValues() == nil {\n")
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"update_values are required\")\n")
+	fmt.Fprintf(sb, "\t}\n")
+	fmt.Fprintf(sb, "\tif len(req.GetUpdateFields()) == 0 {\n")
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"update_fields are required\")\n")
+	fmt.Fprintf(sb, "\t}\n\n")
+
+	fmt.Fprintf(sb, "\trow := req.GetUpdateValues()\n")
+	fmt.Fprintf(sb, "\tcolumns := make([]string, 0, len(req.GetUpdateFields()))\n")
+	fmt.Fprintf(sb, "\tvalues := make([]string, 0, len(req.GetUpdateFields()))\n")
+	fmt.Fprintf(sb, "\targs := make([]interface{}, 0, len(req.GetUpdateFields()))\n")
+	fmt.Fprintf(sb, "\tupdated := make(map[string]bool, len(req.GetUpdateFields()))\n")
+	fmt.Fprintf(sb, "\tfor _, field := range req.GetUpdateFields() {\n")
+	fmt.Fprintf(sb, "\t\tif updated[field] {\n")
+	fmt.Fprintf(sb, "\t\t\treturn SQLQuery{}, fmt.Errorf(\"update_fields lists %%s twice\", field)\n")
+	fmt.Fprintf(sb, "\t\t}\n")
+	fmt.Fprintf(sb, "\t\tupdated[field] = true\n\n")
+	fmt.Fprintf(sb, "\t\tswitch field {\n")
+	for i, col := range columns {
+		field := fields[i]
+		fmt.Fprintf(sb, "\t\tcase %q:\n", field.Name)
+		value := g.writeInsertArg(sb, field, "\t\t\t")
+		fmt.Fprintf(sb, "\t\t\tcolumns = append(columns, %q)\n", col.Name)
+		fmt.Fprintf(sb, "\t\t\tvalues = append(values, %q)\n", getInsertValueExpression(col, table.Name, &g.config.Conversion))
+		fmt.Fprintf(sb, "\t\t\targs = append(args, %s)\n", value)
+	}
+	fmt.Fprintf(sb, "\t\tdefault:\n")
+	fmt.Fprintf(sb, "\t\t\treturn SQLQuery{}, fmt.Errorf(\"field %%s can't be updated\", field)\n")
+	fmt.Fprintf(sb, "\t\t}\n")
+	fmt.Fprintf(sb, "\t}\n\n")
+
+	g.writeMutationFilters(sb, table, tenant, fmt.Sprintf("Update%sRequest", messageName))
+	fmt.Fprintf(sb, "\treturn BuildMutationQuery(%q, %q, columns, values, args, qb, options...)\n", target, cluster)
+	fmt.Fprintf(sb, "}\n")
+}
+
+// writeMutationFilters writes the validation and conditions of the filters of a mutation
+// request, the same as those of the List request except that the primary key filter is
+// always required. The scope conditions the generator adds come last, so they can't stand
+// in for the request's own filters.
+func (g *Generator) writeMutationFilters(sb *strings.Builder, table *clickhouse.Table, tenant *tenantScope, request string) {
+	writeValidateRequestCall(sb, request)
+
+	columnMap := make(map[string]*clickhouse.Column)
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		columnMap[col.Name] = col
+	}
+	primaryKey := table.SortingKey[0]
+	primaryKeyField := g.fieldName(table.Name, primaryKey)
+	missing := fmt.Sprintf("\t\treturn SQLQuery{}, invalidFilter(%q, \"mutations require a filter on primary key field %s\")\n", primaryKeyField, primaryKeyField)
+
+	fmt.Fprintf(sb, "\t// Build the filters using QueryBuilder, without the table alias ALTER TABLE lacks\n")
+	fmt.Fprintf(sb, "\tqb := %s\n\n", g.newQueryBuilder("WithUnqualifiedColumns()"))
+	fmt.Fprintf(sb, "\t// Mutations always filter on the primary key, even where projections make it optional\n")
+	fmt.Fprintf(sb, "\tif req.%s == nil {\n", ToPascalCase(primaryKeyField))
+	sb.WriteString(missing)
+	fmt.Fprintf(sb, "\t}\n")
+	g.writeFilterCondition(sb, table, primaryKey, primaryKeyField, columnMap[primaryKey], true)
+	fmt.Fprintf(sb, "\tif len(qb.whereConditions()) == 0 {\n")
+	sb.WriteString(missing)
+	fmt.Fprintf(sb, "\t}\n")
+	g.writeColumnFilterConditions(sb, table, primaryKey)
+
+	writeTenantCondition(sb, tenant)
+	g.writeDefaultWhereCondition(sb, table, false)
+}
+
+// writeMutationSQLFunctions writes BuildMutationQuery into common.go when unsafe_mutations
+// gives any table mutations
+func (g *Generator) writeMutationSQLFunctions(sb *strings.Builder) {
+	if !slices.ContainsFunc(g.tables, g.mutationsEnabled) {
+		return
+	}
+
+	sb.WriteString(`
+// BuildMutationQuery constructs an ALTER TABLE mutation of the rows of table matching qb:
+// a DELETE without columns, otherwise an UPDATE of the columns. Like those of
+// BuildInsertQuery, values holds the SQL expression of each column, whose first ? stands
+// for its value, and args the values. They are bound in qb's placeholder style. With a
+// cluster the mutation runs ON CLUSTER, as Distributed tables mutate their local tables on
+// every node.
+func BuildMutationQuery(table, cluster string, columns, values []string, args []interface{}, qb *QueryBuilder, options ...QueryOption) (SQLQuery, error) {
+	opts := &QueryOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	// ALTER TABLE takes no table alias, so conditions must name their columns unqualified
+	if !qb.options.Unqualified {
+		return SQLQuery{}, fmt.Errorf("mutations need a query builder made WithUnqualifiedColumns")
+	}
+	// A mutation without conditions would rewrite the whole table
+	if len(qb.whereConditions()) == 0 {
+		return SQLQuery{}, fmt.Errorf("mutations need at least one filter")
+	}
+	if len(qb.havingConditions()) > 0 {
+		return SQLQuery{}, fmt.Errorf("mutations take no HAVING conditions")
+	}
+	if len(qb.ctes) > 0 {
+		return SQLQuery{}, fmt.Errorf("mutations take no CTEs")
+	}
+	if len(columns) != len(values) || len(columns) != len(args) {
+		return SQLQuery{}, fmt.Errorf("every updated column needs one value expression and value")
+	}
+
+	target := quoteTable(opts.Database, table)
+	if cluster != "" {
+		target += " ON CLUSTER '" + strings.ReplaceAll(cluster, "'", "\\'") + "'"
+	}
+
+	mutation := "DELETE"
+	queryArgs := qb.GetArgs()
+	if len(columns) > 0 {
+		// Numbered placeholders follow the conditions', while ? arguments come in query order
+		assignments := make([]string, len(columns))
+		for i, col := range columns {
+			placeholder := qb.formatVariable(qb.argCounter+i, args[i])
+			assignments[i] = QuoteIdentifier(col) + " = " + strings.Replace(values[i], "?", placeholder, 1)
+		}
+		mutation = "UPDATE " + strings.Join(assignments, ", ")
+		if qb.options.VariableSubstitution == VariableSubstitutionStandard {
+			queryArgs = append(append([]interface{}{}, args...), queryArgs...)
+		} else {
+			queryArgs = append(append([]interface{}{}, queryArgs...), args...)
+		}
+	}
+
+	return qb.sqlQuery(fmt.Sprintf("ALTER TABLE %s %s WHERE %s", target, mutation, strings.Join(qb.whereConditions(), " AND ")), queryArgs), nil
+}
+`)
+}
@@ -0,0 +1,257 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Mutations(t *testing.T) {
+	block := &clickhouse.Table{
+		Name:         "fct_block",
+		Database:     "default",
+		Engine:       "Distributed('{cluster}', default, fct_block_local, rand())",
+		PartitionKey: "toYYYYMM(slot_start_date_time)",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("slot_start_date_time", "DateTime", 2),
+			clickhouse.NewColumn("client", "Nullable(String)", 3),
+			clickhouse.NewColumn("seen", "DateTime64(3)", 4),
+		},
+		SortingKey: []string{"slot"},
+	}
+	keysOnly := &clickhouse.Table{
+		Name:       "fct_keys",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+
+	generate := func(t *testing.T, unsafe bool) func(string) string {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.EnableAPI = true
+		cfg.UnsafeMutations = unsafe
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{block, keysOnly}))
+		return func(name string) string {
+			content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+			require.NoError(t, err)
+			return string(content)
+		}
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		read := generate(t, false)
+		assert.NotContains(t, read("fct_block.proto"), "Delete")
+		assert.NotContains(t, read("common.go"), "BuildMutationQuery")
+	})
+
+	read := generate(t, true)
+
+	proto := read("fct_block.proto")
+	assert.Contains(t, proto, "message DeleteFctBlockRequest {")
+	assert.Contains(t, proto, "UInt32Filter slot = 1 [(google.api.field_behavior) = REQUIRED")
+	assert.Contains(t, proto, "FctBlock update_values = 5 [(google.api.field_behavior) = REQUIRED];")
+	assert.Contains(t, proto, "repeated string update_fields = 6 [(google.api.field_behavior) = REQUIRED];")
+	assert.Contains(t, proto, "rpc Delete(DeleteFctBlockRequest) returns (DeleteFctBlockResponse)")
+	assert.Contains(t, proto, `post: "/api/v1/fct_block:update"`)
+	assert.Contains(t, proto, "  // follow its progress in system.mutations.\n  rpc Update(")
+
+	sql := read("fct_block_sql.go")
+	// Distributed tables mutate their local table on the cluster
+	assert.Contains(t, sql, `return BuildMutationQuery("fct_block_local", "{cluster}", nil, nil, nil, qb, options...)`)
+	assert.Contains(t, sql, `return BuildMutationQuery("fct_block_local", "{cluster}", columns, values, args, qb, options...)`)
+	// Sorting and partition key columns can't be updated
+	assert.NotContains(t, sql, `case "slot":`)
+	assert.NotContains(t, sql, `case "slot_start_date_time":`)
+	assert.Contains(t, sql, "case \"client\":\n\t\t\tvar valueClient interface{}")
+	assert.Contains(t, sql, "columns = append(columns, \"seen\")\n\t\t\tvalues = append(values, \"CAST(fromUnixTimestamp64Micro(toInt64(?)) AS DateTime64(3))\")")
+
+	keysProto := read("fct_keys.proto")
+	assert.Contains(t, keysProto, "rpc Delete(")
+	assert.NotContains(t, keysProto, "Update", "tables with only key columns can't be updated")
+	assert.Contains(t, read("common.go"), "func BuildMutationQuery(table, cluster string, columns, values []string, args []interface{}, qb *QueryBuilder, options ...QueryOption) (SQLQuery, error) {")
+}
+
+// mutationScopeTest runs in the generated package: a Delete must filter on the primary key
+// itself, however the tenant condition and the projection's alternative key are set
+const mutationScopeTest = `package testv1
+
+import "testing"
+
+func TestDeleteNeedsPrimaryKeyFilter(t *testing.T) {
+	for name, req := range map[string]*DeleteFctEventsRequest{
+		"empty":          {},
+		"empty filter":   {EventId: &UInt64Filter{}},
+		"empty in":       {EventId: &UInt64Filter{Filter: &UInt64Filter_In{In: &UInt64List{}}}},
+		"projection key": {OrgId: &UInt32Filter{Filter: &UInt32Filter_Eq{Eq: 1}}},
+	} {
+		if query, err := BuildDeleteFctEventsQuery(req, "acme"); err == nil {
+			t.Errorf("%s: got %q", name, query.Query)
+		}
+	}
+
+	query, err := BuildDeleteFctEventsQuery(&DeleteFctEventsRequest{EventId: &UInt64Filter{Filter: &UInt64Filter_Eq{Eq: 5}}}, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ALTER TABLE ` + "`fct_events` DELETE WHERE `event_id` = ? AND `tenant_id` = ?" + `"; query.Query != want {
+		t.Errorf("got %q, want %q", query.Query, want)
+	}
+}
+`
+
+func TestGenerator_MutationsRequirePrimaryKeyFilter(t *testing.T) {
+	table := tenantTestTable()
+	table.Projections = []clickhouse.Projection{{Name: "by_org", OrderByKey: []string{"org_id"}, Type: "NORMAL"}}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.GoPackage = "example.com/gen/testv1"
+	cfg.UnsafeMutations = true
+	cfg.Tenant = config.TenantConfig{Column: "tenant_id"}
+	generateModule(t, cfg, []*clickhouse.Table{table})
+
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "mutation_test.go"), []byte(mutationScopeTest), 0o600))
+	goCommand(t, cfg.OutputDir, "test", "-run", "TestDeleteNeedsPrimaryKeyFilter", ".")
+}
+
+// mutationColumnsTest runs in the generated package: mutation conditions name their columns
+// without the _t alias ALTER TABLE lacks, however the QueryBuilder writes them. In the wanted
+// queries ' stands for a backtick and $ for a DateTime64 value.
+const mutationColumnsTest = `package testv1
+
+import (
+	"strings"
+	"testing"
+)
+
+var sql = strings.NewReplacer("'", "` + "`" + `", "$", "fromUnixTimestamp64Micro(toInt64(?))")
+
+func TestMutationColumnsUnqualified(t *testing.T) {
+	seen := &Int64Filter{Filter: &Int64Filter_In{In: &Int64List{Values: []int64{1, 2, 3}}}}
+	query, err := BuildDeleteFctSeenQuery(&DeleteFctSeenRequest{
+		Slot:       &UInt32Filter{Filter: &UInt32Filter_Eq{Eq: 5}},
+		Seen:       seen,
+		IsOrphaned: &BoolFilter{Filter: &BoolFilter_Eq{Eq: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sql.Replace("ALTER TABLE 'fct_seen' DELETE WHERE 'slot' = ? AND ('seen' IN ($, $) OR 'seen' IN ($)) AND ('is_orphaned' != 0) = ?")
+	if query.Query != want {
+		t.Errorf("got %q, want %q", query.Query, want)
+	}
+
+	// Other queries keep the alias
+	list, err := BuildListFctSeenQuery(&ListFctSeenRequest{Slot: &UInt32Filter{Filter: &UInt32Filter_Eq{Eq: 5}}, Seen: seen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sql.Replace("(_t.'seen' IN ($, $) OR _t.'seen' IN ($))"); !strings.Contains(list.Query, want) {
+		t.Errorf("got %q, want it to contain %q", list.Query, want)
+	}
+}
+`
+
+func TestGenerator_MutationColumnsUnqualified(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:   "fct_seen",
+		Engine: "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("seen", "DateTime64(3)", 2),
+			clickhouse.NewColumn("is_orphaned", "UInt8", 3),
+		},
+		SortingKey: []string{"slot"},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.GoPackage = "example.com/gen/testv1"
+	cfg.UnsafeMutations = true
+	cfg.QueryLimits.InChunkSize = 2
+	cfg.Conversion.BoolColumns = []string{"is_*"}
+	generateModule(t, cfg, []*clickhouse.Table{table})
+
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "mutation_test.go"), []byte(mutationColumnsTest), 0o600))
+	goCommand(t, cfg.OutputDir, "test", "-run", "TestMutationColumnsUnqualified", ".")
+}
+
+// serverParametersTest runs in the generated package: update values that aren't scalars
+// are typed and formatted as the literals ClickHouse parses server-side parameters with
+const serverParametersTest = `package testv1
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestServerParameterValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		field      string
+		row        *FctTags
+		assignment string
+		value      string
+	}{
+		{"Array", "tags", &FctTags{Tags: []string{"a", "b'c"}}, "` + "`tags`" + ` = {p2:Array(String)}", "['a', 'b\\'c']"},
+		{"Numeric array", "counts", &FctTags{Counts: []uint32{1, 2}}, "` + "`counts`" + ` = {p2:Array(UInt32)}", "[1, 2]"},
+		{"Map", "attrs", &FctTags{Attrs: map[string]uint64{"k": 1, "a": 2}}, "` + "`attrs`" + ` = {p2:Map(String, UInt64)}", "{'a': 2, 'k': 1}"},
+		{"Null", "note", &FctTags{}, "` + "`note`" + ` = {p2:Nullable(Nothing)}", "\\N"},
+		{"Nullable", "note", &FctTags{Note: wrapperspb.String("x\ty")}, "` + "`note`" + ` = {p2:String}", "x\\ty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := BuildUpdateFctTagsQuery(&UpdateFctTagsRequest{
+				Id:           &UInt64Filter{Filter: &UInt64Filter_Eq{Eq: 5}},
+				UpdateValues: tt.row,
+				UpdateFields: []string{tt.field},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "ALTER TABLE ` + "`fct_tags`" + ` UPDATE " + tt.assignment + " WHERE ` + "`id`" + ` = {p1:UInt64}"
+			if query.Query != want {
+				t.Errorf("got %q, want %q", query.Query, want)
+			}
+			if got := query.Parameters["p2"]; got != tt.value {
+				t.Errorf("got value %q, want %q", got, tt.value)
+			}
+		})
+	}
+}
+`
+
+func TestGenerator_ServerParameterValues(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.GoPackage = "example.com/gen/testv1"
+	cfg.UnsafeMutations = true
+	cfg.SQLParameters = config.SQLParametersServer
+	generateModule(t, cfg, []*clickhouse.Table{{
+		Name:   "fct_tags",
+		Engine: "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("id", "UInt64", 1),
+			clickhouse.NewColumn("tags", "Array(String)", 2),
+			clickhouse.NewColumn("counts", "Array(UInt32)", 3),
+			clickhouse.NewColumn("attrs", "Map(String, UInt64)", 4),
+			clickhouse.NewColumn("note", "Nullable(String)", 5),
+		},
+		SortingKey: []string{"id"},
+	}})
+
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "parameters_test.go"), []byte(serverParametersTest), 0o600))
+	goCommand(t, cfg.OutputDir, "test", "-run", "TestServerParameterValues", ".")
+}
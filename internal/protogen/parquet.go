@@ -0,0 +1,240 @@
+package protogen
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+const defaultParquetDir = "parquet"
+
+// icebergTableSpec is the body of an Iceberg REST catalog CreateTableRequest
+type icebergTableSpec struct {
+	Name       string            `json:"name"`
+	Schema     icebergSchema     `json:"schema"`
+	WriteOrder *icebergSortOrder `json:"write-order,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type icebergSchema struct {
+	Type     string         `json:"type"`
+	SchemaID int            `json:"schema-id"`
+	Fields   []icebergField `json:"fields"`
+}
+
+type icebergField struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Type     any    `json:"type"`
+	Doc      string `json:"doc,omitempty"`
+}
+
+type icebergList struct {
+	Type            string `json:"type"`
+	ElementID       int    `json:"element-id"`
+	Element         string `json:"element"`
+	ElementRequired bool   `json:"element-required"`
+}
+
+type icebergMap struct {
+	Type          string `json:"type"`
+	KeyID         int    `json:"key-id"`
+	Key           string `json:"key"`
+	ValueID       int    `json:"value-id"`
+	Value         string `json:"value"`
+	ValueRequired bool   `json:"value-required"`
+}
+
+type icebergSortOrder struct {
+	OrderID int                `json:"order-id"`
+	Fields  []icebergSortField `json:"fields"`
+}
+
+type icebergSortField struct {
+	Transform string `json:"transform"`
+	SourceID  int    `json:"source-id"`
+	Direction string `json:"direction"`
+	NullOrder string `json:"null-order"`
+}
+
+// GenerateParquet generates the Parquet schema of every table's rows, and with
+// parquet.iceberg an Iceberg table spec, so lake exports mirroring ClickHouse use the
+// column types of the API layer. Both are derived from the row message and use its proto
+// field numbers as field IDs, which is how Iceberg maps Parquet columns to its schema.
+func (g *Generator) GenerateParquet(tables []*clickhouse.Table) error {
+	dir := g.emitterDir(g.config.Parquet.Dir, defaultParquetDir)
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create Parquet directory: %w", err)
+	}
+
+	for _, table := range tables {
+		row := g.rowMessage(table)
+		name := strings.ToLower(table.Name)
+
+		if err := g.writeFile(filepath.Join(dir, name+".schema"), buildParquetSchema(table, row)); err != nil {
+			return err
+		}
+
+		if !g.config.Parquet.Iceberg {
+			continue
+		}
+		// Renamed sorting key columns are ordered by their fields
+		keyed := *table
+		keyed.SortingKey = make([]string, len(table.SortingKey))
+		for i, column := range table.SortingKey {
+			keyed.SortingKey[i] = g.fieldName(table.Name, column)
+		}
+		content, err := json.MarshalIndent(buildIcebergTableSpec(&keyed, row), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode Iceberg table spec of %s: %w", table.Name, err)
+		}
+		if err := g.writeFile(filepath.Join(dir, name+".iceberg.json"), string(content)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildParquetSchema renders the schema in the message syntax of parquet-mr and
+// parquet-cli, with lists and maps in the standard three-level layout. The syntax has no
+// comments, so unlike other outputs the file has no generated-code header.
+func buildParquetSchema(table *clickhouse.Table, row protoMessage) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "message %s {\n", SanitizeName(table.Name))
+	for _, field := range row.Fields {
+		switch {
+		case field.IsMap():
+			fmt.Fprintf(sb, "  required group %s = %d (MAP) {\n", field.Name, field.Number)
+			sb.WriteString("    repeated group key_value {\n")
+			fmt.Fprintf(sb, "      required %s;\n", parquetPrimitive(field.MapKey, "key"))
+			fmt.Fprintf(sb, "      required %s;\n", parquetPrimitive(field.Type, "value"))
+			sb.WriteString("    }\n  }\n")
+		case field.Repeated:
+			fmt.Fprintf(sb, "  required group %s = %d (LIST) {\n", field.Name, field.Number)
+			sb.WriteString("    repeated group list {\n")
+			fmt.Fprintf(sb, "      required %s;\n", parquetPrimitive(field.Type, "element"))
+			sb.WriteString("    }\n  }\n")
+		default:
+			repetition := "required"
+			if field.Nullable() {
+				repetition = "optional"
+			}
+			fmt.Fprintf(sb, "  %s %s;\n", repetition, parquetPrimitive(field.Type, fmt.Sprintf("%s = %d", field.Name, field.Number)))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// parquetPrimitive returns the physical type, name and logical type annotation of a proto
+// scalar or wrapper. Unsigned integers keep their signedness through the INTEGER annotation.
+func parquetPrimitive(protoType, name string) string {
+	scalar := wrapperScalar(protoType)
+	if scalar == "" {
+		scalar = protoType
+	}
+
+	switch scalar {
+	case protoInt32:
+		return "int32 " + name
+	case protoInt64:
+		return "int64 " + name
+	case protoUInt32:
+		return fmt.Sprintf("int32 %s (INTEGER(32,false))", name)
+	case protoUInt64:
+		return fmt.Sprintf("int64 %s (INTEGER(64,false))", name)
+	case protoFloat:
+		return "float " + name
+	case protoDouble:
+		return "double " + name
+	case protoBool:
+		return "boolean " + name
+	case protoBytes:
+		return "binary " + name
+	}
+	return fmt.Sprintf("binary %s (STRING)", name)
+}
+
+// buildIcebergTableSpec converts the row message to an Iceberg schema with the sorting key
+// as write order. Element, key and value IDs of lists and maps are numbered after the
+// highest field number.
+func buildIcebergTableSpec(table *clickhouse.Table, row protoMessage) icebergTableSpec {
+	nextID := 0
+	for _, field := range row.Fields {
+		nextID = max(nextID, field.Number)
+	}
+	allocate := func() int {
+		nextID++
+		return nextID
+	}
+
+	spec := icebergTableSpec{
+		Name:   table.Name,
+		Schema: icebergSchema{Type: "struct", Fields: make([]icebergField, 0, len(row.Fields))},
+	}
+	if table.Database != "" {
+		spec.Properties = map[string]string{"clickhouse.source": table.Database + "." + table.Name}
+	}
+
+	ids := make(map[string]int, len(row.Fields))
+	for _, field := range row.Fields {
+		ids[field.Name] = field.Number
+		iceberg := icebergField{ID: field.Number, Name: field.Name, Required: !field.Nullable(), Doc: field.Comment}
+		switch {
+		case field.IsMap():
+			keyID := allocate()
+			iceberg.Type = icebergMap{
+				Type: "map", KeyID: keyID, Key: icebergPrimitive(field.MapKey),
+				ValueID: allocate(), Value: icebergPrimitive(field.Type), ValueRequired: true,
+			}
+		case field.Repeated:
+			iceberg.Type = icebergList{Type: "list", ElementID: allocate(), Element: icebergPrimitive(field.Type), ElementRequired: true}
+		default:
+			iceberg.Type = icebergPrimitive(field.Type)
+		}
+		spec.Schema.Fields = append(spec.Schema.Fields, iceberg)
+	}
+
+	order := icebergSortOrder{OrderID: 1}
+	for _, column := range table.SortingKey {
+		id, ok := ids[SanitizeName(column)]
+		if !ok {
+			// Sorting key expressions and omitted columns can't be expressed
+			break
+		}
+		order.Fields = append(order.Fields, icebergSortField{Transform: "identity", SourceID: id, Direction: "asc", NullOrder: "nulls-first"})
+	}
+	if len(order.Fields) > 0 {
+		spec.WriteOrder = &order
+	}
+	return spec
+}
+
+// icebergPrimitive maps a proto scalar or wrapper to an Iceberg primitive. Iceberg has no
+// unsigned integers: uint32 widens to long, and uint64 shares long's range.
+func icebergPrimitive(protoType string) string {
+	scalar := wrapperScalar(protoType)
+	if scalar == "" {
+		scalar = protoType
+	}
+
+	switch scalar {
+	case protoInt32:
+		return "int"
+	case protoInt64, protoUInt32, protoUInt64:
+		return "long"
+	case protoFloat:
+		return "float"
+	case protoDouble:
+		return "double"
+	case protoBool:
+		return "boolean"
+	case protoBytes:
+		return "binary"
+	}
+	return "string"
+}
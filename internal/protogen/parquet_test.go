@@ -0,0 +1,153 @@
+package protogen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParquetPrimitive(t *testing.T) {
+	assert.Equal(t, "int32 slot (INTEGER(32,false))", parquetPrimitive(protoUInt32, "slot"))
+	assert.Equal(t, "int64 fee", parquetPrimitive("google.protobuf.Int64Value", "fee"))
+	assert.Equal(t, "binary root (STRING)", parquetPrimitive(protoString, "root"))
+	assert.Equal(t, "binary raw", parquetPrimitive(protoBytes, "raw"))
+}
+
+func TestBuildIcebergTableSpec_WriteOrder(t *testing.T) {
+	row := protoMessage{Fields: []protoField{
+		{Name: "slot", Type: protoUInt32, Number: 11},
+		{Name: "tags", Type: protoString, Number: 12, Repeated: true},
+		{Name: "fee", Type: "google.protobuf.UInt64Value", Number: 14},
+	}}
+
+	t.Run("Columns", func(t *testing.T) {
+		spec := buildIcebergTableSpec(&clickhouse.Table{Name: "blocks", SortingKey: []string{"slot", "fee"}}, row)
+
+		require.NotNil(t, spec.WriteOrder)
+		require.Len(t, spec.WriteOrder.Fields, 2)
+		assert.Equal(t, 11, spec.WriteOrder.Fields[0].SourceID)
+		assert.Equal(t, 14, spec.WriteOrder.Fields[1].SourceID)
+		assert.Equal(t, icebergList{Type: "list", ElementID: 15, Element: "string", ElementRequired: true}, spec.Schema.Fields[1].Type)
+		assert.False(t, spec.Schema.Fields[2].Required)
+		assert.Nil(t, spec.Properties)
+	})
+
+	t.Run("Stops at an expression", func(t *testing.T) {
+		spec := buildIcebergTableSpec(&clickhouse.Table{Name: "blocks", SortingKey: []string{"slot", "toDate(fee)", "fee"}}, row)
+
+		require.NotNil(t, spec.WriteOrder)
+		assert.Len(t, spec.WriteOrder.Fields, 1)
+	})
+
+	t.Run("No usable sorting key", func(t *testing.T) {
+		spec := buildIcebergTableSpec(&clickhouse.Table{Name: "blocks", SortingKey: []string{"cityHash64(slot)"}}, row)
+		assert.Nil(t, spec.WriteOrder)
+	})
+}
+
+func TestGenerator_GenerateParquet_WithoutIceberg(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := config.NewConfig()
+	cfg.OutputDir = tmpDir
+	cfg.Package = "test.v1"
+	cfg.Parquet.Enabled = true
+
+	tables := []*clickhouse.Table{
+		{
+			Name:    "blocks",
+			Columns: []clickhouse.Column{{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1}},
+		},
+	}
+
+	require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateParquet(tables))
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, defaultParquetDir, "blocks.schema"))
+	require.NoError(t, err)
+	assert.Equal(t, "message blocks {\n  required int32 slot = 11 (INTEGER(32,false));\n}\n", string(content))
+	assert.NoFileExists(t, filepath.Join(tmpDir, defaultParquetDir, "blocks.iceberg.json"))
+}
+
+func TestGenerator_ParquetColumnTypes(t *testing.T) {
+	tests := []struct {
+		column  clickhouse.Column
+		parquet string
+		iceberg string
+	}{
+		{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			"  required int32 slot = 11 (INTEGER(32,false));\n",
+			`{"id": 11, "name": "slot", "required": true, "type": "long"}`,
+		},
+		{
+			clickhouse.NewColumn("block_root", "FixedString(66)", 2),
+			"  required binary block_root = 12 (STRING);\n",
+			`{"id": 12, "name": "block_root", "required": true, "type": "string"}`,
+		},
+		{
+			clickhouse.NewColumn("gas_used", "Nullable(UInt64)", 3),
+			"  optional int64 gas_used = 13 (INTEGER(64,false));\n",
+			`{"id": 13, "name": "gas_used", "required": false, "type": "long"}`,
+		},
+		{
+			clickhouse.NewColumn("base_fee", "Int64", 4),
+			"  required int64 base_fee = 14;\n",
+			`{"id": 14, "name": "base_fee", "required": true, "type": "long"}`,
+		},
+		{
+			clickhouse.NewColumn("blob_sizes", "Array(UInt32)", 5),
+			"  required group blob_sizes = 15 (LIST) {\n    repeated group list {\n      required int32 element (INTEGER(32,false));\n    }\n  }\n",
+			`{"id": 15, "name": "blob_sizes", "required": true, "type": {"type": "list", "element-id": 19, "element": "long", "element-required": true}}`,
+		},
+		{
+			clickhouse.NewColumn("client_share", "Map(LowCardinality(String), Float64)", 6),
+			"  required group client_share = 16 (MAP) {\n    repeated group key_value {\n      required binary key (STRING);\n      required double value;\n    }\n  }\n",
+			`{"id": 16, "name": "client_share", "required": true, "type": {"type": "map", "key-id": 20, "key": "string", "value-id": 21, "value": "double", "value-required": true}}`,
+		},
+		{
+			clickhouse.NewColumn("reward", "Float32", 7),
+			"  required float reward = 17;\n",
+			`{"id": 17, "name": "reward", "required": true, "type": "float"}`,
+		},
+		{
+			clickhouse.NewColumn("missed", "Bool", 8),
+			"  required boolean missed = 18;\n",
+			`{"id": 18, "name": "missed", "required": true, "type": "boolean"}`,
+		},
+	}
+
+	columns := make([]clickhouse.Column, len(tests))
+	for i, tt := range tests {
+		columns[i] = tt.column
+	}
+	cfg := config.NewConfig()
+	cfg.Parquet = config.ParquetConfig{Enabled: true, Iceberg: true}
+	files := generateColumnTypes(t, cfg, &clickhouse.Table{Name: "fct_block_24h", Database: "default", Engine: "MergeTree", SortingKey: []string{"slot"}}, columns)
+
+	schema := files[defaultParquetDir+"/fct_block_24h.schema"]
+	assert.True(t, strings.HasPrefix(schema, "message fct_block_24h {\n"))
+
+	var spec struct {
+		Schema struct {
+			Fields []json.RawMessage `json:"fields"`
+		} `json:"schema"`
+		Properties map[string]string `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(files[defaultParquetDir+"/fct_block_24h.iceberg.json"]), &spec))
+	assert.Equal(t, map[string]string{"clickhouse.source": "default.fct_block_24h"}, spec.Properties)
+	require.Len(t, spec.Schema.Fields, len(tests))
+
+	for i, tt := range tests {
+		t.Run(tt.column.Name, func(t *testing.T) {
+			assert.Contains(t, schema, tt.parquet)
+			assert.JSONEq(t, tt.iceberg, string(spec.Schema.Fields[i]))
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package protogen
+
+import (
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// applyServicePolicies returns tables with the sorting key cleared where a policy turns the
+// service off, so they only get their message like tables without one. Those tables are
+// copied before they are changed; other tables are returned as is.
+func (g *Generator) applyServicePolicies(tables []*clickhouse.Table) []*clickhouse.Table {
+	if len(g.config.Policies) == 0 {
+		return tables
+	}
+
+	result := make([]*clickhouse.Table, 0, len(tables))
+	for _, table := range tables {
+		if len(table.SortingKey) == 0 || g.config.Policy(table.Name).Service {
+			result = append(result, table)
+			continue
+		}
+
+		messageOnly := *table
+		messageOnly.SortingKey = nil
+		result = append(result, &messageOnly)
+	}
+	return result
+}
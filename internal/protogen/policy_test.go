@@ -0,0 +1,61 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Policies(t *testing.T) {
+	columns := []clickhouse.Column{
+		clickhouse.NewColumn("slot", "UInt64", 1),
+		clickhouse.NewColumn("block_root", "String", 2),
+	}
+	newTable := func(name string) *clickhouse.Table {
+		return &clickhouse.Table{Name: name, Database: "default", Engine: "MergeTree", Columns: columns, SortingKey: []string{"slot"}}
+	}
+	block, raw, node := newTable("fct_block"), newTable("int_block_raw"), newTable("dim_node")
+
+	off, on := false, true
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.EnableAPI = true
+	cfg.Policies = []config.PolicyConfig{
+		{Match: "*", API: &off},
+		{Match: "fct_", API: &on},
+		{Match: "int_", Service: &off},
+	}
+	gen := NewGenerator(cfg, logrus.New())
+
+	tables := gen.applyServicePolicies([]*clickhouse.Table{block, raw, node})
+	assert.Same(t, block, tables[0])
+	assert.Empty(t, tables[1].SortingKey, "int_ tables lose their service")
+	assert.Equal(t, []string{"slot"}, raw.SortingKey, "the input table is not modified")
+	assert.Same(t, node, tables[2])
+
+	require.NoError(t, gen.Generate([]*clickhouse.Table{block, raw, node}))
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	blockProto := read("fct_block.proto")
+	assert.Contains(t, blockProto, "service FctBlockService")
+	assert.Contains(t, blockProto, "option (google.api.http)")
+
+	rawProto := read("int_block_raw.proto")
+	assert.Contains(t, rawProto, "message IntBlockRaw {")
+	assert.NotContains(t, rawProto, "service ")
+
+	nodeProto := read("dim_node.proto")
+	assert.Contains(t, nodeProto, "service DimNodeService")
+	assert.NotContains(t, nodeProto, "google.api.http", "the API is off outside fct_")
+}
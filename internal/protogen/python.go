@@ -0,0 +1,278 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+const (
+	defaultPythonDir = "python"
+	pythonHeader     = "# Code generated by clickhouse-proto-gen. DO NOT EDIT.\n"
+	pythonBaseModel  = "ProtoModel"
+	protoEmpty       = "google.protobuf.Empty"
+)
+
+// pythonReserved are names a model field can't use: Python keywords, attributes of
+// pydantic's BaseModel that a field would shadow, and the names the annotations refer to
+//
+//nolint:gochecknoglobals // Lookup table
+var pythonReserved = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true, "def": true,
+	"del": true, "elif": true, "else": true, "except": true, "finally": true, "for": true,
+	"from": true, "global": true, "if": true, "import": true, "in": true, "is": true,
+	"lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true,
+	"construct": true, "copy": true, "dict": true, "json": true, "parse_obj": true,
+	"parse_raw": true, "parse_file": true, "schema": true, "schema_json": true,
+	"validate": true, "update_forward_refs": true, "from_orm": true, "fields": true,
+	"int": true, "str": true, "float": true, "bool": true, "Any": true, "Dict": true,
+	"List": true, "Optional": true, "Base64Bytes": true, "Field": true,
+}
+
+// GeneratePython generates a Python package with a Pydantic v2 model for every message:
+// common.py holds the filter types and <table>.py the messages of each table. Models
+// accept and produce the proto JSON mapping, so 64-bit integers are strings and field
+// aliases are the lowerCamelCase JSON names.
+func (g *Generator) GeneratePython(tables []*clickhouse.Table) error {
+	dir := g.emitterDir(g.config.Python.Dir, defaultPythonDir)
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create Python directory: %w", err)
+	}
+
+	common := g.commonMessages()
+	if err := g.writeFile(filepath.Join(dir, "common.py"), g.buildPythonCommon(common)); err != nil {
+		return err
+	}
+
+	commonNames := make(map[string]bool, len(common))
+	for _, msg := range common {
+		commonNames[msg.Name] = true
+	}
+
+	modules := make([]string, 0, len(tables))
+	for _, table := range tables {
+		module := strings.ToLower(table.Name)
+		messages := g.tableMessages(table)
+		content := buildPythonModule(messages, commonNames, pythonPagerOf(table, messages))
+		if err := g.writeFile(filepath.Join(dir, module+".py"), content); err != nil {
+			return err
+		}
+		modules = append(modules, module)
+	}
+
+	if err := g.writeFile(filepath.Join(dir, "pagination.py"), pythonPagination); err != nil {
+		return err
+	}
+	return g.writeFile(filepath.Join(dir, "__init__.py"), buildPythonInit(modules))
+}
+
+func (g *Generator) buildPythonCommon(messages []protoMessage) string {
+	sb := &strings.Builder{}
+	writePythonImports(sb, false)
+
+	sb.WriteString("\n\n")
+	fmt.Fprintf(sb, "class %s(BaseModel):\n", pythonBaseModel)
+	sb.WriteString("    \"\"\"Base of the generated models: fields are populated by their proto JSON name or Python name.\"\"\"\n\n")
+	sb.WriteString("    model_config = ConfigDict(populate_by_name=True, protected_namespaces=())\n")
+
+	for _, msg := range messages {
+		writePythonModel(sb, msg)
+	}
+	return sb.String()
+}
+
+// buildPythonModule renders the models of a table, importing the common types they use, and
+// the iterators of its List RPC when pager isn't nil
+func buildPythonModule(messages []protoMessage, commonNames map[string]bool, pager *pythonPager) string {
+	local := make(map[string]bool, len(messages))
+	for _, msg := range messages {
+		local[msg.Name] = true
+	}
+
+	imports := []string{pythonBaseModel}
+	for _, msg := range messages {
+		for _, field := range msg.Fields {
+			if commonNames[field.Type] && !local[field.Type] && !slices.Contains(imports, field.Type) {
+				imports = append(imports, field.Type)
+			}
+		}
+	}
+	slices.Sort(imports)
+
+	sb := &strings.Builder{}
+	writePythonImports(sb, pager != nil)
+	sb.WriteString("\n")
+	if len(imports) == 1 {
+		fmt.Fprintf(sb, "from .common import %s\n", imports[0])
+	} else {
+		sb.WriteString("from .common import (\n")
+		for _, name := range imports {
+			fmt.Fprintf(sb, "    %s,\n", name)
+		}
+		sb.WriteString(")\n")
+	}
+	if pager != nil {
+		sb.WriteString("from .pagination import RateLimiter, iterate_pages, iterate_pages_concurrently\n")
+	}
+
+	for _, msg := range messages {
+		writePythonModel(sb, msg)
+	}
+	if pager != nil {
+		writePythonIterators(sb, pager)
+	}
+	return sb.String()
+}
+
+func buildPythonInit(modules []string) string {
+	sb := &strings.Builder{}
+	sb.WriteString(pythonHeader)
+	sb.WriteString("\"\"\"Pydantic models of the generated protobuf messages.\"\"\"\n\n")
+	sb.WriteString("from . import common\n")
+	sb.WriteString("from . import pagination\n")
+	for _, module := range modules {
+		fmt.Fprintf(sb, "from . import %s\n", module)
+	}
+	sb.WriteString("\n__all__ = [\n    \"common\",\n    \"pagination\",\n")
+	for _, module := range modules {
+		fmt.Fprintf(sb, "    %q,\n", module)
+	}
+	sb.WriteString("]\n")
+	return sb.String()
+}
+
+// writePythonImports writes the header and imports of a module, with the callable and
+// iterator types the List iterators are annotated with when iterators is set
+func writePythonImports(sb *strings.Builder, iterators bool) {
+	sb.WriteString(pythonHeader)
+	sb.WriteString("from __future__ import annotations\n\n")
+	if iterators {
+		sb.WriteString("from collections.abc import AsyncIterator, Awaitable, Callable, Iterable\n")
+	}
+	sb.WriteString("from typing import Any, Dict, List, Optional\n\n")
+	sb.WriteString("from pydantic import Base64Bytes, BaseModel, ConfigDict, Field\n")
+}
+
+func writePythonModel(sb *strings.Builder, msg protoMessage) {
+	sb.WriteString("\n\n")
+	fmt.Fprintf(sb, "class %s(%s):\n", msg.Name, pythonBaseModel)
+
+	doc := msg.Comment
+	if msg.Deprecated {
+		doc = strings.TrimSpace(doc + "\n\nDeprecated.")
+	}
+	if doc != "" {
+		fmt.Fprintf(sb, "    \"\"\"%s\"\"\"\n", pythonDocstring(doc))
+		if len(msg.Fields) > 0 {
+			sb.WriteString("\n")
+		}
+	}
+	if doc == "" && len(msg.Fields) == 0 {
+		sb.WriteString("    pass\n")
+	}
+
+	for _, field := range msg.Fields {
+		fmt.Fprintf(sb, "    %s: %s\n", pythonFieldName(field.Name), pythonField(field))
+	}
+}
+
+// pythonField returns the annotation and Field() default of a model field
+func pythonField(field protoField) string {
+	var annotation, def string
+
+	switch {
+	case field.IsMap():
+		annotation, def = fmt.Sprintf("Dict[str, %s]", pythonValueType(field.Type)), "default_factory=dict"
+	case field.Repeated:
+		annotation, def = fmt.Sprintf("List[%s]", pythonValueType(field.Type)), "default_factory=list"
+	case field.Type == protoEmpty:
+		annotation, def = "Optional[Dict[str, Any]]", "default=None"
+	case field.Oneof != "" || field.Nullable() || field.Scalar() == "":
+		// Oneof members, wrappers and messages are absent rather than zero when unset
+		annotation, def = fmt.Sprintf("Optional[%s]", pythonValueType(field.Type)), "default=None"
+	default:
+		annotation, def = pythonValueType(field.Type), "default="+pythonZeroValue(field.Scalar())
+	}
+
+	args := []string{def, "alias=" + strconv.Quote(field.JSONName())}
+	if field.Comment != "" {
+		args = append(args, "description="+strconv.Quote(field.Comment))
+	}
+	if field.Deprecated {
+		args = append(args, "deprecated=True")
+	}
+	return fmt.Sprintf("%s = Field(%s)", annotation, strings.Join(args, ", "))
+}
+
+// pythonValueType maps a proto value type to its Python type in the proto JSON mapping
+func pythonValueType(protoType string) string {
+	if protoType == protoEmpty {
+		return "Dict[str, Any]"
+	}
+
+	scalar := wrapperScalar(protoType)
+	if scalar == "" {
+		scalar = protoType
+	}
+
+	switch {
+	case isBigIntScalar(scalar), scalar == protoString:
+		return "str"
+	case scalar == protoInt32 || scalar == protoUInt32:
+		return "int"
+	case scalar == protoFloat || scalar == protoDouble:
+		return "float"
+	case scalar == protoBool:
+		return "bool"
+	case scalar == protoBytes:
+		return "Base64Bytes"
+	}
+	return protoType
+}
+
+func pythonZeroValue(scalar string) string {
+	switch {
+	case isBigIntScalar(scalar), scalar == protoString:
+		return `""`
+	case scalar == protoInt32 || scalar == protoUInt32:
+		return "0"
+	case scalar == protoFloat || scalar == protoDouble:
+		return "0.0"
+	case scalar == protoBool:
+		return "False"
+	case scalar == protoBytes:
+		return `b""`
+	}
+	return "None"
+}
+
+// pythonFieldName returns the attribute name of a field, suffixed with an underscore when
+// it would clash with a keyword or BaseModel attribute. The alias keeps the JSON name.
+func pythonFieldName(name string) string {
+	if pythonReserved[name] || strings.HasPrefix(name, "model_") {
+		return name + "_"
+	}
+	return name
+}
+
+// pythonDocstring escapes a comment for use inside a triple-quoted docstring
+func pythonDocstring(comment string) string {
+	comment = strings.ReplaceAll(comment, `\`, `\\`)
+	comment = strings.ReplaceAll(comment, `"""`, `\"\"\"`)
+	if strings.HasSuffix(comment, `"`) {
+		comment += " "
+	}
+	lines := strings.Split(comment, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = "    " + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
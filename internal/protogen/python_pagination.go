@@ -0,0 +1,185 @@
+package protogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// pythonPager names the models and response field the List iterators of a table use
+type pythonPager struct {
+	function string // iterate_<table>
+	request  string
+	response string
+	row      string
+	rows     string // The attribute of the response holding the rows
+}
+
+// pythonPagerOf returns the pager of a table, or nil when it has no List RPC
+func pythonPagerOf(table *clickhouse.Table, messages []protoMessage) *pythonPager {
+	messageName := getProtocMessageName(table.Name)
+	response := fmt.Sprintf("List%sResponse", messageName)
+
+	for _, msg := range messages {
+		if msg.Name != response {
+			continue
+		}
+		for _, field := range msg.Fields {
+			if !field.Repeated {
+				continue
+			}
+			return &pythonPager{
+				function: "iterate_" + strings.ToLower(table.Name),
+				request:  fmt.Sprintf("List%sRequest", messageName),
+				response: response,
+				row:      field.Type,
+				rows:     pythonFieldName(field.Name),
+			}
+		}
+	}
+	return nil
+}
+
+// writePythonIterators writes the typed iterators over the rows of a table's List RPC
+func writePythonIterators(sb *strings.Builder, p *pythonPager) {
+	sb.WriteString("\n\n")
+	fmt.Fprintf(sb, "async def %s(\n", p.function)
+	fmt.Fprintf(sb, "    fetch: Callable[[%s], Awaitable[%s]],\n", p.request, p.response)
+	fmt.Fprintf(sb, "    request: %s,\n", p.request)
+	sb.WriteString("    *,\n")
+	sb.WriteString("    rate_limiter: Optional[RateLimiter] = None,\n")
+	fmt.Fprintf(sb, ") -> AsyncIterator[%s]:\n", p.row)
+	sb.WriteString("    \"\"\"Yields the rows matching request, from its page_token to the last page.\"\"\"\n")
+	sb.WriteString("    async for response in iterate_pages(fetch, request, rate_limiter=rate_limiter):\n")
+	fmt.Fprintf(sb, "        for row in response.%s:\n", p.rows)
+	sb.WriteString("            yield row\n")
+
+	sb.WriteString("\n\n")
+	fmt.Fprintf(sb, "async def %s_concurrently(\n", p.function)
+	fmt.Fprintf(sb, "    fetch: Callable[[%s], Awaitable[%s]],\n", p.request, p.response)
+	fmt.Fprintf(sb, "    requests: Iterable[%s],\n", p.request)
+	sb.WriteString("    *,\n")
+	sb.WriteString("    concurrency: int = 4,\n")
+	sb.WriteString("    rate_limiter: Optional[RateLimiter] = None,\n")
+	fmt.Fprintf(sb, ") -> AsyncIterator[%s]:\n", p.row)
+	sb.WriteString("    \"\"\"Yields the rows matching any of requests, paging through up to concurrency of them at once.\"\"\"\n")
+	sb.WriteString("    async for response in iterate_pages_concurrently(\n")
+	sb.WriteString("        fetch, requests, concurrency=concurrency, rate_limiter=rate_limiter\n")
+	sb.WriteString("    ):\n")
+	fmt.Fprintf(sb, "        for row in response.%s:\n", p.rows)
+	sb.WriteString("            yield row\n")
+}
+
+// pythonPagination is pagination.py, the table-independent part of the List iterators
+const pythonPagination = pythonHeader + `"""Iteration over every page of List RPCs, following next_page_token.
+
+The iterators don't depend on a transport: fetch is any coroutine function sending a List
+request, e.g. to the REST gateway or through a gRPC stub, and returning its response model.
+"""
+
+from __future__ import annotations
+
+import asyncio
+import contextlib
+from collections.abc import AsyncIterator, Awaitable, Callable, Iterable
+from typing import Optional, Tuple, TypeVar
+
+from pydantic import BaseModel
+
+RequestT = TypeVar("RequestT", bound=BaseModel)
+ResponseT = TypeVar("ResponseT", bound=BaseModel)
+
+
+class RateLimiter:
+    """Spaces out the pages fetched by every iterator it is passed to, to at most rate per second."""
+
+    def __init__(self, rate: float) -> None:
+        if rate <= 0:
+            raise ValueError("rate must be positive")
+        self._interval = 1.0 / rate
+        self._next = 0.0
+        self._lock = asyncio.Lock()
+
+    async def acquire(self) -> None:
+        """Waits until the next page may be fetched."""
+        async with self._lock:
+            now = asyncio.get_running_loop().time()
+            wait = self._next - now
+            self._next = max(now, self._next) + self._interval
+        if wait > 0:
+            await asyncio.sleep(wait)
+
+
+async def iterate_pages(
+    fetch: Callable[[RequestT], Awaitable[ResponseT]],
+    request: RequestT,
+    *,
+    rate_limiter: Optional[RateLimiter] = None,
+) -> AsyncIterator[ResponseT]:
+    """Yields the responses of request, from its page_token to the last page.
+
+    Iteration stops at the first error fetch raises. request itself isn't changed.
+    """
+    page = request
+    while True:
+        if rate_limiter is not None:
+            await rate_limiter.acquire()
+        response = await fetch(page)
+        yield response
+
+        token = getattr(response, "next_page_token", "")
+        if not token:
+            return
+        page = request.model_copy(update={"page_token": token})
+
+
+async def iterate_pages_concurrently(
+    fetch: Callable[[RequestT], Awaitable[ResponseT]],
+    requests: Iterable[RequestT],
+    *,
+    concurrency: int = 4,
+    rate_limiter: Optional[RateLimiter] = None,
+) -> AsyncIterator[ResponseT]:
+    """Yields the responses of every page of requests, paging through up to concurrency of them at once.
+
+    The pages of a request are yielded in order, but interleaved with those of the others. The
+    first error fetch raises cancels the other requests and is raised by the iterator.
+    """
+    if concurrency < 1:
+        raise ValueError("concurrency must be at least 1")
+
+    queue: asyncio.Queue[Tuple[Optional[ResponseT], Optional[BaseException]]] = asyncio.Queue(concurrency)
+    semaphore = asyncio.Semaphore(concurrency)
+
+    async def run(request: RequestT) -> None:
+        async with semaphore:
+            async for response in iterate_pages(fetch, request, rate_limiter=rate_limiter):
+                await queue.put((response, None))
+
+    async def produce() -> None:
+        tasks = [asyncio.create_task(run(request)) for request in requests]
+        try:
+            await asyncio.gather(*tasks)
+        except Exception as exc:
+            await queue.put((None, exc))
+        else:
+            await queue.put((None, None))
+        finally:
+            for task in tasks:
+                task.cancel()
+
+    producer = asyncio.create_task(produce())
+    try:
+        while True:
+            response, exc = await queue.get()
+            if exc is not None:
+                raise exc
+            if response is None:
+                return
+            yield response
+    finally:
+        producer.cancel()
+        with contextlib.suppress(asyncio.CancelledError):
+            await producer
+`
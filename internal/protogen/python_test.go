@@ -0,0 +1,167 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPythonField(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    protoField
+		expected string
+	}{
+		{
+			name:     "Big integer as string",
+			field:    protoField{Name: "block_number", Type: protoUInt64},
+			expected: `str = Field(default="", alias="blockNumber")`,
+		},
+		{
+			name:     "Wrapper",
+			field:    protoField{Name: "fee", Type: "google.protobuf.Int32Value", Comment: `Fee in "gwei"`},
+			expected: `Optional[int] = Field(default=None, alias="fee", description="Fee in \"gwei\"")`,
+		},
+		{
+			name:     "Repeated bytes",
+			field:    protoField{Name: "roots", Type: protoBytes, Repeated: true},
+			expected: `List[Base64Bytes] = Field(default_factory=list, alias="roots")`,
+		},
+		{
+			name:     "Map",
+			field:    protoField{Name: "labels", Type: protoInt64, MapKey: protoString},
+			expected: `Dict[str, str] = Field(default_factory=dict, alias="labels")`,
+		},
+		{
+			name:     "Oneof member",
+			field:    protoField{Name: "eq", Type: protoDouble, Oneof: "filter"},
+			expected: `Optional[float] = Field(default=None, alias="eq")`,
+		},
+		{
+			name:     "Empty",
+			field:    protoField{Name: "is_null", Type: protoEmpty, Oneof: "filter"},
+			expected: `Optional[Dict[str, Any]] = Field(default=None, alias="isNull")`,
+		},
+		{
+			name:     "Message",
+			field:    protoField{Name: "item", Type: "Blocks"},
+			expected: `Optional[Blocks] = Field(default=None, alias="item")`,
+		},
+		{
+			name:     "Deprecated",
+			field:    protoField{Name: "is_admin", Type: protoBool, Deprecated: true},
+			expected: `bool = Field(default=False, alias="isAdmin", deprecated=True)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, pythonField(tt.field))
+		})
+	}
+}
+
+func TestPythonFieldName(t *testing.T) {
+	assert.Equal(t, "slot", pythonFieldName("slot"))
+	assert.Equal(t, "from_", pythonFieldName("from"))
+	assert.Equal(t, "json_", pythonFieldName("json"))
+	assert.Equal(t, "model_version_", pythonFieldName("model_version"))
+	assert.Equal(t, "bytes", pythonFieldName("bytes"))
+}
+
+func TestGenerator_GeneratePython(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tmpDir
+	cfg.Package = "test.v1"
+	cfg.Python.Enabled = true
+	cfg.Python.Dir = "py/models"
+
+	tables := []*clickhouse.Table{
+		{
+			Name:       "blocks",
+			SortingKey: []string{"slot"},
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt32", BaseType: "UInt32", Position: 1},
+				{Name: "block_root", Type: "String", BaseType: "String", Position: 2},
+			},
+		},
+	}
+
+	require.NoError(t, NewGenerator(cfg, logrus.New()).GeneratePython(tables))
+
+	dir := filepath.Join(tmpDir, "py", "models")
+	for _, name := range []string{"__init__.py", "common.py", "pagination.py", "blocks.py"} {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "blocks.py"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "from .common import (\n    ProtoModel,\n    StringFilter,\n    UInt32Filter,\n)")
+	assert.Contains(t, string(content), "class Blocks(ProtoModel):")
+	assert.Contains(t, string(content), `block_root: str = Field(default="", alias="blockRoot")`)
+	assert.Contains(t, string(content), "blocks: List[Blocks] = Field(default_factory=list")
+
+	// The List RPC gets typed iterators over the rows of every page
+	assert.Contains(t, string(content), "from .pagination import RateLimiter, iterate_pages, iterate_pages_concurrently\n")
+	assert.Contains(t, string(content), "async def iterate_blocks(\n    fetch: Callable[[ListBlocksRequest], Awaitable[ListBlocksResponse]],\n")
+	assert.Contains(t, string(content), ") -> AsyncIterator[Blocks]:\n")
+	assert.Contains(t, string(content), "async def iterate_blocks_concurrently(\n")
+	assert.Contains(t, string(content), "        for row in response.blocks:\n            yield row\n")
+}
+
+func TestGenerator_GeneratePython_NoListRPC(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Python.Enabled = true
+
+	tables := []*clickhouse.Table{{Name: "events", Columns: []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)}}}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).GeneratePython(tables))
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "python", "events.py"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "pagination")
+	assert.NotContains(t, string(content), "collections.abc")
+}
+
+func TestGenerator_PythonColumnTypes(t *testing.T) {
+	tests := []struct {
+		column   clickhouse.Column
+		expected string
+	}{
+		{clickhouse.NewColumn("block_number", "UInt64", 1), `block_number: str = Field(default="", alias="blockNumber")`},
+		{clickhouse.NewColumn("log_index", "UInt32", 2), `log_index: int = Field(default=0, alias="logIndex")`},
+		{commented(clickhouse.NewColumn("from", "String", 3), "Sender address"), `from_: str = Field(default="", alias="from", description="Sender address")`},
+		{clickhouse.NewColumn("to", "Nullable(String)", 4), `to: Optional[str] = Field(default=None, alias="to")`},
+		{clickhouse.NewColumn("amount", "Float64", 5), `amount: float = Field(default=0.0, alias="amount")`},
+		{clickhouse.NewColumn("fee", "Nullable(Int64)", 6), `fee: Optional[str] = Field(default=None, alias="fee")`},
+		{clickhouse.NewColumn("topics", "Array(String)", 7), `topics: List[str] = Field(default_factory=list, alias="topics")`},
+		{clickhouse.NewColumn("labels", "Map(String, UInt64)", 8), `labels: Dict[str, str] = Field(default_factory=dict, alias="labels")`},
+		{clickhouse.NewColumn("is_contract", "Bool", 9), `is_contract: bool = Field(default=False, alias="isContract")`},
+		{commented(clickhouse.NewColumn("memo", "String", 10), "Memo. DEPRECATED: use labels"), `memo: str = Field(default="", alias="memo", description="Memo. DEPRECATED: use labels", deprecated=True)`},
+	}
+
+	columns := make([]clickhouse.Column, len(tests))
+	for i, tt := range tests {
+		columns[i] = tt.column
+	}
+	cfg := config.NewConfig()
+	cfg.IncludeComments = true
+	cfg.Python.Enabled = true
+	files := generateColumnTypes(t, cfg, &clickhouse.Table{Name: "transfers", Database: "default", Engine: "MergeTree", SortingKey: []string{"block_number"}}, columns)
+
+	row, _, _ := strings.Cut(files["python/transfers.py"], "class ListTransfersRequest(ProtoModel):")
+	for _, tt := range tests {
+		t.Run(tt.column.Name, func(t *testing.T) {
+			assert.Contains(t, row, "    "+tt.expected+"\n")
+		})
+	}
+}
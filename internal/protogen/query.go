@@ -0,0 +1,297 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// ErrTableFileClash is returned when the proto file of a table would be one the run writes
+// for all tables
+var ErrTableFileClash = errors.New("table proto file clashes with a generated file")
+
+// validateTableFiles checks that no table's proto file, named after the table, is one of
+// the files shared by all tables: a table named query would be overwritten by the Query
+// service, while its SQL helpers still refer to its messages.
+func (g *Generator) validateTableFiles(tables []*clickhouse.Table) error {
+	shared := map[string]string{}
+	if g.config.Emits(config.EmitServices) {
+		shared["common.proto"] = "the common filter types"
+	}
+	if g.registryEnabled() {
+		shared["registry.proto"] = "the table registry"
+	}
+	if g.queryServiceEnabled() {
+		shared["query.proto"] = "the Query service"
+	}
+
+	for _, table := range tables {
+		file := strings.ToLower(table.Name) + ".proto"
+		if owner, ok := shared[file]; ok {
+			return fmt.Errorf("%w: table %s would write %s, which holds %s; exclude or rename the table", ErrTableFileClash, table.Name, file, owner)
+		}
+	}
+	return nil
+}
+
+// registryEnabled reports whether the run writes the table registry, which the Query
+// service needs to look up tables
+func (g *Generator) registryEnabled() bool {
+	return (g.config.Registry || g.queryServiceEnabled()) && g.config.Emits(config.EmitMessages)
+}
+
+// queryServiceEnabled reports whether the run writes the umbrella Query service
+func (g *Generator) queryServiceEnabled() bool {
+	return g.config.QueryService && g.config.Emits(config.EmitServices) && g.config.Emits(config.EmitMessages)
+}
+
+// queryTables returns the tables the Query service dispatches to: those with a List
+// builder, in name order. Tenant-scoped tables are left out, as the dispatcher has no
+// tenant to scope them by.
+func (g *Generator) queryTables(tables []*clickhouse.Table) []*clickhouse.Table {
+	queryable := make([]*clickhouse.Table, 0, len(tables))
+	for _, table := range tables {
+		if len(table.SortingKey) == 0 {
+			continue
+		}
+		if tenant, _ := g.tenantScopeFor(table); tenant != nil {
+			g.log.WithField("table", table.Name).Debug("Leaving tenant-scoped table out of the Query service")
+			continue
+		}
+		queryable = append(queryable, table)
+	}
+	slices.SortFunc(queryable, func(a, b *clickhouse.Table) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return queryable
+}
+
+// GenerateQueryService writes query.proto, declaring a QueryService whose Query RPC lists
+// the rows of any table by name, and with the SQL helpers query.go, which dispatches a
+// QueryRequest to the List builder of its table
+func (g *Generator) GenerateQueryService(tables []*clickhouse.Table) error {
+	if err := g.writeFile(filepath.Join(g.protoDir(), "query.proto"), g.queryProtoContent()); err != nil {
+		return err
+	}
+	if !g.config.Emits(config.EmitSQL) {
+		return nil
+	}
+
+	filename := filepath.Join(g.goOutputDir(), "query.go")
+	if err := g.writeFile(filename, g.queryGoContent(tables)); err != nil {
+		return err
+	}
+	g.log.WithField("file", filename).Info("Generated Query service dispatcher")
+	return nil
+}
+
+// queryProtoContent renders query.proto
+func (g *Generator) queryProtoContent() string {
+	var sb strings.Builder
+	api := g.config.EnableAPI && g.config.Emits(config.EmitREST)
+
+	g.writeSyntax(&sb)
+	if g.config.Package != "" {
+		fmt.Fprintf(&sb, "package %s;\n", g.config.Package)
+	}
+	sb.WriteString("\nimport \"google/protobuf/struct.proto\";\n")
+	if api {
+		sb.WriteString("import \"google/api/annotations.proto\";\n")
+		sb.WriteString("import \"google/api/field_behavior.proto\";\n")
+	}
+	if goPackage := g.goPackage(); goPackage != "" {
+		fmt.Fprintf(&sb, "\noption go_package = \"%s\";\n", goPackage)
+	}
+	g.writeLanguageOptions(&sb, false)
+	g.writeFileFeatures(&sb)
+
+	required, optional := "", ""
+	if api {
+		required = " [(google.api.field_behavior) = REQUIRED]"
+		optional = " [(google.api.field_behavior) = OPTIONAL]"
+	}
+
+	sb.WriteString("\n// Request for listing the records of any table with a List RPC\n")
+	sb.WriteString("message QueryRequest {\n")
+	sb.WriteString("  // Name of the table, as listed by the registry\n")
+	fmt.Fprintf(&sb, "  string table = 1%s;\n", required)
+	sb.WriteString("  // Filters keyed by field name, in the JSON form of the table's List request,\n")
+	sb.WriteString("  // e.g. {\"slot\": {\"gte\": 100}}. Only fields filtering a column are accepted.\n")
+	fmt.Fprintf(&sb, "  google.protobuf.Struct filters = 2%s;\n", optional)
+	sb.WriteString("  // The order of results, as in the table's List request: \"foo,bar desc\".\n")
+	fmt.Fprintf(&sb, "  string order_by = 3%s;\n", optional)
+	sb.WriteString("  // The maximum number of records to return, at most 100 if unspecified.\n")
+	fmt.Fprintf(&sb, "  int32 page_size = 4%s;\n", optional)
+	sb.WriteString("  // A page token, received from a previous `Query` call for the same table.\n")
+	fmt.Fprintf(&sb, "  string page_token = 5%s;\n", optional)
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// Response for listing the records of a table\n")
+	sb.WriteString("message QueryResponse {\n")
+	sb.WriteString("  // The records, each in the JSON form of the table's message.\n")
+	sb.WriteString("  repeated google.protobuf.Struct rows = 1;\n")
+	sb.WriteString("  // A token, which can be sent as `page_token` to retrieve the next page.\n")
+	sb.WriteString("  // If this field is omitted, there are no subsequent pages.\n")
+	sb.WriteString("  string next_page_token = 2;\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// Query the data of any table by name, for tooling that picks tables at runtime\n")
+	sb.WriteString("service QueryService {\n")
+	sb.WriteString("  // Query records | Retrieve paginated results of a table with optional filtering\n")
+	if api {
+		sb.WriteString("  rpc Query(QueryRequest) returns (QueryResponse) {\n")
+		sb.WriteString("    option (google.api.http) = {\n")
+		fmt.Fprintf(&sb, "      post: \"%s/tables:query\"\n", g.config.APIBasePath)
+		sb.WriteString("      body: \"*\"\n")
+		sb.WriteString("    };\n")
+		sb.WriteString("  }\n")
+	} else {
+		writeRPC(&sb, "Query", "", "")
+	}
+	sb.WriteString("}\n")
+
+	return g.applySyntax(sb.String())
+}
+
+// queryGoContent renders query.go with the dispatcher of the Query service
+func (g *Generator) queryGoContent(tables []*clickhouse.Table) string {
+	sb := &strings.Builder{}
+	g.writeSQLFileHeader(sb, "This file dispatches Query requests to the List builders of the tables.")
+
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"fmt\"\n\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/encoding/protojson\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/reflect/protoreflect\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/types/known/structpb\"\n")
+	sb.WriteString(")\n\n")
+
+	queryable := g.queryTables(tables)
+
+	sb.WriteString("// BuildQuery constructs the List query of the table a QueryRequest names. The filters are\n")
+	sb.WriteString("// decoded into the table's List request, so they take the same operators and are checked\n")
+	sb.WriteString("// by its builder like List requests are.\n")
+	sb.WriteString("func BuildQuery(req *QueryRequest, options ...QueryOption) (SQLQuery, error) {\n")
+	sb.WriteString("\tif LookupTable(req.GetTable()) == nil {\n")
+	sb.WriteString("\t\treturn SQLQuery{}, fmt.Errorf(\"unknown table %q\", req.GetTable())\n")
+	sb.WriteString("\t}\n\n")
+	if len(queryable) > 0 {
+		sb.WriteString("\tswitch req.GetTable() {\n")
+		for _, table := range queryable {
+			messageName := getProtocMessageName(table.Name)
+			fmt.Fprintf(sb, "\tcase %q:\n", table.Name)
+			fmt.Fprintf(sb, "\t\tlist := &List%sRequest{}\n", messageName)
+			sb.WriteString("\t\tif err := decodeQueryFilters(req, list); err != nil {\n")
+			sb.WriteString("\t\t\treturn SQLQuery{}, err\n")
+			sb.WriteString("\t\t}\n")
+			sb.WriteString("\t\tlist.OrderBy, list.PageSize, list.PageToken = req.GetOrderBy(), req.GetPageSize(), req.GetPageToken()\n")
+			fmt.Fprintf(sb, "\t\treturn BuildList%sQuery(list, options...)\n", messageName)
+		}
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn SQLQuery{}, fmt.Errorf(\"table %q has no List query\", req.GetTable())\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// queryFilterTypes are the filter messages of the List requests the Query service\n")
+	sb.WriteString("// dispatches to, the only messages its filters may set\n")
+	sb.WriteString("var queryFilterTypes = map[protoreflect.Name]bool{\n")
+	for _, filterType := range g.queryFilterTypes(queryable) {
+		fmt.Fprintf(sb, "\t%q: true,\n", filterType)
+	}
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// NewQueryRow returns an empty message of a table for decoding its rows, or nil when the\n")
+	sb.WriteString("// Query service doesn't serve the table\n")
+	sb.WriteString("func NewQueryRow(table string) proto.Message {\n")
+	if len(queryable) > 0 {
+		sb.WriteString("\tswitch table {\n")
+		for _, table := range queryable {
+			fmt.Fprintf(sb, "\tcase %q:\n", table.Name)
+			fmt.Fprintf(sb, "\t\treturn &%s{}\n", getProtocMessageName(table.Name))
+		}
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn nil\n")
+	sb.WriteString("}\n")
+
+	sb.WriteString(queryGoHelpers)
+	return sb.String()
+}
+
+// queryFilterTypes returns the filter messages the List requests of the tables use for their
+// columns, sorted
+func (g *Generator) queryFilterTypes(tables []*clickhouse.Table) []string {
+	var filterTypes []string
+	for _, table := range tables {
+		for i := range table.Columns {
+			filterType := g.typeMapper.GetFilterTypeForColumn(&table.Columns[i], table.Name, &g.config.Conversion)
+			if filterType != "" && !slices.Contains(filterTypes, filterType) {
+				filterTypes = append(filterTypes, filterType)
+			}
+		}
+	}
+	slices.Sort(filterTypes)
+	return filterTypes
+}
+
+// queryGoHelpers converts between the Struct fields of the Query service and the messages of
+// the tables
+const queryGoHelpers = `
+// QueryRowStruct returns a row as a QueryResponse row, in the JSON form of its message
+func QueryRowStruct(row proto.Message) (*structpb.Struct, error) {
+	data, err := protojson.Marshal(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode row: %w", err)
+	}
+	value := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, value); err != nil {
+		return nil, fmt.Errorf("failed to encode row: %w", err)
+	}
+	return value, nil
+}
+
+// decodeQueryFilters decodes the filters of a Query request into the table's List request.
+// Filters may only name the fields of the List request filtering a column, by their proto
+// or JSON name as protojson does, so they can't set paging or scoping fields.
+func decodeQueryFilters(req *QueryRequest, list proto.Message) error {
+	filters := req.GetFilters().GetFields()
+	if len(filters) == 0 {
+		return nil
+	}
+
+	desc := list.ProtoReflect().Descriptor()
+	for name := range filters {
+		field := desc.Fields().ByJSONName(name)
+		if field == nil {
+			field = desc.Fields().ByName(protoreflect.Name(name))
+		}
+		if field == nil || !isQueryFilter(desc, field) {
+			return fmt.Errorf("unknown filter %q for table %q", name, req.GetTable())
+		}
+	}
+
+	data, err := protojson.Marshal(req.GetFilters())
+	if err != nil {
+		return fmt.Errorf("invalid filters: %w", err)
+	}
+	if err := protojson.Unmarshal(data, list); err != nil {
+		return fmt.Errorf("invalid filters: %w", err)
+	}
+	return nil
+}
+
+// isQueryFilter reports whether a field of a List request is one of its column filters, a
+// generated filter message of the request's package
+func isQueryFilter(list protoreflect.MessageDescriptor, field protoreflect.FieldDescriptor) bool {
+	filter := field.Message()
+	if filter == nil || field.IsList() || field.IsMap() {
+		return false
+	}
+	return queryFilterTypes[filter.Name()] && filter.ParentFile().Package() == list.ParentFile().Package()
+}
+`
@@ -0,0 +1,205 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGenerator_QueryService(t *testing.T) {
+	block := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+	tenantEvents := &clickhouse.Table{
+		Name:     "fct_events",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("tenant_id", "String", 2),
+		},
+		SortingKey: []string{"slot"},
+	}
+	logs := &clickhouse.Table{
+		Name:     "audit_log",
+		Database: "default",
+		Engine:   "Log",
+		Columns:  []clickhouse.Column{clickhouse.NewColumn("message", "String", 1)},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.EnableAPI = true
+	cfg.QueryService = true
+	cfg.Tenant.Column = "tenant_id"
+	cfg.Tenant.ExemptTables = []string{"fct_block", "audit_log"}
+	cfg.DescriptorSetOut = filepath.Join(cfg.OutputDir, "schema.binpb")
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{block, tenantEvents, logs}))
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	queryProto := read("query.proto")
+	assert.Contains(t, queryProto, `import "google/protobuf/struct.proto";`)
+	assert.Contains(t, queryProto, "string table = 1 [(google.api.field_behavior) = REQUIRED];")
+	assert.Contains(t, queryProto, "google.protobuf.Struct filters = 2 [(google.api.field_behavior) = OPTIONAL];")
+	assert.Contains(t, queryProto, `post: "/api/v1/tables:query"`)
+
+	queryGo := read("query.go")
+	assert.Contains(t, queryGo, "func BuildQuery(req *QueryRequest, options ...QueryOption) (SQLQuery, error) {")
+	assert.Contains(t, queryGo, "\tcase \"fct_block\":\n\t\tlist := &ListFctBlockRequest{}\n")
+	assert.Contains(t, queryGo, "return BuildListFctBlockQuery(list, options...)")
+	assert.NotContains(t, queryGo, `"fct_events"`, "tenant-scoped tables can't be queried without a tenant")
+	assert.NotContains(t, queryGo, `"audit_log"`, "tables without a sorting key have no List query")
+
+	// The service implies the registry it looks tables up in
+	assert.Contains(t, read("registry.go"), "func LookupTable(name string) *RegistryTable {")
+
+	// query.proto compiles, with struct.proto resolved as a well-known import
+	data, err := os.ReadFile(cfg.DescriptorSetOut)
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, set))
+	files, err := protodesc.NewFiles(set)
+	require.NoError(t, err)
+	_, err = files.FindDescriptorByName("clickhouse.v1.QueryService")
+	require.NoError(t, err)
+}
+
+func TestGenerator_QueryServiceWithoutAPI(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.QueryService = true
+	cfg.Emit = []string{config.EmitMessages, config.EmitServices}
+	table := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "query.proto"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "  rpc Query(QueryRequest) returns (QueryResponse);\n")
+	assert.NotContains(t, string(content), "google.api")
+	assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "query.go"))
+}
+
+// queryFiltersTest runs in the generated package and checks the filters BuildQuery accepts
+const queryFiltersTest = `package testv1
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestQueryFilters(t *testing.T) {
+	for _, tc := range []struct {
+		filters map[string]any
+		valid   bool
+	}{
+		{map[string]any{"slot": map[string]any{"eq": 1}, "block_root": map[string]any{"eq": "0xab"}}, true},
+		{map[string]any{"slot": map[string]any{"eq": 1}, "blockRoot": map[string]any{"eq": "0xab"}}, true},
+		{map[string]any{"slot": map[string]any{"eq": 1}, "fee": 1.5}, false},
+		{map[string]any{"slot": map[string]any{"eq": 1}, "page_size": 10}, false},
+		{map[string]any{"slot": map[string]any{"eq": 1}, "pageSize": 10}, false},
+		{map[string]any{"slot": map[string]any{"eq": 1}, "missing": map[string]any{}}, false},
+	} {
+		filters, err := structpb.NewStruct(tc.filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := BuildQuery(&QueryRequest{Table: "fct_block", Filters: filters})
+		if !tc.valid {
+			if err == nil || !strings.Contains(err.Error(), "unknown filter") {
+				t.Errorf("%v: got %v, want an unknown filter", tc.filters, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", tc.filters, err)
+		} else if !strings.Contains(q.Query, "block_root") || len(q.Args) != 2 {
+			t.Errorf("%v: the block_root filter is missing from %s", tc.filters, q.Query)
+		}
+	}
+}
+`
+
+func TestGenerator_QueryServiceFilters(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.GoPackage = "example.com/gen/testv1"
+	cfg.QueryService = true
+	generateModule(t, cfg, []*clickhouse.Table{{
+		Name:   "fct_block",
+		Engine: "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("fee", "Float64", 2),
+			clickhouse.NewColumn("block_root", "String", 3),
+		},
+		SortingKey: []string{"slot", "fee"},
+	}})
+
+	queryGo, err := os.ReadFile(filepath.Join(cfg.OutputDir, "query.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(queryGo), "var queryFilterTypes = map[protoreflect.Name]bool{\n\t\"StringFilter\": true,\n\t\"UInt32Filter\": true,\n}\n")
+
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "query_test.go"), []byte(queryFiltersTest), 0o600))
+	goCommand(t, cfg.OutputDir, "test", "-run", "TestQueryFilters", ".")
+}
+
+func TestGenerator_TableFileClash(t *testing.T) {
+	tests := []struct {
+		name      string
+		table     string
+		configure func(*config.Config)
+		expectErr bool
+	}{
+		{name: "Query service", table: "query", configure: func(cfg *config.Config) { cfg.QueryService = true }, expectErr: true},
+		{name: "Query service, other case", table: "Query", configure: func(cfg *config.Config) { cfg.QueryService = true }, expectErr: true},
+		{name: "Registry", table: "registry", configure: func(cfg *config.Config) { cfg.Registry = true }, expectErr: true},
+		{name: "Registry of the Query service", table: "registry", configure: func(cfg *config.Config) { cfg.QueryService = true }, expectErr: true},
+		{name: "Common types", table: "common", configure: func(*config.Config) {}, expectErr: true},
+		{name: "Query service disabled", table: "query", configure: func(*config.Config) {}},
+		{name: "Messages only", table: "common", configure: func(cfg *config.Config) { cfg.Emit = []string{config.EmitMessages} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.OutputDir = t.TempDir()
+			tt.configure(cfg)
+			err := NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{{
+				Name:       tt.table,
+				Engine:     "MergeTree",
+				Columns:    []clickhouse.Column{clickhouse.NewColumn("id", "UInt64", 1)},
+				SortingKey: []string{"id"},
+			}})
+			if tt.expectErr {
+				require.ErrorIs(t, err, ErrTableFileClash)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
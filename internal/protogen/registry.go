@@ -0,0 +1,128 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// GenerateRegistry writes registry.proto, describing the generated tables, and with the SQL
+// helpers registry.go, listing them as data for routers and admin UIs iterating over them
+func (g *Generator) GenerateRegistry(tables []*clickhouse.Table) error {
+	if err := g.writeFile(filepath.Join(g.protoDir(), "registry.proto"), g.registryProtoContent()); err != nil {
+		return err
+	}
+	if !g.config.Emits(config.EmitSQL) {
+		return nil
+	}
+
+	filename := filepath.Join(g.goOutputDir(), "registry.go")
+	if err := g.writeFile(filename, g.registryGoContent(tables)); err != nil {
+		return err
+	}
+	g.log.WithField("file", filename).Info("Generated table registry")
+	return nil
+}
+
+// registryProtoContent renders registry.proto
+func (g *Generator) registryProtoContent() string {
+	var sb strings.Builder
+
+	g.writeSyntax(&sb)
+	if g.config.Package != "" {
+		fmt.Fprintf(&sb, "package %s;\n", g.config.Package)
+	}
+	if goPackage := g.goPackage(); goPackage != "" {
+		fmt.Fprintf(&sb, "\noption go_package = \"%s\";\n", goPackage)
+	}
+	g.writeLanguageOptions(&sb, false)
+	g.writeFileFeatures(&sb)
+
+	sb.WriteString("\n// A ClickHouse table generated into a message, and a service when it has a sorting key\n")
+	sb.WriteString("message RegistryTable {\n")
+	sb.WriteString("  // Name of the ClickHouse table\n")
+	sb.WriteString("  string name = 1;\n")
+	sb.WriteString("  // Database the table was generated from\n")
+	sb.WriteString("  string database = 2;\n")
+	sb.WriteString("  // Fully qualified name of the table's message\n")
+	sb.WriteString("  string message = 3;\n")
+	sb.WriteString("  // Fully qualified name of the table's service, empty for tables without one\n")
+	sb.WriteString("  string service = 4;\n")
+	sb.WriteString("  // Fields of the sorting key, primary key first\n")
+	sb.WriteString("  repeated string primary_key_fields = 5;\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// The tables of a generation run\n")
+	sb.WriteString("message Registry {\n")
+	sb.WriteString("  // Tables in name order\n")
+	sb.WriteString("  repeated RegistryTable tables = 1;\n")
+	sb.WriteString("}\n")
+
+	return g.applySyntax(sb.String())
+}
+
+// qualifiedName prefixes a message or service name with the proto package
+func (g *Generator) qualifiedName(name string) string {
+	if g.config.Package == "" {
+		return name
+	}
+	return g.config.Package + "." + name
+}
+
+// registryGoContent renders registry.go with the registry of the tables
+func (g *Generator) registryGoContent(tables []*clickhouse.Table) string {
+	sb := &strings.Builder{}
+	g.writeSQLFileHeader(sb, "This file lists the generated tables.")
+
+	sb.WriteString("// Tables returns the registry of the generated tables, in name order. Each call\n")
+	sb.WriteString("// returns a new copy, which callers may change.\n")
+	sb.WriteString("func Tables() *Registry {\n")
+	sb.WriteString("\treturn &Registry{\n")
+	sb.WriteString("\t\tTables: []*RegistryTable{\n")
+	sorted := slices.SortedFunc(slices.Values(tables), func(a, b *clickhouse.Table) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, table := range sorted {
+		messageName := ToPascalCase(table.Name)
+		service := ""
+		if len(table.SortingKey) > 0 && g.config.Emits(config.EmitServices) {
+			service = g.qualifiedName(messageName + "Service")
+		}
+
+		// Fields are aligned when registry.go is formatted
+		sb.WriteString("\t\t\t{\n")
+		fmt.Fprintf(sb, "\t\t\t\tName: %q,\n", table.Name)
+		fmt.Fprintf(sb, "\t\t\t\tDatabase: %q,\n", table.Database)
+		fmt.Fprintf(sb, "\t\t\t\tMessage: %q,\n", g.qualifiedName(messageName))
+		if service != "" {
+			fmt.Fprintf(sb, "\t\t\t\tService: %q,\n", service)
+		}
+		if len(table.SortingKey) > 0 {
+			fields := make([]string, 0, len(table.SortingKey))
+			for _, column := range table.SortingKey {
+				fields = append(fields, fmt.Sprintf("%q", g.fieldName(table.Name, column)))
+			}
+			fmt.Fprintf(sb, "\t\t\t\tPrimaryKeyFields: []string{%s},\n", strings.Join(fields, ", "))
+		}
+		sb.WriteString("\t\t\t},\n")
+	}
+	sb.WriteString("\t\t},\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("// LookupTable returns the registry entry of a table by name, or nil when it wasn't generated\n")
+	sb.WriteString("func LookupTable(name string) *RegistryTable {\n")
+	sb.WriteString("\tfor _, table := range Tables().GetTables() {\n")
+	sb.WriteString("\t\tif table.GetName() == name {\n")
+	sb.WriteString("\t\t\treturn table\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn nil\n")
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
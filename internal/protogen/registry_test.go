@@ -0,0 +1,79 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGenerator_Registry(t *testing.T) {
+	block := &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "beacon",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot_start_date_time", "DateTime", 1),
+			clickhouse.NewColumn("block_root", "String", 2),
+		},
+		SortingKey: []string{"slot_start_date_time", "block_root"},
+	}
+	events := &clickhouse.Table{
+		Name:     "audit_events",
+		Database: "beacon",
+		Engine:   "Log",
+		Columns:  []clickhouse.Column{clickhouse.NewColumn("message", "String", 1)},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Package = "beacon.v1"
+	cfg.Registry = true
+	cfg.DescriptorSetOut = filepath.Join(cfg.OutputDir, "schema.binpb")
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{block, events}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "registry.go"))
+	require.NoError(t, err)
+	registry := string(content)
+	assert.Contains(t, registry, "func Tables() *Registry {")
+	assert.Contains(t, registry, "func LookupTable(name string) *RegistryTable {")
+	assert.Contains(t, registry, `Service:          "beacon.v1.FctBlockService",`)
+	assert.Contains(t, registry, `PrimaryKeyFields: []string{"slot_start_date_time", "block_root"},`)
+	assert.Less(t, strings.Index(registry, `"audit_events"`), strings.Index(registry, `"fct_block"`), "tables are in name order")
+	assert.NotContains(t, registry, "AuditEventsService", "tables without a sorting key have no service")
+
+	// registry.proto compiles into the descriptor set with the table protos
+	data, err := os.ReadFile(cfg.DescriptorSetOut)
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, set))
+	files, err := protodesc.NewFiles(set)
+	require.NoError(t, err)
+	desc, err := files.FindDescriptorByName("beacon.v1.RegistryTable")
+	require.NoError(t, err)
+	assert.Equal(t, "registry.proto", desc.ParentFile().Path())
+}
+
+func TestGenerator_RegistryOff(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	table := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "registry.proto"))
+	assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "registry.go"))
+}
@@ -0,0 +1,96 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrRenamedColumn is returned when a renamed column's original name is still taken
+var ErrRenamedColumn = errors.New("renamed column conflicts with an existing column")
+
+// renamedFrom returns the name a column had before a configured rename. Its field keeps
+// that name, and its number under the hash and lock strategies.
+func (g *Generator) renamedFrom(tableName, columnName string) (string, bool) {
+	for original, renamed := range g.config.RenamedColumns[tableName] {
+		if renamed == columnName {
+			return original, true
+		}
+	}
+	return "", false
+}
+
+// fieldName returns the name of a column's field in messages and requests
+func (g *Generator) fieldName(tableName, columnName string) string {
+	if original, ok := g.renamedFrom(tableName, columnName); ok {
+		return SanitizeName(original)
+	}
+	return SanitizeName(columnName)
+}
+
+// applyRenameToField gives the field of a renamed column its original name
+func (g *Generator) applyRenameToField(field *ProtoField, column *clickhouse.Column, tableName string) {
+	original, ok := g.renamedFrom(tableName, column.Name)
+	if !ok {
+		return
+	}
+	field.Name = SanitizeName(original)
+
+	note := fmt.Sprintf("Reads column %s, renamed from %s.", column.Name, original)
+	if field.Comment == "" {
+		field.Comment = note
+	} else {
+		field.Comment += "\n" + note
+	}
+}
+
+// fieldSelectExpression aliases the SELECT expression of a column to its field when their
+// names differ, as they do for renamed columns and for columns whose name isn't a valid
+// field name, e.g. "block root" read as block_root
+func (g *Generator) fieldSelectExpression(col *clickhouse.Column, tableName, expr string) string {
+	field := g.fieldName(tableName, col.Name)
+	if field == col.Name {
+		return expr
+	}
+	return fmt.Sprintf("%s AS %s", unaliasedExpression(col, expr), quoteIdentifier(field))
+}
+
+// originallyNamedColumns returns the columns of a table under the names they had before
+// any configured rename, which field numbers are derived from
+func (g *Generator) originallyNamedColumns(table *clickhouse.Table) []clickhouse.Column {
+	columns := slices.Clone(table.Columns)
+	for i := range columns {
+		if original, ok := g.renamedFrom(table.Name, columns[i].Name); ok {
+			columns[i].Name = original
+		}
+	}
+	return columns
+}
+
+// validateRenamedColumns checks that renamed columns don't share their field with another
+// column, and warns about renames of columns that don't exist
+func (g *Generator) validateRenamedColumns(tables []*clickhouse.Table) error {
+	for _, table := range tables {
+		renames := g.config.RenamedColumns[table.Name]
+		for _, original := range slices.Sorted(maps.Keys(renames)) {
+			renamed := renames[original]
+			if !slices.ContainsFunc(table.Columns, func(col clickhouse.Column) bool { return col.Name == renamed }) {
+				g.log.WithFields(logrus.Fields{
+					"table":  table.Name,
+					"column": renamed,
+				}).Warn("Renamed column does not exist")
+				continue
+			}
+			for _, col := range table.Columns {
+				if col.Name != renamed && g.fieldName(table.Name, col.Name) == SanitizeName(original) {
+					return fmt.Errorf("%w: %s.%s would share field %s with %s", ErrRenamedColumn, table.Name, renamed, SanitizeName(original), col.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
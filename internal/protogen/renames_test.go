@@ -0,0 +1,87 @@
+package protogen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_RenamedColumns(t *testing.T) {
+	table := func() *clickhouse.Table {
+		return &clickhouse.Table{
+			Name:     "fct_block",
+			Database: "default",
+			Engine:   "MergeTree",
+			Columns: []clickhouse.Column{
+				clickhouse.NewColumn("slot_number", "UInt32", 1),
+				clickhouse.NewColumn("proposer_index", "UInt32", 2),
+				clickhouse.NewColumn("seen_at", "DateTime", 3),
+			},
+			SortingKey: []string{"slot_number"},
+		}
+	}
+
+	generate := func(t *testing.T, strategy string, tables ...*clickhouse.Table) (func(string) string, error) {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.FieldNumbers.Strategy = strategy
+		cfg.RenamedColumns = map[string]map[string]string{
+			"fct_block": {"slot": "slot_number", "seen": "seen_at"},
+		}
+		err := NewGenerator(cfg, logrus.New()).Generate(tables)
+		return func(name string) string {
+			content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+			require.NoError(t, err)
+			return string(content)
+		}, err
+	}
+
+	read, err := generate(t, config.FieldNumbersPosition, table())
+	require.NoError(t, err)
+
+	proto := read("fct_block.proto")
+	assert.Contains(t, proto, "  // Reads column slot_number, renamed from slot.\n  uint32 slot = 11;")
+	assert.Contains(t, proto, "  uint32 seen = 13;")
+	assert.NotContains(t, proto, "slot_number =")
+	assert.Contains(t, proto, "UInt32Filter slot = 1")
+	assert.Contains(t, proto, "uint32 slot = 1; // Primary key (required)")
+
+	sql := read("fct_block_sql.go")
+	// Filters and keys use the column, while results are aliased to the field
+	assert.Contains(t, sql, "if req.Slot == nil {")
+	assert.Contains(t, sql, "qb.AddCondition(\"`slot_number`\", \"=\", filter.Eq)")
+	assert.Contains(t, sql, "`slot_number` AS `slot`")
+	assert.Contains(t, sql, "toUnixTimestamp(`seen_at`) AS `seen`")
+	assert.Regexp(t, `\t"slot": +"slot_number",\n`, sql)
+	assert.Contains(t, sql, `ParseOrderBy(req.OrderBy, FctBlockColumnsByField)`)
+	assert.Contains(t, sql, "orderByClause = \" ORDER BY `slot_number`\"")
+
+	t.Run("hash numbers follow the original name", func(t *testing.T) {
+		renamed, err := generate(t, config.FieldNumbersHash, table())
+		require.NoError(t, err)
+
+		original := table()
+		original.Columns[0].Name = "slot"
+		original.SortingKey = []string{"slot"}
+		cfg := config.NewConfig()
+		cfg.FieldNumbers.Strategy = config.FieldNumbersHash
+		number := NewGenerator(cfg, logrus.New()).fieldNumbers(original)["slot"]
+
+		assert.Contains(t, renamed("fct_block.proto"), fmt.Sprintf("  uint32 slot = %d;", number))
+	})
+
+	t.Run("original name still taken", func(t *testing.T) {
+		conflicting := table()
+		conflicting.Columns = append(conflicting.Columns, clickhouse.NewColumn("slot", "UInt32", 4))
+		_, err := generate(t, config.FieldNumbersPosition, conflicting)
+		require.ErrorIs(t, err, ErrRenamedColumn)
+	})
+}
@@ -0,0 +1,113 @@
+package protogen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// Table statuses recorded in the generation report
+const (
+	TableStatusGenerated = "generated"
+	TableStatusSkipped   = "skipped"
+)
+
+// Report is a machine-readable summary of a generation run
+type Report struct {
+	Tables         []TableReport `json:"tables"`
+	FilesChanged   int           `json:"files_changed"`
+	FilesUnchanged int           `json:"files_unchanged"`
+}
+
+// TableReport describes the outcome for a single table
+type TableReport struct {
+	Database string          `json:"database,omitempty"`
+	Table    string          `json:"table"`
+	Status   string          `json:"status"`
+	Reason   string          `json:"reason,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Topology *TopologyReport `json:"topology,omitempty"`
+}
+
+// TopologyReport describes where a replicated or Distributed table's data lives and which
+// table its SQL helpers query
+type TopologyReport struct {
+	Kind        string `json:"kind"`
+	Cluster     string `json:"cluster,omitempty"`
+	LocalTable  string `json:"local_table,omitempty"`
+	ShardingKey string `json:"sharding_key,omitempty"`
+	QueryTable  string `json:"query_table,omitempty"`
+}
+
+// AddSkipped records a table that was not generated
+func (r *Report) AddSkipped(database, table string, reason error) {
+	r.Tables = append(r.Tables, TableReport{
+		Database: database,
+		Table:    table,
+		Status:   TableStatusSkipped,
+		Reason:   reason.Error(),
+	})
+}
+
+// WriteFile writes the report as indented JSON
+func (r *Report) WriteFile(filename string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	if err := os.WriteFile(filename, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write report %s: %w", filename, err)
+	}
+	return nil
+}
+
+// Report returns the generated tables and file counts of the last Generate run
+func (g *Generator) Report() *Report {
+	report := &Report{
+		Tables:         make([]TableReport, 0, len(g.tables)),
+		FilesChanged:   g.stats.Changed,
+		FilesUnchanged: g.stats.Unchanged,
+	}
+
+	for _, table := range g.tables {
+		report.Tables = append(report.Tables, TableReport{
+			Database: table.Database,
+			Table:    table.Name,
+			Status:   TableStatusGenerated,
+			Warnings: g.tableWarnings(table),
+			Topology: g.topologyReport(table),
+		})
+	}
+	for _, f := range slices.Concat(g.skipped, g.failed) {
+		report.AddSkipped(f.table.Database, f.table.Name, f.err)
+	}
+	return report
+}
+
+// tableWarnings lists the issues worth surfacing to CI for a generated table
+func (g *Generator) tableWarnings(table *clickhouse.Table) []string {
+	var warnings []string
+
+	if len(table.Columns) == 0 {
+		warnings = append(warnings, "table has no columns; SQL helpers not generated")
+	} else if len(table.SortingKey) == 0 {
+		warnings = append(warnings, "table has no sorting key; service and SQL helpers not generated")
+	}
+
+	if warning := g.topologyWarning(table); warning != "" {
+		warnings = append(warnings, warning)
+	}
+	if warning := g.viewWarning(table); warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	for _, m := range g.lossyMappings([]*clickhouse.Table{table}) {
+		warnings = append(warnings, fmt.Sprintf("column %s: %s", m.column, m.reason))
+	}
+
+	return warnings
+}
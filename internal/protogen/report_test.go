@@ -0,0 +1,90 @@
+package protogen
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestTableNotFound = errors.New("table not found")
+
+func TestGenerator_Report(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	tables := []*clickhouse.Table{
+		{
+			Name:     "users",
+			Database: "test",
+			Columns: []clickhouse.Column{
+				{Name: "id", Type: "UInt64", BaseType: "UInt64", Position: 1},
+				{Name: "location", Type: "Geometry", BaseType: "Geometry", Position: 2},
+			},
+			SortingKey: []string{"id"},
+		},
+		{
+			Name:     "events_log",
+			Database: "test",
+			Columns: []clickhouse.Column{
+				{Name: "message", Type: "String", BaseType: "String", Position: 1},
+			},
+		},
+	}
+
+	gen := NewGenerator(cfg, log)
+	require.NoError(t, gen.Generate(tables))
+
+	report := gen.Report()
+	assert.Equal(t, []TableReport{
+		{
+			Database: "test",
+			Table:    "users",
+			Status:   TableStatusGenerated,
+			Warnings: []string{"column location: unknown type Geometry, mapped to string"},
+		},
+		{
+			Database: "test",
+			Table:    "events_log",
+			Status:   TableStatusGenerated,
+			Warnings: []string{"table has no sorting key; service and SQL helpers not generated"},
+		},
+	}, report.Tables)
+	assert.Equal(t, gen.Stats().Changed, report.FilesChanged)
+	assert.Zero(t, report.FilesUnchanged)
+}
+
+func TestReport_WriteFile(t *testing.T) {
+	report := &Report{
+		Tables: []TableReport{
+			{Table: "users", Status: TableStatusGenerated},
+		},
+		FilesChanged: 3,
+	}
+	report.AddSkipped("test", "missing", errTestTableNotFound)
+
+	filename := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, report.WriteFile(filename))
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	assert.InDelta(t, 3, decoded["files_changed"], 0)
+	assert.Equal(t, []any{
+		map[string]any{"table": "users", "status": "generated"},
+		map[string]any{"database": "test", "table": "missing", "status": "skipped", "reason": "table not found"},
+	}, decoded["tables"])
+}
@@ -0,0 +1,217 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+)
+
+// requestHashMethod is the method of the request messages returning their canonical hash
+const requestHashMethod = "RequestHash"
+
+// tenantRequestHashMethod is the method of the requests of tenant-scoped tables returning their
+// hash with the tenant
+const tenantRequestHashMethod = "TenantRequestHash"
+
+// hashedRequest is a request message given a RequestHash method
+type hashedRequest struct {
+	name   string
+	paged  bool         // The request carries a page_token, which the hash leaves out
+	tenant *tenantScope // Set for the requests of tenant-scoped tables
+}
+
+// GenerateRequestHashing writes hash.go, which gives every request message of the tables with
+// query builders, their Insert requests and the QueryRequest a RequestHash method. Requests of
+// tenant-scoped tables also get a TenantRequestHash method hashing in the authorized tenant.
+// Tables with a column whose field would clash with the methods are skipped.
+func (g *Generator) GenerateRequestHashing(tables []*clickhouse.Table) error {
+	var requests []hashedRequest
+	for _, table := range tables {
+		// The tables hasSQLHelper accepts, without logging the others again
+		if len(table.Columns) == 0 || len(table.SortingKey) == 0 {
+			continue
+		}
+		if column := g.requestHashClash(table); column != "" {
+			g.log.WithFields(logrus.Fields{
+				"table":  table.Name,
+				"column": column,
+			}).Warn("Skipping request hashing: the column's field clashes with the RequestHash method")
+			continue
+		}
+		tenant, _ := g.tenantScopeFor(table)
+		for _, request := range g.requestMessages(table) {
+			requests = append(requests, hashedRequest{name: request.name, paged: request.paged, tenant: tenant})
+		}
+		if g.insertEnabled(table) {
+			requests = append(requests, hashedRequest{name: fmt.Sprintf("Insert%sRequest", ToPascalCase(table.Name)), tenant: tenant})
+		}
+	}
+	// Tenant-scoped tables are left out of the Query service, so its request has no tenant
+	if g.queryServiceEnabled() {
+		requests = append(requests, hashedRequest{name: "QueryRequest", paged: true})
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	sb := &strings.Builder{}
+	g.writeSQLFileHeader(sb, "This file hashes request messages into idempotency and cache keys.")
+
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"bytes\"\n")
+	sb.WriteString("\t\"crypto/sha256\"\n")
+	sb.WriteString("\t\"encoding/hex\"\n")
+	sb.WriteString("\t\"fmt\"\n")
+	sb.WriteString("\t\"math\"\n")
+	sb.WriteString("\t\"sort\"\n\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/encoding/protowire\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/proto\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/reflect/protoreflect\"\n")
+	sb.WriteString(")\n\n")
+
+	for _, request := range requests {
+		ignore := ""
+		if request.paged {
+			ignore = ", \"page_token\""
+		}
+		fmt.Fprintf(sb, "// %s returns the canonical hash of the request, for idempotency and cache keys.\n", requestHashMethod)
+		if request.paged {
+			sb.WriteString("// Its page_token is left out, so every page of a request shares the hash.\n")
+		}
+		sb.WriteString("// The hash leaves out the method and the authorized tenant, which keys must add.\n")
+		fmt.Fprintf(sb, "func (x *%s) %s() string {\n", request.name, requestHashMethod)
+		fmt.Fprintf(sb, "\treturn hashRequest(x, nil%s)\n", ignore)
+		sb.WriteString("}\n\n")
+
+		if request.tenant == nil {
+			continue
+		}
+		fmt.Fprintf(sb, "// %s returns the %s of the request scoped to the tenant its builder is\n", tenantRequestHashMethod, requestHashMethod)
+		sb.WriteString("// given, so requests of different tenants never share a key\n")
+		fmt.Fprintf(sb, "func (x *%s) %s(tenant %s) string {\n", request.name, tenantRequestHashMethod, request.tenant.goType)
+		fmt.Fprintf(sb, "\treturn hashRequest(x, tenant%s)\n", ignore)
+		sb.WriteString("}\n\n")
+	}
+
+	sb.WriteString(requestHashRuntime)
+
+	filename := filepath.Join(g.goOutputDir(), "hash.go")
+	if err := g.writeFile(filename, sb.String()); err != nil {
+		return err
+	}
+
+	g.log.WithField("file", filename).Info("Generated request hashing file")
+	return nil
+}
+
+// requestHashClash returns the column of a table whose Go field in the request messages
+// would be named like the RequestHash or TenantRequestHash method, or ""
+func (g *Generator) requestHashClash(table *clickhouse.Table) string {
+	for _, column := range table.Columns {
+		if name := ToPascalCase(g.fieldName(table.Name, column.Name)); name == requestHashMethod || name == tenantRequestHashMethod {
+			return column.Name
+		}
+	}
+	return ""
+}
+
+// requestHashRuntime is the table-independent part of hash.go
+const requestHashRuntime = `// hashRequest returns the hex SHA-256 of the canonical encoding of a request without the
+// ignored fields. The encoding starts with the message's full name and lists the set fields
+// by field number, with map entries sorted, so equal requests hash alike however they were
+// built or serialized. Fields hash by presence, as the query builders read them: a filter
+// message or optional field set to an empty or zero value hashes unlike an unset one. A
+// non-nil tenant follows the name under field number 0, which no field can have.
+func hashRequest(req proto.Message, tenant any, ignore ...protoreflect.Name) string {
+	msg := req.ProtoReflect()
+	b := protowire.AppendString(nil, string(msg.Descriptor().FullName()))
+	if tenant != nil {
+		b = protowire.AppendVarint(b, 0)
+		b = protowire.AppendString(b, fmt.Sprint(tenant))
+	}
+	b = appendCanonicalMessage(b, msg, ignore)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// appendCanonicalMessage appends the set fields of msg but the ignored ones, in field number
+// order
+func appendCanonicalMessage(b []byte, msg protoreflect.Message, ignore []protoreflect.Name) []byte {
+	var fields []protoreflect.FieldDescriptor
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		for _, name := range ignore {
+			if fd.Name() == name {
+				return true
+			}
+		}
+		fields = append(fields, fd)
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Number() < fields[j].Number()
+	})
+
+	for _, fd := range fields {
+		b = protowire.AppendVarint(b, uint64(fd.Number()))
+		b = appendCanonicalField(b, fd, msg.Get(fd))
+	}
+	return b
+}
+
+// appendCanonicalField appends a field's value: the elements of a list in order, and the
+// entries of a map sorted by their encoding
+func appendCanonicalField(b []byte, fd protoreflect.FieldDescriptor, v protoreflect.Value) []byte {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		b = protowire.AppendVarint(b, uint64(list.Len()))
+		for i := 0; i < list.Len(); i++ {
+			b = appendCanonicalValue(b, fd, list.Get(i))
+		}
+	case fd.IsMap():
+		entries := make([][]byte, 0, v.Map().Len())
+		v.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+			entry := appendCanonicalValue(nil, fd.MapKey(), key.Value())
+			entries = append(entries, appendCanonicalValue(entry, fd.MapValue(), value))
+			return true
+		})
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i], entries[j]) < 0
+		})
+		b = protowire.AppendVarint(b, uint64(len(entries)))
+		for _, entry := range entries {
+			b = protowire.AppendBytes(b, entry)
+		}
+	default:
+		b = appendCanonicalValue(b, fd, v)
+	}
+	return b
+}
+
+// appendCanonicalValue appends a single value of a field's kind
+func appendCanonicalValue(b []byte, fd protoreflect.FieldDescriptor, v protoreflect.Value) []byte {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protowire.AppendVarint(b, protowire.EncodeBool(v.Bool()))
+	case protoreflect.EnumKind:
+		return protowire.AppendVarint(b, protowire.EncodeZigZag(int64(v.Enum())))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protowire.AppendVarint(b, protowire.EncodeZigZag(v.Int()))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protowire.AppendVarint(b, v.Uint())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return protowire.AppendFixed64(b, math.Float64bits(v.Float()))
+	case protoreflect.StringKind:
+		return protowire.AppendString(b, v.String())
+	case protoreflect.BytesKind:
+		return protowire.AppendBytes(b, v.Bytes())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protowire.AppendBytes(b, appendCanonicalMessage(nil, v.Message(), nil))
+	}
+	return b
+}
+`
@@ -0,0 +1,210 @@
+package protogen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_RequestHashing(t *testing.T) {
+	block := &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("block_root", "String", 2),
+		},
+		SortingKey: []string{"slot"},
+	}
+	clashing := &clickhouse.Table{
+		Name:     "fct_request",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("request_hash", "String", 2),
+		},
+		SortingKey: []string{"slot"},
+	}
+	scoped := &clickhouse.Table{
+		Name:     "fct_scoped",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("tenant_id", "String", 1),
+			clickhouse.NewColumn("slot", "UInt32", 2),
+		},
+		SortingKey: []string{"tenant_id", "slot"},
+	}
+
+	on := true
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.UnsafeMutations = true
+	cfg.QueryService = true
+	cfg.Policies = []config.PolicyConfig{{Match: "fct_block", Insert: &on}}
+	cfg.Tenant = config.TenantConfig{Column: "tenant_id", ExemptTables: []string{"fct_block", "fct_request"}}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{block, clashing, scoped}))
+
+	path := filepath.Join(cfg.OutputDir, "hash.go")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	hash := string(content)
+
+	// Pages of a List request share its hash, other requests hash every field
+	assert.Contains(t, hash, "func (x *ListFctBlockRequest) RequestHash() string {\n\treturn hashRequest(x, nil, \"page_token\")\n}\n")
+	assert.Contains(t, hash, "func (x *GetFctBlockRequest) RequestHash() string {\n\treturn hashRequest(x, nil)\n}\n")
+	assert.Contains(t, hash, "func (x *DeleteFctBlockRequest) RequestHash() string {\n")
+	assert.Contains(t, hash, "func hashRequest(req proto.Message, tenant any, ignore ...protoreflect.Name) string {")
+
+	// Insert requests and the Query service's request are hashed too
+	assert.Contains(t, hash, "func (x *InsertFctBlockRequest) RequestHash() string {\n\treturn hashRequest(x, nil)\n}\n")
+	assert.Contains(t, hash, "func (x *QueryRequest) RequestHash() string {\n\treturn hashRequest(x, nil, \"page_token\")\n}\n")
+
+	// Requests of tenant-scoped tables can be hashed with the authorized tenant
+	assert.Contains(t, hash, "func (x *ListFctScopedRequest) TenantRequestHash(tenant string) string {\n\treturn hashRequest(x, tenant, \"page_token\")\n}\n")
+	assert.Contains(t, hash, "func (x *GetFctScopedRequest) TenantRequestHash(tenant string) string {\n")
+	assert.NotContains(t, hash, "func (x *ListFctBlockRequest) TenantRequestHash(")
+
+	// The request_hash column's field would clash with the method
+	assert.NotContains(t, hash, "FctRequest")
+
+	_, err = parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors)
+	require.NoError(t, err)
+
+	t.Run("No query builders", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{
+			{Name: "no_key", Columns: []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)}},
+		}))
+		assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "hash.go"))
+	})
+}
+
+// requestHashTest runs in the generated package and checks the hashes themselves
+const requestHashTest = `package testv1
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func slotFilter(slot uint32) *UInt32Filter {
+	return &UInt32Filter{Filter: &UInt32Filter_Eq{Eq: slot}}
+}
+
+func TestRequestHash(t *testing.T) {
+	list := &ListFctBlockRequest{Slot: slotFilter(1), PageSize: 10}
+
+	// The canonical encoding is stable, so hashes can be stored as keys
+	if got, want := list.RequestHash(), expectedListHash; got != want {
+		t.Errorf("hash of %v is %s, want %s", list, got, want)
+	}
+
+	// The tenant keeps the requests of different tenants apart
+	if hashRequest(list, "a", "page_token") == hashRequest(list, "b", "page_token") {
+		t.Error("the tenant didn't change the hash")
+	}
+	if hashRequest(list, "", "page_token") == list.RequestHash() {
+		t.Error("an empty tenant hashes like no tenant")
+	}
+
+	// Insert and Query requests have hashes too
+	insert := &InsertFctBlockRequest{Rows: []*FctBlock{{Slot: 1}}}
+	if insert.RequestHash() == (&InsertFctBlockRequest{Rows: []*FctBlock{{Slot: 2}}}).RequestHash() {
+		t.Error("different rows hash alike")
+	}
+	query := &QueryRequest{Table: "fct_block", PageSize: 10}
+	if query.RequestHash() != (&QueryRequest{Table: "fct_block", PageSize: 10, PageToken: "next"}).RequestHash() {
+		t.Error("the page_token changed the Query hash")
+	}
+
+	// Every page of a List request shares the hash, other fields don't
+	paged := proto.Clone(list).(*ListFctBlockRequest)
+	paged.PageToken = "next"
+	if paged.RequestHash() != list.RequestHash() {
+		t.Error("page_token changed the hash")
+	}
+	resized := proto.Clone(list).(*ListFctBlockRequest)
+	resized.PageSize = 20
+	if resized.RequestHash() == list.RequestHash() {
+		t.Error("page_size didn't change the hash")
+	}
+
+	// Messages hash by name too, and fields by presence
+	if (&GetFctBlockRequest{Slot: 1}).RequestHash() == (&DeleteFctBlockRequest{Slot: slotFilter(1)}).RequestHash() {
+		t.Error("Get and Delete requests hash alike")
+	}
+	if (&ListFctBlockRequest{Slot: &UInt32Filter{}}).RequestHash() == (&ListFctBlockRequest{}).RequestHash() {
+		t.Error("an empty filter hashes like an unset one")
+	}
+	if (&ListFctBlockRequest{Slot: slotFilter(0)}).RequestHash() == (&ListFctBlockRequest{Slot: &UInt32Filter{}}).RequestHash() {
+		t.Error("a zero eq hashes like an empty filter")
+	}
+
+	// Map entries hash alike whatever order they were added or serialized in
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	forward, backward := map[string]uint64{}, map[string]uint64{}
+	for i, key := range keys {
+		forward[key] = uint64(i)
+		backward[keys[len(keys)-1-i]] = uint64(len(keys) - 1 - i)
+	}
+	update := func(labels map[string]uint64) *UpdateFctBlockRequest {
+		return &UpdateFctBlockRequest{Slot: slotFilter(1), UpdateValues: &FctBlock{Labels: labels}, UpdateFields: []string{"labels"}}
+	}
+	want := update(forward).RequestHash()
+	data, err := proto.Marshal(update(backward))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &UpdateFctBlockRequest{}
+	if err := proto.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if update(backward).RequestHash() != want || decoded.RequestHash() != want {
+			t.Fatal("map order changed the hash")
+		}
+	}
+	backward["a"] = 100
+	if update(backward).RequestHash() == want {
+		t.Error("a map value didn't change the hash")
+	}
+}
+`
+
+func TestGenerator_RequestHashValues(t *testing.T) {
+	on := true
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.GoPackage = "example.com/gen/testv1"
+	cfg.UnsafeMutations = true
+	cfg.QueryService = true
+	cfg.Policies = []config.PolicyConfig{{Match: "fct_block", Insert: &on}}
+	generateModule(t, cfg, []*clickhouse.Table{{
+		Name:   "fct_block",
+		Engine: "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("labels", "Map(String, UInt64)", 2),
+		},
+		SortingKey: []string{"slot"},
+	}})
+
+	// The hash of ListFctBlockRequest{slot: {eq: 1}, page_size: 10}
+	const expected = "dddf85089ae4c416898fcf44f626d20ba95e971b74f03c9a1b5c8b8f98aa6d07"
+	test := requestHashTest + "\nconst expectedListHash = \"" + expected + "\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "hash_test.go"), []byte(test), 0o600))
+	goCommand(t, cfg.OutputDir, "test", "-run", "TestRequestHash", ".")
+}
@@ -0,0 +1,112 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+var (
+	// ErrReservedField is returned when a column's field number or name is reserved
+	ErrReservedField = errors.New("column field is reserved")
+	// ErrReservedOverlap is returned when reserved and extension ranges of a message overlap
+	ErrReservedOverlap = errors.New("reserved ranges overlap")
+)
+
+// reservedRanges parses reserved numbers or extension ranges. The config is validated
+// before generation, so unparsable entries are skipped.
+func reservedRanges(values []string) []config.FieldRange {
+	ranges := make([]config.FieldRange, 0, len(values))
+	for _, value := range values {
+		if r, err := config.ParseFieldRange(value); err == nil {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+func inRanges(ranges []config.FieldRange, number int32) bool {
+	for _, r := range ranges {
+		if r.Contains(number) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateReserved checks that no column is numbered or named like a reserved field of its
+// table, including omitted columns, whose numbers the message reserves as well
+func (g *Generator) validateReserved(tables []*clickhouse.Table) error {
+	for _, table := range tables {
+		reserved := g.config.ReservedFor(table.Name)
+		if len(reserved.Numbers)+len(reserved.Names)+len(reserved.Extensions) == 0 {
+			continue
+		}
+
+		ranges := append(reservedRanges(reserved.Numbers), reservedRanges(reserved.Extensions)...)
+		for i, r := range ranges {
+			for _, other := range ranges[i+1:] {
+				if r.Start <= other.End && other.Start <= r.End {
+					return fmt.Errorf("%w in %s: %s and %s", ErrReservedOverlap, table.Name, r, other)
+				}
+			}
+		}
+
+		names := make(map[string]bool, len(reserved.Names))
+		for _, name := range reserved.Names {
+			names[name] = true
+		}
+
+		numbers := g.fieldNumbers(table)
+		for _, column := range table.Columns {
+			if number := numbers[column.Name]; inRanges(ranges, number) {
+				return fmt.Errorf("%w: %s.%s has field number %d", ErrReservedField, table.Name, column.Name, number)
+			}
+			if field := g.fieldName(table.Name, column.Name); names[field] && !g.isOmitted(table.Name, column.Name) {
+				return fmt.Errorf("%w: %s.%s has reserved name %s", ErrReservedField, table.Name, column.Name, field)
+			}
+		}
+	}
+	return nil
+}
+
+// writeReserved writes the configured reserved numbers, names and extension ranges of a
+// table's message, skipping the numbers of dropped columns that the ranges already cover
+func (g *Generator) writeReserved(sb *strings.Builder, table *clickhouse.Table) {
+	reserved := g.config.ReservedFor(table.Name)
+	ranges := reservedRanges(reserved.Numbers)
+
+	removed, names := g.removedFieldNumbers(table)
+	for _, number := range removed {
+		if !inRanges(ranges, number) {
+			fmt.Fprintf(sb, "  reserved %d; // Dropped column %s\n", number, names[number])
+		}
+	}
+
+	if len(ranges) > 0 {
+		values := make([]string, 0, len(ranges))
+		for _, r := range ranges {
+			values = append(values, r.String())
+		}
+		fmt.Fprintf(sb, "  reserved %s;\n", strings.Join(values, ", "))
+	}
+
+	if len(reserved.Names) > 0 {
+		seen := make(map[string]bool, len(reserved.Names))
+		quoted := make([]string, 0, len(reserved.Names))
+		for _, name := range reserved.Names {
+			if !seen[name] {
+				seen[name] = true
+				quoted = append(quoted, fmt.Sprintf("%q", name))
+			}
+		}
+		fmt.Fprintf(sb, "  reserved %s;\n", strings.Join(quoted, ", "))
+	}
+
+	for _, r := range reservedRanges(reserved.Extensions) {
+		fmt.Fprintf(sb, "  extensions %s;\n", r)
+	}
+}
@@ -0,0 +1,67 @@
+package protogen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Reserved(t *testing.T) {
+	table := &clickhouse.Table{
+		Name: "blocks",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("legacy_root", "String", 2),
+		},
+	}
+
+	generate := func(t *testing.T, reserved map[string]config.ReservedConfig) (string, error) {
+		t.Helper()
+		tmpDir := t.TempDir()
+		cfg := config.NewConfig()
+		cfg.OutputDir = tmpDir
+		cfg.Package = "test.v1"
+		cfg.Columns = map[string]map[string]config.ColumnConfig{"blocks": {"legacy_root": {Mask: config.MaskOmit}}}
+		cfg.Reserved = reserved
+
+		if err := NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}); err != nil {
+			return "", err
+		}
+		return readFile(filepath.Join(tmpDir, "blocks.proto"))
+	}
+
+	t.Run("Merged with the wildcard entry", func(t *testing.T) {
+		proto, err := generate(t, map[string]config.ReservedConfig{
+			"*":      {Numbers: []string{"1 to 9"}, Names: []string{"legacy_root", "internal"}},
+			"blocks": {Numbers: []string{"500", "1000 to max"}, Names: []string{"internal"}},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, proto, "  reserved 12; // Omitted by column mask\n"+
+			"  reserved 1 to 9, 500, 1000 to max;\n"+
+			"  reserved \"legacy_root\", \"internal\";\n}\n")
+	})
+
+	t.Run("Column numbered in a reserved range", func(t *testing.T) {
+		_, err := generate(t, map[string]config.ReservedConfig{"blocks": {Numbers: []string{"10 to 12"}}})
+		require.ErrorIs(t, err, ErrReservedField)
+		assert.Contains(t, err.Error(), "blocks.slot has field number 11")
+	})
+
+	t.Run("Column with a reserved name", func(t *testing.T) {
+		_, err := generate(t, map[string]config.ReservedConfig{"*": {Names: []string{"slot"}}})
+		require.ErrorIs(t, err, ErrReservedField)
+	})
+
+	t.Run("Overlapping ranges", func(t *testing.T) {
+		_, err := generate(t, map[string]config.ReservedConfig{
+			"*":      {Numbers: []string{"100 to 199"}},
+			"blocks": {Numbers: []string{"150"}},
+		})
+		require.ErrorIs(t, err, ErrReservedOverlap)
+	})
+}
@@ -0,0 +1,146 @@
+package protogen
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// responseMetaField is the field of List responses holding their ResponseMeta
+const responseMetaField = "response_meta"
+
+// responseMetaEnabled reports whether List responses carry a ResponseMeta
+func (g *Generator) responseMetaEnabled() bool {
+	return g.config.ResponseMeta && g.config.Emits(config.EmitServices)
+}
+
+// writeResponseMetaMessage writes the ResponseMeta message of common.proto
+func (g *Generator) writeResponseMetaMessage(sb *strings.Builder) {
+	sb.WriteString("\n// ResponseMeta describes how a List response was produced, for debugging and\n")
+	sb.WriteString("// client-side observability\n")
+	sb.WriteString("message ResponseMeta {\n")
+	sb.WriteString("  // Time the query took, in milliseconds\n")
+	sb.WriteString("  uint64 duration_ms = 1;\n")
+	sb.WriteString("  // Rows ClickHouse read to answer the query\n")
+	sb.WriteString("  uint64 rows_read = 2;\n")
+	sb.WriteString("  // Uncompressed bytes ClickHouse read to answer the query\n")
+	sb.WriteString("  uint64 bytes_read = 3;\n")
+	sb.WriteString("  // The filter fields the request set\n")
+	sb.WriteString("  repeated string filters = 4;\n")
+	sb.WriteString("  // The order_by of the request\n")
+	sb.WriteString("  string order_by = 5;\n")
+	sb.WriteString("  // The page_size of the request\n")
+	sb.WriteString("  int32 page_size = 6;\n")
+	sb.WriteString("}\n")
+}
+
+// writeResponseMetaField writes the ResponseMeta field of a List response
+func (g *Generator) writeResponseMetaField(sb *strings.Builder) {
+	if !g.responseMetaEnabled() {
+		return
+	}
+	sb.WriteString("  // How the response was produced: query timing, rows read and the request's filters.\n")
+	sb.WriteString("  ResponseMeta " + responseMetaField + " = 3;\n")
+}
+
+// GenerateResponseMetaHelpers writes meta.go, with which servers fill in the ResponseMeta of
+// their List responses from the query summary ClickHouse reports
+func (g *Generator) GenerateResponseMetaHelpers() error {
+	sb := &strings.Builder{}
+	g.writeSQLFileHeader(sb, "This file describes executed queries in the ResponseMeta of List responses.")
+	sb.WriteString(responseMetaHelpers)
+
+	filename := filepath.Join(g.goOutputDir(), "meta.go")
+	if err := g.writeFile(filename, sb.String()); err != nil {
+		return err
+	}
+
+	g.log.WithField("file", filename).Info("Generated response meta helper file")
+	return nil
+}
+
+// responseMetaHelpers collects the query summary over either protocol and turns it into a
+// ResponseMeta
+const responseMetaHelpers = `import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// QuerySummary holds the counters ClickHouse reports for a query
+type QuerySummary struct {
+	// ReadRows is the number of rows read
+	ReadRows uint64
+	// ReadBytes is the number of uncompressed bytes read
+	ReadBytes uint64
+}
+
+// ParseQuerySummary parses the X-ClickHouse-Summary header of an HTTP response, whose
+// counters are JSON strings, e.g. {"read_rows":"8192","read_bytes":"65536"}
+func ParseQuerySummary(header string) (QuerySummary, error) {
+	var raw struct {
+		ReadRows  string ` + "`json:\"read_rows\"`" + `
+		ReadBytes string ` + "`json:\"read_bytes\"`" + `
+	}
+	if err := json.Unmarshal([]byte(header), &raw); err != nil {
+		return QuerySummary{}, fmt.Errorf("invalid query summary: %w", err)
+	}
+
+	var summary QuerySummary
+	var err error
+	if summary.ReadRows, err = parseSummaryCounter(raw.ReadRows); err != nil {
+		return QuerySummary{}, fmt.Errorf("invalid read_rows: %w", err)
+	}
+	if summary.ReadBytes, err = parseSummaryCounter(raw.ReadBytes); err != nil {
+		return QuerySummary{}, fmt.Errorf("invalid read_bytes: %w", err)
+	}
+	return summary, nil
+}
+
+// parseSummaryCounter parses a counter of X-ClickHouse-Summary, which is absent for some
+// queries
+func parseSummaryCounter(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// AddProgress adds a progress packet of the native protocol to the summary, e.g. from the
+// clickhouse.WithProgress callback of clickhouse-go
+func (s *QuerySummary) AddProgress(rows, bytes uint64) {
+	s.ReadRows += rows
+	s.ReadBytes += bytes
+}
+
+// NewResponseMeta describes how a List response was produced from its request, the time
+// its query took and the summary ClickHouse reported for it. The filters are the fields of
+// the request filtering a column that were set, in declaration order.
+func NewResponseMeta(req proto.Message, elapsed time.Duration, summary QuerySummary) *ResponseMeta {
+	meta := &ResponseMeta{
+		DurationMs: uint64(elapsed.Milliseconds()),
+		RowsRead:   summary.ReadRows,
+		BytesRead:  summary.ReadBytes,
+	}
+
+	msg := req.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		// Column filters are the only message fields of List requests
+		if field := fields.Get(i); field.Message() != nil && msg.Has(field) {
+			meta.Filters = append(meta.Filters, string(field.Name()))
+		}
+	}
+	if field := fields.ByName("order_by"); field != nil {
+		meta.OrderBy = msg.Get(field).String()
+	}
+	if field := fields.ByName("page_size"); field != nil {
+		meta.PageSize = int32(msg.Get(field).Int())
+	}
+	return meta
+}
+`
@@ -0,0 +1,77 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGenerator_ResponseMeta(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Engine:     "MergeTree",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Package = "test.v1"
+	cfg.ResponseMeta = true
+	cfg.Python.Enabled = true
+	cfg.GraphQL.Enabled = true
+	cfg.DescriptorSetOut = filepath.Join(cfg.OutputDir, "schema.binpb")
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	assert.Contains(t, read("common.proto"), "message ResponseMeta {\n")
+	assert.Contains(t, read("fct_block.proto"), "  string next_page_token = 2;\n  // How the response was produced: query timing, rows read and the request's filters.\n  ResponseMeta response_meta = 3;\n")
+
+	meta := read("meta.go")
+	assert.Contains(t, meta, "func ParseQuerySummary(header string) (QuerySummary, error) {")
+	assert.Contains(t, meta, "func NewResponseMeta(req proto.Message, elapsed time.Duration, summary QuerySummary) *ResponseMeta {")
+
+	// Models see the meta like any other field of the response
+	assert.Contains(t, read(filepath.Join("python", "fct_block.py")), `response_meta: Optional[ResponseMeta] = Field(default=None, alias="responseMeta"`)
+
+	data, err := os.ReadFile(cfg.DescriptorSetOut)
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, set))
+	files, err := protodesc.NewFiles(set)
+	require.NoError(t, err)
+	_, err = files.FindDescriptorByName("test.v1.ListFctBlockResponse.response_meta")
+	require.NoError(t, err)
+}
+
+func TestGenerator_ResponseMetaOff(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	table := &clickhouse.Table{
+		Name:       "fct_block",
+		Database:   "default",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("slot", "UInt32", 1)},
+		SortingKey: []string{"slot"},
+	}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "common.proto"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "ResponseMeta")
+	assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "meta.go"))
+}
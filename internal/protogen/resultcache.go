@@ -0,0 +1,271 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// defaultCacheMaxEntries is the size of the result cache when max_entries is unset
+const defaultCacheMaxEntries = 10000
+
+// resultCacheTTL returns the TTL of a table's cached results, or 0 when the result cache is
+// disabled or doesn't cover the table
+func (g *Generator) resultCacheTTL(table *clickhouse.Table) time.Duration {
+	if !g.config.Middleware.ResultCache.Enabled {
+		return 0
+	}
+	return g.config.ResultCacheTTL(table.Name)
+}
+
+// writeCacheTTLOption writes the clickhouse.v1.cache_ttl_seconds option of a cached table's
+// service, telling clients and gateways how stale its responses may be
+func (g *Generator) writeCacheTTLOption(sb *strings.Builder, table *clickhouse.Table) {
+	ttl := g.resultCacheTTL(table)
+	if ttl <= 0 || !g.config.Emits(config.EmitAnnotations) {
+		return
+	}
+	fmt.Fprintf(sb, "  option (clickhouse.v1.cache_ttl_seconds) = %d;\n", int64(ttl.Round(time.Second)/time.Second))
+}
+
+// writeResultCache writes middleware/cache.go when the result cache is enabled, and removes
+// one left over from an earlier run otherwise
+func (g *Generator) writeResultCache(middlewareDir string, tables []*clickhouse.Table) error {
+	filename := filepath.Join(middlewareDir, "cache.go")
+	if !g.config.Middleware.ResultCache.Enabled {
+		if err := g.removeStaleFile(filename); err != nil {
+			return fmt.Errorf("failed to remove stale result cache file: %w", err)
+		}
+		return nil
+	}
+	return g.writeFile(filename, g.buildResultCache(tables))
+}
+
+func (g *Generator) buildResultCache(tables []*clickhouse.Table) string {
+	sb := &strings.Builder{}
+
+	sb.WriteString("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n\n")
+	sb.WriteString("package middleware\n\n")
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"container/list\"\n")
+	sb.WriteString("\t\"context\"\n")
+	sb.WriteString("\t\"crypto/sha256\"\n")
+	sb.WriteString("\t\"encoding/hex\"\n")
+	sb.WriteString("\t\"fmt\"\n")
+	sb.WriteString("\t\"reflect\"\n")
+	sb.WriteString("\t\"strings\"\n")
+	sb.WriteString("\t\"sync\"\n")
+	sb.WriteString("\t\"time\"\n")
+	sb.WriteString("\t\"unicode\"\n\n")
+	sb.WriteString("\t\"github.com/ClickHouse/clickhouse-go/v2/lib/driver\"\n")
+	sb.WriteString(")\n\n")
+
+	maxEntries := g.config.Middleware.ResultCache.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	sb.WriteString("// DefaultCacheMaxEntries is the result cache size configured at generation time\n")
+	fmt.Fprintf(sb, "const DefaultCacheMaxEntries = %d\n\n", maxEntries)
+
+	sb.WriteString("// cacheTTLByTable holds the result cache TTL of each table configured at generation time\n")
+	sb.WriteString("var cacheTTLByTable = map[string]time.Duration{\n")
+	for _, table := range tables {
+		if ttl := g.resultCacheTTL(table); ttl > 0 {
+			fmt.Fprintf(sb, "\t%q: %d * time.Millisecond,\n", table.Name, ttl.Milliseconds())
+		}
+	}
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(resultCacheRuntime)
+	return sb.String()
+}
+
+// resultCacheRuntime is the table-independent part of the generated result cache
+const resultCacheRuntime = `// CacheOptions configures a caching connection
+type CacheOptions struct {
+	// MaxEntries bounds the cached results, evicting the least recently used. Defaults to
+	// DefaultCacheMaxEntries.
+	MaxEntries int
+	// TTLs of the cached results by table. Tables without one aren't cached. Defaults to
+	// the TTLs configured at generation time.
+	TTLs map[string]time.Duration
+}
+
+// CachingConn answers repeated Select queries of the tables with a TTL from memory. The table
+// is the one of the request's labels, set by UnaryServerInterceptor or WithLabels. Other
+// queries go to the wrapped connection.
+type CachingConn struct {
+	driver.Conn
+	opts CacheOptions
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cacheEntry struct {
+	key     string
+	rows    reflect.Value
+	expires time.Time
+}
+
+// CacheConn wraps conn with an in-process cache of Select results. Wrap the instrumented
+// connection, so that cache hits aren't recorded as ClickHouse queries:
+//
+//	middleware.CacheConn(middleware.InstrumentConn(conn, opts), middleware.CacheOptions{})
+func CacheConn(conn driver.Conn, opts CacheOptions) *CachingConn {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultCacheMaxEntries
+	}
+	if opts.TTLs == nil {
+		opts.TTLs = cacheTTLByTable
+	}
+	return &CachingConn{Conn: conn, opts: opts, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Select scans the result of an identical earlier query of the table into dest while it's
+// fresh, and otherwise runs the query and caches its result. The rows are copied, but not
+// what their fields point to, so callers must not modify those.
+func (c *CachingConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	ttl := c.opts.TTLs[labelsFrom(ctx).table]
+	target := reflect.ValueOf(dest)
+	if ttl <= 0 || target.Kind() != reflect.Pointer || target.Elem().Kind() != reflect.Slice {
+		return c.Conn.Select(ctx, dest, query, args...)
+	}
+
+	key := cacheKey(target.Type(), query, args)
+	if rows, ok := c.get(key); ok {
+		target.Elem().Set(copyRows(rows))
+		return nil
+	}
+
+	if err := c.Conn.Select(ctx, dest, query, args...); err != nil {
+		return err
+	}
+	c.put(key, copyRows(target.Elem()), ttl)
+	return nil
+}
+
+// Purge drops every cached result, e.g. after reloading a table
+func (c *CachingConn) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+func (c *CachingConn) get(key string) (reflect.Value, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return reflect.Value{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.rows, true
+}
+
+func (c *CachingConn) put(key string, rows reflect.Value, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, rows: rows, expires: time.Now().Add(ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// copyRows returns a copy of a slice of rows
+func copyRows(rows reflect.Value) reflect.Value {
+	out := reflect.MakeSlice(rows.Type(), rows.Len(), rows.Len())
+	reflect.Copy(out, rows)
+	return out
+}
+
+// cacheKey identifies a query by the type it's scanned into, its normalized text and its
+// arguments
+func cacheKey(dest reflect.Type, query string, args []any) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", dest, normalizeQuery(query))
+	for _, arg := range args {
+		fmt.Fprintf(h, "\x00%s", cacheArg(arg))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheArg renders an argument of a query key by value, as RenderQuery renders it, so
+// arguments pointing to equal values share an entry. Pointers and the elements of slices are
+// dereferenced.
+func cacheArg(arg any) string {
+	if _, ok := arg.(fmt.Stringer); ok || arg == nil {
+		return fmt.Sprintf("%T=%s", arg, renderArg(arg))
+	}
+
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return fmt.Sprintf("%T=%s", arg, renderArg(nil))
+		}
+		return cacheArg(v.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = cacheArg(v.Index(i).Interface())
+		}
+		return fmt.Sprintf("%T=[%s]", arg, strings.Join(elems, ","))
+	}
+	return fmt.Sprintf("%T=%s", arg, renderArg(arg))
+}
+
+// normalizeQuery collapses the whitespace of a query outside its string literals, so queries
+// differing only in formatting share an entry
+func normalizeQuery(query string) string {
+	var sb strings.Builder
+	quoted, escaped, space := false, false, false
+	for _, r := range strings.TrimSpace(query) {
+		if quoted {
+			sb.WriteRune(r)
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '\'':
+				quoted = false
+			}
+			continue
+		}
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		sb.WriteRune(r)
+		quoted = r == '\''
+	}
+	return sb.String()
+}
+`
@@ -0,0 +1,80 @@
+package protogen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGenerator_ResultCache(t *testing.T) {
+	table := func(name string) *clickhouse.Table {
+		return &clickhouse.Table{
+			Name:       name,
+			Database:   "default",
+			Engine:     "MergeTree",
+			Columns:    []clickhouse.Column{clickhouse.NewColumn("id", "UInt32", 1)},
+			SortingKey: []string{"id"},
+		}
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.GoPackage = "github.com/acme/api/gen/v1"
+	cfg.Server.Enabled = true
+	cfg.Middleware.ResultCache.Enabled = true
+	cfg.Middleware.ResultCache.TTL = map[string]time.Duration{"dim_*": time.Hour}
+	cfg.DescriptorSetOut = filepath.Join(cfg.OutputDir, "schema.binpb")
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table("dim_node"), table("fct_block")}))
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	assert.Contains(t, read("dim_node.proto"), "  option (clickhouse.v1.cache_ttl_seconds) = 3600;\n")
+	assert.NotContains(t, read("fct_block.proto"), "cache_ttl_seconds", "tables without a TTL aren't cached")
+
+	cachePath := filepath.Join(cfg.OutputDir, "middleware", "cache.go")
+	cache := read(filepath.Join("middleware", "cache.go"))
+	assert.Contains(t, cache, "const DefaultCacheMaxEntries = 10000")
+	assert.Contains(t, cache, "\t\"dim_node\": 3600000 * time.Millisecond,\n")
+	assert.NotContains(t, cache, "fct_block")
+	assert.Contains(t, cache, "func CacheConn(conn driver.Conn, opts CacheOptions) *CachingConn {")
+	assert.Contains(t, cache, "fmt.Fprintf(h, \"\\x00%s\", cacheArg(arg))", "arguments are keyed by value, not by address")
+	_, err := parser.ParseFile(token.NewFileSet(), cachePath, nil, parser.AllErrors)
+	require.NoError(t, err)
+
+	// The cache implies the middleware, and the server wraps the instrumented connection in it
+	assert.FileExists(t, filepath.Join(cfg.OutputDir, "middleware", "middleware.go"))
+	assert.Contains(t, read(filepath.Join("server", "main.go")), "registerServices(srv, middleware.CacheConn(middleware.InstrumentConn(conn, ")
+
+	data, err := os.ReadFile(cfg.DescriptorSetOut)
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, set))
+	_, err = protodesc.NewFiles(set)
+	require.NoError(t, err)
+
+	// Disabling the cache again removes the stale file, but not in memory
+	cfg.Middleware.ResultCache.Enabled = false
+	cfg.Middleware.Enabled = true
+	_, err = generateInMemory(cfg, []*clickhouse.Table{table("dim_node")})
+	require.NoError(t, err)
+	assert.FileExists(t, cachePath)
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table("dim_node")}))
+	assert.NoFileExists(t, cachePath)
+	assert.NotContains(t, read("dim_node.proto"), "cache_ttl_seconds")
+}
@@ -0,0 +1,479 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+const (
+	defaultRustDir = "rust"
+	rustHeader     = "// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n"
+	rustEmpty      = "Empty"
+	rustDerive     = "#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]\n"
+
+	// Structs also derive Default for #[serde(default)], as unset proto fields are omitted
+	rustStructDerive = "#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]\n"
+)
+
+// rustKeywords are identifiers written as raw identifiers (r#type); serde strips the prefix
+//
+//nolint:gochecknoglobals // Lookup table
+var rustKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "break": true, "const": true, "continue": true,
+	"dyn": true, "else": true, "enum": true, "extern": true, "false": true, "fn": true,
+	"for": true, "if": true, "impl": true, "in": true, "let": true, "loop": true, "match": true,
+	"mod": true, "move": true, "mut": true, "pub": true, "ref": true, "return": true,
+	"static": true, "struct": true, "trait": true, "true": true, "type": true, "unsafe": true,
+	"use": true, "where": true, "while": true, "abstract": true, "become": true, "box": true,
+	"do": true, "final": true, "gen": true, "macro": true, "override": true, "priv": true,
+	"try": true, "typeof": true, "unsized": true, "virtual": true, "yield": true,
+}
+
+// rustInt64Helpers serializes 64-bit integers as strings, as the proto JSON mapping does,
+// and accepts both strings and numbers when deserializing
+const rustInt64Helpers = `
+/// Serde helpers for 64-bit integers, which the proto JSON mapping encodes as strings.
+/// Deserialization accepts numbers too.
+pub mod int64_json {
+    use serde::de::Error;
+    use serde::{Deserialize, Deserializer, Serialize, Serializer};
+    use std::collections::HashMap;
+    use std::fmt::Display;
+    use std::hash::Hash;
+    use std::str::FromStr;
+
+    #[derive(Deserialize)]
+    #[serde(untagged)]
+    enum Repr {
+        Str(String),
+        Unsigned(u64),
+        Signed(i64),
+    }
+
+    impl Repr {
+        fn parse<T, E>(self) -> Result<T, E>
+        where
+            T: FromStr,
+            T::Err: Display,
+            E: Error,
+        {
+            let text = match self {
+                Repr::Str(text) => text,
+                Repr::Unsigned(n) => n.to_string(),
+                Repr::Signed(n) => n.to_string(),
+            };
+            text.parse().map_err(E::custom)
+        }
+    }
+
+    pub fn serialize<T: Display, S: Serializer>(value: &T, serializer: S) -> Result<S::Ok, S::Error> {
+        serializer.collect_str(value)
+    }
+
+    pub fn deserialize<'de, T, D>(deserializer: D) -> Result<T, D::Error>
+    where
+        T: FromStr,
+        T::Err: Display,
+        D: Deserializer<'de>,
+    {
+        Repr::deserialize(deserializer)?.parse()
+    }
+
+    pub mod option {
+        use super::*;
+
+        pub fn serialize<T: Display, S: Serializer>(value: &Option<T>, serializer: S) -> Result<S::Ok, S::Error> {
+            match value {
+                Some(value) => serializer.collect_str(value),
+                None => serializer.serialize_none(),
+            }
+        }
+
+        pub fn deserialize<'de, T, D>(deserializer: D) -> Result<Option<T>, D::Error>
+        where
+            T: FromStr,
+            T::Err: Display,
+            D: Deserializer<'de>,
+        {
+            Option::<Repr>::deserialize(deserializer)?.map(Repr::parse).transpose()
+        }
+    }
+
+    pub mod vec {
+        use super::*;
+
+        pub fn serialize<T: Display, S: Serializer>(values: &[T], serializer: S) -> Result<S::Ok, S::Error> {
+            serializer.collect_seq(values.iter().map(ToString::to_string))
+        }
+
+        pub fn deserialize<'de, T, D>(deserializer: D) -> Result<Vec<T>, D::Error>
+        where
+            T: FromStr,
+            T::Err: Display,
+            D: Deserializer<'de>,
+        {
+            Vec::<Repr>::deserialize(deserializer)?.into_iter().map(Repr::parse).collect()
+        }
+    }
+
+    pub mod map {
+        use super::*;
+
+        pub fn serialize<K, T, S>(values: &HashMap<K, T>, serializer: S) -> Result<S::Ok, S::Error>
+        where
+            K: Serialize,
+            T: Display,
+            S: Serializer,
+        {
+            serializer.collect_map(values.iter().map(|(key, value)| (key, value.to_string())))
+        }
+
+        pub fn deserialize<'de, K, T, D>(deserializer: D) -> Result<HashMap<K, T>, D::Error>
+        where
+            K: Deserialize<'de> + Eq + Hash,
+            T: FromStr,
+            T::Err: Display,
+            D: Deserializer<'de>,
+        {
+            HashMap::<K, Repr>::deserialize(deserializer)?
+                .into_iter()
+                .map(|(key, value)| Ok((key, value.parse()?)))
+                .collect()
+        }
+    }
+}
+`
+
+// GenerateRust generates a Rust module tree with serde types for every message: mod.rs
+// declares the modules, common.rs holds the filter types and <table>.rs the messages of
+// each table. Messages made of a single oneof, such as the filters, become externally
+// tagged enums. The types match the proto JSON mapping the REST gateway serves.
+func (g *Generator) GenerateRust(tables []*clickhouse.Table) error {
+	dir := g.emitterDir(g.config.Rust.Dir, defaultRustDir)
+	if err := g.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create Rust directory: %w", err)
+	}
+
+	common := g.commonMessages()
+	if err := g.writeFile(filepath.Join(dir, "common.rs"), buildRustCommon(common)); err != nil {
+		return err
+	}
+
+	commonNames := map[string]bool{protoEmpty: true}
+	for _, msg := range common {
+		commonNames[msg.Name] = true
+	}
+
+	modules := make([]string, 0, len(tables))
+	for _, table := range tables {
+		module := strings.ToLower(table.Name)
+		content := buildRustModule(g.tableMessages(table), commonNames)
+		if err := g.writeFile(filepath.Join(dir, module+".rs"), content); err != nil {
+			return err
+		}
+		modules = append(modules, module)
+	}
+
+	return g.writeFile(filepath.Join(dir, "mod.rs"), buildRustMod(modules))
+}
+
+func buildRustMod(modules []string) string {
+	sb := &strings.Builder{}
+	sb.WriteString(rustHeader)
+	sb.WriteString("//! Serde types of the generated protobuf messages, matching their proto JSON mapping.\n\n")
+	sb.WriteString("pub mod common;\n")
+	for _, module := range modules {
+		fmt.Fprintf(sb, "pub mod %s;\n", rustIdent(module))
+	}
+	return sb.String()
+}
+
+func buildRustCommon(messages []protoMessage) string {
+	r := rustWriter{int64Helpers: "int64_json"}
+
+	sb := &strings.Builder{}
+	writeRustPrelude(sb, messages)
+	sb.WriteString(rustInt64Helpers)
+
+	sb.WriteString("\n/// google.protobuf.Empty, an empty JSON object\n")
+	sb.WriteString(rustStructDerive)
+	fmt.Fprintf(sb, "pub struct %s {}\n", rustEmpty)
+
+	for _, msg := range messages {
+		r.writeMessage(sb, msg)
+	}
+	return sb.String()
+}
+
+// buildRustModule renders the types of a table, importing the common types they use
+func buildRustModule(messages []protoMessage, commonNames map[string]bool) string {
+	r := rustWriter{int64Helpers: "super::common::int64_json"}
+
+	local := make(map[string]bool, len(messages))
+	for _, msg := range messages {
+		local[msg.Name] = true
+	}
+
+	var imports []string
+	for _, msg := range messages {
+		for _, field := range msg.Fields {
+			name := rustValueType(field.Type)
+			if commonNames[field.Type] && !local[field.Type] && !slices.Contains(imports, name) {
+				imports = append(imports, name)
+			}
+		}
+	}
+	slices.Sort(imports)
+
+	sb := &strings.Builder{}
+	writeRustPrelude(sb, messages)
+	switch {
+	case len(imports) == 0:
+	case len(imports) == 1:
+		fmt.Fprintf(sb, "\nuse super::common::%s;\n", imports[0])
+	default:
+		// One import per line keeps long lists readable, as rustfmt would wrap them anyway
+		fmt.Fprintf(sb, "\nuse super::common::{\n    %s,\n};\n", strings.Join(imports, ",\n    "))
+	}
+
+	for _, msg := range messages {
+		r.writeMessage(sb, msg)
+	}
+	return sb.String()
+}
+
+// writeRustPrelude writes the header and imports shared by all files with types
+func writeRustPrelude(sb *strings.Builder, messages []protoMessage) {
+	sb.WriteString(rustHeader)
+
+	// Derived impls reference deprecated fields, which would otherwise warn
+	deprecated := slices.ContainsFunc(messages, func(msg protoMessage) bool {
+		return msg.Deprecated || slices.ContainsFunc(msg.Fields, func(f protoField) bool { return f.Deprecated })
+	})
+	if deprecated {
+		sb.WriteString("#![allow(deprecated)]\n")
+	}
+
+	sb.WriteString("\nuse serde::{Deserialize, Serialize};\n")
+	if slices.ContainsFunc(messages, func(msg protoMessage) bool {
+		return slices.ContainsFunc(msg.Fields, protoField.IsMap)
+	}) {
+		sb.WriteString("use std::collections::HashMap;\n")
+	}
+}
+
+// rustWriter renders messages as Rust types
+type rustWriter struct {
+	int64Helpers string // Path of the int64_json helper module
+}
+
+func (r rustWriter) writeMessage(sb *strings.Builder, msg protoMessage) {
+	oneofs := make([]string, 0, 1)
+	for _, field := range msg.Fields {
+		if field.Oneof != "" && !slices.Contains(oneofs, field.Oneof) {
+			oneofs = append(oneofs, field.Oneof)
+		}
+	}
+
+	// A message that is a single oneof is the enum itself
+	if len(oneofs) == 1 && !slices.ContainsFunc(msg.Fields, func(f protoField) bool { return f.Oneof == "" }) {
+		r.writeEnum(sb, msg.Name, msg.Comment, msg.Deprecated, msg.Fields)
+		return
+	}
+
+	sb.WriteString("\n")
+	writeRustDoc(sb, msg.Comment, "")
+	if msg.Deprecated {
+		sb.WriteString("#[deprecated]\n")
+	}
+	sb.WriteString(rustStructDerive)
+	sb.WriteString("#[serde(default, rename_all = \"camelCase\")]\n")
+	fmt.Fprintf(sb, "pub struct %s {\n", msg.Name)
+
+	written := make(map[string]bool, len(oneofs))
+	for _, field := range msg.Fields {
+		if field.Oneof == "" {
+			r.writeField(sb, field)
+			continue
+		}
+		if written[field.Oneof] {
+			continue
+		}
+		written[field.Oneof] = true
+		fmt.Fprintf(sb, "    #[serde(flatten)]\n    pub %s: Option<%s%s>,\n", rustIdent(field.Oneof), msg.Name, ToPascalCase(field.Oneof))
+	}
+	sb.WriteString("}\n")
+
+	for _, oneof := range oneofs {
+		var members []protoField
+		for _, field := range msg.Fields {
+			if field.Oneof == oneof {
+				members = append(members, field)
+			}
+		}
+		r.writeEnum(sb, msg.Name+ToPascalCase(oneof), "", false, members)
+	}
+}
+
+func (r rustWriter) writeEnum(sb *strings.Builder, name, comment string, deprecated bool, members []protoField) {
+	sb.WriteString("\n")
+	writeRustDoc(sb, comment, "")
+	if deprecated {
+		sb.WriteString("#[deprecated]\n")
+	}
+	sb.WriteString(rustDerive)
+	sb.WriteString("#[serde(rename_all = \"camelCase\")]\n")
+	fmt.Fprintf(sb, "pub enum %s {\n", name)
+
+	for _, field := range members {
+		variant := ToPascalCase(field.Name)
+		writeRustDoc(sb, field.Comment, "    ")
+		if field.Deprecated {
+			sb.WriteString("    #[deprecated]\n")
+		}
+		if json := field.JSONName(); lowerFirst(variant) != json {
+			fmt.Fprintf(sb, "    #[serde(rename = %q)]\n", json)
+		}
+		attr := ""
+		if helper := r.int64Helper(field); helper != "" {
+			attr = fmt.Sprintf("#[serde(with = %q)] ", helper)
+		}
+		fmt.Fprintf(sb, "    %s(%s%s),\n", variant, attr, rustValueType(field.Type))
+	}
+	sb.WriteString("}\n")
+}
+
+func (r rustWriter) writeField(sb *strings.Builder, field protoField) {
+	writeRustDoc(sb, field.Comment, "    ")
+	if field.Deprecated {
+		sb.WriteString("    #[deprecated]\n")
+	}
+
+	var attrs []string
+	ident := rustIdent(field.Name)
+	if json := field.JSONName(); serdeCamelCase(strings.TrimPrefix(ident, "r#")) != json {
+		attrs = append(attrs, fmt.Sprintf("rename = %q", json))
+	}
+	if helper := r.int64Helper(field); helper != "" {
+		attrs = append(attrs, fmt.Sprintf("with = %q", helper))
+	}
+	typ := rustFieldType(field)
+	if strings.HasPrefix(typ, "Option<") {
+		attrs = append(attrs, `skip_serializing_if = "Option::is_none"`)
+	}
+	if len(attrs) > 0 {
+		fmt.Fprintf(sb, "    #[serde(%s)]\n", strings.Join(attrs, ", "))
+	}
+	fmt.Fprintf(sb, "    pub %s: %s,\n", ident, typ)
+}
+
+// int64Helper returns the serde `with` module of a field holding 64-bit integers
+func (r rustWriter) int64Helper(field protoField) string {
+	if !isBigIntScalar(field.Scalar()) {
+		return ""
+	}
+	switch {
+	case field.IsMap():
+		return r.int64Helpers + "::map"
+	case field.Repeated:
+		return r.int64Helpers + "::vec"
+	case field.Oneof != "":
+		// Enum variants hold the value itself
+		return r.int64Helpers
+	case field.Nullable():
+		return r.int64Helpers + "::option"
+	}
+	return r.int64Helpers
+}
+
+// rustFieldType returns the type of a struct field
+func rustFieldType(field protoField) string {
+	value := rustValueType(field.Type)
+	switch {
+	case field.IsMap():
+		return fmt.Sprintf("HashMap<%s, %s>", rustValueType(field.MapKey), value)
+	case field.Repeated:
+		return fmt.Sprintf("Vec<%s>", value)
+	case field.Nullable() || field.Scalar() == "":
+		return fmt.Sprintf("Option<%s>", value)
+	}
+	return value
+}
+
+// rustValueType maps a proto value type to its Rust type. Bytes stay base64 strings.
+func rustValueType(protoType string) string {
+	if protoType == protoEmpty {
+		return rustEmpty
+	}
+
+	scalar := wrapperScalar(protoType)
+	if scalar == "" {
+		scalar = protoType
+	}
+
+	switch scalar {
+	case protoInt32:
+		return "i32"
+	case protoUInt32:
+		return "u32"
+	case protoInt64:
+		return "i64"
+	case protoUInt64:
+		return "u64"
+	case protoFloat:
+		return "f32"
+	case protoDouble:
+		return "f64"
+	case protoBool:
+		return "bool"
+	case protoString, protoBytes:
+		return "String"
+	}
+	return protoType
+}
+
+// rustIdent returns a field or module identifier, escaping keywords
+func rustIdent(name string) string {
+	switch {
+	case name == "self" || name == "Self" || name == "super" || name == "crate" || name == "_":
+		return name + "_"
+	case rustKeywords[name]:
+		return "r#" + name
+	}
+	return name
+}
+
+// serdeCamelCase returns the name serde's rename_all = "camelCase" gives a field
+func serdeCamelCase(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			sb.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return lowerFirst(sb.String())
+}
+
+func writeRustDoc(sb *strings.Builder, comment, indent string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		if line == "" {
+			fmt.Fprintf(sb, "%s///\n", indent)
+			continue
+		}
+		fmt.Fprintf(sb, "%s/// %s\n", indent, line)
+	}
+}
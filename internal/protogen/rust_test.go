@@ -0,0 +1,192 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRustWriter_WriteField(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    protoField
+		expected string
+	}{
+		{
+			name:     "Scalar",
+			field:    protoField{Name: "log_index", Type: protoUInt32},
+			expected: "    pub log_index: u32,\n",
+		},
+		{
+			name:     "Big integer",
+			field:    protoField{Name: "block_number", Type: protoUInt64},
+			expected: "    #[serde(with = \"int64_json\")]\n    pub block_number: u64,\n",
+		},
+		{
+			name:     "Nullable big integer",
+			field:    protoField{Name: "fee", Type: "google.protobuf.Int64Value", Comment: "Fee paid"},
+			expected: "    /// Fee paid\n    #[serde(with = \"int64_json::option\", skip_serializing_if = \"Option::is_none\")]\n    pub fee: Option<i64>,\n",
+		},
+		{
+			name:     "Repeated big integers",
+			field:    protoField{Name: "slots", Type: protoUInt64, Repeated: true},
+			expected: "    #[serde(with = \"int64_json::vec\")]\n    pub slots: Vec<u64>,\n",
+		},
+		{
+			name:     "Map",
+			field:    protoField{Name: "labels", Type: protoString, MapKey: protoString},
+			expected: "    pub labels: HashMap<String, String>,\n",
+		},
+		{
+			name:     "Keyword",
+			field:    protoField{Name: "type", Type: protoString},
+			expected: "    pub r#type: String,\n",
+		},
+		{
+			name:     "Reserved identifier",
+			field:    protoField{Name: "self", Type: protoString},
+			expected: "    pub self_: String,\n",
+		},
+		{
+			name:     "JSON name differing from serde's camelCase",
+			field:    protoField{Name: "UserID", Type: protoString},
+			expected: "    #[serde(rename = \"UserID\")]\n    pub UserID: String,\n",
+		},
+		{
+			name:     "Deprecated message field",
+			field:    protoField{Name: "item", Type: "Blocks", Deprecated: true},
+			expected: "    #[deprecated]\n    #[serde(skip_serializing_if = \"Option::is_none\")]\n    pub item: Option<Blocks>,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &strings.Builder{}
+			rustWriter{int64Helpers: "int64_json"}.writeField(sb, tt.field)
+			assert.Equal(t, tt.expected, sb.String())
+		})
+	}
+}
+
+func TestRustWriter_WriteMessage(t *testing.T) {
+	r := rustWriter{int64Helpers: "int64_json"}
+
+	t.Run("Single oneof becomes an enum", func(t *testing.T) {
+		sb := &strings.Builder{}
+		r.writeMessage(sb, protoMessage{
+			Name: "UInt64Filter",
+			Fields: []protoField{
+				{Name: "eq", Type: protoUInt64, Oneof: "filter"},
+				{Name: "not_in", Type: "UInt64List", Oneof: "filter"},
+				{Name: "is_null", Type: protoEmpty, Oneof: "filter"},
+			},
+		})
+		assert.Equal(t, `
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+#[serde(rename_all = "camelCase")]
+pub enum UInt64Filter {
+    Eq(#[serde(with = "int64_json")] u64),
+    NotIn(UInt64List),
+    IsNull(Empty),
+}
+`, sb.String())
+	})
+
+	t.Run("Oneof with other fields is flattened", func(t *testing.T) {
+		sb := &strings.Builder{}
+		r.writeMessage(sb, protoMessage{
+			Name: "Query",
+			Fields: []protoField{
+				{Name: "limit", Type: protoUInt32},
+				{Name: "eq", Type: protoString, Oneof: "filter"},
+			},
+		})
+		content := sb.String()
+		assert.Contains(t, content, "pub struct Query {\n    pub limit: u32,\n    #[serde(flatten)]\n    pub filter: Option<QueryFilter>,\n}\n")
+		assert.Contains(t, content, "pub enum QueryFilter {\n    Eq(String),\n}\n")
+	})
+}
+
+func TestGenerator_GenerateRust(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tmpDir
+	cfg.Package = "test.v1"
+	cfg.Rust.Enabled = true
+
+	tables := []*clickhouse.Table{
+		{
+			Name:       "blocks",
+			SortingKey: []string{"slot"},
+			Columns: []clickhouse.Column{
+				{Name: "slot", Type: "UInt64", BaseType: "UInt64", Position: 1},
+				{Name: "block_root", Type: "String", BaseType: "String", Position: 2},
+			},
+		},
+	}
+
+	require.NoError(t, NewGenerator(cfg, logrus.New()).GenerateRust(tables))
+
+	dir := filepath.Join(tmpDir, defaultRustDir)
+	mod, err := os.ReadFile(filepath.Join(dir, "mod.rs"))
+	require.NoError(t, err)
+	assert.Contains(t, string(mod), "pub mod common;\npub mod blocks;\n")
+
+	content, err := os.ReadFile(filepath.Join(dir, "blocks.rs"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "use super::common::{\n    StringFilter,\n    UInt64Filter,\n};")
+	assert.Contains(t, string(content), "#[serde(with = \"super::common::int64_json\")]\n    pub slot: u64,")
+	assert.NotContains(t, string(content), "HashMap")
+
+	common, err := os.ReadFile(filepath.Join(dir, "common.rs"))
+	require.NoError(t, err)
+	assert.Contains(t, string(common), "pub mod int64_json {")
+	assert.Contains(t, string(common), "pub enum UInt64Filter {")
+}
+
+func TestGenerator_RustColumnTypes(t *testing.T) {
+	tests := []struct {
+		column   clickhouse.Column
+		expected string
+	}{
+		{clickhouse.NewColumn("block_number", "UInt64", 1), "    #[serde(with = \"super::common::int64_json\")]\n    pub block_number: u64,\n"},
+		{clickhouse.NewColumn("log_index", "UInt32", 2), "    pub log_index: u32,\n"},
+		{commented(clickhouse.NewColumn("from", "String", 3), "Sender address"), "    /// Sender address\n    pub from: String,\n"},
+		{clickhouse.NewColumn("to", "Nullable(String)", 4), "    #[serde(skip_serializing_if = \"Option::is_none\")]\n    pub to: Option<String>,\n"},
+		{clickhouse.NewColumn("amount", "Float64", 5), "    pub amount: f64,\n"},
+		{clickhouse.NewColumn("fee", "Nullable(Int64)", 6), "    #[serde(with = \"super::common::int64_json::option\", skip_serializing_if = \"Option::is_none\")]\n    pub fee: Option<i64>,\n"},
+		{clickhouse.NewColumn("topics", "Array(String)", 7), "    pub topics: Vec<String>,\n"},
+		{clickhouse.NewColumn("labels", "Map(String, UInt64)", 8), "    #[serde(with = \"super::common::int64_json::map\")]\n    pub labels: HashMap<String, u64>,\n"},
+		{clickhouse.NewColumn("is_contract", "Bool", 9), "    pub is_contract: bool,\n"},
+		{commented(clickhouse.NewColumn("memo", "String", 10), "Memo. DEPRECATED: use labels"), "    /// Memo. DEPRECATED: use labels\n    #[deprecated]\n    pub memo: String,\n"},
+	}
+
+	columns := make([]clickhouse.Column, len(tests))
+	for i, tt := range tests {
+		columns[i] = tt.column
+	}
+	cfg := config.NewConfig()
+	cfg.IncludeComments = true
+	cfg.Rust.Enabled = true
+	files := generateColumnTypes(t, cfg, &clickhouse.Table{Name: "transfers", Database: "default", Engine: "MergeTree", SortingKey: []string{"block_number"}}, columns)
+
+	content := files[defaultRustDir+"/transfers.rs"]
+	assert.Contains(t, content, "#![allow(deprecated)]\n")
+	assert.Contains(t, content, "use std::collections::HashMap;\n")
+
+	_, row, _ := strings.Cut(content, "pub struct Transfers {\n")
+	row, _, _ = strings.Cut(row, "\n}\n")
+	for _, tt := range tests {
+		t.Run(tt.column.Name, func(t *testing.T) {
+			assert.Contains(t, row+"\n", tt.expected)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package protogen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GenerateServerScaffold(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tempDir
+	cfg.Package = "test.v1"
+	cfg.Server.Enabled = true
+	cfg.Server.ListenAddress = ":7070"
+
+	gen := NewGenerator(cfg, logrus.New())
+
+	tables := []*clickhouse.Table{
+		{
+			Name:       "fct_block",
+			Columns:    []clickhouse.Column{{Name: "slot", Type: "UInt64", BaseType: "UInt64", Position: 1}},
+			SortingKey: []string{"slot"},
+		},
+		{
+			Name:    "no_key",
+			Columns: []clickhouse.Column{{Name: "id", Type: "UInt64", BaseType: "UInt64", Position: 1}},
+		},
+	}
+
+	require.NoError(t, gen.GenerateServerScaffold(tables))
+
+	mainPath := filepath.Join(tempDir, "server", "main.go")
+	servicesPath := filepath.Join(tempDir, "server", "services.go")
+
+	mainContent, err := readFile(mainPath)
+	require.NoError(t, err)
+
+	assert.Contains(t, mainContent, "healthpb.RegisterHealthServer(srv, healthServer)")
+	// Only the services registerServices registered report serving
+	assert.Contains(t, mainContent, "registerServices(srv, conn)\n")
+	assert.Contains(t, mainContent, "registered := srv.GetServiceInfo()\n")
+	assert.Contains(t, mainContent, "\t\tstatus := healthpb.HealthCheckResponse_NOT_SERVING\n\t\tif _, ok := registered[name]; ok {\n\t\t\tstatus = healthpb.HealthCheckResponse_SERVING\n")
+	assert.Contains(t, mainContent, "healthServer.SetServingStatus(\"\", overall)")
+	assert.NotContains(t, mainContent, "SetServingStatus(\"\", healthpb.HealthCheckResponse_SERVING)")
+	assert.Contains(t, mainContent, "reflection.Register(srv)")
+	assert.Contains(t, mainContent, "\"test.v1.FctBlockService\",")
+	assert.NotContains(t, mainContent, "NoKeyService", "Tables without sorting keys have no service")
+	assert.Contains(t, mainContent, "envOrDefault(\"LISTEN_ADDRESS\", \":7070\")")
+
+	servicesContent, err := readFile(servicesPath)
+	require.NoError(t, err)
+	assert.Contains(t, servicesContent, "func registerServices(srv *grpc.Server, conn driver.Conn)")
+	assert.Contains(t, servicesContent, "pb.RegisterFctBlockServiceServer(srv, &fctBlockService{conn: conn})")
+
+	// Both files must be valid Go
+	fset := token.NewFileSet()
+	_, err = parser.ParseFile(fset, mainPath, nil, parser.AllErrors)
+	require.NoError(t, err)
+	_, err = parser.ParseFile(fset, servicesPath, nil, parser.AllErrors)
+	require.NoError(t, err)
+
+	// services.go is user-owned and must survive regeneration
+	require.NoError(t, os.WriteFile(servicesPath, []byte("package main\n// edited\n"), 0o600))
+	require.NoError(t, gen.GenerateServerScaffold(tables))
+
+	servicesContent, err = readFile(servicesPath)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n// edited\n", servicesContent)
+}
+
+func TestGenerator_GenerateWithServerScaffoldDisabled(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tempDir
+
+	gen := NewGenerator(cfg, logrus.New())
+
+	tables := []*clickhouse.Table{
+		{
+			Name:       "users",
+			Columns:    []clickhouse.Column{{Name: "id", Type: "UInt64", BaseType: "UInt64", Position: 1}},
+			SortingKey: []string{"id"},
+		},
+	}
+
+	require.NoError(t, gen.Generate(tables))
+	assert.NoDirExists(t, filepath.Join(tempDir, "server"))
+}
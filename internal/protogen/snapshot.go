@@ -0,0 +1,96 @@
+package protogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+)
+
+// snapshotColumn returns the ingestion timestamp column the as_of field of a table's List
+// request filters on, or nil when the table has no such column
+func (g *Generator) snapshotColumn(table *clickhouse.Table) *clickhouse.Column {
+	name := g.config.Snapshot.Column
+	if name == "" {
+		return nil
+	}
+
+	column := findColumn(table, name)
+	if column == nil || column.IsArray || (column.BaseType != clickhouseDateTime && column.BaseType != clickhouseDateTime64) {
+		return nil
+	}
+
+	// Deduplicating compares whole rows by their sorting key, which must be plain columns
+	if g.config.Snapshot.Dedup {
+		for _, key := range table.SortingKey {
+			if findColumn(table, key) == nil {
+				g.log.WithFields(logrus.Fields{
+					"table": table.Name,
+					"key":   key,
+				}).Debug("Skipping as_of: sorting key is not a column, so snapshots can't be deduplicated")
+				return nil
+			}
+		}
+	}
+	return column
+}
+
+// findColumn returns the column of the table with the name, or nil
+func findColumn(table *clickhouse.Table, name string) *clickhouse.Column {
+	for i := range table.Columns {
+		if table.Columns[i].Name == name {
+			return &table.Columns[i]
+		}
+	}
+	return nil
+}
+
+// writeAsOfField writes the as_of field of a List request
+func (g *Generator) writeAsOfField(sb *strings.Builder, table *clickhouse.Table, column *clickhouse.Column, fieldNumber int) {
+	behavior := ""
+	if g.shouldGenerateAPI(table.Name) {
+		behavior = " [(google.api.field_behavior) = OPTIONAL]"
+	}
+
+	protoType, unit := "uint32", "seconds"
+	if column.BaseType == clickhouseDateTime64 {
+		protoType, unit = "uint64", "microseconds"
+	}
+	fmt.Fprintf(sb, "  // Reads the table as it was at this Unix timestamp in %s, from the rows whose\n", unit)
+	fmt.Fprintf(sb, "  // %s is at or before it", column.Name)
+	if g.config.Snapshot.Dedup {
+		fmt.Fprintf(sb, ", keeping the latest of them per %s", strings.Join(table.SortingKey, ", "))
+	}
+	fmt.Fprintf(sb, ".\n  // Repeating a request with the same as_of returns the same rows. Unset reads the latest data.\n")
+	fmt.Fprintf(sb, "  %s as_of = %d%s;\n", protoType, fieldNumber, behavior)
+}
+
+// writeSnapshotOption writes the code of a List query builder applying the as_of field
+func (g *Generator) writeSnapshotOption(sb *strings.Builder, table *clickhouse.Table, column *clickhouse.Column) {
+	dedup := ""
+	if g.config.Snapshot.Dedup {
+		quoted := make([]string, len(table.SortingKey))
+		for i, name := range table.SortingKey {
+			quoted[i] = fmt.Sprintf("%q", name)
+		}
+		dedup = ", Key: []string{" + strings.Join(quoted, ", ") + "}"
+
+		// Queries of a Distributed table run on every shard, so the lookups must be global
+		if clickhouse.TableTopology(table).Kind == clickhouse.TopologyDistributed && g.queryTable(table) == table.Name {
+			dedup += ", Global: true"
+		}
+	}
+
+	micro := column.BaseType == clickhouseDateTime64
+	asOf := "uint64(req.AsOf)"
+	if micro {
+		asOf = "req.AsOf"
+	}
+
+	fmt.Fprintf(sb, "\t// Handle as_of (point-in-time read)\n")
+	fmt.Fprintf(sb, "\tif req.AsOf != 0 {\n")
+	fmt.Fprintf(sb, "\t\tsnapshot := WithSnapshot(Snapshot{Column: %q, AsOf: %s, Micro: %t%s})\n", column.Name, asOf, micro, dedup)
+	fmt.Fprintf(sb, "\t\toptions = append([]QueryOption{snapshot}, options...)\n")
+	fmt.Fprintf(sb, "\t}\n\n")
+}
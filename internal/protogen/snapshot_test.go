@@ -0,0 +1,99 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Snapshot(t *testing.T) {
+	newTable := func(name, engine string, sortingKey ...string) *clickhouse.Table {
+		return &clickhouse.Table{
+			Name:     name,
+			Database: "default",
+			Engine:   engine,
+			Columns: []clickhouse.Column{
+				clickhouse.NewColumn("slot", "UInt32", 1),
+				clickhouse.NewColumn("block_root", "String", 2),
+				clickhouse.NewColumn("updated_date_time", "DateTime", 3),
+			},
+			SortingKey: sortingKey,
+		}
+	}
+	local := newTable("fct_block", "ReplacingMergeTree(updated_date_time)", "slot", "block_root")
+	distributed := newTable("fct_block_dist", "Distributed('{cluster}', default, fct_block, rand())", "slot", "block_root")
+	expression := newTable("fct_block_daily", "ReplacingMergeTree(updated_date_time)", "slot", "toStartOfDay(updated_date_time)")
+	noColumn := &clickhouse.Table{
+		Name:       "dim_node",
+		Columns:    []clickhouse.Column{clickhouse.NewColumn("name", "String", 1)},
+		SortingKey: []string{"name"},
+	}
+
+	generate := func(t *testing.T, snapshot config.SnapshotConfig) func(string) string {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.Snapshot = snapshot
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{local, distributed, expression, noColumn}))
+
+		return func(name string) string {
+			content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+			require.NoError(t, err)
+			return string(content)
+		}
+	}
+
+	t.Run("Dedup", func(t *testing.T) {
+		read := generate(t, config.SnapshotConfig{Column: "updated_date_time", Dedup: true})
+
+		assert.Contains(t, read("fct_block.proto"), "uint32 as_of = 10;")
+		assert.Contains(t, read("fct_block.proto"), "keeping the latest of them per slot, block_root")
+		assert.Contains(t, read("fct_block_sql.go"),
+			`WithSnapshot(Snapshot{Column: "updated_date_time", AsOf: uint64(req.AsOf), Micro: false, Key: []string{"slot", "block_root"}})`)
+		assert.Contains(t, read("fct_block_dist_sql.go"),
+			`WithSnapshot(Snapshot{Column: "updated_date_time", AsOf: uint64(req.AsOf), Micro: false, Key: []string{"slot", "block_root"}, Global: true})`)
+
+		// Versions can't be matched on an expression
+		assert.NotContains(t, read("fct_block_daily.proto"), "as_of")
+		assert.NotContains(t, read("dim_node.proto"), "as_of")
+	})
+
+	t.Run("Without dedup", func(t *testing.T) {
+		read := generate(t, config.SnapshotConfig{Column: "updated_date_time"})
+
+		assert.NotContains(t, read("fct_block.proto"), "keeping the latest")
+		assert.Contains(t, read("fct_block_sql.go"), `WithSnapshot(Snapshot{Column: "updated_date_time", AsOf: uint64(req.AsOf), Micro: false})`)
+		assert.Contains(t, read("fct_block_daily.proto"), "uint32 as_of = ")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		read := generate(t, config.SnapshotConfig{})
+
+		assert.NotContains(t, read("fct_block.proto"), "as_of")
+		assert.NotContains(t, read("fct_block_sql.go"), "WithSnapshot")
+	})
+}
+
+func TestSnapshotGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{}
+	g.writeCommonSQLTypes(&sb)
+	g.writeCommonSQLFunctions(&sb)
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, "func WithSnapshot(snapshot Snapshot) QueryOption")
+	assert.Contains(t, generatedCode, "func buildWhereClause(table string, opts *QueryOptions, qb *QueryBuilder) (string, []interface{}, error)")
+	assert.Contains(t, generatedCode, `if opts.AddFinal && opts.Snapshot == nil {`)
+
+	// The snapshot time is bound, and the lookups repeat it with the conditions' ? arguments
+	assert.Contains(t, generatedCode, `asOf := fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter, snapshot.AsOf))`)
+	assert.Contains(t, generatedCode, "args = append(append(append(args, snapshot.AsOf), whereArgs...), snapshot.AsOf)")
+}
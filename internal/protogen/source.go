@@ -0,0 +1,68 @@
+package protogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// ddlExcerptPrefix starts the comment block of a DDL excerpt
+const ddlExcerptPrefix = "CREATE TABLE "
+
+// writeDDLExcerpt writes the engine and keys of a message's table as a comment block, so
+// reviewers of a proto diff see the schema context. It follows the table comment after an
+// empty comment line and is left out of the message comments model emitters read.
+func (g *Generator) writeDDLExcerpt(sb *strings.Builder, table *clickhouse.Table) {
+	if !g.config.DDLComments {
+		return
+	}
+
+	if g.config.IncludeComments && table.Comment != "" {
+		sb.WriteString("//\n")
+	}
+	for i, line := range clickhouse.DDLExcerpt(table) {
+		if i > 0 {
+			line = "  " + line
+		}
+		fmt.Fprintf(sb, "// %s\n", line)
+	}
+}
+
+// stripDDLExcerpt removes the DDL excerpt written by writeDDLExcerpt from a message comment
+func stripDDLExcerpt(comment string) string {
+	if strings.HasPrefix(comment, ddlExcerptPrefix) {
+		return ""
+	}
+	if idx := strings.Index(comment, "\n\n"+ddlExcerptPrefix); idx >= 0 {
+		return comment[:idx]
+	}
+	return comment
+}
+
+// writeSourceOption writes the clickhouse.v1 option describing the table a message or
+// service was generated from, so middleware can read the database, table, engine and
+// sorting key from the descriptors at runtime
+func (g *Generator) writeSourceOption(sb *strings.Builder, table *clickhouse.Table, extension string) {
+	if !g.config.Emits(config.EmitAnnotations) {
+		return
+	}
+
+	fmt.Fprintf(sb, "  option (clickhouse.v1.%s) = {\n", extension)
+	if table.Database != "" {
+		fmt.Fprintf(sb, "    source_database: %q\n", table.Database)
+	}
+	fmt.Fprintf(sb, "    source_table: %q\n", table.Name)
+	if table.Engine != "" {
+		fmt.Fprintf(sb, "    engine: %q\n", table.Engine)
+	}
+	if len(table.SortingKey) > 0 {
+		keys := make([]string, 0, len(table.SortingKey))
+		for _, key := range table.SortingKey {
+			keys = append(keys, fmt.Sprintf("%q", key))
+		}
+		fmt.Fprintf(sb, "    sorting_key: [%s]\n", strings.Join(keys, ", "))
+	}
+	sb.WriteString("  };\n")
+}
@@ -0,0 +1,101 @@
+package protogen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSourceOption(t *testing.T) {
+	t.Run("Full table", func(t *testing.T) {
+		sb := &strings.Builder{}
+		NewGenerator(config.NewConfig(), logrus.New()).writeSourceOption(sb, &clickhouse.Table{
+			Name:       "blocks",
+			Database:   "beacon",
+			Engine:     "ReplicatedMergeTree('/clickhouse/{shard}/blocks', '{replica}')",
+			SortingKey: []string{"slot", "block_root"},
+		}, "service_table")
+
+		assert.Equal(t, "  option (clickhouse.v1.service_table) = {\n"+
+			"    source_database: \"beacon\"\n"+
+			"    source_table: \"blocks\"\n"+
+			"    engine: \"ReplicatedMergeTree('/clickhouse/{shard}/blocks', '{replica}')\"\n"+
+			"    sorting_key: [\"slot\", \"block_root\"]\n"+
+			"  };\n", sb.String())
+	})
+
+	t.Run("Unknown database and engine", func(t *testing.T) {
+		sb := &strings.Builder{}
+		NewGenerator(config.NewConfig(), logrus.New()).writeSourceOption(sb, &clickhouse.Table{Name: "events"}, "table")
+
+		assert.Equal(t, "  option (clickhouse.v1.table) = {\n    source_table: \"events\"\n  };\n", sb.String())
+	})
+}
+
+func TestWriteDDLExcerpt(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:         "users",
+		Database:     "analytics",
+		Comment:      "Registered users",
+		Engine:       "ReplacingMergeTree(updated_at)",
+		PartitionKey: "toYYYYMM(created_at)",
+		SortingKey:   []string{"user_id"},
+	}
+
+	tests := []struct {
+		name            string
+		table           *clickhouse.Table
+		ddlComments     bool
+		includeComments bool
+		expected        string
+	}{
+		{
+			name:            "Disabled",
+			table:           table,
+			includeComments: true,
+		},
+		{
+			name:            "After the table comment",
+			table:           table,
+			ddlComments:     true,
+			includeComments: true,
+			expected: "//\n" +
+				"// CREATE TABLE analytics.users\n" +
+				"//   ENGINE = ReplacingMergeTree(updated_at)\n" +
+				"//   PARTITION BY toYYYYMM(created_at)\n" +
+				"//   ORDER BY user_id\n",
+		},
+		{
+			name:        "Without comments",
+			table:       table,
+			ddlComments: true,
+			expected: "// CREATE TABLE analytics.users\n" +
+				"//   ENGINE = ReplacingMergeTree(updated_at)\n" +
+				"//   PARTITION BY toYYYYMM(created_at)\n" +
+				"//   ORDER BY user_id\n",
+		},
+		{
+			name:            "Not a MergeTree",
+			table:           &clickhouse.Table{Name: "events", Database: "default", Engine: "Memory"},
+			ddlComments:     true,
+			includeComments: true,
+			expected:        "// CREATE TABLE default.events\n//   ENGINE = Memory\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.DDLComments = tt.ddlComments
+			cfg.IncludeComments = tt.includeComments
+
+			sb := &strings.Builder{}
+			NewGenerator(cfg, logrus.New()).writeDDLExcerpt(sb, tt.table)
+			assert.Equal(t, tt.expected, sb.String())
+		})
+	}
+}
@@ -3,6 +3,7 @@ package protogen
 
 import (
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -11,34 +12,29 @@ func (g *Generator) GenerateSQLCommon() error {
 	sb := &strings.Builder{}
 
 	// Write package header
-	sb.WriteString("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n")
-	sb.WriteString("// This file provides common SQL query building helpers.\n\n")
-	sb.WriteString("package ")
-
-	// Extract package name from go_package
-	pkgName := "main"
-	if g.config.GoPackage != "" {
-		parts := strings.Split(g.config.GoPackage, "/")
-		pkgName = parts[len(parts)-1]
-		pkgName = strings.ReplaceAll(pkgName, "-", "_")
-	}
-	sb.WriteString(pkgName)
-	sb.WriteString("\n\n")
+	g.writeSQLFileHeader(sb, "This file provides common SQL query building helpers.")
 
 	// Write imports
 	sb.WriteString("import (\n")
 	sb.WriteString("\t\"encoding/base64\"\n")
 	sb.WriteString("\t\"fmt\"\n")
+	sb.WriteString("\t\"reflect\"\n")
 	sb.WriteString("\t\"regexp\"\n")
+	sb.WriteString("\t\"sort\"\n")
+	sb.WriteString("\t\"strconv\"\n")
 	sb.WriteString("\t\"strings\"\n")
+	sb.WriteString("\t\"time\"\n")
 	sb.WriteString(")\n\n")
 
 	// Generate the common SQL builder types and functions
 	g.writeCommonSQLTypes(sb)
+	g.writeQueryLimits(sb)
 	g.writeCommonSQLFunctions(sb)
+	g.writeInsertSQLFunctions(sb)
+	g.writeMutationSQLFunctions(sb)
 
 	// Write to file
-	filename := filepath.Join(g.config.OutputDir, "common.go")
+	filename := filepath.Join(g.goOutputDir(), "common.go")
 	if err := g.writeFile(filename, sb.String()); err != nil {
 		return err
 	}
@@ -56,11 +52,17 @@ const (
 	VariableSubstitutionStandard VariableSubstitutionStyle = iota
 	// VariableSubstitutionPositional uses $1, $2, $3... placeholders.
 	VariableSubstitutionPositional
+	// VariableSubstitutionNamed uses {p1:UInt32}, {p2:String}... server-side query
+	// parameters, whose values SQLQuery.Parameters holds.
+	VariableSubstitutionNamed
 )
 
 // QueryBuilderOptions configures QueryBuilder behavior
 type QueryBuilderOptions struct {
 	VariableSubstitution VariableSubstitutionStyle
+	// Unqualified names the columns of conditions without the _t table alias, for
+	// statements without one such as ALTER TABLE mutations
+	Unqualified bool
 }
 
 // QueryBuilderOption is a functional option for QueryBuilder configuration
@@ -73,6 +75,13 @@ func WithVariableSubstitution(style VariableSubstitutionStyle) QueryBuilderOptio
 	}
 }
 
+// WithUnqualifiedColumns names the columns of conditions without the _t table alias
+func WithUnqualifiedColumns() QueryBuilderOption {
+	return func(opts *QueryBuilderOptions) {
+		opts.Unqualified = true
+	}
+}
+
 // QueryOptions configures SQL query generation behavior
 type QueryOptions struct {
 	// AddFinal adds FINAL modifier after table name for ClickHouse MergeTree tables
@@ -81,7 +90,21 @@ type QueryOptions struct {
 	Database string
 	// Projection optionally specifies the projection to use
 	Projection string
-}
+	// Columns optionally replaces the default SELECT column list
+	Columns []string
+	// LimitBy keeps at most this many rows per distinct combination of LimitByColumns
+	LimitBy uint32
+	// LimitByColumns are the columns of the LIMIT BY clause
+	LimitByColumns []string
+	// Sample reads about this fraction of the rows (ClickHouse SAMPLE)
+	Sample float64
+	// Snapshot reads the table as of a point in time
+	Snapshot *Snapshot
+	// Tiers reads the rows older than a cutoff from an archive
+	Tiers *Tiers
+`)
+	g.writeAsyncInsertQueryOption(sb)
+	sb.WriteString(`}
 
 // QueryOption is a functional option for query configuration
 type QueryOption func(*QueryOptions)
@@ -107,10 +130,104 @@ func WithProjection(projection string) QueryOption {
 	}
 }
 
+// WithColumns replaces the default SELECT column list, e.g. with a visibility
+// profile's generated column set such as FctBlockPublicColumns
+func WithColumns(columns []string) QueryOption {
+	return func(opts *QueryOptions) {
+		opts.Columns = columns
+	}
+}
+
+// WithLimitBy keeps the first limit rows, in ORDER BY order, of each distinct combination
+// of columns (ClickHouse LIMIT n BY columns)
+func WithLimitBy(limit uint32, columns ...string) QueryOption {
+	return func(opts *QueryOptions) {
+		opts.LimitBy = limit
+		opts.LimitByColumns = columns
+	}
+}
+
+// WithSample reads about ratio of the rows, for tables with a SAMPLE BY key. Ratios
+// outside (0, 1) read every row.
+func WithSample(ratio float64) QueryOption {
+	return func(opts *QueryOptions) {
+		opts.Sample = ratio
+	}
+}
+
+// Snapshot selects the rows of a table as it was at a point in time
+type Snapshot struct {
+	// Column is the DateTime or DateTime64 column holding when a row was written
+	Column string
+	// AsOf is a Unix timestamp, in microseconds when Micro is set and seconds otherwise
+	AsOf uint64
+	// Micro is set when Column is a DateTime64
+	Micro bool
+	// Key are the columns identifying the versions of a row. When set, only the latest
+	// version as of AsOf is read, and versions written later don't hide it.
+	Key []string
+	// Global looks the versions up with GLOBAL IN, which Distributed tables need
+	Global bool
+}
+
+// WithSnapshot reads only the rows written at or before snapshot.AsOf. It replaces FINAL,
+// which would deduplicate to versions written after the snapshot.
+func WithSnapshot(snapshot Snapshot) QueryOption {
+	return func(opts *QueryOptions) {
+		opts.Snapshot = &snapshot
+	}
+}
+
+// Tiers splits the rows of a table at a cutoff: the rows since the cutoff are read from the
+// table, the older ones from Archive. Queries read only the tiers From and To reach.
+type Tiers struct {
+	// Column is the DateTime or DateTime64 column the rows are split by
+	Column string
+	// Micro is set when Column is a DateTime64, whose bounds are in microseconds
+	Micro bool
+	// Columns are the columns read from each tier, which the archive must have too
+	Columns []string
+	// Archive is the table or table function holding the rows older than the cutoff
+	Archive string
+	// RetentionSeconds is how long rows stay in the table
+	RetentionSeconds uint64
+	// From and To bound the Column values the query selects, inclusive. 0 leaves a bound
+	// open.
+	From, To uint64
+
+	cutoff uint64
+}
+
+// WithTiers reads the rows older than tiers.RetentionSeconds from tiers.Archive. The cutoff
+// is taken when the option is created, so every part of the query splits the rows alike.
+func WithTiers(tiers Tiers) QueryOption {
+	if now := uint64(time.Now().Unix()); now > tiers.RetentionSeconds {
+		tiers.cutoff = now - tiers.RetentionSeconds
+	}
+	if tiers.Micro {
+		tiers.cutoff *= 1000000
+	}
+	return func(opts *QueryOptions) {
+		opts.Tiers = &tiers
+	}
+}
+
+// tierBound converts a DateTime64 filter value to a tier bound, leaving the bound open for
+// values before 1970
+func tierBound(value int64) uint64 {
+	if value < 0 {
+		return 0
+	}
+	return uint64(value)
+}
+
 // SQLQuery represents a parameterized SQL query
 type SQLQuery struct {
-	Query  string
-	Args   []interface{}
+	Query string
+	Args  []interface{}
+	// Parameters holds the values of the {name:Type} placeholders of
+	// VariableSubstitutionNamed, for clickhouse.WithParameters. Args is empty then.
+	Parameters map[string]string
 }
 
 // DateTimeValue wraps a uint32 Unix timestamp for proper DateTime handling in ClickHouse
@@ -129,6 +246,21 @@ type QueryBuilder struct {
 	args       []interface{}
 	argCounter int
 	options    *QueryBuilderOptions
+	// Once Having is called, the conditions and args from these offsets on belong to the
+	// HAVING clause
+	hasHaving      bool
+	havingFrom     int
+	havingArgsFrom int
+	// ctes are the common table expressions of the WITH clause. With ? placeholders their
+	// arguments come first, so they are kept apart in cteArgs.
+	ctes    []cte
+	cteArgs []interface{}
+}
+
+// cte is a common table expression added with AddCTE
+type cte struct {
+	name string
+	sql  string
 }
 
 // NewQueryBuilder creates a new query builder with optional configuration
@@ -149,11 +281,29 @@ func NewQueryBuilder(options ...QueryBuilderOption) *QueryBuilder {
 	}
 }
 
-// formatVariable returns the appropriate placeholder for the given argument index
-func (qb *QueryBuilder) formatVariable(index int) string {
+// qualify names a column of the queried table through the _t alias, which keeps conditions
+// on the stored column when the SELECT list aliases a conversion of it to the same name
+func (qb *QueryBuilder) qualify(column string) string {
+	if qb.options.Unqualified {
+		return column
+	}
+	return "_t." + column
+}
+
+// boolColumn returns the condition expression of a UInt8 column holding booleans, true for
+// any non-zero value
+func (qb *QueryBuilder) boolColumn(column string) string {
+	return "(" + qb.qualify(column) + " != 0)"
+}
+
+// formatVariable returns the appropriate placeholder for the given argument index, typed
+// after its value for named parameters
+func (qb *QueryBuilder) formatVariable(index int, value interface{}) string {
 	switch qb.options.VariableSubstitution {
 	case VariableSubstitutionPositional:
 		return fmt.Sprintf("$%d", index)
+	case VariableSubstitutionNamed:
+		return fmt.Sprintf("{p%d:%s}", index, parameterType(value))
 	case VariableSubstitutionStandard:
 		return "?"
 	default:
@@ -161,13 +311,109 @@ func (qb *QueryBuilder) formatVariable(index int) string {
 	}
 }
 
+// parameterType returns the ClickHouse type of a named parameter holding value. Slices are
+// Arrays and maps Maps of their elements' types, and nil, the value of an unset Nullable
+// column, is a Nullable(Nothing) NULL, which converts to any Nullable column.
+func parameterType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "Nullable(Nothing)"
+	case bool:
+		return "Bool"
+	case int32:
+		return "Int32"
+	case int, int64:
+		return "Int64"
+	case uint32, DateTimeValue:
+		return "UInt32"
+	case uint64, DateTime64Value:
+		return "UInt64"
+	case float32:
+		return "Float32"
+	case float64:
+		return "Float64"
+	case string:
+		return "String"
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice:
+		return "Array(" + parameterType(reflect.Zero(v.Type().Elem()).Interface()) + ")"
+	case reflect.Map:
+		key := parameterType(reflect.Zero(v.Type().Key()).Interface())
+		return "Map(" + key + ", " + parameterType(reflect.Zero(v.Type().Elem()).Interface()) + ")"
+	default:
+		return "String"
+	}
+}
+
+// parameterEscaper escapes the characters ClickHouse unescapes in parameter values
+var parameterEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n")
+
+// literalEscaper escapes the characters ending a quoted string literal
+var literalEscaper = strings.NewReplacer("\\", "\\\\", "'", "\\'")
+
+// formatParameter formats the value of a named parameter. Strings are sent as they are,
+// apart from the escapes ClickHouse reads parameter values with, nil as the \N of NULL,
+// and slices and maps as the Array and Map literals their types parse.
+func formatParameter(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "\\N"
+	case string:
+		return parameterEscaper.Replace(v)
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Slice || v.Kind() == reflect.Map {
+		return formatLiteral(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// formatLiteral formats a value as a ClickHouse literal, with the entries of maps sorted so
+// equal maps format alike
+func formatLiteral(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return "'" + literalEscaper.Replace(v.String()) + "'"
+	case reflect.Slice:
+		elements := make([]string, v.Len())
+		for i := range elements {
+			elements[i] = formatLiteral(v.Index(i))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			entries = append(entries, formatLiteral(iter.Key())+": "+formatLiteral(iter.Value()))
+		}
+		sort.Strings(entries)
+		return "{" + strings.Join(entries, ", ") + "}"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// sqlQuery returns query with its arguments, which are the values of its parameters when qb
+// uses named placeholders
+func (qb *QueryBuilder) sqlQuery(query string, args []interface{}) SQLQuery {
+	if qb.options.VariableSubstitution != VariableSubstitutionNamed {
+		return SQLQuery{Query: query, Args: args}
+	}
+	parameters := make(map[string]string, len(args))
+	for i, arg := range args {
+		parameters[fmt.Sprintf("p%d", i+1)] = formatParameter(arg)
+	}
+	return SQLQuery{Query: query, Parameters: parameters}
+}
+
 `)
 }
 
 func (g *Generator) writeCommonSQLFunctions(sb *strings.Builder) {
 	sb.WriteString(`// AddCondition adds a condition with a parameterized value
 func (qb *QueryBuilder) AddCondition(column, operator string, value interface{}) {
-	placeholder := qb.formatVariable(qb.argCounter)
+	placeholder := qb.formatVariable(qb.argCounter, value)
 
 	// Check if value is a DateTime wrapper and handle accordingly
 	switch v := value.(type) {
@@ -176,10 +422,10 @@ func (qb *QueryBuilder) AddCondition(column, operator string, value interface{})
 		qb.conditions = append(qb.conditions, fmt.Sprintf("%s %s fromUnixTimestamp(%s)", column, operator, placeholder))
 		qb.args = append(qb.args, v.Timestamp)
 	case DateTime64Value:
-		// For DateTime64 values, use table alias _t. to reference original column and avoid
+		// For DateTime64 values, qualify the column to reference the original column and avoid
 		// collision with SELECT aliases. Wrap parameter with toInt64() to prevent ClickHouse
 		// Go driver from auto-casting uint64 values to DateTime64 type.
-		qb.conditions = append(qb.conditions, fmt.Sprintf("_t.%s %s fromUnixTimestamp64Micro(toInt64(%s))", column, operator, placeholder))
+		qb.conditions = append(qb.conditions, fmt.Sprintf("%s %s fromUnixTimestamp64Micro(toInt64(%s))", qb.qualify(column), operator, placeholder))
 		qb.args = append(qb.args, v.Timestamp)
 	default:
 		// Regular value
@@ -191,9 +437,9 @@ func (qb *QueryBuilder) AddCondition(column, operator string, value interface{})
 
 // AddBetweenCondition adds a BETWEEN condition
 func (qb *QueryBuilder) AddBetweenCondition(column string, minValue, maxValue interface{}) {
-	placeholderMin := qb.formatVariable(qb.argCounter)
+	placeholderMin := qb.formatVariable(qb.argCounter, minValue)
 	qb.argCounter++
-	placeholderMax := qb.formatVariable(qb.argCounter)
+	placeholderMax := qb.formatVariable(qb.argCounter, maxValue)
 	qb.argCounter++
 
 	// Check if values are DateTime wrappers
@@ -207,8 +453,8 @@ func (qb *QueryBuilder) AddBetweenCondition(column string, minValue, maxValue in
 	case DateTime64Value:
 		minV := minValue.(DateTime64Value)
 		maxV := maxValue.(DateTime64Value)
-		qb.conditions = append(qb.conditions, fmt.Sprintf("_t.%s BETWEEN fromUnixTimestamp64Micro(toInt64(%s)) AND fromUnixTimestamp64Micro(toInt64(%s))",
-			column, placeholderMin, placeholderMax))
+		qb.conditions = append(qb.conditions, fmt.Sprintf("%s BETWEEN fromUnixTimestamp64Micro(toInt64(%s)) AND fromUnixTimestamp64Micro(toInt64(%s))",
+			qb.qualify(column), placeholderMin, placeholderMax))
 		qb.args = append(qb.args, minV.Timestamp, maxV.Timestamp)
 	default:
 		qb.conditions = append(qb.conditions, fmt.Sprintf("%s BETWEEN %s AND %s", column, placeholderMin, placeholderMax))
@@ -229,21 +475,21 @@ func (qb *QueryBuilder) AddInCondition(column string, values []interface{}) {
 			placeholders := make([]string, len(values))
 			for i, v := range values {
 				dt := v.(DateTimeValue)
-				placeholders[i] = fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter))
+				placeholders[i] = fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter, dt.Timestamp))
 				qb.args = append(qb.args, dt.Timestamp)
 				qb.argCounter++
 			}
-			qb.conditions = append(qb.conditions, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+			qb.conditions = append(qb.conditions, inList(column, "IN", placeholders))
 			return
 		case DateTime64Value:
 			placeholders := make([]string, len(values))
 			for i, v := range values {
 				dt := v.(DateTime64Value)
-				placeholders[i] = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%s))", qb.formatVariable(qb.argCounter))
+				placeholders[i] = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%s))", qb.formatVariable(qb.argCounter, dt.Timestamp))
 				qb.args = append(qb.args, dt.Timestamp)
 				qb.argCounter++
 			}
-			qb.conditions = append(qb.conditions, fmt.Sprintf("_t.%s IN (%s)", column, strings.Join(placeholders, ", ")))
+			qb.conditions = append(qb.conditions, inList(qb.qualify(column), "IN", placeholders))
 			return
 		}
 	}
@@ -251,11 +497,33 @@ func (qb *QueryBuilder) AddInCondition(column string, values []interface{}) {
 	// Regular values
 	placeholders := make([]string, len(values))
 	for i, v := range values {
-		placeholders[i] = qb.formatVariable(qb.argCounter)
+		placeholders[i] = qb.formatVariable(qb.argCounter, v)
 		qb.args = append(qb.args, v)
 		qb.argCounter++
 	}
-	qb.conditions = append(qb.conditions, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+	qb.conditions = append(qb.conditions, inList(column, "IN", placeholders))
+}
+
+// inList returns the IN (or NOT IN) condition of a column and the placeholders of its values.
+// Lists longer than inChunkSize are split into groups joined with OR (AND for NOT IN).
+func inList(column, operator string, placeholders []string) string {
+	chunk := int(inChunkSize)
+	if chunk == 0 || len(placeholders) <= chunk {
+		return fmt.Sprintf("%s %s (%s)", column, operator, strings.Join(placeholders, ", "))
+	}
+	join := " OR "
+	if operator == "NOT IN" {
+		join = " AND "
+	}
+	groups := make([]string, 0, (len(placeholders)+chunk-1)/chunk)
+	for start := 0; start < len(placeholders); start += chunk {
+		end := start + chunk
+		if end > len(placeholders) {
+			end = len(placeholders)
+		}
+		groups = append(groups, fmt.Sprintf("%s %s (%s)", column, operator, strings.Join(placeholders[start:end], ", ")))
+	}
+	return "(" + strings.Join(groups, join) + ")"
 }
 
 // AddNotInCondition adds a NOT IN condition
@@ -271,21 +539,21 @@ func (qb *QueryBuilder) AddNotInCondition(column string, values []interface{}) {
 			placeholders := make([]string, len(values))
 			for i, v := range values {
 				dt := v.(DateTimeValue)
-				placeholders[i] = fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter))
+				placeholders[i] = fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter, dt.Timestamp))
 				qb.args = append(qb.args, dt.Timestamp)
 				qb.argCounter++
 			}
-			qb.conditions = append(qb.conditions, fmt.Sprintf("%s NOT IN (%s)", column, strings.Join(placeholders, ", ")))
+			qb.conditions = append(qb.conditions, inList(column, "NOT IN", placeholders))
 			return
 		case DateTime64Value:
 			placeholders := make([]string, len(values))
 			for i, v := range values {
 				dt := v.(DateTime64Value)
-				placeholders[i] = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%s))", qb.formatVariable(qb.argCounter))
+				placeholders[i] = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%s))", qb.formatVariable(qb.argCounter, dt.Timestamp))
 				qb.args = append(qb.args, dt.Timestamp)
 				qb.argCounter++
 			}
-			qb.conditions = append(qb.conditions, fmt.Sprintf("_t.%s NOT IN (%s)", column, strings.Join(placeholders, ", ")))
+			qb.conditions = append(qb.conditions, inList(qb.qualify(column), "NOT IN", placeholders))
 			return
 		}
 	}
@@ -293,16 +561,27 @@ func (qb *QueryBuilder) AddNotInCondition(column string, values []interface{}) {
 	// Regular values
 	placeholders := make([]string, len(values))
 	for i, v := range values {
-		placeholders[i] = qb.formatVariable(qb.argCounter)
+		placeholders[i] = qb.formatVariable(qb.argCounter, v)
 		qb.args = append(qb.args, v)
 		qb.argCounter++
 	}
-	qb.conditions = append(qb.conditions, fmt.Sprintf("%s NOT IN (%s)", column, strings.Join(placeholders, ", ")))
+	qb.conditions = append(qb.conditions, inList(column, "NOT IN", placeholders))
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, and the backslash escaping them
+var likeEscaper = strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+
+// EscapeLike escapes the % and _ wildcards of a value, so it matches itself within a LIKE
+// pattern. The contains, starts_with and ends_with filters escape their value; only like and
+// not_like take a raw pattern.
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
 }
 
-// AddLikeCondition adds a LIKE condition with proper escaping
+// AddLikeCondition adds a LIKE condition. The pattern is bound as a parameter; its % and _
+// are wildcards, so parts matched literally must go through EscapeLike.
 func (qb *QueryBuilder) AddLikeCondition(column, pattern string) {
-	placeholder := qb.formatVariable(qb.argCounter)
+	placeholder := qb.formatVariable(qb.argCounter, pattern)
 	qb.conditions = append(qb.conditions, fmt.Sprintf("%s LIKE %s", column, placeholder))
 	qb.args = append(qb.args, pattern)
 	qb.argCounter++
@@ -310,12 +589,18 @@ func (qb *QueryBuilder) AddLikeCondition(column, pattern string) {
 
 // AddNotLikeCondition adds a NOT LIKE condition
 func (qb *QueryBuilder) AddNotLikeCondition(column, pattern string) {
-	placeholder := qb.formatVariable(qb.argCounter)
+	placeholder := qb.formatVariable(qb.argCounter, pattern)
 	qb.conditions = append(qb.conditions, fmt.Sprintf("%s NOT LIKE %s", column, placeholder))
 	qb.args = append(qb.args, pattern)
 	qb.argCounter++
 }
 
+// AddRawCondition adds a SQL condition as is, in parentheses. It takes no arguments, so it
+// must not contain request values.
+func (qb *QueryBuilder) AddRawCondition(condition string) {
+	qb.conditions = append(qb.conditions, "("+condition+")")
+}
+
 // AddIsNullCondition adds an IS NULL condition
 func (qb *QueryBuilder) AddIsNullCondition(column string) {
 	qb.conditions = append(qb.conditions, fmt.Sprintf("%s IS NULL", column))
@@ -326,39 +611,48 @@ func (qb *QueryBuilder) AddIsNotNullCondition(column string) {
 	qb.conditions = append(qb.conditions, fmt.Sprintf("%s IS NOT NULL", column))
 }
 
-// AddMapKeyCondition adds a condition for accessing a map key value
+// AddMapKeyCondition adds a condition for accessing a map key value. The key is bound like
+// the value, never written into the query.
 func (qb *QueryBuilder) AddMapKeyCondition(column, key, operator string, value interface{}) {
-	placeholder := qb.formatVariable(qb.argCounter)
-	// Escape the key for SQL safety
-	escapedKey := strings.ReplaceAll(key, "'", "''")
-	qb.conditions = append(qb.conditions, fmt.Sprintf("%s['%s'] %s %s", column, escapedKey, operator, placeholder))
+	element := qb.mapElement(column, key)
+	placeholder := qb.formatVariable(qb.argCounter, value)
+	qb.conditions = append(qb.conditions, fmt.Sprintf("%s %s %s", element, operator, placeholder))
 	qb.args = append(qb.args, value)
 	qb.argCounter++
 }
 
 // AddMapKeyLikeCondition adds a LIKE condition for a map key value
 func (qb *QueryBuilder) AddMapKeyLikeCondition(column, key, pattern string) {
-	placeholder := qb.formatVariable(qb.argCounter)
-	escapedKey := strings.ReplaceAll(key, "'", "''")
-	qb.conditions = append(qb.conditions, fmt.Sprintf("%s['%s'] LIKE %s", column, escapedKey, placeholder))
+	element := qb.mapElement(column, key)
+	placeholder := qb.formatVariable(qb.argCounter, pattern)
+	qb.conditions = append(qb.conditions, fmt.Sprintf("%s LIKE %s", element, placeholder))
 	qb.args = append(qb.args, pattern)
 	qb.argCounter++
 }
 
 // AddMapKeyBetweenCondition adds a BETWEEN condition for a map key value
 func (qb *QueryBuilder) AddMapKeyBetweenCondition(column, key string, minValue, maxValue interface{}) {
-	placeholderMin := qb.formatVariable(qb.argCounter)
+	element := qb.mapElement(column, key)
+	placeholderMin := qb.formatVariable(qb.argCounter, minValue)
 	qb.argCounter++
-	placeholderMax := qb.formatVariable(qb.argCounter)
+	placeholderMax := qb.formatVariable(qb.argCounter, maxValue)
 	qb.argCounter++
-	escapedKey := strings.ReplaceAll(key, "'", "''")
-	qb.conditions = append(qb.conditions, fmt.Sprintf("%s['%s'] BETWEEN %s AND %s", column, escapedKey, placeholderMin, placeholderMax))
+	qb.conditions = append(qb.conditions, fmt.Sprintf("%s BETWEEN %s AND %s", element, placeholderMin, placeholderMax))
 	qb.args = append(qb.args, minValue, maxValue)
 }
 
+// mapElement binds a map key and returns the element of column it selects. The key comes
+// before the value in the query, so its argument does too.
+func (qb *QueryBuilder) mapElement(column, key string) string {
+	placeholder := qb.formatVariable(qb.argCounter, key)
+	qb.args = append(qb.args, key)
+	qb.argCounter++
+	return fmt.Sprintf("%s[%s]", column, placeholder)
+}
+
 // AddMapContainsCondition adds a mapContains condition
 func (qb *QueryBuilder) AddMapContainsCondition(column string, key interface{}) {
-	placeholder := qb.formatVariable(qb.argCounter)
+	placeholder := qb.formatVariable(qb.argCounter, key)
 	qb.conditions = append(qb.conditions, fmt.Sprintf("mapContains(%s, %s)", column, placeholder))
 	qb.args = append(qb.args, key)
 	qb.argCounter++
@@ -366,7 +660,7 @@ func (qb *QueryBuilder) AddMapContainsCondition(column string, key interface{})
 
 // AddNotMapContainsCondition adds a NOT mapContains condition
 func (qb *QueryBuilder) AddNotMapContainsCondition(column string, key interface{}) {
-	placeholder := qb.formatVariable(qb.argCounter)
+	placeholder := qb.formatVariable(qb.argCounter, key)
 	qb.conditions = append(qb.conditions, fmt.Sprintf("NOT mapContains(%s, %s)", column, placeholder))
 	qb.args = append(qb.args, key)
 	qb.argCounter++
@@ -379,7 +673,7 @@ func (qb *QueryBuilder) AddMapContainsAnyCondition(column string, keys []string)
 	}
 	conditions := make([]string, 0, len(keys))
 	for _, key := range keys {
-		placeholder := qb.formatVariable(qb.argCounter)
+		placeholder := qb.formatVariable(qb.argCounter, key)
 		conditions = append(conditions, fmt.Sprintf("mapContains(%s, %s)", column, placeholder))
 		qb.args = append(qb.args, key)
 		qb.argCounter++
@@ -392,7 +686,7 @@ func (qb *QueryBuilder) AddMapContainsAnyCondition(column string, keys []string)
 
 // AddDateTimeCondition adds a condition for DateTime columns (converts Unix timestamp to DateTime)
 func (qb *QueryBuilder) AddDateTimeCondition(column, operator string, unixTimestamp uint32) {
-	placeholder := qb.formatVariable(qb.argCounter)
+	placeholder := qb.formatVariable(qb.argCounter, unixTimestamp)
 	qb.conditions = append(qb.conditions, fmt.Sprintf("%s %s fromUnixTimestamp(%s)", column, operator, placeholder))
 	qb.args = append(qb.args, unixTimestamp)
 	qb.argCounter++
@@ -400,9 +694,9 @@ func (qb *QueryBuilder) AddDateTimeCondition(column, operator string, unixTimest
 
 // AddDateTimeBetweenCondition adds a BETWEEN condition for DateTime columns
 func (qb *QueryBuilder) AddDateTimeBetweenCondition(column string, minTimestamp, maxTimestamp uint32) {
-	placeholderMin := qb.formatVariable(qb.argCounter)
+	placeholderMin := qb.formatVariable(qb.argCounter, minTimestamp)
 	qb.argCounter++
-	placeholderMax := qb.formatVariable(qb.argCounter)
+	placeholderMax := qb.formatVariable(qb.argCounter, maxTimestamp)
 	qb.argCounter++
 	qb.conditions = append(qb.conditions, fmt.Sprintf("%s BETWEEN fromUnixTimestamp(%s) AND fromUnixTimestamp(%s)",
 		column, placeholderMin, placeholderMax))
@@ -416,11 +710,11 @@ func (qb *QueryBuilder) AddDateTimeInCondition(column string, timestamps []uint3
 	}
 	placeholders := make([]string, len(timestamps))
 	for i, ts := range timestamps {
-		placeholders[i] = fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter))
+		placeholders[i] = fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter, ts))
 		qb.args = append(qb.args, ts)
 		qb.argCounter++
 	}
-	qb.conditions = append(qb.conditions, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+	qb.conditions = append(qb.conditions, inList(column, "IN", placeholders))
 }
 
 // AddDateTimeNotInCondition adds a NOT IN condition for DateTime columns
@@ -430,32 +724,32 @@ func (qb *QueryBuilder) AddDateTimeNotInCondition(column string, timestamps []ui
 	}
 	placeholders := make([]string, len(timestamps))
 	for i, ts := range timestamps {
-		placeholders[i] = fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter))
+		placeholders[i] = fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter, ts))
 		qb.args = append(qb.args, ts)
 		qb.argCounter++
 	}
-	qb.conditions = append(qb.conditions, fmt.Sprintf("%s NOT IN (%s)", column, strings.Join(placeholders, ", ")))
+	qb.conditions = append(qb.conditions, inList(column, "NOT IN", placeholders))
 }
 
 // DateTime64-specific condition methods (for microsecond precision timestamps)
 
 // AddDateTime64Condition adds a condition for DateTime64 columns (converts Unix timestamp to DateTime64)
 func (qb *QueryBuilder) AddDateTime64Condition(column, operator string, unixTimestamp uint64) {
-	placeholder := qb.formatVariable(qb.argCounter)
-	// Use _t. prefix and toInt64() wrapper to avoid driver auto-casting
-	qb.conditions = append(qb.conditions, fmt.Sprintf("_t.%s %s fromUnixTimestamp64Micro(toInt64(%s))", column, operator, placeholder))
+	placeholder := qb.formatVariable(qb.argCounter, unixTimestamp)
+	// Qualify the column and wrap with toInt64() to avoid driver auto-casting
+	qb.conditions = append(qb.conditions, fmt.Sprintf("%s %s fromUnixTimestamp64Micro(toInt64(%s))", qb.qualify(column), operator, placeholder))
 	qb.args = append(qb.args, unixTimestamp)
 	qb.argCounter++
 }
 
 // AddDateTime64BetweenCondition adds a BETWEEN condition for DateTime64 columns
 func (qb *QueryBuilder) AddDateTime64BetweenCondition(column string, minTimestamp, maxTimestamp uint64) {
-	placeholderMin := qb.formatVariable(qb.argCounter)
+	placeholderMin := qb.formatVariable(qb.argCounter, minTimestamp)
 	qb.argCounter++
-	placeholderMax := qb.formatVariable(qb.argCounter)
+	placeholderMax := qb.formatVariable(qb.argCounter, maxTimestamp)
 	qb.argCounter++
-	qb.conditions = append(qb.conditions, fmt.Sprintf("_t.%s BETWEEN fromUnixTimestamp64Micro(toInt64(%s)) AND fromUnixTimestamp64Micro(toInt64(%s))",
-		column, placeholderMin, placeholderMax))
+	qb.conditions = append(qb.conditions, fmt.Sprintf("%s BETWEEN fromUnixTimestamp64Micro(toInt64(%s)) AND fromUnixTimestamp64Micro(toInt64(%s))",
+		qb.qualify(column), placeholderMin, placeholderMax))
 	qb.args = append(qb.args, minTimestamp, maxTimestamp)
 }
 
@@ -466,11 +760,11 @@ func (qb *QueryBuilder) AddDateTime64InCondition(column string, timestamps []uin
 	}
 	placeholders := make([]string, len(timestamps))
 	for i, ts := range timestamps {
-		placeholders[i] = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%s))", qb.formatVariable(qb.argCounter))
+		placeholders[i] = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%s))", qb.formatVariable(qb.argCounter, ts))
 		qb.args = append(qb.args, ts)
 		qb.argCounter++
 	}
-	qb.conditions = append(qb.conditions, fmt.Sprintf("_t.%s IN (%s)", column, strings.Join(placeholders, ", ")))
+	qb.conditions = append(qb.conditions, inList(qb.qualify(column), "IN", placeholders))
 }
 
 // AddDateTime64NotInCondition adds a NOT IN condition for DateTime64 columns
@@ -480,24 +774,163 @@ func (qb *QueryBuilder) AddDateTime64NotInCondition(column string, timestamps []
 	}
 	placeholders := make([]string, len(timestamps))
 	for i, ts := range timestamps {
-		placeholders[i] = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%s))", qb.formatVariable(qb.argCounter))
+		placeholders[i] = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%s))", qb.formatVariable(qb.argCounter, ts))
 		qb.args = append(qb.args, ts)
 		qb.argCounter++
 	}
-	qb.conditions = append(qb.conditions, fmt.Sprintf("_t.%s NOT IN (%s)", column, strings.Join(placeholders, ", ")))
+	qb.conditions = append(qb.conditions, inList(qb.qualify(column), "NOT IN", placeholders))
 }
 
 // GetWhereClause returns the WHERE clause if conditions exist
 func (qb *QueryBuilder) GetWhereClause() string {
-	if len(qb.conditions) == 0 {
+	conditions := qb.whereConditions()
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// Having starts the HAVING clause of an aggregating query: conditions added from now on
+// filter its groups rather than its rows, and can name its aggregates, such as _count. Add
+// every WHERE condition first, so the arguments of both clauses are in query order.
+func (qb *QueryBuilder) Having() {
+	if qb.hasHaving {
+		return
+	}
+	qb.hasHaving = true
+	qb.havingFrom = len(qb.conditions)
+	qb.havingArgsFrom = len(qb.args)
+}
+
+// GetHavingClause returns the HAVING clause if conditions were added after Having
+func (qb *QueryBuilder) GetHavingClause() string {
+	conditions := qb.havingConditions()
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " HAVING " + strings.Join(conditions, " AND ")
+}
+
+// whereConditions returns the conditions of the WHERE clause
+func (qb *QueryBuilder) whereConditions() []string {
+	if !qb.hasHaving {
+		return qb.conditions
+	}
+	return qb.conditions[:qb.havingFrom]
+}
+
+// havingConditions returns the conditions of the HAVING clause
+func (qb *QueryBuilder) havingConditions() []string {
+	if !qb.hasHaving {
+		return nil
+	}
+	return qb.conditions[qb.havingFrom:]
+}
+
+// clauseArgs returns the arguments of the WHERE and HAVING clauses. Numbered placeholders
+// index every argument, so for them all of the arguments stay together.
+func (qb *QueryBuilder) clauseArgs() (where, having []interface{}) {
+	if !qb.hasHaving || qb.options.VariableSubstitution != VariableSubstitutionStandard {
+		return qb.args, nil
+	}
+	return qb.args[:qb.havingArgsFrom], qb.args[qb.havingArgsFrom:]
+}
+
+// AddCTE adds a common table expression, WITH name AS (sql), to the queries built from qb,
+// for filters that need a derived table such as a percentile threshold or the latest row
+// per key. sql takes its values as ? placeholders, which are bound in qb's style, and
+// AddCTECondition compares a column with its rows.
+func (qb *QueryBuilder) AddCTE(name, sql string, args []interface{}) error {
+	if !isValidColumnName(name) || strings.Contains(name, ".") {
+		return fmt.Errorf("invalid CTE name: %s", name)
+	}
+	if qb.hasCTE(name) {
+		return fmt.Errorf("CTE %s is already defined", name)
+	}
+	parts := splitPlaceholders(sql)
+	if len(parts)-1 != len(args) {
+		return fmt.Errorf("CTE %s has %d placeholders for %d values", name, len(parts)-1, len(args))
+	}
+
+	if qb.options.VariableSubstitution == VariableSubstitutionStandard {
+		qb.cteArgs = append(qb.cteArgs, args...)
+	} else {
+		var numbered strings.Builder
+		numbered.WriteString(parts[0])
+		for i, arg := range args {
+			numbered.WriteString(qb.formatVariable(qb.argCounter, arg))
+			numbered.WriteString(parts[i+1])
+			qb.args = append(qb.args, arg)
+			qb.argCounter++
+		}
+		sql = numbered.String()
+	}
+	qb.ctes = append(qb.ctes, cte{name: name, sql: sql})
+	return nil
+}
+
+// AddCTECondition compares column with the rows of a CTE added with AddCTE: IN for a list
+// of keys, or an operator such as > for a single value
+func (qb *QueryBuilder) AddCTECondition(column, operator, name string) error {
+	if !qb.hasCTE(name) {
+		return fmt.Errorf("unknown CTE: %s", name)
+	}
+	qb.conditions = append(qb.conditions, fmt.Sprintf("%s %s (SELECT * FROM %s)", column, operator, QuoteIdentifier(name)))
+	return nil
+}
+
+// hasCTE reports whether a CTE of that name was added
+func (qb *QueryBuilder) hasCTE(name string) bool {
+	for _, existing := range qb.ctes {
+		if existing.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetWithClause returns the WITH clause of the CTEs, followed by a space, if any were added
+func (qb *QueryBuilder) GetWithClause() string {
+	if len(qb.ctes) == 0 {
 		return ""
 	}
-	return " WHERE " + strings.Join(qb.conditions, " AND ")
+	ctes := make([]string, len(qb.ctes))
+	for i, c := range qb.ctes {
+		ctes[i] = fmt.Sprintf("%s AS (%s)", QuoteIdentifier(c.name), c.sql)
+	}
+	return "WITH " + strings.Join(ctes, ", ") + " "
 }
 
-// GetArgs returns the query arguments
+// splitPlaceholders splits sql at its ? placeholders, leaving those within quoted strings
+// and identifiers
+func splitPlaceholders(sql string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '\x60':
+			quote = c
+		case c == '?':
+			parts = append(parts, sql[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, sql[start:])
+}
+
+// GetArgs returns the query arguments, those of the WITH clause first
 func (qb *QueryBuilder) GetArgs() []interface{} {
-	return qb.args
+	if len(qb.cteArgs) == 0 {
+		return qb.args
+	}
+	return append(append([]interface{}{}, qb.cteArgs...), qb.args...)
 }
 
 
@@ -545,7 +978,7 @@ func StringSliceToInterface(values []string) []interface{} {
 
 // AddArrayHasCondition adds a has(array, value) condition
 func (qb *QueryBuilder) AddArrayHasCondition(column string, value interface{}) {
-	placeholder := qb.formatVariable(qb.argCounter)
+	placeholder := qb.formatVariable(qb.argCounter, value)
 	qb.conditions = append(qb.conditions, fmt.Sprintf("has(%s, %s)", column, placeholder))
 	qb.args = append(qb.args, value)
 	qb.argCounter++
@@ -558,7 +991,7 @@ func (qb *QueryBuilder) AddArrayHasAllCondition(column string, values []interfac
 	}
 	placeholders := make([]string, len(values))
 	for i, v := range values {
-		placeholders[i] = qb.formatVariable(qb.argCounter)
+		placeholders[i] = qb.formatVariable(qb.argCounter, v)
 		qb.args = append(qb.args, v)
 		qb.argCounter++
 	}
@@ -572,7 +1005,7 @@ func (qb *QueryBuilder) AddArrayHasAnyCondition(column string, values []interfac
 	}
 	placeholders := make([]string, len(values))
 	for i, v := range values {
-		placeholders[i] = qb.formatVariable(qb.argCounter)
+		placeholders[i] = qb.formatVariable(qb.argCounter, v)
 		qb.args = append(qb.args, v)
 		qb.argCounter++
 	}
@@ -581,7 +1014,7 @@ func (qb *QueryBuilder) AddArrayHasAnyCondition(column string, values []interfac
 
 // AddArrayLengthCondition adds a length(array) op value condition
 func (qb *QueryBuilder) AddArrayLengthCondition(column, operator string, length uint32) {
-	placeholder := qb.formatVariable(qb.argCounter)
+	placeholder := qb.formatVariable(qb.argCounter, length)
 	qb.conditions = append(qb.conditions, fmt.Sprintf("length(%s) %s %s", column, operator, placeholder))
 	qb.args = append(qb.args, length)
 	qb.argCounter++
@@ -636,6 +1069,148 @@ func CalculateNextPageToken(currentOffset, limit, resultCount uint32) string {
 	return EncodePageToken(nextOffset)
 }
 
+// InvalidFilterError is returned by the query builders for a request field whose value they
+// can't use, such as a missing primary key or a negative page_size
+type InvalidFilterError struct {
+	// Field is the request field, e.g. slot or page_size
+	Field string
+	// Reason describes the problem, and is the message of the error
+	Reason string
+}
+
+func (e *InvalidFilterError) Error() string {
+	return e.Reason
+}
+
+// invalidFilter returns the InvalidFilterError of a request field
+func invalidFilter(field, format string, args ...interface{}) error {
+	return &InvalidFilterError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+// validateFilterValues rejects the list of a filter holding more than maxFilterValues values
+func validateFilterValues(field, list string, count int) error {
+	if maxFilterValues > 0 && count > maxFilterValues {
+		return invalidFilter(field, "%s of %s holds %d values, at most %d are allowed", list, field, count, maxFilterValues)
+	}
+	return nil
+}
+
+// UnknownColumnError is returned by the query builders for a request naming a field the
+// table doesn't have, or doesn't accept there, such as a masked field in order_by
+type UnknownColumnError struct {
+	// Field is the request field naming it: order_by, distinct_on or value_field
+	Field string
+	// Name is the unknown name
+	Name string
+	// SuggestedField is the field reading the column, when Name is a column
+	SuggestedField string
+	// ValidFields are the fields accepted, sorted
+	ValidFields []string
+}
+
+func (e *UnknownColumnError) Error() string {
+	if e.SuggestedField != "" {
+		return fmt.Sprintf("%s is a column, use its field %s", e.Name, e.SuggestedField)
+	}
+	return fmt.Sprintf("unknown field %s (valid fields: %s)", e.Name, strings.Join(e.ValidFields, ", "))
+}
+
+// PageTokenExpiredError is returned by the List query builders for a page_token they can't
+// decode, e.g. one issued by another version of the service
+type PageTokenExpiredError struct {
+	// PageToken is the rejected token
+	PageToken string
+	// Err is why it was rejected
+	Err error
+}
+
+func (e *PageTokenExpiredError) Error() string {
+	return fmt.Sprintf("invalid page_token: %v", e.Err)
+}
+
+func (e *PageTokenExpiredError) Unwrap() error {
+	return e.Err
+}
+
+// ColumnsByField maps the proto fields of a table to the ClickHouse columns they read. Each
+// table has one, <Table>ColumnsByField, which the order_by, distinct_on and value_field of
+// its requests are checked against.
+type ColumnsByField map[string]string
+
+// Column returns the column a field reads
+func (m ColumnsByField) Column(field string) (string, bool) {
+	column, ok := m[field]
+	return column, ok
+}
+
+// Field returns the field reading a column, the first in sorted order if several do
+func (m ColumnsByField) Field(column string) (string, bool) {
+	return m.FieldsByColumn().Field(column)
+}
+
+// FieldsByColumn returns the reverse of the mapping
+func (m ColumnsByField) FieldsByColumn() FieldsByColumn {
+	reverse := make(FieldsByColumn, len(m))
+	for _, field := range m.Fields() {
+		column := m[field]
+		reverse[column] = append(reverse[column], field)
+	}
+	return reverse
+}
+
+// Fields returns the fields of the mapping, sorted
+func (m ColumnsByField) Fields() []string {
+	fields := make([]string, 0, len(m))
+	for field := range m {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Only returns the mapping of the given fields
+func (m ColumnsByField) Only(fields ...string) ColumnsByField {
+	only := make(ColumnsByField, len(fields))
+	for _, field := range fields {
+		if column, ok := m[field]; ok {
+			only[field] = column
+		}
+	}
+	return only
+}
+
+// Without returns the mapping of the fields other than the given ones
+func (m ColumnsByField) Without(fields ...string) ColumnsByField {
+	without := make(ColumnsByField, len(m))
+	for field, column := range m {
+		without[field] = column
+	}
+	for _, field := range fields {
+		delete(without, field)
+	}
+	return without
+}
+
+// unknownField returns the UnknownColumnError of a request field naming a field that isn't
+// in the mapping. A name that is the column of a field suggests that field.
+func (m ColumnsByField) unknownField(requestField, name string) error {
+	suggested, _ := m.Field(name)
+	return &UnknownColumnError{Field: requestField, Name: name, SuggestedField: suggested, ValidFields: m.Fields()}
+}
+
+// FieldsByColumn maps the ClickHouse columns of a table to the proto fields reading them,
+// sorted. Each table has one, <Table>FieldsByColumn, the reverse of its ColumnsByField.
+type FieldsByColumn map[string][]string
+
+// Field returns the field reading a column, the first in sorted order if several do
+func (m FieldsByColumn) Field(column string) (string, bool) {
+	fields := m[column]
+	if len(fields) == 0 {
+		return "", false
+	}
+	return fields[0], true
+}
+
 // OrderByField represents a parsed order by field with direction
 type OrderByField struct {
 	Field string
@@ -644,17 +1219,11 @@ type OrderByField struct {
 
 // ParseOrderBy parses an AIP-132 compliant order_by string
 // Format: "field1,field2 desc,field3"
-func ParseOrderBy(orderBy string, validFields []string) ([]OrderByField, error) {
+func ParseOrderBy(orderBy string, fields ColumnsByField) ([]OrderByField, error) {
 	if orderBy == "" {
 		return nil, nil
 	}
 
-	// Create a map of valid fields for quick lookup
-	validFieldMap := make(map[string]bool)
-	for _, f := range validFields {
-		validFieldMap[f] = true
-	}
-
 	var result []OrderByField
 
 	// Split by comma
@@ -684,15 +1253,15 @@ func ParseOrderBy(orderBy string, validFields []string) ([]OrderByField, error)
 		// Validate field name (only alphanumeric, underscore, and dots allowed)
 		validFieldRegex := regexp.MustCompile("^[a-zA-Z0-9_.]+$")
 		if !validFieldRegex.MatchString(field) {
-			return nil, fmt.Errorf("invalid field name: %s", field)
+			return nil, invalidFilter("order_by", "invalid field name: %s", field)
 		}
 
-		// Check if field is valid (if validFields provided)
-		if len(validFields) > 0 {
+		// Check if field is valid (if fields provided)
+		if len(fields) > 0 {
 			// For subfields like "address.street", check the base field
 			baseField := strings.Split(field, ".")[0]
-			if !validFieldMap[baseField] {
-				return nil, fmt.Errorf("invalid field for ordering: %s", field)
+			if _, ok := fields[baseField]; !ok {
+				return nil, fields.unknownField("order_by", field)
 			}
 		}
 
@@ -714,15 +1283,58 @@ func BuildOrderByClause(fields []OrderByField) string {
 	var parts []string
 	for _, f := range fields {
 		if f.Desc {
-			parts = append(parts, fmt.Sprintf("%s DESC", f.Field))
+			parts = append(parts, quoteFieldPath(f.Field)+" DESC")
 		} else {
-			parts = append(parts, f.Field)
+			parts = append(parts, quoteFieldPath(f.Field))
 		}
 	}
 
 	return " ORDER BY " + strings.Join(parts, ", ")
 }
 
+// ParseDistinctOn validates the distinct_on and limit_by fields of a List request and
+// returns the LIMIT BY option they select, or nil when distinct_on is empty
+func ParseDistinctOn(distinctOn []string, limitBy int32, fields ColumnsByField) (QueryOption, error) {
+	if limitBy < 0 {
+		return nil, invalidFilter("limit_by", "limit_by must be non-negative, got %d", limitBy)
+	}
+	if len(distinctOn) == 0 {
+		if limitBy > 0 {
+			return nil, invalidFilter("limit_by", "limit_by requires distinct_on")
+		}
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(distinctOn))
+	for _, field := range distinctOn {
+		if _, ok := fields[field]; !ok || !isValidColumnName(field) {
+			return nil, fmt.Errorf("invalid field for distinct_on: %w", fields.unknownField("distinct_on", field))
+		}
+		if seen[field] {
+			return nil, invalidFilter("distinct_on", "duplicate field in distinct_on: %s", field)
+		}
+		seen[field] = true
+	}
+
+	limit := uint32(1)
+	if limitBy > 0 {
+		limit = uint32(limitBy)
+	}
+	return WithLimitBy(limit, distinctOn...), nil
+}
+
+// ParseSample validates the sample field of a List request and returns the SAMPLE option it
+// selects, or nil to read every row
+func ParseSample(sample float64) (QueryOption, error) {
+	if !(sample >= 0 && sample <= 1) {
+		return nil, invalidFilter("sample", "sample must be between 0 and 1, got %v", sample)
+	}
+	if sample == 0 || sample == 1 {
+		return nil, nil
+	}
+	return WithSample(sample), nil
+}
+
 // validColumnNamePattern is compiled once for performance
 var validColumnNamePattern = regexp.MustCompile("^[a-zA-Z0-9_.]+$")
 
@@ -732,6 +1344,155 @@ func isValidColumnName(name string) bool {
 	return len(name) > 0 && len(name) < 128 && validColumnNamePattern.MatchString(name)
 }
 
+// QuoteIdentifier quotes a ClickHouse identifier with backticks, so that names which are
+// keywords (index, order) or hold spaces, dots or backticks read as a single identifier.
+// The generated builders pass every column through it.
+func QuoteIdentifier(name string) string {
+	name = strings.ReplaceAll(name, "\\", "\\\\")
+	return "\x60" + strings.ReplaceAll(name, "\x60", "\\\x60") + "\x60"
+}
+
+// quoteTable quotes a table name like QuoteIdentifier, qualified by the database when one
+// is set
+func quoteTable(database, table string) string {
+	if database == "" {
+		return QuoteIdentifier(table)
+	}
+	return QuoteIdentifier(database) + "." + QuoteIdentifier(table)
+}
+
+// quoteFieldPath quotes each part of a field path such as address.street
+func quoteFieldPath(path string) string {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		parts[i] = QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// buildFromClause builds the FROM clause with optional database, table alias, and FINAL.
+// The table alias "_t" is used to disambiguate column references in the WHERE clause
+// from column aliases in the SELECT clause (e.g., when SELECT has
+// "toUnixTimestamp64Micro(probe_date_time) AS probe_date_time", the WHERE clause
+// needs to reference "_t.probe_date_time" to get the original DateTime64 column)
+func buildFromClause(table string, opts *QueryOptions) string {
+	if opts.Tiers != nil {
+		return buildTieredFromClause(table, opts)
+	}
+
+	fromClause := quoteTable(opts.Database, table) + " AS _t"
+
+	// Add projection if specified
+	if opts.Projection != "" {
+		fromClause = fmt.Sprintf("%s PROJECTION %s", fromClause, opts.Projection)
+	}
+
+	if opts.AddFinal && opts.Snapshot == nil {
+		fromClause += " FINAL"
+	}
+
+	// SAMPLE follows FINAL
+	if opts.Sample > 0 && opts.Sample < 1 {
+		fromClause += " SAMPLE " + strconv.FormatFloat(opts.Sample, 'f', -1, 64)
+	}
+	return fromClause
+}
+
+// checkTieredSample rejects a sampled query reading rows from the archive of a tiered table.
+// SAMPLE only applies to the table itself, so sampled recent rows would be mixed with every
+// archived one.
+func checkTieredSample(opts *QueryOptions) error {
+	tiers := opts.Tiers
+	if tiers == nil || !(opts.Sample > 0 && opts.Sample < 1) || tiers.From >= tiers.cutoff {
+		return nil
+	}
+	return invalidFilter("sample", "sample can't be applied to the rows older than the retention, which are read from %s; filter %s to newer rows", tiers.Archive, tiers.Column)
+}
+
+// buildTieredFromClause builds the FROM clause of a tiered table: the table alone when the
+// query's bounds start at or after the cutoff, the archive alone when they end before it,
+// and otherwise a UNION ALL of both. FINAL, PROJECTION and SAMPLE apply to the table only.
+func buildTieredFromClause(table string, opts *QueryOptions) string {
+	tiers := opts.Tiers
+	recent := *opts
+	recent.Tiers = nil
+	if tiers.From >= tiers.cutoff {
+		return buildFromClause(table, &recent)
+	}
+
+	cutoff := fmt.Sprintf("fromUnixTimestamp(%d)", tiers.cutoff)
+	if tiers.Micro {
+		cutoff = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%d))", tiers.cutoff)
+	}
+	columns := make([]string, len(tiers.Columns))
+	for i, col := range tiers.Columns {
+		columns[i] = QuoteIdentifier(col)
+	}
+	columnList := strings.Join(columns, ", ")
+
+	archived := fmt.Sprintf("SELECT %s FROM %s WHERE %s < %s", columnList, tiers.Archive, QuoteIdentifier(tiers.Column), cutoff)
+	if tiers.To != 0 && tiers.To < tiers.cutoff {
+		return "(" + archived + ") AS _t"
+	}
+	current := fmt.Sprintf("SELECT %s FROM %s WHERE _t.%s >= %s", columnList, buildFromClause(table, &recent), QuoteIdentifier(tiers.Column), cutoff)
+	return fmt.Sprintf("(%s UNION ALL %s) AS _t", current, archived)
+}
+
+// buildWhereClause returns the WHERE clause of qb and the arguments of the query, with the
+// conditions of a snapshot. The arguments of the WITH clause come first and those of a
+// HAVING clause last. With a key, the snapshot keeps the rows that are the latest version of their
+// key as of the snapshot: the keys with a row matching qb are looked up first, then their
+// latest versions, which are returned if they match qb too. The lookups repeat qb's
+// conditions and the snapshot time, so their arguments are repeated for ? placeholders.
+func buildWhereClause(table string, opts *QueryOptions, qb *QueryBuilder) (string, []interface{}, error) {
+	snapshot := opts.Snapshot
+	if snapshot == nil {
+		return qb.GetWhereClause(), qb.GetArgs(), nil
+	}
+	// The snapshot time is bound after qb's arguments
+	asOf := fmt.Sprintf("fromUnixTimestamp(%s)", qb.formatVariable(qb.argCounter, snapshot.AsOf))
+	if snapshot.Micro {
+		asOf = fmt.Sprintf("fromUnixTimestamp64Micro(toInt64(%s))", qb.formatVariable(qb.argCounter, snapshot.AsOf))
+	}
+	version := "_t." + QuoteIdentifier(snapshot.Column)
+	written := fmt.Sprintf("%s <= %s", version, asOf)
+	conditions := append(append([]string{}, qb.whereConditions()...), written)
+	whereArgs, havingArgs := qb.clauseArgs()
+	args := append(append(append([]interface{}{}, qb.cteArgs...), whereArgs...), snapshot.AsOf)
+
+	if len(snapshot.Key) > 0 {
+		keys := make([]string, len(snapshot.Key))
+		for i, col := range snapshot.Key {
+			keys[i] = "_t." + QuoteIdentifier(col)
+		}
+		keyList := strings.Join(keys, ", ")
+		source := buildFromClause(table, &QueryOptions{Database: opts.Database, Tiers: opts.Tiers})
+		matching := strings.Join(conditions, " AND ")
+
+		in := "IN"
+		if snapshot.Global {
+			in = "GLOBAL IN"
+		}
+
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s, %s) %s (SELECT %s, max(%s) FROM %s WHERE %s AND (%s) %s (SELECT %s FROM %s WHERE %s) GROUP BY %s)",
+			keyList, version, in, keyList, version, source, written, keyList, in, keyList, source, matching, keyList))
+		if qb.options.VariableSubstitution == VariableSubstitutionStandard {
+			args = append(append(append(args, snapshot.AsOf), whereArgs...), snapshot.AsOf)
+		}
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), append(args, havingArgs...), nil
+}
+
+// capLimit caps the LIMIT of a query at maxQueryRows, including a limit of 0 (no LIMIT)
+func capLimit(limit uint32) uint32 {
+	if maxQueryRows > 0 && (limit == 0 || limit > maxQueryRows) {
+		return maxQueryRows
+	}
+	return limit
+}
+
 // BuildParameterizedQuery constructs the final parameterized query with explicit column selection
 func BuildParameterizedQuery(table string, columns []string, qb *QueryBuilder, orderByClause string, limit, offset uint32, options ...QueryOption) (SQLQuery, error) {
 	// Apply options
@@ -739,26 +1500,19 @@ func BuildParameterizedQuery(table string, columns []string, qb *QueryBuilder, o
 	for _, opt := range options {
 		opt(opts)
 	}
-
-	// Build FROM clause with optional database, table alias, and FINAL
-	// The table alias "_t" is used to disambiguate column references in the WHERE clause
-	// from column aliases in the SELECT clause (e.g., when SELECT has
-	// "toUnixTimestamp64Micro(probe_date_time) AS probe_date_time", the WHERE clause
-	// needs to reference "_t.probe_date_time" to get the original DateTime64 column)
-	var fromClause string
-	if opts.Database != "" {
-		fromClause = fmt.Sprintf("` + "`" + `%s` + "`" + `.%s AS _t", opts.Database, table)
-	} else {
-		fromClause = fmt.Sprintf("%s AS _t", table)
+	if err := checkTieredSample(opts); err != nil {
+		return SQLQuery{}, err
 	}
+	fromClause := buildFromClause(table, opts)
 
-	// Add projection if specified
-	if opts.Projection != "" {
-		fromClause = fmt.Sprintf("%s PROJECTION %s", fromClause, opts.Projection)
+	// Without GROUP BY there are no groups to filter
+	if len(qb.havingConditions()) > 0 {
+		return SQLQuery{}, fmt.Errorf("HAVING conditions need an aggregating query")
 	}
 
-	if opts.AddFinal {
-		fromClause += " FINAL"
+	// Use the caller-provided column set if any (e.g. a visibility profile)
+	if len(opts.Columns) > 0 {
+		columns = opts.Columns
 	}
 
 	// Validate and build column list
@@ -777,42 +1531,174 @@ func BuildParameterizedQuery(table string, columns []string, qb *QueryBuilder, o
 			if !isValidColumnName(col) {
 				return SQLQuery{}, fmt.Errorf("invalid column name: %s", col)
 			}
-			parts := strings.Split(col, ".")
-			escapedParts := make([]string, len(parts))
-			for i, part := range parts {
-				escapedParts[i] = fmt.Sprintf("` + "`" + `%s` + "`" + `", part)
-			}
-			escapedColumns = append(escapedColumns, strings.Join(escapedParts, "."))
+			escapedColumns = append(escapedColumns, quoteFieldPath(col))
 		} else {
-			// Simple column name - validate and escape it
-			if !isValidColumnName(col) {
-				return SQLQuery{}, fmt.Errorf("invalid column name: %s", col)
-			}
-			escapedColumns = append(escapedColumns, fmt.Sprintf("` + "`" + `%s` + "`" + `", col))
+			// Simple column name, which may be a keyword such as index
+			escapedColumns = append(escapedColumns, QuoteIdentifier(col))
 		}
 	}
 
 	columnList := strings.Join(escapedColumns, ", ")
-	query := fmt.Sprintf("SELECT %s FROM %s", columnList, fromClause)
+	query := qb.GetWithClause() + fmt.Sprintf("SELECT %s FROM %s", columnList, fromClause)
 
 	// Add WHERE clause
-	query += qb.GetWhereClause()
+	whereClause, args, err := buildWhereClause(table, opts, qb)
+	if err != nil {
+		return SQLQuery{}, err
+	}
+	query += whereClause
 
 	// Add ORDER BY clause
 	query += orderByClause
 
+	// Add LIMIT BY clause, which applies before LIMIT and OFFSET
+	if opts.LimitBy > 0 && len(opts.LimitByColumns) > 0 {
+		limitBy := make([]string, len(opts.LimitByColumns))
+		for i, col := range opts.LimitByColumns {
+			if !isValidColumnName(col) {
+				return SQLQuery{}, fmt.Errorf("invalid LIMIT BY column: %s", col)
+			}
+			limitBy[i] = quoteFieldPath(col)
+		}
+		query += fmt.Sprintf(" LIMIT %d BY %s", opts.LimitBy, strings.Join(limitBy, ", "))
+	}
+
 	// Add LIMIT and OFFSET
-	if limit > 0 {
+	if limit = capLimit(limit); limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", limit)
 		if offset > 0 {
 			query += fmt.Sprintf(" OFFSET %d", offset)
 		}
 	}
+	query += querySettings
+
+	return qb.sqlQuery(query, args), nil
+}
+
+// intervalUnits maps the units of a bucket width to ClickHouse interval units
+var intervalUnits = map[string]string{
+	"s": "SECOND",
+	"m": "MINUTE",
+	"h": "HOUR",
+	"d": "DAY",
+	"w": "WEEK",
+}
+
+// intervalPattern matches bucket widths such as "15m" or "1d"
+var intervalPattern = regexp.MustCompile("^([1-9][0-9]{0,5})([smhdw])$")
+
+// ParseInterval converts a bucket width such as "15m" into a ClickHouse interval
+// such as "INTERVAL 15 MINUTE"
+func ParseInterval(interval string) (string, error) {
+	match := intervalPattern.FindStringSubmatch(interval)
+	if match == nil {
+		return "", invalidFilter("interval", "expected a positive number and a unit (s, m, h, d or w), got %q", interval)
+	}
+	return fmt.Sprintf("INTERVAL %s %s", match[1], intervalUnits[match[2]]), nil
+}
+
+// BucketValues selects the aggregates of a numeric column returned for each bucket
+type BucketValues struct {
+	// Column is the aggregated column; without it only rows are counted
+	Column string
+	// Quantiles are the quantile levels returned, between 0 and 1
+	Quantiles []float64
+	// HistogramBins is the number of histogram bins returned, or 0 for no histogram
+	HistogramBins uint32
+}
+
+// ParseBucketValues validates the value_field, quantiles and histogram_bins fields of a
+// ListBuckets request. value_field names one of fields, whose column is aggregated.
+func ParseBucketValues(valueField string, quantiles []float64, histogramBins int32, fields ColumnsByField) (BucketValues, error) {
+	if valueField == "" {
+		if len(quantiles) > 0 || histogramBins != 0 {
+			return BucketValues{}, invalidFilter("value_field", "quantiles and histogram_bins require value_field")
+		}
+		return BucketValues{}, nil
+	}
+
+	column, ok := fields.Column(valueField)
+	if !ok {
+		return BucketValues{}, fmt.Errorf("invalid value_field: %w", fields.unknownField("value_field", valueField))
+	}
+	if len(quantiles) > ` + strconv.Itoa(maxBucketQuantiles) + ` {
+		return BucketValues{}, invalidFilter("quantiles", "at most ` + strconv.Itoa(maxBucketQuantiles) + ` quantiles can be requested, got %d", len(quantiles))
+	}
+	for _, level := range quantiles {
+		if !(level >= 0 && level <= 1) {
+			return BucketValues{}, invalidFilter("quantiles", "quantile levels must be between 0 and 1, got %v", level)
+		}
+	}
+	if histogramBins < 0 || histogramBins > ` + strconv.Itoa(maxBucketHistogramBins) + ` {
+		return BucketValues{}, invalidFilter("histogram_bins", "histogram_bins must be between 0 and ` + strconv.Itoa(maxBucketHistogramBins) + `, got %d", histogramBins)
+	}
+
+	return BucketValues{Column: column, Quantiles: quantiles, HistogramBins: uint32(histogramBins)}, nil
+}
+
+// BuildBucketQuery constructs a query counting the rows matching qb per interval of
+// timeColumn, oldest first. The columns are:
+//   - _start: the start of the interval as a Unix timestamp
+//   - _count: the number of rows
+//   - _min, _max: Nullable(Float64) extremes of values.Column, NULL without a column
+//   - _quantiles: Array(Float64) of the values.Quantiles levels, computed with quantiles
+//   - _histogram: Array(Tuple(lower Float64, upper Float64, count Float64)) of
+//     values.HistogramBins adaptive bins
+//
+// The aliases are prefixed so they never shadow a column referenced in the WHERE clause.
+// Conditions added to qb after Having filter the buckets by these aliases, e.g. _count.
+func BuildBucketQuery(table, timeColumn, interval string, values BucketValues, qb *QueryBuilder, limit uint32, options ...QueryOption) (SQLQuery, error) {
+	opts := &QueryOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if err := checkTieredSample(opts); err != nil {
+		return SQLQuery{}, err
+	}
+
+	if !strings.HasPrefix(interval, "INTERVAL ") {
+		return SQLQuery{}, fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	aggregates := []string{
+		"CAST(NULL AS Nullable(Float64)) AS _min",
+		"CAST(NULL AS Nullable(Float64)) AS _max",
+		"CAST([] AS Array(Float64)) AS _quantiles",
+		"CAST([] AS Array(Tuple(Float64, Float64, Float64))) AS _histogram",
+	}
+	if values.Column != "" {
+		value := fmt.Sprintf("toFloat64(_t.%s)", QuoteIdentifier(values.Column))
+		aggregates[0] = fmt.Sprintf("toNullable(min(%s)) AS _min", value)
+		aggregates[1] = fmt.Sprintf("toNullable(max(%s)) AS _max", value)
+		if len(values.Quantiles) > 0 {
+			levels := make([]string, len(values.Quantiles))
+			for i, level := range values.Quantiles {
+				if !(level >= 0 && level <= 1) {
+					return SQLQuery{}, fmt.Errorf("invalid quantile level: %v", level)
+				}
+				levels[i] = fmt.Sprintf("%v", level)
+			}
+			aggregates[2] = fmt.Sprintf("quantiles(%s)(%s) AS _quantiles", strings.Join(levels, ", "), value)
+		}
+		if values.HistogramBins > 0 {
+			aggregates[3] = fmt.Sprintf("histogram(%d)(%s) AS _histogram", values.HistogramBins, value)
+		}
+	}
+
+	query := qb.GetWithClause() + fmt.Sprintf("SELECT toUnixTimestamp(toStartOfInterval(_t.%s, %s)) AS _start, count() AS _count, %s FROM %s",
+		QuoteIdentifier(timeColumn), interval, strings.Join(aggregates, ", "), buildFromClause(table, opts))
+	whereClause, args, err := buildWhereClause(table, opts, qb)
+	if err != nil {
+		return SQLQuery{}, err
+	}
+	query += whereClause
+	query += " GROUP BY _start" + qb.GetHavingClause() + " ORDER BY _start"
+	if limit = capLimit(limit); limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	query += querySettings
 
-	return SQLQuery{
-		Query: query,
-		Args:  qb.GetArgs(),
-	}, nil
+	return qb.sqlQuery(query, args), nil
 }
 `)
 }
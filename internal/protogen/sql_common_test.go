@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -97,11 +98,13 @@ func TestBuildParameterizedQueryImplementation(t *testing.T) {
 	generatedCode := sb.String()
 
 	// Check that BuildParameterizedQuery uses opts.Database correctly
-	assert.Contains(t, generatedCode, "if opts.Database != \"\"",
+	assert.Contains(t, generatedCode, "if database == \"\"",
 		"Should check if database is provided")
-	// The generated code should have backticks around the database name and table alias
-	assert.Contains(t, generatedCode, "opts.Database, table)",
+	// The generated code should quote the database and table names and add a table alias
+	assert.Contains(t, generatedCode, "return QuoteIdentifier(database) + \".\" + QuoteIdentifier(table)",
 		"Should format with database when provided")
+	assert.Contains(t, generatedCode, "quoteTable(opts.Database, table) + \" AS _t\"",
+		"Should quote the table when the database isn't provided")
 	assert.Contains(t, generatedCode, "AS _t",
 		"Should add table alias for disambiguation")
 }
@@ -583,3 +586,123 @@ func TestWriteDateTimeFilterCases(t *testing.T) {
 		}
 	}
 }
+
+func TestLimitByGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{}
+	g.writeCommonSQLTypes(&sb)
+	g.writeCommonSQLFunctions(&sb)
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, "func WithLimitBy(limit uint32, columns ...string) QueryOption")
+	assert.Contains(t, generatedCode, "func ParseDistinctOn(distinctOn []string, limitBy int32, fields ColumnsByField) (QueryOption, error)")
+	assert.Contains(t, generatedCode, `return nil, invalidFilter("limit_by", "limit_by requires distinct_on")`)
+
+	// LIMIT BY goes between ORDER BY and LIMIT
+	orderBy := strings.Index(generatedCode, "query += orderByClause")
+	limitBy := strings.Index(generatedCode, `query += fmt.Sprintf(" LIMIT %d BY %s"`)
+	limit := strings.Index(generatedCode, `query += fmt.Sprintf(" LIMIT %d", limit)`)
+	assert.Less(t, orderBy, limitBy)
+	assert.Less(t, limitBy, limit)
+}
+
+func TestSampleGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{}
+	g.writeCommonSQLTypes(&sb)
+	g.writeCommonSQLFunctions(&sb)
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, "func WithSample(ratio float64) QueryOption")
+	assert.Contains(t, generatedCode, "func ParseSample(sample float64) (QueryOption, error)")
+
+	// SAMPLE goes after FINAL in the FROM clause
+	final := strings.Index(generatedCode, `fromClause += " FINAL"`)
+	sample := strings.Index(generatedCode, `fromClause += " SAMPLE " + strconv.FormatFloat(opts.Sample, 'f', -1, 64)`)
+	assert.Less(t, final, sample)
+}
+
+func TestLikeEscapingGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{}
+	g.writeCommonSQLFunctions(&sb)
+	g.writeStringFilterCases(&sb, "client", "\t")
+	g.writeNullableStringFilterCases(&sb, "client", "\t")
+	g.writeMapStringStringFilterCases(&sb, "labels", "\t")
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, `var likeEscaper = strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")`)
+	assert.Contains(t, generatedCode, "func EscapeLike(value string) string {")
+
+	// The literal filters escape their value, like and not_like keep their pattern
+	assert.Equal(t, 2, strings.Count(generatedCode, `qb.AddLikeCondition("client", "%" + EscapeLike(filter.Contains) + "%")`))
+	assert.Equal(t, 2, strings.Count(generatedCode, `qb.AddLikeCondition("client", EscapeLike(filter.StartsWith) + "%")`))
+	assert.Equal(t, 2, strings.Count(generatedCode, `qb.AddLikeCondition("client", "%" + EscapeLike(filter.EndsWith))`))
+	assert.Equal(t, 2, strings.Count(generatedCode, `qb.AddLikeCondition("client", filter.Like)`))
+	assert.Equal(t, 2, strings.Count(generatedCode, `qb.AddNotLikeCondition("client", filter.NotLike)`))
+	assert.Contains(t, generatedCode, `qb.AddMapKeyLikeCondition("labels", filter.KeyValue.Key, "%" + EscapeLike(kvFilter.Contains) + "%")`)
+	assert.Contains(t, generatedCode, `qb.AddMapKeyLikeCondition("labels", filter.KeyValue.Key, kvFilter.Like)`)
+}
+
+func TestServerParametersGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{config: &config.Config{}}
+	g.writeCommonSQLTypes(&sb)
+	g.writeCommonSQLFunctions(&sb)
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, "\tVariableSubstitutionNamed\n")
+	assert.Contains(t, generatedCode, `return fmt.Sprintf("{p%d:%s}", index, parameterType(value))`)
+	assert.Contains(t, generatedCode, "\tParameters map[string]string\n")
+	assert.Contains(t, generatedCode, `var parameterEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n")`)
+	assert.Contains(t, generatedCode, "return qb.sqlQuery(query, args), nil")
+	// Map keys are bound rather than quoted into the query
+	assert.Contains(t, generatedCode, "element := qb.mapElement(column, key)")
+	assert.NotContains(t, generatedCode, "['%s']")
+
+	// Builders use ? placeholders unless sql_parameters is server
+	assert.Equal(t, "NewQueryBuilder()", g.newQueryBuilder())
+	g.config.SQLParameters = config.SQLParametersServer
+	assert.Equal(t, "NewQueryBuilder(WithVariableSubstitution(VariableSubstitutionNamed))", g.newQueryBuilder())
+}
+
+func TestCTEGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{}
+	g.writeCommonSQLTypes(&sb)
+	g.writeCommonSQLFunctions(&sb)
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, "func (qb *QueryBuilder) AddCTE(name, sql string, args []interface{}) error {")
+	assert.Contains(t, generatedCode, "func (qb *QueryBuilder) AddCTECondition(column, operator, name string) error {")
+	assert.Contains(t, generatedCode, "func splitPlaceholders(sql string) []string {")
+	assert.Contains(t, generatedCode, `return "WITH " + strings.Join(ctes, ", ") + " "`)
+	// Both queries start with the WITH clause, and its ? arguments come first
+	assert.Contains(t, generatedCode, `query := qb.GetWithClause() + fmt.Sprintf("SELECT %s FROM %s", columnList, fromClause)`)
+	assert.Contains(t, generatedCode, `query := qb.GetWithClause() + fmt.Sprintf("SELECT toUnixTimestamp(`)
+	assert.Contains(t, generatedCode, "return append(append([]interface{}{}, qb.cteArgs...), qb.args...)")
+	assert.Contains(t, generatedCode, "args := append(append(append([]interface{}{}, qb.cteArgs...), whereArgs...), snapshot.AsOf)")
+}
+
+func TestColumnsByFieldGeneration(t *testing.T) {
+	var sb strings.Builder
+	g := &Generator{}
+	g.writeCommonSQLFunctions(&sb)
+
+	generatedCode := sb.String()
+
+	assert.Contains(t, generatedCode, "type ColumnsByField map[string]string")
+	assert.Contains(t, generatedCode, "func (m ColumnsByField) Column(field string) (string, bool)")
+	assert.Contains(t, generatedCode, "func (m ColumnsByField) Field(column string) (string, bool)")
+	assert.Contains(t, generatedCode, "type FieldsByColumn map[string][]string")
+	assert.Contains(t, generatedCode, "func (m ColumnsByField) FieldsByColumn() FieldsByColumn")
+	assert.Contains(t, generatedCode, "func ParseOrderBy(orderBy string, fields ColumnsByField) ([]OrderByField, error)")
+	// Columns named instead of their fields are pointed to the field
+	assert.Contains(t, generatedCode, `return fmt.Sprintf("%s is a column, use its field %s", e.Name, e.SuggestedField)`)
+	assert.Contains(t, generatedCode, `return BucketValues{Column: column, Quantiles: quantiles, HistogramBins: uint32(histogramBins)}, nil`)
+}
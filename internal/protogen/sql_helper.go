@@ -3,8 +3,10 @@ package protogen
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
@@ -28,20 +30,65 @@ const (
 // GenerateSQLHelpers generates SQL query builder helpers for all tables
 func (g *Generator) GenerateSQLHelpers(tables []*clickhouse.Table) error {
 	for _, table := range tables {
-		// Skip tables with no columns (likely non-existent or failed to load)
-		if len(table.Columns) == 0 {
-			g.log.WithField("table", table.Name).Warn("Skipping SQL helper generation for table with no columns")
-			continue
-		}
-		// Skip tables without sorting keys (no service/request types generated for them)
-		if len(table.SortingKey) == 0 {
-			g.log.WithField("table", table.Name).Debug("Skipping SQL helper generation for table without sorting key")
+		if !g.hasSQLHelper(table) {
 			continue
 		}
 		if err := g.generateSQLHelper(table); err != nil {
 			return err
 		}
 	}
+	return g.generateSQLSharedFiles(tables)
+}
+
+// hasSQLHelper reports whether a table gets SQL helpers, logging why not
+func (g *Generator) hasSQLHelper(table *clickhouse.Table) bool {
+	// Skip tables with no columns (likely non-existent or failed to load)
+	if len(table.Columns) == 0 {
+		g.log.WithField("table", table.Name).Warn("Skipping SQL helper generation for table with no columns")
+		return false
+	}
+	// Skip tables without sorting keys (no service/request types generated for them)
+	if len(table.SortingKey) == 0 {
+		g.log.WithField("table", table.Name).Debug("Skipping SQL helper generation for table without sorting key")
+		return false
+	}
+	return true
+}
+
+// generateSQLSharedFiles generates the SQL helper files shared by all tables
+func (g *Generator) generateSQLSharedFiles(tables []*clickhouse.Table) error {
+	// Generate the big.Int conversions of bigint_to_string fields
+	if g.hasBigIntColumns(tables) {
+		if err := g.GenerateBigIntHelpers(); err != nil {
+			return err
+		}
+	}
+	// Generate the unit conversion helpers if enabled
+	if g.config.UnitHelpers {
+		if err := g.GenerateUnitHelpers(); err != nil {
+			return err
+		}
+	}
+	// Generate the ResponseMeta helpers of List responses if enabled
+	if g.responseMetaEnabled() {
+		if err := g.GenerateResponseMetaHelpers(); err != nil {
+			return err
+		}
+	}
+	// Generate the statuses of rejected requests, whose details are in common.proto
+	if g.config.Emits(config.EmitServices) {
+		if err := g.GenerateErrorStatusHelpers(); err != nil {
+			return err
+		}
+	}
+	// Generate the dispatcher of the request validators
+	if err := g.GenerateRequestValidation(tables); err != nil {
+		return err
+	}
+	// Generate the RequestHash methods of the request messages
+	if err := g.GenerateRequestHashing(tables); err != nil {
+		return err
+	}
 	// Generate the common SQL helper file
 	return g.GenerateSQLCommon()
 }
@@ -51,38 +98,101 @@ func (g *Generator) generateSQLHelper(table *clickhouse.Table) error {
 	sb := &strings.Builder{}
 
 	// Write package header
-	sb.WriteString("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n")
-	fmt.Fprintf(sb, "// SQL query builder for %s\n\n", table.Name)
-	sb.WriteString("package ")
-
-	// Extract package name from go_package
-	pkgName := "main"
-	if g.config.GoPackage != "" {
-		parts := strings.Split(g.config.GoPackage, "/")
-		pkgName = parts[len(parts)-1]
-		pkgName = strings.ReplaceAll(pkgName, "-", "_")
-	}
-	sb.WriteString(pkgName)
-	sb.WriteString("\n\n")
+	g.writeSQLFileHeader(sb, "SQL query builder for "+table.Name)
 
 	// Write imports
 	sb.WriteString("import (\n")
 	sb.WriteString("\t\"fmt\"\n")
+	columns := g.bigIntColumns(table)
+	if len(columns) > 0 {
+		sb.WriteString("\t\"math/big\"\n")
+	}
+	if g.upsertVersionFor(table) != nil {
+		sb.WriteString("\t\"time\"\n")
+	}
+	for _, col := range columns {
+		if col.IsNullable && !col.IsArray && g.config.Syntax != config.SyntaxEdition2023 {
+			sb.WriteString("\n\t\"google.golang.org/protobuf/types/known/wrapperspb\"\n")
+			break
+		}
+	}
 	sb.WriteString(")\n\n")
 
+	// Generate the mapping of the fields to the columns they read
+	g.writeColumnsByField(sb, table)
+
+	// Generate the validators of the request messages, which the builders call first
+	g.writeRequestValidators(sb, table)
+
 	// Generate the List SQL builder function
 	g.writeSQLBuilderFunction(sb, table)
 
 	// Generate the Get SQL builder function
 	g.writeGetSQLBuilderFunction(sb, table)
 
+	// Generate the ListBuckets SQL builder function of time-series tables
+	g.writeBucketsSQLBuilderFunction(sb, table)
+
+	// Generate the Insert and Upsert SQL builder functions of tables accepting writes
+	g.writeInsertSQLBuilderFunction(sb, table)
+	g.writeUpsertSQLBuilderFunction(sb, table)
+
+	// Generate the Delete and Update SQL builder functions when mutations are enabled
+	g.writeMutationSQLBuilderFunctions(sb, table)
+
+	// Generate column sets for visibility profiles
+	g.writeVisibilityColumnSets(sb, table)
+
+	// Generate big.Int accessors of bigint_to_string fields
+	g.writeBigIntAccessors(sb, table)
+
 	// Write to file
-	filename := filepath.Join(g.config.OutputDir, fmt.Sprintf("%s.go", table.Name))
+	filename := filepath.Join(g.goOutputDir(), fmt.Sprintf("%s_sql.go", table.Name))
 	if err := g.writeFile(filename, sb.String()); err != nil {
 		return err
 	}
 
 	g.log.WithField("file", filename).Info("Generated SQL helper file")
+	return g.removeLegacySQLHelper(table)
+}
+
+// writeSQLFileHeader writes the optional build constraint, the generated-code marker
+// and the package clause of a SQL helper file
+func (g *Generator) writeSQLFileHeader(sb *strings.Builder, description string) {
+	if g.config.SQLBuildTag != "" {
+		fmt.Fprintf(sb, "//go:build %s\n\n", g.config.SQLBuildTag)
+	}
+	sb.WriteString("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n")
+	fmt.Fprintf(sb, "// %s\n\n", description)
+	fmt.Fprintf(sb, "package %s\n\n", g.goPackageName())
+}
+
+// removeLegacySQLHelper deletes the <table>.go file written by earlier versions, which
+// would redeclare the builders in <table>_sql.go. Files without the generated marker
+// for the table are left alone.
+func (g *Generator) removeLegacySQLHelper(table *clickhouse.Table) error {
+	if g.output != nil {
+		return nil
+	}
+
+	filename := filepath.Join(g.goOutputDir(), table.Name+".go")
+	content, err := os.ReadFile(filepath.Clean(filename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read legacy SQL helper file: %w", err)
+	}
+
+	marker := "// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n// SQL query builder for " + table.Name + "\n"
+	if !strings.HasPrefix(string(content), marker) {
+		return nil
+	}
+
+	if err := os.Remove(filename); err != nil {
+		return fmt.Errorf("failed to remove legacy SQL helper file: %w", err)
+	}
+	g.log.WithField("file", filename).Info("Removed legacy SQL helper file")
 	return nil
 }
 
@@ -170,19 +280,31 @@ func getDefaultValueForType(baseType string) string {
 //nolint:gocyclo // High complexity is inherent to type mapping logic
 func getSelectColumnExpression(col *clickhouse.Column, tableName string, convConfig *config.ConversionConfig) string {
 	hasNullable := hasNullableArrayElements(col)
+	name := quoteIdentifier(col.Name)
 
 	// PRIORITY 1: Check if this Int64/UInt64 should be converted to string for JavaScript precision
 	if (col.BaseType == typeUInt64 || col.BaseType == typeInt64) && convConfig.ShouldConvertToString(tableName, col.Name) {
 		if col.IsArray {
 			if hasNullable {
 				// Array(Nullable(Int64/UInt64)) → Array(String) with NULL handling
-				return fmt.Sprintf("arrayMap(x -> toString(coalesce(x, 0)), `%s`) AS `%s`", col.Name, col.Name)
+				return fmt.Sprintf("arrayMap(x -> toString(coalesce(x, 0)), %s) AS %s", name, name)
 			}
 			// Array(Int64/UInt64) → Array(String)
-			return fmt.Sprintf("arrayMap(x -> toString(x), `%s`) AS `%s`", col.Name, col.Name)
+			return fmt.Sprintf("arrayMap(x -> toString(x), %s) AS %s", name, name)
 		}
 		// Regular Int64/UInt64 → String
-		return fmt.Sprintf("toString(`%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("toString(%s) AS %s", name, name)
+	}
+
+	// UInt8 columns holding booleans are returned as Bool
+	if isBoolColumn(col, tableName, convConfig) {
+		if col.IsArray {
+			if hasNullable {
+				return fmt.Sprintf("arrayMap(x -> toBool(coalesce(x, 0)), %s) AS %s", name, name)
+			}
+			return fmt.Sprintf("arrayMap(x -> toBool(x), %s) AS %s", name, name)
+		}
+		return fmt.Sprintf("toBool(%s) AS %s", name, name)
 	}
 
 	// Handle FixedString types - convert zero-byte strings to NULL
@@ -190,7 +312,7 @@ func getSelectColumnExpression(col *clickhouse.Column, tableName string, convCon
 	// Check BaseType first (handles Nullable(FixedString(N))), then parse full Type for length
 	if col.BaseType == "FixedString" {
 		if isFixed, length := IsFixedString(col.Type); isFixed {
-			return fmt.Sprintf("NULLIF(`%s`, repeat('\\x00', %d)) AS `%s`", col.Name, length, col.Name)
+			return fmt.Sprintf("NULLIF(%s, repeat('\\x00', %d)) AS %s", name, length, name)
 		}
 	}
 
@@ -198,51 +320,51 @@ func getSelectColumnExpression(col *clickhouse.Column, tableName string, convCon
 	if col.IsArray && col.BaseType == clickhouseDateTime {
 		if hasNullable {
 			defVal := getDefaultValueForType(col.BaseType)
-			return fmt.Sprintf("arrayMap(x -> toUnixTimestamp(coalesce(x, %s)), `%s`) AS `%s`", defVal, col.Name, col.Name)
+			return fmt.Sprintf("arrayMap(x -> toUnixTimestamp(coalesce(x, %s)), %s) AS %s", defVal, name, name)
 		}
-		return fmt.Sprintf("arrayMap(x -> toUnixTimestamp(x), `%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("arrayMap(x -> toUnixTimestamp(x), %s) AS %s", name, name)
 	}
 	if col.IsArray && col.BaseType == clickhouseDateTime64 {
 		if hasNullable {
 			defVal := getDefaultValueForType(col.BaseType)
-			return fmt.Sprintf("arrayMap(x -> toUnixTimestamp64Micro(coalesce(x, %s)), `%s`) AS `%s`", defVal, col.Name, col.Name)
+			return fmt.Sprintf("arrayMap(x -> toUnixTimestamp64Micro(coalesce(x, %s)), %s) AS %s", defVal, name, name)
 		}
-		return fmt.Sprintf("arrayMap(x -> toUnixTimestamp64Micro(x), `%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("arrayMap(x -> toUnixTimestamp64Micro(x), %s) AS %s", name, name)
 	}
 
 	// Handle Array(Date) and Array(Date32) types with arrayMap transformation
 	if col.IsArray && (col.BaseType == clickhouseDate || col.BaseType == clickhouseDate32) {
 		if hasNullable {
 			defVal := getDefaultValueForType(col.BaseType)
-			return fmt.Sprintf("arrayMap(x -> toString(coalesce(x, %s)), `%s`) AS `%s`", defVal, col.Name, col.Name)
+			return fmt.Sprintf("arrayMap(x -> toString(coalesce(x, %s)), %s) AS %s", defVal, name, name)
 		}
-		return fmt.Sprintf("arrayMap(x -> toString(x), `%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("arrayMap(x -> toString(x), %s) AS %s", name, name)
 	}
 
 	// Handle Array(UInt8) and Array(UInt16) types with arrayMap transformation
 	if col.IsArray && (col.BaseType == clickhouseUInt8 || col.BaseType == clickhouseUInt16) {
 		if hasNullable {
-			return fmt.Sprintf("arrayMap(x -> toUInt32(coalesce(x, 0)), `%s`) AS `%s`", col.Name, col.Name)
+			return fmt.Sprintf("arrayMap(x -> toUInt32(coalesce(x, 0)), %s) AS %s", name, name)
 		}
-		return fmt.Sprintf("arrayMap(x -> toUInt32(x), `%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("arrayMap(x -> toUInt32(x), %s) AS %s", name, name)
 	}
 
 	// Handle regular DateTime types
 	if col.BaseType == clickhouseDateTime {
-		return fmt.Sprintf("toUnixTimestamp(`%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("toUnixTimestamp(%s) AS %s", name, name)
 	}
 	if col.BaseType == clickhouseDateTime64 {
-		return fmt.Sprintf("toUnixTimestamp64Micro(`%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("toUnixTimestamp64Micro(%s) AS %s", name, name)
 	}
 
 	// Handle Date and Date32 types (convert to string for Go scanning)
 	if col.BaseType == clickhouseDate || col.BaseType == clickhouseDate32 {
-		return fmt.Sprintf("toString(`%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("toString(%s) AS %s", name, name)
 	}
 
 	// Handle UInt8 and UInt16 types (convert to UInt32 for Go scanning)
 	if col.BaseType == clickhouseUInt8 || col.BaseType == clickhouseUInt16 {
-		return fmt.Sprintf("toUInt32(`%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("toUInt32(%s) AS %s", name, name)
 	}
 
 	// Handle Decimal types (convert to string to preserve precision)
@@ -251,35 +373,44 @@ func getSelectColumnExpression(col *clickhouse.Column, tableName string, convCon
 		col.BaseType == clickhouseDecimal256 {
 		if col.IsArray {
 			if hasNullable {
-				return fmt.Sprintf("arrayMap(x -> toString(coalesce(x, 0)), `%s`) AS `%s`", col.Name, col.Name)
+				return fmt.Sprintf("arrayMap(x -> toString(coalesce(x, 0)), %s) AS %s", name, name)
 			}
-			return fmt.Sprintf("arrayMap(x -> toString(x), `%s`) AS `%s`", col.Name, col.Name)
+			return fmt.Sprintf("arrayMap(x -> toString(x), %s) AS %s", name, name)
 		}
-		return fmt.Sprintf("toString(`%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("toString(%s) AS %s", name, name)
 	}
 
 	// Handle large integer types that need string conversion
 	if needsStringConversion(col) {
 		if col.IsArray {
 			if hasNullable {
-				return fmt.Sprintf("arrayMap(x -> toString(coalesce(x, 0)), `%s`) AS `%s`", col.Name, col.Name)
+				return fmt.Sprintf("arrayMap(x -> toString(coalesce(x, 0)), %s) AS %s", name, name)
 			}
-			return fmt.Sprintf("arrayMap(x -> toString(x), `%s`) AS `%s`", col.Name, col.Name)
+			return fmt.Sprintf("arrayMap(x -> toString(x), %s) AS %s", name, name)
 		}
-		return fmt.Sprintf("toString(`%s`) AS `%s`", col.Name, col.Name)
+		return fmt.Sprintf("toString(%s) AS %s", name, name)
 	}
 
 	// Handle any remaining arrays with nullable elements that don't need type conversion
 	// but still need NULL handling (e.g., Array(Nullable(UInt64)))
 	if col.IsArray && hasNullable {
 		defVal := getDefaultValueForType(col.BaseType)
-		return fmt.Sprintf("arrayMap(x -> coalesce(x, %s), `%s`) AS `%s`", defVal, col.Name, col.Name)
+		return fmt.Sprintf("arrayMap(x -> coalesce(x, %s), %s) AS %s", defVal, name, name)
 	}
 
 	// Default: return column name as-is
 	return col.Name
 }
 
+// newQueryBuilder returns the NewQueryBuilder call of the generated builders, with the
+// placeholder style sql_parameters selects and the given options
+func (g *Generator) newQueryBuilder(options ...string) string {
+	if g.config.SQLParameters == config.SQLParametersServer {
+		options = append([]string{"WithVariableSubstitution(VariableSubstitutionNamed)"}, options...)
+	}
+	return "NewQueryBuilder(" + strings.Join(options, ", ") + ")"
+}
+
 // writeSQLBuilderFunction generates the SQL query builder function for a List request
 func (g *Generator) writeSQLBuilderFunction(sb *strings.Builder, table *clickhouse.Table) {
 	messageName := getProtocMessageName(table.Name)
@@ -287,6 +418,8 @@ func (g *Generator) writeSQLBuilderFunction(sb *strings.Builder, table *clickhou
 
 	// Write function signature - now returns SQLQuery and accepts query options
 	fmt.Fprintf(sb, "// BuildList%sQuery constructs a parameterized SQL query from a List%sRequest\n", messageName, messageName)
+	g.writeTopologyComment(sb, table)
+	g.writeTiersComment(sb, table)
 	if len(table.Projections) > 0 {
 		fmt.Fprintf(sb, "//\n")
 		fmt.Fprintf(sb, "// Available projections:\n")
@@ -300,14 +433,24 @@ func (g *Generator) writeSQLBuilderFunction(sb *strings.Builder, table *clickhou
 		fmt.Fprintf(sb, "//\n")
 		fmt.Fprintf(sb, "// Use WithProjection() option to select a specific projection.\n")
 	}
-	fmt.Fprintf(sb, "func BuildList%sQuery(req *%s, options ...QueryOption) (SQLQuery, error) {\n", messageName, requestType)
+	tenant, _ := g.tenantScopeFor(table)
+	if tenant != nil {
+		fmt.Fprintf(sb, "//\n")
+		fmt.Fprintf(sb, "// The query is always scoped to tenant via %s; it must come from the caller's authorization, not the request.\n", tenant.column)
+	}
+	g.writeBuilderSignature(sb, table, "List", requestType, tenant)
 
-	// Write primary key validation - check base table and projections
-	g.writePrimaryKeyValidation(sb, table)
+	// Validate the primary key group, filters and page size
+	writeValidateRequestCall(sb, requestType)
 
 	// Write query building logic with QueryBuilder
 	fmt.Fprintf(sb, "\t// Build query using QueryBuilder\n")
-	fmt.Fprintf(sb, "\tqb := NewQueryBuilder()\n\n")
+	fmt.Fprintf(sb, "\tqb := %s\n\n", g.newQueryBuilder())
+
+	// Mandatory tenant condition comes before any user-provided filters
+	writeTenantCondition(sb, tenant)
+	g.writeDefaultWhereCondition(sb, table, true)
+	g.writeLabelCondition(sb, table)
 
 	// Get column map for type information
 	columnMap := make(map[string]*clickhouse.Column)
@@ -321,13 +464,6 @@ func (g *Generator) writeSQLBuilderFunction(sb *strings.Builder, table *clickhou
 
 	// Build final query
 	fmt.Fprintf(sb, "\t// Handle pagination per AIP-132\n")
-	fmt.Fprintf(sb, "\t// Validate page size\n")
-	fmt.Fprintf(sb, "\tif req.PageSize < 0 {\n")
-	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"page_size must be non-negative, got %%d\", req.PageSize)\n")
-	fmt.Fprintf(sb, "\t}\n")
-	fmt.Fprintf(sb, "\tif req.PageSize > %d {\n", g.config.MaxPageSize)
-	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"page_size must not exceed %%d, got %%d\", %d, req.PageSize)\n", g.config.MaxPageSize)
-	fmt.Fprintf(sb, "\t}\n\n")
 	fmt.Fprintf(sb, "\tvar limit, offset uint32\n")
 	fmt.Fprintf(sb, "\tlimit = 100 // Default page size\n")
 	fmt.Fprintf(sb, "\tif req.PageSize > 0 {\n")
@@ -336,7 +472,7 @@ func (g *Generator) writeSQLBuilderFunction(sb *strings.Builder, table *clickhou
 	fmt.Fprintf(sb, "\tif req.PageToken != \"\" {\n")
 	fmt.Fprintf(sb, "\t\tdecodedOffset, err := DecodePageToken(req.PageToken)\n")
 	fmt.Fprintf(sb, "\t\tif err != nil {\n")
-	fmt.Fprintf(sb, "\t\t\treturn SQLQuery{}, fmt.Errorf(\"invalid page_token: %%w\", err)\n")
+	fmt.Fprintf(sb, "\t\t\treturn SQLQuery{}, &PageTokenExpiredError{PageToken: req.PageToken, Err: err}\n")
 	fmt.Fprintf(sb, "\t\t}\n")
 	fmt.Fprintf(sb, "\t\toffset = decodedOffset\n")
 	fmt.Fprintf(sb, "\t}\n\n")
@@ -345,16 +481,7 @@ func (g *Generator) writeSQLBuilderFunction(sb *strings.Builder, table *clickhou
 	fmt.Fprintf(sb, "\t// Handle custom ordering if provided\n")
 	fmt.Fprintf(sb, "\tvar orderByClause string\n")
 	fmt.Fprintf(sb, "\tif req.OrderBy != \"\" {\n")
-	// Get all valid column names for validation
-	fmt.Fprintf(sb, "\t\tvalidFields := []string{")
-	for i, col := range table.Columns {
-		if i > 0 {
-			fmt.Fprintf(sb, ", ")
-		}
-		fmt.Fprintf(sb, "\"%s\"", col.Name)
-	}
-	fmt.Fprintf(sb, "}\n")
-	fmt.Fprintf(sb, "\t\torderFields, err := ParseOrderBy(req.OrderBy, validFields)\n")
+	fmt.Fprintf(sb, "\t\torderFields, err := ParseOrderBy(req.OrderBy, %s)\n", g.orderableFields(table))
 	fmt.Fprintf(sb, "\t\tif err != nil {\n")
 	fmt.Fprintf(sb, "\t\t\treturn SQLQuery{}, fmt.Errorf(\"invalid order_by: %%w\", err)\n")
 	fmt.Fprintf(sb, "\t\t}\n")
@@ -362,36 +489,60 @@ func (g *Generator) writeSQLBuilderFunction(sb *strings.Builder, table *clickhou
 	fmt.Fprintf(sb, "\t} else {\n")
 	if len(table.SortingKey) > 0 {
 		fmt.Fprintf(sb, "\t\t// Default sorting by primary key\n")
-		fmt.Fprintf(sb, "\t\torderByClause = \" ORDER BY ")
-		for i, key := range table.SortingKey {
-			if i > 0 {
-				fmt.Fprintf(sb, " + \", ")
-			}
-			fmt.Fprintf(sb, "%s\"", key)
-		}
-		fmt.Fprintf(sb, "\n")
+		fmt.Fprintf(sb, "\t\torderByClause = %q\n", sortingKeyClause(table))
 	} else {
 		fmt.Fprintf(sb, "\t\t// No default sorting (table has no primary key)\n")
 		fmt.Fprintf(sb, "\t\torderByClause = \"\"\n")
 	}
 	fmt.Fprintf(sb, "\t}\n\n")
 
+	// LIMIT BY of the request, before the caller's options so those can override it
+	fmt.Fprintf(sb, "\t// Handle distinct_on and limit_by (LIMIT n BY)\n")
+	fmt.Fprintf(sb, "\tlimitBy, err := ParseDistinctOn(req.DistinctOn, req.LimitBy, %s)\n", g.orderableFields(table))
+	fmt.Fprintf(sb, "\tif err != nil {\n")
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, err\n")
+	fmt.Fprintf(sb, "\t}\n")
+	fmt.Fprintf(sb, "\tif limitBy != nil {\n")
+	fmt.Fprintf(sb, "\t\toptions = append([]QueryOption{limitBy}, options...)\n")
+	fmt.Fprintf(sb, "\t}\n\n")
+
+	if table.SamplingKey != "" {
+		fmt.Fprintf(sb, "\t// Handle sample (SAMPLE)\n")
+		fmt.Fprintf(sb, "\tsample, err := ParseSample(req.Sample)\n")
+		fmt.Fprintf(sb, "\tif err != nil {\n")
+		fmt.Fprintf(sb, "\t\treturn SQLQuery{}, err\n")
+		fmt.Fprintf(sb, "\t}\n")
+		fmt.Fprintf(sb, "\tif sample != nil {\n")
+		fmt.Fprintf(sb, "\t\toptions = append([]QueryOption{sample}, options...)\n")
+		fmt.Fprintf(sb, "\t}\n\n")
+	}
+	if column := g.snapshotColumn(table); column != nil {
+		g.writeSnapshotOption(sb, table, column)
+	}
+	g.writeTiersOption(sb, table, false)
+	g.writeTopologyOption(sb, table)
+
 	// Build column list for explicit selection
 	fmt.Fprintf(sb, "\t// Build column list\n")
+	g.writeSelectColumns(sb, table)
+	fmt.Fprintf(sb, "\treturn BuildParameterizedQuery(%s, columns, qb, orderByClause, limit, offset, options...)\n", g.queryTableArg(table))
+	fmt.Fprintf(sb, "}\n")
+}
+
+// writeSelectColumns writes the explicit SELECT column list, applying column masks
+func (g *Generator) writeSelectColumns(sb *strings.Builder, table *clickhouse.Table) {
 	fmt.Fprintf(sb, "\tcolumns := []string{")
-	for i, col := range table.Columns {
+	for i, colExpr := range g.selectColumnExpressions(table) {
 		if i > 0 {
 			fmt.Fprintf(sb, ", ")
 		}
-		colExpr := getSelectColumnExpression(&col, table.Name, &g.config.Conversion)
-		fmt.Fprintf(sb, "\"%s\"", colExpr)
+		fmt.Fprintf(sb, "%q", colExpr)
 	}
 	fmt.Fprintf(sb, "}\n\n")
-	fmt.Fprintf(sb, "\treturn BuildParameterizedQuery(\"%s\", columns, qb, orderByClause, limit, offset, options...)\n", table.Name)
-	fmt.Fprintf(sb, "}\n")
 }
 
-// writePrimaryKeyValidation writes validation to ensure at least one primary key is provided
+// writePrimaryKeyValidation writes the check of the primary_key required group of a request
+// validator, ensuring at least one primary key is provided
 func (g *Generator) writePrimaryKeyValidation(sb *strings.Builder, table *clickhouse.Table) {
 	// Collect all primary keys from base table and projections
 	allPrimaryKeys := make(map[string]bool)
@@ -425,19 +576,21 @@ func (g *Generator) writePrimaryKeyValidation(sb *strings.Builder, table *clickh
 
 	// Build the validation condition with sorted keys
 	conditions := make([]string, 0, len(keyNames))
+	fieldNames := make([]string, 0, len(keyNames))
 	for _, key := range keyNames {
-		fieldName := SanitizeName(key)
+		fieldName := g.fieldName(table.Name, key)
 		conditions = append(conditions, fmt.Sprintf("req.%s == nil", ToPascalCase(fieldName)))
+		fieldNames = append(fieldNames, fieldName)
 	}
 
 	if len(conditions) == 1 {
 		// Only one primary key exists
 		fmt.Fprintf(sb, "\tif %s {\n", conditions[0])
-		fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"primary key field %s is required\")\n", keyNames[0])
+		fmt.Fprintf(sb, "\t\treturn invalidFilter(%q, \"primary key field %s is required\")\n", fieldNames[0], fieldNames[0])
 	} else {
 		// Multiple primary keys exist, at least one must be provided
 		fmt.Fprintf(sb, "\tif %s {\n", strings.Join(conditions, " && "))
-		fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"at least one primary key field is required: %s\")\n", strings.Join(keyNames, ", "))
+		fmt.Fprintf(sb, "\t\treturn invalidFilter(%q, \"at least one primary key field is required: %s\")\n", fieldNames[0], strings.Join(fieldNames, ", "))
 	}
 	fmt.Fprintf(sb, "\t}\n\n")
 }
@@ -449,94 +602,56 @@ func (g *Generator) writeGetSQLBuilderFunction(sb *strings.Builder, table *click
 
 	// Write function signature with query options
 	fmt.Fprintf(sb, "\n// BuildGet%sQuery constructs a parameterized SQL query from a Get%sRequest\n", messageName, messageName)
-	fmt.Fprintf(sb, "func BuildGet%sQuery(req *%s, options ...QueryOption) (SQLQuery, error) {\n", messageName, requestType)
+	g.writeTopologyComment(sb, table)
+	g.writeTiersComment(sb, table)
+	tenant, _ := g.tenantScopeFor(table)
+	g.writeBuilderSignature(sb, table, "Get", requestType, tenant)
 
 	// Check if table has sorting keys
 	if len(table.SortingKey) == 0 {
 		// No sorting key, generate simple query without primary key
 		fmt.Fprintf(sb, "\t// Table has no primary key\n")
-		fmt.Fprintf(sb, "\tqb := NewQueryBuilder()\n\n")
+		fmt.Fprintf(sb, "\tqb := %s\n\n", g.newQueryBuilder())
+		g.writeDefaultWhereCondition(sb, table, false)
+		g.writeTiersOption(sb, table, true)
+		g.writeTopologyOption(sb, table)
 		// Build column list for explicit selection
 		fmt.Fprintf(sb, "\t// Build column list\n")
-		fmt.Fprintf(sb, "\tcolumns := []string{")
-		for i, col := range table.Columns {
-			if i > 0 {
-				fmt.Fprintf(sb, ", ")
-			}
-			colExpr := getSelectColumnExpression(&col, table.Name, &g.config.Conversion)
-			fmt.Fprintf(sb, "\"%s\"", colExpr)
-		}
-		fmt.Fprintf(sb, "}\n\n")
+		g.writeSelectColumns(sb, table)
 		fmt.Fprintf(sb, "\t// Return single record\n")
-		fmt.Fprintf(sb, "\treturn BuildParameterizedQuery(\"%s\", columns, qb, \"\", 1, 0, options...)\n", table.Name)
+		fmt.Fprintf(sb, "\treturn BuildParameterizedQuery(%s, columns, qb, \"\", 1, 0, options...)\n", g.queryTableArg(table))
 		fmt.Fprintf(sb, "}\n")
 		return
 	}
 
 	// Get primary key info
 	primaryKey := table.SortingKey[0]
-	primaryKeyField := SanitizeName(primaryKey)
-
-	// Find primary key column type
-	const (
-		stringType  = "string"
-		numericType = "numeric"
-	)
-	var primaryKeyType string
-	for _, col := range table.Columns {
-		if col.Name == primaryKey {
-			protoType, _ := g.typeMapper.MapType(&col, table.Name, &g.config.Conversion)
-			// Check if it's a string type
-			if protoType == protoString {
-				primaryKeyType = stringType
-			} else {
-				primaryKeyType = numericType
-			}
-			break
-		}
-	}
+	primaryKeyField := g.fieldName(table.Name, primaryKey)
 
-	// Validate primary key is provided based on type
-	fmt.Fprintf(sb, "\t// Validate primary key is provided\n")
-	if primaryKeyType == stringType {
-		fmt.Fprintf(sb, "\tif req.%s == \"\" {\n", ToPascalCase(primaryKeyField))
-	} else {
-		fmt.Fprintf(sb, "\tif req.%s == 0 {\n", ToPascalCase(primaryKeyField))
-	}
-	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"primary key field %s is required\")\n", primaryKey)
-	fmt.Fprintf(sb, "\t}\n\n")
+	// Validate primary key is provided
+	writeValidateRequestCall(sb, requestType)
 
 	// Build simple query with primary key
 	fmt.Fprintf(sb, "\t// Build query with primary key condition\n")
-	fmt.Fprintf(sb, "\tqb := NewQueryBuilder()\n")
-	fmt.Fprintf(sb, "\tqb.AddCondition(\"%s\", \"=\", req.%s)\n\n", primaryKey, ToPascalCase(primaryKeyField))
+	fmt.Fprintf(sb, "\tqb := %s\n", g.newQueryBuilder())
+	writeTenantCondition(sb, tenant)
+	g.writeDefaultWhereCondition(sb, table, true)
+	g.writeLabelCondition(sb, table)
+	fmt.Fprintf(sb, "\tqb.AddCondition(%q, \"=\", req.%s)\n\n", quoteIdentifier(primaryKey), ToPascalCase(primaryKeyField))
 
 	// Build ORDER BY clause
 	fmt.Fprintf(sb, "\t// Build ORDER BY clause\n")
-	fmt.Fprintf(sb, "\torderByClause := \" ORDER BY ")
-	for i, key := range table.SortingKey {
-		if i > 0 {
-			fmt.Fprintf(sb, ", ")
-		}
-		fmt.Fprintf(sb, "%s", key)
-	}
-	fmt.Fprintf(sb, "\"\n\n")
+	fmt.Fprintf(sb, "\torderByClause := %q\n\n", sortingKeyClause(table))
+	g.writeTiersOption(sb, table, true)
+	g.writeTopologyOption(sb, table)
 
 	// Build column list for explicit selection
 	fmt.Fprintf(sb, "\t// Build column list\n")
-	fmt.Fprintf(sb, "\tcolumns := []string{")
-	for i, col := range table.Columns {
-		if i > 0 {
-			fmt.Fprintf(sb, ", ")
-		}
-		colExpr := getSelectColumnExpression(&col, table.Name, &g.config.Conversion)
-		fmt.Fprintf(sb, "\"%s\"", colExpr)
-	}
-	fmt.Fprintf(sb, "}\n\n")
+	g.writeSelectColumns(sb, table)
 
 	// Return query with LIMIT 1
 	fmt.Fprintf(sb, "\t// Return single record\n")
-	fmt.Fprintf(sb, "\treturn BuildParameterizedQuery(\"%s\", columns, qb, orderByClause, 1, 0, options...)\n", table.Name)
+	fmt.Fprintf(sb, "\treturn BuildParameterizedQuery(%s, columns, qb, orderByClause, 1, 0, options...)\n", g.queryTableArg(table))
 	fmt.Fprintf(sb, "}\n")
 }
 
@@ -562,20 +677,28 @@ func (g *Generator) writeAllFilterConditions(sb *strings.Builder, table *clickho
 	if len(table.SortingKey) > 0 {
 		// Process primary key filter
 		primaryKey = table.SortingKey[0]
-		primaryKeyField := SanitizeName(primaryKey)
+		primaryKeyField := g.fieldName(table.Name, primaryKey)
 		fmt.Fprintf(sb, "\t// Add primary key filter\n")
 		// If multiple primary keys exist, treat this one as optional too
 		isPrimary := !hasMultiplePrimaryKeys
 		g.writeFilterCondition(sb, table, primaryKey, primaryKeyField, columnMap[primaryKey], isPrimary)
 	}
+	g.writeColumnFilterConditions(sb, table, primaryKey)
+}
 
-	// Process all other columns
+// writeColumnFilterConditions writes the optional filter conditions of the columns other
+// than the primary key
+func (g *Generator) writeColumnFilterConditions(sb *strings.Builder, table *clickhouse.Table, primaryKey string) {
 	for _, col := range table.Columns {
 		// Skip primary key as it's already handled
 		if primaryKey != "" && col.Name == primaryKey {
 			continue
 		}
-		fieldName := SanitizeName(col.Name)
+		// Masked columns have no request filter
+		if g.isMasked(table.Name, col.Name) {
+			continue
+		}
+		fieldName := g.fieldName(table.Name, col.Name)
 		fmt.Fprintf(sb, "\n\t// Add filter for column: %s\n", col.Name)
 		g.writeFilterCondition(sb, table, col.Name, fieldName, &col, false)
 	}
@@ -604,11 +727,22 @@ func (g *Generator) writeFilterCondition(sb *strings.Builder, table *clickhouse.
 
 	fmt.Fprintf(sb, "%sswitch filter := req.%s.Filter.(type) {\n", indent, pascalFieldName)
 
+	columnName = quoteIdentifier(columnName)
+
 	// Write filter cases based on type
-	if isDateTime {
+	switch {
+	case isBoolColumn(column, table.Name, &g.config.Conversion):
+		// UInt8 columns holding booleans compare any non-zero value as true
+		expr := fmt.Sprintf("qb.boolColumn(%q)", columnName)
+		if column.IsNullable {
+			g.writeNullableBoolFilterCases(sb, expr, indent)
+		} else {
+			g.writeBoolFilterCases(sb, expr, indent)
+		}
+	case isDateTime:
 		// For DateTime columns, we need special handling
 		g.writeDateTimeFilterCases(sb, columnName, filterType, indent)
-	} else {
+	default:
 		g.writeFilterCases(sb, columnName, filterType, indent)
 	}
 
@@ -671,9 +805,9 @@ func (g *Generator) writeFilterCases(sb *strings.Builder, columnName, filterType
 	// Handle boolean filters
 	if strings.Contains(filterType, "Bool") {
 		if strings.HasPrefix(filterType, "Nullable") {
-			g.writeNullableBoolFilterCases(sb, columnName, indent)
+			g.writeNullableBoolFilterCases(sb, strconv.Quote(columnName), indent)
 		} else {
-			g.writeBoolFilterCases(sb, columnName, indent)
+			g.writeBoolFilterCases(sb, strconv.Quote(columnName), indent)
 		}
 		return
 	}
@@ -694,34 +828,34 @@ func (g *Generator) writeFilterCases(sb *strings.Builder, columnName, filterType
 // writeStringFilterCases generates switch cases for StringFilter using QueryBuilder
 func (g *Generator) writeStringFilterCases(sb *strings.Builder, columnName, indent string) {
 	fmt.Fprintf(sb, "%scase *StringFilter_Eq:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", filter.Eq)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"=\", filter.Eq)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *StringFilter_Ne:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", filter.Ne)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"!=\", filter.Ne)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *StringFilter_Contains:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(\"%s\", \"%%\" + filter.Contains + \"%%\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(%q, \"%%\" + EscapeLike(filter.Contains) + \"%%\")\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *StringFilter_StartsWith:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(\"%s\", filter.StartsWith + \"%%\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(%q, EscapeLike(filter.StartsWith) + \"%%\")\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *StringFilter_EndsWith:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(\"%s\", \"%%\" + filter.EndsWith)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(%q, \"%%\" + EscapeLike(filter.EndsWith))\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *StringFilter_Like:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(\"%s\", filter.Like)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(%q, filter.Like)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *StringFilter_NotLike:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddNotLikeCondition(\"%s\", filter.NotLike)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddNotLikeCondition(%q, filter.NotLike)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *StringFilter_In:\n", indent)
 	fmt.Fprintf(sb, "%s\tif len(filter.In.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(\"%s\", StringSliceToInterface(filter.In.Values))\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(%q, StringSliceToInterface(filter.In.Values))\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	fmt.Fprintf(sb, "%scase *StringFilter_NotIn:\n", indent)
 	fmt.Fprintf(sb, "%s\tif len(filter.NotIn.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(\"%s\", StringSliceToInterface(filter.NotIn.Values))\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(%q, StringSliceToInterface(filter.NotIn.Values))\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 }
 
@@ -729,42 +863,42 @@ func (g *Generator) writeStringFilterCases(sb *strings.Builder, columnName, inde
 func (g *Generator) writeNullableStringFilterCases(sb *strings.Builder, columnName, indent string) {
 	// Nullable string filter cases
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_Eq:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", filter.Eq)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"=\", filter.Eq)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_Ne:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", filter.Ne)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"!=\", filter.Ne)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_Contains:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(\"%s\", \"%%\" + filter.Contains + \"%%\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(%q, \"%%\" + EscapeLike(filter.Contains) + \"%%\")\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_StartsWith:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(\"%s\", filter.StartsWith + \"%%\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(%q, EscapeLike(filter.StartsWith) + \"%%\")\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_EndsWith:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(\"%s\", \"%%\" + filter.EndsWith)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(%q, \"%%\" + EscapeLike(filter.EndsWith))\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_Like:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(\"%s\", filter.Like)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddLikeCondition(%q, filter.Like)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_NotLike:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddNotLikeCondition(\"%s\", filter.NotLike)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddNotLikeCondition(%q, filter.NotLike)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_In:\n", indent)
 	fmt.Fprintf(sb, "%s\tif len(filter.In.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(\"%s\", StringSliceToInterface(filter.In.Values))\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(%q, StringSliceToInterface(filter.In.Values))\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_NotIn:\n", indent)
 	fmt.Fprintf(sb, "%s\tif len(filter.NotIn.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(\"%s\", StringSliceToInterface(filter.NotIn.Values))\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(%q, StringSliceToInterface(filter.NotIn.Values))\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	// Plus null cases
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_IsNull:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(\"%s\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(%q)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *NullableStringFilter_IsNotNull:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(\"%s\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(%q)\n", indent, columnName)
 }
 
 // writeNumericFilterCases generates switch cases for numeric filters using QueryBuilder
@@ -772,34 +906,34 @@ func (g *Generator) writeNumericFilterCases(sb *strings.Builder, columnName, ind
 	// typeName is already correctly cased (e.g., "UInt32", "Int64")
 	typePrefix := typeName
 	fmt.Fprintf(sb, "%scase *%sFilter_Eq:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", filter.Eq)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"=\", filter.Eq)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Ne:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", filter.Ne)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"!=\", filter.Ne)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Lt:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<\", filter.Lt)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<\", filter.Lt)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Lte:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<=\", filter.Lte)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<=\", filter.Lte)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Gt:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">\", filter.Gt)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">\", filter.Gt)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Gte:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">=\", filter.Gte)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">=\", filter.Gte)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Between:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(\"%s\", filter.Between.Min, filter.Between.Max.GetValue())\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(%q, filter.Between.Min, filter.Between.Max.GetValue())\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_In:\n", indent, typePrefix)
 	fmt.Fprintf(sb, "%s\tif len(filter.In.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(\"%s\", %sSliceToInterface(filter.In.Values))\n", indent, columnName, typePrefix)
+	fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(%q, %sSliceToInterface(filter.In.Values))\n", indent, columnName, typePrefix)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_NotIn:\n", indent, typePrefix)
 	fmt.Fprintf(sb, "%s\tif len(filter.NotIn.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(\"%s\", %sSliceToInterface(filter.NotIn.Values))\n", indent, columnName, typePrefix)
+	fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(%q, %sSliceToInterface(filter.NotIn.Values))\n", indent, columnName, typePrefix)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 }
 
@@ -810,66 +944,68 @@ func (g *Generator) writeNullableNumericFilterCases(sb *strings.Builder, columnN
 
 	// Generate all the numeric filter cases with Nullable prefix
 	fmt.Fprintf(sb, "%scase *%sFilter_Eq:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", filter.Eq)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"=\", filter.Eq)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Ne:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", filter.Ne)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"!=\", filter.Ne)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Lt:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<\", filter.Lt)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<\", filter.Lt)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Lte:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<=\", filter.Lte)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<=\", filter.Lte)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Gt:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">\", filter.Gt)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">\", filter.Gt)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Gte:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">=\", filter.Gte)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">=\", filter.Gte)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_Between:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(\"%s\", filter.Between.Min, filter.Between.Max.GetValue())\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(%q, filter.Between.Min, filter.Between.Max.GetValue())\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_In:\n", indent, typePrefix)
 	fmt.Fprintf(sb, "%s\tif len(filter.In.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(\"%s\", %sSliceToInterface(filter.In.Values))\n", indent, columnName, typeName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(%q, %sSliceToInterface(filter.In.Values))\n", indent, columnName, typeName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_NotIn:\n", indent, typePrefix)
 	fmt.Fprintf(sb, "%s\tif len(filter.NotIn.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(\"%s\", %sSliceToInterface(filter.NotIn.Values))\n", indent, columnName, typeName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(%q, %sSliceToInterface(filter.NotIn.Values))\n", indent, columnName, typeName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	// Plus null cases
 	fmt.Fprintf(sb, "%scase *%sFilter_IsNull:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(\"%s\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(%q)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%sFilter_IsNotNull:\n", indent, typePrefix)
-	fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(\"%s\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(%q)\n", indent, columnName)
 }
 
-// writeBoolFilterCases generates switch cases for BoolFilter using QueryBuilder
-func (g *Generator) writeBoolFilterCases(sb *strings.Builder, columnName, indent string) {
+// writeBoolFilterCases generates switch cases for BoolFilter using QueryBuilder. column is
+// the Go expression of the column.
+func (g *Generator) writeBoolFilterCases(sb *strings.Builder, column, indent string) {
 	fmt.Fprintf(sb, "%scase *BoolFilter_Eq:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", filter.Eq)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%s, \"=\", filter.Eq)\n", indent, column)
 
 	fmt.Fprintf(sb, "%scase *BoolFilter_Ne:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", filter.Ne)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%s, \"!=\", filter.Ne)\n", indent, column)
 }
 
-// writeNullableBoolFilterCases generates switch cases for NullableBoolFilter using QueryBuilder
-func (g *Generator) writeNullableBoolFilterCases(sb *strings.Builder, columnName, indent string) {
+// writeNullableBoolFilterCases generates switch cases for NullableBoolFilter using
+// QueryBuilder. column is the Go expression of the column.
+func (g *Generator) writeNullableBoolFilterCases(sb *strings.Builder, column, indent string) {
 	fmt.Fprintf(sb, "%scase *NullableBoolFilter_Eq:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", filter.Eq)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%s, \"=\", filter.Eq)\n", indent, column)
 
 	fmt.Fprintf(sb, "%scase *NullableBoolFilter_Ne:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", filter.Ne)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddCondition(%s, \"!=\", filter.Ne)\n", indent, column)
 
 	fmt.Fprintf(sb, "%scase *NullableBoolFilter_IsNull:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(\"%s\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(%s)\n", indent, column)
 
 	fmt.Fprintf(sb, "%scase *NullableBoolFilter_IsNotNull:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(\"%s\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(%s)\n", indent, column)
 }
 
 // writeMapStringStringFilterCases generates switch cases for MapStringStringFilter using QueryBuilder
@@ -878,34 +1014,34 @@ func (g *Generator) writeMapStringStringFilterCases(sb *strings.Builder, columnN
 	fmt.Fprintf(sb, "%s\t// Handle key-value filter with string values\n", indent)
 	fmt.Fprintf(sb, "%s\tswitch kvFilter := filter.KeyValue.ValueFilter.Filter.(type) {\n", indent)
 	fmt.Fprintf(sb, "%s\tcase *StringFilter_Eq:\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(\"%s\", filter.KeyValue.Key, \"=\", kvFilter.Eq)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(%q, filter.KeyValue.Key, \"=\", kvFilter.Eq)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *StringFilter_Ne:\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(\"%s\", filter.KeyValue.Key, \"!=\", kvFilter.Ne)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(%q, filter.KeyValue.Key, \"!=\", kvFilter.Ne)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *StringFilter_Like:\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyLikeCondition(\"%s\", filter.KeyValue.Key, kvFilter.Like)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyLikeCondition(%q, filter.KeyValue.Key, kvFilter.Like)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *StringFilter_StartsWith:\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyLikeCondition(\"%s\", filter.KeyValue.Key, kvFilter.StartsWith + \"%%\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyLikeCondition(%q, filter.KeyValue.Key, EscapeLike(kvFilter.StartsWith) + \"%%\")\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *StringFilter_EndsWith:\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyLikeCondition(\"%s\", filter.KeyValue.Key, \"%%\" + kvFilter.EndsWith)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyLikeCondition(%q, filter.KeyValue.Key, \"%%\" + EscapeLike(kvFilter.EndsWith))\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *StringFilter_Contains:\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyLikeCondition(\"%s\", filter.KeyValue.Key, \"%%\" + kvFilter.Contains + \"%%\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyLikeCondition(%q, filter.KeyValue.Key, \"%%\" + EscapeLike(kvFilter.Contains) + \"%%\")\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	fmt.Fprintf(sb, "%scase *MapStringStringFilter_HasKey:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddMapContainsCondition(\"%s\", filter.HasKey)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddMapContainsCondition(%q, filter.HasKey)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *MapStringStringFilter_NotHasKey:\n", indent)
-	fmt.Fprintf(sb, "%s\tqb.AddNotMapContainsCondition(\"%s\", filter.NotHasKey)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddNotMapContainsCondition(%q, filter.NotHasKey)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *MapStringStringFilter_HasAnyKey:\n", indent)
 	fmt.Fprintf(sb, "%s\tif len(filter.HasAnyKey.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapContainsAnyCondition(\"%s\", filter.HasAnyKey.Values)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapContainsAnyCondition(%q, filter.HasAnyKey.Values)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	fmt.Fprintf(sb, "%scase *MapStringStringFilter_HasAllKeys:\n", indent)
 	fmt.Fprintf(sb, "%s\tif len(filter.HasAllKeys.Values) > 0 {\n", indent)
 	fmt.Fprintf(sb, "%s\t\tfor _, key := range filter.HasAllKeys.Values {\n", indent)
-	fmt.Fprintf(sb, "%s\t\t\tqb.AddMapContainsCondition(\"%s\", key)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\t\tqb.AddMapContainsCondition(%q, key)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t\t}\n", indent)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 }
@@ -922,25 +1058,25 @@ func (g *Generator) writeDateTimeFilterCases(sb *strings.Builder, columnName, fi
 		// Wrap values in DateTimeValue to trigger fromUnixTimestamp conversion
 
 		fmt.Fprintf(sb, "%scase *UInt32Filter_Eq:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", DateTimeValue{filter.Eq})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"=\", DateTimeValue{filter.Eq})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *UInt32Filter_Ne:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", DateTimeValue{filter.Ne})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"!=\", DateTimeValue{filter.Ne})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *UInt32Filter_Lt:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<\", DateTimeValue{filter.Lt})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<\", DateTimeValue{filter.Lt})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *UInt32Filter_Lte:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<=\", DateTimeValue{filter.Lte})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<=\", DateTimeValue{filter.Lte})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *UInt32Filter_Gt:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">\", DateTimeValue{filter.Gt})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">\", DateTimeValue{filter.Gt})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *UInt32Filter_Gte:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">=\", DateTimeValue{filter.Gte})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">=\", DateTimeValue{filter.Gte})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *UInt32Filter_Between:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(\"%s\", DateTimeValue{filter.Between.Min}, DateTimeValue{filter.Between.Max.GetValue()})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(%q, DateTimeValue{filter.Between.Min}, DateTimeValue{filter.Between.Max.GetValue()})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *UInt32Filter_In:\n", indent)
 		fmt.Fprintf(sb, "%s\tif len(filter.In.Values) > 0 {\n", indent)
@@ -948,7 +1084,7 @@ func (g *Generator) writeDateTimeFilterCases(sb *strings.Builder, columnName, fi
 		fmt.Fprintf(sb, "%s\t\tfor i, v := range filter.In.Values {\n", indent)
 		fmt.Fprintf(sb, "%s\t\t\tconverted[i] = DateTimeValue{v}\n", indent)
 		fmt.Fprintf(sb, "%s\t\t}\n", indent)
-		fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(\"%s\", converted)\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(%q, converted)\n", indent, columnName)
 		fmt.Fprintf(sb, "%s\t}\n", indent)
 
 		fmt.Fprintf(sb, "%scase *UInt32Filter_NotIn:\n", indent)
@@ -957,30 +1093,30 @@ func (g *Generator) writeDateTimeFilterCases(sb *strings.Builder, columnName, fi
 		fmt.Fprintf(sb, "%s\t\tfor i, v := range filter.NotIn.Values {\n", indent)
 		fmt.Fprintf(sb, "%s\t\t\tconverted[i] = DateTimeValue{v}\n", indent)
 		fmt.Fprintf(sb, "%s\t\t}\n", indent)
-		fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(\"%s\", converted)\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(%q, converted)\n", indent, columnName)
 		fmt.Fprintf(sb, "%s\t}\n", indent)
 	case "Int64Filter":
 		// DateTime64 (stored as Int64 - toUnixTimestamp64Micro returns Int64)
 		fmt.Fprintf(sb, "%scase *Int64Filter_Eq:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", DateTime64Value{uint64(filter.Eq)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"=\", DateTime64Value{uint64(filter.Eq)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *Int64Filter_Ne:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", DateTime64Value{uint64(filter.Ne)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"!=\", DateTime64Value{uint64(filter.Ne)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *Int64Filter_Lt:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<\", DateTime64Value{uint64(filter.Lt)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<\", DateTime64Value{uint64(filter.Lt)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *Int64Filter_Lte:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<=\", DateTime64Value{uint64(filter.Lte)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<=\", DateTime64Value{uint64(filter.Lte)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *Int64Filter_Gt:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">\", DateTime64Value{uint64(filter.Gt)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">\", DateTime64Value{uint64(filter.Gt)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *Int64Filter_Gte:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">=\", DateTime64Value{uint64(filter.Gte)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">=\", DateTime64Value{uint64(filter.Gte)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *Int64Filter_Between:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(\"%s\", DateTime64Value{uint64(filter.Between.Min)}, DateTime64Value{uint64(filter.Between.Max.GetValue())})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(%q, DateTime64Value{uint64(filter.Between.Min)}, DateTime64Value{uint64(filter.Between.Max.GetValue())})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *Int64Filter_In:\n", indent)
 		fmt.Fprintf(sb, "%s\tif len(filter.In.Values) > 0 {\n", indent)
@@ -988,7 +1124,7 @@ func (g *Generator) writeDateTimeFilterCases(sb *strings.Builder, columnName, fi
 		fmt.Fprintf(sb, "%s\t\tfor i, v := range filter.In.Values {\n", indent)
 		fmt.Fprintf(sb, "%s\t\t\tconverted[i] = DateTime64Value{uint64(v)}\n", indent)
 		fmt.Fprintf(sb, "%s\t\t}\n", indent)
-		fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(\"%s\", converted)\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(%q, converted)\n", indent, columnName)
 		fmt.Fprintf(sb, "%s\t}\n", indent)
 
 		fmt.Fprintf(sb, "%scase *Int64Filter_NotIn:\n", indent)
@@ -997,36 +1133,36 @@ func (g *Generator) writeDateTimeFilterCases(sb *strings.Builder, columnName, fi
 		fmt.Fprintf(sb, "%s\t\tfor i, v := range filter.NotIn.Values {\n", indent)
 		fmt.Fprintf(sb, "%s\t\t\tconverted[i] = DateTime64Value{uint64(v)}\n", indent)
 		fmt.Fprintf(sb, "%s\t\t}\n", indent)
-		fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(\"%s\", converted)\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(%q, converted)\n", indent, columnName)
 		fmt.Fprintf(sb, "%s\t}\n", indent)
 	case "NullableUInt32Filter":
 		// Nullable DateTime (stored as NullableUInt32)
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_IsNull:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(\"%s\")\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(%q)\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_IsNotNull:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(\"%s\")\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(%q)\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_Eq:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", DateTimeValue{filter.Eq})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"=\", DateTimeValue{filter.Eq})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_Ne:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", DateTimeValue{filter.Ne})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"!=\", DateTimeValue{filter.Ne})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_Lt:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<\", DateTimeValue{filter.Lt})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<\", DateTimeValue{filter.Lt})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_Lte:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<=\", DateTimeValue{filter.Lte})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<=\", DateTimeValue{filter.Lte})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_Gt:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">\", DateTimeValue{filter.Gt})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">\", DateTimeValue{filter.Gt})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_Gte:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">=\", DateTimeValue{filter.Gte})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">=\", DateTimeValue{filter.Gte})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_Between:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(\"%s\", DateTimeValue{filter.Between.Min}, DateTimeValue{filter.Between.Max.GetValue()})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(%q, DateTimeValue{filter.Between.Min}, DateTimeValue{filter.Between.Max.GetValue()})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_In:\n", indent)
 		fmt.Fprintf(sb, "%s\tif len(filter.In.Values) > 0 {\n", indent)
@@ -1034,7 +1170,7 @@ func (g *Generator) writeDateTimeFilterCases(sb *strings.Builder, columnName, fi
 		fmt.Fprintf(sb, "%s\t\tfor i, v := range filter.In.Values {\n", indent)
 		fmt.Fprintf(sb, "%s\t\t\tconverted[i] = DateTimeValue{v}\n", indent)
 		fmt.Fprintf(sb, "%s\t\t}\n", indent)
-		fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(\"%s\", converted)\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(%q, converted)\n", indent, columnName)
 		fmt.Fprintf(sb, "%s\t}\n", indent)
 
 		fmt.Fprintf(sb, "%scase *NullableUInt32Filter_NotIn:\n", indent)
@@ -1043,37 +1179,37 @@ func (g *Generator) writeDateTimeFilterCases(sb *strings.Builder, columnName, fi
 		fmt.Fprintf(sb, "%s\t\tfor i, v := range filter.NotIn.Values {\n", indent)
 		fmt.Fprintf(sb, "%s\t\t\tconverted[i] = DateTimeValue{v}\n", indent)
 		fmt.Fprintf(sb, "%s\t\t}\n", indent)
-		fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(\"%s\", converted)\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(%q, converted)\n", indent, columnName)
 		fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	case "NullableInt64Filter":
 		// Nullable DateTime64 (stored as NullableInt64)
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_IsNull:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(\"%s\")\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddIsNullCondition(%q)\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_IsNotNull:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(\"%s\")\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddIsNotNullCondition(%q)\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_Eq:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"=\", DateTime64Value{uint64(filter.Eq)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"=\", DateTime64Value{uint64(filter.Eq)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_Ne:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"!=\", DateTime64Value{uint64(filter.Ne)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"!=\", DateTime64Value{uint64(filter.Ne)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_Lt:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<\", DateTime64Value{uint64(filter.Lt)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<\", DateTime64Value{uint64(filter.Lt)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_Lte:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \"<=\", DateTime64Value{uint64(filter.Lte)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \"<=\", DateTime64Value{uint64(filter.Lte)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_Gt:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">\", DateTime64Value{uint64(filter.Gt)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">\", DateTime64Value{uint64(filter.Gt)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_Gte:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddCondition(\"%s\", \">=\", DateTime64Value{uint64(filter.Gte)})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddCondition(%q, \">=\", DateTime64Value{uint64(filter.Gte)})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_Between:\n", indent)
-		fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(\"%s\", DateTime64Value{uint64(filter.Between.Min)}, DateTime64Value{uint64(filter.Between.Max.GetValue())})\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\tqb.AddBetweenCondition(%q, DateTime64Value{uint64(filter.Between.Min)}, DateTime64Value{uint64(filter.Between.Max.GetValue())})\n", indent, columnName)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_In:\n", indent)
 		fmt.Fprintf(sb, "%s\tif len(filter.In.Values) > 0 {\n", indent)
@@ -1081,7 +1217,7 @@ func (g *Generator) writeDateTimeFilterCases(sb *strings.Builder, columnName, fi
 		fmt.Fprintf(sb, "%s\t\tfor i, v := range filter.In.Values {\n", indent)
 		fmt.Fprintf(sb, "%s\t\t\tconverted[i] = DateTime64Value{uint64(v)}\n", indent)
 		fmt.Fprintf(sb, "%s\t\t}\n", indent)
-		fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(\"%s\", converted)\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\t\tqb.AddInCondition(%q, converted)\n", indent, columnName)
 		fmt.Fprintf(sb, "%s\t}\n", indent)
 
 		fmt.Fprintf(sb, "%scase *NullableInt64Filter_NotIn:\n", indent)
@@ -1090,7 +1226,7 @@ func (g *Generator) writeDateTimeFilterCases(sb *strings.Builder, columnName, fi
 		fmt.Fprintf(sb, "%s\t\tfor i, v := range filter.NotIn.Values {\n", indent)
 		fmt.Fprintf(sb, "%s\t\t\tconverted[i] = DateTime64Value{uint64(v)}\n", indent)
 		fmt.Fprintf(sb, "%s\t\t}\n", indent)
-		fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(\"%s\", converted)\n", indent, columnName)
+		fmt.Fprintf(sb, "%s\t\tqb.AddNotInCondition(%q, converted)\n", indent, columnName)
 		fmt.Fprintf(sb, "%s\t}\n", indent)
 	}
 }
@@ -1103,36 +1239,36 @@ func (g *Generator) writeMapStringNumericFilterCases(sb *strings.Builder, column
 	fmt.Fprintf(sb, "%s\t// Handle key-value filter with %s values\n", indent, numericType)
 	fmt.Fprintf(sb, "%s\tswitch kvFilter := filter.KeyValue.ValueFilter.Filter.(type) {\n", indent)
 	fmt.Fprintf(sb, "%s\tcase *%sFilter_Eq:\n", indent, numericType)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(\"%s\", filter.KeyValue.Key, \"=\", kvFilter.Eq)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(%q, filter.KeyValue.Key, \"=\", kvFilter.Eq)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *%sFilter_Ne:\n", indent, numericType)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(\"%s\", filter.KeyValue.Key, \"!=\", kvFilter.Ne)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(%q, filter.KeyValue.Key, \"!=\", kvFilter.Ne)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *%sFilter_Lt:\n", indent, numericType)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(\"%s\", filter.KeyValue.Key, \"<\", kvFilter.Lt)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(%q, filter.KeyValue.Key, \"<\", kvFilter.Lt)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *%sFilter_Lte:\n", indent, numericType)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(\"%s\", filter.KeyValue.Key, \"<=\", kvFilter.Lte)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(%q, filter.KeyValue.Key, \"<=\", kvFilter.Lte)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *%sFilter_Gt:\n", indent, numericType)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(\"%s\", filter.KeyValue.Key, \">\", kvFilter.Gt)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(%q, filter.KeyValue.Key, \">\", kvFilter.Gt)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *%sFilter_Gte:\n", indent, numericType)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(\"%s\", filter.KeyValue.Key, \">=\", kvFilter.Gte)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyCondition(%q, filter.KeyValue.Key, \">=\", kvFilter.Gte)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\tcase *%sFilter_Between:\n", indent, numericType)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyBetweenCondition(\"%s\", filter.KeyValue.Key, kvFilter.Between.Min, kvFilter.Between.Max)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapKeyBetweenCondition(%q, filter.KeyValue.Key, kvFilter.Between.Min, kvFilter.Between.Max)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	fmt.Fprintf(sb, "%scase *%s_HasKey:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddMapContainsCondition(\"%s\", filter.HasKey)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddMapContainsCondition(%q, filter.HasKey)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%s_NotHasKey:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddNotMapContainsCondition(\"%s\", filter.NotHasKey)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddNotMapContainsCondition(%q, filter.NotHasKey)\n", indent, columnName)
 
 	fmt.Fprintf(sb, "%scase *%s_HasAnyKey:\n", indent, filterType)
 	fmt.Fprintf(sb, "%s\tif len(filter.HasAnyKey.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddMapContainsAnyCondition(\"%s\", filter.HasAnyKey.Values)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\tqb.AddMapContainsAnyCondition(%q, filter.HasAnyKey.Values)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	fmt.Fprintf(sb, "%scase *%s_HasAllKeys:\n", indent, filterType)
 	fmt.Fprintf(sb, "%s\tif len(filter.HasAllKeys.Values) > 0 {\n", indent)
 	fmt.Fprintf(sb, "%s\t\tfor _, key := range filter.HasAllKeys.Values {\n", indent)
-	fmt.Fprintf(sb, "%s\t\t\tqb.AddMapContainsCondition(\"%s\", key)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\t\t\tqb.AddMapContainsCondition(%q, key)\n", indent, columnName)
 	fmt.Fprintf(sb, "%s\t\t}\n", indent)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 }
@@ -1145,45 +1281,45 @@ func (g *Generator) writeArrayFilterCases(sb *strings.Builder, columnName, filte
 
 	// has - single value check
 	fmt.Fprintf(sb, "%scase *%s_Has:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddArrayHasCondition(\"%s\", filter.Has)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddArrayHasCondition(%q, filter.Has)\n", indent, columnName)
 
 	// has_all - all values must exist
 	fmt.Fprintf(sb, "%scase *%s_HasAll:\n", indent, filterType)
 	fmt.Fprintf(sb, "%s\tif len(filter.HasAll.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddArrayHasAllCondition(\"%s\", %sSliceToInterface(filter.HasAll.Values))\n", indent, columnName, elementType)
+	fmt.Fprintf(sb, "%s\t\tqb.AddArrayHasAllCondition(%q, %sSliceToInterface(filter.HasAll.Values))\n", indent, columnName, elementType)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	// has_any - any value exists
 	fmt.Fprintf(sb, "%scase *%s_HasAny:\n", indent, filterType)
 	fmt.Fprintf(sb, "%s\tif len(filter.HasAny.Values) > 0 {\n", indent)
-	fmt.Fprintf(sb, "%s\t\tqb.AddArrayHasAnyCondition(\"%s\", %sSliceToInterface(filter.HasAny.Values))\n", indent, columnName, elementType)
+	fmt.Fprintf(sb, "%s\t\tqb.AddArrayHasAnyCondition(%q, %sSliceToInterface(filter.HasAny.Values))\n", indent, columnName, elementType)
 	fmt.Fprintf(sb, "%s\t}\n", indent)
 
 	// length_eq
 	fmt.Fprintf(sb, "%scase *%s_LengthEq:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(\"%s\", \"=\", filter.LengthEq)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(%q, \"=\", filter.LengthEq)\n", indent, columnName)
 
 	// length_gt
 	fmt.Fprintf(sb, "%scase *%s_LengthGt:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(\"%s\", \">\", filter.LengthGt)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(%q, \">\", filter.LengthGt)\n", indent, columnName)
 
 	// length_gte
 	fmt.Fprintf(sb, "%scase *%s_LengthGte:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(\"%s\", \">=\", filter.LengthGte)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(%q, \">=\", filter.LengthGte)\n", indent, columnName)
 
 	// length_lt
 	fmt.Fprintf(sb, "%scase *%s_LengthLt:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(\"%s\", \"<\", filter.LengthLt)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(%q, \"<\", filter.LengthLt)\n", indent, columnName)
 
 	// length_lte
 	fmt.Fprintf(sb, "%scase *%s_LengthLte:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(\"%s\", \"<=\", filter.LengthLte)\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddArrayLengthCondition(%q, \"<=\", filter.LengthLte)\n", indent, columnName)
 
 	// is_empty
 	fmt.Fprintf(sb, "%scase *%s_IsEmpty:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddArrayIsEmptyCondition(\"%s\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddArrayIsEmptyCondition(%q)\n", indent, columnName)
 
 	// is_not_empty
 	fmt.Fprintf(sb, "%scase *%s_IsNotEmpty:\n", indent, filterType)
-	fmt.Fprintf(sb, "%s\tqb.AddArrayIsNotEmptyCondition(\"%s\")\n", indent, columnName)
+	fmt.Fprintf(sb, "%s\tqb.AddArrayIsNotEmptyCondition(%q)\n", indent, columnName)
 }
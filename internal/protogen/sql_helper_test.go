@@ -2,6 +2,7 @@ package protogen
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -26,6 +27,40 @@ func TestGenerateSQLCommon(t *testing.T) {
 	assert.NoError(t, err, "Should generate SQL common file without error")
 }
 
+// TestGenerateSQLHelpersPerTableFiles tests that each table gets its own build-tagged
+// <table>_sql.go and that helpers from the old <table>.go naming are cleaned up
+func TestGenerateSQLHelpersPerTableFiles(t *testing.T) {
+	outputDir := t.TempDir()
+	g := &Generator{
+		config: &config.Config{
+			OutputDir:   outputDir,
+			GoPackage:   "github.com/test/chv1",
+			SQLBuildTag: "chsql",
+		},
+		log: logrus.New().WithField("test", true),
+	}
+
+	legacy := filepath.Join(outputDir, "users.go")
+	require.NoError(t, os.WriteFile(legacy, []byte("// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n// SQL query builder for users\n\npackage chv1\n"), 0o600))
+	handwritten := filepath.Join(outputDir, "orders.go")
+	require.NoError(t, os.WriteFile(handwritten, []byte("package chv1\n"), 0o600))
+
+	tables := []*clickhouse.Table{
+		{Name: "users", Columns: []clickhouse.Column{{Name: "id", Type: "UInt64", BaseType: "UInt64"}}, SortingKey: []string{"id"}},
+		{Name: "orders", Columns: []clickhouse.Column{{Name: "id", Type: "UInt64", BaseType: "UInt64"}}, SortingKey: []string{"id"}},
+	}
+	require.NoError(t, g.GenerateSQLHelpers(tables))
+
+	for _, name := range []string{"users_sql.go", "orders_sql.go", "common.go"} {
+		content, err := readFile(filepath.Join(outputDir, name))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(content, "//go:build chsql\n\n// Code generated by clickhouse-proto-gen. DO NOT EDIT.\n"), name)
+	}
+
+	assert.NoFileExists(t, legacy)
+	assert.FileExists(t, handwritten, "files without the generated marker must be kept")
+}
+
 // TestWriteSQLBuilderFunctionDatabaseAgnostic tests that the generated SQL builder functions
 // are database-agnostic and use the new WithDatabase option
 func TestWriteSQLBuilderFunctionDatabaseAgnostic(t *testing.T) {
@@ -254,7 +289,9 @@ func TestSQLHelperWithProjections(t *testing.T) {
 			gen := NewGenerator(cfg, logger)
 			var sb strings.Builder
 
-			// Generate the SQL builder function
+			// Generate the List request validator, which holds the primary key check, and
+			// the SQL builder function
+			gen.writeRequestValidator(&sb, tt.table, gen.requestMessages(tt.table)[0])
 			gen.writeSQLBuilderFunction(&sb, tt.table)
 
 			generatedCode := sb.String()
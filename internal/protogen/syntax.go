@@ -0,0 +1,132 @@
+package protogen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// explicitPresenceOption gives a field of an edition 2023 file explicit presence, overriding
+// the implicit presence its file defaults to
+const explicitPresenceOption = "features.field_presence = EXPLICIT"
+
+// proto2FieldPattern matches the declaration of a field with its type, name and number
+//
+//nolint:gochecknoglobals // Compiled once
+var proto2FieldPattern = regexp.MustCompile(`^\s*[A-Za-z_][\w.]* [A-Za-z_]\w* = \d+`)
+
+// writeSyntax writes the syntax or edition statement opening a proto file
+func (g *Generator) writeSyntax(sb *strings.Builder) {
+	switch g.config.Syntax {
+	case config.SyntaxProto2:
+		sb.WriteString("syntax = \"proto2\";\n\n")
+	case config.SyntaxEdition2023:
+		sb.WriteString("edition = \"2023\";\n\n")
+	default:
+		sb.WriteString("syntax = \"proto3\";\n\n")
+	}
+}
+
+// writeFileFeatures writes the file options an edition 2023 file needs to keep proto3's
+// field presence, so fields only get explicit presence where they ask for it
+func (g *Generator) writeFileFeatures(sb *strings.Builder) {
+	if g.config.Syntax == config.SyntaxEdition2023 {
+		sb.WriteString("option features.field_presence = IMPLICIT;\n")
+	}
+}
+
+// hasLanguageOptions reports whether any package option of another language is configured
+func (g *Generator) hasLanguageOptions() bool {
+	return g.config.FileOptions != config.FileOptionsConfig{}
+}
+
+// writeLanguageOptions writes the configured package options of languages other than Go.
+// clickhouse/annotations.proto passes annotations to place its types in a Clickhouse
+// namespace below them.
+func (g *Generator) writeLanguageOptions(sb *strings.Builder, annotations bool) {
+	opts := g.config.FileOptions
+	suffix := func(value, separator, name string) string {
+		if annotations {
+			return value + separator + name
+		}
+		return value
+	}
+
+	if opts.JavaPackage != "" {
+		fmt.Fprintf(sb, "option java_package = \"%s\";\n", suffix(opts.JavaPackage, ".", "clickhouse"))
+	}
+	if opts.JavaMultipleFiles {
+		sb.WriteString("option java_multiple_files = true;\n")
+	}
+	if opts.CSharpNamespace != "" {
+		fmt.Fprintf(sb, "option csharp_namespace = \"%s\";\n", suffix(opts.CSharpNamespace, ".", "Clickhouse"))
+	}
+	if opts.PHPNamespace != "" {
+		namespace := suffix(opts.PHPNamespace, `\`, "Clickhouse")
+		fmt.Fprintf(sb, "option php_namespace = \"%s\";\n", strings.ReplaceAll(namespace, `\`, `\\`))
+	}
+	if opts.RubyPackage != "" {
+		fmt.Fprintf(sb, "option ruby_package = \"%s\";\n", suffix(opts.RubyPackage, "::", "Clickhouse"))
+	}
+}
+
+// applyPresenceToField replaces the wrapper type of a nullable column's field with its scalar
+// and explicit presence in edition 2023 files, where presence tells NULL from zero values
+func (g *Generator) applyPresenceToField(field *ProtoField) {
+	if g.config.Syntax != config.SyntaxEdition2023 {
+		return
+	}
+	scalar := wrapperScalar(field.Type)
+	if scalar == "" {
+		return
+	}
+	field.Type = scalar
+	field.Options = append([]string{explicitPresenceOption}, field.Options...)
+}
+
+// applySyntax adapts a rendered proto file to the configured syntax. proto2 needs a label
+// on every field, so singular fields of messages and extensions are labelled optional;
+// oneof members take no label.
+func (g *Generator) applySyntax(content string) string {
+	if g.config.Syntax != config.SyntaxProto2 {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	var blocks []string
+	for i, line := range lines {
+		code := strings.TrimSpace(line)
+		if idx := strings.Index(code, "//"); idx >= 0 {
+			code = strings.TrimSpace(code[:idx])
+		}
+
+		switch {
+		case code == "":
+			continue
+		case strings.HasSuffix(code, "{"):
+			kind, _, _ := strings.Cut(code, " ")
+			blocks = append(blocks, kind)
+			continue
+		case strings.HasPrefix(code, "}"):
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		}
+
+		if len(blocks) == 0 {
+			continue
+		}
+		if kind := blocks[len(blocks)-1]; kind != "message" && kind != "extend" {
+			continue
+		}
+		if strings.HasPrefix(code, "repeated ") || strings.HasPrefix(code, "map<") || !proto2FieldPattern.MatchString(code) {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+		lines[i] = indent + "optional " + strings.TrimLeft(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
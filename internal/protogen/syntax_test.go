@@ -0,0 +1,162 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// syntaxTestTable has a nullable column, a nullable bigint_to_string column and a filter
+// oneof in its List request. Its message declares an extension range.
+func syntaxTestTable() *clickhouse.Table {
+	return &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("proposer_index", "Nullable(UInt32)", 2),
+			clickhouse.NewColumn("value_wei", "Nullable(UInt64)", 3),
+			clickhouse.NewColumn("tags", "Array(String)", 4),
+		},
+		SortingKey: []string{"slot"},
+	}
+}
+
+// compileSyntaxTest generates the test table with a descriptor set, which compiling the
+// protos validates, and returns the set's files
+func compileSyntaxTest(t *testing.T, cfg *config.Config) *protoregistry.Files {
+	t.Helper()
+
+	cfg.OutputDir = t.TempDir()
+	cfg.Package = "beacon.v1"
+	cfg.EnableAPI = true
+	cfg.DescriptorSetOut = filepath.Join(cfg.OutputDir, "schema.binpb")
+	cfg.Conversion.BigIntToStringFields = []string{"value_wei"}
+	cfg.Reserved = map[string]config.ReservedConfig{"fct_block": {Extensions: []string{"1000 to max"}}}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{syntaxTestTable()}))
+
+	data, err := os.ReadFile(cfg.DescriptorSetOut)
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, set))
+	files, err := protodesc.NewFiles(set)
+	require.NoError(t, err)
+	return files
+}
+
+func findMessage(t *testing.T, files *protoregistry.Files, name protoreflect.FullName) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	desc, err := files.FindDescriptorByName(name)
+	require.NoError(t, err)
+	message, ok := desc.(protoreflect.MessageDescriptor)
+	require.True(t, ok)
+	return message
+}
+
+func TestGenerator_Edition2023(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Syntax = config.SyntaxEdition2023
+	files := compileSyntaxTest(t, cfg)
+
+	row := findMessage(t, files, "beacon.v1.FctBlock")
+	assert.Equal(t, protoreflect.Editions, row.ParentFile().Syntax())
+	assert.False(t, row.Fields().ByName("slot").HasPresence(), "fields keep proto3's implicit presence")
+	proposer := row.Fields().ByName("proposer_index")
+	assert.True(t, proposer.HasPresence())
+	assert.Equal(t, protoreflect.Uint32Kind, proposer.Kind(), "nullable columns use scalars instead of wrappers")
+	assert.Equal(t, protoreflect.StringKind, row.Fields().ByName("value_wei").Kind())
+	assert.Equal(t, 1, row.ExtensionRanges().Len())
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_block.proto"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "edition = \"2023\";\n")
+	assert.NotContains(t, string(content), "google/protobuf/wrappers.proto")
+
+	// Models still see the nullable fields
+	fields := parseProtoMessages(string(content))[0].Fields
+	assert.True(t, fields[1].Nullable())
+	assert.Equal(t, protoUInt32, fields[1].Scalar())
+
+	helpers, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_block_sql.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(helpers), "if x.ValueWei == nil {")
+	assert.Contains(t, string(helpers), "x.ValueWei = &s")
+	assert.NotContains(t, string(helpers), "wrapperspb")
+}
+
+func TestGenerator_Proto2(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Syntax = config.SyntaxProto2
+	cfg.Emit = []string{config.EmitMessages, config.EmitServices, config.EmitREST, config.EmitAnnotations}
+	files := compileSyntaxTest(t, cfg)
+
+	row := findMessage(t, files, "beacon.v1.FctBlock")
+	assert.Equal(t, protoreflect.Proto2, row.ParentFile().Syntax())
+	assert.Equal(t, protoreflect.Optional, row.Fields().ByName("slot").Cardinality())
+	assert.Equal(t, protoreflect.Repeated, row.Fields().ByName("tags").Cardinality())
+	assert.Equal(t, "google.protobuf.UInt32Value", string(row.Fields().ByName("proposer_index").Message().FullName()),
+		"nullable columns keep their wrappers")
+	assert.Equal(t, 1, row.ExtensionRanges().Len())
+
+	filter := findMessage(t, files, "beacon.v1.UInt32Filter")
+	assert.Equal(t, protoreflect.Proto2, filter.ParentFile().Syntax())
+	assert.NotNil(t, filter.Fields().ByName("eq").ContainingOneof())
+
+	content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_block.proto"))
+	require.NoError(t, err)
+	fields := parseProtoMessages(string(content))[0].Fields
+	assert.Equal(t, "slot", fields[0].Name)
+	assert.False(t, fields[0].Nullable())
+	assert.True(t, fields[1].Nullable())
+}
+
+func TestGenerator_FileOptions(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Syntax = config.SyntaxEdition2023
+	cfg.GoPackage = "github.com/ethpandaops/beacon/gen/beaconv1"
+	cfg.FileOptions = config.FileOptionsConfig{
+		JavaPackage:       "io.ethpandaops.beacon.v1",
+		JavaMultipleFiles: true,
+		CSharpNamespace:   "Ethpandaops.Beacon.V1",
+		PHPNamespace:      `Ethpandaops\Beacon\V1`,
+		RubyPackage:       "Ethpandaops::Beacon::V1",
+	}
+	files := compileSyntaxTest(t, cfg)
+
+	fileOptions := func(path string) *descriptorpb.FileOptions {
+		file, err := files.FindFileByPath(path)
+		require.NoError(t, err)
+		options, ok := file.Options().(*descriptorpb.FileOptions)
+		require.True(t, ok)
+		return options
+	}
+
+	for _, path := range []string{"fct_block.proto", "common.proto"} {
+		options := fileOptions(path)
+		assert.Equal(t, "io.ethpandaops.beacon.v1", options.GetJavaPackage(), path)
+		assert.True(t, options.GetJavaMultipleFiles(), path)
+		assert.Equal(t, "Ethpandaops.Beacon.V1", options.GetCsharpNamespace(), path)
+		assert.Equal(t, `Ethpandaops\Beacon\V1`, options.GetPhpNamespace(), path)
+		assert.Equal(t, "Ethpandaops::Beacon::V1", options.GetRubyPackage(), path)
+	}
+
+	// The annotations get their own namespace, as they do their own Go package
+	options := fileOptions("clickhouse/annotations.proto")
+	assert.Equal(t, "io.ethpandaops.beacon.v1.clickhouse", options.GetJavaPackage())
+	assert.Equal(t, "Ethpandaops.Beacon.V1.Clickhouse", options.GetCsharpNamespace())
+	assert.Equal(t, `Ethpandaops\Beacon\V1\Clickhouse`, options.GetPhpNamespace())
+	assert.Equal(t, "Ethpandaops::Beacon::V1::Clickhouse", options.GetRubyPackage())
+}
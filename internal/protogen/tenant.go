@@ -0,0 +1,153 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// Tenant isolation errors
+var (
+	ErrTenantColumnMissing     = errors.New("tenant column not found in table")
+	ErrTenantColumnUnsupported = errors.New("tenant column must be a non-nullable string or integer column")
+	ErrTenantFieldConflict     = errors.New("tenant request field conflicts with a column")
+)
+
+const defaultTenantField = "tenant"
+
+// tenantScope describes the mandatory tenant condition injected into a table's
+// request messages and Build*Query functions
+type tenantScope struct {
+	column    string // ClickHouse column the query is scoped by
+	field     string // Proto request field carrying the tenant
+	protoType string // Proto scalar type of the request field
+	goType    string // Go type of the Build*Query tenant parameter
+}
+
+// goFieldName returns the Go struct field name protoc generates for the request field
+func (s *tenantScope) goFieldName() string {
+	return ToPascalCase(s.field)
+}
+
+// zeroValue returns the Go zero value literal for the tenant type
+func (s *tenantScope) zeroValue() string {
+	if s.goType == protoString {
+		return `""`
+	}
+	return "0"
+}
+
+// tenantScopeFor returns the tenant scope for a table, or nil when tenant isolation
+// is disabled or the table is exempt
+func (g *Generator) tenantScopeFor(table *clickhouse.Table) (*tenantScope, error) {
+	cfg := g.config.Tenant
+	if cfg.Column == "" {
+		return nil, nil
+	}
+
+	for _, exempt := range cfg.ExemptTables {
+		if exempt == table.Name {
+			return nil, nil
+		}
+	}
+
+	field := cfg.Field
+	if field == "" {
+		field = defaultTenantField
+	}
+	field = SanitizeName(field)
+
+	var column *clickhouse.Column
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		if g.fieldName(table.Name, col.Name) == field {
+			return nil, fmt.Errorf("%w: field %q in table %s", ErrTenantFieldConflict, field, table.Name)
+		}
+		if col.Name == cfg.Column {
+			column = col
+		}
+	}
+
+	if column == nil {
+		return nil, fmt.Errorf("%w: %s.%s (add it to tenant.exempt_tables to generate it unscoped)", ErrTenantColumnMissing, table.Name, cfg.Column)
+	}
+
+	if column.IsNullable || column.IsArray {
+		return nil, fmt.Errorf("%w: %s.%s is %s", ErrTenantColumnUnsupported, table.Name, column.Name, column.Type)
+	}
+
+	protoType, err := g.typeMapper.MapType(column, table.Name, &g.config.Conversion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map tenant column type: %w", err)
+	}
+
+	switch protoType {
+	case protoString, protoInt32, protoInt64, protoUInt32, protoUInt64:
+	default:
+		return nil, fmt.Errorf("%w: %s.%s is %s", ErrTenantColumnUnsupported, table.Name, column.Name, column.Type)
+	}
+
+	return &tenantScope{
+		column:    column.Name,
+		field:     field,
+		protoType: protoType,
+		goType:    protoType, // Proto scalar names match their Go types
+	}, nil
+}
+
+// validateTenantScopes checks that every table with a service can be tenant-scoped
+func (g *Generator) validateTenantScopes(tables []*clickhouse.Table) error {
+	if g.config.Tenant.Column == "" {
+		return nil
+	}
+
+	for _, table := range tables {
+		if len(table.SortingKey) == 0 {
+			continue
+		}
+		if _, err := g.tenantScopeFor(table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeTenantRequestField writes the mandatory tenant field into a request message
+func (g *Generator) writeTenantRequestField(sb *strings.Builder, table *clickhouse.Table, scope *tenantScope, fieldNumber int) {
+	fmt.Fprintf(sb, "  // Tenant the request is scoped to. Every query is filtered by %s.\n", scope.column)
+	if g.shouldGenerateAPI(table.Name) {
+		fmt.Fprintf(sb, "  %s %s = %d [(google.api.field_behavior) = REQUIRED];\n", scope.protoType, scope.field, fieldNumber)
+	} else {
+		fmt.Fprintf(sb, "  %s %s = %d;\n", scope.protoType, scope.field, fieldNumber)
+	}
+}
+
+// tenantParam returns the extra Build*Query parameter for a tenant-scoped table
+func tenantParam(scope *tenantScope) string {
+	if scope == nil {
+		return ""
+	}
+	return fmt.Sprintf(", tenant %s", scope.goType)
+}
+
+// writeTenantCondition writes the tenant validation and mandatory WHERE condition
+// into a Build*Query function body
+func writeTenantCondition(sb *strings.Builder, scope *tenantScope) {
+	if scope == nil {
+		return
+	}
+
+	zero := scope.zeroValue()
+
+	fmt.Fprintf(sb, "\t// Scope the query to the tenant\n")
+	fmt.Fprintf(sb, "\tif tenant == %s {\n", zero)
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"tenant is required\")\n")
+	fmt.Fprintf(sb, "\t}\n")
+	fmt.Fprintf(sb, "\tif req.%s != %s && req.%s != tenant {\n", scope.goFieldName(), zero, scope.goFieldName())
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, fmt.Errorf(\"request %s does not match the authorized tenant\")\n", scope.field)
+	fmt.Fprintf(sb, "\t}\n")
+	fmt.Fprintf(sb, "\tqb.AddCondition(%q, \"=\", tenant)\n\n", quoteIdentifier(scope.column))
+}
@@ -0,0 +1,129 @@
+package protogen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tenantTestTable() *clickhouse.Table {
+	return &clickhouse.Table{
+		Name: "fct_events",
+		Columns: []clickhouse.Column{
+			{Name: "event_id", Type: "UInt64", BaseType: "UInt64", Position: 1},
+			{Name: "tenant_id", Type: "String", BaseType: "String", Position: 2},
+			{Name: "org_id", Type: "UInt32", BaseType: "UInt32", Position: 3},
+			{Name: "owner", Type: "Nullable(String)", BaseType: "String", IsNullable: true, Position: 4},
+		},
+		SortingKey: []string{"event_id"},
+	}
+}
+
+func TestGenerator_TenantScopeFor(t *testing.T) {
+	tests := []struct {
+		name        string
+		tenant      config.TenantConfig
+		expected    *tenantScope
+		expectedErr error
+	}{
+		{
+			name:     "Disabled",
+			tenant:   config.TenantConfig{},
+			expected: nil,
+		},
+		{
+			name:   "String column with default field",
+			tenant: config.TenantConfig{Column: "tenant_id"},
+			expected: &tenantScope{
+				column: "tenant_id", field: "tenant", protoType: "string", goType: "string",
+			},
+		},
+		{
+			name:   "Integer column with custom field",
+			tenant: config.TenantConfig{Column: "org_id", Field: "org"},
+			expected: &tenantScope{
+				column: "org_id", field: "org", protoType: "uint32", goType: "uint32",
+			},
+		},
+		{
+			name:     "Exempt table",
+			tenant:   config.TenantConfig{Column: "missing", ExemptTables: []string{"fct_events"}},
+			expected: nil,
+		},
+		{
+			name:        "Missing column",
+			tenant:      config.TenantConfig{Column: "missing"},
+			expectedErr: ErrTenantColumnMissing,
+		},
+		{
+			name:        "Nullable column",
+			tenant:      config.TenantConfig{Column: "owner"},
+			expectedErr: ErrTenantColumnUnsupported,
+		},
+		{
+			name:        "Field conflicts with column",
+			tenant:      config.TenantConfig{Column: "tenant_id", Field: "org_id"},
+			expectedErr: ErrTenantFieldConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Tenant = tt.tenant
+			gen := NewGenerator(cfg, logrus.New())
+
+			scope, err := gen.tenantScopeFor(tenantTestTable())
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, scope)
+		})
+	}
+}
+
+func TestGenerator_GenerateWithTenantIsolation(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = tempDir
+	cfg.EnableAPI = true
+	cfg.Tenant = config.TenantConfig{Column: "tenant_id"}
+
+	gen := NewGenerator(cfg, logrus.New())
+	require.NoError(t, gen.Generate([]*clickhouse.Table{tenantTestTable()}))
+
+	protoContent, err := readFile(filepath.Join(tempDir, "fct_events.proto"))
+	require.NoError(t, err)
+
+	// List request: tenant comes after order_by (event_id, tenant_id, org_id, owner, page_size, page_token, order_by)
+	assert.Contains(t, protoContent, "string tenant = 8 [(google.api.field_behavior) = REQUIRED];")
+	// Get request: tenant follows the primary key
+	assert.Contains(t, protoContent, "string tenant = 2 [(google.api.field_behavior) = REQUIRED];")
+
+	sqlContent, err := readFile(filepath.Join(tempDir, "fct_events_sql.go"))
+	require.NoError(t, err)
+
+	assert.Contains(t, sqlContent, "func BuildListFctEventsQuery(req *ListFctEventsRequest, tenant string, options ...QueryOption) (SQLQuery, error) {")
+	assert.Contains(t, sqlContent, "func BuildGetFctEventsQuery(req *GetFctEventsRequest, tenant string, options ...QueryOption) (SQLQuery, error) {")
+	assert.Contains(t, sqlContent, "if req.Tenant != \"\" && req.Tenant != tenant {")
+	assert.Contains(t, sqlContent, "qb.AddCondition(\"`tenant_id`\", \"=\", tenant)")
+}
+
+func TestGenerator_GenerateWithTenantIsolationMissingColumn(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Tenant = config.TenantConfig{Column: "account_id"}
+
+	gen := NewGenerator(cfg, logrus.New())
+	err := gen.Generate([]*clickhouse.Table{tenantTestTable()})
+	require.ErrorIs(t, err, ErrTenantColumnMissing)
+}
@@ -0,0 +1,123 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// ErrInvalidTierColumn is returned when the tiers of a table split it by a column it can't
+// be split by
+var ErrInvalidTierColumn = errors.New("invalid tier column")
+
+// tiers returns the tier config of a table, or nil when the table isn't tiered
+func (g *Generator) tiers(table *clickhouse.Table) *config.TierConfig {
+	tiers, ok := g.config.Tiers[table.Name]
+	if !ok {
+		return nil
+	}
+	return &tiers
+}
+
+// validateTiers checks that every tiered table is split by one of its non-nullable DateTime
+// or DateTime64 columns, whose values the cutoff is compared with
+func (g *Generator) validateTiers(tables []*clickhouse.Table) error {
+	for _, table := range tables {
+		tiers := g.tiers(table)
+		if tiers == nil {
+			continue
+		}
+		column := findColumn(table, tiers.Column)
+		switch {
+		case column == nil:
+			return fmt.Errorf("%w: %s has no column %s", ErrInvalidTierColumn, table.Name, tiers.Column)
+		case column.IsArray || column.IsNullable || (column.BaseType != clickhouseDateTime && column.BaseType != clickhouseDateTime64):
+			return fmt.Errorf("%w: %s.%s is a %s (must be a DateTime or DateTime64)", ErrInvalidTierColumn, table.Name, column.Name, column.Type)
+		}
+	}
+	return nil
+}
+
+// writeTiersComment documents on a query builder where the rows of a tiered table are read
+func (g *Generator) writeTiersComment(sb *strings.Builder, table *clickhouse.Table) {
+	tiers := g.tiers(table)
+	if tiers == nil {
+		return
+	}
+	fmt.Fprintf(sb, "//\n")
+	fmt.Fprintf(sb, "// Rows whose %s is older than %s are read from %s.\n", tiers.Column, tiers.Retention, tiers.Archive)
+}
+
+// writeTiersOption writes the code of a query builder reading a tiered table's older rows
+// from its archive. List queries are bounded by the filter of the tier column, so they skip
+// the tiers it can't reach; Get queries are bounded by the primary key when it's the tier
+// column.
+func (g *Generator) writeTiersOption(sb *strings.Builder, table *clickhouse.Table, get bool) {
+	tiers := g.tiers(table)
+	if tiers == nil {
+		return
+	}
+	column := findColumn(table, tiers.Column)
+	micro := column.BaseType == clickhouseDateTime64
+
+	columns := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		columns[i] = fmt.Sprintf("%q", col.Name)
+	}
+
+	fmt.Fprintf(sb, "\t// Read the rows older than the retention of %s from the archive\n", column.Name)
+	fmt.Fprintf(sb, "\ttiers := Tiers{Column: %q, Micro: %t, Columns: []string{%s}, Archive: %q, RetentionSeconds: %d}\n",
+		column.Name, micro, strings.Join(columns, ", "), tiers.Archive, int64(tiers.Retention.Seconds()))
+
+	field := ToPascalCase(g.fieldName(table.Name, column.Name))
+	switch {
+	case get:
+		if len(table.SortingKey) > 0 && table.SortingKey[0] == column.Name {
+			bound := fmt.Sprintf("uint64(req.%s)", field)
+			if micro {
+				bound = fmt.Sprintf("tierBound(req.%s)", field)
+			}
+			fmt.Fprintf(sb, "\ttiers.From, tiers.To = %s, %s\n", bound, bound)
+		}
+	case !g.isMasked(table.Name, column.Name):
+		g.writeTierBounds(sb, field, micro)
+	}
+
+	fmt.Fprintf(sb, "\toptions = append([]QueryOption{WithTiers(tiers)}, options...)\n\n")
+}
+
+// writeTierBounds writes the switch bounding the tiers of a List query by the filter of the
+// tier column. Filters without a range leave the bounds open.
+func (g *Generator) writeTierBounds(sb *strings.Builder, field string, micro bool) {
+	filterType, bound := "UInt32Filter", func(value string) string { return "uint64(" + value + ")" }
+	if micro {
+		filterType, bound = "Int64Filter", func(value string) string { return "tierBound(" + value + ")" }
+	}
+
+	fmt.Fprintf(sb, "\tswitch filter := req.%s.GetFilter().(type) {\n", field)
+	fmt.Fprintf(sb, "\tcase *%s_Eq:\n", filterType)
+	fmt.Fprintf(sb, "\t\ttiers.From, tiers.To = %s, %s\n", bound("filter.Eq"), bound("filter.Eq"))
+	for _, op := range []string{"Gt", "Gte"} {
+		fmt.Fprintf(sb, "\tcase *%s_%s:\n", filterType, op)
+		fmt.Fprintf(sb, "\t\ttiers.From = %s\n", bound("filter."+op))
+	}
+	for _, op := range []string{"Lt", "Lte"} {
+		fmt.Fprintf(sb, "\tcase *%s_%s:\n", filterType, op)
+		fmt.Fprintf(sb, "\t\ttiers.To = %s\n", bound("filter."+op))
+	}
+	fmt.Fprintf(sb, "\tcase *%s_Between:\n", filterType)
+	fmt.Fprintf(sb, "\t\ttiers.From, tiers.To = %s, %s\n", bound("filter.Between.Min"), bound("filter.Between.Max.GetValue()"))
+	fmt.Fprintf(sb, "\tcase *%s_In:\n", filterType)
+	fmt.Fprintf(sb, "\t\tfor i, value := range filter.In.Values {\n")
+	fmt.Fprintf(sb, "\t\t\tif v := %s; i == 0 || v < tiers.From {\n", bound("value"))
+	fmt.Fprintf(sb, "\t\t\t\ttiers.From = v\n")
+	fmt.Fprintf(sb, "\t\t\t}\n")
+	fmt.Fprintf(sb, "\t\t\tif v := %s; v > tiers.To {\n", bound("value"))
+	fmt.Fprintf(sb, "\t\t\t\ttiers.To = v\n")
+	fmt.Fprintf(sb, "\t\t\t}\n")
+	fmt.Fprintf(sb, "\t\t}\n")
+	fmt.Fprintf(sb, "\t}\n")
+}
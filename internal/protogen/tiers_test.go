@@ -0,0 +1,210 @@
+package protogen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_TiersColumn(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("finalized_at", "Nullable(DateTime)", 2),
+		},
+		SortingKey: []string{"slot"},
+	}
+
+	for _, column := range []string{"slot", "finalized_at", "missing"} {
+		t.Run(column, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.OutputDir = t.TempDir()
+			cfg.Tiers = map[string]config.TierConfig{
+				"fct_block": {Column: column, Retention: time.Hour, Archive: "archive.fct_block"},
+			}
+			err := NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table})
+			require.ErrorIs(t, err, ErrInvalidTierColumn)
+		})
+	}
+}
+
+func TestGenerator_TiersQueryBuilders(t *testing.T) {
+	const archive = "s3('https://archive.example.com/fct_block/*.parquet', 'Parquet')"
+	tiers := func(micro bool) string {
+		return fmt.Sprintf("\ttiers := Tiers{Column: \"slot_start_date_time\", Micro: %t, Columns: []string{\"slot_start_date_time\", \"slot\"}, "+
+			"Archive: %q, RetentionSeconds: 7776000}\n", micro, archive)
+	}
+
+	tests := []struct {
+		name       string
+		columnType string
+		sortingKey []string
+		mask       string
+		list       []string
+		get        []string
+		absent     []string
+	}{
+		{
+			name:       "DateTime",
+			columnType: "DateTime",
+			sortingKey: []string{"slot_start_date_time", "slot"},
+			list: []string{
+				tiers(false),
+				"\tcase *UInt32Filter_Eq:\n\t\ttiers.From, tiers.To = uint64(filter.Eq), uint64(filter.Eq)\n",
+				"\tcase *UInt32Filter_Between:\n\t\ttiers.From, tiers.To = uint64(filter.Between.Min), uint64(filter.Between.Max.GetValue())\n",
+			},
+			get: []string{
+				tiers(false),
+				"\ttiers.From, tiers.To = uint64(req.SlotStartDateTime), uint64(req.SlotStartDateTime)\n",
+			},
+		},
+		{
+			name:       "DateTime64",
+			columnType: "DateTime64(6)",
+			sortingKey: []string{"slot_start_date_time", "slot"},
+			list: []string{
+				tiers(true),
+				"\tcase *Int64Filter_Eq:\n\t\ttiers.From, tiers.To = tierBound(filter.Eq), tierBound(filter.Eq)\n",
+			},
+			get: []string{
+				tiers(true),
+				"\ttiers.From, tiers.To = tierBound(req.SlotStartDateTime), tierBound(req.SlotStartDateTime)\n",
+			},
+		},
+		{
+			name:       "Not the primary key",
+			columnType: "DateTime",
+			sortingKey: []string{"slot", "slot_start_date_time"},
+			list:       []string{"\tswitch filter := req.SlotStartDateTime.GetFilter().(type) {\n"},
+			get:        []string{tiers(false)},
+			absent:     []string{"tiers.From, tiers.To = uint64(req."},
+		},
+		{
+			name:       "Masked",
+			columnType: "DateTime",
+			sortingKey: []string{"slot"},
+			mask:       config.MaskHash,
+			list:       []string{tiers(false)},
+			absent:     []string{"switch filter := req.SlotStartDateTime"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &clickhouse.Table{
+				Name:     "fct_block",
+				Database: "default",
+				Engine:   "MergeTree",
+				Columns: []clickhouse.Column{
+					clickhouse.NewColumn("slot_start_date_time", tt.columnType, 1),
+					clickhouse.NewColumn("slot", "UInt32", 2),
+				},
+				SortingKey: tt.sortingKey,
+			}
+
+			cfg := config.NewConfig()
+			cfg.OutputDir = t.TempDir()
+			cfg.Tiers = map[string]config.TierConfig{
+				"fct_block": {Column: "slot_start_date_time", Retention: 2160 * time.Hour, Archive: archive},
+			}
+			if tt.mask != "" {
+				cfg.Columns = map[string]map[string]config.ColumnConfig{"fct_block": {"slot_start_date_time": {Mask: tt.mask}}}
+			}
+			log := logrus.New()
+			log.SetLevel(logrus.ErrorLevel)
+			require.NoError(t, NewGenerator(cfg, log).Generate([]*clickhouse.Table{table}))
+
+			content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_block_sql.go"))
+			require.NoError(t, err)
+			list, get, ok := strings.Cut(string(content), "func BuildGetFctBlockQuery(")
+			require.True(t, ok)
+
+			comment := "// Rows whose slot_start_date_time is older than 2160h0m0s are read from " + archive + ".\n"
+			assert.Contains(t, list, comment+"func BuildListFctBlockQuery(")
+			for _, want := range tt.list {
+				assert.Contains(t, list, want)
+			}
+			for _, want := range tt.get {
+				assert.Contains(t, get, want)
+			}
+			for _, unwanted := range tt.absent {
+				assert.NotContains(t, string(content), unwanted)
+			}
+		})
+	}
+}
+
+// tiersSampleTest runs in the generated package and checks which tiered reads can be sampled
+const tiersSampleTest = `package testv1
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTiersSample(t *testing.T) {
+	now := uint32(time.Now().Unix())
+	since := func(ago time.Duration, sample float64) *ListFctBlockRequest {
+		gte := &UInt32Filter_Gte{Gte: now - uint32(ago.Seconds())}
+		return &ListFctBlockRequest{SlotStartDateTime: &UInt32Filter{Filter: gte}, Sample: sample}
+	}
+
+	// Recent rows are read from the table alone, which can be sampled
+	q, err := BuildListFctBlockQuery(since(time.Hour, 0.1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(q.Query, "AS _t SAMPLE 0.1") || strings.Contains(q.Query, "UNION ALL") {
+		t.Errorf("recent rows aren't sampled from the table alone: %s", q.Query)
+	}
+
+	// Reads reaching the archive are complete unless sampled
+	if _, err := BuildListFctBlockQuery(since(100*24*time.Hour, 0)); err != nil {
+		t.Fatal(err)
+	}
+	archived := &ListFctBlockRequest{SlotStartDateTime: &UInt32Filter{Filter: &UInt32Filter_Lte{Lte: now - 100*24*3600}}, Sample: 0.1}
+	for _, req := range []*ListFctBlockRequest{since(100*24*time.Hour, 0.1), archived} {
+		_, err := BuildListFctBlockQuery(req)
+		var invalid *InvalidFilterError
+		if !errors.As(err, &invalid) || invalid.Field != "sample" {
+			t.Errorf("%v: sampling the archive isn't rejected: %v", req, err)
+		}
+	}
+}
+`
+
+func TestGenerator_TiersSample(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.GoPackage = "example.com/gen/testv1"
+	cfg.Tiers = map[string]config.TierConfig{
+		"fct_block": {Column: "slot_start_date_time", Retention: 2160 * time.Hour, Archive: "archive.fct_block"},
+	}
+	generateModule(t, cfg, []*clickhouse.Table{{
+		Name:   "fct_block",
+		Engine: "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot_start_date_time", "DateTime", 1),
+			clickhouse.NewColumn("slot", "UInt32", 2),
+		},
+		SortingKey:  []string{"slot_start_date_time", "slot"},
+		SamplingKey: "slot",
+	}})
+
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "tiers_test.go"), []byte(tiersSampleTest), 0o600))
+	goCommand(t, cfg.OutputDir, "test", "-run", "TestTiersSample", ".")
+}
@@ -0,0 +1,95 @@
+package protogen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// queryTarget returns the database and table the SQL helpers of table read from: the
+// underlying local table when a Distributed table is configured to target it, the table
+// itself otherwise. The database is only set for a local table in another database.
+func (g *Generator) queryTarget(table *clickhouse.Table) (string, string) {
+	topology := clickhouse.TableTopology(table)
+	if topology.Kind != clickhouse.TopologyDistributed || g.config.TopologyTarget(table.Name) != config.TargetLocal {
+		return "", table.Name
+	}
+
+	if topology.LocalDatabase != "" && topology.LocalDatabase != table.Database {
+		return topology.LocalDatabase, topology.LocalTable
+	}
+	return "", topology.LocalTable
+}
+
+// queryTable returns the name of the table the SQL helpers of table read from, qualified
+// with its database when it's in another one
+func (g *Generator) queryTable(table *clickhouse.Table) string {
+	database, name := g.queryTarget(table)
+	if database != "" {
+		return database + "." + name
+	}
+	return name
+}
+
+// queryTableArg returns the Go string literal of the table the query builders of table
+// pass to the common builders, which quote it. A local table in another database is read
+// from it with writeTopologyOption.
+func (g *Generator) queryTableArg(table *clickhouse.Table) string {
+	_, name := g.queryTarget(table)
+	return strconv.Quote(name)
+}
+
+// writeTopologyOption writes the option of a query builder reading a local table from its
+// database, when it's in another one than the Distributed table
+func (g *Generator) writeTopologyOption(sb *strings.Builder, table *clickhouse.Table) {
+	if database, _ := g.queryTarget(table); database != "" {
+		fmt.Fprintf(sb, "\t// The local table is in %s, unless a WithDatabase option names another database\n", database)
+		fmt.Fprintf(sb, "\toptions = append([]QueryOption{WithDatabase(%q)}, options...)\n\n", database)
+	}
+}
+
+// writeTopologyComment documents on a query builder that it reads a local table directly
+func (g *Generator) writeTopologyComment(sb *strings.Builder, table *clickhouse.Table) {
+	if target := g.queryTable(table); target != table.Name {
+		fmt.Fprintf(sb, "//\n")
+		fmt.Fprintf(sb, "// Reads the local table %s instead of the Distributed table %s, so results only\n", target, table.Name)
+		fmt.Fprintf(sb, "// include the shard of the replica the query is sent to.\n")
+	}
+}
+
+// topologyReport describes a clustered table for the generation report, nil for tables
+// stored only on the node they are queried on
+func (g *Generator) topologyReport(table *clickhouse.Table) *TopologyReport {
+	topology := clickhouse.TableTopology(table)
+	if topology.Kind == clickhouse.TopologyLocal {
+		return nil
+	}
+
+	report := &TopologyReport{Kind: topology.Kind}
+	if topology.Kind == clickhouse.TopologyDistributed {
+		report.Cluster = topology.Cluster
+		report.LocalTable = topology.LocalTable
+		if topology.LocalDatabase != "" {
+			report.LocalTable = topology.LocalDatabase + "." + topology.LocalTable
+		}
+		report.ShardingKey = topology.ShardingKey
+	}
+	if len(table.Columns) > 0 && len(table.SortingKey) > 0 {
+		report.QueryTable = g.queryTable(table)
+	}
+	return report
+}
+
+// topologyWarning reports a local target configured for a table that isn't Distributed
+func (g *Generator) topologyWarning(table *clickhouse.Table) string {
+	if g.config.Topology[table.Name].Target != config.TargetLocal {
+		return ""
+	}
+	if clickhouse.TableTopology(table).Kind == clickhouse.TopologyDistributed {
+		return ""
+	}
+	return "topology target local ignored: table is not a Distributed table"
+}
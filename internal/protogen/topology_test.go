@@ -0,0 +1,145 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func topologyTestTables() []*clickhouse.Table {
+	columns := []clickhouse.Column{
+		clickhouse.NewColumn("id", "UInt64", 1),
+		clickhouse.NewColumn("name", "String", 2),
+	}
+
+	return []*clickhouse.Table{
+		{
+			Name:       "events_local",
+			Database:   "test",
+			Engine:     "ReplicatedMergeTree('/clickhouse/{shard}/events', '{replica}')",
+			Columns:    columns,
+			SortingKey: []string{"id"},
+		},
+		{
+			Name:       "events",
+			Database:   "test",
+			Engine:     "Distributed(prod, currentDatabase(), events_local, rand())",
+			Columns:    columns,
+			SortingKey: []string{"id"},
+		},
+		{
+			Name:       "blocks",
+			Database:   "test",
+			Engine:     "Distributed(prod, archive, blocks_local)",
+			Columns:    columns,
+			SortingKey: []string{"id"},
+		},
+	}
+}
+
+func TestGenerator_TopologyTargets(t *testing.T) {
+	tests := []struct {
+		name           string
+		topology       map[string]config.TopologyConfig
+		expectedEvents string
+		expectedBlocks string
+		eventsLocal    bool
+	}{
+		{
+			name:           "Distributed by default",
+			expectedEvents: `BuildParameterizedQuery("events", `,
+			expectedBlocks: `BuildParameterizedQuery("blocks", `,
+		},
+		{
+			name:           "Local for all Distributed tables",
+			topology:       map[string]config.TopologyConfig{"*": {Target: config.TargetLocal}},
+			expectedEvents: `BuildParameterizedQuery("events_local", `,
+			expectedBlocks: "\toptions = append([]QueryOption{WithDatabase(\"archive\")}, options...)\n\n" +
+				"\t// Build column list\n\tcolumns := []string{\"id\", \"name\"}\n\n\treturn BuildParameterizedQuery(\"blocks_local\", ",
+			eventsLocal: true,
+		},
+		{
+			name: "Per table target",
+			topology: map[string]config.TopologyConfig{
+				"*":      {Target: config.TargetLocal},
+				"blocks": {Target: config.TargetDistributed},
+			},
+			expectedEvents: `BuildParameterizedQuery("events_local", `,
+			expectedBlocks: `BuildParameterizedQuery("blocks", `,
+			eventsLocal:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.OutputDir = t.TempDir()
+			cfg.Topology = tt.topology
+
+			log := logrus.New()
+			log.SetLevel(logrus.ErrorLevel)
+			require.NoError(t, NewGenerator(cfg, log).Generate(topologyTestTables()))
+
+			events, err := os.ReadFile(filepath.Join(cfg.OutputDir, "events_sql.go"))
+			require.NoError(t, err)
+			// The List and Get builders read the same table, and document reading a local one
+			assert.Equal(t, 2, strings.Count(string(events), tt.expectedEvents))
+			comment := "// Reads the local table events_local instead of the Distributed table events, so results only\n"
+			if tt.eventsLocal {
+				assert.Equal(t, 2, strings.Count(string(events), comment))
+			} else {
+				assert.NotContains(t, string(events), comment)
+			}
+
+			blocks, err := os.ReadFile(filepath.Join(cfg.OutputDir, "blocks_sql.go"))
+			require.NoError(t, err)
+			assert.Contains(t, string(blocks), tt.expectedBlocks)
+
+			local, err := os.ReadFile(filepath.Join(cfg.OutputDir, "events_local_sql.go"))
+			require.NoError(t, err)
+			assert.Contains(t, string(local), `BuildParameterizedQuery("events_local", `)
+		})
+	}
+}
+
+func TestGenerator_TopologyReport(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Topology = map[string]config.TopologyConfig{
+		"events":       {Target: config.TargetLocal},
+		"events_local": {Target: config.TargetLocal},
+	}
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	gen := NewGenerator(cfg, log)
+	require.NoError(t, gen.Generate(topologyTestTables()))
+
+	report := gen.Report()
+	require.Len(t, report.Tables, 3)
+
+	assert.Equal(t, &TopologyReport{Kind: clickhouse.TopologyReplicated, QueryTable: "events_local"}, report.Tables[0].Topology)
+	assert.Equal(t, []string{"topology target local ignored: table is not a Distributed table"}, report.Tables[0].Warnings)
+
+	assert.Equal(t, &TopologyReport{
+		Kind:        clickhouse.TopologyDistributed,
+		Cluster:     "prod",
+		LocalTable:  "test.events_local",
+		ShardingKey: "rand()",
+		QueryTable:  "events_local",
+	}, report.Tables[1].Topology)
+
+	assert.Equal(t, &TopologyReport{
+		Kind:       clickhouse.TopologyDistributed,
+		Cluster:    "prod",
+		LocalTable: "archive.blocks_local",
+		QueryTable: "blocks",
+	}, report.Tables[2].Topology)
+}
@@ -0,0 +1,102 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+)
+
+// applyUnitToField notes the configured unit of a column in its message field's comment
+// and clickhouse.v1.unit option
+func (g *Generator) applyUnitToField(field *ProtoField, column *clickhouse.Column, tableName string) {
+	unit := g.config.ColumnOverrides(tableName, column.Name).Unit
+	if unit == "" {
+		return
+	}
+
+	note := fmt.Sprintf("Value in %s.", unit)
+	if field.Comment == "" {
+		field.Comment = note
+	} else {
+		field.Comment += "\n" + note
+	}
+
+	if g.config.Emits(config.EmitAnnotations) {
+		field.Options = append(field.Options, fmt.Sprintf("(clickhouse.v1.unit) = %q", unit))
+	}
+}
+
+// GenerateUnitHelpers generates a units.go file converting between Ethereum denominations.
+// Amounts are decimal strings, so values of UInt256 columns convert without overflow.
+func (g *Generator) GenerateUnitHelpers() error {
+	sb := &strings.Builder{}
+	g.writeSQLFileHeader(sb, "This file provides conversions between units of column values.")
+
+	sb.WriteString(`import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Decimal places of gwei and ether amounts in wei
+const (
+	gweiDecimals  = 9
+	etherDecimals = 18
+)
+
+// WeiToGwei converts an amount of wei, a decimal integer string, to a decimal string of
+// gwei, e.g. "1500000000" to "1.5"
+func WeiToGwei(wei string) (string, error) {
+	return shiftDecimals(wei, gweiDecimals)
+}
+
+// WeiToEther converts an amount of wei, a decimal integer string, to a decimal string of
+// ether, e.g. "250000000000000000" to "0.25"
+func WeiToEther(wei string) (string, error) {
+	return shiftDecimals(wei, etherDecimals)
+}
+
+// GweiToEther converts an amount of gwei, a decimal integer string, to a decimal string of
+// ether, e.g. "32000000000" to "32"
+func GweiToEther(gwei string) (string, error) {
+	return shiftDecimals(gwei, etherDecimals-gweiDecimals)
+}
+
+// shiftDecimals divides a decimal integer string by 10^decimals without rounding, trimming
+// trailing zeros of the fraction
+func shiftDecimals(value string, decimals int) (string, error) {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid integer amount: %q", value)
+	}
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+		n.Neg(n)
+	}
+
+	digits := n.String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole := digits[:len(digits)-decimals]
+	fraction := strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if fraction == "" {
+		return sign + whole, nil
+	}
+	return sign + whole + "." + fraction, nil
+}
+`)
+
+	filename := filepath.Join(g.goOutputDir(), "units.go")
+	if err := g.writeFile(filename, sb.String()); err != nil {
+		return err
+	}
+
+	g.log.WithField("file", filename).Info("Generated unit helper file")
+	return nil
+}
@@ -0,0 +1,69 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Units(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt64", 1),
+			{Name: "base_fee", Type: "UInt256", BaseType: "UInt256", Comment: "Base fee per gas", Position: 2},
+			clickhouse.NewColumn("block_total_bytes", "UInt64", 3),
+		},
+		SortingKey: []string{"slot"},
+	}
+
+	generate := func(t *testing.T, configure func(*config.Config)) (string, string) {
+		t.Helper()
+		cfg := config.NewConfig()
+		cfg.OutputDir = t.TempDir()
+		cfg.Columns = map[string]map[string]config.ColumnConfig{
+			"fct_block": {"base_fee": {Unit: "wei"}},
+			"*":         {"block_total_bytes": {Unit: "bytes"}},
+		}
+		configure(cfg)
+		require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+		proto, err := os.ReadFile(filepath.Join(cfg.OutputDir, "fct_block.proto"))
+		require.NoError(t, err)
+		return string(proto), cfg.OutputDir
+	}
+
+	t.Run("Annotated", func(t *testing.T) {
+		proto, dir := generate(t, func(*config.Config) {})
+
+		assert.Contains(t, proto, "  // Base fee per gas\n  // Value in wei.\n  string base_fee = 12 [(clickhouse.v1.unit) = \"wei\"];")
+		assert.Contains(t, proto, "  // Value in bytes.\n  uint64 block_total_bytes = 13 [(clickhouse.v1.unit) = \"bytes\"];")
+		assert.NoFileExists(t, filepath.Join(dir, "units.go"))
+	})
+
+	t.Run("Helpers", func(t *testing.T) {
+		_, dir := generate(t, func(cfg *config.Config) { cfg.UnitHelpers = true })
+
+		content, err := os.ReadFile(filepath.Join(dir, "units.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "func WeiToGwei(wei string) (string, error)")
+		assert.Contains(t, string(content), "func GweiToEther(gwei string) (string, error)")
+	})
+
+	t.Run("Without annotations", func(t *testing.T) {
+		proto, _ := generate(t, func(cfg *config.Config) {
+			cfg.Emit = []string{config.EmitMessages, config.EmitServices}
+		})
+
+		assert.Contains(t, proto, "// Value in wei.")
+		assert.NotContains(t, proto, "clickhouse.v1.unit")
+	})
+}
@@ -0,0 +1,94 @@
+package protogen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+)
+
+// upsertVersion is the version column of a ReplacingMergeTree table that an Upsert sets to
+// the current time for rows without a version
+type upsertVersion struct {
+	column string // ClickHouse version column
+	now    string // Go expression of the current time in the field's type and unit
+}
+
+// upsertEnabled reports whether a table gets an Upsert builder: it takes Insert requests
+// and deduplicates rows with ReplacingMergeTree
+func (g *Generator) upsertEnabled(table *clickhouse.Table) (clickhouse.Replacing, bool) {
+	if !g.insertEnabled(table) {
+		return clickhouse.Replacing{}, false
+	}
+	return clickhouse.TableReplacing(table)
+}
+
+// upsertVersionFor returns the version column an Upsert fills in, or nil when the table has
+// no version column or rows have to carry their own version. Times are in the unit the
+// List request reads the column in; other integer versions take the time in microseconds.
+func (g *Generator) upsertVersionFor(table *clickhouse.Table) *upsertVersion {
+	replacing, ok := g.upsertEnabled(table)
+	if !ok || replacing.VersionColumn == "" {
+		return nil
+	}
+
+	columns, fields := g.insertColumns(table)
+	for i, col := range columns {
+		if col.Name != replacing.VersionColumn {
+			continue
+		}
+
+		goType := fields[i].Type
+		switch {
+		case col.IsNullable || col.IsArray:
+		case goType == protoUInt32 && (col.BaseType == clickhouseDateTime || col.BaseType == "UInt32"):
+			return &upsertVersion{column: col.Name, now: "uint32(now.Unix())"}
+		case goType == protoInt64 || goType == protoUInt64:
+			return &upsertVersion{column: col.Name, now: fmt.Sprintf("%s(now.UnixMicro())", goType)}
+		}
+		g.log.WithFields(logrus.Fields{
+			"table":  table.Name,
+			"column": col.Name,
+			"type":   col.Type,
+		}).Debug("Upsert rows have to carry their version: no current time fits the version column")
+		return nil
+	}
+	return nil
+}
+
+// writeUpsertSQLBuilderFunction generates the Upsert builder of a ReplacingMergeTree table,
+// an INSERT of the rows of an Insert request documenting how they replace stored rows
+func (g *Generator) writeUpsertSQLBuilderFunction(sb *strings.Builder, table *clickhouse.Table) {
+	replacing, ok := g.upsertEnabled(table)
+	if !ok {
+		return
+	}
+
+	messageName := getProtocMessageName(table.Name)
+	tenant, _ := g.tenantScopeFor(table)
+	version := g.upsertVersionFor(table)
+
+	fmt.Fprintf(sb, "\n// BuildUpsert%sQuery constructs a parameterized INSERT of the rows of an Insert%sRequest\n", messageName, messageName)
+	fmt.Fprintf(sb, "// that replace the stored rows with the same sorting key (%s).\n", strings.Join(table.SortingKey, ", "))
+	fmt.Fprintf(sb, "//\n")
+	switch {
+	case version != nil:
+		fmt.Fprintf(sb, "// ReplacingMergeTree keeps the row with the highest %s of each key. Rows without\n", version.column)
+		fmt.Fprintf(sb, "// one get the current time, so they replace rows written before.\n")
+	case replacing.VersionColumn != "":
+		fmt.Fprintf(sb, "// ReplacingMergeTree keeps the row with the highest %s of each key, which every\n", replacing.VersionColumn)
+		fmt.Fprintf(sb, "// row has to carry: a lower version than the stored row's is discarded.\n")
+	default:
+		fmt.Fprintf(sb, "// ReplacingMergeTree has no version column here, so it keeps the last inserted row of\n")
+		fmt.Fprintf(sb, "// each key.\n")
+	}
+	if replacing.IsDeletedColumn != "" {
+		fmt.Fprintf(sb, "// Rows with %s set to 1 delete their key.\n", replacing.IsDeletedColumn)
+	}
+	fmt.Fprintf(sb, "//\n")
+	fmt.Fprintf(sb, "// Replaced rows are only dropped when ClickHouse merges parts, which may never happen\n")
+	fmt.Fprintf(sb, "// for some of them; read with FINAL (WithFinal) to see only the latest row of each key.\n")
+	fmt.Fprintf(sb, "func BuildUpsert%sQuery(req *Insert%sRequest%s, options ...QueryOption) (SQLQuery, error) {\n", messageName, messageName, tenantParam(tenant))
+	g.writeInsertBody(sb, table, tenant, version)
+}
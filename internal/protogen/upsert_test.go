@@ -0,0 +1,69 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Upsert(t *testing.T) {
+	replacing := func(name, engine, versionType string) *clickhouse.Table {
+		return &clickhouse.Table{
+			Name:     name,
+			Database: "default",
+			Engine:   engine,
+			Columns: []clickhouse.Column{
+				clickhouse.NewColumn("slot", "UInt32", 1),
+				clickhouse.NewColumn("updated_date_time", versionType, 2),
+				clickhouse.NewColumn("is_deleted", "UInt8", 3),
+			},
+			SortingKey: []string{"slot"},
+		}
+	}
+	tables := []*clickhouse.Table{
+		replacing("fct_block", "ReplacingMergeTree(updated_date_time, is_deleted)", "DateTime"),
+		replacing("fct_block_micros", "ReplicatedReplacingMergeTree('/clickhouse/{shard}/fct', '{replica}', updated_date_time)", "DateTime64(6)"),
+		replacing("fct_block_dated", "ReplacingMergeTree(updated_date_time)", "Date"),
+		replacing("fct_block_latest", "ReplacingMergeTree", "DateTime"),
+		replacing("fct_block_log", "MergeTree", "DateTime"),
+	}
+
+	on := true
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Policies = []config.PolicyConfig{{Match: "fct_", Insert: &on}}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate(tables))
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(cfg.OutputDir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	sql := read("fct_block_sql.go")
+	assert.Contains(t, sql, "\t\"time\"\n")
+	assert.Contains(t, sql, "func BuildUpsertFctBlockQuery(req *InsertFctBlockRequest, options ...QueryOption) (SQLQuery, error) {")
+	assert.Contains(t, sql, "// Rows with is_deleted set to 1 delete their key.")
+	assert.Contains(t, sql, "\t\tversion := row.GetUpdatedDateTime()\n\t\tif version == 0 {\n\t\t\tversion = uint32(now.Unix())\n\t\t}")
+	assert.Contains(t, sql, "args = append(args, row.GetSlot(), version, row.GetIsDeleted())")
+
+	micros := read("fct_block_micros_sql.go")
+	assert.Contains(t, micros, "version = int64(now.UnixMicro())")
+
+	// Versions no current time fits have to be provided
+	dated := read("fct_block_dated_sql.go")
+	assert.Contains(t, dated, "func BuildUpsertFctBlockDatedQuery(")
+	assert.Contains(t, dated, "which every\n// row has to carry")
+	assert.NotContains(t, dated, "\"time\"")
+
+	latest := read("fct_block_latest_sql.go")
+	assert.Contains(t, latest, "it keeps the last inserted row")
+
+	assert.NotContains(t, read("fct_block_log_sql.go"), "BuildUpsert")
+}
@@ -0,0 +1,219 @@
+package protogen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// requestMessage is a request message of a table with a validator
+type requestMessage struct {
+	name string
+	// get is set for the Get request, whose primary key is a value rather than a filter
+	get bool
+	// paged is set for the List request, whose page_size is bounded
+	paged bool
+	// having is set for the ListBuckets request, whose having filters are checked too
+	having bool
+}
+
+// requestMessages returns the request messages of a table with validators, in the order
+// their query builders are written
+func (g *Generator) requestMessages(table *clickhouse.Table) []requestMessage {
+	messageName := getProtocMessageName(table.Name)
+	requests := []requestMessage{
+		{name: fmt.Sprintf("List%sRequest", messageName), paged: true},
+		{name: fmt.Sprintf("Get%sRequest", messageName), get: true},
+	}
+	if g.bucketColumn(table) != nil {
+		requests = append(requests, requestMessage{name: fmt.Sprintf("List%sBucketsRequest", messageName), having: true})
+	}
+	if g.mutationsEnabled(table) {
+		requests = append(requests, requestMessage{name: fmt.Sprintf("Delete%sRequest", messageName)})
+		if columns, _ := g.updatableColumns(table); len(columns) > 0 {
+			requests = append(requests, requestMessage{name: fmt.Sprintf("Update%sRequest", messageName)})
+		}
+	}
+	return requests
+}
+
+// writeRequestValidators writes the Validate<Request> function of every request message of a
+// table, which its query builder calls first
+func (g *Generator) writeRequestValidators(sb *strings.Builder, table *clickhouse.Table) {
+	for _, request := range g.requestMessages(table) {
+		g.writeRequestValidator(sb, table, request)
+	}
+}
+
+// writeRequestValidator writes the validator of one request message. The filters of List,
+// ListBuckets, Delete and Update requests get the same checks.
+func (g *Generator) writeRequestValidator(sb *strings.Builder, table *clickhouse.Table, request requestMessage) {
+	fmt.Fprintf(sb, "// Validate%s checks a %s before it is queried: the primary_key\n", request.name, request.name)
+	fmt.Fprintf(sb, "// required group, the bounds of between filters and the length of list filters. Servers\n")
+	fmt.Fprintf(sb, "// and interceptors can call it, or ValidateRequest, to reject requests early.\n")
+	fmt.Fprintf(sb, "func Validate%s(req *%s) error {\n", request.name, request.name)
+	if request.get {
+		g.writeGetRequestValidation(sb, table)
+	} else {
+		g.writePrimaryKeyValidation(sb, table)
+		g.writeFilterValidations(sb, table)
+	}
+	if request.having {
+		fmt.Fprintf(sb, "\tswitch filter := req.GetHaving().GetCount().GetFilter().(type) {\n")
+		writeFilterTypeValidation(sb, "having.count", "UInt64Filter", "\t")
+		fmt.Fprintf(sb, "\t}\n\n")
+	}
+	if request.paged {
+		g.writePageSizeValidation(sb)
+	}
+	fmt.Fprintf(sb, "\treturn nil\n")
+	fmt.Fprintf(sb, "}\n\n")
+}
+
+// writeGetRequestValidation writes the check of the primary key of a Get request, a plain
+// value rather than a filter
+func (g *Generator) writeGetRequestValidation(sb *strings.Builder, table *clickhouse.Table) {
+	if len(table.SortingKey) == 0 {
+		return
+	}
+	primaryKey := table.SortingKey[0]
+	field := g.fieldName(table.Name, primaryKey)
+	zero := "0"
+	if column := findColumn(table, primaryKey); column != nil {
+		if protoType, _ := g.typeMapper.MapType(column, table.Name, &g.config.Conversion); protoType == protoString {
+			zero = `""`
+		}
+	}
+	fmt.Fprintf(sb, "\t// Validate primary key is provided\n")
+	fmt.Fprintf(sb, "\tif req.%s == %s {\n", ToPascalCase(field), zero)
+	fmt.Fprintf(sb, "\t\treturn invalidFilter(%q, \"primary key field %s is required\")\n", field, field)
+	fmt.Fprintf(sb, "\t}\n\n")
+}
+
+// writePageSizeValidation writes the bounds of the page_size of a List request
+func (g *Generator) writePageSizeValidation(sb *strings.Builder) {
+	fmt.Fprintf(sb, "\t// Validate page size\n")
+	fmt.Fprintf(sb, "\tif req.PageSize < 0 {\n")
+	fmt.Fprintf(sb, "\t\treturn invalidFilter(\"page_size\", \"page_size must be non-negative, got %%d\", req.PageSize)\n")
+	fmt.Fprintf(sb, "\t}\n")
+	fmt.Fprintf(sb, "\tif req.PageSize > %d {\n", g.config.MaxPageSize)
+	fmt.Fprintf(sb, "\t\treturn invalidFilter(\"page_size\", \"page_size must not exceed %%d, got %%d\", %d, req.PageSize)\n", g.config.MaxPageSize)
+	fmt.Fprintf(sb, "\t}\n\n")
+}
+
+// writeFilterValidations writes the checks of the filters of a request, in the order of
+// writeAllFilterConditions. Masked columns have no filter.
+func (g *Generator) writeFilterValidations(sb *strings.Builder, table *clickhouse.Table) {
+	var primaryKey string
+	if len(table.SortingKey) > 0 {
+		primaryKey = table.SortingKey[0]
+		g.writeFilterValidation(sb, table, findColumn(table, primaryKey))
+	}
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		if col.Name == primaryKey || g.isMasked(table.Name, col.Name) {
+			continue
+		}
+		g.writeFilterValidation(sb, table, col)
+	}
+}
+
+// writeFilterValidation writes the check of the filter of one column, if its filter type has
+// a range or lists to check
+func (g *Generator) writeFilterValidation(sb *strings.Builder, table *clickhouse.Table, column *clickhouse.Column) {
+	if column == nil {
+		return
+	}
+	filterType := g.typeMapper.GetFilterTypeForColumn(column, table.Name, &g.config.Conversion)
+	if filterType == "" || strings.HasSuffix(filterType, "BoolFilter") {
+		return
+	}
+	field := g.fieldName(table.Name, column.Name)
+	fmt.Fprintf(sb, "\tswitch filter := req.Get%s().GetFilter().(type) {\n", ToPascalCase(field))
+	writeFilterTypeValidation(sb, field, filterType, "\t")
+	fmt.Fprintf(sb, "\t}\n\n")
+}
+
+// writeFilterTypeValidation writes the switch cases checking a filter of a type: the bounds
+// of between and the length of the value lists. The key_value filters of numeric and string
+// maps get the checks of their value filter.
+func writeFilterTypeValidation(sb *strings.Builder, field, filterType, indent string) {
+	var lists [][2]string
+	switch {
+	case strings.HasPrefix(filterType, "Array"):
+		lists = [][2]string{{"HasAll", "has_all"}, {"HasAny", "has_any"}}
+	case strings.HasPrefix(filterType, "Map"):
+		lists = [][2]string{{"HasAnyKey", "has_any_key"}, {"HasAllKeys", "has_all_keys"}}
+		valueType := strings.TrimPrefix(filterType, "MapString")
+		fmt.Fprintf(sb, "%scase *%s_KeyValue:\n", indent, filterType)
+		fmt.Fprintf(sb, "%s\tswitch filter := filter.KeyValue.GetValueFilter().GetFilter().(type) {\n", indent)
+		writeFilterTypeValidation(sb, field, valueType, indent+"\t")
+		fmt.Fprintf(sb, "%s\t}\n", indent)
+	default:
+		lists = [][2]string{{"In", "in"}, {"NotIn", "not_in"}}
+		if !strings.Contains(filterType, "String") {
+			fmt.Fprintf(sb, "%scase *%s_Between:\n", indent, filterType)
+			fmt.Fprintf(sb, "%s\tif upper := filter.Between.GetMax(); upper != nil && upper.GetValue() < filter.Between.GetMin() {\n", indent)
+			fmt.Fprintf(sb, "%s\t\treturn invalidFilter(%q, \"between max %%d of %s is less than min %%d\", upper.GetValue(), filter.Between.GetMin())\n", indent, field, field)
+			fmt.Fprintf(sb, "%s\t}\n", indent)
+		}
+	}
+	for _, list := range lists {
+		fmt.Fprintf(sb, "%scase *%s_%s:\n", indent, filterType, list[0])
+		fmt.Fprintf(sb, "%s\tif err := validateFilterValues(%q, %q, len(filter.%s.GetValues())); err != nil {\n", indent, field, list[1], list[0])
+		fmt.Fprintf(sb, "%s\t\treturn err\n", indent)
+		fmt.Fprintf(sb, "%s\t}\n", indent)
+	}
+}
+
+// writeValidateRequestCall writes the call of a request validator opening a query builder
+func writeValidateRequestCall(sb *strings.Builder, request string) {
+	fmt.Fprintf(sb, "\t// Validate the request\n")
+	fmt.Fprintf(sb, "\tif err := Validate%s(req); err != nil {\n", request)
+	fmt.Fprintf(sb, "\t\treturn SQLQuery{}, err\n")
+	fmt.Fprintf(sb, "\t}\n\n")
+}
+
+// GenerateRequestValidation writes validate.go, whose ValidateRequest dispatches any request
+// message to its validator, for interceptors handling every service. Without tables with
+// query builders there is nothing to dispatch, and no file.
+func (g *Generator) GenerateRequestValidation(tables []*clickhouse.Table) error {
+	var requests []requestMessage
+	for _, table := range tables {
+		// The tables hasSQLHelper accepts, without logging the others again
+		if len(table.Columns) == 0 || len(table.SortingKey) == 0 {
+			continue
+		}
+		requests = append(requests, g.requestMessages(table)...)
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	sb := &strings.Builder{}
+	g.writeSQLFileHeader(sb, "This file validates request messages before they are queried.")
+
+	sb.WriteString("// ValidateRequest checks a request message with its Validate<Request> function, and returns\n")
+	sb.WriteString("// nil for other messages. Errors are InvalidFilterError, which ErrorStatus turns into a\n")
+	sb.WriteString("// status with an InvalidFilter detail.\n")
+	sb.WriteString("func ValidateRequest(req interface{}) error {\n")
+	sb.WriteString("\tswitch req := req.(type) {\n")
+	for _, request := range requests {
+		fmt.Fprintf(sb, "\tcase *%s:\n", request.name)
+		fmt.Fprintf(sb, "\t\treturn Validate%s(req)\n", request.name)
+	}
+	sb.WriteString("\tdefault:\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n")
+
+	filename := filepath.Join(g.goOutputDir(), "validate.go")
+	if err := g.writeFile(filename, sb.String()); err != nil {
+		return err
+	}
+
+	g.log.WithField("file", filename).Info("Generated request validation file")
+	return nil
+}
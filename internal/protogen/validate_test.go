@@ -0,0 +1,88 @@
+package protogen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_RequestValidation(t *testing.T) {
+	block := &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot_start_date_time", "DateTime", 1),
+			clickhouse.NewColumn("proposer_index", "UInt32", 2),
+			clickhouse.NewColumn("client", "String", 3),
+			clickhouse.NewColumn("fee", "Nullable(Int64)", 4),
+			clickhouse.NewColumn("blobs", "Map(String, Int32)", 5),
+			clickhouse.NewColumn("tags", "Array(String)", 6),
+			clickhouse.NewColumn("graffiti", "String", 7),
+			clickhouse.NewColumn("is_empty", "Bool", 8),
+		},
+		SortingKey: []string{"slot_start_date_time"},
+		Projections: []clickhouse.Projection{
+			{Name: "by_proposer", OrderByKey: []string{"proposer_index"}, Type: "NORMAL"},
+		},
+	}
+
+	on := true
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.UnsafeMutations = true
+	cfg.Policies = []config.PolicyConfig{{Match: "fct_", Buckets: &on}}
+	cfg.Columns = map[string]map[string]config.ColumnConfig{"fct_block": {"graffiti": {Mask: config.MaskHash}}}
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{block}))
+
+	read := func(name string) string {
+		path := filepath.Join(cfg.OutputDir, name)
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		_, err = parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	sql := read("fct_block_sql.go")
+	for _, request := range []string{"ListFctBlockRequest", "GetFctBlockRequest", "ListFctBlockBucketsRequest", "DeleteFctBlockRequest", "UpdateFctBlockRequest"} {
+		assert.Contains(t, sql, "func Validate"+request+"(req *"+request+") error {")
+		assert.Contains(t, sql, "\tif err := Validate"+request+"(req); err != nil {\n\t\treturn SQLQuery{}, err\n\t}")
+	}
+
+	// Either primary key of the required group will do
+	assert.Contains(t, sql, "\tif req.ProposerIndex == nil && req.SlotStartDateTime == nil {\n"+
+		`		return invalidFilter("proposer_index", "at least one primary key field is required: proposer_index, slot_start_date_time")`)
+	assert.Contains(t, sql, "\tif req.SlotStartDateTime == 0 {\n"+
+		`		return invalidFilter("slot_start_date_time", "primary key field slot_start_date_time is required")`)
+
+	// Ranges are sane and lists are bounded, including the value filters of maps
+	assert.Contains(t, sql, "\tcase *NullableInt64Filter_Between:\n"+
+		"\t\tif upper := filter.Between.GetMax(); upper != nil && upper.GetValue() < filter.Between.GetMin() {\n"+
+		`			return invalidFilter("fee", "between max %d of fee is less than min %d", upper.GetValue(), filter.Between.GetMin())`)
+	assert.Contains(t, sql, `if err := validateFilterValues("client", "not_in", len(filter.NotIn.GetValues())); err != nil {`)
+	assert.Contains(t, sql, `if err := validateFilterValues("tags", "has_any", len(filter.HasAny.GetValues())); err != nil {`)
+	assert.Contains(t, sql, `if err := validateFilterValues("blobs", "has_all_keys", len(filter.HasAllKeys.GetValues())); err != nil {`)
+	assert.Contains(t, sql, "\tcase *MapStringInt32Filter_KeyValue:\n"+
+		"\t\tswitch filter := filter.KeyValue.GetValueFilter().GetFilter().(type) {\n"+
+		"\t\tcase *Int32Filter_Between:\n")
+	assert.NotContains(t, sql, "req.GetClient().GetFilter().(type) {\n\tcase *StringFilter_Between")
+	// Masked columns have no filter, and bools nothing to check
+	assert.NotContains(t, sql, "req.GetGraffiti()")
+	assert.NotContains(t, sql, "req.GetIsEmpty()")
+
+	assert.Contains(t, read("common.go"), "\tmaxFilterValues = 1000\n")
+
+	validate := read("validate.go")
+	assert.Contains(t, validate, "func ValidateRequest(req interface{}) error {")
+	assert.Contains(t, validate, "\tcase *ListFctBlockBucketsRequest:\n\t\treturn ValidateListFctBlockBucketsRequest(req)\n")
+	assert.Contains(t, validate, "\tcase *UpdateFctBlockRequest:\n\t\treturn ValidateUpdateFctBlockRequest(req)\n")
+}
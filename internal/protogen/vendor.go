@@ -0,0 +1,70 @@
+package protogen
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/wellknownimports"
+)
+
+// googleAPIProtos are copies of the googleapis protos behind the google/api Go package the
+// generated code depends on
+//
+//go:embed thirdparty/google/api/*.proto
+var googleAPIProtos embed.FS
+
+// vendoredImports are the import paths of the protos outside this run that generated protos
+// import, with the imports of those
+//
+//nolint:gochecknoglobals // Fixed list of files
+var vendoredImports = []string{
+	"google/protobuf/descriptor.proto",
+	"google/protobuf/empty.proto",
+	"google/protobuf/struct.proto",
+	"google/protobuf/wrappers.proto",
+	"google/api/annotations.proto",
+	"google/api/field_behavior.proto",
+	"google/api/http.proto",
+}
+
+// GenerateVendoredImports copies the google/protobuf and google/api protos into output_dir,
+// so the generated protos compile without googleapis on the include path. The well-known
+// types are the sources shipped with protoc, as embedded by protocompile.
+func (g *Generator) GenerateVendoredImports() error {
+	wellKnown := wellknownimports.WithStandardImports(protocompile.CompositeResolver{})
+
+	for _, name := range vendoredImports {
+		content, err := vendoredSource(wellKnown, name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		filename := filepath.Join(g.config.OutputDir, filepath.FromSlash(name))
+		if err := g.mkdirAll(filepath.Dir(filename)); err != nil {
+			return fmt.Errorf("failed to create directory of %s: %w", name, err)
+		}
+		if err := g.writeFile(filename, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vendoredSource returns the source of a vendored import
+func vendoredSource(wellKnown protocompile.Resolver, name string) (string, error) {
+	if strings.HasPrefix(name, "google/api/") {
+		data, err := googleAPIProtos.ReadFile("thirdparty/" + name)
+		return string(data), err
+	}
+
+	result, err := wellKnown.FindFileByPath(name)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(result.Source)
+	return string(data), err
+}
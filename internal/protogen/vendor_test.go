@@ -0,0 +1,75 @@
+package protogen
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/wellknownimports"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func TestGenerator_VendorImports(t *testing.T) {
+	table := &clickhouse.Table{
+		Name:     "fct_block",
+		Database: "default",
+		Engine:   "MergeTree",
+		Columns: []clickhouse.Column{
+			clickhouse.NewColumn("slot", "UInt32", 1),
+			clickhouse.NewColumn("block_root", "Nullable(String)", 2),
+		},
+		SortingKey: []string{"slot"},
+	}
+
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Package = "beacon.v1"
+	cfg.EnableAPI = true
+	cfg.VendorImports = true
+	cfg.DescriptorSetOut = filepath.Join(cfg.OutputDir, "schema.binpb")
+	require.NoError(t, NewGenerator(cfg, logrus.New()).Generate([]*clickhouse.Table{table}))
+
+	// The output compiles on its own, without the standard imports
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{ImportPaths: []string{cfg.OutputDir}},
+	}
+	_, err := compiler.Compile(context.Background(), "fct_block.proto")
+	require.NoError(t, err)
+}
+
+func TestVendoredGoogleAPIProtos(t *testing.T) {
+	// The copies match the descriptors of the google/api Go package
+	compiler := protocompile.Compiler{
+		Resolver: wellknownimports.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: func(path string) (io.ReadCloser, error) {
+				return googleAPIProtos.Open("thirdparty/" + path)
+			},
+		}),
+	}
+	for _, name := range vendoredImports {
+		if !strings.HasPrefix(name, "google/api/") {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			compiled, err := compiler.Compile(context.Background(), name)
+			require.NoError(t, err)
+			registered, err := protoregistry.GlobalFiles.FindFileByPath(name)
+			require.NoError(t, err)
+
+			vendored := protodesc.ToFileDescriptorProto(compiled[0])
+			vendored.SourceCodeInfo = nil
+			assert.True(t, proto.Equal(protodesc.ToFileDescriptorProto(registered), vendored), prototext.Format(vendored))
+		})
+	}
+}
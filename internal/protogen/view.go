@@ -0,0 +1,49 @@
+package protogen
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// ErrViewKeyColumn is returned when a view's configured primary key names a column the view doesn't have
+var ErrViewKeyColumn = errors.New("view primary key column not found")
+
+// ApplyViewKeys returns tables with the primary keys configured under views applied as the
+// sorting key of the matching views, so they get a read-only service and SQL helpers like
+// tables do. Views are copied before they are changed; other tables are returned as is.
+func (g *Generator) ApplyViewKeys(tables []*clickhouse.Table) ([]*clickhouse.Table, error) {
+	if len(g.config.Views) == 0 {
+		return tables, nil
+	}
+
+	result := make([]*clickhouse.Table, 0, len(tables))
+	for _, table := range tables {
+		options, ok := g.config.Views[table.Name]
+		if !ok || !clickhouse.IsView(table) {
+			result = append(result, table)
+			continue
+		}
+
+		for _, key := range options.PrimaryKey {
+			if !slices.ContainsFunc(table.Columns, func(col clickhouse.Column) bool { return col.Name == key }) {
+				return nil, fmt.Errorf("%w: %s.%s", ErrViewKeyColumn, table.Name, key)
+			}
+		}
+
+		view := *table
+		view.SortingKey = append([]string{}, options.PrimaryKey...)
+		result = append(result, &view)
+	}
+	return result, nil
+}
+
+// viewWarning reports a views entry configured for a table that isn't a view
+func (g *Generator) viewWarning(table *clickhouse.Table) string {
+	if _, ok := g.config.Views[table.Name]; !ok || clickhouse.IsView(table) {
+		return ""
+	}
+	return "views primary_key ignored: table is not a view"
+}
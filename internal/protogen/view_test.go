@@ -0,0 +1,118 @@
+package protogen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_ApplyViewKeys(t *testing.T) {
+	columns := []clickhouse.Column{
+		clickhouse.NewColumn("day", "Date", 1),
+		clickhouse.NewColumn("total", "UInt64", 2),
+	}
+	view := &clickhouse.Table{Name: "daily_totals", Engine: clickhouse.EngineView, Columns: columns, SortingKey: []string{}}
+	table := &clickhouse.Table{Name: "totals", Engine: "MergeTree", Columns: columns, SortingKey: []string{"day"}}
+
+	cfg := config.NewConfig()
+	cfg.Views = map[string]config.ViewConfig{
+		"daily_totals": {PrimaryKey: []string{"day"}},
+		"totals":       {PrimaryKey: []string{"total"}},
+	}
+	gen := NewGenerator(cfg, logrus.New())
+
+	tables, err := gen.ApplyViewKeys([]*clickhouse.Table{view, table})
+	require.NoError(t, err)
+	require.Len(t, tables, 2)
+
+	assert.Equal(t, []string{"day"}, tables[0].SortingKey)
+	assert.Empty(t, view.SortingKey, "the input view is not modified")
+	assert.Same(t, table, tables[1], "tables that aren't views are left alone")
+	assert.Equal(t, "views primary_key ignored: table is not a view", gen.viewWarning(table))
+	assert.Empty(t, gen.viewWarning(view))
+
+	cfg.Views["daily_totals"] = config.ViewConfig{PrimaryKey: []string{"missing"}}
+	_, err = gen.ApplyViewKeys([]*clickhouse.Table{view})
+	require.ErrorIs(t, err, ErrViewKeyColumn)
+}
+
+func TestGenerator_ViewService(t *testing.T) {
+	view := func() *clickhouse.Table {
+		return &clickhouse.Table{
+			Name:     "daily_signups",
+			Database: "analytics",
+			Engine:   clickhouse.EngineView,
+			Columns: []clickhouse.Column{
+				clickhouse.NewColumn("day", "Date", 1),
+				clickhouse.NewColumn("country", "LowCardinality(String)", 2),
+				clickhouse.NewColumn("signups", "UInt64", 3),
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		views      map[string]config.ViewConfig
+		contains   []string
+		notContain []string
+		sql        bool
+	}{
+		{
+			name: "Primary key configured",
+			views: map[string]config.ViewConfig{
+				"daily_signups": {PrimaryKey: []string{"day", "country"}},
+			},
+			contains: []string{
+				"  // Filter by day (PRIMARY KEY - required)\n  StringFilter day = 1;",
+				"  // Filter by country (ORDER BY column 2 - optional)\n  StringFilter country = 2;",
+				"message GetDailySignupsRequest {\n  string day = 1; // Primary key (required)\n}",
+				"    engine: \"View\"\n    sorting_key: [\"day\", \"country\"]\n",
+				"  rpc List(ListDailySignupsRequest) returns (ListDailySignupsResponse);",
+				"  rpc Get(GetDailySignupsRequest) returns (GetDailySignupsResponse);",
+			},
+			// Views are read-only, even with mutations allowed
+			notContain: []string{"rpc Insert", "rpc Update", "rpc Delete"},
+			sql:        true,
+		},
+		{
+			name:       "No primary key",
+			contains:   []string{"message DailySignups {"},
+			notContain: []string{"service DailySignupsService", "ListDailySignupsRequest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.OutputDir = t.TempDir()
+			cfg.UnsafeMutations = true
+			cfg.Views = tt.views
+
+			log := logrus.New()
+			log.SetLevel(logrus.ErrorLevel)
+			require.NoError(t, NewGenerator(cfg, log).Generate([]*clickhouse.Table{view()}))
+
+			content, err := os.ReadFile(filepath.Join(cfg.OutputDir, "daily_signups.proto"))
+			require.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, string(content), want)
+			}
+			for _, unwanted := range tt.notContain {
+				assert.NotContains(t, string(content), unwanted)
+			}
+
+			sqlFile := filepath.Join(cfg.OutputDir, "daily_signups_sql.go")
+			if tt.sql {
+				assert.FileExists(t, sqlFile)
+			} else {
+				assert.NoFileExists(t, sqlFile)
+			}
+		})
+	}
+}
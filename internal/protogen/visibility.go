@@ -0,0 +1,162 @@
+package protogen
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+)
+
+// visibilityProfile is a named subset of a table's columns exposed as its own message
+type visibilityProfile struct {
+	name    string
+	columns []clickhouse.Column
+}
+
+// messageSuffix returns the suffix appended to the table message name, e.g. "Public"
+func (p *visibilityProfile) messageSuffix() string {
+	return ToPascalCase(p.name)
+}
+
+// visibilityProfiles resolves the visibility profiles configured for a table, sorted by name.
+// Table-specific profiles replace wildcard ("*") profiles of the same name. Columns omitted
+// by a mask are never part of a profile.
+func (g *Generator) visibilityProfiles(table *clickhouse.Table) []visibilityProfile {
+	selections := make(map[string][]string)
+	for _, key := range []string{"*", table.Name} {
+		for name, columns := range g.config.Visibility[key] {
+			selections[name] = columns
+		}
+	}
+
+	if len(selections) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(selections))
+	for name := range selections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	profiles := make([]visibilityProfile, 0, len(names))
+	for _, name := range names {
+		columns := g.selectProfileColumns(table, name, selections[name])
+		if len(columns) == 0 {
+			// An empty column set would make WithColumns fall back to all columns
+			g.log.WithFields(logrus.Fields{
+				"table":   table.Name,
+				"profile": name,
+			}).Warn("Skipping visibility profile without any columns")
+			continue
+		}
+		profiles = append(profiles, visibilityProfile{name: name, columns: columns})
+	}
+
+	return profiles
+}
+
+// selectProfileColumns returns the table columns selected by a profile, in table order
+func (g *Generator) selectProfileColumns(table *clickhouse.Table, profile string, selection []string) []clickhouse.Column {
+	all := false
+	selected := make(map[string]bool, len(selection))
+	for _, name := range selection {
+		if name == "*" {
+			all = true
+		}
+		selected[name] = true
+	}
+
+	columns := make([]clickhouse.Column, 0, len(table.Columns))
+	for _, col := range table.Columns {
+		if !all && !selected[col.Name] {
+			continue
+		}
+		delete(selected, col.Name)
+		if g.isOmitted(table.Name, col.Name) {
+			continue
+		}
+		columns = append(columns, col)
+	}
+
+	// Wildcard profiles are shared across tables, so only warn for table-specific ones
+	if _, ok := g.config.Visibility[table.Name][profile]; ok {
+		for _, name := range slices.Sorted(maps.Keys(selected)) {
+			if name == "*" {
+				continue
+			}
+			g.log.WithFields(logrus.Fields{
+				"table":   table.Name,
+				"profile": profile,
+				"column":  name,
+			}).Warn("Visibility profile references a column that does not exist")
+		}
+	}
+
+	return columns
+}
+
+// writeVisibilityMessages writes one message per visibility profile. Fields keep the
+// numbers of the full message, so a profile message can decode a full message's bytes.
+func (g *Generator) writeVisibilityMessages(sb *strings.Builder, table *clickhouse.Table) {
+	messageName := ToPascalCase(table.Name)
+
+	numbers := g.fieldNumbers(table)
+	for _, profile := range g.visibilityProfiles(table) {
+		fmt.Fprintf(sb, "\n// %s visibility profile of %s\n", profile.name, messageName)
+		fmt.Fprintf(sb, "message %s%s {\n", messageName, profile.messageSuffix())
+
+		fields := make([]*ProtoField, 0, len(profile.columns))
+		for i := range profile.columns {
+			column := &profile.columns[i]
+			field, err := g.typeMapper.ConvertColumn(column, table.Name, &g.config.Conversion)
+			if err != nil {
+				g.log.WithError(err).WithField("column", column.Name).Warn("Failed to convert column")
+				continue
+			}
+			field.Number = numbers[column.Name]
+			g.applyRenameToField(field, column, table.Name)
+
+			g.applyTypeOverride(field, column, table.Name)
+			g.applyMaskToField(field, column, table.Name)
+			g.applyPresenceToField(field)
+			g.applyEncodingToField(field, column, table.Name)
+			g.applyJSONNameToField(field, column, table.Name)
+			g.applyDeprecation(field, column)
+			fields = append(fields, field)
+		}
+
+		g.sortFields(fields)
+		for _, field := range fields {
+			g.writeField(sb, field)
+		}
+
+		sb.WriteString("}\n")
+	}
+}
+
+// writeVisibilityColumnSets writes the SELECT column list of each visibility profile,
+// for use with the WithColumns query option
+func (g *Generator) writeVisibilityColumnSets(sb *strings.Builder, table *clickhouse.Table) {
+	messageName := getProtocMessageName(table.Name)
+
+	for _, profile := range g.visibilityProfiles(table) {
+		subset := &clickhouse.Table{Name: table.Name, Columns: profile.columns}
+		variable := fmt.Sprintf("%s%sColumns", messageName, profile.messageSuffix())
+
+		fmt.Fprintf(sb, "\n// %s selects the columns of the %s visibility profile.\n", variable, profile.name)
+		fmt.Fprintf(sb, "// Pass it to WithColumns and scan results into %s%s.\n", messageName, profile.messageSuffix())
+		fmt.Fprintf(sb, "var %s = []string{", variable)
+		for i, expr := range g.selectColumnExpressions(subset) {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(sb, "%q", expr)
+		}
+		sb.WriteString("}\n")
+	}
+}
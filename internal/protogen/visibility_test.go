@@ -0,0 +1,123 @@
+package protogen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func visibilityTestTable() *clickhouse.Table {
+	return &clickhouse.Table{
+		Name: "fct_block",
+		Columns: []clickhouse.Column{
+			{Name: "slot", Type: "UInt64", BaseType: "UInt64", Position: 1},
+			{Name: "block_root", Type: "String", BaseType: "String", Position: 2},
+			{Name: "proposer_email", Type: "String", BaseType: "String", Position: 3},
+			{Name: "secret", Type: "String", BaseType: "String", Position: 4},
+		},
+		SortingKey: []string{"slot"},
+	}
+}
+
+func TestGenerator_VisibilityProfiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility map[string]map[string][]string
+		expected   map[string][]string
+	}{
+		{
+			name:     "No profiles",
+			expected: map[string][]string{},
+		},
+		{
+			name: "Table profile selects columns in table order",
+			visibility: map[string]map[string][]string{
+				"fct_block": {"public": {"block_root", "slot"}},
+			},
+			expected: map[string][]string{"public": {"slot", "block_root"}},
+		},
+		{
+			name: "Table profile replaces wildcard profile of the same name",
+			visibility: map[string]map[string][]string{
+				"*":         {"public": {"slot"}, "internal": {"*"}},
+				"fct_block": {"public": {"slot", "proposer_email"}},
+			},
+			expected: map[string][]string{
+				"internal": {"slot", "block_root", "proposer_email"},
+				"public":   {"slot", "proposer_email"},
+			},
+		},
+		{
+			name: "Profile without existing columns is skipped",
+			visibility: map[string]map[string][]string{
+				"fct_block": {"empty": {"missing"}, "public": {"slot"}},
+			},
+			expected: map[string][]string{"public": {"slot"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Visibility = tt.visibility
+			cfg.Columns = map[string]map[string]config.ColumnConfig{
+				"fct_block": {"secret": {Mask: config.MaskOmit}},
+			}
+			gen := NewGenerator(cfg, logrus.New())
+
+			result := make(map[string][]string)
+			for _, profile := range gen.visibilityProfiles(visibilityTestTable()) {
+				names := make([]string, 0, len(profile.columns))
+				for _, col := range profile.columns {
+					names = append(names, col.Name)
+				}
+				result[profile.name] = names
+			}
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGenerator_GenerateWithVisibilityProfiles(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.OutputDir = t.TempDir()
+	cfg.Visibility = map[string]map[string][]string{
+		"fct_block": {
+			"public":   {"slot", "block_root"},
+			"internal": {"*"},
+		},
+	}
+	cfg.Columns = map[string]map[string]config.ColumnConfig{
+		"fct_block": {
+			"proposer_email": {Mask: config.MaskHash},
+			"secret":         {Mask: config.MaskOmit},
+		},
+	}
+
+	gen := NewGenerator(cfg, logrus.New())
+	require.NoError(t, gen.Generate([]*clickhouse.Table{visibilityTestTable()}))
+
+	protoContent, err := readFile(filepath.Join(cfg.OutputDir, "fct_block.proto"))
+	require.NoError(t, err)
+
+	// Profile messages keep the field numbers of the full message
+	assert.Contains(t, protoContent, "message FctBlockPublic {\n  uint64 slot = 11;\n  string block_root = 12;\n}")
+	assert.Contains(t, protoContent, "message FctBlockInternal {")
+	assert.Contains(t, protoContent, "string proposer_email = 13 [(clickhouse.v1.mask) = \"hash\"];")
+
+	sqlContent, err := readFile(filepath.Join(cfg.OutputDir, "fct_block_sql.go"))
+	require.NoError(t, err)
+
+	assert.Contains(t, sqlContent, "var FctBlockPublicColumns = []string{\"slot\", \"block_root\"}")
+	assert.Contains(t, sqlContent, "var FctBlockInternalColumns = []string{\"slot\", \"block_root\", \"hex(SHA256(concat(getSetting('custom_mask_key'), toString(`proposer_email`)))) AS `proposer_email`\"}")
+	assert.NotContains(t, sqlContent, "secret")
+
+	commonContent, err := readFile(filepath.Join(cfg.OutputDir, "common.go"))
+	require.NoError(t, err)
+	assert.Contains(t, commonContent, "func WithColumns(columns []string) QueryOption {")
+}
@@ -0,0 +1,175 @@
+package selftest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/protogen"
+)
+
+// driverDir is the directory, relative to the generated module, of the program that
+// executes the generated queries
+const driverDir = "cmd/selftest"
+
+// Environment variables the driver program reads
+const (
+	envDSN      = "SELFTEST_DSN"
+	envDatabase = "SELFTEST_DATABASE"
+)
+
+// buildDriver renders a program that builds List and Get requests for every table with
+// SQL helpers, turns them into queries with the generated builders and runs them against
+// ClickHouse. Requests are filled through protobuf reflection on the sorting key fields,
+// so the program doesn't depend on the generated filter types.
+func buildDriver(modulePath string, tables []*clickhouse.Table) string {
+	var sb strings.Builder
+
+	sb.WriteString("// Code generated by clickhouse-proto-gen selftest. DO NOT EDIT.\n\n")
+	sb.WriteString("package main\n\n")
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"context\"\n")
+	sb.WriteString("\t\"fmt\"\n")
+	sb.WriteString("\t\"os\"\n\n")
+	sb.WriteString("\t\"github.com/ClickHouse/clickhouse-go/v2\"\n")
+	sb.WriteString("\t\"google.golang.org/protobuf/reflect/protoreflect\"\n\n")
+	fmt.Fprintf(&sb, "\tgen %q\n", modulePath)
+	sb.WriteString(")\n\n")
+
+	sb.WriteString("type check struct {\n")
+	sb.WriteString("\tname  string\n")
+	sb.WriteString("\tbuild func(database string) (gen.SQLQuery, error)\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("func checks() []check {\n")
+	sb.WriteString("\treturn []check{\n")
+	for _, table := range tables {
+		if len(table.Columns) == 0 || len(table.SortingKey) == 0 {
+			continue
+		}
+		writeTableChecks(&sb, table)
+	}
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(driverRuntime)
+	return sb.String()
+}
+
+// writeTableChecks writes the List and Get checks of a table
+func writeTableChecks(sb *strings.Builder, table *clickhouse.Table) {
+	messageName := protogen.ToPascalCase(table.Name)
+	keyField := protogen.SanitizeName(table.SortingKey[0])
+
+	fmt.Fprintf(sb, "\t\t{name: \"List%s\", build: func(database string) (gen.SQLQuery, error) {\n", messageName)
+	fmt.Fprintf(sb, "\t\t\treq := &gen.List%sRequest{}\n", messageName)
+	fmt.Fprintf(sb, "\t\t\tsetFilter(req.ProtoReflect(), %q)\n", keyField)
+	fmt.Fprintf(sb, "\t\t\treturn gen.BuildList%sQuery(req, gen.WithDatabase(database))\n", messageName)
+	sb.WriteString("\t\t}},\n")
+
+	fmt.Fprintf(sb, "\t\t{name: \"Get%s\", build: func(database string) (gen.SQLQuery, error) {\n", messageName)
+	fmt.Fprintf(sb, "\t\t\treq := &gen.Get%sRequest{}\n", messageName)
+	fmt.Fprintf(sb, "\t\t\tsetFilter(req.ProtoReflect(), %q)\n", keyField)
+	fmt.Fprintf(sb, "\t\t\treturn gen.BuildGet%sQuery(req, gen.WithDatabase(database))\n", messageName)
+	sb.WriteString("\t\t}},\n")
+}
+
+// driverRuntime is the table independent part of the driver program
+const driverRuntime = `func main() {
+	if err := run(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context) error {
+	options, err := clickhouse.ParseDSN(os.Getenv("` + envDSN + `"))
+	if err != nil {
+		return fmt.Errorf("failed to parse DSN: %w", err)
+	}
+
+	conn, err := clickhouse.Open(options)
+	if err != nil {
+		return fmt.Errorf("failed to open connection: %w", err)
+	}
+	defer conn.Close()
+
+	database := os.Getenv("` + envDatabase + `")
+	for _, c := range checks() {
+		query, err := c.build(database)
+		if err != nil {
+			return fmt.Errorf("%s: failed to build query: %w", c.name, err)
+		}
+
+		rows, err := conn.Query(ctx, query.Query, query.Args...)
+		if err != nil {
+			return fmt.Errorf("%s: query failed: %w\n%s", c.name, err, query.Query)
+		}
+
+		count := 0
+		for rows.Next() {
+			count++
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("%s: reading rows failed: %w\n%s", c.name, err, query.Query)
+		}
+
+		fmt.Printf("ok %s (%d rows)\n", c.name, count)
+	}
+	return nil
+}
+
+// setFilter sets the named field of a request. Filter messages get a "ne" condition,
+// or their first scalar condition; scalar fields get a sample value.
+func setFilter(msg protoreflect.Message, name string) {
+	field := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		return
+	}
+	if field.Kind() != protoreflect.MessageKind {
+		msg.Set(field, sampleValue(field))
+		return
+	}
+
+	filter := msg.Mutable(field).Message()
+	fields := filter.Descriptor().Fields()
+	condition := fields.ByName("ne")
+	for i := 0; condition == nil && i < fields.Len(); i++ {
+		if fields.Get(i).Kind() != protoreflect.MessageKind {
+			condition = fields.Get(i)
+		}
+	}
+	if condition != nil {
+		filter.Set(condition, sampleValue(condition))
+	}
+}
+
+func sampleValue(field protoreflect.FieldDescriptor) protoreflect.Value {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString("1")
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte("1"))
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(1)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(1)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(1)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(1)
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(1)
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(1)
+	case protoreflect.EnumKind:
+		return protoreflect.ValueOfEnum(field.Enum().Values().Get(0).Number())
+	default:
+		return field.Default()
+	}
+}
+`
@@ -0,0 +1,59 @@
+//go:build integration
+
+package selftest
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcclickhouse "github.com/testcontainers/testcontainers-go/modules/clickhouse"
+)
+
+// defaultImage is the ClickHouse image the suite runs against, override with CLICKHOUSE_IMAGE
+const defaultImage = "clickhouse/clickhouse-server:24.8"
+
+// TestSelftestIntegration starts ClickHouse in a container and runs the full self-test
+// against it. Run with: go test -tags integration ./internal/selftest
+func TestSelftestIntegration(t *testing.T) {
+	for _, tool := range requiredTools {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found in PATH", tool)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	image := os.Getenv("CLICKHOUSE_IMAGE")
+	if image == "" {
+		image = defaultImage
+	}
+
+	container, err := tcclickhouse.Run(ctx, image,
+		tcclickhouse.WithUsername("default"),
+		tcclickhouse.WithPassword("selftest"),
+	)
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	dsn, err := container.ConnectionString(ctx)
+	require.NoError(t, err)
+
+	log := logrus.New()
+	log.SetLevel(logrus.InfoLevel)
+
+	output, err := Run(ctx, Options{DSN: dsn, WorkDir: t.TempDir()}, log)
+	require.NoError(t, err)
+
+	for _, check := range []string{"ListEventsLocal", "GetEventsLocal", "ListEvents", "GetEvents", "ListUsers", "GetUsers"} {
+		assert.Contains(t, output, "ok "+check+" ")
+	}
+	assert.Contains(t, output, "ok ListUsers (1 rows)", "the seeded user 2 matches user_id != 1")
+}
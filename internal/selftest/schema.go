@@ -0,0 +1,64 @@
+package selftest
+
+import "strings"
+
+// databasePlaceholder is replaced with the scratch database name in schema statements
+const databasePlaceholder = "{database}"
+
+// schemaStatements create representative tables covering the type and engine features
+// the generator handles: nullable columns, arrays, maps, low cardinality strings,
+// decimals, enums, projections and a Distributed table over a local one
+//
+//nolint:gochecknoglobals // Fixed schema shared by Run and the tests
+var schemaStatements = []string{
+	`CREATE TABLE {database}.events_local (
+    event_date_time DateTime COMMENT 'When the event happened',
+    event_id UInt64,
+    user_id Nullable(UInt32),
+    name LowCardinality(String),
+    score Nullable(Float64),
+    tags Array(String),
+    counts Array(UInt32),
+    attributes Map(String, String),
+    amount Decimal(18, 4),
+    updated_date_time DateTime64(3),
+    PROJECTION p_by_name (SELECT * ORDER BY name)
+) ENGINE = ReplacingMergeTree(updated_date_time)
+ORDER BY (event_date_time, event_id)
+COMMENT 'Raw events'`,
+	`CREATE TABLE {database}.events AS {database}.events_local
+ENGINE = Distributed(test_shard_localhost, {database}, events_local, rand())`,
+	`CREATE TABLE {database}.users (
+    user_id UInt32,
+    email Nullable(String),
+    status Enum8('active' = 1, 'disabled' = 2),
+    is_admin Bool,
+    session_id UUID,
+    labels Map(String, UInt64),
+    created_at DateTime
+) ENGINE = MergeTree
+ORDER BY user_id
+COMMENT 'Registered users'`,
+}
+
+// seedStatements insert a few rows so the generated queries return data
+//
+//nolint:gochecknoglobals // Fixed seed data shared by Run and the tests
+var seedStatements = []string{
+	`INSERT INTO {database}.events_local VALUES
+    ('2025-01-01 00:00:00', 1, 1, 'login', 0.5, ['a', 'b'], [1, 2], {'k': 'v'}, 1.25, '2025-01-01 00:00:00.000'),
+    ('2025-01-01 00:01:00', 2, NULL, 'logout', NULL, [], [], {}, 0, '2025-01-01 00:01:00.000')`,
+	`INSERT INTO {database}.users VALUES
+    (1, 'one@example.com', 'active', true, generateUUIDv4(), {'logins': 3}, '2025-01-01 00:00:00'),
+    (2, NULL, 'disabled', false, generateUUIDv4(), {}, '2025-01-02 00:00:00')`,
+}
+
+// tableNames are the tables created by schemaStatements
+//
+//nolint:gochecknoglobals // Fixed schema shared by Run and the tests
+var tableNames = []string{"events_local", "events", "users"}
+
+// withDatabase substitutes the scratch database into a statement
+func withDatabase(statement, database string) string {
+	return strings.ReplaceAll(statement, databasePlaceholder, database)
+}
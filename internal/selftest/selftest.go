@@ -0,0 +1,246 @@
+// Package selftest checks the generator end-to-end against a live ClickHouse server: it
+// creates representative tables, generates protos and SQL helpers for them, compiles the
+// output and executes the generated queries
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/config"
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/protogen"
+	"github.com/sirupsen/logrus"
+)
+
+// Self-test errors
+var (
+	ErrDSNRequired   = errors.New("selftest requires a DSN")
+	ErrToolMissing   = errors.New("required tool not found in PATH")
+	ErrMissingTables = errors.New("not all self-test tables could be loaded")
+	ErrCommandFailed = errors.New("command failed")
+)
+
+// modulePath is the module path of the generated Go code
+const modulePath = "selftest.local/gen"
+
+// requiredTools must be on PATH to compile the generated protos and run the driver
+//
+//nolint:gochecknoglobals // Fixed list of external tools
+var requiredTools = []string{"protoc", "protoc-gen-go", "go"}
+
+// Options configures a self-test run
+type Options struct {
+	// DSN of the ClickHouse server. A scratch database is created and dropped on it.
+	DSN string
+	// WorkDir receives the generated files. A temporary directory is used, and
+	// removed afterwards, when empty.
+	WorkDir string
+}
+
+// Run executes the self-test and returns the output of the driver program, one line
+// per executed query
+func Run(ctx context.Context, opts Options, log logrus.FieldLogger) (string, error) {
+	if opts.DSN == "" {
+		return "", ErrDSNRequired
+	}
+	log = log.WithField("component", "selftest")
+
+	for _, tool := range requiredTools {
+		if _, err := exec.LookPath(tool); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrToolMissing, tool)
+		}
+	}
+
+	workDir := opts.WorkDir
+	if workDir == "" {
+		dir, err := os.MkdirTemp("", "clickhouse-proto-gen-selftest-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create work directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		workDir = dir
+	}
+
+	conn, err := connect(ctx, opts.DSN)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	database := fmt.Sprintf("clickhouse_proto_gen_selftest_%d", time.Now().UnixNano())
+	if err := createSchema(ctx, conn, database); err != nil {
+		return "", err
+	}
+	defer func() {
+		// Use a fresh context so the database is dropped even when ctx was cancelled
+		if err := conn.Exec(context.Background(), "DROP DATABASE IF EXISTS "+database+" SYNC"); err != nil {
+			log.WithError(err).WithField("database", database).Warn("Failed to drop self-test database")
+		}
+	}()
+	log.WithField("database", database).Info("Created self-test tables")
+
+	tables, err := loadTables(ctx, opts.DSN, database, log)
+	if err != nil {
+		return "", err
+	}
+
+	moduleDir, err := generate(workDir, opts.DSN, tables, log)
+	if err != nil {
+		return "", err
+	}
+	log.WithField("dir", workDir).Info("Generated protos and SQL helpers")
+
+	if err := compileProtos(ctx, filepath.Join(workDir, "proto"), moduleDir); err != nil {
+		return "", err
+	}
+
+	driverPath := filepath.Join(moduleDir, driverDir, "main.go")
+	if err := os.MkdirAll(filepath.Dir(driverPath), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create driver directory: %w", err)
+	}
+	if err := os.WriteFile(driverPath, []byte(buildDriver(modulePath, tables)), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write driver: %w", err)
+	}
+
+	if _, err := runCommand(ctx, moduleDir, nil, "go", "mod", "tidy"); err != nil {
+		return "", err
+	}
+	if _, err := runCommand(ctx, moduleDir, nil, "go", "vet", "./..."); err != nil {
+		return "", err
+	}
+	log.Info("Compiled generated code")
+
+	env := []string{envDSN + "=" + opts.DSN, envDatabase + "=" + database}
+	return runCommand(ctx, moduleDir, env, "go", "run", "./"+driverDir)
+}
+
+func connect(ctx context.Context, dsn string) (driver.Conn, error) {
+	options, err := ch.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DSN: %w", err)
+	}
+
+	conn, err := ch.Open(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection: %w", err)
+	}
+
+	if err := conn.Ping(ctx); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return conn, nil
+}
+
+// createSchema creates the scratch database with the self-test tables and seed rows
+func createSchema(ctx context.Context, conn driver.Conn, database string) error {
+	if err := conn.Exec(ctx, "CREATE DATABASE "+database); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", database, err)
+	}
+
+	for _, statement := range append(append([]string{}, schemaStatements...), seedStatements...) {
+		if err := conn.Exec(ctx, withDatabase(statement, database)); err != nil {
+			return fmt.Errorf("failed to run %q: %w", firstLine(statement), err)
+		}
+	}
+	return nil
+}
+
+// loadTables introspects the self-test tables the same way generation does
+func loadTables(ctx context.Context, dsn, database string, log logrus.FieldLogger) ([]*clickhouse.Table, error) {
+	svc := clickhouse.NewService(dsn, log)
+	if err := svc.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer svc.Close()
+
+	tables, err := svc.GetTables(ctx, database, tableNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tables: %w", err)
+	}
+	if len(tables) != len(tableNames) {
+		return nil, fmt.Errorf("%w: got %d of %d", ErrMissingTables, len(tables), len(tableNames))
+	}
+	return tables, nil
+}
+
+// generate writes protos to workDir/proto and the Go module to workDir/gen, and returns
+// the module directory
+func generate(workDir, dsn string, tables []*clickhouse.Table, log logrus.FieldLogger) (string, error) {
+	moduleDir := filepath.Join(workDir, "gen")
+
+	cfg := config.NewConfig()
+	cfg.DSN = dsn
+	cfg.OutputDir = filepath.Join(workDir, "proto")
+	cfg.Tables = tableNames
+	cfg.Package = "selftest.v1"
+	cfg.GoModule = config.GoModuleConfig{
+		Enabled: true,
+		Dir:     moduleDir,
+		Path:    modulePath,
+	}
+	if err := cfg.Validate(); err != nil {
+		return "", fmt.Errorf("invalid self-test configuration: %w", err)
+	}
+
+	if err := protogen.NewGenerator(cfg, log).Generate(tables); err != nil {
+		return "", fmt.Errorf("failed to generate: %w", err)
+	}
+	return moduleDir, nil
+}
+
+// compileProtos runs protoc-gen-go on every proto under protoDir, placing the output
+// in the generated module
+func compileProtos(ctx context.Context, protoDir, moduleDir string) error {
+	args := []string{"--go_out=" + moduleDir, "--go_opt=module=" + modulePath}
+	err := filepath.WalkDir(protoDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".proto" {
+			return err
+		}
+		rel, err := filepath.Rel(protoDir, path)
+		if err != nil {
+			return err
+		}
+		args = append(args, rel)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list proto files: %w", err)
+	}
+
+	_, err = runCommand(ctx, protoDir, nil, "protoc", args...)
+	return err
+}
+
+// runCommand runs a command in dir and returns its stdout. Output is included in the
+// error when the command fails.
+func runCommand(ctx context.Context, dir string, env []string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s %s: %w\n%s%s", ErrCommandFailed, name, strings.Join(args, " "), err, stdout.String(), stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+func firstLine(statement string) string {
+	line, _, _ := strings.Cut(statement, "\n")
+	return line
+}
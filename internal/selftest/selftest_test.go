@@ -0,0 +1,55 @@
+package selftest
+
+import (
+	"context"
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseSchema parses the self-test schema into the tables introspection would return
+func parseSchema(t *testing.T) []*clickhouse.Table {
+	t.Helper()
+
+	tables, err := clickhouse.ParseDDL(withDatabase(strings.Join(schemaStatements, ";\n")+";", "selftest"))
+	require.NoError(t, err)
+	return tables
+}
+
+func TestSchema(t *testing.T) {
+	tables := parseSchema(t)
+	require.Len(t, tables, len(tableNames))
+
+	byName := make(map[string]*clickhouse.Table, len(tables))
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+	for _, name := range tableNames {
+		require.Contains(t, byName, name)
+	}
+
+	assert.Equal(t, []string{"event_date_time", "event_id"}, byName["events"].SortingKey, "distributed table uses the local sorting key")
+	assert.Len(t, byName["events_local"].Projections, 1)
+}
+
+func TestBuildDriver(t *testing.T) {
+	source := buildDriver(modulePath, parseSchema(t))
+
+	_, err := format.Source([]byte(source))
+	require.NoError(t, err)
+
+	assert.Contains(t, source, "gen \"selftest.local/gen\"")
+	assert.Contains(t, source, "req := &gen.ListEventsLocalRequest{}")
+	assert.Contains(t, source, "setFilter(req.ProtoReflect(), \"event_date_time\")")
+	assert.Contains(t, source, "return gen.BuildGetUsersQuery(req, gen.WithDatabase(database))")
+}
+
+func TestRun_RequiresDSN(t *testing.T) {
+	_, err := Run(context.Background(), Options{}, logrus.New())
+	require.ErrorIs(t, err, ErrDSNRequired)
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Scores of fuzzyScore: consecutive matches and matches at the start of a word rank higher,
+// and every skipped character costs a little, so "blk" ranks "fct_block" above "fct_bulk_..."
+const (
+	scoreMatch       = 1
+	scoreConsecutive = 4
+	scoreWordStart   = 6
+	scoreGap         = -1
+)
+
+// fuzzyScore matches query against target as a case-insensitive subsequence, returning the
+// match score and whether all of query was found. An empty query matches everything.
+func fuzzyScore(query, target string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	query = strings.ToLower(query)
+	target = strings.ToLower(target)
+
+	score := 0
+	lastEnd := -1 // Byte offset after the previous match
+	qi := 0
+	prev := rune(0)
+	for ti, r := range target {
+		q, size := utf8.DecodeRuneInString(query[qi:])
+		if r == q {
+			score += scoreMatch
+			switch {
+			case ti == lastEnd:
+				score += scoreConsecutive
+			case isWordStart(prev):
+				score += scoreWordStart
+			}
+			if lastEnd >= 0 {
+				score += scoreGap * (ti - lastEnd)
+			}
+			lastEnd = ti + utf8.RuneLen(r)
+			qi += size
+			if qi == len(query) {
+				return score, true
+			}
+		}
+		prev = r
+	}
+	return 0, false
+}
+
+// isWordStart reports whether a character following prev starts a word of a table name
+func isWordStart(prev rune) bool {
+	return prev == 0 || prev == '.' || prev == '_' || prev == '-'
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		target  string
+		matches bool
+	}{
+		{name: "Empty query", query: "", target: "users", matches: true},
+		{name: "Substring", query: "user", target: "default.users", matches: true},
+		{name: "Subsequence", query: "fblk", target: "analytics.fct_block", matches: true},
+		{name: "Case insensitive", query: "FCT", target: "analytics.fct_block", matches: true},
+		{name: "Out of order", query: "kb", target: "block", matches: false},
+		{name: "Longer than target", query: "users_all", target: "users", matches: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := fuzzyScore(tt.query, tt.target)
+			assert.Equal(t, tt.matches, ok)
+		})
+	}
+}
+
+func TestFuzzyScore_Ranking(t *testing.T) {
+	score := func(query, target string) int {
+		s, ok := fuzzyScore(query, target)
+		assert.True(t, ok, "%q should match %q", query, target)
+		return s
+	}
+
+	// Consecutive characters beat scattered ones
+	assert.Greater(t, score("block", "fct_block"), score("block", "fct_bulk_lock"))
+	// Matches at word starts beat matches inside words
+	assert.Greater(t, score("fb", "fct_block"), score("fb", "fact_tbl"))
+}
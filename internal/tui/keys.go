@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+)
+
+// KeyCode identifies a key press decoded from terminal input
+type KeyCode int
+
+// Keys the table picker reacts to. KeyRune carries a printable character in Key.Rune.
+const (
+	KeyUnknown KeyCode = iota
+	KeyRune
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyTab
+	KeyCtrlC
+)
+
+// Key is a single key press
+type Key struct {
+	Code KeyCode
+	Rune rune
+}
+
+// Control characters of raw terminal input
+const (
+	byteCtrlC     = 0x03
+	byteBackspace = 0x08
+	byteTab       = 0x09
+	byteNewline   = 0x0a
+	byteReturn    = 0x0d
+	byteEscape    = 0x1b
+	byteDelete    = 0x7f
+)
+
+// readKey decodes the next key press from raw terminal input. An escape byte with nothing
+// buffered after it is the escape key; otherwise it starts a CSI or SS3 sequence.
+func readKey(r *bufio.Reader) (Key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch b {
+	case byteCtrlC:
+		return Key{Code: KeyCtrlC}, nil
+	case byteBackspace, byteDelete:
+		return Key{Code: KeyBackspace}, nil
+	case byteTab:
+		return Key{Code: KeyTab}, nil
+	case byteNewline, byteReturn:
+		return Key{Code: KeyEnter}, nil
+	case byteEscape:
+		if r.Buffered() == 0 {
+			return Key{Code: KeyEscape}, nil
+		}
+		return readEscapeSequence(r)
+	}
+
+	if b < 0x20 {
+		return Key{Code: KeyUnknown}, nil
+	}
+	if err := r.UnreadByte(); err != nil {
+		return Key{}, err
+	}
+	ch, _, err := r.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{Code: KeyRune, Rune: ch}, nil
+}
+
+func readEscapeSequence(r *bufio.Reader) (Key, error) {
+	introducer, err := r.ReadByte()
+	if err != nil {
+		return Key{}, err
+	}
+	if introducer != '[' && introducer != 'O' {
+		return Key{Code: KeyUnknown}, nil
+	}
+
+	// Parameters and intermediates run until the final byte in @..~
+	var params []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return Key{}, fmt.Errorf("incomplete escape sequence: %w", err)
+		}
+		if b >= '@' && b <= '~' {
+			return sequenceKey(string(params), b), nil
+		}
+		params = append(params, b)
+	}
+}
+
+func sequenceKey(params string, final byte) Key {
+	switch final {
+	case 'A':
+		return Key{Code: KeyUp}
+	case 'B':
+		return Key{Code: KeyDown}
+	case 'H':
+		return Key{Code: KeyHome}
+	case 'F':
+		return Key{Code: KeyEnd}
+	case '~':
+		switch params {
+		case "1", "7":
+			return Key{Code: KeyHome}
+		case "4", "8":
+			return Key{Code: KeyEnd}
+		case "5":
+			return Key{Code: KeyPageUp}
+		case "6":
+			return Key{Code: KeyPageDown}
+		}
+	}
+	return Key{Code: KeyUnknown}
+}
@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadKey(t *testing.T) {
+	input := "a\x1b[A\x1b[B\x1bOA\x1b[5~\x1b[6~\x1b[H\x1b[4~\r\x7f\t\x03 é\x01\x1b[1;5C"
+	reader := bufio.NewReader(strings.NewReader(input))
+
+	expected := []Key{
+		{Code: KeyRune, Rune: 'a'},
+		{Code: KeyUp},
+		{Code: KeyDown},
+		{Code: KeyUp},
+		{Code: KeyPageUp},
+		{Code: KeyPageDown},
+		{Code: KeyHome},
+		{Code: KeyEnd},
+		{Code: KeyEnter},
+		{Code: KeyBackspace},
+		{Code: KeyTab},
+		{Code: KeyCtrlC},
+		{Code: KeyRune, Rune: ' '},
+		{Code: KeyRune, Rune: 'é'},
+		{Code: KeyUnknown},
+		{Code: KeyUnknown},
+	}
+	for _, want := range expected {
+		key, err := readKey(reader)
+		require.NoError(t, err)
+		assert.Equal(t, want, key)
+	}
+
+	_, err := readKey(reader)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadKey_Escape(t *testing.T) {
+	// A lone escape byte, with nothing buffered after it, is the escape key
+	key, err := readKey(bufio.NewReader(strings.NewReader("\x1b")))
+	require.NoError(t, err)
+	assert.Equal(t, Key{Code: KeyEscape}, key)
+}
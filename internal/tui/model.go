@@ -0,0 +1,255 @@
+// Package tui implements the interactive table picker: a terminal UI listing the tables of
+// a schema source with fuzzy search, multi-select and a preview of the generated proto.
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+)
+
+// PreviewFunc renders the generated proto of a table
+type PreviewFunc func(table clickhouse.TableSummary) (string, error)
+
+// Options configure a Model
+type Options struct {
+	// Tables lists the tables to pick from
+	Tables []clickhouse.TableSummary
+	// Selected preselects tables, database-qualified or bare names in Database
+	Selected []string
+	// Database resolves bare names in Selected
+	Database string
+	// Preview renders the highlighted table, no preview is shown when nil
+	Preview PreviewFunc
+}
+
+// Model is the state of the table picker. It is updated one key press at a time and
+// rendered to a string, which keeps it independent of the terminal.
+type Model struct {
+	tables   []clickhouse.TableSummary
+	selected map[string]bool
+	preview  PreviewFunc
+	previews map[string]string
+
+	query     string
+	searching bool
+	visible   []int // Indexes of the tables matching the query, best match first
+	cursor    int   // Position of the highlighted table in visible
+	offset    int   // First row of visible on screen
+	scroll    int   // First line of the preview on screen
+	pageRows  int   // Preview lines on screen, from the last View
+
+	done bool
+	save bool
+}
+
+// NewModel creates a picker over the given tables
+func NewModel(opts Options) *Model {
+	m := &Model{
+		tables:   opts.Tables,
+		selected: make(map[string]bool, len(opts.Selected)),
+		preview:  opts.Preview,
+		previews: make(map[string]string),
+		pageRows: 1,
+	}
+
+	for _, name := range opts.Selected {
+		if !strings.Contains(name, ".") && opts.Database != "" {
+			name = opts.Database + "." + name
+		}
+		m.selected[name] = true
+	}
+
+	m.filter()
+	return m
+}
+
+// qualifiedName returns database.table, or the table name alone for tables without database
+func qualifiedName(table clickhouse.TableSummary) string {
+	if table.Database == "" {
+		return table.Name
+	}
+	return table.Database + "." + table.Name
+}
+
+// Done reports whether the picker was closed, and whether the selection should be saved
+func (m *Model) Done() (done, save bool) {
+	return m.done, m.save
+}
+
+// Selected returns the selected tables in listing order
+func (m *Model) Selected() []string {
+	var names []string
+	for _, table := range m.tables {
+		if name := qualifiedName(table); m.selected[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// filter recomputes the tables matching the query and moves the cursor to the best match
+func (m *Model) filter() {
+	type match struct {
+		index int
+		score int
+	}
+
+	matches := make([]match, 0, len(m.tables))
+	for i, table := range m.tables {
+		if score, ok := fuzzyScore(m.query, qualifiedName(table)); ok {
+			matches = append(matches, match{index: i, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	m.visible = m.visible[:0]
+	for _, match := range matches {
+		m.visible = append(m.visible, match.index)
+	}
+	m.cursor, m.offset, m.scroll = 0, 0, 0
+}
+
+// highlighted returns the table under the cursor
+func (m *Model) highlighted() (clickhouse.TableSummary, bool) {
+	if len(m.visible) == 0 {
+		return clickhouse.TableSummary{}, false
+	}
+	return m.tables[m.visible[m.cursor]], true
+}
+
+// Update applies a key press. While searching, printable characters edit the query;
+// otherwise they are commands.
+func (m *Model) Update(key Key) {
+	switch key.Code {
+	case KeyCtrlC:
+		m.done, m.save = true, false
+	case KeyEnter:
+		if m.searching {
+			m.searching = false
+			return
+		}
+		m.done, m.save = true, true
+	case KeyEscape:
+		switch {
+		case m.searching || m.query != "":
+			m.searching = false
+			m.setQuery("")
+		default:
+			m.done, m.save = true, false
+		}
+	case KeyBackspace:
+		if m.searching && m.query != "" {
+			_, size := utf8.DecodeLastRuneInString(m.query)
+			m.setQuery(m.query[:len(m.query)-size])
+		}
+	case KeyRune:
+		if m.searching {
+			m.setQuery(m.query + string(key.Rune))
+			return
+		}
+		m.command(key.Rune)
+	case KeyUp, KeyDown, KeyHome, KeyEnd, KeyPageUp, KeyPageDown, KeyTab:
+		m.navigate(key.Code)
+	case KeyUnknown:
+	}
+}
+
+// navigate handles the keys moving the cursor and scrolling the preview
+func (m *Model) navigate(code KeyCode) {
+	switch code {
+	case KeyUp:
+		m.move(-1)
+	case KeyDown:
+		m.move(1)
+	case KeyHome:
+		m.move(-len(m.visible))
+	case KeyEnd:
+		m.move(len(m.visible))
+	case KeyPageUp:
+		m.scroll = max(0, m.scroll-m.pageRows)
+	case KeyPageDown:
+		m.scroll += m.pageRows
+	case KeyTab:
+		m.toggle()
+		m.move(1)
+	case KeyUnknown, KeyRune, KeyEnter, KeyEscape, KeyBackspace, KeyCtrlC:
+		// Handled by Update
+	}
+}
+
+// command handles a character typed outside of search
+func (m *Model) command(r rune) {
+	switch r {
+	case 'k':
+		m.move(-1)
+	case 'j':
+		m.move(1)
+	case ' ':
+		m.toggle()
+	case 'a':
+		m.toggleAll()
+	case '/':
+		m.searching = true
+	case 'q':
+		m.done, m.save = true, false
+	}
+}
+
+func (m *Model) setQuery(query string) {
+	m.query = query
+	m.filter()
+}
+
+func (m *Model) move(delta int) {
+	if len(m.visible) == 0 {
+		return
+	}
+
+	cursor := min(max(m.cursor+delta, 0), len(m.visible)-1)
+	if cursor != m.cursor {
+		m.cursor = cursor
+		m.scroll = 0
+	}
+}
+
+func (m *Model) toggle() {
+	if table, ok := m.highlighted(); ok {
+		name := qualifiedName(table)
+		m.selected[name] = !m.selected[name]
+	}
+}
+
+// toggleAll selects every matching table, or deselects them when all are selected already
+func (m *Model) toggleAll() {
+	all := true
+	for _, index := range m.visible {
+		all = all && m.selected[qualifiedName(m.tables[index])]
+	}
+	for _, index := range m.visible {
+		m.selected[qualifiedName(m.tables[index])] = !all
+	}
+}
+
+// currentPreview renders the highlighted table, caching previews by table
+func (m *Model) currentPreview() string {
+	table, ok := m.highlighted()
+	if !ok || m.preview == nil {
+		return ""
+	}
+
+	name := qualifiedName(table)
+	if preview, ok := m.previews[name]; ok {
+		return preview
+	}
+
+	preview, err := m.preview(table)
+	if err != nil {
+		preview = fmt.Sprintf("Preview failed: %v", err)
+	}
+	m.previews[name] = preview
+	return preview
+}
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/clickhouse-proto-gen/internal/clickhouse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestPreview = errors.New("table is gone")
+
+func testTables() []clickhouse.TableSummary {
+	return []clickhouse.TableSummary{
+		{Database: "analytics", Name: "fct_block", Engine: "ReplacingMergeTree", Columns: 12},
+		{Database: "analytics", Name: "dim_node", Engine: "MergeTree", Columns: 4},
+		{Database: "default", Name: "users", Engine: "MergeTree", Columns: 3},
+	}
+}
+
+func typeKeys(m *Model, keys string) {
+	for _, r := range keys {
+		m.Update(Key{Code: KeyRune, Rune: r})
+	}
+}
+
+func TestModel_Select(t *testing.T) {
+	m := NewModel(Options{Tables: testTables(), Selected: []string{"users"}, Database: "default"})
+	assert.Equal(t, []string{"default.users"}, m.Selected())
+
+	// Space toggles the highlighted table, tab toggles it and moves on
+	typeKeys(m, " ")
+	m.Update(Key{Code: KeyDown})
+	m.Update(Key{Code: KeyTab})
+	typeKeys(m, " ")
+	assert.Equal(t, []string{"analytics.fct_block", "analytics.dim_node"}, m.Selected())
+
+	// a selects every matching table, and deselects them once all are selected
+	typeKeys(m, "a")
+	assert.Len(t, m.Selected(), 3)
+	typeKeys(m, "a")
+	assert.Empty(t, m.Selected())
+
+	m.Update(Key{Code: KeyEnter})
+	done, save := m.Done()
+	assert.True(t, done)
+	assert.True(t, save)
+}
+
+func TestModel_Search(t *testing.T) {
+	m := NewModel(Options{Tables: testTables()})
+
+	// Outside of search, letters are commands; a isn't part of the query
+	typeKeys(m, "/node")
+	table, ok := m.highlighted()
+	require.True(t, ok)
+	assert.Equal(t, "dim_node", table.Name)
+	assert.Len(t, m.visible, 1)
+
+	// Enter leaves the search and keeps the filter, so space selects instead of typing
+	m.Update(Key{Code: KeyEnter})
+	typeKeys(m, " ")
+	assert.Equal(t, []string{"analytics.dim_node"}, m.Selected())
+	done, _ := m.Done()
+	assert.False(t, done)
+
+	// Selecting all only covers the matching tables
+	typeKeys(m, "/")
+	m.Update(Key{Code: KeyBackspace})
+	m.Update(Key{Code: KeyBackspace})
+	m.Update(Key{Code: KeyBackspace})
+	m.Update(Key{Code: KeyBackspace})
+	typeKeys(m, "zzz")
+	assert.Empty(t, m.visible)
+	m.Update(Key{Code: KeyEnter})
+	typeKeys(m, "a")
+	assert.Equal(t, []string{"analytics.dim_node"}, m.Selected())
+
+	// Escape clears the query first, then quits without saving
+	m.Update(Key{Code: KeyEscape})
+	assert.Len(t, m.visible, 3)
+	m.Update(Key{Code: KeyEscape})
+	done, save := m.Done()
+	assert.True(t, done)
+	assert.False(t, save)
+}
+
+func TestModel_Navigation(t *testing.T) {
+	m := NewModel(Options{Tables: testTables()})
+
+	m.Update(Key{Code: KeyUp})
+	assert.Equal(t, 0, m.cursor)
+	typeKeys(m, "jj")
+	assert.Equal(t, 2, m.cursor)
+	m.Update(Key{Code: KeyDown})
+	assert.Equal(t, 2, m.cursor)
+	m.Update(Key{Code: KeyHome})
+	assert.Equal(t, 0, m.cursor)
+	m.Update(Key{Code: KeyEnd})
+	assert.Equal(t, 2, m.cursor)
+	typeKeys(m, "k")
+	assert.Equal(t, 1, m.cursor)
+
+	m.Update(Key{Code: KeyCtrlC})
+	done, save := m.Done()
+	assert.True(t, done)
+	assert.False(t, save)
+}
+
+func TestModel_View(t *testing.T) {
+	previews := 0
+	m := NewModel(Options{
+		Tables:   testTables(),
+		Selected: []string{"analytics.dim_node"},
+		Preview: func(table clickhouse.TableSummary) (string, error) {
+			previews++
+			if table.Name == "users" {
+				return "", errTestPreview
+			}
+			lines := make([]string, 0, 40)
+			for i := range 40 {
+				lines = append(lines, fmt.Sprintf("%s line %d", table.Name, i))
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+	})
+
+	view := m.View(100, 20)
+	lines := strings.Split(view, "\n")
+	assert.Len(t, lines, 20)
+	assert.Contains(t, lines[0], "1 of 3 selected")
+	assert.Contains(t, view, "> [ ] analytics.fct_block")
+	assert.Contains(t, view, "[x] analytics.dim_node")
+	assert.Contains(t, view, "ReplacingMergeTree")
+	assert.Contains(t, view, "12 cols")
+	assert.Contains(t, view, "Preview: analytics.fct_block")
+	assert.Contains(t, view, "fct_block line 0\n")
+
+	// Previews are cached, and paging scrolls the preview
+	m.Update(Key{Code: KeyPageDown})
+	view = m.View(100, 20)
+	assert.NotContains(t, view, "fct_block line 0\n")
+	assert.Contains(t, view, "fct_block line 10\n")
+	assert.Equal(t, 1, previews)
+
+	// Failed previews show the error
+	m.Update(Key{Code: KeyEnd})
+	assert.Contains(t, m.View(100, 20), "Preview failed: table is gone")
+
+	// Narrow screens truncate every line
+	for _, line := range strings.Split(m.View(30, 20), "\n") {
+		plain := strings.NewReplacer(styleReverse, "", styleDim, "", styleReset, "").Replace(line)
+		assert.LessOrEqual(t, len([]rune(plain)), 30, line)
+	}
+}
+
+func TestModel_ViewWithoutPreview(t *testing.T) {
+	m := NewModel(Options{Tables: testTables()})
+	typeKeys(m, "/missing")
+
+	view := m.View(80, 10)
+	assert.Len(t, strings.Split(view, "\n"), 10)
+	assert.Contains(t, view, "No tables match")
+	assert.Contains(t, view, "Search: missing█")
+	assert.NotContains(t, view, "Preview")
+}
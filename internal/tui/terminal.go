@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNotTerminal is returned when the picker is started without an interactive terminal
+var ErrNotTerminal = errors.New("interactive mode needs a terminal")
+
+// Terminal control sequences: the alternate screen keeps the shell's scrollback intact
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen    = "\x1b[H\x1b[2J"
+)
+
+// Fallback screen size when the terminal doesn't report one
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Run shows the picker on the terminal until it is closed. The terminal is put into raw
+// mode and restored afterwards, also when rendering or reading input fails.
+func Run(in, out *os.File, m *Model) (err error) {
+	inFd := int(in.Fd())   //nolint:gosec // File descriptors fit in int
+	outFd := int(out.Fd()) //nolint:gosec // File descriptors fit in int
+	if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		return ErrNotTerminal
+	}
+
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("failed to enable raw mode: %w", err)
+	}
+	defer func() {
+		_, _ = io.WriteString(out, exitAltScreen)
+		if restoreErr := term.Restore(inFd, state); restoreErr != nil && err == nil {
+			err = fmt.Errorf("failed to restore terminal: %w", restoreErr)
+		}
+	}()
+
+	if _, err := io.WriteString(out, enterAltScreen); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(in)
+	for done, _ := m.Done(); !done; done, _ = m.Done() {
+		width, height, sizeErr := term.GetSize(outFd)
+		if sizeErr != nil || width <= 0 || height <= 0 {
+			width, height = defaultWidth, defaultHeight
+		}
+		if err := draw(out, m.View(width, height)); err != nil {
+			return err
+		}
+
+		key, err := readKey(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		m.Update(key)
+	}
+	return nil
+}
+
+// draw repaints the screen. Raw mode doesn't translate newlines, so lines end in \r\n.
+func draw(w io.Writer, view string) error {
+	_, err := io.WriteString(w, clearScreen+strings.ReplaceAll(view, "\n", "\r\n"))
+	return err
+}
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI sequences for the highlighted row and dimmed text
+const (
+	styleReverse = "\x1b[7m"
+	styleDim     = "\x1b[2m"
+	styleReset   = "\x1b[0m"
+)
+
+const helpLine = "↑/↓ move  space select  tab select+next  a all  / search  PgUp/PgDn preview  enter save  q quit"
+
+// View renders the picker for a screen of width by height characters. The table list takes
+// the upper part of the screen and the preview of the highlighted table the rest.
+func (m *Model) View(width, height int) string {
+	width = max(width, 20)
+	height = max(height, 8)
+
+	// Title, search line, preview separator and help line surround the list and preview
+	const chrome = 4
+	listRows := max(3, (height-chrome)*2/5)
+	if m.preview == nil {
+		listRows = height - chrome + 1
+	}
+	m.pageRows = max(1, height-chrome-listRows)
+
+	lines := make([]string, 0, height)
+	lines = append(lines,
+		truncate(fmt.Sprintf("Select tables: %d of %d selected, %d shown", len(m.Selected()), len(m.tables), len(m.visible)), width),
+		m.searchLine(width),
+	)
+	lines = append(lines, m.listLines(width, listRows)...)
+
+	if m.preview != nil {
+		lines = append(lines, m.previewLines(width, m.pageRows)...)
+	}
+
+	lines = append(lines, styleDim+truncate(helpLine, width)+styleReset)
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) searchLine(width int) string {
+	switch {
+	case m.searching:
+		return truncate("Search: "+m.query+"█", width)
+	case m.query != "":
+		return truncate("Search: "+m.query+"  (esc clears)", width)
+	default:
+		return styleDim + truncate("Press / to search", width) + styleReset
+	}
+}
+
+// listLines renders a window of the matching tables that keeps the cursor on screen
+func (m *Model) listLines(width, rows int) []string {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+
+	nameWidth := 0
+	for _, index := range m.visible {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(qualifiedName(m.tables[index])))
+	}
+	nameWidth = min(nameWidth, width/2)
+
+	lines := make([]string, 0, rows)
+	for row := m.offset; row < m.offset+rows; row++ {
+		if row >= len(m.visible) {
+			lines = append(lines, "")
+			continue
+		}
+
+		table := m.tables[m.visible[row]]
+		mark := " "
+		if m.selected[qualifiedName(table)] {
+			mark = "x"
+		}
+		name := truncate(qualifiedName(table), nameWidth)
+		line := fmt.Sprintf("[%s] %-*s  %-20s %4d cols", mark, nameWidth, name, table.Engine, table.Columns)
+		line = truncate(line, width-2)
+
+		if row == m.cursor {
+			lines = append(lines, styleReverse+"> "+line+styleReset)
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+
+	if len(m.visible) == 0 {
+		lines[0] = "  No tables match"
+	}
+	return lines
+}
+
+// previewLines renders the separator and a scrolled window of the highlighted table's proto
+func (m *Model) previewLines(width, rows int) []string {
+	title := "Preview"
+	if table, ok := m.highlighted(); ok {
+		title = "Preview: " + qualifiedName(table)
+	}
+	lines := make([]string, 0, rows+1)
+	lines = append(lines, styleDim+truncate("── "+title+" "+strings.Repeat("─", width), width)+styleReset)
+
+	content := strings.Split(strings.TrimRight(m.currentPreview(), "\n"), "\n")
+	m.scroll = min(m.scroll, max(0, len(content)-rows))
+	for i := m.scroll; i < m.scroll+rows; i++ {
+		if i < len(content) {
+			lines = append(lines, truncate(strings.ReplaceAll(content[i], "\t", "  "), width))
+		} else {
+			lines = append(lines, "")
+		}
+	}
+	return lines
+}
+
+// truncate cuts s to width characters, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}